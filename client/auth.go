@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// withAuth returns the context of a call with the authorization header when a token is set
+func (c *gRPCClient) withAuth(ctx context.Context) context.Context {
+	if c.authToken == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.authToken)
+}
+
+// authError replaces the message of an Unauthenticated error with advice to check the token. The
+// node's message isn't kept, since a proxy may echo the rejected header in it.
+func authError(err error) error {
+	if status.Code(err) == codes.Unauthenticated {
+		return status.Error(codes.Unauthenticated, common.UnauthenticatedMsg)
+	}
+	return err
+}
+
+// authUnary adds the authorization header to a call
+func (c *gRPCClient) authUnary(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return authError(invoker(c.withAuth(ctx), method, req, reply, cc, opts...))
+}
+
+// authStream adds the authorization header to a stream
+func (c *gRPCClient) authStream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(c.withAuth(ctx), desc, cc, method, opts...)
+	if err != nil {
+		return nil, authError(err)
+	}
+	return authClientStream{stream}, nil
+}
+
+// authClientStream reports Unauthenticated errors of a stream with advice to check the token
+type authClientStream struct {
+	grpc.ClientStream
+}
+
+func (s authClientStream) RecvMsg(m interface{}) error {
+	return authError(s.ClientStream.RecvMsg(m))
+}
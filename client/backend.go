@@ -3,10 +3,14 @@ package client
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -24,24 +28,71 @@ import (
 type WalletBackend struct {
 	*gRPCClient      // Embedded interface
 	workingDirectory string
-	wallet           *smWallet.Wallet
-	open             bool
+	// mu guards wallet, open and submitted: the accounts, the current account and the writes of
+	// the wallet file, which commands, watch mode and background streams use from different
+	// goroutines. Methods which only read may take the read lock. It isn't held while the user
+	// is prompted.
+	mu        sync.RWMutex
+	wallet    *smWallet.Wallet
+	open      bool
+	submitted map[gosmtypes.Address][]common.SubmittedTx
+	// configMu guards the lazy load of config
+	configMu  sync.Mutex
+	config    *common.Config
+	contacts  *common.AddressBook
+	templates *common.TemplateBook
+	multisig  *common.MultisigBook
+	nonces    *common.NonceTracker
+	fees      *common.FeeEstimate
+	oracle    *common.GasOracle
+	// in is the input the prompts read, nil when they read the terminal. It is set by SetInput
+	// before the session starts.
+	in *bufio.Reader
+}
+
+// SetInput makes the prompts of the wallet commands, such as the passwords and the mnemonic, read
+// their answers from in, the input of a session which isn't the terminal. The answers are read as
+// lines.
+func (w *WalletBackend) SetInput(in *bufio.Reader) {
+	w.in = in
+}
+
+// readLine reads a line of the input given with SetInput without its line break. It fails when
+// the input ended.
+func (w *WalletBackend) readLine() (string, error) {
+	line, err := w.in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
 }
 
 func (w *WalletBackend) IsOpen() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
 	return w.wallet != nil
 }
 
 func friendlyTime(nastyString string) string {
-	t, err := time.Parse("2006-01-02T15-04-05.000Z", nastyString)
+	t, err := time.Parse(smWallet.TimeFormat, nastyString)
 	if err != nil {
 		return nastyString
 	}
 	return t.Format("Jan 02 2006 03:04 PM")
 }
 
+// WalletMnemonic returns the mnemonic of the open wallet
+func (w *WalletBackend) WalletMnemonic() (string, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.wallet == nil {
+		return "", common.ErrNoWallet
+	}
+	return w.wallet.GetMnemonic()
+}
+
 func (w *WalletBackend) PrintWalletMnemonic() {
-	mnemonic, err := w.wallet.GetMnemonic()
+	mnemonic, err := w.WalletMnemonic()
 	if err != nil {
 		log.Error("error reading mnemonic", err)
 		return
@@ -50,23 +101,58 @@ func (w *WalletBackend) PrintWalletMnemonic() {
 	fmt.Println("Mnemonic:", mnemonic)
 }
 
-func (w *WalletBackend) WalletInfo() {
-	fmt.Println("Name:", w.wallet.Meta.DisplayName)
-	fmt.Println("Created:", friendlyTime(w.wallet.Meta.Created))
-	fmt.Println("File Path:", w.wallet.WalletPath())
+// WalletInfo returns a description of the open wallet and its file
+func (w *WalletBackend) WalletInfo() (*common.WalletInfo, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.wallet == nil {
+		return nil, common.ErrNoWallet
+	}
+	info := &common.WalletInfo{
+		Name:           w.wallet.Meta.DisplayName,
+		Path:           w.wallet.WalletPath(),
+		Version:        w.wallet.Version,
+		Encrypted:      w.wallet.Crypto.CipherText != "",
+		Cipher:         w.wallet.Crypto.Cipher,
+		UnsavedChanges: w.wallet.HasUnsavedChanges(),
+	}
+	if created, err := time.Parse(smWallet.TimeFormat, w.wallet.Meta.Created); err == nil {
+		info.Created = created
+	}
+	if path, err := filepath.Abs(info.Path); err == nil {
+		info.Path = path
+	}
+	if stat, err := os.Stat(info.Path); err == nil {
+		info.Modified = stat.ModTime()
+	}
 
-	addressesCount, err := w.wallet.GetNumberOfAccounts()
+	origins, err := w.wallet.AccountOrigins()
 	if err != nil {
-		log.Error("error reading addresses count", err)
-		return
+		return nil, err
 	}
-
-	fmt.Println("Addresses created:", addressesCount)
-
+	info.Accounts = len(origins)
+	for _, origin := range origins {
+		switch origin.Kind {
+		case common.OriginDerived:
+			info.DerivedAccounts++
+		case common.OriginImported:
+			info.ImportedAccounts++
+		case common.OriginWatchOnly:
+			info.WatchOnlyAccounts++
+		}
+	}
+	if current, err := w.wallet.CurrentAccount(); err == nil {
+		info.CurrentAccount = current.DisplayName
+	}
+	return info, nil
 }
 
-func getString(prompt string) (string, error) {
+func (w *WalletBackend) getString(prompt string) (string, error) {
 	fmt.Print(prompt)
+	if w.in != nil {
+		line, err := w.readLine()
+		return strings.TrimSpace(line), err
+	}
 	bytePassword, err := terminal.ReadPassword(int(syscall.Stdin)) // no history
 	if err != nil {
 		return "", err
@@ -74,8 +160,12 @@ func getString(prompt string) (string, error) {
 	return strings.TrimSpace(string(bytePassword)), nil
 }
 
-func getClearString(prompt string) string {
+func (w *WalletBackend) getClearString(prompt string) string {
 	fmt.Print(prompt)
+	if w.in != nil {
+		line, _ := w.readLine()
+		return strings.TrimSpace(line)
+	}
 	reader := bufio.NewReader(os.Stdin)
 	text, _ := reader.ReadString('\n')
 
@@ -84,17 +174,66 @@ func getClearString(prompt string) string {
 	return strings.TrimSpace(text)
 }
 
-func getPassword() (string, error) {
-	return getString("Enter wallet file password: ")
+// loadWallet loads a wallet file. If the file can't be parsed but its backup can, the user is
+// offered to restore the wallet from the backup.
+func (w *WalletBackend) loadWallet(path string) (*smWallet.Wallet, error) {
+	wallet, err := smWallet.LoadWallet(path)
+	if err == nil {
+		w.checkWalletPermissions(path)
+		if backup, err := smWallet.MigrateWalletFile(path); err != nil {
+			return nil, err
+		} else if backup != "" {
+			fmt.Println("upgraded the wallet file to version", smWallet.WalletVersion, "- the original file was saved to", backup)
+			// load the upgraded file, so it isn't taken for a change by another program
+			return smWallet.LoadWallet(path)
+		}
+		return wallet, nil
+	}
+	if _, statErr := os.Stat(path); statErr != nil {
+		return nil, err
+	}
+	if _, backupErr := smWallet.LoadWallet(smWallet.BackupPath(path)); backupErr != nil {
+		return nil, err
+	}
+	fmt.Println("failed to read wallet file:", err)
+	fmt.Println("a readable backup of the previous version was found at", smWallet.BackupPath(path))
+	if w.getClearString("Restore the wallet from the backup? (y/n) ") != "y" {
+		return nil, err
+	}
+	return smWallet.RestoreFromBackup(path)
+}
+
+// checkWalletPermissions warns when a wallet file can be read by other users and offers to fix its mode
+func (w *WalletBackend) checkWalletPermissions(path string) {
+	mode, insecure, err := common.InsecurePermissions(path)
+	if err != nil || !insecure {
+		return
+	}
+	fmt.Printf("WARNING: the wallet file %s has mode %v, other users of this machine can read it\n", path, mode)
+	if w.getClearString(fmt.Sprintf("Restrict it to mode %v? (y/n) ", common.PrivateFileMode)) != "y" {
+		return
+	}
+	if err := os.Chmod(path, common.PrivateFileMode); err != nil {
+		fmt.Println("failed to change the wallet file mode:", err)
+	}
+}
+
+func (w *WalletBackend) getPassword() (string, error) {
+	return w.getString("Enter wallet file password: ")
 }
 
-// OpenConnection opens a connection but not the wallet
-func OpenConnection(grpcServer string, secureConnection bool, wd string) (wbx *WalletBackend, err error) {
+// OpenConnection opens a connection but not the wallet. It doesn't wait for the node, which is
+// connected to in the background.
+func OpenConnection(grpcServers []string, secureConnection bool, authToken string, proxy *url.URL, limits common.MessageLimits, wd string) (wbx *WalletBackend, err error) {
 	wbe := WalletBackend{workingDirectory: wd}
-	wbe.gRPCClient = newGRPCClient(grpcServer, secureConnection)
+	if err = os.MkdirAll(wd, common.PrivateDirMode); err != nil {
+		log.Error("failed to create the wallets directory: %s", err)
+		return
+	}
+	wbe.gRPCClient = newGRPCClient(grpcServers, secureConnection, authToken, proxy, limits)
 	if err = wbe.gRPCClient.Connect(); err != nil {
-		// failed to connect to grpc server
-		log.Error("failed to connect to the grpc server: %s", err)
+		// the connection options are invalid, the node isn't called yet
+		log.Error("failed to set up the grpc connection: %s", err)
 		return
 	}
 	return &wbe, nil
@@ -111,38 +250,41 @@ func accounts(num int) string {
 func (w *WalletBackend) OpenWallet() bool {
 	fmt.Println("Press on TAB to select wallet file")
 	walletToOpen := w.getWallet()
-	wallet, err := smWallet.LoadWallet(walletToOpen)
+	wallet, err := w.loadWallet(walletToOpen)
 	if err != nil {
-		// error message
+		fmt.Println(err)
 		return false
 	}
-	w.wallet = wallet
-	password, err := getPassword()
+	password, err := w.getPassword()
 	if err != nil {
 		return false
 	}
 	fmt.Println("\nloading...")
-	if err = w.wallet.Unlock(password); err != nil {
+	if err = wallet.Unlock(password); err != nil {
 		fmt.Println(err)
 		return false
 	}
-	ne, err := w.wallet.GetNumberOfAccounts()
+	ne, err := wallet.GetNumberOfAccounts()
 	if err != nil {
 		return false
 	}
-	fmt.Println(w.wallet.Meta.DisplayName, "successfully opened with", accounts(ne))
+	fmt.Println(wallet.Meta.DisplayName, "successfully opened with", accounts(ne))
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.wallet = wallet
 	w.open = true
+	w.restoreCurrentAccount()
 	return true
 }
 
 // OpenWalletBackend opens an existing wallet
-func OpenWalletBackend(wallet string, grpcServer string, secureConnection bool) (wbx *WalletBackend, err error) {
-	var wbe WalletBackend
+func OpenWalletBackend(wallet string, grpcServers []string, secureConnection bool, authToken string, proxy *url.URL, limits common.MessageLimits) (wbx *WalletBackend, err error) {
+	wbe := WalletBackend{workingDirectory: filepath.Dir(wallet)}
 	wbx = nil
-	if wbe.wallet, err = smWallet.LoadWallet(wallet); err != nil {
+	if wbe.wallet, err = wbe.loadWallet(wallet); err != nil {
 		return
 	}
-	password, err := getPassword()
+	password, err := wbe.getPassword()
 	if err != nil {
 		return
 	}
@@ -155,25 +297,34 @@ func OpenWalletBackend(wallet string, grpcServer string, secureConnection bool)
 		return nil, err
 	}
 	fmt.Println(wbe.wallet.Meta.DisplayName, "successfully opened with", accounts(ne))
-	wbe.gRPCClient = newGRPCClient(grpcServer, secureConnection)
+	wbe.gRPCClient = newGRPCClient(grpcServers, secureConnection, authToken, proxy, limits)
 	if err = wbe.gRPCClient.Connect(); err != nil {
-		// failed to connect to grpc server
-		log.Error("failed to connect to the grpc server: %s", err)
+		// the connection options are invalid, the node isn't called yet
+		log.Error("failed to set up the grpc connection: %s", err)
 		return
 	}
 	wbe.open = true
+	wbe.restoreCurrentAccount()
 	return &wbe, nil
 }
 
-func (w *WalletBackend) NewWallet() bool {
-	walletName := getClearString("Wallet Display Name: ")
-	fmt.Println()
-	password, err := getPassword()
+// NewWallet creates a new wallet file in the wallets directory. The user is prompted for the
+// wallet name unless one is provided. When entropy is provided the wallet mnemonic is derived from
+// it, otherwise the user may enter a mnemonic or get a random one.
+func (w *WalletBackend) NewWallet(walletName string, entropy []byte) bool {
+	filePrefix := defaultWalletFilePrefix
+	if walletName == "" {
+		walletName = w.getClearString("Wallet Display Name: ")
+		fmt.Println()
+	} else {
+		filePrefix = walletName
+	}
+	password, err := w.getPassword()
 	fmt.Println()
 	if err != nil {
 		return false
 	}
-	password2, err := getString("Repeat password: ")
+	password2, err := w.getString("Repeat password: ")
 	fmt.Println()
 	if err != nil {
 		return false
@@ -183,51 +334,127 @@ func (w *WalletBackend) NewWallet() bool {
 		return false
 	}
 
-	mnemonicString := getClearString("Mnemonic (optional): ")
-	fmt.Println()
-	if len(mnemonicString) > 0 {
-		w.wallet, err = smWallet.NewWalletWithMnemonic(walletName, password, mnemonicString)
-		if err != nil {
-			fmt.Println(err)
-			return false
-		}
+	mnemonicString := ""
+	if entropy == nil {
+		mnemonicString = w.getClearString("Mnemonic (optional): ")
+		fmt.Println()
+	}
+	var wallet *smWallet.Wallet
+	if entropy != nil {
+		wallet, err = smWallet.NewWalletFromEntropy(walletName, password, entropy)
+	} else if len(mnemonicString) > 0 {
+		wallet, err = smWallet.NewWalletWithMnemonic(walletName, password, mnemonicString)
 	} else {
-		w.wallet, err = smWallet.NewWallet(walletName, password)
-		if err != nil {
-			fmt.Println(err)
-			return false
-		}
+		wallet, err = smWallet.NewWallet(walletName, password)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return false
 	}
 
-	err = w.wallet.SaveWalletAs(w.workingDirectory + "/my_wallet")
+	err = wallet.SaveWalletAs(filepath.Join(w.workingDirectory, filePrefix))
 	if err != nil {
 		fmt.Println(err)
 		return false
 	}
 	fmt.Println("Wallet created")
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.wallet = wallet
 	w.open = true
 	return true
 }
 
+// ConfirmPassword prompts for the wallet password and checks it. Wallets without encryption need no
+// password.
+func (w *WalletBackend) ConfirmPassword() error {
+	w.mu.RLock()
+	if w.wallet == nil {
+		w.mu.RUnlock()
+		return common.ErrNoWallet
+	}
+	encrypted := w.wallet.Crypto.CipherText != ""
+	w.mu.RUnlock()
+	if !encrypted {
+		return nil
+	}
+	password, err := w.getPassword()
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.wallet == nil {
+		return common.ErrNoWallet
+	}
+	return w.wallet.CheckPassword(password)
+}
+
+// ChangePassword prompts for the current and a new wallet password and re-encrypts the wallet file
+func (w *WalletBackend) ChangePassword() error {
+	current, err := w.getString("Enter current wallet password: ")
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+	password, err := w.getString("Enter new wallet password: ")
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+	password2, err := w.getString("Repeat new password: ")
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+	if password != password2 {
+		return errors.New("passwords do not match")
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wallet == nil {
+		return common.ErrNoWallet
+	}
+	fmt.Println("re-encrypting...")
+	return w.wallet.ChangePassword(current, password)
+}
+
 func (w *WalletBackend) CloseWallet() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wallet != nil {
+		w.wallet.Lock()
+	}
 	w.wallet = nil
+	w.open = false
 }
 
 // CurrentAccount - get the latest account into cli-wallet format
 func (w *WalletBackend) CurrentAccount() (*common.LocalAccount, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.currentAccount()
+}
 
-	ca, err := w.wallet.CurrentAccount()
-	if err != nil {
-		return nil, err
+// currentAccount returns the current account. w.mu must be held.
+func (w *WalletBackend) currentAccount() (*common.LocalAccount, error) {
+	if w.wallet == nil {
+		return nil, common.ErrNoWallet
 	}
-	pk, err := ca.PrivateKey()
+	ca, err := w.wallet.CurrentAccount()
 	if err != nil {
 		return nil, err
 	}
-	return &common.LocalAccount{Name: ca.DisplayName, PrivKey: pk, PubKey: smWallet.PublicKey(pk)}, nil
+	return w.getAccount(ca.DisplayName)
 }
 
 func (w *WalletBackend) CreateAccount(displayName string) (la *common.LocalAccount, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wallet == nil {
+		return nil, common.ErrNoWallet
+	}
 	pos, err := w.wallet.GenerateNewPair(displayName)
 	if err != nil {
 		return nil, err
@@ -235,11 +462,81 @@ func (w *WalletBackend) CreateAccount(displayName string) (la *common.LocalAccou
 	if err = w.wallet.SetCurrent(pos); err != nil {
 		return
 	}
-	return w.CurrentAccount()
+	w.rememberCurrentAccount()
+	return w.currentAccount()
+}
+
+// WatchAccount adds a watch-only account which tracks an address without its private key
+func (w *WalletBackend) WatchAccount(displayName string, address gosmtypes.Address) (*common.LocalAccount, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wallet == nil {
+		return nil, common.ErrNoWallet
+	}
+	if _, err := w.wallet.AddWatchOnlyAccount(displayName, address); err != nil {
+		return nil, err
+	}
+	return w.getAccount(strings.TrimSpace(displayName))
 }
 
 func (w *WalletBackend) SetCurrentAccount(accountNumber int) error {
-	return w.wallet.SetCurrent(accountNumber)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wallet == nil {
+		return common.ErrNoWallet
+	}
+	if err := w.wallet.SetCurrent(accountNumber); err != nil {
+		return err
+	}
+	w.rememberCurrentAccount()
+	return nil
+}
+
+// walletKey identifies the open wallet in the settings file. w.mu must be held.
+func (w *WalletBackend) walletKey() string {
+	path, err := filepath.Abs(w.wallet.WalletPath())
+	if err != nil {
+		return w.wallet.WalletPath()
+	}
+	return path
+}
+
+// rememberCurrentAccount records the alias of the current account in the settings file so it is
+// selected again when the wallet is next opened. w.mu must be held.
+func (w *WalletBackend) rememberCurrentAccount() {
+	current, err := w.wallet.CurrentAccount()
+	if err != nil {
+		return
+	}
+	config, err := w.Config()
+	if err != nil {
+		log.Error("failed to read settings: %v", err)
+		return
+	}
+	if config.SetRememberedAccount(w.walletKey(), current.DisplayName) {
+		if err := config.Save(); err != nil {
+			log.Error("failed to save settings: %v", err)
+		}
+	}
+}
+
+// restoreCurrentAccount selects the account that was current when the open wallet was last used.
+// If that account was deleted the wallet is left without a current account. w.mu must be held.
+func (w *WalletBackend) restoreCurrentAccount() {
+	config, err := w.Config()
+	if err != nil {
+		log.Error("failed to read settings: %v", err)
+		return
+	}
+	alias := config.RememberedAccount(w.walletKey())
+	if alias == "" {
+		return
+	}
+	if idx, err := w.accountIndex(alias); err == nil {
+		_ = w.wallet.SetCurrent(idx)
+		return
+	}
+	w.wallet.ClearCurrent()
 }
 
 func interfaceToBytes(i interface{}) ([]byte, error) {
@@ -250,55 +547,240 @@ func interfaceToBytes(i interface{}) ([]byte, error) {
 	return w.Bytes(), nil
 }
 
+// StoreAccounts writes the wallet file. Concurrent calls are serialized with the account changes.
 func (w *WalletBackend) StoreAccounts() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wallet == nil {
+		return common.ErrNoWallet
+	}
 	return w.wallet.SaveWallet()
 }
 
-// Transfer creates a sign coin transaction and submits it
-func (w *WalletBackend) Transfer(recipient gosmtypes.Address, nonce, amount, gasPrice, gasLimit uint64, key ed25519.PrivateKey) (*pb.TransactionState, error) {
-	tx := common.SerializableSignedTransaction{}
-	tx.AccountNonce = nonce
-	tx.Amount = amount
-	tx.Recipient = recipient
-	tx.GasLimit = gasLimit
-	tx.Price = gasPrice
+// UnsignedTransaction returns the serialized data of a coin transaction which is signed
+func (w *WalletBackend) UnsignedTransaction(recipient gosmtypes.Address, nonce, amount, gasPrice, gasLimit uint64) ([]byte, error) {
+	inner := common.InnerSerializableSignedTransaction{
+		AccountNonce: nonce,
+		Recipient:    recipient,
+		GasLimit:     gasLimit,
+		Price:        gasPrice,
+		Amount:       amount,
+	}
+	return interfaceToBytes(&inner)
+}
+
+// SignTransfer creates a signed coin transaction and returns it with its serialized bytes, the
+// signed message, the signature and its id. It doesn't talk to the node, so it works offline.
+func (w *WalletBackend) SignTransfer(recipient gosmtypes.Address, nonce, amount, gasPrice, gasLimit uint64, key *common.SigningKey) (*common.SignedTransfer, error) {
+	if key == nil {
+		return nil, common.ErrWatchOnly
+	}
+	codec, err := w.txCodec()
+	if err != nil {
+		return nil, err
+	}
+	return codec.SignTransfer(common.TxRequest{
+		Recipient: recipient,
+		Amount:    amount,
+		GasPrice:  gasPrice,
+		GasLimit:  gasLimit,
+		Nonce:     nonce,
+	}, key)
+}
+
+// txCodec returns the codec transactions are signed with. Unless the tx-format setting picks one,
+// the format is the one of the version the node reported when it was last asked, and it is an
+// error when the node hasn't been reached, so signing never waits for the node.
+func (w *WalletBackend) txCodec() (common.TxCodec, error) {
+	version := ""
+	if w.gRPCClient != nil {
+		version = w.knownNodeVersion()
+	}
+	config, err := w.Config()
+	if err != nil {
+		return nil, err
+	}
+	return config.TxCodec(version)
+}
+
+// Transfer creates a sign coin transaction and submits it, retrying when the node is unavailable
+func (w *WalletBackend) Transfer(ctx context.Context, recipient gosmtypes.Address, nonce, amount, gasPrice, gasLimit uint64, key *common.SigningKey) (*pb.TransactionState, error) {
+	tx, err := w.SignTransfer(recipient, nonce, amount, gasPrice, gasLimit, key)
+	if err != nil {
+		return nil, err
+	}
+	return w.SubmitTransfer(ctx, tx)
+}
 
-	buf, _ := interfaceToBytes(&tx.InnerSerializableSignedTransaction)
-	copy(tx.Signature[:], ed25519.Sign2(key, buf))
-	b, err := interfaceToBytes(&tx)
+// SubmitTransfer submits a coin transaction signed with SignTransfer, retrying when the node is
+// unavailable, and records its nonce
+func (w *WalletBackend) SubmitTransfer(ctx context.Context, tx *common.SignedTransfer) (*pb.TransactionState, error) {
+	txState, _, err := submitWithRetry(ctx, w, tx.Signed, tx.ID)
 	if err != nil {
+		var nodeErr *common.NodeError
+		if errors.As(err, &nodeErr) && nodeErr.Reason == common.ReasonNonce {
+			if state, stateErr := w.AccountState(ctx, tx.Sender); stateErr == nil && state.StateProjected != nil {
+				counter := state.StateProjected.Counter
+				nodeErr.Advice = fmt.Sprintf("nonce %d already used or out of order — the projected counter is %d; re-run with --nonce %d", tx.Nonce, counter, counter)
+			}
+		}
 		return nil, err
 	}
-	return w.SubmitCoinTransaction(b)
+	if err := w.useNonce(tx.Sender, tx.Nonce); err != nil {
+		log.Error("failed to record the transaction nonce: %v", err)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.submitted == nil {
+		w.submitted = make(map[gosmtypes.Address][]common.SubmittedTx)
+	}
+	w.submitted[tx.Sender] = append(w.submitted[tx.Sender], common.SubmittedTx{ID: txState.Id.Id, TxRequest: tx.TxRequest})
+	return txState, nil
+}
+
+// SubmittedTransactions returns the transactions an account submitted with Transfer since the wallet backend started
+func (w *WalletBackend) SubmittedTransactions(address gosmtypes.Address) []common.SubmittedTx {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]common.SubmittedTx(nil), w.submitted[address]...)
 }
 
 func (w *WalletBackend) GetAccount(accountName string) (*common.LocalAccount, error) {
-	numberOfAccounts, err := w.wallet.GetNumberOfAccounts()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.getAccount(accountName)
+}
+
+// getAccount returns the account with a display name. w.mu must be held.
+func (w *WalletBackend) getAccount(accountName string) (*common.LocalAccount, error) {
+	j, err := w.accountIndex(accountName)
 	if err != nil {
-		log.Error("failed to retrieve number of accounts", err)
+		log.Error(err.Error())
 		return nil, err
 	}
-	for j := 0; j < numberOfAccounts; j++ {
-		dn, err := w.wallet.GetAccountDisplayName(j)
+	note, err := w.wallet.GetAccountNote(j)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := w.wallet.GetAccountTags(j)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, gasLimit, err := w.wallet.GetAccountGas(j)
+	if err != nil {
+		return nil, err
+	}
+	origins, err := w.wallet.AccountOrigins()
+	if err != nil {
+		return nil, err
+	}
+	if origins[j].Kind == common.OriginWatchOnly {
+		addr, err := w.wallet.GetAddress(j)
 		if err != nil {
-			log.Error("failed to retrieve display names", err)
 			return nil, err
 		}
-		if dn == accountName {
-			pk, err := w.wallet.GetPrivateKey(j)
-			if err != nil {
-				log.Error("failed to retrieve private key", err)
-				return nil, err
-			}
-			return &common.LocalAccount{Name: accountName, PrivKey: pk, PubKey: smWallet.PublicKey(pk)}, nil
-		}
+		return &common.LocalAccount{Name: accountName, WatchAddress: addr, Note: note, Tags: tags,
+			GasPrice: gasPrice, GasLimit: gasLimit, Origin: origins[j]}, nil
+	}
+	pk, err := w.wallet.GetPrivateKey(j)
+	if err != nil {
+		log.Error("failed to retrieve private key", err)
+		return nil, err
+	}
+	return &common.LocalAccount{Name: accountName, PrivKey: pk, PubKey: smWallet.PublicKey(pk), Note: note, Tags: tags,
+		GasPrice: gasPrice, GasLimit: gasLimit, Origin: origins[j]}, nil
+}
+
+// DeriveAddresses returns the addresses derived from the wallet mnemonic at the n indexes following
+// the highest index used by an account of the wallet
+func (w *WalletBackend) DeriveAddresses(n int) ([]common.DerivedAddress, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.wallet == nil {
+		return nil, common.ErrNoWallet
+	}
+	return w.wallet.DeriveAddresses(n)
+}
+
+// AddDerivedAccount adds the account derived from the wallet mnemonic at index
+func (w *WalletBackend) AddDerivedAccount(displayName string, index uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wallet == nil {
+		return common.ErrNoWallet
+	}
+	_, err := w.wallet.AddDerivedAccount(displayName, index)
+	return err
+}
+
+// mnemonicCheckAccounts is the number of leading derivation indexes compared when verifying a mnemonic
+const mnemonicCheckAccounts = 5
+
+// readMnemonic prompts for a 12 or 24 word mnemonic without echoing it
+func (w *WalletBackend) readMnemonic() (string, error) {
+	fmt.Print("Enter the 12 or 24 word mnemonic: ")
+	var input []byte
+	var err error
+	if w.in != nil {
+		var line string
+		line, err = w.readLine()
+		input = []byte(line)
+	} else {
+		input, err = terminal.ReadPassword(int(syscall.Stdin))
+	}
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	defer common.Zero(input)
+	words := strings.Fields(strings.ToLower(string(input)))
+	if len(words) != 12 && len(words) != 24 {
+		return "", fmt.Errorf("expected 12 or 24 words, got %d", len(words))
+	}
+	return strings.Join(words, " "), nil
+}
+
+// RecoverKey prompts for a 12 or 24 word mnemonic without echoing it and returns the key derived
+// from it at index
+func (w *WalletBackend) RecoverKey(index uint64) (ed25519.PrivateKey, error) {
+	mnemonic, err := w.readMnemonic()
+	if err != nil {
+		return nil, err
+	}
+	return smWallet.DeriveKey(mnemonic, index)
+}
+
+// VerifyMnemonic reads a mnemonic without echo and tells whether the wallet keys derive from it
+func (w *WalletBackend) VerifyMnemonic() (bool, error) {
+	mnemonic, err := w.readMnemonic()
+	if err != nil {
+		return false, err
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.wallet == nil {
+		return false, common.ErrNoWallet
+	}
+	return w.wallet.MatchesMnemonic(mnemonic, mnemonicCheckAccounts)
+}
+
+// AddKeyAccount adds an account for a private key
+func (w *WalletBackend) AddKeyAccount(displayName string, key ed25519.PrivateKey) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wallet == nil {
+		return common.ErrNoWallet
 	}
-	err = errors.New("failed to find :" + accountName)
-	log.Error(err.Error())
-	return nil, err
+	_, err := w.wallet.AddKeyAccount(displayName, key)
+	return err
 }
 
 func (w *WalletBackend) ListAccounts() (res []string, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wallet == nil {
+		return []string{}, common.ErrNoWallet
+	}
 	numberOfAccounts, err := w.wallet.GetNumberOfAccounts()
 	if err != nil {
 		log.Error("failed to retrieve number of accounts", err)
@@ -315,3 +797,95 @@ func (w *WalletBackend) ListAccounts() (res []string, err error) {
 
 	return res, nil
 }
+
+// accountIndex returns the position in the wallet of the account with the provided display name.
+// w.mu must be held.
+func (w *WalletBackend) accountIndex(accountName string) (int, error) {
+	if w.wallet == nil {
+		return 0, common.ErrNoWallet
+	}
+	numberOfAccounts, err := w.wallet.GetNumberOfAccounts()
+	if err != nil {
+		return 0, err
+	}
+	for j := 0; j < numberOfAccounts; j++ {
+		dn, err := w.wallet.GetAccountDisplayName(j)
+		if err != nil {
+			return 0, err
+		}
+		if dn == accountName {
+			return j, nil
+		}
+	}
+	return 0, errors.New("failed to find :" + accountName)
+}
+
+// SetAccountNote attaches a note to an account. An empty note removes it.
+func (w *WalletBackend) SetAccountNote(accountName, note string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	idx, err := w.accountIndex(accountName)
+	if err != nil {
+		return err
+	}
+	return w.wallet.SetAccountNote(idx, note)
+}
+
+// TagAccount adds a tag to an account
+func (w *WalletBackend) TagAccount(accountName, tag string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	idx, err := w.accountIndex(accountName)
+	if err != nil {
+		return err
+	}
+	return w.wallet.AddAccountTag(idx, tag)
+}
+
+// UntagAccount removes a tag from an account
+func (w *WalletBackend) UntagAccount(accountName, tag string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	idx, err := w.accountIndex(accountName)
+	if err != nil {
+		return err
+	}
+	return w.wallet.RemoveAccountTag(idx, tag)
+}
+
+// SetAccountGas sets the default gas price and gas limit of an account. 0 removes a default.
+func (w *WalletBackend) SetAccountGas(accountName string, gasPrice, gasLimit uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	idx, err := w.accountIndex(accountName)
+	if err != nil {
+		return err
+	}
+	return w.wallet.SetAccountGas(idx, gasPrice, gasLimit)
+}
+
+// DeleteAccount removes an account from the wallet. Deleting the current account clears the current account.
+func (w *WalletBackend) DeleteAccount(accountName string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	idx, err := w.accountIndex(accountName)
+	if err != nil {
+		return err
+	}
+	return w.wallet.DeleteAccount(idx)
+}
+
+// RenameAccount changes the display name of an account. The current account selection is not affected.
+func (w *WalletBackend) RenameAccount(oldName, newName string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	idx, err := w.accountIndex(oldName)
+	if err != nil {
+		return err
+	}
+	if err := w.wallet.RenameAccount(idx, newName); err != nil {
+		return err
+	}
+	w.rememberCurrentAccount()
+	return nil
+}
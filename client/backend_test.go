@@ -0,0 +1,213 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spacemeshos/ed25519"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/smWallet"
+)
+
+// newTestBackend returns a wallet backend with an open wallet saved in a temporary directory, without
+// a node connection
+func newTestBackend(t *testing.T) (*WalletBackend, func()) {
+	dir, err := ioutil.TempDir("", "smrepl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet, err := smWallet.NewWallet("test", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wallet.SaveWalletAs(filepath.Join(dir, "wallet")); err != nil {
+		t.Fatal(err)
+	}
+	return &WalletBackend{workingDirectory: dir, wallet: wallet, open: true}, func() { _ = os.RemoveAll(dir) }
+}
+
+// TestConcurrentAccounts changes and reads the accounts from several goroutines, which go test
+// -race checks for unsynchronized access
+func TestConcurrentAccounts(t *testing.T) {
+	w, cleanup := newTestBackend(t)
+	defer cleanup()
+
+	const workers = 4
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := w.CreateAccount(fmt.Sprintf("account%d", i)); err != nil {
+				t.Error(err)
+				return
+			}
+			if err := w.StoreAccounts(); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = w.CurrentAccount()
+			if _, err := w.ListAccounts(); err != nil {
+				t.Error(err)
+			}
+			_ = w.IsOpen()
+		}()
+	}
+	wg.Wait()
+
+	names, err := w.ListAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != workers+1 {
+		t.Fatalf("expected %d accounts, got %v", workers+1, names)
+	}
+	if _, err := w.CurrentAccount(); err != nil {
+		t.Fatalf("expected a current account, got %v", err)
+	}
+}
+
+// TestConcurrentCurrentAccount switches the current account while it is read
+func TestConcurrentCurrentAccount(t *testing.T) {
+	w, cleanup := newTestBackend(t)
+	defer cleanup()
+	if _, err := w.CreateAccount("second"); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			if err := w.SetCurrentAccount(i % 2); err != nil {
+				t.Error(err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			acc, err := w.CurrentAccount()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := w.GetAccount(acc.Name); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestConcurrentClose closes the wallet while it is read, which must neither race nor dereference
+// the closed wallet
+func TestConcurrentClose(t *testing.T) {
+	w, cleanup := newTestBackend(t)
+	defer cleanup()
+	backup := filepath.Join(w.workingDirectory, "backup.json")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			_ = w.WalletName()
+			_, _ = w.WalletInfo()
+			_, _ = w.WalletMnemonic()
+			_, _ = w.BackupWallet(backup)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		w.CloseWallet()
+	}()
+	wg.Wait()
+
+	if _, err := w.WalletInfo(); err != common.ErrNoWallet {
+		t.Fatalf("expected no wallet after closing it, got %v", err)
+	}
+	if _, err := w.BackupWallet(backup); err != common.ErrNoWallet {
+		t.Fatalf("expected no backup without a wallet, got %v", err)
+	}
+	if name := w.WalletName(); name != "" {
+		t.Fatalf("expected no wallet name after closing it, got %q", name)
+	}
+	if _, err := w.DeriveAddresses(1); err != common.ErrNoWallet {
+		t.Fatalf("expected no derived addresses without a wallet, got %v", err)
+	}
+	if err := w.AddDerivedAccount("derived", 3); err != common.ErrNoWallet {
+		t.Fatalf("expected no derived account without a wallet, got %v", err)
+	}
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	if err := w.AddKeyAccount("imported", key); err != common.ErrNoWallet {
+		t.Fatalf("expected no imported account without a wallet, got %v", err)
+	}
+}
+
+// TestPromptsReadInput creates, unlocks and checks a wallet with the answers of the prompts read
+// from the input given with SetInput, as a script does
+func TestPromptsReadInput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smrepl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	const mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	w := &WalletBackend{workingDirectory: dir}
+	w.SetInput(bufio.NewReader(strings.NewReader("password\npassword\n" + mnemonic + "\n")))
+	if !w.NewWallet("scripted", nil) {
+		t.Fatal("expected the wallet to be created from the input")
+	}
+	name := w.WalletName()
+	w.CloseWallet()
+
+	w.SetInput(bufio.NewReader(strings.NewReader("password\n" + strings.ToUpper(mnemonic) + "\n")))
+	if err := w.SwitchWallet(name); err != nil {
+		t.Fatalf("expected the wallet to be unlocked with the password of the input: %v", err)
+	}
+	if ok, err := w.VerifyMnemonic(); err != nil || !ok {
+		t.Fatalf("expected the mnemonic of the input to match the wallet, got %v %v", ok, err)
+	}
+	if _, err := w.RecoverKey(0); err == nil {
+		t.Fatal("expected an error when the input ends before the mnemonic")
+	}
+}
+
+// TestLoadCorruptWallet checks that a wallet file which can't be parsed is an error and is left
+// as it is, rather than replaced by an empty wallet
+func TestLoadCorruptWallet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smrepl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "wallet.json")
+	corrupt := []byte(`{"meta": {"displayName": "test"`)
+	if err := ioutil.WriteFile(path, corrupt, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (&WalletBackend{}).loadWallet(path); err == nil {
+		t.Fatal("expected an error loading a corrupt wallet file")
+	}
+	if _, err := OpenWalletBackend(path, nil, false, "", nil, common.MessageLimits{}); err == nil {
+		t.Fatal("expected an error opening a corrupt wallet file")
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(corrupt) {
+		t.Fatalf("expected the wallet file to be left as it was, got %s", data)
+	}
+}
@@ -1,8 +1,11 @@
 package client
 
 import (
+	"context"
 	"testing"
 	"time"
+
+	"github.com/spacemeshos/smrepl/common"
 )
 
 func TestDefault(t *testing.T) {
@@ -14,7 +17,7 @@ func TestPublic(t *testing.T) {
 }
 
 func testClient(t *testing.T, server string, secure bool) {
-	client := newGRPCClient(server, secure)
+	client := newGRPCClient([]string{server}, secure, "", nil, common.MessageLimits{})
 
 	err := client.Connect()
 	if err != nil {
@@ -23,7 +26,7 @@ func testClient(t *testing.T, server string, secure bool) {
 	str := client.ServerInfo()
 	t.Log(str)
 	client.getNodeServiceClient()
-	ni, err := client.NodeInfo()
+	ni, err := client.NodeInfo(context.Background())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+func TestConnectDoesNotWaitForTheNode(t *testing.T) {
+	backoff := readRetryBackoff
+	readRetryBackoff = common.Backoff{Initial: time.Millisecond, Max: time.Millisecond, Attempts: backoff.Attempts}
+	defer func() { readRetryBackoff = backoff }()
+
+	for _, secure := range []bool{false, true} {
+		client := newGRPCClient([]string{closedAddr(t)}, secure, "", nil, common.MessageLimits{})
+		start := time.Now()
+		if err := client.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("expected connect to return at once, it took %v", elapsed)
+		}
+		if _, err := client.NodeStatus(context.Background()); status.Code(err) != codes.Unavailable {
+			t.Fatalf("expected the call to fail with Unavailable, got %v", err)
+		}
+		_ = client.Close()
+	}
+}
+
+func TestConnectSelectsServerInBackground(t *testing.T) {
+	node, stop := startNode(t)
+	defer stop()
+
+	client := newGRPCClient([]string{closedAddr(t), node}, false, "", nil, common.MessageLimits{})
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for client.ActiveServer() != node {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected to switch to %s, still on %s", node, client.ActiveServer())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := client.NodeStatus(context.Background()); err != nil {
+		t.Fatalf("expected the call to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// slowNodeService answers status requests after a delay, or fails when the call is cancelled first
+type slowNodeService struct {
+	apitypes.UnimplementedNodeServiceServer
+	delay time.Duration
+}
+
+func (s *slowNodeService) Status(ctx context.Context, req *apitypes.StatusRequest) (*apitypes.StatusResponse, error) {
+	select {
+	case <-time.After(s.delay):
+		return &apitypes.StatusResponse{Status: &apitypes.NodeStatus{}}, nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// Echo answers the health checks of the servers to connect to
+func (s *slowNodeService) Echo(ctx context.Context, req *apitypes.EchoRequest) (*apitypes.EchoResponse, error) {
+	return &apitypes.EchoResponse{Msg: req.Msg}, nil
+}
+
+// startSlowNode serves a slowNodeService on a local port and returns a client connected to it
+func startSlowNode(t *testing.T, delay time.Duration) (*gRPCClient, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	apitypes.RegisterNodeServiceServer(server, &slowNodeService{delay: delay})
+	go server.Serve(listener)
+
+	client := newGRPCClient([]string{listener.Addr().String()}, false, "", nil, common.MessageLimits{})
+	if err := client.Connect(); err != nil {
+		server.Stop()
+		t.Fatal(err)
+	}
+	return client, func() {
+		_ = client.Close()
+		server.Stop()
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	client, stop := startSlowNode(t, time.Second)
+	defer stop()
+
+	client.setCallSettings(50*time.Millisecond, false)
+	start := time.Now()
+	_, err := client.NodeStatus(context.Background())
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("expected the call to time out, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the call to end at its deadline, it took %v", elapsed)
+	}
+
+	client.setCallSettings(0, false)
+	if _, err := client.NodeStatus(context.Background()); err != nil {
+		t.Fatalf("expected the call to succeed without a timeout, got %v", err)
+	}
+}
+
+func TestCallCancel(t *testing.T) {
+	client, stop := startSlowNode(t, time.Second)
+	defer stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client.setCallSettings(time.Minute, false)
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := client.NodeStatus(ctx)
+	if status.Code(err) != codes.Canceled {
+		t.Fatalf("expected the call to be cancelled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the call to end when its context was cancelled, it took %v", elapsed)
+	}
+}
@@ -7,9 +7,11 @@ import (
 	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
 )
 
-func (c *gRPCClient) DebugAllAccounts() ([]*apitypes.Account, error) {
+func (c *gRPCClient) DebugAllAccounts(ctx context.Context) ([]*apitypes.Account, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	dbgService := c.getDebugServiceClient()
-	resp, err := dbgService.Accounts(context.Background(), &empty.Empty{})
+	resp, err := dbgService.Accounts(ctx, &empty.Empty{})
 	if err != nil {
 		return nil, err
 	}
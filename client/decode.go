@@ -0,0 +1,41 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+
+	xdr "github.com/davecgh/go-xdr/xdr2"
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// DecodeTransaction returns the fields of serialized coin transaction data
+func (w *WalletBackend) DecodeTransaction(data []byte) (*common.InnerSerializableSignedTransaction, error) {
+	var tx common.InnerSerializableSignedTransaction
+	if _, err := xdr.Unmarshal(bytes.NewReader(data), &tx); err != nil {
+		return nil, fmt.Errorf("invalid transaction data: %v", err)
+	}
+	return &tx, nil
+}
+
+// DecodeSignedTransaction strictly decodes a signed coin transaction of either format and returns
+// its fields and sender. Errors name the field that can't be decoded. Data left after the
+// signature is an error.
+func (w *WalletBackend) DecodeSignedTransaction(data []byte) (*common.DecodedTransfer, error) {
+	return common.DecodeSignedTransfer(data)
+}
+
+// DecodeMultisigTransaction strictly decodes a transaction of an m-of-n account
+func (w *WalletBackend) DecodeMultisigTransaction(data []byte) (*common.SerializableMultisigTransaction, error) {
+	var tx common.SerializableMultisigTransaction
+	r := bytes.NewReader(data)
+	if _, err := xdr.Unmarshal(r, &tx); err != nil {
+		return nil, fmt.Errorf("invalid multisig transaction: %v", err)
+	}
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("invalid multisig transaction: %d unexpected bytes after the signatures", r.Len())
+	}
+	if tx.Threshold == 0 || int(tx.Threshold) > len(tx.Signatures) {
+		return nil, fmt.Errorf("invalid multisig transaction: %d signatures for a threshold of %d", len(tx.Signatures), tx.Threshold)
+	}
+	return &tx, nil
+}
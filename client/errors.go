@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// translateError gives the common errors of calls advice naming the active server and the call
+// timeout. The translated errors keep their status code, and show it in verbose mode.
+func (c *gRPCClient) translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	c.mu.Lock()
+	endpoint, timeout, verbose := c.servers[c.active], c.callTimeout, c.verbose
+	c.mu.Unlock()
+	err = common.TranslateError(err, endpoint, timeout)
+	if nodeErr, ok := err.(*common.NodeError); ok {
+		nodeErr.ShowStatus = verbose
+	}
+	return err
+}
+
+// errorsUnary translates the errors of calls
+func (c *gRPCClient) errorsUnary(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return c.translateError(invoker(ctx, method, req, reply, cc, opts...))
+}
+
+// errorsStream translates the errors of opening streams and of their messages
+func (c *gRPCClient) errorsStream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, c.translateError(err)
+	}
+	return errorsClientStream{ClientStream: stream, client: c}, nil
+}
+
+// errorsClientStream translates the errors of the messages of a stream
+type errorsClientStream struct {
+	grpc.ClientStream
+	client *gRPCClient
+}
+
+func (s errorsClientStream) RecvMsg(m interface{}) error {
+	return s.client.translateError(s.ClientStream.RecvMsg(m))
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCallErrorsTranslated(t *testing.T) {
+	client, _, stop := startFlakyNode(t, readAttempts)
+	defer stop()
+
+	_, err := client.NodeStatus(context.Background())
+	if err == nil {
+		t.Fatal("expected the status call to fail")
+	}
+	if want := "node unreachable at " + client.ActiveServer(); !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected %q, got %q", want, err.Error())
+	}
+	if strings.Contains(err.Error(), "node is restarting") {
+		t.Fatalf("expected the status of the node to be hidden, got %q", err.Error())
+	}
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected the code to be kept, got %s", status.Code(err))
+	}
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// healthTimeout is how long a server may take to answer the echo call which tells whether it is
+// healthy
+const healthTimeout = 3 * time.Second
+
+// noFailoverKey marks the context of calls which must not fail over, such as health checks
+type noFailoverKey struct{}
+
+// healthContext returns the context of a health check echo call
+func healthContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithValue(context.Background(), noFailoverKey{}, true), healthTimeout)
+}
+
+// dialHealthy connects to a server and returns the connection when the server answers an echo call
+func (c *gRPCClient) dialHealthy(server string) (*grpc.ClientConn, error) {
+	conn, err := c.dialServer(server, healthTimeout)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := healthContext()
+	defer cancel()
+	if err := echo(ctx, apitypes.NewNodeServiceClient(conn)); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// shouldFailover tells whether a call error may be fixed by switching to another server: the
+// server was unavailable and there is another one to switch to
+func (c *gRPCClient) shouldFailover(ctx context.Context, err error) bool {
+	if ctx.Value(noFailoverKey{}) != nil || status.Code(err) != codes.Unavailable {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.servers) > 1
+}
+
+// failover switches to the next server which answers an echo call after a call on the connection
+// failed with Unavailable. The active server is kept when it still answers an echo call,
+// since the error was transient then. It returns the connection to retry the call on, or nil when
+// the call can't be retried.
+func (c *gRPCClient) failover(failed *grpc.ClientConn) *grpc.ClientConn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connection != failed {
+		// another call already switched servers
+		return c.connection
+	}
+	ctx, cancel := healthContext()
+	defer cancel()
+	if echo(ctx, apitypes.NewNodeServiceClient(failed)) == nil {
+		return nil
+	}
+	for i := 1; i < len(c.servers); i++ {
+		next := (c.active + i) % len(c.servers)
+		conn, err := c.dialHealthy(c.servers[next])
+		if err != nil {
+			continue
+		}
+		_ = failed.Close()
+		c.use(next, conn)
+		fmt.Println("switched to node", c.servers[next])
+		return conn
+	}
+	return nil
+}
+
+// failoverUnary retries a call failing with Unavailable on the next healthy server
+func (c *gRPCClient) failoverUnary(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if !c.shouldFailover(ctx, err) {
+		return err
+	}
+	conn := c.failover(cc)
+	if conn == nil {
+		return err
+	}
+	return conn.Invoke(ctx, method, req, reply, opts...)
+}
+
+// failoverStream opens a stream failing with Unavailable again on the next healthy server, so
+// streams interrupted by a failed server are re-established against another one
+func (c *gRPCClient) failoverStream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if !c.shouldFailover(ctx, err) {
+		return stream, err
+	}
+	conn := c.failover(cc)
+	if conn == nil {
+		return stream, err
+	}
+	return conn.NewStream(ctx, desc, method, opts...)
+}
+
+// CheckServers reports whether every configured server answers an echo call and how fast
+func (c *gRPCClient) CheckServers() []common.ServerHealth {
+	c.mu.Lock()
+	servers, active, activeConn := c.servers, c.active, c.connection
+	c.mu.Unlock()
+
+	report := make([]common.ServerHealth, len(servers))
+	for i, server := range servers {
+		report[i] = common.ServerHealth{Server: server, Active: i == active}
+		conn := activeConn
+		if i != active {
+			var err error
+			if conn, err = c.dialServer(server, healthTimeout); err != nil {
+				report[i].Err = err
+				continue
+			}
+		}
+		ctx, cancel := healthContext()
+		start := time.Now()
+		report[i].Err = echo(ctx, apitypes.NewNodeServiceClient(conn))
+		report[i].Latency = time.Since(start)
+		cancel()
+		if i != active {
+			_ = conn.Close()
+		}
+	}
+	return report
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+const (
+	// feeSampleLayers is the number of recent layers whose transactions are sampled for a fee estimate
+	feeSampleLayers = 50
+	// feeEstimateTTL is how long a fee estimate is reused before the layers are sampled again
+	feeEstimateTTL = time.Minute
+	// minGasPrice is the lowest gas price suggested
+	minGasPrice = 1
+)
+
+// FeeEstimate returns gas prices suggested from the transactions included in recent layers. The
+// estimate is cached for a minute so that back-to-back sends don't sample the mesh every time.
+func (w *WalletBackend) FeeEstimate(ctx context.Context) (*common.FeeEstimate, error) {
+	if w.fees != nil && time.Since(w.fees.Computed) < feeEstimateTTL {
+		return w.fees, nil
+	}
+	info, err := w.GetMeshInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	last := info.CurrentLayer
+	first := uint32(0)
+	if last > feeSampleLayers {
+		first = last - feeSampleLayers
+	}
+	txs, err := w.LayerTransactions(ctx, first, last)
+	if err != nil {
+		return nil, err
+	}
+	prices := make([]uint64, 0, len(txs))
+	for _, tx := range txs {
+		if tx.GasOffered != nil {
+			prices = append(prices, tx.GasOffered.GasPrice)
+		}
+	}
+	w.fees = common.NewFeeEstimate(prices, first, last, minGasPrice)
+	return w.fees, nil
+}
+
+// GasOracle reports the gas prices of the transactions included in the last window layers. The
+// scanned layers are kept, so later calls only fetch the layers which arrived since.
+func (w *WalletBackend) GasOracle(ctx context.Context, window uint32) (*common.GasOracleReport, error) {
+	if window == 0 {
+		return nil, errors.New("the number of layers must be positive")
+	}
+	if w.oracle == nil || w.oracle.Window != window {
+		w.oracle = common.NewGasOracle(window)
+	}
+	info, err := w.GetMeshInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	first, last := w.oracle.Missing(info.CurrentLayer)
+	layers, err := w.Layers(ctx, first, last)
+	if err != nil {
+		return nil, err
+	}
+	for _, layer := range layers {
+		if layer.Number == nil {
+			continue
+		}
+		prices := make([]uint64, 0)
+		for _, tx := range layerTransactions(layer) {
+			if tx.GasOffered != nil {
+				prices = append(prices, tx.GasOffered.GasPrice)
+			}
+		}
+		w.oracle.Add(layer.Number.Number, prices)
+	}
+	return w.oracle.Report(minGasPrice), nil
+}
@@ -83,7 +83,17 @@ func (w *WalletBackend) getWallet() string {
 	thisDir = w.workingDirectory
 	for {
 
-		t := prompt.Input(">", completer)
+		var t string
+		if w.in != nil {
+			fmt.Print(">")
+			line, err := w.readLine()
+			if err != nil {
+				return ""
+			}
+			t = strings.TrimSpace(line)
+		} else {
+			t = prompt.Input(">", completer)
+		}
 		fi, err := os.Lstat(t)
 		if err != nil {
 			fmt.Println(err)
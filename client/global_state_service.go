@@ -2,37 +2,72 @@ package client
 
 import (
 	"context"
+	"time"
 
+	"github.com/golang/protobuf/proto"
 	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
 )
 
 // GlobalStateHash returns the current global state hash
-func (c *gRPCClient) GlobalStateHash() (*apitypes.GlobalStateHash, error) {
+func (c *gRPCClient) GlobalStateHash(ctx context.Context) (*apitypes.GlobalStateHash, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	gsc := c.getGlobalStateServiceClient()
-	if resp, err := gsc.GlobalStateHash(context.Background(), &apitypes.GlobalStateHashRequest{}); err != nil {
+	if resp, err := gsc.GlobalStateHash(ctx, &apitypes.GlobalStateHashRequest{}); err != nil {
 		return nil, err
 	} else {
 		return resp.Response, nil
 	}
 }
 
+// stateCompareTimeout is how long another server may take to connect and report its global state
+// hash, so a dead server doesn't stall a comparison
+const stateCompareTimeout = 5 * time.Second
+
+// ServerStateHash returns the global state hash of another server over a temporary connection,
+// which is closed before returning. The call doesn't fail over or retry.
+func (c *gRPCClient) ServerStateHash(ctx context.Context, server string) (*apitypes.GlobalStateHash, error) {
+	conn, err := c.dialServer(server, stateCompareTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, noFailoverKey{}, true), stateCompareTimeout)
+	defer cancel()
+	resp, err := apitypes.NewGlobalStateServiceClient(conn).GlobalStateHash(ctx, &apitypes.GlobalStateHashRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Response, nil
+}
+
 // AccountInfo returns basic account data such as balance and nonce from the global state
-func (c *gRPCClient) AccountState(address gosmtypes.Address) (*apitypes.Account, error) {
+func (c *gRPCClient) AccountState(ctx context.Context, address gosmtypes.Address) (*apitypes.Account, error) {
+	key := accountCacheKey(address)
+	if cached, ok := c.cached(ctx, key); ok {
+		return proto.Clone(cached.(*apitypes.Account)).(*apitypes.Account), nil
+	}
+	generation := c.cache.current()
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	gsc := c.getGlobalStateServiceClient()
-	resp, err := gsc.Account(context.Background(), &apitypes.AccountRequest{
+	resp, err := gsc.Account(ctx, &apitypes.AccountRequest{
 		AccountId: &apitypes.AccountId{Address: address.Bytes()}})
 	if err != nil {
 		return nil, err
 	}
 
+	c.cache.put(key, proto.Clone(resp.AccountWrapper), generation)
 	return resp.AccountWrapper, nil
 }
 
 // SmesherRewards returns rewards for a smesher identified by a smesher id
-func (c *gRPCClient) SmesherRewards(smesherId []byte, offset uint32, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+func (c *gRPCClient) SmesherRewards(ctx context.Context, smesherId []byte, offset uint32, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	gsc := c.getGlobalStateServiceClient()
-	resp, err := gsc.SmesherDataQuery(context.Background(), &apitypes.SmesherDataQueryRequest{
+	resp, err := gsc.SmesherDataQuery(ctx, &apitypes.SmesherDataQueryRequest{
 		SmesherId:  &apitypes.SmesherId{Id: smesherId},
 		MaxResults: maxResults,
 		Offset:     offset,
@@ -46,9 +81,11 @@ func (c *gRPCClient) SmesherRewards(smesherId []byte, offset uint32, maxResults
 }
 
 // AccountRewards returns rewards for an account
-func (c *gRPCClient) AccountRewards(address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+func (c *gRPCClient) AccountRewards(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	gsc := c.getGlobalStateServiceClient()
-	resp, err := gsc.AccountDataQuery(context.Background(), &apitypes.AccountDataQueryRequest{
+	resp, err := gsc.AccountDataQuery(ctx, &apitypes.AccountDataQueryRequest{
 		Filter: &apitypes.AccountDataFilter{
 			AccountId:        &apitypes.AccountId{Address: address.Bytes()},
 			AccountDataFlags: uint32(apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_REWARD),
@@ -74,33 +111,35 @@ func (c *gRPCClient) AccountRewards(address gosmtypes.Address, offset uint32, ma
 	return rewards, resp.TotalResults, nil
 }
 
-// getAccountStream returns an AccountDataStreamClient
-func (c *gRPCClient) getAccountStream(address gosmtypes.Address, flag apitypes.AccountDataFlag) (apitypes.GlobalStateService_AccountDataStreamClient, error) {
+// AccountDataStream returns a stream of the account data of an address of the types in flags,
+// apitypes.AccountDataFlag values or'ed together. The stream ends when ctx is done.
+func (c *gRPCClient) AccountDataStream(ctx context.Context, address gosmtypes.Address, flags apitypes.AccountDataFlag) (apitypes.GlobalStateService_AccountDataStreamClient, error) {
 	gsc := c.getGlobalStateServiceClient()
-	return gsc.AccountDataStream(context.Background(), &apitypes.AccountDataStreamRequest{
+	return gsc.AccountDataStream(ctx, &apitypes.AccountDataStreamRequest{
 		Filter: &apitypes.AccountDataFilter{
 			AccountId: &apitypes.AccountId{
 				Address: address.Bytes()},
-			AccountDataFlags: uint32(flag),
+			AccountDataFlags: uint32(flags),
 		},
 	})
 }
 
-// AccountRewardsStream returns a stream of account rewards
-func (c *gRPCClient) AccountRewardsStream(address gosmtypes.Address) (apitypes.GlobalStateService_AccountDataStreamClient, error) {
-	return c.getAccountStream(address, apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_REWARD)
-}
-
-// AccountRewardsStream returns a stream of account changes
-func (c *gRPCClient) AccountUpdatesStream(address gosmtypes.Address) (apitypes.GlobalStateService_AccountDataStreamClient, error) {
-	return c.getAccountStream(address, apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_ACCOUNT)
+// GlobalStateStream returns a stream of the global state hashes of the layers the node applies. The
+// stream ends when ctx is done.
+func (c *gRPCClient) GlobalStateStream(ctx context.Context) (apitypes.GlobalStateService_GlobalStateStreamClient, error) {
+	gsc := c.getGlobalStateServiceClient()
+	return gsc.GlobalStateStream(ctx, &apitypes.GlobalStateStreamRequest{
+		GlobalStateDataFlags: uint32(apitypes.GlobalStateDataFlag_GLOBAL_STATE_DATA_FLAG_GLOBAL_STATE_HASH),
+	})
 }
 
 // AccountTransactionsReceipts returns transaction receipts for an account
-func (c *gRPCClient) AccountTransactionsReceipts(address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.TransactionReceipt, uint32, error) {
+func (c *gRPCClient) AccountTransactionsReceipts(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.TransactionReceipt, uint32, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	gsc := c.getGlobalStateServiceClient()
 
-	resp, err := gsc.AccountDataQuery(context.Background(), &apitypes.AccountDataQueryRequest{
+	resp, err := gsc.AccountDataQuery(ctx, &apitypes.AccountDataQueryRequest{
 		Filter: &apitypes.AccountDataFilter{
 			AccountId:        &apitypes.AccountId{Address: address.Bytes()},
 			AccountDataFlags: uint32(apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_TRANSACTION_RECEIPT),
@@ -2,6 +2,10 @@ package client
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/fullstorydev/grpcurl"
@@ -9,63 +13,289 @@ import (
 
 	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/spacemeshos/smrepl/common"
 )
 
 const DefaultGRPCServer = "localhost:9092"
 const DefaultSecureConnection = false
 
+// todo: set release version in user agent
+const userAgent = "sm-cli-wallet/dev-build"
+
 type gRPCClient struct {
-	connection               *grpc.ClientConn
-	server                   string
-	secureConnection         bool
+	// mu guards the connection, the active server and the service clients, which change when the
+	// client fails over to another node
+	mu         sync.Mutex
+	connection *grpc.ClientConn
+	// servers are the node endpoints in order of preference, active is the index of the one in use
+	servers          []string
+	active           int
+	secureConnection bool
+	// authToken is sent as a bearer token with every call, empty when the node needs none
+	authToken string
+	// proxy is the SOCKS5 or HTTP proxy the node is reached through, nil to connect directly
+	proxy *url.URL
+	// limits are the sizes of the largest messages received from and sent to the node
+	limits                   common.MessageLimits
 	nodeServiceClient        apitypes.NodeServiceClient
 	debugServiceClient       apitypes.DebugServiceClient
 	meshServiceClient        apitypes.MeshServiceClient
 	globalStateServiceClient apitypes.GlobalStateServiceClient
 	transactionServiceClient apitypes.TransactionServiceClient
 	smesherServiceClient     apitypes.SmesherServiceClient
+	// params caches the network parameters, which don't change while the node runs. They are
+	// stale after switching to another server, and fetched again by the next call which needs them.
+	params      *common.NetInfo
+	paramsStale bool
+	// nodeVersion is the version reported by the active server, which picks the transaction format.
+	// Empty until NodeInfo succeeds and after switching to another server.
+	nodeVersion string
+	// callTimeout is the deadline of unary calls, 0 for none
+	callTimeout time.Duration
+	// verbose prints the retries of failed reads
+	verbose bool
+	// reachable tells whether the node answered the last call, accessed atomically
+	reachable int32
+	// cache keeps account states, the mesh info and the node status for a short time. It is
+	// emptied when the client switches to another server.
+	cache *stateCache
+	// metrics counts the calls of each method in the session
+	metrics callMetrics
+	// debug is 1 while the calls and streams are logged, accessed atomically
+	debug int32
 }
 
-func newGRPCClient(server string, secureConnection bool) *gRPCClient {
+func newGRPCClient(servers []string, secureConnection bool, authToken string, proxy *url.URL, limits common.MessageLimits) *gRPCClient {
 	return &gRPCClient{
-		nil,
-		server,
-		secureConnection,
-		nil,
-		nil,
-		nil,
-		nil,
-		nil,
-		nil,
+		servers:          servers,
+		secureConnection: secureConnection,
+		authToken:        authToken,
+		proxy:            proxy,
+		limits:           limits,
+		cache:            newStateCache(common.DefaultCacheTTL),
 	}
 }
 
+// Connect connects to the servers without waiting for them, so the wallet works while the node is
+// down. The first server is used right away, over a connection which is established by the first
+// call; calls wait for it until their deadline and fail with Unavailable when the server can't be
+// reached. With several servers, the first one which answers an echo call is looked for in the
+// background and replaces it.
 func (c *gRPCClient) Connect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.connection != nil {
 		_ = c.connection.Close()
+		c.connection = nil
 	}
 
-	var conn *grpc.ClientConn
-	var err error
+	conn, err := c.dialLazy(c.servers[0])
+	if err != nil {
+		return err
+	}
+	c.use(0, conn)
+	if len(c.servers) > 1 {
+		go c.selectServer(conn, append([]string(nil), c.servers...))
+	}
+	return nil
+}
+
+// selectServer switches from the initial connection to the first server which answers an echo
+// call. It keeps the connection when the first server answers, or when the connection was replaced
+// in the meantime.
+func (c *gRPCClient) selectServer(initial *grpc.ClientConn, servers []string) {
+	for i, server := range servers {
+		conn, err := c.dialHealthy(server)
+		if err != nil {
+			continue
+		}
+		c.mu.Lock()
+		if i == 0 || c.connection != initial {
+			c.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		c.use(i, conn)
+		c.mu.Unlock()
+		_ = initial.Close()
+		return
+	}
+}
+
+// SwitchServer connects to another server, which replaces the configured servers. The server must
+// answer an echo call and report its network parameters. Unless force is set, it must be on the
+// network of the current server when that is known. It returns the network parameters of the new
+// server.
+func (c *gRPCClient) SwitchServer(ctx context.Context, server string, force bool) (*common.NetInfo, error) {
+	conn, err := c.dialHealthy(server)
+	if err != nil {
+		return nil, err
+	}
+	healthCtx, cancel := healthContext()
+	defer cancel()
+	params, err := fetchNetworkParams(healthCtx, apitypes.NewMeshServiceClient(conn))
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if current, err := c.NetworkParams(ctx, false); err == nil && !force && !current.SameNetwork(params) {
+		_ = conn.Close()
+		return nil, &common.NetworkMismatchError{Server: server, Current: current, New: params}
+	}
+
+	c.mu.Lock()
+	previous := c.connection
+	c.servers = []string{server}
+	c.use(0, conn)
+	c.params = params
+	c.paramsStale = false
+	c.mu.Unlock()
+	if previous != nil {
+		_ = previous.Close()
+	}
+	result := *params
+	return &result, nil
+}
+
+// setCallSettings sets the timeout of unary calls and whether their retries and cache hits are
+// printed
+func (c *gRPCClient) setCallSettings(callTimeout time.Duration, verbose bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.callTimeout = callTimeout
+	c.verbose = verbose
+}
+
+// isVerbose tells whether the retries of calls and cache hits are printed
+func (c *gRPCClient) isVerbose() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.verbose
+}
+
+// cached returns a value of the state cache unless ctx bypasses it. Hits are printed in verbose
+// mode.
+func (c *gRPCClient) cached(ctx context.Context, key string) (interface{}, bool) {
+	if common.CacheBypassed(ctx) {
+		return nil, false
+	}
+	value, ok := c.cache.get(key)
+	if ok && c.isVerbose() {
+		fmt.Printf("cache hit: %s\n", key)
+	}
+	return value, ok
+}
+
+// InvalidateCache drops the cached account states, mesh info and node status, so the next calls
+// reach the node
+func (c *gRPCClient) InvalidateCache() {
+	c.cache.invalidateAll()
+}
+
+// callContext returns the context of a unary call made with ctx, which is cancelled with ctx and
+// times out after the call timeout
+func (c *gRPCClient) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	c.mu.Lock()
+	timeout := c.callTimeout
+	c.mu.Unlock()
+	if timeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// dialServer opens a connection to a server which fails over to the other servers
+func (c *gRPCClient) dialServer(server string, timeout time.Duration) (*grpc.ClientConn, error) {
 	if !c.secureConnection {
 		// simple grpc dial
-		conn, err = grpc.Dial(c.server, grpc.WithInsecure())
-	} else {
-		// secure connection without client cert or server cert validation
-		conn, err = c.dial(c.server)
+		return grpc.Dial(dialTarget(server), append(c.dialOptions(server), grpc.WithInsecure())...)
 	}
+	// secure connection without client cert or server cert validation
+	return c.dial(server, timeout)
+}
 
+// dialLazy opens a connection to a server which is established by the first call
+func (c *gRPCClient) dialLazy(server string) (*grpc.ClientConn, error) {
+	if !c.secureConnection {
+		return grpc.Dial(dialTarget(server), append(c.dialOptions(server), grpc.WithInsecure())...)
+	}
+	creds, err := grpcurl.ClientTransportCredentials(false, "", "", "")
 	if err != nil {
-		return err
+		return nil, err
+	}
+	opts := append(c.dialOptions(server), grpc.WithUserAgent(userAgent), grpc.WithTransportCredentials(creds))
+	return grpc.Dial(dialTarget(server), opts...)
+}
+
+// keepaliveTime is the idle time after which the client pings the node while streams are open, so
+// a connection dropped by a NAT or load balancer is detected and streams fail instead of going
+// quiet. It is the shortest interval gRPC servers accept by default.
+const keepaliveTime = 5 * time.Minute
+
+// keepaliveTimeout is how long the client waits for a ping to be answered before it closes the
+// connection
+const keepaliveTimeout = 20 * time.Second
+
+// dialTarget returns the gRPC dial target of a server
+func dialTarget(server string) string {
+	if _, ok := common.SocketPath(server); ok {
+		return socketTarget(server)
+	}
+	return server
+}
+
+// dialOptions are the dial options of the connections to server. Calls are counted for the session
+// statistics, their common errors get advice, whether the node answers is recorded, failed reads
+// are retried, calls failing with Unavailable fail over to the other servers, every call carries
+// the authorization header, calls are logged while debug logging is on and idle connections are
+// kept alive with pings. Unix sockets are dialed directly, other connections go through the proxy
+// when there is one, and messages are limited to the configured sizes.
+func (c *gRPCClient) dialOptions(server string) []grpc.DialOption {
+	opts := []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(c.metricsUnary, c.errorsUnary, c.reachabilityUnary, c.retryUnary, c.failoverUnary, c.authUnary, c.debugUnary),
+		grpc.WithChainStreamInterceptor(c.metricsStream, c.errorsStream, c.failoverStream, c.authStream, c.debugStream),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{Time: keepaliveTime, Timeout: keepaliveTimeout}),
 	}
+	if _, ok := common.SocketPath(server); ok {
+		opts = append(opts, grpc.WithContextDialer(dialSocket), grpc.WithAuthority(socketAuthority))
+	} else if c.proxy != nil {
+		opts = append(opts, grpc.WithContextDialer(c.dialProxy))
+	}
+	var callOpts []grpc.CallOption
+	if c.limits.Receive > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(c.limits.Receive))
+	}
+	if c.limits.Send > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(c.limits.Send))
+	}
+	if len(callOpts) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+	return opts
+}
+
+// use makes conn to the server at index active the connection of the client. The service clients
+// of the previous connection are dropped and the cached network parameters become stale. c.mu must
+// be held.
+func (c *gRPCClient) use(active int, conn *grpc.ClientConn) {
 	c.connection = conn
-	return nil
+	c.active = active
+	c.paramsStale = c.params != nil
+	c.nodeVersion = ""
+	c.cache.invalidateAll()
+	c.nodeServiceClient = nil
+	c.debugServiceClient = nil
+	c.meshServiceClient = nil
+	c.globalStateServiceClient = nil
+	c.transactionServiceClient = nil
+	c.smesherServiceClient = nil
 }
 
 // used in secure dial (tls)
-func (c *gRPCClient) dial(address string) (*grpc.ClientConn, error) {
-	dialTime := 60 * time.Second
-	ctx, cancel := context.WithTimeout(context.Background(), dialTime)
+func (c *gRPCClient) dial(address string, timeout time.Duration) (*grpc.ClientConn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	var creds credentials.TransportCredentials
@@ -75,10 +305,16 @@ func (c *gRPCClient) dial(address string) (*grpc.ClientConn, error) {
 		return nil, err
 	}
 
-	// todo: set release version in user agent
 	var opts []grpc.DialOption
-	opts = append(opts, grpc.WithUserAgent("sm-cli-wallet/dev-build"))
+	opts = append(opts, grpc.WithUserAgent(userAgent))
+	opts = append(opts, c.dialOptions(address)...)
 
+	if _, ok := common.SocketPath(address); ok || c.proxy != nil {
+		// grpcurl dials the address directly, so the socket and proxy dialers are kept with a plain
+		// blocking dial
+		opts = append(opts, grpc.WithTransportCredentials(creds), grpc.WithBlock(), grpc.FailOnNonTempDialError(true))
+		return grpc.DialContext(ctx, dialTarget(address), opts...)
+	}
 	cc, err := grpcurl.BlockingDial(ctx, "tcp", address, creds, opts...)
 	if err != nil {
 		return nil, err
@@ -87,21 +323,55 @@ func (c *gRPCClient) dial(address string) (*grpc.ClientConn, error) {
 	return cc, nil
 }
 
+// Close closes the connection, which stops the background search for a server which answers
 func (c *gRPCClient) Close() error {
-	if c.connection != nil {
-		return c.connection.Close()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connection == nil {
+		return nil
 	}
-	return nil
+	err := c.connection.Close()
+	c.connection = nil
+	return err
+}
+
+// ActiveServer returns the host and port of the server in use, or its unix:// socket
+func (c *gRPCClient) ActiveServer() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.servers[c.active]
 }
 
-//ServerInfo
+// ServerInfo returns the active server, the connection security and the standby servers
 func (c *gRPCClient) ServerInfo() string {
-	s := c.server + " (GRPC API 1.1)"
-	if c.secureConnection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name := c.servers[c.active]
+	path, socket := common.SocketPath(name)
+	if socket {
+		name = "socket " + path
+	}
+	s := name + " (GRPC API 1.1)"
+	switch {
+	case c.secureConnection:
 		s += ". Secure Connection."
-	} else {
+	case socket:
+		s += ". Local Unix socket connection."
+	default:
 		s += ". >> Insecure Connection. Use only with a local trusted server <<"
 	}
+	if c.authToken != "" {
+		s += " Authenticated with a token."
+	}
+	var standbys []string
+	for i, server := range c.servers {
+		if i != c.active {
+			standbys = append(standbys, server)
+		}
+	}
+	if len(standbys) > 0 {
+		s += " Standby: " + strings.Join(standbys, ", ")
+	}
 
 	return s
 }
@@ -109,6 +379,8 @@ func (c *gRPCClient) ServerInfo() string {
 //// services clients
 
 func (c *gRPCClient) getNodeServiceClient() apitypes.NodeServiceClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.nodeServiceClient == nil {
 		c.nodeServiceClient = apitypes.NewNodeServiceClient(c.connection)
 	}
@@ -116,6 +388,8 @@ func (c *gRPCClient) getNodeServiceClient() apitypes.NodeServiceClient {
 }
 
 func (c *gRPCClient) getDebugServiceClient() apitypes.DebugServiceClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.debugServiceClient == nil {
 		c.debugServiceClient = apitypes.NewDebugServiceClient(c.connection)
 	}
@@ -123,6 +397,8 @@ func (c *gRPCClient) getDebugServiceClient() apitypes.DebugServiceClient {
 }
 
 func (c *gRPCClient) getMeshServiceClient() apitypes.MeshServiceClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.meshServiceClient == nil {
 		c.meshServiceClient = apitypes.NewMeshServiceClient(c.connection)
 	}
@@ -130,6 +406,8 @@ func (c *gRPCClient) getMeshServiceClient() apitypes.MeshServiceClient {
 }
 
 func (c *gRPCClient) getGlobalStateServiceClient() apitypes.GlobalStateServiceClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.globalStateServiceClient == nil {
 		c.globalStateServiceClient = apitypes.NewGlobalStateServiceClient(c.connection)
 	}
@@ -138,6 +416,8 @@ func (c *gRPCClient) getGlobalStateServiceClient() apitypes.GlobalStateServiceCl
 }
 
 func (c *gRPCClient) getTransactionServiceClient() apitypes.TransactionServiceClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.transactionServiceClient == nil {
 		c.transactionServiceClient = apitypes.NewTransactionServiceClient(c.connection)
 	}
@@ -145,6 +425,8 @@ func (c *gRPCClient) getTransactionServiceClient() apitypes.TransactionServiceCl
 }
 
 func (c *gRPCClient) getSmesherServiceClient() apitypes.SmesherServiceClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.smesherServiceClient == nil {
 		c.smesherServiceClient = apitypes.NewSmesherServiceClient(c.connection)
 	}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// SetGRPCDebug starts or stops logging the calls and streams of the node API at debug level. It
+// takes effect on the calls in flight: their remaining events are logged from then on, or not.
+func (c *gRPCClient) SetGRPCDebug(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&c.debug, v)
+}
+
+// GRPCDebug tells whether the calls and streams of the node API are logged
+func (c *gRPCClient) GRPCDebug() bool {
+	return atomic.LoadInt32(&c.debug) == 1
+}
+
+// debugUnary logs the method, request, response size, status and latency of the calls while debug
+// logging is on. It is the innermost interceptor, so each attempt of retried and failed over calls
+// is logged.
+func (c *gRPCClient) debugUnary(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !c.GRPCDebug() {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if !c.GRPCDebug() {
+		return err
+	}
+	if err != nil {
+		log.Debug("grpc %s %s: %s in %v: %s", method, summarizeMessage(req), status.Code(err), time.Since(start), status.Convert(err).Message())
+	} else {
+		log.Debug("grpc %s %s: %s in %v, response %d bytes", method, summarizeMessage(req), status.Code(err), time.Since(start), messageSize(reply))
+	}
+	return err
+}
+
+// debugStream logs when the streams are subscribed to, their first message and their end while
+// debug logging is on
+func (c *gRPCClient) debugStream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	start := time.Now()
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		if c.GRPCDebug() {
+			log.Debug("grpc stream %s: subscribing failed with %s in %v: %s", method, status.Code(err), time.Since(start), status.Convert(err).Message())
+		}
+		return nil, err
+	}
+	return &debugClientStream{ClientStream: stream, client: c, method: method, start: time.Now()}, nil
+}
+
+// debugClientStream logs the request of a stream, its first message and its end. A stream is used
+// by one goroutine, which sends the request and then receives the messages.
+type debugClientStream struct {
+	grpc.ClientStream
+	client   *gRPCClient
+	method   string
+	start    time.Time
+	messages int
+	closed   bool
+}
+
+func (s *debugClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if s.client.GRPCDebug() {
+		log.Debug("grpc stream %s: subscribed with %s", s.method, summarizeMessage(m))
+	}
+	return err
+}
+
+func (s *debugClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		s.messages++
+		if s.messages == 1 && s.client.GRPCDebug() {
+			log.Debug("grpc stream %s: first message of %d bytes after %v", s.method, messageSize(m), time.Since(s.start))
+		}
+		return err
+	}
+	if !s.closed && s.client.GRPCDebug() {
+		code := status.Code(err)
+		if err == io.EOF {
+			code = status.Code(nil)
+		}
+		log.Debug("grpc stream %s: closed with %s after %d messages in %v", s.method, code, s.messages, time.Since(s.start))
+	}
+	s.closed = true
+	return err
+}
+
+// redactedFields are the parts of field names whose values aren't logged
+var redactedFields = []string{"key", "signature", "secret", "seed", "mnemonic", "password"}
+
+// maxLoggedBytes is the size of the largest bytes field logged in hex, longer ones are logged with
+// their size only. Raw transactions, which include their signature, are longer.
+const maxLoggedBytes = 32
+
+// messageSize returns the size of the wire encoding of a message, 0 if it isn't a protobuf message
+func messageSize(m interface{}) int {
+	if msg, ok := m.(proto.Message); ok {
+		return proto.Size(msg)
+	}
+	return 0
+}
+
+// summarizeMessage returns the fields of a message for the log, with the keys and signatures
+// redacted, the long bytes fields replaced by their size and the lists by their length
+func summarizeMessage(m interface{}) string {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return fmt.Sprintf("%T", m)
+	}
+	return summarizeReflect(proto.MessageReflect(msg))
+}
+
+func summarizeReflect(m protoreflect.Message) string {
+	var fields []string
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		fields = append(fields, string(fd.Name())+"="+summarizeValue(fd, v))
+		return true
+	})
+	return "{" + strings.Join(fields, " ") + "}"
+}
+
+func summarizeValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	name := strings.ToLower(string(fd.Name()))
+	for _, redacted := range redactedFields {
+		if strings.Contains(name, redacted) {
+			return "[redacted]"
+		}
+	}
+	switch {
+	case fd.IsList():
+		return fmt.Sprintf("[%d items]", v.List().Len())
+	case fd.IsMap():
+		return fmt.Sprintf("[%d entries]", v.Map().Len())
+	}
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return summarizeReflect(v.Message())
+	case protoreflect.BytesKind:
+		if len(v.Bytes()) > maxLoggedBytes {
+			return fmt.Sprintf("[%d bytes]", len(v.Bytes()))
+		}
+		return "0x" + hex.EncodeToString(v.Bytes())
+	case protoreflect.EnumKind:
+		if value := fd.Enum().Values().ByNumber(v.Enum()); value != nil {
+			return string(value.Name())
+		}
+	case protoreflect.StringKind:
+		return fmt.Sprintf("%q", v.String())
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"testing"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+)
+
+func TestSummarizeMessage(t *testing.T) {
+	signature := bytes.Repeat([]byte{0xab}, 64)
+	tx := &apitypes.Transaction{
+		Id:        &apitypes.TransactionId{Id: bytes.Repeat([]byte{0x01}, 32)},
+		Sender:    &apitypes.AccountId{Address: bytes.Repeat([]byte{0x02}, 20)},
+		Counter:   3,
+		Signature: &apitypes.Signature{Scheme: apitypes.Signature_SCHEME_ED25519_PLUS_PLUS, Signature: signature, PublicKey: []byte{0xcd}},
+	}
+	summary := summarizeMessage(tx)
+	for _, want := range []string{"id={id=0x" + strings.Repeat("01", 32) + "}", "sender={address=0x" + strings.Repeat("02", 20) + "}", "counter=3", "signature=[redacted]"} {
+		if !strings.Contains(summary, want) {
+			t.Fatalf("expected %q in the summary, got %s", want, summary)
+		}
+	}
+	if strings.Contains(summary, hex.EncodeToString(signature)) || strings.Contains(summary, "0xcd") {
+		t.Fatalf("expected the signature and the key to be redacted, got %s", summary)
+	}
+
+	raw := &apitypes.SubmitTransactionRequest{Transaction: append(bytes.Repeat([]byte{0x05}, 56), signature...)}
+	if summary := summarizeMessage(raw); summary != "{transaction=[120 bytes]}" {
+		t.Fatalf("expected a raw transaction to be logged with its size only, got %s", summary)
+	}
+}
+
+// TestGRPCDebugToggle turns the logging on and off while calls are made, which go test -race
+// checks for unsynchronized access
+func TestGRPCDebugToggle(t *testing.T) {
+	client, _, stop := startFlakyNode(t, 0)
+	defer stop()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			if _, err := client.NodeStatus(context.Background()); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			client.SetGRPCDebug(i%2 == 0)
+		}
+	}()
+	wg.Wait()
+
+	client.SetGRPCDebug(true)
+	if !client.GRPCDebug() {
+		t.Fatal("expected the logging to be on")
+	}
+	if _, err := client.NodeStatus(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	client.SetGRPCDebug(false)
+	if client.GRPCDebug() {
+		t.Fatal("expected the logging to be off")
+	}
+}
@@ -4,15 +4,18 @@ import (
 	"context"
 
 	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
 
 	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
 )
 
 // GetMeshTransactions returns the transactions on the mesh to or from an address.
-func (c *gRPCClient) GetMeshTransactions(address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Transaction, uint32, error) {
+func (c *gRPCClient) GetMeshTransactions(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Transaction, uint32, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	ms := c.getMeshServiceClient()
-	resp, err := ms.AccountMeshDataQuery(context.Background(), &apitypes.AccountMeshDataQueryRequest{
+	resp, err := ms.AccountMeshDataQuery(ctx, &apitypes.AccountMeshDataQueryRequest{
 		Filter: &apitypes.AccountMeshDataFilter{
 			AccountId:            &apitypes.AccountId{Address: address.Bytes()},
 			AccountMeshDataFlags: uint32(apitypes.AccountMeshDataFlag_ACCOUNT_MESH_DATA_FLAG_TRANSACTIONS),
@@ -46,10 +49,12 @@ func (c *gRPCClient) GetMeshTransactions(address gosmtypes.Address, offset uint3
 }
 
 // GetMeshActivations returns activations where the address is the coinbase
-func (c *gRPCClient) GetMeshActivations(address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Activation, uint32, error) {
+func (c *gRPCClient) GetMeshActivations(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Activation, uint32, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	ms := c.getMeshServiceClient()
 
-	resp, err := ms.AccountMeshDataQuery(context.Background(), &apitypes.AccountMeshDataQueryRequest{
+	resp, err := ms.AccountMeshDataQuery(ctx, &apitypes.AccountMeshDataQueryRequest{
 		Filter: &apitypes.AccountMeshDataFilter{
 			AccountId:            &apitypes.AccountId{Address: address.Bytes()},
 			AccountMeshDataFlags: uint32(apitypes.AccountMeshDataFlag_ACCOUNT_MESH_DATA_FLAG_ACTIVATIONS),
@@ -75,51 +80,151 @@ func (c *gRPCClient) GetMeshActivations(address gosmtypes.Address, offset uint32
 	return activations, resp.TotalResults, nil
 }
 
-func (c *gRPCClient) GetMeshInfo() (*common.NetInfo, error) {
+// NetworkParams returns the network id, genesis time, layer and epoch parameters and the
+// transaction rate of the network. They are fetched once and cached, refresh fetches them again.
+// They are fetched again after switching to another server as well, with a warning when the
+// server is on another network.
+func (c *gRPCClient) NetworkParams(ctx context.Context, refresh bool) (*common.NetInfo, error) {
+	c.mu.Lock()
+	cached, stale, server := c.params, c.paramsStale, c.servers[c.active]
+	c.mu.Unlock()
+	if cached != nil && !stale && !refresh {
+		params := *cached
+		return &params, nil
+	}
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	netInfo, err := fetchNetworkParams(ctx, c.getMeshServiceClient())
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.params = netInfo
+	c.paramsStale = false
+	c.mu.Unlock()
+	if cached != nil && !cached.SameNetwork(netInfo) {
+		log.Warning("%v", &common.NetworkMismatchError{Server: server, Current: cached, New: netInfo})
+	}
+	params := *netInfo
+	return &params, nil
+}
+
+// fetchNetworkParams asks a node for the network parameters
+func fetchNetworkParams(ctx context.Context, ms apitypes.MeshServiceClient) (*common.NetInfo, error) {
 	netInfo := &common.NetInfo{}
-	ms := c.getMeshServiceClient()
 
-	res, err := ms.GenesisTime(context.Background(), &apitypes.GenesisTimeRequest{})
+	res, err := ms.GenesisTime(ctx, &apitypes.GenesisTimeRequest{})
 	if err != nil {
 		return nil, err
 	}
 	netInfo.GenesisTime = res.Unixtime.Value
 
-	currLayer, err := ms.CurrentLayer(context.Background(), &apitypes.CurrentLayerRequest{})
+	netId, err := ms.NetID(ctx, &apitypes.NetIDRequest{})
 	if err != nil {
 		return nil, err
 	}
-	netInfo.CurrentLayer = currLayer.Layernum.Number
+	netInfo.NetId = netId.Netid.Value
 
-	epochNum, err := ms.CurrentEpoch(context.Background(), &apitypes.CurrentEpochRequest{})
+	layersPerEpoch, err := ms.EpochNumLayers(ctx, &apitypes.EpochNumLayersRequest{})
 	if err != nil {
 		return nil, err
 	}
-	netInfo.CurrentEpoch = epochNum.Epochnum.Value
+	netInfo.LayerPerEpoch = layersPerEpoch.Numlayers.Value
 
-	netId, err := ms.NetID(context.Background(), &apitypes.NetIDRequest{})
+	layerDuration, err := ms.LayerDuration(ctx, &apitypes.LayerDurationRequest{})
 	if err != nil {
 		return nil, err
 	}
-	netInfo.NetId = netId.Netid.Value
+	netInfo.LayerDuration = layerDuration.Duration.Value
 
-	layersPerEpoch, err := ms.EpochNumLayers(context.Background(), &apitypes.EpochNumLayersRequest{})
+	maxTxsPerSec, err := ms.MaxTransactionsPerSecond(ctx, &apitypes.MaxTransactionsPerSecondRequest{})
 	if err != nil {
 		return nil, err
 	}
-	netInfo.LayerPerEpoch = layersPerEpoch.Numlayers.Value
+	netInfo.MaxTxsPerSec = maxTxsPerSec.Maxtxpersecond.Value
 
-	layerDuration, err := ms.LayerDuration(context.Background(), &apitypes.LayerDurationRequest{})
+	return netInfo, nil
+}
+
+// GetMeshInfo returns the network parameters with the current layer and epoch
+func (c *gRPCClient) GetMeshInfo(ctx context.Context) (*common.NetInfo, error) {
+	if cached, ok := c.cached(ctx, meshInfoCacheKey); ok {
+		info := *cached.(*common.NetInfo)
+		return &info, nil
+	}
+	generation := c.cache.current()
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	netInfo, err := c.NetworkParams(ctx, false)
 	if err != nil {
 		return nil, err
 	}
-	netInfo.LayerDuration = layerDuration.Duration.Value
+	ms := c.getMeshServiceClient()
 
-	maxTxsPerSec, err := ms.MaxTransactionsPerSecond(context.Background(), &apitypes.MaxTransactionsPerSecondRequest{})
+	currLayer, err := ms.CurrentLayer(ctx, &apitypes.CurrentLayerRequest{})
 	if err != nil {
 		return nil, err
 	}
-	netInfo.MaxTxsPerSec = maxTxsPerSec.Maxtxpersecond.Value
+	netInfo.CurrentLayer = currLayer.Layernum.Number
+
+	epochNum, err := ms.CurrentEpoch(ctx, &apitypes.CurrentEpochRequest{})
+	if err != nil {
+		return nil, err
+	}
+	netInfo.CurrentEpoch = epochNum.Epochnum.Value
 
+	cached := *netInfo
+	c.cache.put(meshInfoCacheKey, &cached, generation)
 	return netInfo, nil
 }
+
+// Layers returns layers first to last with their blocks
+func (c *gRPCClient) Layers(ctx context.Context, first, last uint32) ([]*apitypes.Layer, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	ms := c.getMeshServiceClient()
+	resp, err := ms.LayersQuery(ctx, &apitypes.LayersQueryRequest{
+		StartLayer: &apitypes.LayerNumber{Number: first},
+		EndLayer:   &apitypes.LayerNumber{Number: last},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Layer, nil
+}
+
+// LayerStream returns a stream of the layers of the mesh as their status changes. The stream ends
+// when ctx is done.
+func (c *gRPCClient) LayerStream(ctx context.Context) (apitypes.MeshService_LayerStreamClient, error) {
+	ms := c.getMeshServiceClient()
+	return ms.LayerStream(ctx, &apitypes.LayerStreamRequest{})
+}
+
+// layerTransactions returns the transactions included in the blocks of a layer
+func layerTransactions(layer *apitypes.Layer) []*apitypes.Transaction {
+	txsMap := make(map[string]bool)
+	txs := make([]*apitypes.Transaction, 0)
+	for _, block := range layer.Blocks {
+		for _, tx := range block.Transactions {
+			// a transaction may be included in more than one block of a layer
+			if !txsMap[string(tx.Id.Id)] {
+				txsMap[string(tx.Id.Id)] = true
+				txs = append(txs, tx)
+			}
+		}
+	}
+	return txs
+}
+
+// LayerTransactions returns the transactions included in the blocks of layers first to last
+func (c *gRPCClient) LayerTransactions(ctx context.Context, first, last uint32) ([]*apitypes.Transaction, error) {
+	layers, err := c.Layers(ctx, first, last)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]*apitypes.Transaction, 0)
+	for _, layer := range layers {
+		txs = append(txs, layerTransactions(layer)...)
+	}
+	return txs, nil
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"google.golang.org/grpc"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// paramsMeshService reports the network parameters and counts how often they are fetched
+type paramsMeshService struct {
+	apitypes.UnimplementedMeshServiceServer
+	fetches int32
+}
+
+func (s *paramsMeshService) GenesisTime(context.Context, *apitypes.GenesisTimeRequest) (*apitypes.GenesisTimeResponse, error) {
+	atomic.AddInt32(&s.fetches, 1)
+	return &apitypes.GenesisTimeResponse{Unixtime: &apitypes.SimpleInt{Value: 1600000000}}, nil
+}
+
+func (s *paramsMeshService) NetID(context.Context, *apitypes.NetIDRequest) (*apitypes.NetIDResponse, error) {
+	return &apitypes.NetIDResponse{Netid: &apitypes.SimpleInt{Value: 7}}, nil
+}
+
+func (s *paramsMeshService) EpochNumLayers(context.Context, *apitypes.EpochNumLayersRequest) (*apitypes.EpochNumLayersResponse, error) {
+	return &apitypes.EpochNumLayersResponse{Numlayers: &apitypes.SimpleInt{Value: 288}}, nil
+}
+
+func (s *paramsMeshService) LayerDuration(context.Context, *apitypes.LayerDurationRequest) (*apitypes.LayerDurationResponse, error) {
+	return &apitypes.LayerDurationResponse{Duration: &apitypes.SimpleInt{Value: 300}}, nil
+}
+
+func (s *paramsMeshService) MaxTransactionsPerSecond(context.Context, *apitypes.MaxTransactionsPerSecondRequest) (*apitypes.MaxTransactionsPerSecondResponse, error) {
+	return &apitypes.MaxTransactionsPerSecondResponse{Maxtxpersecond: &apitypes.SimpleInt{Value: 10}}, nil
+}
+
+func TestNetworkParamsCache(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := &paramsMeshService{}
+	server := grpc.NewServer()
+	apitypes.RegisterMeshServiceServer(server, service)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	client := newGRPCClient([]string{listener.Addr().String()}, false, "", nil, common.MessageLimits{})
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	fetch := func() {
+		t.Helper()
+		if params, err := client.NetworkParams(context.Background(), false); err != nil || params.NetId != 7 {
+			t.Fatalf("expected the network parameters, got %v, %v", params, err)
+		}
+	}
+	fetch()
+	fetch()
+	if fetches := atomic.LoadInt32(&service.fetches); fetches != 1 {
+		t.Fatalf("expected the parameters to be cached, fetched %d times", fetches)
+	}
+	if _, err := client.NetworkParams(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	if fetches := atomic.LoadInt32(&service.fetches); fetches != 2 {
+		t.Fatalf("expected refresh to fetch the parameters, fetched %d times", fetches)
+	}
+
+	// switching servers makes the cached parameters stale
+	client.mu.Lock()
+	client.use(0, client.connection)
+	client.mu.Unlock()
+	fetch()
+	fetch()
+	if fetches := atomic.LoadInt32(&service.fetches); fetches != 3 {
+		t.Fatalf("expected the parameters to be fetched once after switching, fetched %d times", fetches)
+	}
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// callMetrics counts the calls, errors and latency of each gRPC method in the session. It is
+// shared by the connections to all the servers, and used concurrently by commands, streams and
+// background probes.
+type callMetrics struct {
+	mu      sync.Mutex
+	methods map[string]*common.CallStats
+}
+
+// record adds a call of a method. Calls cancelled by the user aren't errors.
+func (m *callMetrics) record(method string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.methods == nil {
+		m.methods = make(map[string]*common.CallStats)
+	}
+	stats, ok := m.methods[method]
+	if !ok {
+		stats = &common.CallStats{Method: method}
+		m.methods[method] = stats
+	}
+	stats.Calls++
+	stats.Total += latency
+	if latency > stats.Max {
+		stats.Max = latency
+	}
+	if failed(err) {
+		stats.Errors++
+	}
+}
+
+// recordError adds an error to a method without a call, for streams which fail after they opened
+func (m *callMetrics) recordError(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stats, ok := m.methods[method]; ok {
+		stats.Errors++
+	}
+}
+
+// snapshot returns a copy of the statistics, the costliest methods first
+func (m *callMetrics) snapshot() []common.CallStats {
+	m.mu.Lock()
+	res := make([]common.CallStats, 0, len(m.methods))
+	for _, stats := range m.methods {
+		res = append(res, *stats)
+	}
+	m.mu.Unlock()
+	common.SortCallStats(res)
+	return res
+}
+
+func (m *callMetrics) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.methods = nil
+}
+
+// failed tells whether a call ended with an error other than a cancellation or the end of a stream
+func failed(err error) bool {
+	return err != nil && err != io.EOF && !errors.Is(err, context.Canceled) && status.Code(err) != codes.Canceled
+}
+
+// metricsUnary records the calls of the wallet. It is the outermost interceptor, so retries and
+// failovers are part of the latency of a call.
+func (c *gRPCClient) metricsUnary(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	c.metrics.record(method, time.Since(start), err)
+	return err
+}
+
+// metricsStream records the streams of the wallet when they are opened, and their first error
+func (c *gRPCClient) metricsStream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	start := time.Now()
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	c.metrics.record(method, time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsClientStream{ClientStream: stream, metrics: &c.metrics, method: method}, nil
+}
+
+// metricsClientStream counts the error which ends a stream
+type metricsClientStream struct {
+	grpc.ClientStream
+	metrics *callMetrics
+	method  string
+	failed  bool
+}
+
+func (s *metricsClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if !s.failed && failed(err) {
+		s.failed = true
+		s.metrics.recordError(s.method)
+	}
+	return err
+}
+
+// CallStats returns the calls, errors and latency of the gRPC methods called in the session, the
+// methods with the highest total latency first
+func (c *gRPCClient) CallStats() []common.CallStats {
+	return c.metrics.snapshot()
+}
+
+// ResetCallStats clears the statistics of the gRPC calls
+func (c *gRPCClient) ResetCallStats() {
+	c.metrics.reset()
+}
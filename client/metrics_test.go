@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+func findCallStats(stats []common.CallStats, method string) (common.CallStats, bool) {
+	for _, s := range stats {
+		if s.Method == method {
+			return s, true
+		}
+	}
+	return common.CallStats{}, false
+}
+
+func TestCallMetricsRecord(t *testing.T) {
+	var m callMetrics
+	m.record("a", 10*time.Millisecond, nil)
+	m.record("a", 30*time.Millisecond, status.Error(codes.Unavailable, "down"))
+	m.record("a", 20*time.Millisecond, status.Error(codes.Canceled, "cancelled"))
+	m.record("a", 20*time.Millisecond, context.Canceled)
+	m.record("b", time.Second, io.EOF)
+	m.recordError("b")
+	m.recordError("unknown")
+
+	stats := m.snapshot()
+	if len(stats) != 2 || stats[0].Method != "b" || stats[1].Method != "a" {
+		t.Fatalf("expected the methods by descending total latency, got %+v", stats)
+	}
+	a := stats[1]
+	// cancelled calls aren't errors
+	if a.Calls != 4 || a.Errors != 1 || a.Max != 30*time.Millisecond || a.Avg() != 20*time.Millisecond {
+		t.Fatalf("expected 4 calls, 1 error, max 30ms and avg 20ms, got %+v avg %v", a, a.Avg())
+	}
+	if b := stats[0]; b.Calls != 1 || b.Errors != 1 {
+		t.Fatalf("expected the end of a stream not to be an error and the later error to be, got %+v", b)
+	}
+
+	m.reset()
+	if stats := m.snapshot(); len(stats) != 0 {
+		t.Fatalf("expected no statistics after a reset, got %+v", stats)
+	}
+}
+
+func TestCallMetricsConcurrent(t *testing.T) {
+	var m callMetrics
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				var err error
+				if j%10 == 0 {
+					err = errors.New("failed")
+				}
+				m.record("method", time.Duration(i+1)*time.Millisecond, err)
+				m.snapshot()
+			}
+		}(i)
+	}
+	wg.Wait()
+	s, _ := findCallStats(m.snapshot(), "method")
+	if s.Calls != 800 || s.Errors != 80 || s.Max != 8*time.Millisecond {
+		t.Fatalf("expected 800 calls, 80 errors and max 8ms, got %+v", s)
+	}
+}
+
+func TestCallMetricsInterceptor(t *testing.T) {
+	client, _, stop := startFlakyNode(t, readAttempts)
+	defer stop()
+
+	if _, err := client.NodeStatus(context.Background()); err == nil {
+		t.Fatal("expected the status call to fail")
+	}
+	if _, err := client.SubmitCoinTransaction(context.Background(), []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := client.CallStats()
+	// retries are part of a call
+	if s, ok := findCallStats(stats, "/spacemesh.v1.NodeService/Status"); !ok || s.Calls != 1 || s.Errors != 1 {
+		t.Fatalf("expected 1 failed status call, got %+v", stats)
+	}
+	if s, ok := findCallStats(stats, "/spacemesh.v1.TransactionService/SubmitTransaction"); !ok || s.Calls != 1 || s.Errors != 0 {
+		t.Fatalf("expected 1 successful submission, got %+v", stats)
+	}
+
+	client.ResetCallStats()
+	if stats := client.CallStats(); len(stats) != 0 {
+		t.Fatalf("expected no statistics after a reset, got %+v", stats)
+	}
+}
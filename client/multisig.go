@@ -0,0 +1,90 @@
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// multisigBook returns the multisig accounts stored in the wallets directory
+func (w *WalletBackend) multisigBook() (*common.MultisigBook, error) {
+	if w.multisig == nil {
+		book, err := common.LoadMultisigBook(filepath.Join(w.workingDirectory, common.MultisigFileName))
+		if err != nil {
+			return nil, err
+		}
+		w.multisig = book
+	}
+	return w.multisig, nil
+}
+
+// MultisigAccounts returns the multisig accounts sorted by name
+func (w *WalletBackend) MultisigAccounts() ([]common.MultisigAccount, error) {
+	book, err := w.multisigBook()
+	if err != nil {
+		return nil, err
+	}
+	return book.Accounts, nil
+}
+
+// MultisigAccount returns the multisig account with the provided name
+func (w *WalletBackend) MultisigAccount(name string) (*common.MultisigAccount, error) {
+	book, err := w.multisigBook()
+	if err != nil {
+		return nil, err
+	}
+	account, ok := book.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("no multisig account named %s", name)
+	}
+	return account, nil
+}
+
+// AddMultisigAccount adds a multisig account and saves the multisig accounts file
+func (w *WalletBackend) AddMultisigAccount(account *common.MultisigAccount) error {
+	book, err := w.multisigBook()
+	if err != nil {
+		return err
+	}
+	if err := book.Add(account); err != nil {
+		return err
+	}
+	return book.Save()
+}
+
+// MultisigTransaction returns the serialized transaction of a multisig envelope with enough
+// signatures. It can't be submitted, no node accepts multisig transactions.
+func (w *WalletBackend) MultisigTransaction(e *common.MultisigEnvelope) ([]byte, error) {
+	if err := e.Verify(); err != nil {
+		return nil, err
+	}
+	if !e.Complete() {
+		return nil, fmt.Errorf("the transaction has %d of the %d required signatures", len(e.Signatures), e.Threshold)
+	}
+	txBytes, err := e.TxBytes()
+	if err != nil {
+		return nil, err
+	}
+	inner, err := w.DecodeTransaction(txBytes)
+	if err != nil {
+		return nil, err
+	}
+	tx := common.SerializableMultisigTransaction{InnerSerializableSignedTransaction: *inner, Threshold: uint32(e.Threshold)}
+	for _, s := range e.Signatures {
+		var sig common.SerializableMultisigSignature
+		signer, err := hex.DecodeString(s.Signer)
+		if err != nil || len(signer) != len(sig.Signer) {
+			return nil, fmt.Errorf("invalid signer %s of the multisig transaction", s.Signer)
+		}
+		signature, err := hex.DecodeString(s.Signature)
+		if err != nil || len(signature) != len(sig.Signature) {
+			return nil, fmt.Errorf("invalid signature of %s in the multisig transaction", s.Signer)
+		}
+		copy(sig.Signer[:], signer)
+		copy(sig.Signature[:], signature)
+		tx.Signatures = append(tx.Signatures, sig)
+	}
+	return interfaceToBytes(&tx)
+}
@@ -2,20 +2,37 @@ package client
 
 import (
 	"errors"
+	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/empty"
 	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"github.com/spacemeshos/smrepl/common"
 	"golang.org/x/net/context"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 // Echo is a basic api sanity test. It verifies that the client can connect to
 // the node service and get a response from it to an echo request.
 // todo: change this to api health-check service as node service might not be available
-func (c *gRPCClient) Echo() error {
-	service := c.getNodeServiceClient()
+func (c *gRPCClient) Echo(ctx context.Context) error {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	return echo(ctx, c.getNodeServiceClient())
+}
+
+// EchoTimeout calls the node echo service and fails when it doesn't answer within timeout
+func (c *gRPCClient) EchoTimeout(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return echo(ctx, c.getNodeServiceClient())
+}
+
+// echo calls the echo service of a node and checks its response
+func echo(ctx context.Context, service apitypes.NodeServiceClient) error {
 	const msg = "hello spacemesh"
-	resp, err := service.Echo(context.Background(), &apitypes.EchoRequest{
+	resp, err := service.Echo(ctx, &apitypes.EchoRequest{
 		Msg: &apitypes.SimpleString{Value: msg}})
 
 	if err != nil {
@@ -30,16 +47,21 @@ func (c *gRPCClient) Echo() error {
 }
 
 // NodeInfo returns static node info such as build, version and api server url
-func (c *gRPCClient) NodeInfo() (*common.NodeInfo, error) {
+func (c *gRPCClient) NodeInfo(ctx context.Context) (*common.NodeInfo, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	info := &common.NodeInfo{}
 	s := c.getNodeServiceClient()
-	resp, err := s.Version(context.Background(), &empty.Empty{})
+	resp, err := s.Version(ctx, &empty.Empty{})
 	if err != nil {
 		return nil, err
 	}
 	info.Version = resp.VersionString.Value
+	c.mu.Lock()
+	c.nodeVersion = info.Version
+	c.mu.Unlock()
 
-	resp1, err := s.Build(context.Background(), &empty.Empty{})
+	resp1, err := s.Build(ctx, &empty.Empty{})
 	if err != nil {
 		return nil, err
 	}
@@ -48,13 +70,69 @@ func (c *gRPCClient) NodeInfo() (*common.NodeInfo, error) {
 	return info, nil
 }
 
+// knownNodeVersion returns the version of the active server reported by the last NodeInfo call, or
+// an empty string when it isn't known, without calling the node
+func (c *gRPCClient) knownNodeVersion() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.nodeVersion
+}
+
 // NodeStatus returns dynamic node status such as sync status and number of connected peers
-func (c *gRPCClient) NodeStatus() (*apitypes.NodeStatus, error) {
+func (c *gRPCClient) NodeStatus(ctx context.Context) (*apitypes.NodeStatus, error) {
+	if cached, ok := c.cached(ctx, nodeStatusCacheKey); ok {
+		return proto.Clone(cached.(*apitypes.NodeStatus)).(*apitypes.NodeStatus), nil
+	}
+	generation := c.cache.current()
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	s := c.getNodeServiceClient()
-	resp, err := s.Status(context.Background(), &apitypes.StatusRequest{})
+	resp, err := s.Status(ctx, &apitypes.StatusRequest{})
 	if err != nil {
 		return nil, err
 	}
 
+	c.cache.put(nodeStatusCacheKey, proto.Clone(resp.Status), generation)
+	return resp.Status, nil
+}
+
+// Health asks the node whether it is ready to serve calls, with the standard gRPC health service.
+// It fails with Unimplemented on nodes which don't expose the health service.
+func (c *gRPCClient) Health(ctx context.Context) (bool, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	c.mu.Lock()
+	conn := c.connection
+	c.mu.Unlock()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return false, err
+	}
+	return resp.Status == healthpb.HealthCheckResponse_SERVING, nil
+}
+
+// Shutdown asks the node to shut down gracefully. It fails with Unimplemented on nodes which don't
+// expose the admin endpoints.
+func (c *gRPCClient) Shutdown(ctx context.Context) (*status.Status, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
+	s := c.getNodeServiceClient()
+	resp, err := s.Shutdown(ctx, &apitypes.ShutdownRequest{})
+	if err != nil {
+		return nil, err
+	}
 	return resp.Status, nil
 }
+
+// StatusStream returns a stream of the node status, which the node sends when it changes. The
+// stream ends when ctx is done.
+func (c *gRPCClient) StatusStream(ctx context.Context) (apitypes.NodeService_StatusStreamClient, error) {
+	s := c.getNodeServiceClient()
+	return s.StatusStream(ctx, &apitypes.StatusStreamRequest{})
+}
+
+// ErrorStream returns a stream of the errors the node logs. The stream ends when ctx is done.
+func (c *gRPCClient) ErrorStream(ctx context.Context) (apitypes.NodeService_ErrorStreamClient, error) {
+	s := c.getNodeServiceClient()
+	return s.ErrorStream(ctx, &apitypes.ErrorStreamRequest{})
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"path/filepath"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// nonceTracker returns the locally reserved nonces stored in the wallets directory
+func (w *WalletBackend) nonceTracker() (*common.NonceTracker, error) {
+	if w.nonces == nil {
+		tracker, err := common.LoadNonceTracker(filepath.Join(w.workingDirectory, common.NoncesFileName))
+		if err != nil {
+			return nil, err
+		}
+		w.nonces = tracker
+	}
+	return w.nonces, nil
+}
+
+// NextNonce returns the nonce of the next transaction of an account: its projected counter or the
+// nonce after the last one used from this wallet, whichever is higher
+func (w *WalletBackend) NextNonce(address gosmtypes.Address, projected uint64) (uint64, error) {
+	tracker, err := w.nonceTracker()
+	if err != nil {
+		return 0, err
+	}
+	return tracker.Next(address, projected), nil
+}
+
+// ReservedNonce returns the last nonce used by an account from this wallet, if any
+func (w *WalletBackend) ReservedNonce(address gosmtypes.Address) (uint64, bool, error) {
+	tracker, err := w.nonceTracker()
+	if err != nil {
+		return 0, false, err
+	}
+	nonce, ok := tracker.Reserved(address)
+	return nonce, ok, nil
+}
+
+// ResetNonce releases the nonces reserved by an account, e.g. after one of its transactions was dropped
+func (w *WalletBackend) ResetNonce(address gosmtypes.Address) error {
+	tracker, err := w.nonceTracker()
+	if err != nil {
+		return err
+	}
+	tracker.Reset(address)
+	return tracker.Save()
+}
+
+// useNonce records that a transaction with nonce was submitted by an account
+func (w *WalletBackend) useNonce(address gosmtypes.Address, nonce uint64) error {
+	tracker, err := w.nonceTracker()
+	if err != nil {
+		return err
+	}
+	tracker.Use(address, nonce)
+	return tracker.Save()
+}
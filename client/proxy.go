@@ -0,0 +1,200 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// proxyEnvVars are the environment variables the proxy is read from when none is given, in order
+const proxyEnvVars = "ALL_PROXY,all_proxy,HTTPS_PROXY,https_proxy"
+
+// ProxyURL returns the proxy to reach the node through: explicit when it isn't empty, otherwise
+// the first proxy set in ALL_PROXY or HTTPS_PROXY. It returns nil when no proxy is set. Proxies
+// without a scheme are HTTP proxies.
+func ProxyURL(explicit string) (*url.URL, error) {
+	return proxyURL(explicit, os.Getenv)
+}
+
+func proxyURL(explicit string, getenv func(string) string) (*url.URL, error) {
+	raw := explicit
+	for _, name := range strings.Split(proxyEnvVars, ",") {
+		if raw != "" {
+			break
+		}
+		raw = getenv(name)
+	}
+	if raw == "" {
+		return nil, nil
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %v", raw, err)
+	}
+	switch u.Scheme {
+	case "socks5", "socks5h", "http":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, use socks5 or http", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid proxy %q: missing host", raw)
+	}
+	return u, nil
+}
+
+// proxyError is the error of a connection to a node through a proxy. It tells whether the proxy
+// itself or the node behind it couldn't be reached.
+type proxyError struct {
+	proxy, node      string
+	proxyUnreachable bool
+	err              error
+}
+
+func (e *proxyError) Error() string {
+	if e.proxyUnreachable {
+		return fmt.Sprintf("proxy %s is unreachable: %v", e.proxy, e.err)
+	}
+	return fmt.Sprintf("couldn't reach node %s through proxy %s: %v", e.node, e.proxy, e.err)
+}
+
+func (e *proxyError) Unwrap() error {
+	return e.err
+}
+
+// Temporary tells gRPC that a blocking dial fails right away instead of waiting for its deadline,
+// so the error is reported
+func (e *proxyError) Temporary() bool {
+	return false
+}
+
+// errProxyUnreachable marks the errors of connecting to the proxy itself
+var errProxyUnreachable = errors.New("proxy unreachable")
+
+// proxyForward connects to the proxy and marks its errors as errors of the proxy
+type proxyForward struct{}
+
+func (proxyForward) Dial(network, addr string) (net.Conn, error) {
+	return proxyForward{}.DialContext(context.Background(), network, addr)
+}
+
+func (proxyForward) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errProxyUnreachable, err)
+	}
+	return conn, nil
+}
+
+// proxyAddr returns the host and port of the proxy, with the default port of its scheme when it
+// has none
+func proxyAddr(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	if u.Scheme == "http" {
+		return net.JoinHostPort(u.Hostname(), "80")
+	}
+	return net.JoinHostPort(u.Hostname(), "1080")
+}
+
+// dialProxy connects to a node through the proxy of the client
+func (c *gRPCClient) dialProxy(ctx context.Context, addr string) (net.Conn, error) {
+	var conn net.Conn
+	var err error
+	if c.proxy.Scheme == "http" {
+		conn, err = dialHTTPProxy(ctx, c.proxy, addr)
+	} else {
+		conn, err = dialSOCKS5Proxy(ctx, c.proxy, addr)
+	}
+	if err != nil {
+		return nil, &proxyError{
+			proxy:            c.proxy.Redacted(),
+			node:             addr,
+			proxyUnreachable: errors.Is(err, errProxyUnreachable),
+			err:              err,
+		}
+	}
+	return conn, nil
+}
+
+// dialSOCKS5Proxy connects to addr through a SOCKS5 proxy, authenticating with the user and
+// password of the proxy URL when it has them
+func dialSOCKS5Proxy(ctx context.Context, u *url.URL, addr string) (net.Conn, error) {
+	var auth *proxy.Auth
+	if u.User != nil {
+		password, _ := u.User.Password()
+		auth = &proxy.Auth{User: u.User.Username(), Password: password}
+	}
+	dialer, err := proxy.SOCKS5("tcp", proxyAddr(u), auth, proxyForward{})
+	if err != nil {
+		return nil, err
+	}
+	return dialer.(proxy.ContextDialer).DialContext(ctx, "tcp", addr)
+}
+
+// dialHTTPProxy connects to addr through an HTTP proxy with a CONNECT request, authenticating with
+// the user and password of the proxy URL when it has them
+func dialHTTPProxy(ctx context.Context, u *url.URL, addr string) (net.Conn, error) {
+	conn, err := proxyForward{}.DialContext(ctx, "tcp", proxyAddr(u))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, fmt.Errorf("proxy answered %s", resp.Status)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a connection whose first bytes were read into a buffer
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
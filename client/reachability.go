@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"sync/atomic"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Values of gRPCClient.reachable
+const (
+	reachabilityUnknown int32 = iota
+	reachabilityReachable
+	reachabilityUnreachable
+)
+
+// probeKey marks the context of background probes of the node in use
+type probeKey struct{}
+
+// reachabilityUnary records whether the node in use answered a call. Any answer, including an
+// error the node returned, means the node is reachable. Cancelled calls tell nothing, and neither
+// do health checks, which may be calls to other servers.
+func (c *gRPCClient) reachabilityUnary(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if ctx.Value(noFailoverKey{}) != nil && ctx.Value(probeKey{}) == nil {
+		return err
+	}
+	switch status.Code(err) {
+	case codes.Canceled:
+	case codes.Unavailable:
+		atomic.StoreInt32(&c.reachable, reachabilityUnreachable)
+	default:
+		atomic.StoreInt32(&c.reachable, reachabilityReachable)
+	}
+	return err
+}
+
+// Reachable tells whether the node in use answered the last call. known is false until a call
+// ended.
+func (c *gRPCClient) Reachable() (reachable bool, known bool) {
+	switch atomic.LoadInt32(&c.reachable) {
+	case reachabilityReachable:
+		return true, true
+	case reachabilityUnreachable:
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// ProbeStatus asks the node for its status with a short timeout and without retrying or failing
+// over, so it is cheap enough to call periodically in the background. It doesn't depend on the
+// running command, which it doesn't interfere with.
+func (c *gRPCClient) ProbeStatus() (*apitypes.NodeStatus, error) {
+	ctx, cancel := healthContext()
+	defer cancel()
+	resp, err := c.getNodeServiceClient().Status(context.WithValue(ctx, probeKey{}, true), &apitypes.StatusRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// readAttempts is how many times a read is attempted before its error is returned
+const readAttempts = 3
+
+// readRetryBackoff is the wait before attempting a read again
+var readRetryBackoff = common.Backoff{Initial: 500 * time.Millisecond, Max: 4 * time.Second, Attempts: readAttempts - 1}
+
+// idempotentMethods are the read-only methods which may be called again when a call fails. Calls
+// which submit transactions or change the smesher are never retried, since the first call may have
+// taken effect.
+var idempotentMethods = map[string]bool{
+	"/spacemesh.v1.NodeService/Echo":                     true,
+	"/spacemesh.v1.NodeService/Version":                  true,
+	"/spacemesh.v1.NodeService/Build":                    true,
+	"/spacemesh.v1.NodeService/Status":                   true,
+	"/spacemesh.v1.MeshService/GenesisTime":              true,
+	"/spacemesh.v1.MeshService/CurrentLayer":             true,
+	"/spacemesh.v1.MeshService/CurrentEpoch":             true,
+	"/spacemesh.v1.MeshService/NetID":                    true,
+	"/spacemesh.v1.MeshService/EpochNumLayers":           true,
+	"/spacemesh.v1.MeshService/LayerDuration":            true,
+	"/spacemesh.v1.MeshService/MaxTransactionsPerSecond": true,
+	"/spacemesh.v1.MeshService/AccountMeshDataQuery":     true,
+	"/spacemesh.v1.MeshService/LayersQuery":              true,
+	"/spacemesh.v1.GlobalStateService/GlobalStateHash":   true,
+	"/spacemesh.v1.GlobalStateService/Account":           true,
+	"/spacemesh.v1.GlobalStateService/AccountDataQuery":  true,
+	"/spacemesh.v1.GlobalStateService/SmesherDataQuery":  true,
+	"/spacemesh.v1.TransactionService/TransactionsState": true,
+	"/spacemesh.v1.SmesherService/IsSmeshing":            true,
+	"/spacemesh.v1.SmesherService/SmesherID":             true,
+	"/spacemesh.v1.SmesherService/Coinbase":              true,
+	"/spacemesh.v1.SmesherService/PostStatus":            true,
+	"/spacemesh.v1.SmesherService/PostComputeProviders":  true,
+	"/spacemesh.v1.DebugService/Accounts":                true,
+	"/grpc.health.v1.Health/Check":                       true,
+}
+
+// retryableReadError tells whether a read failed because the node was briefly unreachable or too
+// slow, so calling it again may succeed
+func retryableReadError(err error) bool {
+	c := status.Code(err)
+	return c == codes.Unavailable || c == codes.DeadlineExceeded
+}
+
+// retryUnary calls idempotent methods again with jittered backoff when they fail because the node
+// was unavailable or didn't answer in time. A call is not retried once its context is done, or when
+// it must not fail over, such as a health check.
+func (c *gRPCClient) retryUnary(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !idempotentMethods[method] || ctx.Value(noFailoverKey{}) != nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	for attempt := 1; ; attempt++ {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil || !retryableReadError(err) || ctx.Err() != nil {
+			return err
+		}
+		delay, ok := readRetryBackoff.JitteredDelay(attempt, rand.Float64())
+		if !ok {
+			return err
+		}
+		if c.isVerbose() {
+			fmt.Printf("retrying (attempt %d/%d)...\n", attempt+1, readAttempts)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// flakyNode fails the first calls of its services with Unavailable, as a restarting node does, and
+// counts the calls it receives
+type flakyNode struct {
+	apitypes.UnimplementedNodeServiceServer
+	apitypes.UnimplementedTransactionServiceServer
+	mu       sync.Mutex
+	failures int
+	calls    int
+}
+
+// call counts a call and returns the error it fails with, if any
+func (n *flakyNode) call() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.calls++
+	if n.calls <= n.failures {
+		return status.Error(codes.Unavailable, "node is restarting")
+	}
+	return nil
+}
+
+func (n *flakyNode) Status(context.Context, *apitypes.StatusRequest) (*apitypes.StatusResponse, error) {
+	if err := n.call(); err != nil {
+		return nil, err
+	}
+	return &apitypes.StatusResponse{Status: &apitypes.NodeStatus{}}, nil
+}
+
+func (n *flakyNode) SubmitTransaction(context.Context, *apitypes.SubmitTransactionRequest) (*apitypes.SubmitTransactionResponse, error) {
+	if err := n.call(); err != nil {
+		return nil, err
+	}
+	return &apitypes.SubmitTransactionResponse{Txstate: &apitypes.TransactionState{}}, nil
+}
+
+// startFlakyNode serves a flakyNode failing its first failures calls and returns a client
+// connected to it. Reads are retried without waiting.
+func startFlakyNode(t *testing.T, failures int) (*gRPCClient, *flakyNode, func()) {
+	backoff := readRetryBackoff
+	readRetryBackoff = common.Backoff{Initial: time.Millisecond, Max: time.Millisecond, Attempts: backoff.Attempts}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := &flakyNode{failures: failures}
+	server := grpc.NewServer()
+	apitypes.RegisterNodeServiceServer(server, node)
+	apitypes.RegisterTransactionServiceServer(server, node)
+	go server.Serve(listener)
+
+	client := newGRPCClient([]string{listener.Addr().String()}, false, "", nil, common.MessageLimits{})
+	if err := client.Connect(); err != nil {
+		server.Stop()
+		t.Fatal(err)
+	}
+	return client, node, func() {
+		_ = client.Close()
+		server.Stop()
+		readRetryBackoff = backoff
+	}
+}
+
+func TestReadRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		succeeds  bool
+		wantCalls int
+	}{
+		{"no failure", 0, true, 1},
+		{"recovers", readAttempts - 1, true, readAttempts},
+		{"gives up", readAttempts, false, readAttempts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, node, stop := startFlakyNode(t, tt.failures)
+			defer stop()
+
+			_, err := client.NodeStatus(context.Background())
+			if (err == nil) != tt.succeeds {
+				t.Fatalf("expected success %v, got %v", tt.succeeds, err)
+			}
+			if node.calls != tt.wantCalls {
+				t.Fatalf("expected %d calls, got %d", tt.wantCalls, node.calls)
+			}
+		})
+	}
+}
+
+func TestSubmitNotRetried(t *testing.T) {
+	client, node, stop := startFlakyNode(t, 1)
+	defer stop()
+
+	if _, err := client.SubmitCoinTransaction(context.Background(), []byte{1}); err == nil {
+		t.Fatal("expected the submission to fail")
+	}
+	if node.calls != 1 {
+		t.Fatalf("expected the submission to be sent once, it was sent %d times", node.calls)
+	}
+}
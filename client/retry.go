@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"google.golang.org/grpc/codes"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+const (
+	// submitRetries is how many times a submission failing with a transient error is retried
+	submitRetries = 3
+)
+
+// submitRetryDelay is the wait before the first retry. It doubles with every retry.
+var submitRetryDelay = 2 * time.Second
+
+// txSubmitter is the part of the node API used to submit a transaction and look it up
+type txSubmitter interface {
+	SubmitCoinTransaction(ctx context.Context, tx []byte) (*apitypes.TransactionState, error)
+	TransactionState(ctx context.Context, txId []byte, includeTx bool) (*apitypes.TransactionState, *apitypes.Transaction, error)
+}
+
+// isTransient tells whether a submission error may go away by itself: the node was unavailable or
+// didn't answer in time
+func isTransient(err error) bool {
+	var nodeErr *common.NodeError
+	if !errors.As(err, &nodeErr) {
+		return false
+	}
+	return nodeErr.Code == codes.Unavailable || nodeErr.Code == codes.DeadlineExceeded
+}
+
+// submitWithRetry submits a signed transaction with id and retries up to submitRetries times
+// when the submission fails with a transient error. After every failure the node is asked whether
+// the attempt arrived after all, in which case the transaction isn't submitted again. The retries
+// are reported to the progress report of ctx, and the wait before a retry ends when ctx is done. It
+// returns the transaction state and the attempt which got the transaction to the node.
+func submitWithRetry(ctx context.Context, s txSubmitter, tx, id []byte) (*apitypes.TransactionState, int, error) {
+	delay := submitRetryDelay
+	attempt := 1
+	for {
+		state, err := s.SubmitCoinTransaction(ctx, tx)
+		if err == nil {
+			if attempt > 1 {
+				common.ReportSubmitProgress(ctx, common.SubmitProgress{Attempt: attempt})
+			}
+			return state, attempt, nil
+		}
+		if !isTransient(err) {
+			return nil, attempt, err
+		}
+		retry := attempt <= submitRetries
+		if retry {
+			common.ReportSubmitProgress(ctx, common.SubmitProgress{Attempt: attempt, Err: err, RetryIn: delay})
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, attempt, err
+			}
+			delay *= 2
+		}
+		if state, ok := landed(ctx, s, id); ok {
+			common.ReportSubmitProgress(ctx, common.SubmitProgress{Attempt: attempt, Err: err, Landed: true})
+			return state, attempt, nil
+		}
+		if !retry {
+			return nil, attempt, err
+		}
+		attempt++
+	}
+}
+
+// landed asks the node whether a transaction whose submission failed arrived after all, and
+// returns its state when it did
+func landed(ctx context.Context, s txSubmitter, id []byte) (*apitypes.TransactionState, bool) {
+	state, _, err := s.TransactionState(ctx, id, false)
+	if err != nil || state == nil || state.State == apitypes.TransactionState_TRANSACTION_STATE_UNSPECIFIED {
+		return nil, false
+	}
+	return state, true
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"google.golang.org/grpc/codes"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// flakySubmitter fails submissions with the scripted errors, then succeeds. landedAfter is the
+// number of failed submissions after which the node knows the transaction anyway, 0 for never.
+type flakySubmitter struct {
+	errs        []error
+	landedAfter int
+	submits     int
+	lookups     int
+}
+
+func (f *flakySubmitter) SubmitCoinTransaction(ctx context.Context, tx []byte) (*apitypes.TransactionState, error) {
+	f.submits++
+	if f.submits <= len(f.errs) {
+		return nil, f.errs[f.submits-1]
+	}
+	return &apitypes.TransactionState{State: apitypes.TransactionState_TRANSACTION_STATE_MEMPOOL}, nil
+}
+
+func (f *flakySubmitter) TransactionState(ctx context.Context, txId []byte, includeTx bool) (*apitypes.TransactionState, *apitypes.Transaction, error) {
+	f.lookups++
+	if f.landedAfter != 0 && f.submits >= f.landedAfter {
+		return &apitypes.TransactionState{State: apitypes.TransactionState_TRANSACTION_STATE_MEMPOOL}, nil, nil
+	}
+	return &apitypes.TransactionState{}, nil, nil
+}
+
+func unavailable() error {
+	return common.NewNodeError(submitOp, codes.Unavailable, "connection refused")
+}
+
+func TestSubmitWithRetry(t *testing.T) {
+	submitRetryDelay = 0
+	for _, test := range []struct {
+		name            string
+		submitter       *flakySubmitter
+		fails           bool
+		attempt         int
+		submits, lookup int
+		reports         int
+	}{
+		{"first attempt", &flakySubmitter{}, false, 1, 1, 0, 0},
+		{"transient then success", &flakySubmitter{errs: []error{unavailable(), unavailable()}}, false, 3, 3, 2, 3},
+		{"first attempt landed", &flakySubmitter{errs: []error{unavailable()}, landedAfter: 1}, false, 1, 1, 1, 2},
+		{"gives up", &flakySubmitter{errs: []error{unavailable(), unavailable(), unavailable(), unavailable()}}, true, 4, 4, 4, 3},
+		{"last attempt landed", &flakySubmitter{errs: []error{unavailable(), unavailable(), unavailable(), unavailable()}, landedAfter: 4}, false, 4, 4, 4, 4},
+		{"not transient", &flakySubmitter{errs: []error{common.NewNodeError(submitOp, codes.InvalidArgument, "bad")}}, true, 1, 1, 0, 0},
+	} {
+		var reports []common.SubmitProgress
+		ctx := common.WithSubmitProgress(context.Background(), func(p common.SubmitProgress) { reports = append(reports, p) })
+		state, attempt, err := submitWithRetry(ctx, test.submitter, []byte{1}, []byte{2})
+		if test.fails != (err != nil) {
+			t.Fatalf("%s: unexpected error %v", test.name, err)
+		}
+		if !test.fails && state == nil {
+			t.Fatalf("%s: expected a transaction state", test.name)
+		}
+		if attempt != test.attempt || test.submitter.submits != test.submits || test.submitter.lookups != test.lookup {
+			t.Fatalf("%s: expected attempt %d with %d submissions and %d lookups, got %d, %d and %d", test.name,
+				test.attempt, test.submits, test.lookup, attempt, test.submitter.submits, test.submitter.lookups)
+		}
+		if len(reports) != test.reports {
+			t.Fatalf("%s: expected %d progress reports, got %v", test.name, test.reports, reports)
+		}
+	}
+}
+
+func TestSubmitRetryCancel(t *testing.T) {
+	defer func(delay time.Duration) { submitRetryDelay = delay }(submitRetryDelay)
+	submitRetryDelay = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = common.WithSubmitProgress(ctx, func(common.SubmitProgress) { cancel() })
+	s := &flakySubmitter{errs: []error{unavailable()}}
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := submitWithRetry(ctx, s, []byte{1}, []byte{2})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || s.submits != 1 {
+			t.Fatalf("expected cancelling to end the wait for the retry, got %v after %d submissions", err, s.submits)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected cancelling to end the wait for the retry")
+	}
+}
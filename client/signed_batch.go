@@ -0,0 +1,53 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	pb "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// VerifySignedBatchTx decodes a transaction of a batch signed offline and checks it against the
+// payment fields of its entry and its id. It returns the serialized transaction, its id and the
+// address of the account that signed it.
+func (w *WalletBackend) VerifySignedBatchTx(t common.SignedBatchTx) ([]byte, []byte, gosmtypes.Address, error) {
+	data, id, err := t.Bytes()
+	if err != nil {
+		return nil, nil, gosmtypes.Address{}, err
+	}
+	tx, err := w.DecodeSignedTransaction(data)
+	if err != nil {
+		return nil, nil, gosmtypes.Address{}, fmt.Errorf("line %d: %v", t.Line, err)
+	}
+	switch {
+	case tx.Recipient != gosmtypes.HexToAddress(t.Recipient):
+		return nil, nil, gosmtypes.Address{}, fmt.Errorf("line %d: the transaction is to %s, not %s", t.Line, tx.Recipient.Hex(), t.Recipient)
+	case tx.Amount != t.Amount:
+		return nil, nil, gosmtypes.Address{}, fmt.Errorf("line %d: the transaction amount is %s, not %s", t.Line, common.FormatSmidge(tx.Amount), common.FormatSmidge(t.Amount))
+	case tx.Nonce != t.Nonce:
+		return nil, nil, gosmtypes.Address{}, fmt.Errorf("line %d: the transaction nonce is %d, not %d", t.Line, tx.Nonce, t.Nonce)
+	}
+	return data, id, tx.Sender, nil
+}
+
+// SubmitSignedTx submits a transaction signed elsewhere, retrying when the node is unavailable,
+// and checks that the node reports the expected id
+func (w *WalletBackend) SubmitSignedTx(ctx context.Context, tx, id []byte) (*pb.TransactionState, error) {
+	return submitSigned(ctx, w, tx, id)
+}
+
+// submitSigned submits a signed transaction with submitWithRetry and checks the id the node reports
+func submitSigned(ctx context.Context, s txSubmitter, tx, id []byte) (*pb.TransactionState, error) {
+	state, _, err := submitWithRetry(ctx, s, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if reported := state.GetId().GetId(); !bytes.Equal(reported, id) {
+		return state, fmt.Errorf("the node reported transaction id 0x%x instead of 0x%x", reported, id)
+	}
+	return state, nil
+}
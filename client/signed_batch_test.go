@@ -0,0 +1,111 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/ed25519"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// recordingSubmitter accepts every transaction and reports the hash of the submitted bytes as its
+// id, like the node does
+type recordingSubmitter struct {
+	submitted [][]byte
+}
+
+func (s *recordingSubmitter) SubmitCoinTransaction(ctx context.Context, tx []byte) (*apitypes.TransactionState, error) {
+	s.submitted = append(s.submitted, tx)
+	id := sha256.Sum256(tx)
+	return &apitypes.TransactionState{
+		Id:    &apitypes.TransactionId{Id: id[:]},
+		State: apitypes.TransactionState_TRANSACTION_STATE_MEMPOOL,
+	}, nil
+}
+
+func (s *recordingSubmitter) TransactionState(ctx context.Context, txId []byte, includeTx bool) (*apitypes.TransactionState, *apitypes.Transaction, error) {
+	return &apitypes.TransactionState{}, nil, nil
+}
+
+func TestSignedBatchRoundTrip(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc := &common.LocalAccount{Name: "cold", PubKey: pub, PrivKey: priv}
+	sender := acc.Address()
+	key, err := acc.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer key.Release()
+
+	rows := []common.BatchRow{
+		{Line: 2, Recipient: gosmtypes.HexToAddress("0x7fa75881ca0050028b32f424f860e3a73d4bf168"), Amount: 2500000000000, Note: "rent"},
+		{Line: 3, Recipient: gosmtypes.HexToAddress("0x0000000000000000000000000000000000000001"), Amount: 100},
+	}
+	offline := legacySigner()
+	var signed []common.SignedBatchTx
+	var expected [][]byte
+	for i, row := range rows {
+		tx, err := offline.SignTransfer(row.Recipient, 7+uint64(i), row.Amount, 1, 100, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signed = append(signed, common.NewSignedBatchTx(row, tx))
+		expected = append(expected, tx.Signed)
+	}
+	var file bytes.Buffer
+	if err := common.WriteSignedBatch(&file, signed); err != nil {
+		t.Fatal(err)
+	}
+
+	online := &WalletBackend{}
+	txs, err := common.ReadSignedBatch(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != len(rows) {
+		t.Fatalf("expected %d transactions, got %d", len(rows), len(txs))
+	}
+	node := &recordingSubmitter{}
+	for i, entry := range txs {
+		data, id, from, err := online.VerifySignedBatchTx(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if from != sender {
+			t.Fatalf("line %d: expected sender %s, got %s", entry.Line, sender.Hex(), from.Hex())
+		}
+		if !bytes.Equal(data, expected[i]) {
+			t.Fatalf("line %d: the decoded bytes differ from the signed bytes", entry.Line)
+		}
+		if _, err := submitSigned(context.Background(), node, data, id); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(node.submitted[i], expected[i]) {
+			t.Fatalf("line %d: the submitted bytes differ from the signed bytes", entry.Line)
+		}
+	}
+
+	tampered := txs[0]
+	tampered.Amount++
+	if _, _, _, err := online.VerifySignedBatchTx(tampered); err == nil {
+		t.Fatal("expected an error for an amount that differs from the signed one")
+	}
+	tampered = txs[0]
+	tampered.ID = txs[1].ID
+	if _, _, _, err := online.VerifySignedBatchTx(tampered); err == nil {
+		t.Fatal("expected an error for an id that doesn't match the transaction")
+	}
+	data, _, _, _ := online.VerifySignedBatchTx(txs[0])
+	if _, err := submitSigned(context.Background(), node, data, []byte{1}); err == nil {
+		t.Fatal("expected an error when the node reports another id")
+	}
+}
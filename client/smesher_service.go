@@ -10,19 +10,33 @@ import (
 )
 
 // GetSmesherId returns the current smesher id configured in the node
-func (c *gRPCClient) GetSmesherId() ([]byte, error) {
+func (c *gRPCClient) GetSmesherId(ctx context.Context) ([]byte, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	s := c.getSmesherServiceClient()
-	if resp, err := s.SmesherID(context.Background(), &empty.Empty{}); err != nil {
+	if resp, err := s.SmesherID(ctx, &empty.Empty{}); err != nil {
 		return nil, err
 	} else {
 		return resp.AccountId.Address, nil
 	}
 }
 
+// SmesherIds returns the ids of the identities the node smeshes with. The API has no call listing
+// several identities, so this is the single id SmesherID reports.
+func (c *gRPCClient) SmesherIds(ctx context.Context) ([][]byte, error) {
+	id, err := c.GetSmesherId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{id}, nil
+}
+
 // IsSmeshing returns true iff the node is currently setup to smesh
-func (c *gRPCClient) IsSmeshing() (bool, error) {
+func (c *gRPCClient) IsSmeshing(ctx context.Context) (bool, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	s := c.getSmesherServiceClient()
-	if resp, err := s.IsSmeshing(context.Background(), &empty.Empty{}); err != nil {
+	if resp, err := s.IsSmeshing(ctx, &empty.Empty{}); err != nil {
 		return false, err
 	} else {
 		return resp.IsSmeshing, nil
@@ -30,19 +44,30 @@ func (c *gRPCClient) IsSmeshing() (bool, error) {
 }
 
 // GetPostStatus returns the current node proof of space status
-func (c *gRPCClient) GetPostStatus() (*apitypes.PostStatus, error) {
+func (c *gRPCClient) GetPostStatus(ctx context.Context) (*apitypes.PostStatus, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	s := c.getSmesherServiceClient()
-	if resp, err := s.PostStatus(context.Background(), &empty.Empty{}); err != nil {
+	if resp, err := s.PostStatus(ctx, &empty.Empty{}); err != nil {
 		return nil, err
 	} else {
 		return resp.Status, nil
 	}
 }
 
+// PostStatusStream returns a stream of the proof of space status, which the node sends as PoST data
+// creation progresses. The stream ends when ctx is done.
+func (c *gRPCClient) PostStatusStream(ctx context.Context) (apitypes.SmesherService_PostDataCreationProgressStreamClient, error) {
+	s := c.getSmesherServiceClient()
+	return s.PostDataCreationProgressStream(ctx, &empty.Empty{})
+}
+
 // GetPostComputeProviders returns the proof of space generators available on the system
-func (c *gRPCClient) GetPostComputeProviders() ([]*apitypes.PostComputeProvider, error) {
+func (c *gRPCClient) GetPostComputeProviders(ctx context.Context) ([]*apitypes.PostComputeProvider, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	s := c.getSmesherServiceClient()
-	if resp, err := s.PostComputeProviders(context.Background(), &empty.Empty{}); err != nil {
+	if resp, err := s.PostComputeProviders(ctx, &empty.Empty{}); err != nil {
 		return nil, err
 	} else {
 		return resp.PostComputeProvider, nil
@@ -50,9 +75,11 @@ func (c *gRPCClient) GetPostComputeProviders() ([]*apitypes.PostComputeProvider,
 }
 
 // CreatePostData starts or continues pos data creation operation
-func (c *gRPCClient) CreatePostData(data *apitypes.PostData) (*status.Status, error) {
+func (c *gRPCClient) CreatePostData(ctx context.Context, data *apitypes.PostData) (*status.Status, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	s := c.getSmesherServiceClient()
-	if resp, err := s.CreatePostData(context.Background(), &apitypes.CreatePostDataRequest{Data: data}); err != nil {
+	if resp, err := s.CreatePostData(ctx, &apitypes.CreatePostDataRequest{Data: data}); err != nil {
 		return nil, err
 	} else {
 		return resp.Status, nil
@@ -60,9 +87,11 @@ func (c *gRPCClient) CreatePostData(data *apitypes.PostData) (*status.Status, er
 }
 
 // StartSmeshing instructs the node to start smeshing using user's provider params
-func (c *gRPCClient) StartSmeshing(address gosmtypes.Address, dataDir string, dataSizeBytes uint64) (*status.Status, error) {
+func (c *gRPCClient) StartSmeshing(ctx context.Context, address gosmtypes.Address, dataDir string, dataSizeBytes uint64) (*status.Status, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	s := c.getSmesherServiceClient()
-	resp, err := s.StartSmeshing(context.Background(), &apitypes.StartSmeshingRequest{
+	resp, err := s.StartSmeshing(ctx, &apitypes.StartSmeshingRequest{
 		Coinbase:       &apitypes.AccountId{Address: address.Bytes()},
 		DataDir:        dataDir,
 		CommitmentSize: &apitypes.SimpleInt{Value: dataSizeBytes},
@@ -75,9 +104,11 @@ func (c *gRPCClient) StartSmeshing(address gosmtypes.Address, dataDir string, da
 }
 
 // StopSmeshing instructs the node to stop smeshing and optionally delete smeshing data file(s)
-func (c *gRPCClient) StopSmeshing(deleteFiles bool) (*status.Status, error) {
+func (c *gRPCClient) StopSmeshing(ctx context.Context, deleteFiles bool) (*status.Status, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	s := c.getSmesherServiceClient()
-	resp, err := s.StopSmeshing(context.Background(), &apitypes.StopSmeshingRequest{DeleteFiles: deleteFiles})
+	resp, err := s.StopSmeshing(ctx, &apitypes.StopSmeshingRequest{DeleteFiles: deleteFiles})
 	if err != nil {
 		return nil, err
 	}
@@ -85,9 +116,11 @@ func (c *gRPCClient) StopSmeshing(deleteFiles bool) (*status.Status, error) {
 }
 
 // SetRewardsAddress sets the smesher's rewards address
-func (c *gRPCClient) SetRewardsAddress(address gosmtypes.Address) (*status.Status, error) {
+func (c *gRPCClient) SetRewardsAddress(ctx context.Context, address gosmtypes.Address) (*status.Status, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	s := c.getSmesherServiceClient()
-	resp, err := s.SetCoinbase(context.Background(), &apitypes.SetCoinbaseRequest{Id: &apitypes.AccountId{Address: address.Bytes()}})
+	resp, err := s.SetCoinbase(ctx, &apitypes.SetCoinbaseRequest{Id: &apitypes.AccountId{Address: address.Bytes()}})
 	if err != nil {
 		return nil, err
 	}
@@ -95,9 +128,11 @@ func (c *gRPCClient) SetRewardsAddress(address gosmtypes.Address) (*status.Statu
 }
 
 // GetRewardsAddress get the smesher's current rewards address
-func (c *gRPCClient) GetRewardsAddress() (*gosmtypes.Address, error) {
+func (c *gRPCClient) GetRewardsAddress(ctx context.Context) (*gosmtypes.Address, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	s := c.getSmesherServiceClient()
-	resp, err := s.Coinbase(context.Background(), &empty.Empty{})
+	resp, err := s.Coinbase(ctx, &empty.Empty{})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// Keys of the values in the state cache other than account states
+const (
+	meshInfoCacheKey   = "mesh-info"
+	nodeStatusCacheKey = "node-status"
+)
+
+// accountCacheKey is the state cache key of the state of an account
+func accountCacheKey(address gosmtypes.Address) string {
+	return "account-" + address.Hex()
+}
+
+// stateCache keeps the results of calls which several commands make within a second of each
+// other, such as the account state read by account-info, the nonce of a transfer and its summary.
+// Values are reused for ttl, a ttl of 0 disables the cache. It is safe for concurrent use.
+//
+// Each invalidation starts a new generation. A call reads the generation before it asks the node
+// and stores the answer with it, so an answer to a call made before an invalidation, e.g. the state
+// of the sender of a transaction submitted meanwhile, isn't stored over it.
+type stateCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	entries    map[string]cacheEntry
+	generation uint64
+	// now returns the current time, replaced by tests
+	now func() time.Time
+}
+
+type cacheEntry struct {
+	value   interface{}
+	fetched time.Time
+}
+
+func newStateCache(ttl time.Duration) *stateCache {
+	return &stateCache{ttl: ttl, entries: make(map[string]cacheEntry), now: time.Now}
+}
+
+// setTTL changes how long values are reused. Values older than the new ttl are dropped when they
+// are next read.
+func (c *stateCache) setTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+}
+
+// get returns a value stored less than ttl ago
+func (c *stateCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if c.ttl <= 0 || c.now().Sub(e.fetched) >= c.ttl {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+// current returns the generation of the values the node returns from now on
+func (c *stateCache) current() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// put stores a value the node returned to a call made in a generation. Nothing is stored while the
+// cache is disabled or when the cache was invalidated since the call was made.
+func (c *stateCache) put(key string, value interface{}, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl <= 0 || generation != c.generation {
+		return
+	}
+	c.entries[key] = cacheEntry{value: value, fetched: c.now()}
+}
+
+// invalidate drops a value, so the next read calls the node
+func (c *stateCache) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	delete(c.entries, key)
+}
+
+// invalidateAll drops all values
+func (c *stateCache) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.entries = make(map[string]cacheEntry)
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/ed25519"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"google.golang.org/grpc"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+func TestStateCacheTTL(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	cache := newStateCache(2 * time.Second)
+	cache.now = func() time.Time { return now }
+
+	cache.put("key", 1, cache.current())
+	if value, ok := cache.get("key"); !ok || value != 1 {
+		t.Fatalf("expected the stored value, got %v %v", value, ok)
+	}
+	now = now.Add(1999 * time.Millisecond)
+	if _, ok := cache.get("key"); !ok {
+		t.Fatal("expected the value to be reused within the ttl")
+	}
+	now = now.Add(time.Millisecond)
+	if _, ok := cache.get("key"); ok {
+		t.Fatal("expected the value to expire after the ttl")
+	}
+
+	cache.put("a", 1, cache.current())
+	cache.put("b", 2, cache.current())
+	cache.invalidate("a")
+	if _, ok := cache.get("a"); ok {
+		t.Fatal("expected the invalidated value to be dropped")
+	}
+	if _, ok := cache.get("b"); !ok {
+		t.Fatal("expected the other value to be kept")
+	}
+	cache.invalidateAll()
+	if _, ok := cache.get("b"); ok {
+		t.Fatal("expected all values to be dropped")
+	}
+
+	generation := cache.current()
+	cache.invalidate("a")
+	cache.put("a", 1, generation)
+	if _, ok := cache.get("a"); ok {
+		t.Fatal("expected a value fetched before the invalidation to be dropped")
+	}
+	cache.put("a", 1, cache.current())
+	if _, ok := cache.get("a"); !ok {
+		t.Fatal("expected a value fetched after the invalidation to be stored")
+	}
+
+	cache.setTTL(0)
+	cache.put("key", 1, cache.current())
+	if _, ok := cache.get("key"); ok {
+		t.Fatal("expected nothing to be cached with a ttl of 0")
+	}
+}
+
+// accountStateService reports an account whose counter is the number of submitted transactions,
+// and counts the account calls
+type accountStateService struct {
+	apitypes.UnimplementedGlobalStateServiceServer
+	calls     int32
+	submitted uint64
+}
+
+// submitService accepts every transaction and counts it in the account state
+type submitService struct {
+	apitypes.UnimplementedTransactionServiceServer
+	state *accountStateService
+}
+
+func (s *accountStateService) Account(_ context.Context, req *apitypes.AccountRequest) (*apitypes.AccountResponse, error) {
+	atomic.AddInt32(&s.calls, 1)
+	return &apitypes.AccountResponse{AccountWrapper: &apitypes.Account{
+		AccountId:    req.AccountId,
+		StateCurrent: &apitypes.AccountState{Counter: atomic.LoadUint64(&s.submitted)},
+	}}, nil
+}
+
+func (s *submitService) SubmitTransaction(_ context.Context, req *apitypes.SubmitTransactionRequest) (*apitypes.SubmitTransactionResponse, error) {
+	atomic.AddUint64(&s.state.submitted, 1)
+	id := sha256.Sum256(req.Transaction)
+	return &apitypes.SubmitTransactionResponse{Txstate: &apitypes.TransactionState{Id: &apitypes.TransactionId{Id: id[:]}}}, nil
+}
+
+func TestAccountStateCache(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := &accountStateService{}
+	server := grpc.NewServer()
+	apitypes.RegisterGlobalStateServiceServer(server, service)
+	apitypes.RegisterTransactionServiceServer(server, &submitService{state: service})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	client := newGRPCClient([]string{listener.Addr().String()}, false, "", nil, common.MessageLimits{})
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.cache.setTTL(time.Minute)
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc := &common.LocalAccount{Name: "sender", PubKey: pub, PrivKey: priv}
+	sender := acc.Address()
+	other := gosmtypes.HexToAddress("0x0000000000000000000000000000000000000001")
+	state := func(address gosmtypes.Address) uint64 {
+		t.Helper()
+		account, err := client.AccountState(context.Background(), address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return account.StateCurrent.Counter
+	}
+	calls := func() int32 { return atomic.LoadInt32(&service.calls) }
+
+	state(sender)
+	state(other)
+	state(sender)
+	state(other)
+	if calls() != 2 {
+		t.Fatalf("expected each account to be fetched once, got %d calls", calls())
+	}
+
+	key, err := acc.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer key.Release()
+	tx, err := legacySigner().SignTransfer(other, 0, 100, 1, 100, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.SubmitCoinTransaction(context.Background(), tx.Signed); err != nil {
+		t.Fatal(err)
+	}
+	if counter := state(sender); counter != 1 || calls() != 3 {
+		t.Fatalf("expected the sender to be fetched again after the submit, got counter %d after %d calls", counter, calls())
+	}
+	if state(other); calls() != 3 {
+		t.Fatalf("expected the other account to stay cached, got %d calls", calls())
+	}
+
+	if _, err := client.AccountState(common.WithoutCache(context.Background()), sender); err != nil || calls() != 4 {
+		t.Fatalf("expected a call bypassing the cache to reach the node, got %d calls and %v", calls(), err)
+	}
+
+	client.InvalidateCache()
+	state(other)
+	if calls() != 5 {
+		t.Fatalf("expected the account to be fetched after invalidating the cache, got %d calls", calls())
+	}
+}
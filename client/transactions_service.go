@@ -4,26 +4,53 @@ import (
 	"context"
 
 	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+
+	"github.com/spacemeshos/smrepl/common"
 )
 
-// SubmitCoinTransaction submits a signed binary transaction to the node.
-func (c *gRPCClient) SubmitCoinTransaction(tx []byte) (*apitypes.TransactionState, error) {
+// submitOp names transaction submission in node errors
+const submitOp = "submit transaction"
+
+// SubmitCoinTransaction submits a signed binary transaction to the node. Failures reported by the
+// node are returned as *common.NodeError.
+func (c *gRPCClient) SubmitCoinTransaction(ctx context.Context, tx []byte) (*apitypes.TransactionState, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	s := c.getTransactionServiceClient()
-	resp, err := s.SubmitTransaction(context.Background(), &apitypes.SubmitTransactionRequest{Transaction: tx})
+	resp, err := s.SubmitTransaction(ctx, &apitypes.SubmitTransactionRequest{Transaction: tx})
 	if err != nil {
-		return nil, err
+		return nil, common.CallError(submitOp, err)
+	}
+	if resp.Status != nil {
+		if err := common.StatusError(submitOp, resp.Status.Code, resp.Status.Message); err != nil {
+			return nil, err
+		}
 	}
 
+	c.invalidateSender(tx)
 	return resp.Txstate, nil
 }
 
+// invalidateSender drops the cached state of the sender of a submitted transaction, whose balance
+// and nonce change. All account states are dropped when the sender can't be decoded.
+func (c *gRPCClient) invalidateSender(tx []byte) {
+	decoded, err := common.DecodeSignedTransfer(tx)
+	if err != nil {
+		c.cache.invalidateAll()
+		return
+	}
+	c.cache.invalidate(accountCacheKey(decoded.Sender))
+}
+
 // TransactionState returns the state and optionally the transaction for a single transaction based on tx id
-func (c *gRPCClient) TransactionState(txId []byte, includeTx bool) (*apitypes.TransactionState, *apitypes.Transaction, error) {
+func (c *gRPCClient) TransactionState(ctx context.Context, txId []byte, includeTx bool) (*apitypes.TransactionState, *apitypes.Transaction, error) {
+	ctx, cancel := c.callContext(ctx)
+	defer cancel()
 	s := c.getTransactionServiceClient()
 	ids := make([]*apitypes.TransactionId, 0)
 	ids = append(ids, &apitypes.TransactionId{Id: txId})
 
-	resp, err := s.TransactionsState(context.Background(), &apitypes.TransactionsStateRequest{
+	resp, err := s.TransactionsState(ctx, &apitypes.TransactionsStateRequest{
 		TransactionId:       ids,
 		IncludeTransactions: includeTx,
 	})
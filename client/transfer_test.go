@@ -0,0 +1,118 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/spacemeshos/ed25519"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// Golden serialization of a transfer of 2500000000000 to 0x7fa7...f168 with nonce 7, gas limit 100
+// and gas price 1, signed with the key of seed 0x01 * 32. A change of these bytes means the XDR
+// encoding or the signature scheme changed, and the node would no longer accept the transactions.
+const (
+	goldenPublicKey = "8a88e3dd7409f195fd52db2d3cba5d72ca6709bf1d94121bf3748801b40f6f5c"
+	goldenUnsigned  = "0000000000000007" + // nonce
+		"7fa75881ca0050028b32f424f860e3a73d4bf168" + // recipient
+		"0000000000000064" + // gas limit
+		"0000000000000001" + // gas price
+		"00000246139ca800" // amount
+	goldenSignature = "f2184284b02aab3deac4419b7e210fc9b590407397ade22d8181d25339404824" +
+		"c4427bd71b5ed6a3536044a3e0905d273b79f44b5d07aa1326f867e277e8d908"
+)
+
+func goldenSigningKey(t *testing.T) *common.SigningKey {
+	t.Helper()
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	acc := &common.LocalAccount{Name: "golden", PubKey: priv.Public().(ed25519.PublicKey), PrivKey: priv}
+	key, err := acc.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// legacySigner returns a wallet backend which signs offline in the legacy format
+func legacySigner() *WalletBackend {
+	return &WalletBackend{config: &common.Config{TxFormat: common.TxFormatLegacy}}
+}
+
+func TestSignTransferGolden(t *testing.T) {
+	key := goldenSigningKey(t)
+	defer key.Release()
+	if pub := hex.EncodeToString(key.PublicKey()); pub != goldenPublicKey {
+		t.Fatalf("expected public key %s, got %s", goldenPublicKey, pub)
+	}
+
+	recipient := gosmtypes.HexToAddress("0x7fa75881ca0050028b32f424f860e3a73d4bf168")
+	tx, err := legacySigner().SignTransfer(recipient, 7, 2500000000000, 1, 100, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unsigned := hex.EncodeToString(tx.Unsigned); unsigned != goldenUnsigned {
+		t.Fatalf("expected unsigned bytes %s, got %s", goldenUnsigned, unsigned)
+	}
+	if signature := hex.EncodeToString(tx.Signature); signature != goldenSignature {
+		t.Fatalf("expected signature %s, got %s", goldenSignature, signature)
+	}
+	if signed := hex.EncodeToString(tx.Signed); signed != goldenUnsigned+goldenSignature {
+		t.Fatalf("expected signed bytes %s, got %s", goldenUnsigned+goldenSignature, signed)
+	}
+	id := sha256.Sum256(tx.Signed)
+	if !bytes.Equal(tx.ID, id[:]) {
+		t.Fatalf("expected id %x, got %x", id, tx.ID)
+	}
+
+	pub, _ := hex.DecodeString(goldenPublicKey)
+	if !ed25519.Verify2(pub, tx.Unsigned, tx.Signature) {
+		t.Fatal("expected the signature to verify against the public key")
+	}
+	extracted, err := ed25519.ExtractPublicKey(tx.Unsigned, tx.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(extracted, pub) {
+		t.Fatalf("expected the node to extract public key %x, got %x", pub, extracted)
+	}
+	if expected := gosmtypes.BytesToAddress(pub); tx.Sender != expected {
+		t.Fatalf("expected sender %s, got %s", expected.Hex(), tx.Sender.Hex())
+	}
+}
+
+func TestSignTransferStable(t *testing.T) {
+	key := goldenSigningKey(t)
+	defer key.Release()
+	recipient := gosmtypes.HexToAddress("0x0000000000000000000000000000000000000001")
+	w := legacySigner()
+
+	first, err := w.SignTransfer(recipient, 1, 100, 1, 100, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := w.SignTransfer(recipient, 1, 100, 1, 100, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.Signed, second.Signed) {
+		t.Fatalf("expected signing the same transfer twice to give the same bytes, got %x and %x", first.Signed, second.Signed)
+	}
+	other, err := w.SignTransfer(recipient, 2, 100, 1, 100, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first.Signed, other.Signed) {
+		t.Fatal("expected a different nonce to give different bytes")
+	}
+}
+
+func TestSignTransferWatchOnly(t *testing.T) {
+	recipient := gosmtypes.HexToAddress("0x0000000000000000000000000000000000000001")
+	if _, err := legacySigner().SignTransfer(recipient, 1, 100, 1, 100, nil); err != common.ErrWatchOnly {
+		t.Fatalf("expected %v, got %v", common.ErrWatchOnly, err)
+	}
+}
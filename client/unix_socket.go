@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// socketAuthority is the authority of calls over a Unix domain socket, which has no host
+const socketAuthority = "localhost"
+
+// socketError is the error of a connection to a node over a Unix domain socket. It tells a missing
+// socket from one the wallet isn't allowed to open.
+type socketError struct {
+	path string
+	err  error
+}
+
+func (e *socketError) Error() string {
+	switch {
+	case errors.Is(e.err, os.ErrNotExist):
+		return fmt.Sprintf("no node socket at %s", e.path)
+	case errors.Is(e.err, os.ErrPermission):
+		return fmt.Sprintf("permission denied on node socket %s", e.path)
+	}
+	return fmt.Sprintf("couldn't connect to node socket %s: %v", e.path, e.err)
+}
+
+func (e *socketError) Unwrap() error {
+	return e.err
+}
+
+// Temporary tells gRPC that a blocking dial fails right away instead of waiting for its deadline,
+// so the error is reported
+func (e *socketError) Temporary() bool {
+	return false
+}
+
+// socketTarget returns the dial target of a unix:// server. The passthrough scheme hands the
+// server to dialSocket as it is.
+func socketTarget(server string) string {
+	return "passthrough:///" + server
+}
+
+// dialSocket connects to a node over the Unix domain socket of a unix:// server
+func dialSocket(ctx context.Context, server string) (net.Conn, error) {
+	path, ok := common.SocketPath(server)
+	if !ok {
+		path = server
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, &socketError{path: path, err: err}
+	}
+	return conn, nil
+}
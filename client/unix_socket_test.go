@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"google.golang.org/grpc"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+func TestUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix domain sockets aren't tested on windows")
+	}
+	backoff := readRetryBackoff
+	readRetryBackoff = common.Backoff{Initial: time.Millisecond, Max: time.Millisecond, Attempts: backoff.Attempts}
+	defer func() { readRetryBackoff = backoff }()
+
+	dir, err := ioutil.TempDir("", "smrepl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "grpc.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	apitypes.RegisterNodeServiceServer(server, &slowNodeService{})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	tests := []struct {
+		name    string
+		path    string
+		setup   func(t *testing.T)
+		wantErr string
+	}{
+		{"through the socket", path, nil, ""},
+		{"missing socket", filepath.Join(dir, "missing.sock"), nil, "no node socket at"},
+		{"permission denied", path, func(t *testing.T) {
+			if os.Geteuid() == 0 {
+				t.Skip("root may open any socket")
+			}
+			if err := os.Chmod(path, 0); err != nil {
+				t.Fatal(err)
+			}
+		}, "permission denied on node socket"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup(t)
+			}
+			client := newGRPCClient([]string{common.UnixSocketScheme + tt.path}, false, "", nil, common.MessageLimits{})
+			if err := client.Connect(); err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			_, err := client.NodeStatus(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected the call to succeed, got %v", err)
+				}
+				if info := client.ServerInfo(); !strings.HasPrefix(info, "socket "+tt.path) {
+					t.Fatalf("expected the socket path in the server info, got %s", info)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected an error with %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,595 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+	"github.com/spacemeshos/smrepl/smWallet"
+)
+
+// defaultWalletFilePrefix is used for new wallet files when no wallet name is provided
+const defaultWalletFilePrefix = "my_wallet"
+
+// isWalletFile returns true if the file at path is a readable wallet file
+func isWalletFile(path string) bool {
+	w, err := smWallet.LoadWallet(path)
+	if err != nil {
+		return false
+	}
+	return w.Meta.Created != "" && w.Crypto.CipherText != ""
+}
+
+// ListWallets returns the paths of the wallet files in the wallets directory
+func (w *WalletBackend) ListWallets() ([]string, error) {
+	files, err := walkMatch(w.workingDirectory, "*.json")
+	if err != nil {
+		return nil, err
+	}
+	wallets := make([]string, 0, len(files))
+	for _, f := range files {
+		if isWalletFile(f) {
+			wallets = append(wallets, f)
+		}
+	}
+	return wallets, nil
+}
+
+// WalletName returns the display name of the open wallet or an empty string when no wallet is open
+func (w *WalletBackend) WalletName() string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.wallet == nil {
+		return ""
+	}
+	return w.wallet.Meta.DisplayName
+}
+
+// findWallet returns the path of a wallet file in the wallets directory identified by its
+// file name, file name prefix or display name
+func (w *WalletBackend) findWallet(name string) (string, error) {
+	wallets, err := w.ListWallets()
+	if err != nil {
+		return "", err
+	}
+	var matches []string
+	for _, path := range wallets {
+		base := filepath.Base(path)
+		if base == name || strings.HasPrefix(base, name+"_") {
+			matches = append(matches, path)
+			continue
+		}
+		if wallet, err := smWallet.LoadWallet(path); err == nil && wallet.Meta.DisplayName == name {
+			matches = append(matches, path)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no wallet named %s in %s", name, w.workingDirectory)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", errors.New("more than one wallet matches " + name + ": " + strings.Join(matches, ", "))
+	}
+}
+
+// SwitchWallet closes the open wallet (if any) and opens the wallet identified by name
+func (w *WalletBackend) SwitchWallet(name string) error {
+	path, err := w.findWallet(name)
+	if err != nil {
+		return err
+	}
+	wallet, err := w.loadWallet(path)
+	if err != nil {
+		return err
+	}
+	password, err := w.getPassword()
+	if err != nil {
+		return err
+	}
+	fmt.Println("\nloading...")
+	if err = wallet.Unlock(password); err != nil {
+		return err
+	}
+	ne, err := wallet.GetNumberOfAccounts()
+	if err != nil {
+		return err
+	}
+
+	w.CloseWallet()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.wallet = wallet
+	w.open = true
+	w.restoreCurrentAccount()
+	fmt.Println(w.wallet.Meta.DisplayName, "successfully opened with", accounts(ne))
+	return nil
+}
+
+// checksumFileSuffix is appended to a backup file name to get the name of its checksum file
+const checksumFileSuffix = ".sha256"
+
+// fileChecksum returns the hex encoded sha256 checksum of a file
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// BackupWallet copies the open wallet file to path and writes its sha256 checksum next to it.
+// It returns the checksum.
+func (w *WalletBackend) BackupWallet(path string) (string, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.wallet == nil {
+		return "", common.ErrNoWallet
+	}
+	src := w.wallet.WalletPath()
+	if src == "" {
+		return "", errors.New("the wallet has not been saved to a file")
+	}
+	if srcInfo, err := os.Stat(src); err != nil {
+		return "", err
+	} else if dstInfo, err := os.Stat(path); err == nil && os.SameFile(srcInfo, dstInfo) {
+		return "", errors.New("refusing to back up the wallet onto itself")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), common.PrivateDirMode); err != nil {
+		return "", err
+	}
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return "", err
+	}
+	if err := common.WritePrivateFile(path, data); err != nil {
+		return "", err
+	}
+
+	checksum, err := fileChecksum(path)
+	if err != nil {
+		return "", err
+	}
+	// same format as sha256sum so the backup can also be checked with standard tools
+	line := fmt.Sprintf("%s  %s\n", checksum, filepath.Base(path))
+	if err := common.WritePrivateFile(path+checksumFileSuffix, []byte(line)); err != nil {
+		return "", err
+	}
+	return checksum, nil
+}
+
+// VerifyBackup checks a wallet backup against its checksum file and returns the accounts it holds.
+// The backup is not imported.
+func (w *WalletBackend) VerifyBackup(path string) ([]common.AccountSummary, error) {
+	line, err := ioutil.ReadFile(path + checksumFileSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checksum file: %v", err)
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return nil, errors.New("checksum file is empty")
+	}
+	checksum, err := fileChecksum(path)
+	if err != nil {
+		return nil, err
+	}
+	if checksum != fields[0] {
+		return nil, fmt.Errorf("checksum mismatch: expected %s, got %s", fields[0], checksum)
+	}
+
+	backup, err := smWallet.LoadWallet(path)
+	if err != nil {
+		return nil, err
+	}
+	password, err := w.getPassword()
+	fmt.Println()
+	if err != nil {
+		return nil, err
+	}
+	if err := backup.Unlock(password); err != nil {
+		return nil, err
+	}
+	return walletAccounts(backup)
+}
+
+// ImportSmappWallet converts a wallet file created by Smapp into a wallet in the wallets directory.
+// It returns the path of the new wallet file and the accounts it holds.
+func (w *WalletBackend) ImportSmappWallet(path string) (string, []common.AccountSummary, error) {
+	password, err := w.getPassword()
+	fmt.Println()
+	if err != nil {
+		return "", nil, err
+	}
+	imported, err := smWallet.ImportSmapp(path, password)
+	if err != nil {
+		return "", nil, err
+	}
+
+	wallets, err := w.ListWallets()
+	if err != nil {
+		return "", nil, err
+	}
+	for _, existing := range wallets {
+		if wallet, err := smWallet.LoadWallet(existing); err == nil && wallet.Meta.Created == imported.Meta.Created {
+			return "", nil, fmt.Errorf("this wallet has already been imported as %s", existing)
+		}
+	}
+
+	if err := imported.SaveWalletAs(filepath.Join(w.workingDirectory, defaultWalletFilePrefix)); err != nil {
+		return "", nil, err
+	}
+	accounts, err := walletAccounts(imported)
+	if err != nil {
+		return "", nil, err
+	}
+	return imported.WalletPath(), accounts, nil
+}
+
+// ExportSmappWallet writes a copy of the open wallet that Smapp can open to path. It returns the
+// number of watch-only accounts left out as Smapp has none.
+func (w *WalletBackend) ExportSmappWallet(path string) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.wallet == nil {
+		return 0, common.ErrNoWallet
+	}
+	if srcInfo, err := os.Stat(w.wallet.WalletPath()); err == nil {
+		if dstInfo, err := os.Stat(path); err == nil && os.SameFile(srcInfo, dstInfo) {
+			return 0, errors.New("refusing to export the wallet onto itself")
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), common.PrivateDirMode); err != nil {
+		return 0, err
+	}
+	return w.wallet.ExportSmapp(path)
+}
+
+// MergeWallet adds the accounts of the wallet file at path that are not in the open wallet. With
+// dryRun the open wallet is left unchanged.
+func (w *WalletBackend) MergeWallet(path string, dryRun bool) (*common.MergeResult, error) {
+	if srcInfo, err := os.Stat(w.wallet.WalletPath()); err == nil {
+		if dstInfo, err := os.Stat(path); err == nil && os.SameFile(srcInfo, dstInfo) {
+			return nil, errors.New("refusing to merge the wallet into itself")
+		}
+	}
+	other, err := smWallet.LoadWallet(path)
+	if err != nil {
+		return nil, err
+	}
+	password, err := w.getPassword()
+	fmt.Println()
+	if err != nil {
+		return nil, err
+	}
+	if err := other.Unlock(password); err != nil {
+		return nil, err
+	}
+	defer other.Lock()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.wallet.Merge(other, dryRun)
+}
+
+// WalletConflict returns the differences between the open wallet and its file when another program
+// changed the file and the open wallet has changes which weren't saved over it. It returns nil when
+// there is no conflict.
+func (w *WalletBackend) WalletConflict() (*common.WalletConflict, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wallet == nil || !w.wallet.HasUnsavedChanges() {
+		return nil, nil
+	}
+	changed, err := w.wallet.ChangedOnDisk()
+	if err != nil || !changed {
+		return nil, err
+	}
+	conflict := &common.WalletConflict{Path: w.wallet.WalletPath()}
+	disk, err := w.wallet.DiskVersion()
+	if err != nil {
+		conflict.DiskError = err
+		return conflict, nil
+	}
+	defer disk.Lock()
+	onDisk, err := walletAccounts(disk)
+	if err != nil {
+		conflict.DiskError = err
+		return conflict, nil
+	}
+	inMemory, err := walletAccounts(w.wallet)
+	if err != nil {
+		return nil, err
+	}
+	conflict.OnDiskOnly = missingAccounts(onDisk, inMemory)
+	conflict.InMemoryOnly = missingAccounts(inMemory, onDisk)
+	return conflict, nil
+}
+
+// missingAccounts returns the accounts of a whose address isn't in b
+func missingAccounts(a, b []common.AccountSummary) []common.AccountSummary {
+	in := make(map[gosmtypes.Address]bool, len(b))
+	for _, acc := range b {
+		in[acc.Address] = true
+	}
+	var res []common.AccountSummary
+	for _, acc := range a {
+		if !in[acc.Address] {
+			res = append(res, acc)
+		}
+	}
+	return res
+}
+
+// ReloadWallet replaces the open wallet with its file, dropping the unsaved changes
+func (w *WalletBackend) ReloadWallet() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wallet == nil {
+		return common.ErrNoWallet
+	}
+	disk, err := w.wallet.DiskVersion()
+	if err != nil {
+		return err
+	}
+	w.wallet.Lock()
+	w.wallet = disk
+	w.restoreCurrentAccount()
+	return nil
+}
+
+// MergeWalletFile adds the accounts of the wallet file which aren't in the open wallet, then saves
+// the open wallet over the file. The file must be encrypted with the password of the open wallet.
+func (w *WalletBackend) MergeWalletFile() (*common.MergeResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wallet == nil {
+		return nil, common.ErrNoWallet
+	}
+	disk, err := w.wallet.DiskVersion()
+	if err != nil {
+		return nil, err
+	}
+	defer disk.Lock()
+	w.wallet.AcceptDiskVersion(disk)
+	res, err := w.wallet.Merge(disk, false)
+	if err != nil {
+		return nil, err
+	}
+	if w.wallet.HasUnsavedChanges() {
+		if err := w.wallet.SaveWallet(); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// OverwriteWalletFile saves the open wallet over its file, dropping the changes another program
+// made to the file
+func (w *WalletBackend) OverwriteWalletFile() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wallet == nil {
+		return common.ErrNoWallet
+	}
+	return w.wallet.OverwriteWallet()
+}
+
+// walletAccounts returns the names and addresses of an unlocked wallet's accounts
+func walletAccounts(wallet *smWallet.Wallet) ([]common.AccountSummary, error) {
+	n, err := wallet.GetNumberOfAccounts()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]common.AccountSummary, 0, n)
+	for i := 0; i < n; i++ {
+		name, err := wallet.GetAccountDisplayName(i)
+		if err != nil {
+			return nil, err
+		}
+		addr, err := wallet.GetAddress(i)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, common.AccountSummary{Name: name, Address: addr})
+	}
+	return res, nil
+}
+
+// addressBook returns the address book stored in the wallets directory
+func (w *WalletBackend) addressBook() (*common.AddressBook, error) {
+	if w.contacts == nil {
+		book, err := common.LoadAddressBook(filepath.Join(w.workingDirectory, common.ContactsFileName))
+		if err != nil {
+			return nil, err
+		}
+		w.contacts = book
+	}
+	return w.contacts, nil
+}
+
+// Config returns the settings stored in the wallets directory
+func (w *WalletBackend) Config() (*common.Config, error) {
+	w.configMu.Lock()
+	defer w.configMu.Unlock()
+	if w.config == nil {
+		config, err := common.LoadConfig(filepath.Join(w.workingDirectory, common.ConfigFileName))
+		if err != nil {
+			return nil, err
+		}
+		w.config = config
+	}
+	return w.config, nil
+}
+
+// ReloadConfig reads the settings stored in the wallets directory again, e.g. after they were
+// edited, and applies them to the following calls. The settings are kept when the file can't be
+// read.
+func (w *WalletBackend) ReloadConfig() error {
+	config, err := common.LoadConfig(filepath.Join(w.workingDirectory, common.ConfigFileName))
+	if err != nil {
+		return err
+	}
+	w.configMu.Lock()
+	w.config = config
+	w.configMu.Unlock()
+	w.ApplyConfig()
+	return nil
+}
+
+// ApplyConfig applies the call timeout, the state cache time and verbose mode of the settings to
+// the following calls. In verbose mode retried reads, cache hits and debug logs are printed.
+func (w *WalletBackend) ApplyConfig() {
+	timeout := common.DefaultCallTimeout
+	cacheTTL := common.DefaultCacheTTL
+	verbose := false
+	if config, err := w.Config(); err == nil {
+		timeout = config.CallTimeout()
+		cacheTTL = config.StateCacheTTL()
+		verbose = config.Verbose
+	}
+	w.setCallSettings(timeout, verbose)
+	w.cache.setTTL(cacheTTL)
+	if verbose {
+		log.SetConsoleLevel(log.LevelDebug)
+	} else {
+		log.SetConsoleLevel(log.LevelInfo)
+	}
+}
+
+// Contacts returns the address book entries sorted by name
+func (w *WalletBackend) Contacts() ([]common.Contact, error) {
+	book, err := w.addressBook()
+	if err != nil {
+		return nil, err
+	}
+	return book.Contacts, nil
+}
+
+// AddContact adds a named address to the address book and saves it
+func (w *WalletBackend) AddContact(name string, address gosmtypes.Address) error {
+	book, err := w.addressBook()
+	if err != nil {
+		return err
+	}
+	if err := book.Add(name, address); err != nil {
+		return err
+	}
+	return book.Save()
+}
+
+// DeleteContact removes a named address from the address book and saves it
+func (w *WalletBackend) DeleteContact(name string) error {
+	book, err := w.addressBook()
+	if err != nil {
+		return err
+	}
+	if err := book.Delete(name); err != nil {
+		return err
+	}
+	return book.Save()
+}
+
+// SmesherContacts returns the named smesher ids of the address book sorted by name
+func (w *WalletBackend) SmesherContacts() ([]common.SmesherContact, error) {
+	book, err := w.addressBook()
+	if err != nil {
+		return nil, err
+	}
+	return book.Smeshers, nil
+}
+
+// AddSmesherContact adds a named smesher id to the address book and saves it
+func (w *WalletBackend) AddSmesherContact(name string, id []byte) error {
+	book, err := w.addressBook()
+	if err != nil {
+		return err
+	}
+	if err := book.AddSmesher(name, id); err != nil {
+		return err
+	}
+	return book.Save()
+}
+
+// DeleteSmesherContact removes a named smesher id from the address book and saves it
+func (w *WalletBackend) DeleteSmesherContact(name string) error {
+	book, err := w.addressBook()
+	if err != nil {
+		return err
+	}
+	if err := book.DeleteSmesher(name); err != nil {
+		return err
+	}
+	return book.Save()
+}
+
+// templateBook returns the transaction templates stored in the wallets directory
+func (w *WalletBackend) templateBook() (*common.TemplateBook, error) {
+	if w.templates == nil {
+		book, err := common.LoadTemplateBook(filepath.Join(w.workingDirectory, common.TemplatesFileName))
+		if err != nil {
+			return nil, err
+		}
+		w.templates = book
+	}
+	return w.templates, nil
+}
+
+// TxTemplates returns the transaction templates sorted by name
+func (w *WalletBackend) TxTemplates() ([]common.TxTemplate, error) {
+	book, err := w.templateBook()
+	if err != nil {
+		return nil, err
+	}
+	return book.Templates, nil
+}
+
+// TxTemplate returns a transaction template by name
+func (w *WalletBackend) TxTemplate(name string) (*common.TxTemplate, error) {
+	book, err := w.templateBook()
+	if err != nil {
+		return nil, err
+	}
+	t, ok := book.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("no template named %s", name)
+	}
+	return t, nil
+}
+
+// AddTxTemplate adds a transaction template and saves the templates
+func (w *WalletBackend) AddTxTemplate(t common.TxTemplate) error {
+	book, err := w.templateBook()
+	if err != nil {
+		return err
+	}
+	if err := book.Add(t); err != nil {
+		return err
+	}
+	return book.Save()
+}
+
+// DeleteTxTemplate removes a transaction template and saves the templates
+func (w *WalletBackend) DeleteTxTemplate(name string) error {
+	book, err := w.templateBook()
+	if err != nil {
+		return err
+	}
+	if err := book.Delete(name); err != nil {
+		return err
+	}
+	return book.Save()
+}
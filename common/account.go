@@ -2,22 +2,149 @@ package common
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
+	"unicode"
 
 	"github.com/spacemeshos/ed25519"
 	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
 )
 
+// ErrWatchOnly is returned when a private key is required from a watch-only account
+var ErrWatchOnly = errors.New("watch-only account — no private key")
+
+// ErrNoWallet is returned when an account is requested while no wallet is open
+var ErrNoWallet = errors.New("no wallet is open")
+
+// ErrWalletChanged is returned when the wallet file was changed by another program since it was
+// loaded, and saving would overwrite the changes
+var ErrWalletChanged = errors.New("the wallet file was changed by another program since it was loaded")
+
+// ErrAliasTaken is returned when an account alias is already used by another account
+var ErrAliasTaken = errors.New("an account with this alias already exists")
+
+// ErrInvalidAlias is returned for blank account aliases or aliases with characters that can't be displayed
+var ErrInvalidAlias = errors.New("account alias must not be blank or contain control characters")
+
+// NormalizeAlias trims an account alias and verifies that it can be displayed on a single line
+func NormalizeAlias(alias string) (string, error) {
+	alias = strings.TrimSpace(alias)
+	if alias == "" {
+		return "", ErrInvalidAlias
+	}
+	for _, c := range alias {
+		if unicode.IsControl(c) {
+			return "", ErrInvalidAlias
+		}
+	}
+	return alias, nil
+}
+
+// ErrInvalidTag is returned for blank account tags or tags with whitespace or control characters
+var ErrInvalidTag = errors.New("account tag must be a single word")
+
+// NormalizeTag trims and lower cases an account tag and verifies that it is a single word
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", ErrInvalidTag
+	}
+	for _, c := range tag {
+		if unicode.IsSpace(c) || unicode.IsControl(c) {
+			return "", ErrInvalidTag
+		}
+	}
+	return tag, nil
+}
+
+// HasTag returns true if tags holds tag
+func HasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 type LocalAccount struct {
 	Name    string
-	PrivKey ed25519.PrivateKey // the pub & private key
-	PubKey  ed25519.PublicKey  // only the pub key part
+	PrivKey ed25519.PrivateKey // the pub & private key. nil for watch-only accounts
+	PubKey  ed25519.PublicKey  // only the pub key part. nil for watch-only accounts
+
+	WatchAddress gosmtypes.Address // the address of a watch-only account
+
+	Note string   // free-text note set by the user
+	Tags []string // labels set by the user, e.g. smesher
+
+	GasPrice uint64 // default gas price of the account's transactions, 0 if not set
+	GasLimit uint64 // default gas limit of the account's transactions, 0 if not set
+
+	Origin AccountOrigin // where the key of the account comes from
+}
+
+// IsWatchOnly returns true if the account has no keys
+func (a *LocalAccount) IsWatchOnly() bool {
+	return len(a.PubKey) == 0
+}
+
+// SigningKey moves the private key of the account to a signing key. The caller must release the
+// signing key when done.
+func (a *LocalAccount) SigningKey() (*SigningKey, error) {
+	if a.IsWatchOnly() {
+		return nil, ErrWatchOnly
+	}
+	if len(a.PrivKey) == 0 {
+		return nil, errors.New("the private key of this account has been released")
+	}
+	key := &SigningKey{key: a.PrivKey}
+	a.PrivKey = nil
+	return key, nil
+}
+
+// Wipe overwrites the private key of the account
+func (a *LocalAccount) Wipe() {
+	Zero(a.PrivKey)
+	a.PrivKey = nil
 }
 
 func (a *LocalAccount) Address() gosmtypes.Address {
+	if len(a.PubKey) == 0 {
+		return a.WatchAddress
+	}
 	return gosmtypes.BytesToAddress(a.PubKey[:])
 }
 
+// AccountSummary identifies an account without any of its key material
+type AccountSummary struct {
+	Name    string
+	Address gosmtypes.Address
+}
+
+// MergeResult summarizes the merge of another wallet's accounts into a wallet
+type MergeResult struct {
+	// Imported holds the display names of the imported accounts in this wallet
+	Imported []string
+	// Skipped holds the display names of the other wallet's accounts that are already in this wallet
+	Skipped []string
+	// Renamed maps the display names of the other wallet's accounts that collided with an account
+	// of this wallet to their new names
+	Renamed map[string]string
+}
+
+// WalletConflict describes a wallet file changed by another program while the open wallet has
+// changes which couldn't be saved over it
+type WalletConflict struct {
+	Path string
+	// OnDiskOnly holds the accounts in the file which aren't in the open wallet, and InMemoryOnly
+	// the accounts of the open wallet which aren't in the file. Both are empty when the file can't
+	// be decrypted with the password of the open wallet, which DiskError explains.
+	OnDiskOnly   []AccountSummary
+	InMemoryOnly []AccountSummary
+	DiskError    error
+}
+
 type AccountState struct {
 	Nonce            uint64
 	Balance          uint64
@@ -36,7 +163,7 @@ func (s Store) GetAccount(name string) (*LocalAccount, error) {
 			return nil, err
 		}
 
-		return &LocalAccount{name, priv, pub}, nil
+		return &LocalAccount{Name: name, PrivKey: priv, PubKey: pub}, nil
 	}
 	return nil, fmt.Errorf("account not found")
 }
@@ -0,0 +1,26 @@
+package common
+
+import "testing"
+
+func TestNormalizeAlias(t *testing.T) {
+	valid := map[string]string{
+		"alias":           "alias",
+		"  padded alias ": "padded alias",
+		"עברית":           "עברית",
+	}
+	for in, expected := range valid {
+		got, err := NormalizeAlias(in)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", in, err)
+		}
+		if got != expected {
+			t.Fatalf("expected %q, got %q", expected, got)
+		}
+	}
+
+	for _, in := range []string{"", "   ", "new\nline", "tab\tbed", "bell\a"} {
+		if _, err := NormalizeAlias(in); err != ErrInvalidAlias {
+			t.Fatalf("expected invalid alias error for %q, got %v", in, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// SnapshotState is the global state an accounts snapshot was taken at
+type SnapshotState struct {
+	Layer uint32 `json:"layer"`
+	Hash  string `json:"hash"`
+}
+
+// SnapshotAccount is an account of an accounts snapshot. The balance is in Smidge and exported as
+// a string so no tool reads it as a float.
+type SnapshotAccount struct {
+	Address string `json:"address"`
+	Balance uint64 `json:"balance,string"`
+	Counter uint64 `json:"counter"`
+}
+
+// AccountsSnapshotWriter writes an accounts snapshot as a JSON object with the global state before
+// the accounts were read, the accounts and the global state after, one account at a time so the
+// accounts aren't held in memory:
+//
+//	{"before": {...}, "accounts": [{...}, ...], "after": {...}}
+type AccountsSnapshotWriter struct {
+	w     *bufio.Writer
+	count int
+}
+
+// NewAccountsSnapshotWriter starts a snapshot taken at the before state on w
+func NewAccountsSnapshotWriter(w io.Writer, before SnapshotState) (*AccountsSnapshotWriter, error) {
+	s := &AccountsSnapshotWriter{w: bufio.NewWriter(w)}
+	data, err := json.Marshal(before)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.w.WriteString("{\n  \"before\": " + string(data) + ",\n  \"accounts\": ["); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add writes an account
+func (s *AccountsSnapshotWriter) Add(account SnapshotAccount) error {
+	data, err := json.Marshal(account)
+	if err != nil {
+		return err
+	}
+	sep := ",\n    "
+	if s.count == 0 {
+		sep = "\n    "
+	}
+	s.count++
+	_, err = s.w.WriteString(sep + string(data))
+	return err
+}
+
+// Count returns the number of accounts written
+func (s *AccountsSnapshotWriter) Count() int {
+	return s.count
+}
+
+// Close ends the snapshot with the after state and flushes it. It doesn't close the underlying
+// writer.
+func (s *AccountsSnapshotWriter) Close(after SnapshotState) error {
+	data, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+	end := "\n  ],\n  \"after\": " + string(data) + "\n}\n"
+	if s.count == 0 {
+		end = "],\n  \"after\": " + string(data) + "\n}\n"
+	}
+	if _, err := s.w.WriteString(end); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
@@ -0,0 +1,46 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestAccountsSnapshotWriter(t *testing.T) {
+	type snapshot struct {
+		Before   SnapshotState     `json:"before"`
+		Accounts []SnapshotAccount `json:"accounts"`
+		After    SnapshotState     `json:"after"`
+	}
+	for _, accounts := range [][]SnapshotAccount{
+		{},
+		{{Address: "0x01", Balance: 18446744073709551615, Counter: 3}, {Address: "0x02", Balance: 5}},
+	} {
+		var buf bytes.Buffer
+		w, err := NewAccountsSnapshotWriter(&buf, SnapshotState{Layer: 10, Hash: "0xaa"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, a := range accounts {
+			if err := w.Add(a); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(SnapshotState{Layer: 11, Hash: "0xbb"}); err != nil {
+			t.Fatal(err)
+		}
+		if w.Count() != len(accounts) {
+			t.Fatalf("expected %d accounts counted, got %d", len(accounts), w.Count())
+		}
+
+		var got snapshot
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON %s: %v", buf.String(), err)
+		}
+		want := snapshot{Before: SnapshotState{10, "0xaa"}, Accounts: accounts, After: SnapshotState{11, "0xbb"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected %+v, got %+v", want, got)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package common
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Kinds of account activity besides the transaction directions DirectionIn, DirectionOut and
+// DirectionSelf
+const (
+	// ActivityReward is a smeshing reward
+	ActivityReward = "reward"
+	// ActivityReceipt is the receipt of a transaction the node didn't return
+	ActivityReceipt = "receipt"
+)
+
+// ActivityEvent is an entry of the activity feed of an account. Amounts are in Smidge. Layer is nil
+// when the layer isn't known yet.
+type ActivityEvent struct {
+	Layer        *uint32
+	Kind         string
+	Amount       uint64
+	Fee          uint64
+	Counterparty string
+	TxID         string
+}
+
+// MergeActivity merges the events of transactions, receipts and rewards, newest layer first.
+// Events without a layer, such as transactions not yet applied, come first. A receipt event is
+// dropped when there is a transaction event with the same id as the transaction already includes
+// what the receipt tells.
+func MergeActivity(txs, receipts, rewards []ActivityEvent) []ActivityEvent {
+	seen := make(map[string]bool, len(txs))
+	events := make([]ActivityEvent, 0, len(txs)+len(receipts)+len(rewards))
+	for _, e := range txs {
+		seen[e.TxID] = true
+		events = append(events, e)
+	}
+	for _, e := range receipts {
+		if !seen[e.TxID] {
+			events = append(events, e)
+		}
+	}
+	events = append(events, rewards...)
+	sort.SliceStable(events, func(i, j int) bool {
+		li, lj := events[i].Layer, events[j].Layer
+		if li == nil || lj == nil {
+			return li == nil && lj != nil
+		}
+		return *li > *lj
+	})
+	return events
+}
+
+// activityHeader is the header row of an activity CSV export
+var activityHeader = []string{"layer", "kind", "amount", "fee", "counterparty", "id"}
+
+// WriteActivityCSV writes activity events as CSV with a header row
+func WriteActivityCSV(w io.Writer, events []ActivityEvent) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(activityHeader); err != nil {
+		return err
+	}
+	for _, e := range events {
+		layer := ""
+		if e.Layer != nil {
+			layer = strconv.FormatUint(uint64(*e.Layer), 10)
+		}
+		if err := cw.Write([]string{
+			layer,
+			e.Kind,
+			strconv.FormatUint(e.Amount, 10),
+			strconv.FormatUint(e.Fee, 10),
+			e.Counterparty,
+			e.TxID,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,59 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+)
+
+func layerPtr(l uint32) *uint32 {
+	return &l
+}
+
+func TestMergeActivity(t *testing.T) {
+	txs := []ActivityEvent{
+		{Layer: layerPtr(10), Kind: DirectionOut, Amount: 3, TxID: "0x01"},
+		{Kind: DirectionOut, Amount: 4, TxID: "0x02"},
+	}
+	receipts := []ActivityEvent{
+		{Layer: layerPtr(10), Kind: ActivityReceipt, Fee: 1, TxID: "0x01"},
+		{Layer: layerPtr(8), Kind: ActivityReceipt, Fee: 2, TxID: "0x03"},
+	}
+	rewards := []ActivityEvent{
+		{Layer: layerPtr(12), Kind: ActivityReward, Amount: 50},
+		{Layer: layerPtr(9), Kind: ActivityReward, Amount: 50},
+	}
+	events := MergeActivity(txs, receipts, rewards)
+	expected := []string{"0x02", "", "0x01", "", "0x03"}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %d: %+v", len(expected), len(events), events)
+	}
+	for i, id := range expected {
+		if events[i].TxID != id {
+			t.Fatalf("event %d: expected %q, got %+v", i, id, events[i])
+		}
+	}
+	if events[1].Kind != ActivityReward || *events[1].Layer != 12 {
+		t.Fatalf("expected the reward of layer 12 after the pending transaction, got %+v", events[1])
+	}
+
+	if events := MergeActivity(nil, receipts, nil); len(events) != 2 {
+		t.Fatalf("expected the receipts when the transactions are missing, got %d events", len(events))
+	}
+}
+
+func TestWriteActivityCSV(t *testing.T) {
+	events := []ActivityEvent{
+		{Layer: layerPtr(10432), Kind: ActivityReward, Amount: 12500000000000},
+		{Kind: DirectionOut, Amount: 3, Fee: 1, Counterparty: "0x02", TxID: "0x01"},
+	}
+	var buf bytes.Buffer
+	if err := WriteActivityCSV(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	expected := "layer,kind,amount,fee,counterparty,id\n" +
+		"10432,reward,12500000000000,0,,\n" +
+		",out,3,1,0x02,0x01\n"
+	if buf.String() != expected {
+		t.Fatalf("unexpected CSV:\n%s", buf.String())
+	}
+}
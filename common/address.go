@@ -0,0 +1,64 @@
+package common
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcutil/bech32"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// exampleAddress is shown in address parsing errors
+const exampleAddress = "0x7fa75881ca0050028b32f424f860e3a73d4bf168"
+
+// ParseAddress strictly parses a hex or bech32 address. A hex address must be exactly 40 hex
+// characters with an optional 0x prefix. Unlike gosmtypes.HexToAddress, malformed input is rejected.
+func ParseAddress(s string) (gosmtypes.Address, error) {
+	str := strings.TrimSpace(s)
+	if strings.HasPrefix(str, "0x") || strings.HasPrefix(str, "0X") {
+		str = str[2:]
+	} else if _, _, err := bech32.Decode(str); err == nil || strings.HasPrefix(strings.ToLower(str), AddressHRP+"1") {
+		return DecodeBech32Address(str)
+	}
+	if len(str) != 2*gosmtypes.AddressLength {
+		return gosmtypes.Address{}, fmt.Errorf("invalid address %q: expected %d hex characters, got %d. Example of a valid address: %s",
+			s, 2*gosmtypes.AddressLength, len(str), exampleAddress)
+	}
+	b, err := hex.DecodeString(str)
+	if err != nil {
+		return gosmtypes.Address{}, fmt.Errorf("invalid address %q: not a hex string. Example of a valid address: %s", s, exampleAddress)
+	}
+	return gosmtypes.BytesToAddress(b), nil
+}
+
+// RecipientCheck holds the warnings about the recipient of a transaction
+type RecipientCheck struct {
+	Warnings []string
+	// Zero is set for the all-zero address. Coins sent to it can't be spent by anyone.
+	Zero bool
+}
+
+// CheckRecipient warns about recipients that are most likely a mistake: the sender itself, the
+// all-zero address and addresses made of one repeated byte
+func CheckRecipient(sender, recipient gosmtypes.Address) RecipientCheck {
+	var check RecipientCheck
+	if recipient == sender {
+		check.Warnings = append(check.Warnings, "you are sending to yourself")
+	}
+	repeated := true
+	for _, b := range recipient.Bytes() {
+		if b != recipient.Bytes()[0] {
+			repeated = false
+			break
+		}
+	}
+	switch {
+	case recipient == gosmtypes.Address{}:
+		check.Zero = true
+		check.Warnings = append(check.Warnings, "the recipient is the zero address, coins sent to it are lost")
+	case repeated:
+		check.Warnings = append(check.Warnings, "the recipient address is one repeated byte, it was probably not pasted correctly")
+	}
+	return check
+}
@@ -0,0 +1,71 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func TestParseAddress(t *testing.T) {
+	valid := []string{
+		"0x7fa75881ca0050028b32f424f860e3a73d4bf168",
+		"7fa75881ca0050028b32f424f860e3a73d4bf168",
+		"0X7FA75881CA0050028B32F424F860E3A73D4BF168",
+		"  0x7fa75881ca0050028b32f424f860e3a73d4bf168 ",
+	}
+	expected, err := ParseAddress(valid[0])
+	if err != nil {
+		t.Fatalf("unexpected error for %q: %v", valid[0], err)
+	}
+	for _, s := range valid[1:] {
+		addr, err := ParseAddress(s)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", s, err)
+		}
+		if addr != expected {
+			t.Fatalf("unexpected address for %q: %s", s, addr.Hex())
+		}
+	}
+
+	invalid := map[string]string{
+		"empty":      "",
+		"prefix":     "0x",
+		"short":      "0x7fa75881ca0050028b32f424f860e3a73d4bf1",
+		"long":       "0x7fa75881ca0050028b32f424f860e3a73d4bf16800",
+		"odd length": "0x7fa75881ca0050028b32f424f860e3a73d4bf16",
+		"non-hex":    "0x7fa75881ca0050028b32f424f860e3a73d4bf16z",
+		"alias":      "exchange",
+	}
+	for name, s := range invalid {
+		_, err := ParseAddress(s)
+		if err == nil {
+			t.Fatalf("%s: expected error for %q", name, s)
+		}
+		if !strings.Contains(err.Error(), exampleAddress) {
+			t.Fatalf("%s: expected error to include an example address: %v", name, err)
+		}
+	}
+}
+
+func TestCheckRecipient(t *testing.T) {
+	sender := gosmtypes.HexToAddress("0x7fa75881ca0050028b32f424f860e3a73d4bf168")
+	other := gosmtypes.HexToAddress("0x0000000000000000000000000000000000000001")
+
+	if c := CheckRecipient(sender, other); len(c.Warnings) != 0 || c.Zero {
+		t.Fatalf("expected no warnings, got %+v", c)
+	}
+	if c := CheckRecipient(sender, sender); len(c.Warnings) != 1 || c.Zero {
+		t.Fatalf("expected a self-send warning, got %+v", c)
+	}
+	if c := CheckRecipient(sender, gosmtypes.Address{}); len(c.Warnings) != 1 || !c.Zero {
+		t.Fatalf("expected a zero address warning, got %+v", c)
+	}
+	repeated := gosmtypes.HexToAddress("0xffffffffffffffffffffffffffffffffffffffff")
+	if c := CheckRecipient(sender, repeated); len(c.Warnings) != 1 || c.Zero {
+		t.Fatalf("expected a repeated byte warning, got %+v", c)
+	}
+	if c := CheckRecipient(repeated, repeated); len(c.Warnings) != 2 {
+		t.Fatalf("expected self-send and repeated byte warnings, got %+v", c)
+	}
+}
@@ -0,0 +1,125 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// SmidgePerSmesh is the number of Smidge in one SMH
+const SmidgePerSmesh = 1000000000000
+
+// SmeshDecimals is the number of decimal places of an SMH amount
+const SmeshDecimals = 12
+
+// smallestSMHAmount is the smallest amount FormatAmount shows in SMH, 0.01 SMH
+const smallestSMHAmount = SmidgePerSmesh / 100
+
+// FormatSmidge formats an amount in Smidge, e.g. 1500 Smidge
+func FormatSmidge(amount uint64) string {
+	return strconv.FormatUint(amount, 10) + " Smidge"
+}
+
+// FormatSMH formats an amount in SMH with at most precision decimal places and without trailing
+// zeros, e.g. 2.5 SMH. The precision is at most SmeshDecimals, with which the amount is exact.
+// Decimals beyond the precision are cut rather than rounded so that an amount is never shown larger
+// than it is, and an amount cut to nothing is shown as less than the smallest amount of the
+// precision, e.g. < 0.01 SMH. The output doesn't depend on the locale.
+func FormatSMH(amount uint64, precision int) string {
+	if precision < 0 {
+		precision = 0
+	}
+	if precision > SmeshDecimals {
+		precision = SmeshDecimals
+	}
+	whole, frac := amount/SmidgePerSmesh, amount%SmidgePerSmesh
+	decimals := fmt.Sprintf("%012d", frac)[:precision]
+	decimals = strings.TrimRight(decimals, "0")
+	if whole == 0 && decimals == "" && amount != 0 {
+		if precision == 0 {
+			return "< 1 SMH"
+		}
+		return "< 0." + strings.Repeat("0", precision-1) + "1 SMH"
+	}
+	s := strconv.FormatUint(whole, 10)
+	if decimals != "" {
+		s += "." + decimals
+	}
+	return s + " SMH"
+}
+
+// FormatAmount formats an amount for display: exactly in SMH from 0.01 SMH, e.g. 1.5 SMH, and in
+// Smidge below, e.g. 200 Smidge
+func FormatAmount(amount uint64) string {
+	if amount < smallestSMHAmount {
+		return FormatSmidge(amount)
+	}
+	return FormatSMH(amount, SmeshDecimals)
+}
+
+// FormatSMHAndSmidge formats an amount exactly in SMH and in Smidge, e.g. 2.5 SMH (2500000000000 Smidge)
+func FormatSMHAndSmidge(amount uint64) string {
+	return fmt.Sprintf("%s (%s)", FormatSMH(amount, SmeshDecimals), FormatSmidge(amount))
+}
+
+// ParseAmount parses a coin amount to Smidge. Amounts with the smh suffix are in SMH and may have
+// up to 12 decimal places, e.g. 2.5smh. Amounts without a suffix or with the smidge suffix are whole
+// Smidge.
+func ParseAmount(s string) (uint64, error) {
+	str := strings.ToLower(strings.TrimSpace(s))
+	unit := uint64(1)
+	switch {
+	case strings.HasSuffix(str, "smidge"):
+		str = strings.TrimSpace(strings.TrimSuffix(str, "smidge"))
+	case strings.HasSuffix(str, "smh"):
+		str = strings.TrimSpace(strings.TrimSuffix(str, "smh"))
+		unit = SmidgePerSmesh
+	}
+	if str == "" || strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+
+	whole, frac := str, ""
+	if i := strings.Index(str, "."); i >= 0 {
+		whole, frac = str[:i], str[i+1:]
+		if unit == 1 {
+			return 0, fmt.Errorf("invalid amount %q: Smidge amounts must be whole numbers", s)
+		}
+		if len(frac) > SmeshDecimals {
+			return 0, fmt.Errorf("invalid amount %q: at most %d decimal places are allowed", s, SmeshDecimals)
+		}
+	}
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	digits := whole + frac
+	if unit != 1 {
+		digits += strings.Repeat("0", SmeshDecimals-len(frac))
+	}
+	n, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if !n.IsUint64() {
+		return 0, fmt.Errorf("invalid amount %q: too large", s)
+	}
+	return n.Uint64(), nil
+}
+
+// SweepAmount returns the amount that empties an account with balance once the maximum fee,
+// gasPrice times gasLimit, is paid. It fails when nothing would be left to send.
+func SweepAmount(balance, gasPrice, gasLimit uint64) (uint64, error) {
+	fee := gasPrice * gasLimit
+	if gasLimit != 0 && fee/gasLimit != gasPrice {
+		return 0, errors.New("the transaction fee overflows")
+	}
+	if balance <= fee {
+		return 0, fmt.Errorf("the balance %d doesn't cover more than the maximum fee %d", balance, fee)
+	}
+	return balance - fee, nil
+}
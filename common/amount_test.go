@@ -0,0 +1,160 @@
+package common
+
+import "testing"
+
+func TestParseAmount(t *testing.T) {
+	valid := map[string]uint64{
+		"0":                          0,
+		"100":                        100,
+		"100smidge":                  100,
+		"100 Smidge":                 100,
+		" 100 SMIDGE ":               100,
+		"2.5smh":                     2500000000000,
+		"2.5 SMH":                    2500000000000,
+		"2.5Smh":                     2500000000000,
+		"1smh":                       SmidgePerSmesh,
+		"1.smh":                      SmidgePerSmesh,
+		".5smh":                      500000000000,
+		"0smh":                       0,
+		"007smh":                     7 * SmidgePerSmesh,
+		"0.000000000001smh":          1,
+		"0.999999999999smh":          SmidgePerSmesh - 1,
+		"18446744073709551615":       ^uint64(0),
+		"18446744.073709551615smh":   ^uint64(0),
+		"18446744.073709551615 SMH":  ^uint64(0),
+		"18446744.07370955161 smh":   ^uint64(0) - 5,
+		"18446744073709551615smidge": ^uint64(0),
+	}
+	for s, expected := range valid {
+		amount, err := ParseAmount(s)
+		if err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+		if amount != expected {
+			t.Fatalf("%q: expected %d, got %d", s, expected, amount)
+		}
+	}
+
+	invalid := []string{
+		"", " ", "smh", "smidge", ".smh", "-1", "+1", "-1smh", "1.5", "1.5smidge", "1,5smh", "1 000", "1_000", "0x10",
+		"1.2.3smh", "0.0000000000001smh", "1.0000000000000smh", "abc", "1e3", "1e3smh", "1sm", "1 smh smh",
+		"18446744073709551616", "18446744.073709551616smh", "18446745smh", "99999999999999999999",
+	}
+	for _, s := range invalid {
+		if _, err := ParseAmount(s); err == nil {
+			t.Fatalf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestSweepAmount(t *testing.T) {
+	amount, err := SweepAmount(1000, 2, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount != 800 {
+		t.Fatalf("expected 800, got %d", amount)
+	}
+	if amount, err = SweepAmount(201, 2, 100); err != nil || amount != 1 {
+		t.Fatalf("expected 1 Smidge above the fee, got %d, %v", amount, err)
+	}
+	if _, err := SweepAmount(200, 2, 100); err == nil {
+		t.Fatal("expected an error when the balance equals the fee")
+	}
+	if _, err := SweepAmount(199, 2, 100); err == nil {
+		t.Fatal("expected an error when the balance is below the fee")
+	}
+	if _, err := SweepAmount(0, 0, 0); err == nil {
+		t.Fatal("expected an error for an empty account")
+	}
+	if _, err := SweepAmount(^uint64(0), 1<<40, 1<<30); err == nil {
+		t.Fatal("expected a fee overflow error")
+	}
+}
+
+func TestFormatSmidge(t *testing.T) {
+	for amount, expected := range map[uint64]string{
+		0:              "0 Smidge",
+		1:              "1 Smidge",
+		1234567:        "1234567 Smidge",
+		^uint64(0):     "18446744073709551615 Smidge",
+		1000000:        "1000000 Smidge",
+		SmidgePerSmesh: "1000000000000 Smidge",
+	} {
+		if s := FormatSmidge(amount); s != expected {
+			t.Fatalf("%d: expected %q, got %q", amount, expected, s)
+		}
+	}
+}
+
+func TestFormatSMH(t *testing.T) {
+	tests := []struct {
+		amount    uint64
+		precision int
+		expected  string
+	}{
+		{0, SmeshDecimals, "0 SMH"},
+		{0, 0, "0 SMH"},
+		{1, SmeshDecimals, "0.000000000001 SMH"},
+		{42, SmeshDecimals, "0.000000000042 SMH"},
+		{SmidgePerSmesh, SmeshDecimals, "1 SMH"},
+		{SmidgePerSmesh - 1, SmeshDecimals, "0.999999999999 SMH"},
+		{2500000000000, SmeshDecimals, "2.5 SMH"},
+		{1500000000200, SmeshDecimals, "1.5000000002 SMH"},
+		{^uint64(0), SmeshDecimals, "18446744.073709551615 SMH"},
+		// decimals beyond the precision are cut, not rounded
+		{SmidgePerSmesh - 1, 2, "0.99 SMH"},
+		{1999999999999, 0, "1 SMH"},
+		{^uint64(0), 3, "18446744.073 SMH"},
+		{2500000000000, 4, "2.5 SMH"},
+		// amounts cut to nothing
+		{1, 2, "< 0.01 SMH"},
+		{9999999999, 2, "< 0.01 SMH"},
+		{10000000000, 2, "0.01 SMH"},
+		{SmidgePerSmesh - 1, 0, "< 1 SMH"},
+		{1, 11, "< 0.00000000001 SMH"},
+		// out of range precisions
+		{1, 13, "0.000000000001 SMH"},
+		{1500000000000, -1, "1 SMH"},
+	}
+	for _, test := range tests {
+		if s := FormatSMH(test.amount, test.precision); s != test.expected {
+			t.Fatalf("%d with precision %d: expected %q, got %q", test.amount, test.precision, test.expected, s)
+		}
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	for amount, expected := range map[uint64]string{
+		0:                    "0 Smidge",
+		200:                  "200 Smidge",
+		9999999999:           "9999999999 Smidge",
+		10000000000:          "0.01 SMH",
+		10000000001:          "0.010000000001 SMH",
+		1500000000000:        "1.5 SMH",
+		100 * SmidgePerSmesh: "100 SMH",
+	} {
+		if s := FormatAmount(amount); s != expected {
+			t.Fatalf("%d: expected %q, got %q", amount, expected, s)
+		}
+	}
+	if s := FormatSMHAndSmidge(2500000000000); s != "2.5 SMH (2500000000000 Smidge)" {
+		t.Fatalf("expected 2.5 SMH (2500000000000 Smidge), got %q", s)
+	}
+}
+
+// TestFormatParseRoundTrip checks that amounts formatted exactly in SMH or Smidge parse back to
+// the same amount
+func TestFormatParseRoundTrip(t *testing.T) {
+	for _, amount := range []uint64{0, 1, 42, 9999999999, 10000000000, SmidgePerSmesh - 1, SmidgePerSmesh, 1500000000200, ^uint64(0) - 1, ^uint64(0)} {
+		for _, s := range []string{FormatSMH(amount, SmeshDecimals), FormatSmidge(amount), FormatAmount(amount)} {
+			parsed, err := ParseAmount(s)
+			if err != nil {
+				t.Fatalf("%d formatted as %q: %v", amount, s, err)
+			}
+			if parsed != amount {
+				t.Fatalf("%d formatted as %q: parsed back to %d", amount, s, parsed)
+			}
+		}
+	}
+}
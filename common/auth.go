@@ -0,0 +1,35 @@
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// AuthTokenEnv is the environment variable with the token of nodes which require authentication
+const AuthTokenEnv = "SMREPL_AUTH_TOKEN"
+
+// AuthToken returns the token sent to the node: the content of the token file when one is given,
+// otherwise the AuthTokenEnv environment variable, otherwise the configured token. It is empty
+// when there is none.
+func AuthToken(tokenFile string, config *Config) (string, error) {
+	if tokenFile != "" {
+		data, err := ioutil.ReadFile(tokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read auth token file: %v", err)
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return "", fmt.Errorf("auth token file %s is empty", tokenFile)
+		}
+		return token, nil
+	}
+	if token := strings.TrimSpace(os.Getenv(AuthTokenEnv)); token != "" {
+		return token, nil
+	}
+	if config != nil {
+		return config.AuthToken, nil
+	}
+	return "", nil
+}
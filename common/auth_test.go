@@ -0,0 +1,51 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuthToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smrepl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv(AuthTokenEnv, os.Getenv(AuthTokenEnv))
+
+	config := DefaultConfig()
+	if err := config.Set("auth-token", "from-config"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := config.Get("auth-token"); value != AuthTokenHidden {
+		t.Fatalf("expected the token to be hidden, got %s", value)
+	}
+
+	os.Setenv(AuthTokenEnv, "")
+	if token, err := AuthToken("", config); err != nil || token != "from-config" {
+		t.Fatalf("expected the configured token, got %q, %v", token, err)
+	}
+	os.Setenv(AuthTokenEnv, "from-env")
+	if token, err := AuthToken("", config); err != nil || token != "from-env" {
+		t.Fatalf("expected the environment token, got %q, %v", token, err)
+	}
+	path := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(path, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if token, err := AuthToken(path, config); err != nil || token != "from-file" {
+		t.Fatalf("expected the token of the file, got %q, %v", token, err)
+	}
+	if _, err := AuthToken(filepath.Join(dir, "missing"), config); err == nil {
+		t.Fatal("expected an error for a missing token file")
+	}
+
+	if err := config.Set("auth-token", SettingOff); err != nil {
+		t.Fatal(err)
+	}
+	if config.AuthToken != "" {
+		t.Fatal("expected off to remove the token")
+	}
+}
@@ -0,0 +1,35 @@
+package common
+
+import "time"
+
+// Backoff is the wait before reconnect attempts. The first attempt waits Initial, every further
+// attempt waits twice as long as the one before, up to Max.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+	// Attempts is the number of attempts after which reconnecting gives up
+	Attempts int
+}
+
+// Delay returns the wait before an attempt, counted from 1. It returns false when the attempts are
+// used up.
+func (b Backoff) Delay(attempt int) (time.Duration, bool) {
+	if attempt < 1 || attempt > b.Attempts {
+		return 0, false
+	}
+	delay := b.Initial
+	for i := 1; i < attempt && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if delay > b.Max {
+		delay = b.Max
+	}
+	return delay, true
+}
+
+// JitteredDelay returns the wait before an attempt scaled to between half and all of Delay by
+// random, which is in [0, 1). Jitter keeps clients which failed together from retrying together.
+func (b Backoff) JitteredDelay(attempt int, random float64) (time.Duration, bool) {
+	delay, ok := b.Delay(attempt)
+	return time.Duration(float64(delay) * (0.5 + random/2)), ok
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Initial: 2 * time.Second, Max: 10 * time.Second, Attempts: 5}
+	for attempt, expected := range []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second} {
+		delay, ok := b.Delay(attempt + 1)
+		if !ok || delay != expected {
+			t.Fatalf("attempt %d: expected %v, got %v, %v", attempt+1, expected, delay, ok)
+		}
+	}
+	if _, ok := b.Delay(6); ok {
+		t.Fatal("expected the attempts to be used up")
+	}
+	if _, ok := b.Delay(0); ok {
+		t.Fatal("expected no delay before the first attempt")
+	}
+}
+
+func TestJitteredDelay(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: time.Minute, Attempts: 3}
+	if delay, ok := b.JitteredDelay(2, 0); !ok || delay != time.Second {
+		t.Fatalf("expected half of 2s, got %v, %v", delay, ok)
+	}
+	if delay, _ := b.JitteredDelay(2, 0.5); delay != 1500*time.Millisecond {
+		t.Fatalf("expected 1.5s, got %v", delay)
+	}
+	if _, ok := b.JitteredDelay(4, 0.5); ok {
+		t.Fatal("expected the attempts to be used up")
+	}
+}
@@ -0,0 +1,29 @@
+package common
+
+// The alerts of a watched balance
+const (
+	AlertBelow    = "below"
+	AlertIncrease = "increase"
+)
+
+// BalanceAlert tells when a change of a watched balance is alerted
+type BalanceAlert struct {
+	// Below alerts when the balance drops below it, if BelowSet
+	Below    uint64
+	BelowSet bool
+	// OnIncrease alerts when the balance increases
+	OnIncrease bool
+}
+
+// Check returns the alert of a balance change, AlertBelow or AlertIncrease, or an empty string when
+// it isn't alerted. Dropping below the threshold is alerted when the balance crosses it, not again
+// while it stays below.
+func (a BalanceAlert) Check(before, after uint64) string {
+	switch {
+	case a.BelowSet && before >= a.Below && after < a.Below:
+		return AlertBelow
+	case a.OnIncrease && after > before:
+		return AlertIncrease
+	}
+	return ""
+}
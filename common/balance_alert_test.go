@@ -0,0 +1,28 @@
+package common
+
+import "testing"
+
+func TestBalanceAlert(t *testing.T) {
+	alert := BalanceAlert{Below: 100, BelowSet: true}
+	for _, test := range []struct {
+		before, after uint64
+		alerted       bool
+	}{
+		{150, 120, false},
+		{120, 99, true},
+		{99, 50, false},
+		{100, 99, true},
+		{50, 150, false},
+	} {
+		if reason := alert.Check(test.before, test.after); (reason == AlertBelow) != test.alerted {
+			t.Fatalf("%d to %d: expected alerted %v, got %q", test.before, test.after, test.alerted, reason)
+		}
+	}
+	alert = BalanceAlert{OnIncrease: true}
+	if alert.Check(10, 20) != AlertIncrease || alert.Check(20, 10) != "" || alert.Check(10, 10) != "" {
+		t.Fatal("expected only increases to be alerted")
+	}
+	if (BalanceAlert{}).Check(10, 0) != "" {
+		t.Fatal("expected no alert without a threshold")
+	}
+}
@@ -0,0 +1,70 @@
+package common
+
+import "fmt"
+
+// DerivedBalance is the balance and nonce of an account at a past layer, worked out by undoing the
+// rewards and transactions applied after the layer. It's derived from what the node reports, not
+// read from the global state, so it's only as complete as the rewards and receipts are.
+type DerivedBalance struct {
+	Layer   uint32
+	Balance uint64
+	Nonce   uint64
+	// Rewards, Received, Sent and Fees are the amounts undone, applied after Layer
+	Rewards  uint64
+	Received uint64
+	Sent     uint64
+	Fees     uint64
+	// Undone is the number of rewards and transactions undone
+	Undone int
+	// Unplaced is the number of transactions left out because they have no layer yet
+	Unplaced int
+}
+
+// DeriveBalanceAt returns the balance and nonce of an account at the end of a layer from its
+// current balance and nonce and its rewards and transactions. Rewards and transactions of later
+// layers are undone: rewards and received amounts are subtracted, sent amounts and fees added
+// back, and the nonce is decremented for each transaction sent. Transactions without a layer
+// haven't been applied and are left out. An error is returned when more would be subtracted than
+// the balance, which means the data is inconsistent.
+func DeriveBalanceAt(layer uint32, balance, nonce uint64, rewards []RewardRecord, txs []TxRecord) (DerivedBalance, error) {
+	res := DerivedBalance{Layer: layer}
+	for _, r := range rewards {
+		if r.Layer > layer {
+			res.Rewards += r.Total
+			res.Undone++
+		}
+	}
+	for _, tx := range txs {
+		if tx.Layer == nil {
+			res.Unplaced++
+			continue
+		}
+		if *tx.Layer <= layer {
+			continue
+		}
+		res.Undone++
+		switch tx.Direction {
+		case DirectionIn:
+			res.Received += tx.Amount
+		case DirectionOut:
+			res.Sent += tx.Amount
+			res.Fees += tx.Fee
+		case DirectionSelf:
+			res.Fees += tx.Fee
+		}
+		if tx.Direction != DirectionIn {
+			if nonce == 0 {
+				return res, fmt.Errorf("more transactions were sent after layer %d than the nonce counts", layer)
+			}
+			nonce--
+		}
+	}
+
+	added := res.Sent + res.Fees
+	subtracted := res.Rewards + res.Received
+	if balance+added < subtracted {
+		return res, fmt.Errorf("the rewards and transactions after layer %d add up to more than the balance", layer)
+	}
+	res.Balance, res.Nonce = balance+added-subtracted, nonce
+	return res, nil
+}
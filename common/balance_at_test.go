@@ -0,0 +1,49 @@
+package common
+
+import "testing"
+
+func TestDeriveBalanceAt(t *testing.T) {
+	layer := func(n uint32) *uint32 { return &n }
+	// from a genesis balance of 1000: a reward of 100 at layer 5, 300 received at layer 10, 200
+	// sent with a fee of 10 at layer 15, a transaction to self with a fee of 5 at layer 20 and a
+	// reward of 50 at layer 25, and a pending transaction
+	rewards := []RewardRecord{{Layer: 5, Total: 100}, {Layer: 25, Total: 50}}
+	txs := []TxRecord{
+		{Layer: layer(10), Amount: 300, Fee: 1, Direction: DirectionIn},
+		{Layer: layer(15), Amount: 200, Fee: 10, Direction: DirectionOut},
+		{Layer: layer(20), Amount: 70, Fee: 5, Direction: DirectionSelf},
+		{Amount: 999, Fee: 20, Direction: DirectionOut},
+	}
+	for _, test := range []struct {
+		layer          uint32
+		balance, nonce uint64
+		undone         int
+	}{
+		{0, 1000, 0, 5},
+		{4, 1000, 0, 5},
+		{5, 1100, 0, 4},
+		{12, 1400, 0, 3},
+		{15, 1190, 1, 2},
+		{22, 1185, 2, 1},
+		{30, 1235, 2, 0},
+	} {
+		derived, err := DeriveBalanceAt(test.layer, 1235, 2, rewards, txs)
+		if err != nil {
+			t.Fatalf("layer %d: %v", test.layer, err)
+		}
+		if derived.Balance != test.balance || derived.Nonce != test.nonce || derived.Undone != test.undone || derived.Unplaced != 1 {
+			t.Fatalf("layer %d: expected balance %d, nonce %d and %d undone, got %+v", test.layer, test.balance, test.nonce, test.undone, derived)
+		}
+	}
+
+	derived, _ := DeriveBalanceAt(4, 1235, 2, rewards, txs)
+	if derived.Rewards != 150 || derived.Received != 300 || derived.Sent != 200 || derived.Fees != 15 {
+		t.Fatalf("unexpected amounts undone %+v", derived)
+	}
+	if _, err := DeriveBalanceAt(4, 100, 2, rewards, txs); err == nil {
+		t.Fatal("expected an error when the balance can't have received the rewards")
+	}
+	if _, err := DeriveBalanceAt(4, 1235, 1, rewards, txs); err == nil {
+		t.Fatal("expected an error when the nonce doesn't count the transactions sent")
+	}
+}
@@ -0,0 +1,89 @@
+package common
+
+import "sort"
+
+// Kinds of the items of a balance reconciliation
+const (
+	BalanceItemReward   = "reward"
+	BalanceItemReceived = "received"
+	BalanceItemSent     = "sent"
+	BalanceItemFee      = "fee"
+)
+
+// BalanceItem is a reward or transaction amount which changed a balance
+type BalanceItem struct {
+	Layer  uint32
+	Kind   string
+	Amount uint64
+	// Credit is set for amounts added to the balance
+	Credit bool
+	// ID is the id of the transaction, empty for rewards
+	ID string
+}
+
+// BalanceReconciliation explains the change of an account's balance and nonce between two global
+// states by the rewards and transactions applied in between
+type BalanceReconciliation struct {
+	Before, After           uint64
+	NonceBefore, NonceAfter uint64
+	// Items are the amounts applied after the first state up to the second, oldest first
+	Items []BalanceItem
+	// Credits and Debits are the sums of the amounts added and subtracted by Items
+	Credits, Debits uint64
+	// Sent is the number of transactions sent, which each increment the nonce
+	Sent int
+}
+
+// ReconcileBalance explains the change of a balance and nonce from the global state of layer from
+// to the one of layer to by the rewards and transactions of the layers after from up to to.
+// Transactions without a layer haven't been applied and are left out.
+func ReconcileBalance(before, after, nonceBefore, nonceAfter uint64, from, to uint32, rewards []RewardRecord, txs []TxRecord) *BalanceReconciliation {
+	r := &BalanceReconciliation{Before: before, After: after, NonceBefore: nonceBefore, NonceAfter: nonceAfter}
+	add := func(item BalanceItem) {
+		if item.Amount == 0 {
+			return
+		}
+		if item.Credit {
+			r.Credits += item.Amount
+		} else {
+			r.Debits += item.Amount
+		}
+		r.Items = append(r.Items, item)
+	}
+	for _, reward := range rewards {
+		if reward.Layer > from && reward.Layer <= to {
+			add(BalanceItem{Layer: reward.Layer, Kind: BalanceItemReward, Amount: reward.Total, Credit: true})
+		}
+	}
+	for _, tx := range txs {
+		if tx.Layer == nil || *tx.Layer <= from || *tx.Layer > to {
+			continue
+		}
+		switch tx.Direction {
+		case DirectionIn:
+			add(BalanceItem{Layer: *tx.Layer, Kind: BalanceItemReceived, Amount: tx.Amount, Credit: true, ID: tx.ID})
+			continue
+		case DirectionOut:
+			add(BalanceItem{Layer: *tx.Layer, Kind: BalanceItemSent, Amount: tx.Amount, ID: tx.ID})
+		}
+		add(BalanceItem{Layer: *tx.Layer, Kind: BalanceItemFee, Amount: tx.Fee, ID: tx.ID})
+		r.Sent++
+	}
+	sort.SliceStable(r.Items, func(i, j int) bool { return r.Items[i].Layer < r.Items[j].Layer })
+	return r
+}
+
+// Unexplained returns the part of the balance change the items don't explain and whether it's a
+// decrease. It's 0 when the items add up to the change.
+func (r *BalanceReconciliation) Unexplained() (uint64, bool) {
+	actual, explained := r.After+r.Debits, r.Before+r.Credits
+	if actual < explained {
+		return explained - actual, true
+	}
+	return actual - explained, false
+}
+
+// UnexplainedNonce returns the change of the nonce minus the number of transactions sent
+func (r *BalanceReconciliation) UnexplainedNonce() int64 {
+	return int64(r.NonceAfter) - int64(r.NonceBefore) - int64(r.Sent)
+}
@@ -0,0 +1,59 @@
+package common
+
+import "testing"
+
+func TestReconcileBalance(t *testing.T) {
+	layer := func(n uint32) *uint32 { return &n }
+	rewards := []RewardRecord{{Layer: 10, Total: 1000}, {Layer: 12, Total: 50}, {Layer: 20, Total: 70}}
+	txs := []TxRecord{
+		{ID: "0x1", Layer: layer(11), Amount: 300, Fee: 2, Direction: DirectionIn},
+		{ID: "0x2", Layer: layer(13), Amount: 100, Fee: 5, Direction: DirectionOut},
+		{ID: "0x3", Layer: layer(14), Amount: 40, Fee: 1, Direction: DirectionSelf},
+		{ID: "0x4", Layer: layer(25), Amount: 900, Fee: 9, Direction: DirectionOut},
+		{ID: "0x5", Amount: 500, Fee: 3, Direction: DirectionIn},
+	}
+
+	// from the state of layer 10 to the one of layer 20: +300 +50 -100 -5 -1 +70 = +314
+	r := ReconcileBalance(1000, 1314, 4, 6, 10, 20, rewards, txs)
+	if r.Credits != 420 || r.Debits != 106 || r.Sent != 2 {
+		t.Fatalf("expected 420 credited, 106 debited and 2 sent, got %+v", r)
+	}
+	var kinds []string
+	for _, item := range r.Items {
+		kinds = append(kinds, item.Kind)
+	}
+	want := []string{BalanceItemReceived, BalanceItemReward, BalanceItemSent, BalanceItemFee, BalanceItemFee, BalanceItemReward}
+	if len(kinds) != len(want) {
+		t.Fatalf("expected items %v, got %v", want, kinds)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("expected items %v, got %v", want, kinds)
+		}
+	}
+	if amount, _ := r.Unexplained(); amount != 0 || r.UnexplainedNonce() != 0 {
+		t.Fatalf("expected the change to be explained, got %d and nonce %d", amount, r.UnexplainedNonce())
+	}
+
+	// a deposit the node didn't report
+	r = ReconcileBalance(1000, 1400, 4, 6, 10, 20, rewards, txs)
+	if amount, decrease := r.Unexplained(); amount != 86 || decrease {
+		t.Fatalf("expected 86 unexplained, got %d decrease %v", amount, decrease)
+	}
+	// a missing amount and a nonce incremented by an unreported transaction
+	r = ReconcileBalance(1000, 1200, 4, 7, 10, 20, rewards, txs)
+	if amount, decrease := r.Unexplained(); amount != 114 || !decrease {
+		t.Fatalf("expected 114 missing, got %d decrease %v", amount, decrease)
+	}
+	if r.UnexplainedNonce() != 1 {
+		t.Fatalf("expected 1 unexplained nonce increment, got %d", r.UnexplainedNonce())
+	}
+	// nothing happened in between
+	r = ReconcileBalance(1314, 1314, 6, 6, 20, 22, rewards, txs)
+	if len(r.Items) != 0 {
+		t.Fatalf("expected no items, got %+v", r.Items)
+	}
+	if amount, _ := r.Unexplained(); amount != 0 {
+		t.Fatalf("expected nothing unexplained, got %d", amount)
+	}
+}
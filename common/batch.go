@@ -0,0 +1,106 @@
+package common
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// BatchRow is a payment of a batch file
+type BatchRow struct {
+	Line      int    // line in the batch file
+	Address   string // address as written in the batch file
+	Recipient gosmtypes.Address
+	Amount    uint64 // in Smidge
+	Note      string
+}
+
+// batchHeader is the optional header row of a batch file
+var batchHeader = []string{"address", "amount", "note"}
+
+// ParseBatchCSV reads a batch file of address,amount,note rows. The note is optional and a first
+// row matching the header is skipped. Addresses are resolved with resolve and amounts are parsed
+// with ParseAmount. Every row is checked and all problems are reported in one error.
+func ParseBatchCSV(r io.Reader, resolve func(string) (gosmtypes.Address, error)) ([]BatchRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch file: %v", err)
+	}
+
+	var rows []BatchRow
+	var problems []string
+	for i, record := range records {
+		line := i + 1
+		if i == 0 && len(record) > 0 && strings.EqualFold(strings.TrimSpace(record[0]), batchHeader[0]) {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			problems = append(problems, fmt.Sprintf("line %d: expected address,amount[,note]", line))
+			continue
+		}
+		row := BatchRow{Line: line, Address: strings.TrimSpace(record[0])}
+		if len(record) == 3 {
+			row.Note = strings.TrimSpace(record[2])
+		}
+		if row.Recipient, err = resolve(row.Address); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", line, err))
+		}
+		if row.Amount, err = ParseAmount(record[1]); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", line, err))
+		} else if row.Amount == 0 {
+			problems = append(problems, fmt.Sprintf("line %d: the amount is zero", line))
+		}
+		rows = append(rows, row)
+	}
+	if len(problems) > 0 {
+		return nil, errors.New("invalid batch file:\n" + strings.Join(problems, "\n"))
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("the batch file has no payments")
+	}
+	return rows, nil
+}
+
+// BatchTotal returns the sum of the amounts of a batch and the sum of the maximum fees of its
+// transactions. It fails when a sum overflows.
+func BatchTotal(rows []BatchRow, gasPrice, gasLimit uint64) (uint64, uint64, error) {
+	fee := gasPrice * gasLimit
+	if gasLimit != 0 && fee/gasLimit != gasPrice {
+		return 0, 0, errors.New("the transaction fee overflows")
+	}
+	var amounts, fees uint64
+	for _, row := range rows {
+		if amounts+row.Amount < amounts || fees+fee < fees {
+			return 0, 0, errors.New("the batch total overflows")
+		}
+		amounts += row.Amount
+		fees += fee
+	}
+	if amounts+fees < amounts {
+		return 0, 0, errors.New("the batch total overflows")
+	}
+	return amounts, fees, nil
+}
+
+// WriteBatchCSV writes batch rows in the batch file format, with a header row and amounts in Smidge
+func WriteBatchCSV(w io.Writer, rows []BatchRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(batchHeader); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := cw.Write([]string{row.Address, strconv.FormatUint(row.Amount, 10), row.Note}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
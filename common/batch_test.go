@@ -0,0 +1,73 @@
+package common
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func TestParseBatchCSV(t *testing.T) {
+	file := "address,amount,note\n" +
+		"0x7fa75881ca0050028b32f424f860e3a73d4bf168,2.5smh,March salary\n" +
+		"0x0000000000000000000000000000000000000001, 100\n"
+	rows, err := ParseBatchCSV(strings.NewReader(file), ParseAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	if rows[0].Line != 2 || rows[0].Amount != 2500000000000 || rows[0].Note != "March salary" ||
+		rows[0].Recipient != gosmtypes.HexToAddress("0x7fa75881ca0050028b32f424f860e3a73d4bf168") {
+		t.Fatalf("unexpected first row: %+v", rows[0])
+	}
+	if rows[1].Line != 3 || rows[1].Amount != 100 || rows[1].Note != "" {
+		t.Fatalf("unexpected second row: %+v", rows[1])
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBatchCSV(&buf, rows[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "address,amount,note\n0x0000000000000000000000000000000000000001,100,\n" {
+		t.Fatalf("unexpected remainder file:\n%s", buf.String())
+	}
+	again, err := ParseBatchCSV(&buf, ParseAddress)
+	if err != nil || len(again) != 1 || again[0].Amount != 100 {
+		t.Fatalf("expected the remainder file to parse back: %v %+v", err, again)
+	}
+
+	bad := "0x7fa7,1\n0x7fa75881ca0050028b32f424f860e3a73d4bf168,1.5\n0x7fa75881ca0050028b32f424f860e3a73d4bf168,0\nonlyonefield\n"
+	_, err = ParseBatchCSV(strings.NewReader(bad), ParseAddress)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, line := range []string{"line 1", "line 2", "line 3", "line 4"} {
+		if !strings.Contains(err.Error(), line) {
+			t.Fatalf("expected a problem on %s, got: %v", line, err)
+		}
+	}
+
+	if _, err := ParseBatchCSV(strings.NewReader("address,amount,note\n"), ParseAddress); err == nil {
+		t.Fatal("expected an error for a batch without payments")
+	}
+}
+
+func TestBatchTotal(t *testing.T) {
+	rows := []BatchRow{{Amount: 100}, {Amount: 250}}
+	amounts, fees, err := BatchTotal(rows, 2, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amounts != 350 || fees != 400 {
+		t.Fatalf("expected 350 and 400, got %d and %d", amounts, fees)
+	}
+	if _, _, err := BatchTotal([]BatchRow{{Amount: 1 << 63}, {Amount: 1 << 63}}, 1, 1); err == nil {
+		t.Fatal("expected an overflow error")
+	}
+	if _, _, err := BatchTotal(rows, 1<<40, 1<<30); err == nil {
+		t.Fatal("expected a fee overflow error")
+	}
+}
@@ -0,0 +1,46 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcutil/bech32"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// AddressHRP is the human readable part of bech32 encoded Spacemesh addresses
+const AddressHRP = "sm"
+
+// EncodeBech32Address returns the bech32 encoding of an address, e.g. sm1...
+func EncodeBech32Address(address gosmtypes.Address) string {
+	data, err := bech32.ConvertBits(address.Bytes(), 8, 5, true)
+	if err != nil {
+		// converting whole bytes with padding can't fail
+		panic(err)
+	}
+	s, err := bech32.Encode(AddressHRP, data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// DecodeBech32Address parses a bech32 encoded address. Addresses with another human readable part
+// than AddressHRP are rejected.
+func DecodeBech32Address(s string) (gosmtypes.Address, error) {
+	hrp, data, err := bech32.Decode(strings.TrimSpace(s))
+	if err != nil {
+		return gosmtypes.Address{}, fmt.Errorf("invalid bech32 address %q: %v", s, err)
+	}
+	if hrp != AddressHRP {
+		return gosmtypes.Address{}, fmt.Errorf("invalid bech32 address %q: expected prefix %q, got %q", s, AddressHRP, hrp)
+	}
+	b, err := bech32.ConvertBits(data, 5, 8, false)
+	if err != nil {
+		return gosmtypes.Address{}, fmt.Errorf("invalid bech32 address %q: %v", s, err)
+	}
+	if len(b) != gosmtypes.AddressLength {
+		return gosmtypes.Address{}, fmt.Errorf("invalid bech32 address %q: expected %d bytes, got %d", s, gosmtypes.AddressLength, len(b))
+	}
+	return gosmtypes.BytesToAddress(b), nil
+}
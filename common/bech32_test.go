@@ -0,0 +1,80 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcutil/bech32"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func TestBech32AddressRoundTrip(t *testing.T) {
+	addresses := []gosmtypes.Address{
+		gosmtypes.HexToAddress("0x7fa75881ca0050028b32f424f860e3a73d4bf168"),
+		gosmtypes.HexToAddress("0xf6103aadbba77d2324fc4fad66eaa971bb5b8402"),
+		{},
+	}
+	for _, addr := range addresses {
+		s := EncodeBech32Address(addr)
+		if !strings.HasPrefix(s, AddressHRP+"1") {
+			t.Fatalf("expected %s to start with %s1", s, AddressHRP)
+		}
+		decoded, err := DecodeBech32Address(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded != addr {
+			t.Fatalf("expected %s, got %s", addr.Hex(), decoded.Hex())
+		}
+		parsed, err := ParseAddress(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed != addr {
+			t.Fatalf("expected ParseAddress to decode %s to %s, got %s", s, addr.Hex(), parsed.Hex())
+		}
+	}
+}
+
+func TestBech32AddressWrongHRP(t *testing.T) {
+	addr := gosmtypes.HexToAddress("0x7fa75881ca0050028b32f424f860e3a73d4bf168")
+	data, err := bech32.ConvertBits(addr.Bytes(), 8, 5, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := bech32.Encode("bc", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeBech32Address(s); err == nil {
+		t.Fatal("expected an error for a wrong human readable part")
+	}
+	if _, err := ParseAddress(s); err == nil {
+		t.Fatal("expected ParseAddress to reject a wrong human readable part")
+	}
+}
+
+func TestBech32AddressInvalid(t *testing.T) {
+	s := EncodeBech32Address(gosmtypes.HexToAddress("0x7fa75881ca0050028b32f424f860e3a73d4bf168"))
+	// change the last checksum character
+	last := s[len(s)-1]
+	replacement := byte('q')
+	if last == 'q' {
+		replacement = 'p'
+	}
+	if _, err := DecodeBech32Address(s[:len(s)-1] + string(replacement)); err == nil {
+		t.Fatal("expected a checksum error")
+	}
+
+	short, err := bech32.ConvertBits([]byte{1, 2, 3}, 8, 5, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = bech32.Encode(AddressHRP, short)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeBech32Address(s); err == nil {
+		t.Fatal("expected an error for a short address")
+	}
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"sort"
+	"time"
+)
+
+// BenchResult is the latency percentiles and the error count of the calls of one type made by a
+// node benchmark. Latencies are in milliseconds and cover the calls which succeeded.
+type BenchResult struct {
+	Call   string  `json:"call"`
+	Calls  int     `json:"calls"`
+	Errors int     `json:"errors"`
+	P50    float64 `json:"p50Ms"`
+	P90    float64 `json:"p90Ms"`
+	P99    float64 `json:"p99Ms"`
+	Max    float64 `json:"maxMs"`
+}
+
+// NewBenchResult returns the result of the calls of one type from the latencies of the calls which
+// succeeded and the number of calls which failed
+func NewBenchResult(call string, samples []time.Duration, errors int) BenchResult {
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return BenchResult{
+		Call:   call,
+		Calls:  len(samples) + errors,
+		Errors: errors,
+		P50:    milliseconds(LatencyPercentile(sorted, 50)),
+		P90:    milliseconds(LatencyPercentile(sorted, 90)),
+		P99:    milliseconds(LatencyPercentile(sorted, 99)),
+		Max:    milliseconds(LatencyPercentile(sorted, 100)),
+	}
+}
+
+// LatencyPercentile returns the nearest rank p-th percentile of sorted latencies, or 0 without
+// samples
+func LatencyPercentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
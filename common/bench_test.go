@@ -0,0 +1,39 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 10; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	tests := []struct {
+		p    int
+		want time.Duration
+	}{
+		{0, time.Millisecond},
+		{50, 5 * time.Millisecond},
+		{90, 9 * time.Millisecond},
+		{99, 10 * time.Millisecond},
+		{100, 10 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := LatencyPercentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile %d: expected %v, got %v", tt.p, tt.want, got)
+		}
+	}
+	if got := LatencyPercentile(nil, 50); got != 0 {
+		t.Fatalf("expected 0 without samples, got %v", got)
+	}
+}
+
+func TestNewBenchResult(t *testing.T) {
+	result := NewBenchResult("Echo", []time.Duration{3 * time.Millisecond, time.Millisecond, 2 * time.Millisecond}, 2)
+	expected := BenchResult{Call: "Echo", Calls: 5, Errors: 2, P50: 2, P90: 3, P99: 3, Max: 3}
+	if result != expected {
+		t.Fatalf("expected %+v, got %+v", expected, result)
+	}
+}
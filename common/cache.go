@@ -0,0 +1,17 @@
+package common
+
+import "context"
+
+// noCacheKey marks a context whose calls must reach the node
+type noCacheKey struct{}
+
+// WithoutCache returns a context whose calls don't reuse cached account states, mesh info
+// or node status, e.g. for a benchmark of the node
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// CacheBypassed tells whether the calls made with a context must reach the node
+func CacheBypassed(ctx context.Context) bool {
+	return ctx.Value(noCacheKey{}) != nil
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	"sort"
+	"time"
+)
+
+// CallStats counts the calls of a gRPC method made in the session, the calls which failed and
+// their latency. Streams count once when they are opened, with the time it took to open them.
+type CallStats struct {
+	Method string
+	Calls  uint64
+	Errors uint64
+	Total  time.Duration
+	Max    time.Duration
+}
+
+// Avg returns the average latency of the calls, 0 without calls
+func (s CallStats) Avg() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Calls)
+}
+
+// SortCallStats sorts call statistics by descending total latency, so the methods which cost the
+// most come first. Ties are sorted by method.
+func SortCallStats(stats []CallStats) {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Total != stats[j].Total {
+			return stats[i].Total > stats[j].Total
+		}
+		return stats[i].Method < stats[j].Method
+	})
+}
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CommitmentLimits are the PoST commitment sizes a node accepts. A zero field is unknown and isn't
+// checked.
+type CommitmentLimits struct {
+	// UnitSize is the size in bytes commitments are a multiple of
+	UnitSize uint64
+	// MinUnits and MaxUnits bound the number of units of a commitment
+	MinUnits, MaxUnits uint64
+}
+
+// unitsSuffixes are the suffixes of commitment sizes given as a number of units
+var unitsSuffixes = []string{"units", "unit", "u"}
+
+// ParseCommitmentSize parses a PoST commitment size to bytes. The size is a number of units, e.g.
+// 4 units, when the unit size is known, or a size ParseSize accepts.
+func ParseCommitmentSize(s string, limits CommitmentLimits) (uint64, error) {
+	str := strings.ToLower(strings.TrimSpace(s))
+	for _, suffix := range unitsSuffixes {
+		if !strings.HasSuffix(str, suffix) {
+			continue
+		}
+		if limits.UnitSize == 0 {
+			return 0, fmt.Errorf("the node doesn't report its space unit size, enter the size in GiB or TiB")
+		}
+		units, err := strconv.ParseUint(strings.TrimSpace(strings.TrimSuffix(str, suffix)), 10, 64)
+		if err != nil || units == 0 || units > ^uint64(0)/limits.UnitSize {
+			return 0, fmt.Errorf("invalid number of units %q, expected e.g. 4 units", s)
+		}
+		return units * limits.UnitSize, nil
+	}
+	return ParseSize(s)
+}
+
+// Snap rounds size to the nearest whole number of units, at least one, and checks it against the
+// unit limits. It returns the size to commit and whether it differs from size.
+func (l CommitmentLimits) Snap(size uint64) (uint64, bool, error) {
+	snapped := size
+	units := uint64(0)
+	if l.UnitSize > 0 {
+		units = size / l.UnitSize
+		if size%l.UnitSize >= l.UnitSize-l.UnitSize/2 {
+			units++
+		}
+		if units == 0 {
+			units = 1
+		}
+		snapped = units * l.UnitSize
+		if snapped/l.UnitSize != units {
+			return 0, false, fmt.Errorf("the size is too large")
+		}
+	}
+	if l.MinUnits > 0 && l.UnitSize > 0 && units < l.MinUnits {
+		return 0, false, fmt.Errorf("the node commits at least %d units of %.2f GiB, %.2f GiB", l.MinUnits, GiB(l.UnitSize), GiB(l.MinUnits*l.UnitSize))
+	}
+	if l.MaxUnits > 0 && l.UnitSize > 0 && units > l.MaxUnits {
+		return 0, false, fmt.Errorf("the node commits at most %d units of %.2f GiB, %.2f GiB", l.MaxUnits, GiB(l.UnitSize), GiB(l.MaxUnits*l.UnitSize))
+	}
+	return snapped, snapped != size, nil
+}
@@ -0,0 +1,55 @@
+package common
+
+import "testing"
+
+func TestParseCommitmentSize(t *testing.T) {
+	limits := CommitmentLimits{UnitSize: 16 << 30}
+	for _, test := range []struct {
+		in       string
+		expected uint64
+	}{
+		{"256GiB", 256 << 30},
+		{"1TiB", 1 << 40},
+		{"4 units", 64 << 30},
+		{"1unit", 16 << 30},
+		{"2u", 32 << 30},
+	} {
+		if size, err := ParseCommitmentSize(test.in, limits); err != nil || size != test.expected {
+			t.Fatalf("%s: expected %d, got %d %v", test.in, test.expected, size, err)
+		}
+	}
+	for _, in := range []string{"0 units", "-1 units", "1.5 units", "units"} {
+		if _, err := ParseCommitmentSize(in, limits); err == nil {
+			t.Fatalf("%q: expected an error", in)
+		}
+	}
+	if _, err := ParseCommitmentSize("4 units", CommitmentLimits{}); err == nil {
+		t.Fatal("expected an error for units of an unknown size")
+	}
+}
+
+func TestCommitmentLimitsSnap(t *testing.T) {
+	limits := CommitmentLimits{UnitSize: 16 << 30, MinUnits: 2, MaxUnits: 64}
+	for _, test := range []struct {
+		in, expected uint64
+		changed      bool
+	}{
+		{64 << 30, 64 << 30, false},
+		{70 << 30, 64 << 30, true},
+		{72 << 30, 80 << 30, true},
+		{1 << 40, 1 << 40, false},
+	} {
+		size, changed, err := limits.Snap(test.in)
+		if err != nil || size != test.expected || changed != test.changed {
+			t.Fatalf("%d: expected %d %v, got %d %v %v", test.in, test.expected, test.changed, size, changed, err)
+		}
+	}
+	for _, in := range []uint64{1 << 30, 16 << 30, 2 << 40} {
+		if _, _, err := limits.Snap(in); err == nil {
+			t.Fatalf("%d: expected an error", in)
+		}
+	}
+	if size, changed, err := (CommitmentLimits{}).Snap(123); err != nil || size != 123 || changed {
+		t.Fatalf("unknown limits: expected the size unchanged, got %d %v %v", size, changed, err)
+	}
+}
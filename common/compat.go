@@ -0,0 +1,53 @@
+package common
+
+import "fmt"
+
+// GroupCompat tells which node versions a group of commands works with: from MinNode and below
+// MaxNode, each when set
+type GroupCompat struct {
+	Commands string
+	MinNode  string
+	MaxNode  string
+}
+
+// groupCompatibility lists the command groups whose node API calls are missing from older nodes
+// or changed in newer ones
+var groupCompatibility = []GroupCompat{
+	// the v1 node API which every command calls was introduced in v0.1.16
+	{Commands: "most commands", MinNode: "v0.1.16"},
+	// the debug service answers account queries from v0.1.17
+	{Commands: "dbg commands", MinNode: "v0.1.17"},
+	// the smesher service was redesigned around PoST setup in v0.2.0
+	{Commands: "smesher commands", MaxNode: "v0.2.0"},
+}
+
+// CompatWarnings returns a line for each group of commands which may not work against a node
+// version. Versions which aren't semantic versions, such as development builds, have none.
+func CompatWarnings(nodeVersion string) []string {
+	var warnings []string
+	for _, g := range groupCompatibility {
+		if c, ok := CompareVersions(nodeVersion, g.MinNode); ok && c < 0 {
+			warnings = append(warnings, fmt.Sprintf("%s may not work against node < %s", g.Commands, g.MinNode))
+		}
+		if c, ok := CompareVersions(nodeVersion, g.MaxNode); ok && c >= 0 {
+			warnings = append(warnings, fmt.Sprintf("%s may not work against node >= %s", g.Commands, g.MaxNode))
+		}
+	}
+	return warnings
+}
+
+// NodeVersionDrift tells whether a node version is older than the version this program was built
+// for (-1), a newer minor or major version (1) or one with the same API (0), since patch releases
+// don't change the API. It returns false when either isn't a semantic version.
+func NodeVersionDrift(nodeVersion, builtFor string) (int, bool) {
+	c, ok := CompareVersions(nodeVersion, builtFor)
+	if !ok || c <= 0 {
+		return c, ok
+	}
+	node, _ := parseVersion(nodeVersion)
+	built, _ := parseVersion(builtFor)
+	if node[0] == built[0] && node[1] == built[1] {
+		return 0, true
+	}
+	return 1, true
+}
@@ -0,0 +1,43 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompatWarnings(t *testing.T) {
+	for _, test := range []struct {
+		node     string
+		expected []string
+	}{
+		{"v0.1.17", nil},
+		{"v0.1.16", []string{"dbg commands may not work against node < v0.1.17"}},
+		{"0.1.15", []string{"most commands may not work against node < v0.1.16", "dbg commands may not work against node < v0.1.17"}},
+		{"v0.2.1", []string{"smesher commands may not work against node >= v0.2.0"}},
+		{"dev", nil},
+	} {
+		if warnings := CompatWarnings(test.node); !reflect.DeepEqual(warnings, test.expected) {
+			t.Fatalf("%s: expected %q, got %q", test.node, test.expected, warnings)
+		}
+	}
+}
+
+func TestNodeVersionDrift(t *testing.T) {
+	for _, test := range []struct {
+		node     string
+		expected int
+		ok       bool
+	}{
+		{"v0.1.17", 0, true},
+		{"v0.1.20", 0, true},
+		{"v0.1.16", -1, true},
+		{"v0.2.0", 1, true},
+		{"v1.0.0", 1, true},
+		{"dev", 0, false},
+	} {
+		drift, ok := NodeVersionDrift(test.node, "v0.1.17")
+		if drift != test.expected || ok != test.ok {
+			t.Fatalf("%s: expected %d %v, got %d %v", test.node, test.expected, test.ok, drift, ok)
+		}
+	}
+}
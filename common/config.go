@@ -0,0 +1,492 @@
+package common
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigFileName is the name of the settings file in the wallets directory
+const ConfigFileName = "smrepl.conf"
+
+const (
+	// AddrFormatHex displays addresses as 0x prefixed hex strings
+	AddrFormatHex = "hex"
+	// AddrFormatBech32 displays addresses as bech32 strings with the sm prefix
+	AddrFormatBech32 = "bech32"
+
+	// SettingOn and SettingOff are the values of on/off settings
+	SettingOn  = "on"
+	SettingOff = "off"
+
+	// AuthTokenHidden is displayed instead of the auth token when one is set
+	AuthTokenHidden = "(set, hidden)"
+)
+
+// DefaultCallTimeout is the deadline of calls to the node when the timeout setting isn't set
+const DefaultCallTimeout = 30 * time.Second
+
+// DefaultMaxReceiveMB is the size in MB of the largest node response accepted when the
+// max-receive-mb setting isn't set. It is above the 4MB gRPC default, which large account and
+// reward lists exceed on a busy network.
+const DefaultMaxReceiveMB = 64
+
+// DefaultStreamRetries is the number of consecutive attempts to reopen an interrupted stream when
+// the stream-retries setting isn't set
+const DefaultStreamRetries = 8
+
+// DefaultCacheTTL is how long account states, the mesh info and the node status are reused when
+// the cache-ttl setting isn't set
+const DefaultCacheTTL = time.Second
+
+// maxCacheTTL is the longest cache-ttl setting. Longer would show stale balances.
+const maxCacheTTL = 10 * time.Second
+
+// MessageLimits are the sizes in bytes of the largest messages received from and sent to the
+// node, 0 for the gRPC default
+type MessageLimits struct {
+	Receive, Send int
+}
+
+// Config holds the user settings changed with the config command
+type Config struct {
+	path string
+
+	// AddrFormat is the format addresses are displayed in: hex or bech32
+	AddrFormat string `json:"addrformat"`
+	// IdFormat is the format smesher ids are displayed in: hex or base64. Empty means hex.
+	IdFormat string `json:"idformat,omitempty"`
+	// Verbose displays additional details such as both address formats
+	Verbose bool `json:"verbose"`
+	// GasPrice and GasLimit are the transaction defaults of accounts without their own. 0 means not set.
+	GasPrice uint64 `json:"gasprice,omitempty"`
+	GasLimit uint64 `json:"gaslimit,omitempty"`
+	// SpendLimit is the amount in Smidge above which a transaction or batch needs the amount typed
+	// back. 0 disables the limit.
+	SpendLimit uint64 `json:"spend-limit,omitempty"`
+	// NotifyHook is a program run with the amount, sender and transaction id of every incoming
+	// transaction seen while watching an account, and with the balance, address and alert of the
+	// alerts of state watch. Empty if not set.
+	NotifyHook string `json:"notify-hook,omitempty"`
+	// RememberAccount restores the current account of a wallet when it is opened again: on or off
+	RememberAccount string `json:"remember-account"`
+	// Servers are the node API endpoints in order of preference, used when no -server flag is
+	// given. The client fails over to the next one when the active node becomes unavailable.
+	Servers []string `json:"servers,omitempty"`
+	// Timeout is the deadline of calls to the node such as 30s, or off for none. Empty means
+	// DefaultCallTimeout.
+	Timeout string `json:"timeout,omitempty"`
+	// NodeProbe calls the node in the background to show whether it is online in the prompt: on
+	// or off
+	NodeProbe string `json:"node-probe"`
+	// MaxReceiveMB and MaxSendMB are the sizes in MB of the largest messages received from and sent
+	// to the node. 0 means DefaultMaxReceiveMB and the gRPC default respectively.
+	MaxReceiveMB uint64 `json:"max-receive-mb,omitempty"`
+	MaxSendMB    uint64 `json:"max-send-mb,omitempty"`
+	// StreamRetries is the number of consecutive attempts to reopen an interrupted stream before
+	// giving up. 0 means DefaultStreamRetries.
+	StreamRetries uint64 `json:"stream-retries,omitempty"`
+	// CacheTTL is how long account states, the mesh info and the node status fetched from the
+	// node are reused, such as 2s, or off to always call the node. Empty means DefaultCacheTTL.
+	CacheTTL string `json:"cache-ttl,omitempty"`
+	// TxFormat is the format transactions are signed in: auto, legacy or genvm. Empty means auto,
+	// the format of the connected node.
+	TxFormat string `json:"tx-format,omitempty"`
+	// GenesisID is the hex genesis id of the network, signed with genvm transactions. Empty if
+	// not set.
+	GenesisID string `json:"genesis-id,omitempty"`
+	// AuthToken is sent as a bearer token with every call to the node. It is never displayed.
+	AuthToken string `json:"auth-token,omitempty"`
+	// CurrentAccounts maps wallet file paths to the alias of their last selected account
+	CurrentAccounts map[string]string `json:"currentAccounts,omitempty"`
+}
+
+// DefaultConfig returns the settings used when no settings file exists
+func DefaultConfig() *Config {
+	return &Config{AddrFormat: AddrFormatHex, RememberAccount: SettingOn, NodeProbe: SettingOn}
+}
+
+// LoadConfig reads a settings file. A missing file results in the default settings.
+func LoadConfig(path string) (*Config, error) {
+	config := DefaultConfig()
+	config.path = path
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse settings file %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// Save writes the settings to their file
+func (c *Config) Save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WritePrivateFile(c.path, data)
+}
+
+// ConfigKeys lists the settings which can be changed with Set
+var ConfigKeys = []string{"addrformat", "idformat", "verbose", "gasprice", "gaslimit", "spend-limit", "notify-hook", "remember-account", "servers", "auth-token", "timeout", "node-probe", "max-receive-mb", "max-send-mb", "stream-retries", "cache-ttl", "tx-format", "genesis-id"}
+
+// Get returns the value of a setting as a string
+func (c *Config) Get(key string) (string, error) {
+	switch key {
+	case "addrformat":
+		return c.AddrFormat, nil
+	case "idformat":
+		if c.IdFormat == "" {
+			return IdFormatHex, nil
+		}
+		return c.IdFormat, nil
+	case "verbose":
+		return strconv.FormatBool(c.Verbose), nil
+	case "gasprice":
+		return strconv.FormatUint(c.GasPrice, 10), nil
+	case "gaslimit":
+		return strconv.FormatUint(c.GasLimit, 10), nil
+	case "spend-limit":
+		return strconv.FormatUint(c.SpendLimit, 10), nil
+	case "notify-hook":
+		if c.NotifyHook == "" {
+			return SettingOff, nil
+		}
+		return c.NotifyHook, nil
+	case "remember-account":
+		return c.RememberAccount, nil
+	case "servers":
+		if len(c.Servers) == 0 {
+			return SettingOff, nil
+		}
+		return strings.Join(c.Servers, ","), nil
+	case "auth-token":
+		if c.AuthToken == "" {
+			return SettingOff, nil
+		}
+		return AuthTokenHidden, nil
+	case "timeout":
+		if c.Timeout == SettingOff {
+			return SettingOff, nil
+		}
+		return c.CallTimeout().String(), nil
+	case "node-probe":
+		return c.NodeProbe, nil
+	case "max-receive-mb":
+		return strconv.Itoa(c.MessageLimits().Receive >> 20), nil
+	case "max-send-mb":
+		if c.MaxSendMB == 0 {
+			return SettingOff, nil
+		}
+		return strconv.FormatUint(c.MaxSendMB, 10), nil
+	case "stream-retries":
+		return strconv.Itoa(c.StreamAttempts()), nil
+	case "cache-ttl":
+		if c.CacheTTL == SettingOff {
+			return SettingOff, nil
+		}
+		return c.StateCacheTTL().String(), nil
+	case "tx-format":
+		if c.TxFormat == "" {
+			return TxFormatAuto, nil
+		}
+		return c.TxFormat, nil
+	case "genesis-id":
+		if c.GenesisID == "" {
+			return SettingOff, nil
+		}
+		return c.GenesisID, nil
+	}
+	return "", fmt.Errorf("unknown setting %s", key)
+}
+
+// Set changes the value of a setting after validating it
+func (c *Config) Set(key, value string) error {
+	switch key {
+	case "addrformat":
+		if value != AddrFormatHex && value != AddrFormatBech32 {
+			return fmt.Errorf("addrformat must be %s or %s", AddrFormatHex, AddrFormatBech32)
+		}
+		c.AddrFormat = value
+		return nil
+	case "idformat":
+		if value != IdFormatHex && value != IdFormatBase64 {
+			return fmt.Errorf("idformat must be %s or %s", IdFormatHex, IdFormatBase64)
+		}
+		c.IdFormat = value
+		return nil
+	case "verbose":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("verbose must be true or false")
+		}
+		c.Verbose = b
+		return nil
+	case "gasprice", "gaslimit":
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s must be a positive integer or 0 to remove it", key)
+		}
+		if key == "gasprice" {
+			c.GasPrice = n
+		} else {
+			c.GasLimit = n
+		}
+		return nil
+	case "spend-limit":
+		n, err := ParseAmount(value)
+		if err != nil {
+			return fmt.Errorf("spend-limit must be an amount such as 100smh, or 0 to disable it")
+		}
+		c.SpendLimit = n
+		return nil
+	case "notify-hook":
+		if value == SettingOff {
+			value = ""
+		}
+		c.NotifyHook = value
+		return nil
+	case "remember-account":
+		if value != SettingOn && value != SettingOff {
+			return fmt.Errorf("remember-account must be %s or %s", SettingOn, SettingOff)
+		}
+		c.RememberAccount = value
+		if value == SettingOff {
+			c.CurrentAccounts = nil
+		}
+		return nil
+	case "servers":
+		if value == SettingOff {
+			c.Servers = nil
+			return nil
+		}
+		servers, err := ParseServers(value)
+		if err != nil {
+			return err
+		}
+		c.Servers = servers
+		return nil
+	case "auth-token":
+		if value == SettingOff {
+			value = ""
+		}
+		c.AuthToken = value
+		return nil
+	case "timeout":
+		if value == SettingOff {
+			c.Timeout = value
+			return nil
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("timeout must be a duration such as 30s or %s", SettingOff)
+		}
+		c.Timeout = d.String()
+		return nil
+	case "node-probe":
+		if value != SettingOn && value != SettingOff {
+			return fmt.Errorf("node-probe must be %s or %s", SettingOn, SettingOff)
+		}
+		c.NodeProbe = value
+		return nil
+	case "max-receive-mb", "max-send-mb":
+		n := uint64(0)
+		if value != SettingOff {
+			var err error
+			if n, err = strconv.ParseUint(value, 10, 32); err != nil || n == 0 || n > maxMessageMB {
+				return fmt.Errorf("%s must be a size in MB from 1 to %d, or %s for the default", key, maxMessageMB, SettingOff)
+			}
+		}
+		if key == "max-receive-mb" {
+			c.MaxReceiveMB = n
+		} else {
+			c.MaxSendMB = n
+		}
+		return nil
+	case "stream-retries":
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || n == 0 || n > maxStreamRetries {
+			return fmt.Errorf("stream-retries must be a number of attempts from 1 to %d", maxStreamRetries)
+		}
+		c.StreamRetries = n
+		return nil
+	case "cache-ttl":
+		if value == SettingOff {
+			c.CacheTTL = value
+			return nil
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 || d > maxCacheTTL {
+			return fmt.Errorf("cache-ttl must be a duration up to %v such as 2s, or %s", maxCacheTTL, SettingOff)
+		}
+		c.CacheTTL = d.String()
+		return nil
+	case "tx-format":
+		if value != TxFormatAuto && value != TxFormatLegacy && value != TxFormatGenvm {
+			return fmt.Errorf("tx-format must be %s, %s or %s", TxFormatAuto, TxFormatLegacy, TxFormatGenvm)
+		}
+		if value == TxFormatAuto {
+			value = ""
+		}
+		c.TxFormat = value
+		return nil
+	case "genesis-id":
+		if value == SettingOff {
+			c.GenesisID = ""
+			return nil
+		}
+		id, err := ParseGenesisID(value)
+		if err != nil {
+			return fmt.Errorf("%v, or %s", err, SettingOff)
+		}
+		c.GenesisID = hex.EncodeToString(id)
+		return nil
+	}
+	return fmt.Errorf("unknown setting %s", key)
+}
+
+// maxMessageMB is the largest message size setting in MB, below the 2GB gRPC limit
+const maxMessageMB = 2047
+
+// MessageLimits returns the sizes in bytes of the largest messages received from and sent to the
+// node
+func (c *Config) MessageLimits() MessageLimits {
+	limits := MessageLimits{Receive: DefaultMaxReceiveMB << 20, Send: int(c.MaxSendMB) << 20}
+	if c.MaxReceiveMB != 0 {
+		limits.Receive = int(c.MaxReceiveMB) << 20
+	}
+	return limits
+}
+
+// maxStreamRetries is the largest stream-retries setting
+const maxStreamRetries = 1000
+
+// StreamAttempts returns the number of consecutive attempts to reopen an interrupted stream
+func (c *Config) StreamAttempts() int {
+	if c.StreamRetries == 0 {
+		return DefaultStreamRetries
+	}
+	return int(c.StreamRetries)
+}
+
+// CallTimeout returns the deadline of calls to the node, 0 for none
+func (c *Config) CallTimeout() time.Duration {
+	if c.Timeout == SettingOff {
+		return 0
+	}
+	if d, err := time.ParseDuration(c.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return DefaultCallTimeout
+}
+
+// StateCacheTTL returns how long account states, the mesh info and the node status are reused, 0
+// for not at all
+func (c *Config) StateCacheTTL() time.Duration {
+	if c.CacheTTL == SettingOff {
+		return 0
+	}
+	if d, err := time.ParseDuration(c.CacheTTL); err == nil && d > 0 {
+		return d
+	}
+	return DefaultCacheTTL
+}
+
+// TxCodec returns the codec of the tx-format setting. The node version picks the format when the
+// setting is auto, and may be empty when it isn't known.
+func (c *Config) TxCodec(nodeVersion string) (TxCodec, error) {
+	var genesisID []byte
+	if c.GenesisID != "" {
+		id, err := ParseGenesisID(c.GenesisID)
+		if err != nil {
+			return nil, err
+		}
+		genesisID = id
+	}
+	return NewTxCodec(c.TxFormat, nodeVersion, genesisID)
+}
+
+// ExceedsSpendLimit tells whether an amount is above the spend limit. Amounts equal to the limit
+// don't exceed it.
+func (c *Config) ExceedsSpendLimit(amount uint64) bool {
+	return c.SpendLimit != 0 && amount > c.SpendLimit
+}
+
+// RememberedAccount returns the alias of the last selected account of a wallet file or an empty
+// string when none is remembered
+func (c *Config) RememberedAccount(walletPath string) string {
+	if c.RememberAccount == SettingOff {
+		return ""
+	}
+	return c.CurrentAccounts[walletPath]
+}
+
+// SetRememberedAccount records the alias of the selected account of a wallet file. It returns false
+// when remember-account is off or the alias is already recorded, so there is nothing to save.
+func (c *Config) SetRememberedAccount(walletPath, alias string) bool {
+	if c.RememberAccount == SettingOff || c.CurrentAccounts[walletPath] == alias {
+		return false
+	}
+	if c.CurrentAccounts == nil {
+		c.CurrentAccounts = make(map[string]string)
+	}
+	c.CurrentAccounts[walletPath] = alias
+	return true
+}
+
+// UnixSocketScheme prefixes node API endpoints which are Unix domain sockets, e.g.
+// unix:///var/run/spacemesh/grpc.sock
+const UnixSocketScheme = "unix://"
+
+// SocketPath returns the path of a unix:// node API endpoint, and whether the endpoint is a socket
+func SocketPath(server string) (string, bool) {
+	if !strings.HasPrefix(server, UnixSocketScheme) {
+		return "", false
+	}
+	return strings.TrimPrefix(server, UnixSocketScheme), true
+}
+
+// ValidateServer checks that a node API endpoint is a host:port or the path of a socket given as
+// unix:///path
+func ValidateServer(server string) error {
+	if path, ok := SocketPath(server); ok {
+		if path == "" {
+			return fmt.Errorf("invalid server %s, expected unix:// followed by the path of the socket", server)
+		}
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return fmt.Errorf("invalid server %s, expected host:port or %s/path/to/socket", server, UnixSocketScheme)
+	}
+	return nil
+}
+
+// ParseServers parses a comma separated list of host:port or unix:// socket node API endpoints
+func ParseServers(value string) ([]string, error) {
+	var servers []string
+	for _, server := range strings.Split(value, ",") {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+		if err := ValidateServer(server); err != nil {
+			return nil, err
+		}
+		servers = append(servers, server)
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("servers must be a comma separated list of host:port or unix:// socket endpoints, or %s", SettingOff)
+	}
+	return servers, nil
+}
@@ -0,0 +1,345 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfigSetAndPersist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, ConfigFileName)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.AddrFormat != AddrFormatHex {
+		t.Fatalf("expected default address format %s, got %s", AddrFormatHex, config.AddrFormat)
+	}
+	if err := config.Set("addrformat", "base58"); err == nil {
+		t.Fatal("expected an error for an unknown address format")
+	}
+	if err := config.Set("nosuchkey", "1"); err == nil {
+		t.Fatal("expected an error for an unknown setting")
+	}
+	if err := config.Set("addrformat", AddrFormatBech32); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Set("idformat", "bech32"); err == nil {
+		t.Fatal("expected an error for an unknown id format")
+	}
+	if err := config.Set("idformat", IdFormatBase64); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Set("verbose", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range ConfigKeys {
+		expected, _ := config.Get(key)
+		value, err := loaded.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != expected {
+			t.Fatalf("%s: expected %s, got %s", key, expected, value)
+		}
+	}
+}
+
+func TestRememberedAccount(t *testing.T) {
+	config := DefaultConfig()
+	if !config.SetRememberedAccount("wallet.json", "savings") {
+		t.Fatal("expected the account to be recorded")
+	}
+	if config.SetRememberedAccount("wallet.json", "savings") {
+		t.Fatal("expected nothing to save for the same account")
+	}
+	if alias := config.RememberedAccount("wallet.json"); alias != "savings" {
+		t.Fatalf("expected savings, got %q", alias)
+	}
+	if alias := config.RememberedAccount("other.json"); alias != "" {
+		t.Fatalf("expected no account for another wallet, got %q", alias)
+	}
+
+	if err := config.Set("remember-account", "maybe"); err == nil {
+		t.Fatal("expected an error for an invalid value")
+	}
+	if err := config.Set("remember-account", SettingOff); err != nil {
+		t.Fatal(err)
+	}
+	if alias := config.RememberedAccount("wallet.json"); alias != "" {
+		t.Fatalf("expected no account when remember-account is off, got %q", alias)
+	}
+	if config.SetRememberedAccount("wallet.json", "savings") {
+		t.Fatal("expected accounts not to be recorded when remember-account is off")
+	}
+}
+
+func TestSpendLimit(t *testing.T) {
+	config := DefaultConfig()
+	if config.ExceedsSpendLimit(1 << 63) {
+		t.Fatal("expected no limit by default")
+	}
+	if err := config.Set("spend-limit", "lots"); err == nil {
+		t.Fatal("expected an error for an invalid amount")
+	}
+	if err := config.Set("spend-limit", "100smh"); err != nil {
+		t.Fatal(err)
+	}
+	limit := 100 * uint64(SmidgePerSmesh)
+	if value, _ := config.Get("spend-limit"); value != "100000000000000" {
+		t.Fatalf("expected the limit in Smidge, got %s", value)
+	}
+	if config.ExceedsSpendLimit(limit) {
+		t.Fatal("expected an amount exactly at the limit to be allowed")
+	}
+	if config.ExceedsSpendLimit(limit - 1) {
+		t.Fatal("expected an amount below the limit to be allowed")
+	}
+	if !config.ExceedsSpendLimit(limit + 1) {
+		t.Fatal("expected an amount above the limit to exceed it")
+	}
+	if err := config.Set("spend-limit", "0"); err != nil {
+		t.Fatal(err)
+	}
+	if config.ExceedsSpendLimit(limit + 1) {
+		t.Fatal("expected a limit of 0 to disable the check")
+	}
+}
+
+func TestNotifyHook(t *testing.T) {
+	config := DefaultConfig()
+	if value, _ := config.Get("notify-hook"); value != SettingOff {
+		t.Fatalf("expected no hook by default, got %s", value)
+	}
+	if err := config.Set("notify-hook", "/usr/local/bin/ring"); err != nil {
+		t.Fatal(err)
+	}
+	if config.NotifyHook != "/usr/local/bin/ring" {
+		t.Fatalf("unexpected hook %q", config.NotifyHook)
+	}
+	if err := config.Set("notify-hook", SettingOff); err != nil {
+		t.Fatal(err)
+	}
+	if config.NotifyHook != "" {
+		t.Fatal("expected off to remove the hook")
+	}
+}
+
+func TestServers(t *testing.T) {
+	config := DefaultConfig()
+	if value, _ := config.Get("servers"); value != SettingOff {
+		t.Fatalf("expected no servers by default, got %s", value)
+	}
+	if err := config.Set("servers", "grpc-1.example:9092, grpc-2.example:9092"); err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Servers) != 2 || config.Servers[0] != "grpc-1.example:9092" || config.Servers[1] != "grpc-2.example:9092" {
+		t.Fatalf("unexpected servers %v", config.Servers)
+	}
+	if value, _ := config.Get("servers"); value != "grpc-1.example:9092,grpc-2.example:9092" {
+		t.Fatalf("unexpected servers setting %s", value)
+	}
+	if err := config.Set("servers", "grpc-1.example"); err == nil {
+		t.Fatal("expected an error for a server without a port")
+	}
+	if err := config.Set("servers", " , "); err == nil {
+		t.Fatal("expected an error for an empty list")
+	}
+	if err := config.Set("servers", "unix:///var/run/spacemesh/grpc.sock,grpc-1.example:9092"); err != nil {
+		t.Fatal(err)
+	}
+	if path, ok := SocketPath(config.Servers[0]); !ok || path != "/var/run/spacemesh/grpc.sock" {
+		t.Fatalf("expected the socket path, got %q", path)
+	}
+	if _, ok := SocketPath(config.Servers[1]); ok {
+		t.Fatal("expected a host:port not to be a socket")
+	}
+	if err := config.Set("servers", "unix://"); err == nil {
+		t.Fatal("expected an error for a socket without a path")
+	}
+	if err := config.Set("servers", SettingOff); err != nil {
+		t.Fatal(err)
+	}
+	if config.Servers != nil {
+		t.Fatal("expected off to remove the servers")
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	config := DefaultConfig()
+	if config.CallTimeout() != DefaultCallTimeout {
+		t.Fatalf("expected the default timeout, got %v", config.CallTimeout())
+	}
+	if err := config.Set("timeout", "5s"); err != nil {
+		t.Fatal(err)
+	}
+	if config.CallTimeout() != 5*time.Second {
+		t.Fatalf("expected 5s, got %v", config.CallTimeout())
+	}
+	if err := config.Set("timeout", "-1s"); err == nil {
+		t.Fatal("expected an error for a negative timeout")
+	}
+	if err := config.Set("timeout", "soon"); err == nil {
+		t.Fatal("expected an error for an invalid duration")
+	}
+	if err := config.Set("timeout", SettingOff); err != nil {
+		t.Fatal(err)
+	}
+	if config.CallTimeout() != 0 {
+		t.Fatalf("expected no timeout, got %v", config.CallTimeout())
+	}
+	if value, _ := config.Get("timeout"); value != SettingOff {
+		t.Fatalf("unexpected timeout setting %s", value)
+	}
+}
+
+func TestNodeProbe(t *testing.T) {
+	config := DefaultConfig()
+	if value, _ := config.Get("node-probe"); value != SettingOn {
+		t.Fatalf("expected the probe to be on by default, got %s", value)
+	}
+	if err := config.Set("node-probe", "sometimes"); err == nil {
+		t.Fatal("expected an error for an invalid value")
+	}
+	if err := config.Set("node-probe", SettingOff); err != nil {
+		t.Fatal(err)
+	}
+	if config.NodeProbe != SettingOff {
+		t.Fatalf("expected the probe to be off, got %s", config.NodeProbe)
+	}
+}
+
+func TestMessageLimits(t *testing.T) {
+	config := DefaultConfig()
+	if limits := config.MessageLimits(); limits.Receive != DefaultMaxReceiveMB<<20 || limits.Send != 0 {
+		t.Fatalf("expected the default limits, got %+v", limits)
+	}
+	if err := config.Set("max-receive-mb", "128"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Set("max-send-mb", "8"); err != nil {
+		t.Fatal(err)
+	}
+	if limits := config.MessageLimits(); limits.Receive != 128<<20 || limits.Send != 8<<20 {
+		t.Fatalf("expected 128MB and 8MB, got %+v", limits)
+	}
+	if err := config.Set("max-receive-mb", "0"); err == nil {
+		t.Fatal("expected an error for a zero limit")
+	}
+	if err := config.Set("max-send-mb", "4096"); err == nil {
+		t.Fatal("expected an error for a limit above 2GB")
+	}
+	if err := config.Set("max-receive-mb", SettingOff); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := config.Get("max-receive-mb"); value != "64" {
+		t.Fatalf("expected the default of 64MB, got %s", value)
+	}
+}
+
+func TestStreamRetries(t *testing.T) {
+	config := DefaultConfig()
+	if config.StreamAttempts() != DefaultStreamRetries {
+		t.Fatalf("expected %d attempts by default, got %d", DefaultStreamRetries, config.StreamAttempts())
+	}
+	if err := config.Set("stream-retries", "3"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := config.Get("stream-retries"); value != "3" || config.StreamAttempts() != 3 {
+		t.Fatalf("expected 3 attempts, got %s", value)
+	}
+	for _, value := range []string{"0", "-1", "1001", "many"} {
+		if err := config.Set("stream-retries", value); err == nil {
+			t.Fatalf("expected an error for %s", value)
+		}
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	config := DefaultConfig()
+	if config.StateCacheTTL() != DefaultCacheTTL {
+		t.Fatalf("expected the default cache ttl, got %v", config.StateCacheTTL())
+	}
+	if err := config.Set("cache-ttl", "2s"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := config.Get("cache-ttl"); value != "2s" || config.StateCacheTTL() != 2*time.Second {
+		t.Fatalf("expected 2s, got %s", value)
+	}
+	for _, value := range []string{"0s", "-1s", "1m", "soon"} {
+		if err := config.Set("cache-ttl", value); err == nil {
+			t.Fatalf("expected an error for %s", value)
+		}
+	}
+	if err := config.Set("cache-ttl", SettingOff); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := config.Get("cache-ttl"); value != SettingOff || config.StateCacheTTL() != 0 {
+		t.Fatalf("expected the cache to be off, got %s", value)
+	}
+}
+
+func TestTxFormat(t *testing.T) {
+	config := DefaultConfig()
+	if value, _ := config.Get("tx-format"); value != TxFormatAuto {
+		t.Fatalf("expected the default format %s, got %s", TxFormatAuto, value)
+	}
+	if codec, err := config.TxCodec("v1.0.0"); err != nil || codec.Format() != TxFormatGenvm {
+		t.Fatalf("expected the format of the node, got %v %v", codec, err)
+	}
+	if err := config.Set("tx-format", "scale"); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+	if err := config.Set("tx-format", TxFormatLegacy); err != nil {
+		t.Fatal(err)
+	}
+	if codec, err := config.TxCodec("v1.0.0"); err != nil || codec.Format() != TxFormatLegacy {
+		t.Fatalf("expected the format of the setting, got %v %v", codec, err)
+	}
+	if err := config.Set("tx-format", TxFormatAuto); err != nil {
+		t.Fatal(err)
+	}
+	if config.TxFormat != "" {
+		t.Fatalf("expected auto to be stored as the default, got %s", config.TxFormat)
+	}
+}
+
+func TestGenesisID(t *testing.T) {
+	config := DefaultConfig()
+	if value, _ := config.Get("genesis-id"); value != SettingOff {
+		t.Fatalf("expected no genesis id, got %s", value)
+	}
+	for _, value := range []string{"0x1234", "zz", "4ab8dd4df9abba836365d3cdda324c3bcde59ee800"} {
+		if err := config.Set("genesis-id", value); err == nil {
+			t.Fatalf("expected an error for %s", value)
+		}
+	}
+	if err := config.Set("genesis-id", "0x4AB8DD4DF9ABBA836365D3CDDA324C3BCDE59EE8"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := config.Get("genesis-id"); value != "4ab8dd4df9abba836365d3cdda324c3bcde59ee8" {
+		t.Fatalf("expected the genesis id in lowercase hex, got %s", value)
+	}
+	if err := config.Set("genesis-id", SettingOff); err != nil {
+		t.Fatal(err)
+	}
+	if config.GenesisID != "" {
+		t.Fatalf("expected the genesis id to be cleared, got %s", config.GenesisID)
+	}
+}
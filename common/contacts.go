@@ -0,0 +1,155 @@
+package common
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// ContactsFileName is the name of the address book file in the wallets directory
+const ContactsFileName = "smrepl_contacts.json"
+
+// Contact is a named address in the address book
+type Contact struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// SmesherContact is a named smesher id in the address book
+type SmesherContact struct {
+	Name string `json:"name"`
+	Id   string `json:"id"`
+}
+
+// AddressBook holds frequently used addresses by name. It is stored in its own file and not in the wallet.
+// Smesher ids are named in their own namespace, so a smesher and a contact may have the same name.
+type AddressBook struct {
+	path     string
+	Contacts []Contact        `json:"contacts"`
+	Smeshers []SmesherContact `json:"smeshers"`
+}
+
+// LoadAddressBook reads an address book file. A missing file results in an empty address book.
+func LoadAddressBook(path string) (*AddressBook, error) {
+	book := &AddressBook{path: path, Contacts: []Contact{}, Smeshers: []SmesherContact{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return book, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, book); err != nil {
+		return nil, fmt.Errorf("failed to parse address book %s: %v", path, err)
+	}
+	return book, nil
+}
+
+// Save writes the address book to its file
+func (b *AddressBook) Save() error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WritePrivateFile(b.path, data)
+}
+
+// Add adds a named address. Names must be unique.
+func (b *AddressBook) Add(name string, address gosmtypes.Address) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("contact name can not be blank")
+	}
+	if _, ok := b.Lookup(name); ok {
+		return fmt.Errorf("a contact named %s already exists", name)
+	}
+	b.Contacts = append(b.Contacts, Contact{Name: name, Address: address.Hex()})
+	sort.Slice(b.Contacts, func(i, j int) bool { return b.Contacts[i].Name < b.Contacts[j].Name })
+	return nil
+}
+
+// Delete removes a named address
+func (b *AddressBook) Delete(name string) error {
+	for i, c := range b.Contacts {
+		if c.Name == name {
+			b.Contacts = append(b.Contacts[:i], b.Contacts[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no contact named %s", name)
+}
+
+// Lookup returns the address of a contact
+func (b *AddressBook) Lookup(name string) (gosmtypes.Address, bool) {
+	for _, c := range b.Contacts {
+		if c.Name == name {
+			return gosmtypes.HexToAddress(c.Address), true
+		}
+	}
+	return gosmtypes.Address{}, false
+}
+
+// NameOf returns the name of the contact with the provided address
+func (b *AddressBook) NameOf(address gosmtypes.Address) (string, bool) {
+	for _, c := range b.Contacts {
+		if gosmtypes.HexToAddress(c.Address) == address {
+			return c.Name, true
+		}
+	}
+	return "", false
+}
+
+// AddSmesher adds a named smesher id. Names must be unique among the smeshers.
+func (b *AddressBook) AddSmesher(name string, id []byte) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("smesher name can not be blank")
+	}
+	if _, ok := b.LookupSmesher(name); ok {
+		return fmt.Errorf("a smesher named %s already exists", name)
+	}
+	b.Smeshers = append(b.Smeshers, SmesherContact{Name: name, Id: hex.EncodeToString(id)})
+	sort.Slice(b.Smeshers, func(i, j int) bool { return b.Smeshers[i].Name < b.Smeshers[j].Name })
+	return nil
+}
+
+// DeleteSmesher removes a named smesher id
+func (b *AddressBook) DeleteSmesher(name string) error {
+	for i, c := range b.Smeshers {
+		if c.Name == name {
+			b.Smeshers = append(b.Smeshers[:i], b.Smeshers[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no smesher named %s", name)
+}
+
+// LookupSmesher returns the id of a named smesher
+func (b *AddressBook) LookupSmesher(name string) ([]byte, bool) {
+	for _, c := range b.Smeshers {
+		if c.Name == name {
+			if id, err := hex.DecodeString(c.Id); err == nil {
+				return id, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// SmesherNameOf returns the name of the smesher with the provided id
+func (b *AddressBook) SmesherNameOf(id []byte) (string, bool) {
+	for _, c := range b.Smeshers {
+		if saved, err := hex.DecodeString(c.Id); err == nil && bytes.Equal(saved, id) {
+			return c.Name, true
+		}
+	}
+	return "", false
+}
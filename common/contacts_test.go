@@ -0,0 +1,64 @@
+package common
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAddressBookSmeshers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smrepl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, ContactsFileName)
+
+	book, err := LoadAddressBook(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	home, cloud := bytes.Repeat([]byte{1}, 20), bytes.Repeat([]byte{2}, 32)
+	if err := book.AddSmesher("home", home); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.AddSmesher("cloud", cloud); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.AddSmesher("home", cloud); err == nil {
+		t.Fatal("expected an error for a duplicate smesher name")
+	}
+	if err := book.AddSmesher(" ", cloud); err == nil {
+		t.Fatal("expected an error for a blank smesher name")
+	}
+	if err := book.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if book, err = LoadAddressBook(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Smeshers) != 2 || book.Smeshers[0].Name != "cloud" || len(book.Contacts) != 0 {
+		t.Fatalf("expected the smeshers sorted by name and no contacts, got %+v", book)
+	}
+	if id, ok := book.LookupSmesher("home"); !ok || !bytes.Equal(id, home) {
+		t.Fatalf("expected the home id, got %x %v", id, ok)
+	}
+	if _, ok := book.Lookup("home"); ok {
+		t.Fatal("expected smesher names not to be contacts")
+	}
+	if name, ok := book.SmesherNameOf(cloud); !ok || name != "cloud" {
+		t.Fatalf("expected cloud, got %s %v", name, ok)
+	}
+	if err := book.DeleteSmesher("home"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := book.LookupSmesher("home"); ok {
+		t.Fatal("expected home to be deleted")
+	}
+	if err := book.DeleteSmesher("home"); err == nil {
+		t.Fatal("expected an error deleting a missing smesher")
+	}
+}
@@ -0,0 +1,119 @@
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// postDataPattern matches the names of PoST data files
+const postDataPattern = "postdata_*.bin"
+
+// RequiredSpace returns the free space needed for size bytes of PoST data, which is the size with
+// a 10% margin
+func RequiredSpace(size uint64) uint64 {
+	return size + size/10
+}
+
+// SpaceError is returned when the disk of a data directory has no room for the PoST data
+type SpaceError struct {
+	Free, Required uint64
+}
+
+func (e *SpaceError) Error() string {
+	return fmt.Sprintf("the disk of the data directory has %.2f GiB free, %.2f GiB are needed for the data with a 10%% margin", GiB(e.Free), GiB(e.Required))
+}
+
+// CheckDataDir checks that a PoST data directory exists or can be created, that it is writable and
+// that its disk has room for size bytes of data with a 10% margin, or returns a *SpaceError. A
+// missing directory is created.
+func CheckDataDir(dir string, size uint64) error {
+	if err := os.MkdirAll(dir, PrivateDirMode); err != nil {
+		return fmt.Errorf("the data directory can't be created: %v", err)
+	}
+	if err := checkWritable(dir); err != nil {
+		return fmt.Errorf("the data directory isn't writable: %v", err)
+	}
+
+	free, err := freeSpace(dir)
+	if err != nil {
+		return fmt.Errorf("the free space of the data directory can't be read: %v", err)
+	}
+	if required := RequiredSpace(size); free < required {
+		return &SpaceError{Free: free, Required: required}
+	}
+	return nil
+}
+
+// PrepareWalletDir creates the wallet directory with mode PrivateDirMode when it is missing and
+// checks that files can be written to it, so the first wallet isn't lost when it is saved. It
+// returns true when the directory was created.
+func PrepareWalletDir(dir string) (bool, error) {
+	created := false
+	info, err := os.Stat(dir)
+	switch {
+	case os.IsNotExist(err):
+		if err := os.MkdirAll(dir, PrivateDirMode); err != nil {
+			return false, fmt.Errorf("the wallet directory %s can't be created: %v", dir, err)
+		}
+		created = true
+	case err != nil:
+		return false, fmt.Errorf("the wallet directory %s can't be read: %v", dir, err)
+	case !info.IsDir():
+		return false, fmt.Errorf("the wallet directory %s isn't a directory", dir)
+	}
+	if err := checkWritable(dir); err != nil {
+		return created, fmt.Errorf("the wallet directory %s isn't writable: %v", dir, err)
+	}
+	return created, nil
+}
+
+// checkWritable creates and removes a temporary file in a directory
+func checkWritable(dir string) error {
+	probe, err := ioutil.TempFile(dir, ".smrepl-write-check")
+	if err != nil {
+		return err
+	}
+	_ = probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// ExistingPostData returns the number and size in bytes of the PoST data files already in a
+// directory, which are 0 when there are none or the directory doesn't exist
+func ExistingPostData(dir string) (int, uint64, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	count, size := 0, uint64(0)
+	for _, f := range files {
+		if match, _ := filepath.Match(postDataPattern, f.Name()); match && f.Mode().IsRegular() {
+			count++
+			size += uint64(f.Size())
+		}
+	}
+	return count, size, nil
+}
+
+// DirSize returns the size in bytes of all regular files under a directory, which is 0 when the
+// directory doesn't exist
+func DirSize(dir string) (uint64, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return 0, nil
+	}
+	var size uint64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += uint64(info.Size())
+		}
+		return nil
+	})
+	return size, err
+}
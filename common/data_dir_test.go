@@ -0,0 +1,141 @@
+package common
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckDataDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smrepl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	postDir := filepath.Join(dir, "post")
+	if err := CheckDataDir(postDir, 1<<20); err != nil {
+		t.Fatalf("expected a small size to fit, got %v", err)
+	}
+	if _, err := os.Stat(postDir); err != nil {
+		t.Fatalf("expected the directory to be created, got %v", err)
+	}
+	err = CheckDataDir(postDir, 1<<62)
+	var spaceErr *SpaceError
+	if !errors.As(err, &spaceErr) || spaceErr.Required != RequiredSpace(1<<62) || !strings.Contains(err.Error(), "GiB free") {
+		t.Fatalf("expected the free space to be too small, got %v", err)
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckDataDir(filepath.Join(file, "post"), 1<<20); err == nil {
+		t.Fatal("expected a directory under a file to fail")
+	}
+	if RequiredSpace(100) != 110 {
+		t.Fatalf("expected a 10%% margin, got %d", RequiredSpace(100))
+	}
+}
+
+func TestPrepareWalletDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smrepl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	walletDir := filepath.Join(dir, "wallets", "main")
+	if created, err := PrepareWalletDir(walletDir); !created || err != nil {
+		t.Fatalf("expected the missing directory to be created, got %v %v", created, err)
+	}
+	info, err := os.Stat(walletDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != PrivateDirMode {
+		t.Fatalf("expected mode %v, got %v", PrivateDirMode, mode)
+	}
+	if created, err := PrepareWalletDir(walletDir); created || err != nil {
+		t.Fatalf("expected the existing directory to be used, got %v %v", created, err)
+	}
+	if files, _ := ioutil.ReadDir(walletDir); len(files) != 0 {
+		t.Fatalf("expected the write check to leave no file, got %d", len(files))
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PrepareWalletDir(file); err == nil {
+		t.Fatal("expected an error for a file")
+	}
+}
+
+func TestPrepareWalletDirReadOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smrepl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Chmod(dir, 0700)
+
+	if err := os.Chmod(dir, 0500); err != nil {
+		t.Fatal(err)
+	}
+	if checkWritable(dir) == nil {
+		t.Skip("the directory is writable regardless of its mode, such as when running as root")
+	}
+	if _, err := PrepareWalletDir(dir); err == nil || !strings.Contains(err.Error(), "isn't writable") {
+		t.Fatalf("expected a read-only directory to fail, got %v", err)
+	}
+	if _, err := PrepareWalletDir(filepath.Join(dir, "wallets")); err == nil {
+		t.Fatal("expected a directory which can't be created to fail")
+	}
+}
+
+func TestExistingPostData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smrepl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if files, size, err := ExistingPostData(filepath.Join(dir, "missing")); files != 0 || size != 0 || err != nil {
+		t.Fatalf("expected no data in a missing directory, got %d files %d %v", files, size, err)
+	}
+	for name, size := range map[string]int{"postdata_0.bin": 100, "postdata_1.bin": 50, "other.bin": 10} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), make([]byte, size), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if files, size, err := ExistingPostData(dir); files != 2 || size != 150 || err != nil {
+		t.Fatalf("expected 2 files of 150 bytes, got %d files %d %v", files, size, err)
+	}
+}
+
+func TestDirSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smrepl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if size, err := DirSize(filepath.Join(dir, "missing")); size != 0 || err != nil {
+		t.Fatalf("expected a missing directory to be empty, got %d %v", size, err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for name, size := range map[string]int{"postdata_0.bin": 100, "postdata_metadata.json": 20, "sub/other": 5} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), make([]byte, size), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if size, err := DirSize(dir); size != 125 || err != nil {
+		t.Fatalf("expected 125 bytes, got %d %v", size, err)
+	}
+}
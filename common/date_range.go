@@ -0,0 +1,78 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Forms of the dates of a date range other than YYYY-MM-DD
+const (
+	DateLastMonth = "last-month"
+	DateThisMonth = "this-month"
+	dateEpoch     = "epoch:"
+)
+
+// firstLayerFrom returns the first layer which starts at or after t. Times before genesis give
+// layer 0.
+func (n *NetInfo) firstLayerFrom(t time.Time) uint32 {
+	unix := t.Unix()
+	if unix <= int64(n.GenesisTime) {
+		return 0
+	}
+	return uint32((uint64(unix) - n.GenesisTime + n.LayerDuration - 1) / n.LayerDuration)
+}
+
+// dateLayers returns the first layer of a date and the layer after its last one. A date is a day
+// in the location of now (YYYY-MM-DD), the previous or the current month of now, or an epoch
+// (epoch:N).
+func (n *NetInfo) dateLayers(date string, now time.Time) (uint32, uint32, error) {
+	if strings.HasPrefix(date, dateEpoch) {
+		epoch, err := strconv.ParseUint(strings.TrimPrefix(date, dateEpoch), 10, 32)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid epoch in %s, expected epoch:<number>", date)
+		}
+		if n.LayerPerEpoch == 0 {
+			return 0, 0, fmt.Errorf("the number of layers per epoch isn't known")
+		}
+		return uint32(epoch * n.LayerPerEpoch), uint32((epoch + 1) * n.LayerPerEpoch), nil
+	}
+	if n.LayerDuration == 0 {
+		return 0, 0, fmt.Errorf("the layer duration isn't known")
+	}
+	var start, end time.Time
+	switch date {
+	case DateLastMonth, DateThisMonth:
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		if date == DateLastMonth {
+			start = start.AddDate(0, -1, 0)
+		}
+		end = start.AddDate(0, 1, 0)
+	default:
+		day, err := time.ParseInLocation("2006-01-02", date, now.Location())
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid date %s, expected YYYY-MM-DD, %s, %s or epoch:<number>", date, DateLastMonth, DateThisMonth)
+		}
+		start, end = day, day.AddDate(0, 0, 1)
+	}
+	return n.firstLayerFrom(start), n.firstLayerFrom(end), nil
+}
+
+// DateRangeLayers returns the first and last layers, inclusive, which start from the start of the
+// from date to the end of the to date. Dates are days (YYYY-MM-DD) in the location of now,
+// last-month or this-month relative to now, or epochs (epoch:N).
+func (n *NetInfo) DateRangeLayers(from, to string, now time.Time) (uint32, uint32, error) {
+	first, _, err := n.dateLayers(from, now)
+	if err != nil {
+		return 0, 0, err
+	}
+	_, end, err := n.dateLayers(to, now)
+	if err != nil {
+		return 0, 0, err
+	}
+	if end <= first {
+		return 0, 0, fmt.Errorf("no layers start from %s to %s", from, to)
+	}
+	return first, end - 1, nil
+}
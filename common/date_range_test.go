@@ -0,0 +1,50 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateRangeLayers(t *testing.T) {
+	// hourly layers and daily epochs from 2024-03-31
+	n := &NetInfo{GenesisTime: uint64(time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC).Unix()), LayerDuration: 3600, LayerPerEpoch: 24}
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		from, to    string
+		first, last uint32
+	}{
+		{"2024-04-01", "2024-04-01", 24, 47},
+		{"2024-04-01", "2024-04-02", 24, 71},
+		{"epoch:2", "epoch:2", 48, 71},
+		{"epoch:1", "2024-04-02", 24, 71},
+		{DateLastMonth, DateLastMonth, 24, 743},
+		{DateThisMonth, DateThisMonth, 744, 1487},
+		{"2024-03-01", "2024-03-31", 0, 23},
+	} {
+		first, last, err := n.DateRangeLayers(test.from, test.to, now)
+		if err != nil || first != test.first || last != test.last {
+			t.Fatalf("%s to %s: expected layers %d to %d, got %d to %d %v", test.from, test.to, test.first, test.last, first, last, err)
+		}
+	}
+
+	// a genesis in the middle of an hour: the first layer of a day is the first to start in it
+	shifted := &NetInfo{GenesisTime: n.GenesisTime + 1800, LayerDuration: 3600, LayerPerEpoch: 24}
+	if first, last, err := shifted.DateRangeLayers("2024-04-01", "2024-04-01", now); err != nil || first != 24 || last != 47 {
+		t.Fatalf("expected layers 24 to 47, got %d to %d %v", first, last, err)
+	}
+
+	for _, test := range [][2]string{
+		{"2024-03-01", "2024-03-01"},
+		{"2024-04-02", "2024-04-01"},
+		{"01/04/2024", "2024-04-01"},
+		{"epoch:x", "epoch:2"},
+		{"2024-04-01", "next-month"},
+	} {
+		if _, _, err := n.DateRangeLayers(test[0], test[1], now); err == nil {
+			t.Fatalf("%s to %s: expected an error", test[0], test[1])
+		}
+	}
+	if _, _, err := (&NetInfo{}).DateRangeLayers("epoch:1", "epoch:1", now); err == nil {
+		t.Fatal("expected an error without the layers per epoch")
+	}
+}
@@ -0,0 +1,42 @@
+package common
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+)
+
+const (
+	// MinSeedSize is the minimum size in bytes of a seed provided by the user
+	MinSeedSize = 32
+	// entropySize is the size of the wallet entropy derived from seeds, a 24 words mnemonic
+	entropySize = 32
+)
+
+// SeedEntropy returns the wallet entropy for a seed provided by the user. The same seed always
+// results in the same entropy and so in the same mnemonic and accounts. Seeds longer than 32 bytes
+// are hashed.
+func SeedEntropy(seed []byte) ([]byte, error) {
+	if len(seed) < MinSeedSize {
+		return nil, fmt.Errorf("seed is %d bytes long, at least %d bytes are required", len(seed), MinSeedSize)
+	}
+	if len(seed) == entropySize {
+		return append([]byte{}, seed...), nil
+	}
+	sum := sha256.Sum256(seed)
+	return sum[:], nil
+}
+
+// MixEntropy returns random wallet entropy mixed with additional randomness provided by the user.
+// The result is at least as random as crypto/rand alone.
+func MixEntropy(extra []byte) ([]byte, error) {
+	random := make([]byte, entropySize)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write(random)
+	h.Write(extra)
+	Zero(random)
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSeedEntropy(t *testing.T) {
+	if _, err := SeedEntropy(bytes.Repeat([]byte{1}, MinSeedSize-1)); err == nil {
+		t.Fatal("expected short seeds to be refused")
+	}
+
+	seed := bytes.Repeat([]byte{1}, MinSeedSize)
+	e1, err := SeedEntropy(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e2, err := SeedEntropy(append([]byte{}, seed...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(e1, e2) {
+		t.Fatal("expected the same seed to give the same entropy")
+	}
+
+	long, err := SeedEntropy(bytes.Repeat([]byte{1}, 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(long) != entropySize || bytes.Equal(long, e1) {
+		t.Fatal("expected long seeds to be hashed")
+	}
+}
+
+func TestMixEntropy(t *testing.T) {
+	extra := []byte("dice rolls")
+	e1, err := MixEntropy(extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e2, err := MixEntropy(extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e1) != entropySize {
+		t.Fatalf("expected %d bytes of entropy, got %d", entropySize, len(e1))
+	}
+	if bytes.Equal(e1, e2) {
+		t.Fatal("expected mixed entropy to include randomness")
+	}
+}
@@ -0,0 +1,95 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Kinds of transaction fees
+const (
+	FeePaid = "paid"
+	FeeMax  = "max"
+)
+
+// FeeEstimate holds suggested gas prices computed from the gas prices of transactions included in
+// a range of layers
+type FeeEstimate struct {
+	Low        uint64    `json:"low"`
+	Normal     uint64    `json:"normal"`
+	Fast       uint64    `json:"fast"`
+	Samples    int       `json:"samples"`
+	FirstLayer uint32    `json:"firstLayer"`
+	LastLayer  uint32    `json:"lastLayer"`
+	Computed   time.Time `json:"computed"`
+}
+
+// pricePercentile returns the p-th percentile of sorted prices, at least minPrice. Without prices
+// it is minPrice.
+func pricePercentile(sorted []uint64, p int, minPrice uint64) uint64 {
+	if len(sorted) == 0 {
+		return minPrice
+	}
+	price := sorted[p*(len(sorted)-1)/100]
+	if price < minPrice {
+		return minPrice
+	}
+	return price
+}
+
+// NewFeeEstimate suggests gas prices from the gas prices of the transactions included in layers
+// first to last: the 25th, 50th and 90th percentiles. Without samples every suggestion is
+// minPrice. Suggestions are never lower than minPrice.
+func NewFeeEstimate(prices []uint64, first, last uint32, minPrice uint64) *FeeEstimate {
+	sorted := append([]uint64(nil), prices...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return &FeeEstimate{
+		Low:        pricePercentile(sorted, 25, minPrice),
+		Normal:     pricePercentile(sorted, 50, minPrice),
+		Fast:       pricePercentile(sorted, 90, minPrice),
+		Samples:    len(sorted),
+		FirstLayer: first,
+		LastLayer:  last,
+		Computed:   time.Now(),
+	}
+}
+
+// TxFee is what a transaction costs its sender. Once applied it is the fee paid, gas used times gas
+// price. Before that it is the maximum fee, gas limit times gas price.
+type TxFee struct {
+	Paid     bool
+	Amount   uint64
+	GasUsed  uint64
+	GasPrice uint64
+	GasLimit uint64
+}
+
+// MaxTxFee returns the maximum fee of a transaction which hasn't been applied
+func MaxTxFee(gasPrice, gasLimit uint64) TxFee {
+	return TxFee{Amount: gasPrice * gasLimit, GasPrice: gasPrice, GasLimit: gasLimit}
+}
+
+// PaidTxFee returns the fee paid by an applied transaction. charged is the fee of its receipt; when
+// the receipt has none it is computed from the gas used.
+func PaidTxFee(gasPrice, gasLimit, gasUsed, charged uint64) TxFee {
+	if charged == 0 {
+		charged = gasUsed * gasPrice
+	}
+	return TxFee{Paid: true, Amount: charged, GasUsed: gasUsed, GasPrice: gasPrice, GasLimit: gasLimit}
+}
+
+// Kind returns paid or max
+func (f TxFee) Kind() string {
+	if f.Paid {
+		return FeePaid
+	}
+	return FeeMax
+}
+
+// String describes the fee and how it is computed
+func (f TxFee) String() string {
+	if f.Paid {
+		return fmt.Sprintf("fee paid: %d Smidge (gas used %d × price %d)", f.Amount, f.GasUsed, f.GasPrice)
+	}
+	return fmt.Sprintf("max fee: %d Smidge (gas limit %d × price %d)", f.Amount, f.GasLimit, f.GasPrice)
+}
@@ -0,0 +1,50 @@
+package common
+
+import "testing"
+
+func TestNewFeeEstimate(t *testing.T) {
+	prices := []uint64{10, 1, 3, 2, 5, 4, 7, 6, 9, 8}
+	e := NewFeeEstimate(prices, 5, 10, 1)
+	if e.Low != 3 || e.Normal != 5 || e.Fast != 9 {
+		t.Fatalf("unexpected estimate: %d %d %d", e.Low, e.Normal, e.Fast)
+	}
+	if e.Samples != 10 || e.FirstLayer != 5 || e.LastLayer != 10 {
+		t.Fatalf("unexpected sample range: %d samples, layers %d-%d", e.Samples, e.FirstLayer, e.LastLayer)
+	}
+	if prices[0] != 10 {
+		t.Fatal("expected the prices to be left unsorted")
+	}
+
+	e = NewFeeEstimate(nil, 0, 10, 1)
+	if e.Low != 1 || e.Normal != 1 || e.Fast != 1 || e.Samples != 0 {
+		t.Fatal("expected the minimum price without samples")
+	}
+
+	e = NewFeeEstimate([]uint64{0, 0, 0, 2}, 0, 10, 1)
+	if e.Low != 1 || e.Normal != 1 || e.Fast != 1 {
+		t.Fatalf("expected suggestions of at least the minimum price: %d %d %d", e.Low, e.Normal, e.Fast)
+	}
+}
+
+func TestTxFee(t *testing.T) {
+	fee := MaxTxFee(3, 100)
+	if fee.Paid || fee.Amount != 300 || fee.Kind() != FeeMax {
+		t.Fatalf("unexpected maximum fee %+v", fee)
+	}
+	if s := fee.String(); s != "max fee: 300 Smidge (gas limit 100 × price 3)" {
+		t.Fatalf("unexpected description %q", s)
+	}
+
+	fee = PaidTxFee(3, 100, 21, 0)
+	if !fee.Paid || fee.Amount != 63 || fee.Kind() != FeePaid {
+		t.Fatalf("expected the fee computed from the gas used, got %+v", fee)
+	}
+	if s := fee.String(); s != "fee paid: 63 Smidge (gas used 21 × price 3)" {
+		t.Fatalf("unexpected description %q", s)
+	}
+
+	fee = PaidTxFee(3, 100, 21, 70)
+	if fee.Amount != 70 {
+		t.Fatalf("expected the fee charged by the receipt, got %d", fee.Amount)
+	}
+}
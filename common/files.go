@@ -0,0 +1,37 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+)
+
+const (
+	// PrivateFileMode is the mode of the wallet files and the other files written to the wallets directory
+	PrivateFileMode os.FileMode = 0600
+	// PrivateDirMode is the mode of the directories created for wallet files
+	PrivateDirMode os.FileMode = 0700
+)
+
+// WritePrivateFile writes data to path with PrivateFileMode. Unlike ioutil.WriteFile the mode is
+// also applied when the file already exists.
+func WritePrivateFile(path string, data []byte) error {
+	if err := ioutil.WriteFile(path, data, PrivateFileMode); err != nil {
+		return err
+	}
+	return os.Chmod(path, PrivateFileMode)
+}
+
+// InsecurePermissions returns the mode of the file at path and true if users other than its owner
+// can access it. Permissions are not checked on Windows.
+func InsecurePermissions(path string) (os.FileMode, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false, err
+	}
+	mode := info.Mode().Perm()
+	if runtime.GOOS == "windows" {
+		return mode, false, nil
+	}
+	return mode, mode&^PrivateFileMode != 0, nil
+}
@@ -0,0 +1,73 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWritePrivateFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on Windows")
+	}
+	dir, err := ioutil.TempDir("", "files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "new.json")
+	if err := WritePrivateFile(path, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if mode, insecure, err := InsecurePermissions(path); err != nil || insecure || mode != PrivateFileMode {
+		t.Fatalf("expected a new file with mode %v, got %v (%v)", PrivateFileMode, mode, err)
+	}
+
+	existing := filepath.Join(dir, "existing.json")
+	if err := ioutil.WriteFile(existing, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, insecure, err := InsecurePermissions(existing); err != nil || !insecure {
+		t.Fatalf("expected a world readable file to be insecure (%v)", err)
+	}
+	if err := WritePrivateFile(existing, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if mode, insecure, err := InsecurePermissions(existing); err != nil || insecure || mode != PrivateFileMode {
+		t.Fatalf("expected an existing file to get mode %v, got %v (%v)", PrivateFileMode, mode, err)
+	}
+}
+
+func TestSavedFilesArePrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on Windows")
+	}
+	dir, err := ioutil.TempDir("", "files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config, err := LoadConfig(filepath.Join(dir, ConfigFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Save(); err != nil {
+		t.Fatal(err)
+	}
+	book, err := LoadAddressBook(filepath.Join(dir, ContactsFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := book.Save(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{ConfigFileName, ContactsFileName} {
+		if mode, insecure, err := InsecurePermissions(filepath.Join(dir, name)); err != nil || insecure {
+			t.Fatalf("%s: expected mode %v, got %v (%v)", name, PrivateFileMode, mode, err)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package common
+
+import "syscall"
+
+// slowFilesystems are the network and user space filesystems whose reads are too slow to generate
+// proofs from PoST data in time, by the magic number statfs reports for them
+var slowFilesystems = map[uint32]string{
+	0x6969:     "NFS",
+	0x517b:     "SMB",
+	0xff534d42: "CIFS",
+	0xfe534d42: "SMB2",
+	0x65735546: "FUSE",
+}
+
+// SlowFilesystem returns the type of the filesystem of a directory when it is known to be too slow
+// for PoST data
+func SlowFilesystem(dir string) (string, bool) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return "", false
+	}
+	name, ok := slowFilesystems[uint32(stat.Type)]
+	return name, ok
+}
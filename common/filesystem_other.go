@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package common
+
+// SlowFilesystem returns the type of the filesystem of a directory when it is known to be too slow
+// for PoST data. Filesystem types are only detected on Linux.
+func SlowFilesystem(dir string) (string, bool) {
+	return "", false
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package common
+
+import "syscall"
+
+// freeSpace returns the bytes available to the user on the disk of a directory
+func freeSpace(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,102 @@
+package common
+
+import (
+	"sort"
+	"time"
+)
+
+// GasPriceBucket counts the included transactions which paid a gas price
+type GasPriceBucket struct {
+	Price uint64 `json:"price"`
+	Count int    `json:"count"`
+}
+
+// GasOracleReport describes the gas prices of the transactions included in a range of layers
+type GasOracleReport struct {
+	P10        uint64           `json:"p10"`
+	P50        uint64           `json:"p50"`
+	P90        uint64           `json:"p90"`
+	Samples    int              `json:"samples"`
+	Buckets    []GasPriceBucket `json:"buckets"`
+	FirstLayer uint32           `json:"firstLayer"`
+	LastLayer  uint32           `json:"lastLayer"`
+	Computed   time.Time        `json:"computed"`
+}
+
+// GasOracle keeps the gas prices of the transactions included in a window of recent layers so
+// that only new layers need to be fetched to update it
+type GasOracle struct {
+	// Window is the number of layers reported on
+	Window uint32
+
+	prices  map[uint32][]uint64
+	scanned bool
+	last    uint32
+}
+
+// NewGasOracle creates an empty oracle for a window of layers
+func NewGasOracle(window uint32) *GasOracle {
+	return &GasOracle{Window: window, prices: make(map[uint32][]uint64)}
+}
+
+// firstLayer returns the first layer of the window ending at layer current
+func (o *GasOracle) firstLayer(current uint32) uint32 {
+	if current >= o.Window {
+		return current - o.Window + 1
+	}
+	return 0
+}
+
+// Missing returns the range of layers to fetch to bring the oracle up to layer current. The last
+// scanned layer is fetched again as it may have got more blocks since.
+func (o *GasOracle) Missing(current uint32) (first, last uint32) {
+	first = o.firstLayer(current)
+	if o.scanned && o.last > first {
+		first = o.last
+	}
+	return first, current
+}
+
+// Add records the gas prices of the transactions included in a layer, replacing those recorded
+// before for it, and drops the layers which left the window
+func (o *GasOracle) Add(layer uint32, prices []uint64) {
+	o.prices[layer] = prices
+	if !o.scanned || layer > o.last {
+		o.last = layer
+		o.scanned = true
+	}
+	first := o.firstLayer(o.last)
+	for l := range o.prices {
+		if l < first {
+			delete(o.prices, l)
+		}
+	}
+}
+
+// Report computes the 10th, 50th and 90th percentiles and the number of transactions per gas price
+// of the layers in the window. Percentiles are never lower than minPrice.
+func (o *GasOracle) Report(minPrice uint64) *GasOracleReport {
+	var sorted []uint64
+	for _, prices := range o.prices {
+		sorted = append(sorted, prices...)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	buckets := make([]GasPriceBucket, 0)
+	for _, price := range sorted {
+		if n := len(buckets); n > 0 && buckets[n-1].Price == price {
+			buckets[n-1].Count++
+		} else {
+			buckets = append(buckets, GasPriceBucket{Price: price, Count: 1})
+		}
+	}
+	return &GasOracleReport{
+		P10:        pricePercentile(sorted, 10, minPrice),
+		P50:        pricePercentile(sorted, 50, minPrice),
+		P90:        pricePercentile(sorted, 90, minPrice),
+		Samples:    len(sorted),
+		Buckets:    buckets,
+		FirstLayer: o.firstLayer(o.last),
+		LastLayer:  o.last,
+		Computed:   time.Now(),
+	}
+}
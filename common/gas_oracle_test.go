@@ -0,0 +1,48 @@
+package common
+
+import "testing"
+
+func TestGasOracleIncremental(t *testing.T) {
+	o := NewGasOracle(3)
+	if first, last := o.Missing(10); first != 8 || last != 10 {
+		t.Fatalf("expected layers 8 to 10 on the first scan, got %d to %d", first, last)
+	}
+	o.Add(8, []uint64{1, 2})
+	o.Add(9, nil)
+	o.Add(10, []uint64{3})
+
+	if first, last := o.Missing(12); first != 10 || last != 12 {
+		t.Fatalf("expected layers 10 to 12 after a scan, got %d to %d", first, last)
+	}
+	o.Add(10, []uint64{3, 3})
+	o.Add(11, []uint64{4})
+	o.Add(12, nil)
+
+	report := o.Report(1)
+	if report.FirstLayer != 10 || report.LastLayer != 12 {
+		t.Fatalf("unexpected layers %d to %d", report.FirstLayer, report.LastLayer)
+	}
+	if report.Samples != 3 {
+		t.Fatalf("expected layer 8 to be dropped and layer 10 replaced, got %d samples", report.Samples)
+	}
+	if len(report.Buckets) != 2 || report.Buckets[0] != (GasPriceBucket{3, 2}) || report.Buckets[1] != (GasPriceBucket{4, 1}) {
+		t.Fatalf("unexpected buckets %v", report.Buckets)
+	}
+}
+
+func TestGasOracleReport(t *testing.T) {
+	o := NewGasOracle(100)
+	o.Add(1, []uint64{10, 1, 3, 2, 5, 4, 7, 6, 9, 8, 11})
+	report := o.Report(1)
+	if report.P10 != 2 || report.P50 != 6 || report.P90 != 10 {
+		t.Fatalf("unexpected percentiles %d %d %d", report.P10, report.P50, report.P90)
+	}
+	if report.FirstLayer != 0 {
+		t.Fatalf("expected the window to start at layer 0, got %d", report.FirstLayer)
+	}
+
+	report = NewGasOracle(100).Report(1)
+	if report.Samples != 0 || report.P50 != 1 || len(report.Buckets) != 0 {
+		t.Fatal("expected the minimum price and no buckets without samples")
+	}
+}
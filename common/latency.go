@@ -0,0 +1,39 @@
+package common
+
+import (
+	"math"
+	"time"
+)
+
+// LatencyStats are the minimum, average, maximum and standard deviation of round trip times
+type LatencyStats struct {
+	Min, Avg, Max, StdDev time.Duration
+}
+
+// Latency returns the statistics of round trip times, or zero values without samples
+func Latency(samples []time.Duration) LatencyStats {
+	var stats LatencyStats
+	if len(samples) == 0 {
+		return stats
+	}
+	stats.Min, stats.Max = samples[0], samples[0]
+	var sum float64
+	for _, s := range samples {
+		if s < stats.Min {
+			stats.Min = s
+		}
+		if s > stats.Max {
+			stats.Max = s
+		}
+		sum += float64(s)
+	}
+	mean := sum / float64(len(samples))
+	var variance float64
+	for _, s := range samples {
+		variance += (float64(s) - mean) * (float64(s) - mean)
+	}
+	variance /= float64(len(samples))
+	stats.Avg = time.Duration(mean)
+	stats.StdDev = time.Duration(math.Sqrt(variance))
+	return stats
+}
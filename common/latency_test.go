@@ -0,0 +1,18 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatency(t *testing.T) {
+	stats := Latency([]time.Duration{2 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond,
+		5 * time.Millisecond, 5 * time.Millisecond, 7 * time.Millisecond, 9 * time.Millisecond})
+	expected := LatencyStats{Min: 2 * time.Millisecond, Avg: 5 * time.Millisecond, Max: 9 * time.Millisecond, StdDev: 2 * time.Millisecond}
+	if stats != expected {
+		t.Fatalf("expected %+v, got %+v", expected, stats)
+	}
+	if stats := Latency(nil); stats != (LatencyStats{}) {
+		t.Fatalf("expected zero values without samples, got %+v", stats)
+	}
+}
@@ -1,5 +1,10 @@
 package common
 
+import (
+	"fmt"
+	"time"
+)
+
 type NetInfo struct {
 	GenesisTime   uint64
 	CurrentLayer  uint32
@@ -9,3 +14,120 @@ type NetInfo struct {
 	LayerDuration uint64
 	MaxTxsPerSec  uint64
 }
+
+// SameNetwork tells whether two nodes are on the same network: they report the same network id
+// and genesis time
+func (n *NetInfo) SameNetwork(other *NetInfo) bool {
+	return n.NetId == other.NetId && n.GenesisTime == other.GenesisTime
+}
+
+// NetworkMismatchError is returned when a node is on another network than the current one
+type NetworkMismatchError struct {
+	Server       string
+	Current, New *NetInfo
+}
+
+func (e *NetworkMismatchError) Error() string {
+	return fmt.Sprintf("%s is on network %d with genesis time %d, the current node is on network %d with genesis time %d",
+		e.Server, e.New.NetId, e.New.GenesisTime, e.Current.NetId, e.Current.GenesisTime)
+}
+
+// LayerTime returns the approximate start time of a layer
+func (n *NetInfo) LayerTime(layer uint32) time.Time {
+	return time.Unix(int64(n.GenesisTime+uint64(layer)*n.LayerDuration), 0)
+}
+
+// LayerAt returns the layer in progress at a time. Times before genesis are in layer 0.
+func (n *NetInfo) LayerAt(t time.Time) uint32 {
+	unix := t.Unix()
+	if n.LayerDuration == 0 || unix < int64(n.GenesisTime) {
+		return 0
+	}
+	return uint32((uint64(unix) - n.GenesisTime) / n.LayerDuration)
+}
+
+// LayerStatus is the epoch of a layer and the times around it
+type LayerStatus struct {
+	Layer uint32
+	Epoch uint64
+	// LayersLeft is the number of layers from this one to the first layer of the next epoch
+	LayersLeft uint64
+	Start      time.Time
+	End        time.Time
+	// NextEpoch is the start time of the first layer of the next epoch
+	NextEpoch time.Time
+}
+
+// LayerStatus returns the epoch of a layer, the layers left in the epoch and the start and end
+// times of the layer and the start time of the next epoch
+func (n *NetInfo) LayerStatus(layer uint32) LayerStatus {
+	s := LayerStatus{Layer: layer, Start: n.LayerTime(layer), End: n.LayerTime(layer + 1)}
+	if n.LayerPerEpoch == 0 {
+		return s
+	}
+	s.Epoch = uint64(layer) / n.LayerPerEpoch
+	s.LayersLeft = (s.Epoch+1)*n.LayerPerEpoch - uint64(layer)
+	s.NextEpoch = n.LayerTime(uint32((s.Epoch + 1) * n.LayerPerEpoch))
+	return s
+}
+
+// HumanDuration formats a duration with its largest unit and the next one when it isn't zero,
+// e.g. 3d 4h, 5m or 45s. Negative durations are formatted as 0s.
+func HumanDuration(d time.Duration) string {
+	units := []struct {
+		size time.Duration
+		name string
+	}{{24 * time.Hour, "d"}, {time.Hour, "h"}, {time.Minute, "m"}, {time.Second, "s"}}
+	d = d.Round(time.Second)
+	for i, unit := range units {
+		if d < unit.size {
+			continue
+		}
+		s := fmt.Sprintf("%d%s", d/unit.size, unit.name)
+		if i+1 < len(units) {
+			next := units[i+1]
+			if rest := d % unit.size / next.size; rest > 0 {
+				s += fmt.Sprintf(" %d%s", rest, next.name)
+			}
+		}
+		return s
+	}
+	return "0s"
+}
+
+// ApproxDuration formats a long duration in weeks or days, e.g. ~2 weeks, and shorter ones with
+// HumanDuration
+func ApproxDuration(d time.Duration) string {
+	const day, week = 24 * time.Hour, 7 * 24 * time.Hour
+	switch {
+	case d >= 2*week:
+		return fmt.Sprintf("~%d weeks", (d+week/2)/week)
+	case d >= 2*day:
+		return fmt.Sprintf("~%d days", (d+day/2)/day)
+	default:
+		return HumanDuration(d)
+	}
+}
+
+// EpochSummary describes the length of layers and epochs, when the current epoch started and ends
+// and how long until the next one, in the time zone of now. Before genesis it tells when genesis
+// is instead of the current epoch.
+func (n *NetInfo) EpochSummary(now time.Time) string {
+	date := func(t time.Time) string { return t.In(now.Location()).Format("2006-01-02") }
+	layer := time.Duration(n.LayerDuration) * time.Second
+	s := fmt.Sprintf("layers are %s long, epochs are %d layers (%s)", HumanDuration(layer), n.LayerPerEpoch,
+		ApproxDuration(layer*time.Duration(n.LayerPerEpoch)))
+	if genesis := time.Unix(int64(n.GenesisTime), 0); now.Before(genesis) {
+		return s + fmt.Sprintf("; the network starts at genesis on %s, in %s", date(genesis), HumanDuration(genesis.Sub(now)))
+	}
+	if n.LayerPerEpoch == 0 {
+		return s
+	}
+	status := n.LayerStatus(n.CurrentLayer)
+	start := n.LayerTime(uint32(status.Epoch * n.LayerPerEpoch))
+	s += fmt.Sprintf("; current epoch %d started %s and ends %s", status.Epoch, date(start), date(status.NextEpoch))
+	if next := status.NextEpoch.Sub(now); next > 0 {
+		s += "; next epoch begins in " + HumanDuration(next)
+	}
+	return s
+}
@@ -0,0 +1,92 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLayerStatus(t *testing.T) {
+	info := &NetInfo{GenesisTime: 1600000000, LayerDuration: 30, LayerPerEpoch: 10}
+	for _, test := range []struct {
+		layer      uint32
+		epoch      uint64
+		layersLeft uint64
+	}{
+		{0, 0, 10},
+		{9, 0, 1},
+		{10, 1, 10},
+		{25, 2, 5},
+	} {
+		s := info.LayerStatus(test.layer)
+		if s.Epoch != test.epoch || s.LayersLeft != test.layersLeft {
+			t.Fatalf("layer %d: expected epoch %d with %d layers left, got %+v", test.layer, test.epoch, test.layersLeft, s)
+		}
+		if s.End.Sub(s.Start).Seconds() != 30 {
+			t.Fatalf("layer %d: expected a 30 second layer, got %v to %v", test.layer, s.Start, s.End)
+		}
+		if !s.NextEpoch.Equal(info.LayerTime(uint32((test.epoch + 1) * 10))) {
+			t.Fatalf("layer %d: unexpected next epoch time %v", test.layer, s.NextEpoch)
+		}
+	}
+	if s := (&NetInfo{LayerDuration: 30}).LayerStatus(5); s.Epoch != 0 || s.LayersLeft != 0 {
+		t.Fatalf("expected no epoch without layers per epoch, got %+v", s)
+	}
+}
+
+func TestSameNetwork(t *testing.T) {
+	current := &NetInfo{NetId: 1, GenesisTime: 1600000000, CurrentLayer: 10}
+	if !current.SameNetwork(&NetInfo{NetId: 1, GenesisTime: 1600000000, CurrentLayer: 12}) {
+		t.Fatal("expected nodes at different layers of a network to be on the same network")
+	}
+	if current.SameNetwork(&NetInfo{NetId: 2, GenesisTime: 1600000000}) {
+		t.Fatal("expected another network id to be another network")
+	}
+	if current.SameNetwork(&NetInfo{NetId: 1, GenesisTime: 1700000000}) {
+		t.Fatal("expected another genesis time to be another network")
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	for _, test := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{-time.Minute, "0s"},
+		{0, "0s"},
+		{45 * time.Second, "45s"},
+		{5 * time.Minute, "5m"},
+		{90 * time.Second, "1m 30s"},
+		{2*time.Hour + 5*time.Minute + 10*time.Second, "2h 5m"},
+		{3*24*time.Hour + 4*time.Hour + 30*time.Minute, "3d 4h"},
+		{24 * time.Hour, "1d"},
+	} {
+		if got := HumanDuration(test.d); got != test.want {
+			t.Errorf("%v: expected %q, got %q", test.d, test.want, got)
+		}
+	}
+	if got := ApproxDuration(4032 * 5 * time.Minute); got != "~2 weeks" {
+		t.Fatalf("expected ~2 weeks, got %q", got)
+	}
+	if got := ApproxDuration(60 * time.Hour); got != "~3 days" {
+		t.Fatalf("expected ~3 days, got %q", got)
+	}
+}
+
+func TestEpochSummary(t *testing.T) {
+	genesis := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	info := &NetInfo{GenesisTime: uint64(genesis.Unix()), LayerDuration: 300, LayerPerEpoch: 4032}
+	// layer 12 * 4032 + 100 is in epoch 12, which started 12 * 14 days after genesis
+	info.CurrentLayer = 12*4032 + 100
+	now := info.LayerTime(info.CurrentLayer)
+	want := "layers are 5m long, epochs are 4032 layers (~2 weeks); current epoch 12 started 2024-06-17 " +
+		"and ends 2024-07-01; next epoch begins in 13d 15h"
+	if got := info.EpochSummary(now.In(time.UTC)); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	before := genesis.Add(-(3*24*time.Hour + 4*time.Hour))
+	want = "layers are 5m long, epochs are 4032 layers (~2 weeks); the network starts at genesis on 2024-01-01, in 3d 4h"
+	if got := info.EpochSummary(before); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
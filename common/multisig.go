@@ -0,0 +1,265 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spacemeshos/ed25519"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// MultisigFileName is the name of the multisig accounts file in the wallets directory
+const MultisigFileName = "smrepl_multisig.json"
+
+// MultisigAccount is an m-of-n account: a transaction needs signatures of Threshold of the
+// participants. Only public keys are stored. It only coordinates the signatures of the
+// participants offline: no node account template implements it.
+type MultisigAccount struct {
+	Name      string `json:"name"`
+	Threshold int    `json:"threshold"`
+	// Participants are the hex encoded public keys of the signers, sorted
+	Participants []string `json:"participants"`
+}
+
+// NewMultisigAccount validates an m-of-n definition and returns the account
+func NewMultisigAccount(name string, threshold int, participants []ed25519.PublicKey) (*MultisigAccount, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("multisig account name can not be blank")
+	}
+	keys, err := participantKeys(participants)
+	if err != nil {
+		return nil, err
+	}
+	if threshold < 1 || threshold > len(keys) {
+		return nil, fmt.Errorf("threshold must be between 1 and the number of participants (%d)", len(keys))
+	}
+	return &MultisigAccount{Name: name, Threshold: threshold, Participants: keys}, nil
+}
+
+// participantKeys hex encodes and sorts public keys and rejects duplicates
+func participantKeys(participants []ed25519.PublicKey) ([]string, error) {
+	keys := make([]string, 0, len(participants))
+	seen := make(map[string]bool, len(participants))
+	for _, pub := range participants {
+		if len(pub) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key length %d, expected %d bytes", len(pub), ed25519.PublicKeySize)
+		}
+		key := hex.EncodeToString(pub)
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate participant %s", key)
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Address returns the address of the multisig account. It is derived from the threshold and the
+// participant keys so every participant computes the same address. It identifies the account
+// between the participants only: it isn't the address of a node account and coins sent to it
+// can't be spent.
+func (m *MultisigAccount) Address() gosmtypes.Address {
+	h := sha256.New()
+	_ = binary.Write(h, binary.BigEndian, uint32(m.Threshold))
+	for _, key := range m.Participants {
+		pub, _ := hex.DecodeString(key)
+		h.Write(pub)
+	}
+	return gosmtypes.BytesToAddress(h.Sum(nil))
+}
+
+// MultisigBook holds the multisig account definitions. It is stored in its own file and not in the wallet.
+type MultisigBook struct {
+	path     string
+	Accounts []MultisigAccount `json:"accounts"`
+}
+
+// LoadMultisigBook reads a multisig accounts file. A missing file results in an empty book.
+func LoadMultisigBook(path string) (*MultisigBook, error) {
+	book := &MultisigBook{path: path, Accounts: []MultisigAccount{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return book, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, book); err != nil {
+		return nil, fmt.Errorf("failed to parse multisig accounts %s: %v", path, err)
+	}
+	return book, nil
+}
+
+// Save writes the multisig accounts to their file
+func (b *MultisigBook) Save() error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WritePrivateFile(b.path, data)
+}
+
+// Add adds a multisig account. Names must be unique.
+func (b *MultisigBook) Add(account *MultisigAccount) error {
+	if _, ok := b.Lookup(account.Name); ok {
+		return fmt.Errorf("a multisig account named %s already exists", account.Name)
+	}
+	b.Accounts = append(b.Accounts, *account)
+	sort.Slice(b.Accounts, func(i, j int) bool { return b.Accounts[i].Name < b.Accounts[j].Name })
+	return nil
+}
+
+// Lookup returns the multisig account with the provided name
+func (b *MultisigBook) Lookup(name string) (*MultisigAccount, bool) {
+	for i := range b.Accounts {
+		if b.Accounts[i].Name == name {
+			return &b.Accounts[i], true
+		}
+	}
+	return nil, false
+}
+
+// MultisigSignature is the signature of a multisig transaction by one participant
+type MultisigSignature struct {
+	Signer    string `json:"signer"`
+	Signature string `json:"signature"`
+}
+
+// MultisigEnvelope carries a multisig transaction and the signatures collected so far. It is JSON
+// so the participants can exchange it as a file, e.g. by email.
+type MultisigEnvelope struct {
+	// Tx is the hex encoded transaction data signed by the participants
+	Tx           string              `json:"tx"`
+	Threshold    int                 `json:"threshold"`
+	Participants []string            `json:"participants"`
+	Signatures   []MultisigSignature `json:"signatures"`
+}
+
+// NewMultisigEnvelope returns an envelope without signatures for a transaction of a multisig account
+func NewMultisigEnvelope(account *MultisigAccount, tx []byte) *MultisigEnvelope {
+	return &MultisigEnvelope{
+		Tx:           hex.EncodeToString(tx),
+		Threshold:    account.Threshold,
+		Participants: append([]string(nil), account.Participants...),
+		Signatures:   []MultisigSignature{},
+	}
+}
+
+// ParseMultisigEnvelope parses an envelope and verifies the signatures it holds
+func ParseMultisigEnvelope(data []byte) (*MultisigEnvelope, error) {
+	var e MultisigEnvelope
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&e); err != nil {
+		return nil, fmt.Errorf("invalid multisig transaction file: %v", err)
+	}
+	if err := e.Verify(); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// TxBytes returns the transaction data signed by the participants
+func (e *MultisigEnvelope) TxBytes() ([]byte, error) {
+	tx, err := hex.DecodeString(e.Tx)
+	if err != nil || len(tx) == 0 {
+		return nil, errors.New("invalid multisig transaction file: bad transaction data")
+	}
+	return tx, nil
+}
+
+// isParticipant returns true if the hex encoded public key is one of the participants
+func (e *MultisigEnvelope) isParticipant(key string) bool {
+	for _, p := range e.Participants {
+		if p == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Verify checks that every signature is a valid signature of the transaction by a different participant
+func (e *MultisigEnvelope) Verify() error {
+	tx, err := e.TxBytes()
+	if err != nil {
+		return err
+	}
+	if e.Threshold < 1 || e.Threshold > len(e.Participants) {
+		return fmt.Errorf("invalid multisig transaction file: threshold %d of %d participants", e.Threshold, len(e.Participants))
+	}
+	signers := make(map[string]bool, len(e.Signatures))
+	for _, s := range e.Signatures {
+		if !e.isParticipant(s.Signer) {
+			return fmt.Errorf("signer %s is not a participant", s.Signer)
+		}
+		if signers[s.Signer] {
+			return fmt.Errorf("duplicate signature by %s", s.Signer)
+		}
+		signers[s.Signer] = true
+		pub, _ := hex.DecodeString(s.Signer)
+		sig, err := hex.DecodeString(s.Signature)
+		if err != nil || !VerifySignature(pub, tx, sig) {
+			return fmt.Errorf("invalid signature by %s", s.Signer)
+		}
+	}
+	return nil
+}
+
+// Sign adds the signature of a participant
+func (e *MultisigEnvelope) Sign(key *SigningKey) error {
+	tx, err := e.TxBytes()
+	if err != nil {
+		return err
+	}
+	signer := hex.EncodeToString(key.PublicKey())
+	if !e.isParticipant(signer) {
+		return errors.New("this account is not a participant of the multisig account")
+	}
+	for _, s := range e.Signatures {
+		if s.Signer == signer {
+			return errors.New("this account has already signed the transaction")
+		}
+	}
+	e.Signatures = append(e.Signatures, MultisigSignature{Signer: signer, Signature: hex.EncodeToString(key.Sign(tx))})
+	return nil
+}
+
+// Complete returns true when the envelope holds enough signatures
+func (e *MultisigEnvelope) Complete() bool {
+	return len(e.Signatures) >= e.Threshold
+}
+
+// CombineMultisigEnvelopes merges the signatures of envelopes of the same transaction. Envelopes
+// of different transactions and signatures of the same signer in several envelopes are rejected.
+func CombineMultisigEnvelopes(envelopes []*MultisigEnvelope) (*MultisigEnvelope, error) {
+	if len(envelopes) == 0 {
+		return nil, errors.New("no multisig transactions to combine")
+	}
+	first := envelopes[0]
+	res := &MultisigEnvelope{Tx: first.Tx, Threshold: first.Threshold, Participants: first.Participants,
+		Signatures: []MultisigSignature{}}
+	for i, e := range envelopes {
+		if !strings.EqualFold(e.Tx, first.Tx) {
+			return nil, fmt.Errorf("file %d signs a different transaction", i+1)
+		}
+		if e.Threshold != first.Threshold || strings.Join(e.Participants, ",") != strings.Join(first.Participants, ",") {
+			return nil, fmt.Errorf("file %d is for a different multisig account", i+1)
+		}
+		res.Signatures = append(res.Signatures, e.Signatures...)
+	}
+	if err := res.Verify(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,111 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/spacemeshos/ed25519"
+)
+
+// multisigTestKeys returns n deterministic private keys
+func multisigTestKeys(n int) []ed25519.PrivateKey {
+	keys := make([]ed25519.PrivateKey, n)
+	for i := range keys {
+		keys[i] = ed25519.NewKeyFromSeed(bytes.Repeat([]byte{byte(i + 1)}, ed25519.SeedSize))
+	}
+	return keys
+}
+
+func multisigTestAccount(t *testing.T, threshold int, keys []ed25519.PrivateKey) *MultisigAccount {
+	pubs := make([]ed25519.PublicKey, len(keys))
+	for i, k := range keys {
+		pubs[i] = k.Public().(ed25519.PublicKey)
+	}
+	account, err := NewMultisigAccount("shared", threshold, pubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return account
+}
+
+// cosign signs a copy of an envelope with a key, as a participant does with the file they received
+func cosign(t *testing.T, e *MultisigEnvelope, key ed25519.PrivateKey) *MultisigEnvelope {
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := ParseMultisigEnvelope(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signed.Sign(&SigningKey{key: append(ed25519.PrivateKey{}, key...)}); err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestNewMultisigAccount(t *testing.T) {
+	keys := multisigTestKeys(3)
+	pub := keys[0].Public().(ed25519.PublicKey)
+	if _, err := NewMultisigAccount("shared", 3, []ed25519.PublicKey{pub, pub}); err == nil {
+		t.Fatal("expected an error for duplicate participants")
+	}
+	for _, threshold := range []int{0, 4} {
+		if _, err := NewMultisigAccount("shared", threshold, []ed25519.PublicKey{pub}); err == nil {
+			t.Fatalf("expected an error for threshold %d", threshold)
+		}
+	}
+	a := multisigTestAccount(t, 2, keys)
+	b := multisigTestAccount(t, 2, []ed25519.PrivateKey{keys[2], keys[0], keys[1]})
+	if a.Address() != b.Address() {
+		t.Fatal("expected the address not to depend on the order of participants")
+	}
+	if c := multisigTestAccount(t, 3, keys); c.Address() == a.Address() {
+		t.Fatal("expected the address to depend on the threshold")
+	}
+}
+
+func TestMultisigCombine(t *testing.T) {
+	keys := multisigTestKeys(4)
+	account := multisigTestAccount(t, 2, keys[:3])
+	unsigned := NewMultisigEnvelope(account, []byte("transaction"))
+
+	first := cosign(t, unsigned, keys[0])
+	second := cosign(t, unsigned, keys[1])
+	if first.Complete() {
+		t.Fatal("one signature does not complete a 2-of-3 transaction")
+	}
+	combined, err := CombineMultisigEnvelopes([]*MultisigEnvelope{first, second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !combined.Complete() || len(combined.Signatures) != 2 {
+		t.Fatalf("expected 2 signatures, got %d", len(combined.Signatures))
+	}
+
+	if err := unsigned.Sign(&SigningKey{key: append(ed25519.PrivateKey{}, keys[3]...)}); err == nil {
+		t.Fatal("expected an error signing by a non participant")
+	}
+	if err := first.Sign(&SigningKey{key: append(ed25519.PrivateKey{}, keys[0]...)}); err == nil {
+		t.Fatal("expected an error signing twice")
+	}
+	if _, err := CombineMultisigEnvelopes([]*MultisigEnvelope{first, first}); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Fatalf("expected a duplicate signer error, got %v", err)
+	}
+	other := cosign(t, NewMultisigEnvelope(account, []byte("another transaction")), keys[1])
+	if _, err := CombineMultisigEnvelopes([]*MultisigEnvelope{first, other}); err == nil || !strings.Contains(err.Error(), "different transaction") {
+		t.Fatalf("expected a different transaction error, got %v", err)
+	}
+
+	tampered := cosign(t, unsigned, keys[2])
+	tampered.Signatures[0].Signature = second.Signatures[0].Signature
+	data, err := json.Marshal(tampered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseMultisigEnvelope(data); err == nil {
+		t.Fatal("expected an error for an invalid signature")
+	}
+}
@@ -0,0 +1,280 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Reasons of node errors which have advice
+const (
+	ReasonNonce           = "nonce"
+	ReasonFunds           = "funds"
+	ReasonMempoolFull     = "mempool full"
+	ReasonUnavailable     = "unavailable"
+	ReasonTimeout         = "timeout"
+	ReasonInvalid         = "invalid"
+	ReasonNotSupported    = "not supported"
+	ReasonUnauthenticated = "unauthenticated"
+	ReasonTooLarge        = "too large"
+	ReasonNoSocket        = "no socket"
+	ReasonSocketDenied    = "socket denied"
+	ReasonProxy           = "proxy"
+	ReasonSmeshing        = "already smeshing"
+	ReasonNoSpace         = "no space"
+	ReasonDirDenied       = "directory denied"
+	ReasonOther           = "other"
+)
+
+// UnauthenticatedMsg replaces the message of calls the node rejects as unauthenticated
+const UnauthenticatedMsg = "node requires authentication — check your token"
+
+// NodeError is a failure reported by the node with a gRPC status code and message, with advice on
+// how to resolve it
+type NodeError struct {
+	// Op is what failed, e.g. submit transaction. It is empty for the errors of calls translated
+	// before a command names them.
+	Op      string
+	Code    codes.Code
+	Message string
+	Reason  string
+	Advice  string
+	// Endpoint is the server the call was made to and Timeout the call timeout, when they are known
+	Endpoint string
+	Timeout  time.Duration
+	// ShowStatus appends the status reported by the node to the error, for verbose mode
+	ShowStatus bool
+}
+
+// Error returns the failed operation with the advice, or the message when there is none
+func (e *NodeError) Error() string {
+	detail := e.Message
+	if e.Advice != "" {
+		detail = e.Advice
+	}
+	if e.ShowStatus {
+		detail += " (" + e.Raw() + ")"
+	}
+	if e.Op == "" {
+		return detail
+	}
+	return fmt.Sprintf("%s failed: %s", e.Op, detail)
+}
+
+// GRPCStatus returns the status reported by the node, so that status.Code and status.FromError
+// see through the advice
+func (e *NodeError) GRPCStatus() *status.Status {
+	return status.New(e.Code, e.Message)
+}
+
+// Raw returns the status as reported by the node
+func (e *NodeError) Raw() string {
+	return fmt.Sprintf("code %s (%d): %s", e.Code, e.Code, e.Message)
+}
+
+// nodeErrorReasons maps words of node error messages to reasons, in order of precedence
+var nodeErrorReasons = []struct {
+	word, reason string
+}{
+	{"larger than max", ReasonTooLarge},
+	{"no node socket", ReasonNoSocket},
+	{"permission denied on node socket", ReasonSocketDenied},
+	{"through proxy", ReasonProxy},
+	{"is unreachable", ReasonProxy},
+	{"already smeshing", ReasonSmeshing},
+	{"already started", ReasonSmeshing},
+	{"no space", ReasonNoSpace},
+	{"not enough space", ReasonNoSpace},
+	{"permission denied", ReasonDirDenied},
+	{"nonce", ReasonNonce},
+	{"counter", ReasonNonce},
+	{"insufficient", ReasonFunds},
+	{"balance", ReasonFunds},
+	{"mempool", ReasonMempoolFull},
+	{"pool is full", ReasonMempoolFull},
+}
+
+// invalidInputs maps words of the messages of invalid requests to the input they are likely about,
+// in order of precedence
+var invalidInputs = []struct {
+	word, input string
+}{
+	{"signature", "signature"},
+	{"address", "address"},
+	{"amount", "amount"},
+	{"gas", "gas price and limit"},
+	{"layer", "layer number"},
+	{"epoch", "epoch"},
+	{"smesher", "smesher id"},
+	{"coinbase", "rewards address"},
+	{"hash", "transaction id"},
+	{"transaction", "transaction"},
+	{"offset", "page and its size"},
+	{"max results", "page and its size"},
+	{"data dir", "data directory"},
+	{"num units", "space allocation"},
+}
+
+// likelyInput returns the input an invalid request is likely rejected for, from the message of the
+// node or else from the operation, or an empty string when neither tells
+func likelyInput(op, message string) string {
+	for _, s := range []string{strings.ToLower(message), strings.ToLower(op)} {
+		for _, in := range invalidInputs {
+			if strings.Contains(s, in.word) {
+				return in.input
+			}
+		}
+	}
+	return ""
+}
+
+// proxyFailure returns the part of the message of a call which failed to connect through a proxy
+// which tells whether the proxy or the node behind it couldn't be reached
+func proxyFailure(message string) string {
+	for _, start := range []string{"couldn't reach node", "proxy "} {
+		if i := strings.Index(message, start); i >= 0 {
+			return strings.TrimRight(message[i:], "\"")
+		}
+	}
+	return message
+}
+
+// NewNodeError classifies a status reported by the node and adds advice. The reason comes from the
+// message when it tells one, otherwise from the code.
+func NewNodeError(op string, code codes.Code, message string) *NodeError {
+	return newNodeError(op, code, message, "", 0)
+}
+
+func newNodeError(op string, code codes.Code, message, endpoint string, timeout time.Duration) *NodeError {
+	e := &NodeError{Op: op, Code: code, Message: message, Reason: ReasonOther, Endpoint: endpoint, Timeout: timeout}
+	lower := strings.ToLower(message)
+	for _, r := range nodeErrorReasons {
+		if strings.Contains(lower, r.word) {
+			e.Reason = r.reason
+			break
+		}
+	}
+	if e.Reason == ReasonOther {
+		switch code {
+		case codes.ResourceExhausted:
+			e.Reason = ReasonMempoolFull
+		case codes.Unavailable:
+			e.Reason = ReasonUnavailable
+		case codes.DeadlineExceeded:
+			e.Reason = ReasonTimeout
+		case codes.InvalidArgument:
+			e.Reason = ReasonInvalid
+		case codes.Unimplemented:
+			e.Reason = ReasonNotSupported
+		case codes.Unauthenticated:
+			e.Reason = ReasonUnauthenticated
+		}
+	}
+	switch e.Reason {
+	case ReasonNonce:
+		e.Advice = "the nonce was rejected, check the projected nonce of the account with account info and re-run with --nonce"
+	case ReasonFunds:
+		e.Advice = "the projected balance doesn't cover the amount plus the maximum fee, pending transactions may have used it"
+	case ReasonMempoolFull:
+		e.Advice = "the node's mempool is full, try again later or with a higher gas price"
+	case ReasonUnavailable:
+		e.Advice = "node unreachable — is it running? try again or switch to another node with status node-connect"
+		if endpoint != "" {
+			e.Advice = fmt.Sprintf("node unreachable at %s — is it running? try again or switch to another node with status node-connect", endpoint)
+		}
+	case ReasonTimeout:
+		e.Advice = "the node didn't answer in time, raise the timeout with config set timeout <duration> if it is busy"
+		if timeout > 0 {
+			e.Advice = fmt.Sprintf("the node didn't answer within the %v timeout, raise it with config set timeout <duration> if it is busy", timeout)
+		}
+	case ReasonInvalid:
+		e.Advice = "the node rejected the request as invalid: " + message
+		if input := likelyInput(op, message); input != "" {
+			e.Advice += " — check the " + input
+		}
+	case ReasonNotSupported:
+		e.Advice = "this node doesn't expose that service — check status version, your node may be too old or too new for this smrepl"
+	case ReasonUnauthenticated:
+		e.Advice = UnauthenticatedMsg
+	case ReasonTooLarge:
+		e.Advice = "the message is larger than the gRPC message size limit, request fewer results or raise the limit with config set max-receive-mb"
+	case ReasonNoSocket:
+		e.Advice = "the node socket doesn't exist, check that the node is running and listens on it"
+		if path, ok := SocketPath(endpoint); ok {
+			e.Advice = fmt.Sprintf("no node socket at %s, check that the node is running and listens on it", path)
+		}
+	case ReasonProxy:
+		e.Advice = proxyFailure(message) + " — check the proxy given with -proxy, ALL_PROXY or HTTPS_PROXY"
+	case ReasonSmeshing:
+		e.Advice = "the node is already smeshing, stop it with smesher stop before starting again"
+	case ReasonNoSpace:
+		e.Advice = "the node's disk has no room for the PoST data, choose another data directory or a smaller size"
+	case ReasonDirDenied:
+		e.Advice = "the node can't write to the data directory, choose one the node's user may write to"
+	case ReasonSocketDenied:
+		e.Advice = "the node socket can't be opened, run the wallet as a user with write permission on it"
+	}
+	return e
+}
+
+// StatusError returns the NodeError of a status returned in a node response, or nil when the
+// status is missing or OK
+func StatusError(op string, code int32, message string) error {
+	if codes.Code(code) == codes.OK {
+		return nil
+	}
+	return NewNodeError(op, codes.Code(code), message)
+}
+
+// CallError converts the error of a gRPC call to a NodeError of an operation. Errors without a
+// gRPC status and NodeErrors which already have an operation are returned as they are.
+func CallError(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var nodeErr *NodeError
+	if errors.As(err, &nodeErr) {
+		if nodeErr.Op != "" {
+			return err
+		}
+		e := newNodeError(op, nodeErr.Code, nodeErr.Message, nodeErr.Endpoint, nodeErr.Timeout)
+		e.ShowStatus = nodeErr.ShowStatus
+		return e
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	return NewNodeError(op, st.Code(), st.Message())
+}
+
+// translatedCodes are the codes of the call errors TranslateError gives advice for
+var translatedCodes = map[codes.Code]bool{
+	codes.Unavailable:      true,
+	codes.Unimplemented:    true,
+	codes.InvalidArgument:  true,
+	codes.DeadlineExceeded: true,
+}
+
+// TranslateError replaces the errors of gRPC calls which users run into most, an unreachable node,
+// a service the node doesn't expose, an invalid request and a timeout, with NodeErrors advising on
+// how to resolve them. The endpoint and the timeout of the call go into the advice. Other errors
+// are returned as they are.
+func TranslateError(err error, endpoint string, timeout time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	var nodeErr *NodeError
+	if errors.As(err, &nodeErr) {
+		return err
+	}
+	st, ok := status.FromError(err)
+	if !ok || !translatedCodes[st.Code()] {
+		return err
+	}
+	return newNodeError("", st.Code(), st.Message(), endpoint, timeout)
+}
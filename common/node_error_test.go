@@ -0,0 +1,176 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewNodeError(t *testing.T) {
+	for _, test := range []struct {
+		code    codes.Code
+		message string
+		reason  string
+	}{
+		{codes.InvalidArgument, "incorrect counter or nonce", ReasonNonce},
+		{codes.InvalidArgument, "Insufficient balance", ReasonFunds},
+		{codes.Unknown, "mempool is full", ReasonMempoolFull},
+		{codes.ResourceExhausted, "", ReasonMempoolFull},
+		{codes.Unavailable, "connection refused", ReasonUnavailable},
+		{codes.DeadlineExceeded, "", ReasonTimeout},
+		{codes.Unimplemented, "unknown service spacemesh.v1.DebugService", ReasonNotSupported},
+		{codes.FailedPrecondition, "already smeshing", ReasonSmeshing},
+		{codes.Internal, "write /data/post: no space left on device", ReasonNoSpace},
+		{codes.Internal, "mkdir /data/post: permission denied", ReasonDirDenied},
+		{codes.Unavailable, "transport: Error while dialing no node socket at /run/grpc.sock", ReasonNoSocket},
+		{codes.Unavailable, "transport: Error while dialing permission denied on node socket /run/grpc.sock", ReasonSocketDenied},
+		{codes.InvalidArgument, "bad signature", ReasonInvalid},
+		{codes.Unauthenticated, "missing bearer token", ReasonUnauthenticated},
+		{codes.ResourceExhausted, "grpc: received message larger than max (5242880 vs. 4194304)", ReasonTooLarge},
+		{codes.Internal, "oops", ReasonOther},
+	} {
+		e := NewNodeError("submit transaction", test.code, test.message)
+		if e.Reason != test.reason {
+			t.Fatalf("%s %q: expected reason %s, got %s", test.code, test.message, test.reason, e.Reason)
+		}
+		if test.reason != ReasonOther && e.Advice == "" {
+			t.Fatalf("%s %q: expected advice", test.code, test.message)
+		}
+	}
+
+	e := NewNodeError("start smeshing", codes.Internal, "disk full")
+	if e.Error() != "start smeshing failed: disk full" {
+		t.Fatalf("unexpected error string %q", e.Error())
+	}
+	if e.Raw() != "code Internal (13): disk full" {
+		t.Fatalf("unexpected raw status %q", e.Raw())
+	}
+}
+
+func TestStatusAndCallError(t *testing.T) {
+	if err := StatusError("stop smeshing", 0, ""); err != nil {
+		t.Fatalf("expected no error for an OK status, got %v", err)
+	}
+	err := StatusError("stop smeshing", int32(codes.FailedPrecondition), "not smeshing")
+	var nodeErr *NodeError
+	if !errors.As(err, &nodeErr) || nodeErr.Code != codes.FailedPrecondition {
+		t.Fatalf("expected a node error, got %v", err)
+	}
+
+	err = CallError("submit transaction", status.Error(codes.Unavailable, "connection refused"))
+	if !errors.As(err, &nodeErr) || nodeErr.Reason != ReasonUnavailable {
+		t.Fatalf("expected an unavailable node error, got %v", err)
+	}
+	plain := errors.New("plain")
+	if CallError("submit transaction", plain) != plain {
+		t.Fatal("expected errors without a status to be kept")
+	}
+}
+
+func TestTranslateError(t *testing.T) {
+	const endpoint = "localhost:9092"
+	for _, test := range []struct {
+		name string
+		err  error
+		// want are in the translated error, nothing is translated when empty
+		want []string
+		// message tells whether the advice quotes the message of the node
+		message bool
+	}{
+		{
+			name: "unavailable",
+			err:  status.Error(codes.Unavailable, "connection error: desc = \"transport: Error while dialing dial tcp 127.0.0.1:9092: connect: connection refused\""),
+			want: []string{"node unreachable at localhost:9092 — is it running?", "status node-connect"},
+		},
+		{
+			name: "proxy",
+			err:  status.Error(codes.Unavailable, "connection error: desc = \"transport: Error while dialing couldn't reach node localhost:9092 through proxy socks5://bastion:1080: general SOCKS server failure\""),
+			want: []string{"couldn't reach node localhost:9092 through proxy socks5://bastion:1080: general SOCKS server failure — check the proxy"},
+		},
+		{
+			name: "unimplemented",
+			err:  status.Error(codes.Unimplemented, "unknown service spacemesh.v1.SmesherService"),
+			want: []string{"this node doesn't expose that service — check status version"},
+		},
+		{
+			name:    "invalid address",
+			err:     status.Error(codes.InvalidArgument, "`AccountId.Address` must be a valid address"),
+			want:    []string{"the node rejected the request as invalid: `AccountId.Address` must be a valid address", "check the address"},
+			message: true,
+		},
+		{
+			name:    "invalid layer",
+			err:     status.Error(codes.InvalidArgument, "`LatestLayer` must be less than the current layer"),
+			want:    []string{"the node rejected the request as invalid", "check the layer number"},
+			message: true,
+		},
+		{
+			name:    "invalid page",
+			err:     status.Error(codes.InvalidArgument, "`Offset` must be less than the number of items"),
+			want:    []string{"the node rejected the request as invalid", "check the page and its size"},
+			message: true,
+		},
+		{
+			name: "invalid nonce",
+			err:  status.Error(codes.InvalidArgument, "incorrect counter or nonce"),
+			want: []string{"the nonce was rejected"},
+		},
+		{
+			name: "deadline exceeded",
+			err:  status.Error(codes.DeadlineExceeded, "context deadline exceeded"),
+			want: []string{"the node didn't answer within the 10s timeout", "config set timeout"},
+		},
+		{name: "not found", err: status.Error(codes.NotFound, "no such transaction")},
+		{name: "canceled", err: status.Error(codes.Canceled, "context canceled")},
+		{name: "no status", err: errors.New("plain")},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := TranslateError(test.err, endpoint, 10*time.Second)
+			if len(test.want) == 0 {
+				if err != test.err {
+					t.Fatalf("expected the error to be kept, got %v", err)
+				}
+				return
+			}
+			for _, want := range test.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("expected %q in %q", want, err.Error())
+				}
+			}
+			if status.Code(err) != status.Code(test.err) {
+				t.Fatalf("expected code %s to be kept, got %s", status.Code(test.err), status.Code(err))
+			}
+			if st, _ := status.FromError(test.err); strings.Contains(err.Error(), st.Message()) != test.message {
+				t.Fatalf("expected the message of the node to be quoted %v, got %q", test.message, err.Error())
+			}
+
+			// the raw status is shown in verbose mode
+			var nodeErr *NodeError
+			if !errors.As(err, &nodeErr) {
+				t.Fatalf("expected a node error, got %T", err)
+			}
+			nodeErr.ShowStatus = true
+			if raw := fmt.Sprintf("code %s", status.Code(test.err)); !strings.Contains(err.Error(), raw) {
+				t.Fatalf("expected %q in the verbose error %q", raw, err.Error())
+			}
+
+			// commands name the operation, which keeps the advice
+			named := CallError("get account", err)
+			if !strings.HasPrefix(named.Error(), "get account failed: "+test.want[0]) {
+				t.Fatalf("expected the operation before the advice, got %q", named.Error())
+			}
+		})
+	}
+
+	if err := TranslateError(status.Error(codes.DeadlineExceeded, ""), endpoint, 0); !strings.Contains(err.Error(), "config set timeout") {
+		t.Fatalf("expected the timeout setting without a timeout, got %q", err.Error())
+	}
+	if TranslateError(nil, endpoint, 0) != nil {
+		t.Fatal("expected no error")
+	}
+}
@@ -1,6 +1,18 @@
 package common
 
+import "time"
+
 type NodeInfo struct {
 	Version string
 	Build   string
 }
+
+// ServerHealth is whether a configured node API server answered an echo call and how fast
+type ServerHealth struct {
+	Server string
+	// Active is set for the server the client is connected to, the others are standbys
+	Active  bool
+	Latency time.Duration
+	// Err is the error of the echo call, nil when the server is healthy
+	Err error
+}
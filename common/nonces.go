@@ -0,0 +1,132 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// NoncesFileName is the name of the file in the wallets directory holding the locally reserved nonces
+const NoncesFileName = "smrepl_nonces.json"
+
+// NonceTracker records the last nonce used by each account for transactions submitted from this
+// wallet, so back to back transactions don't reuse a nonce before the node's projected state catches up.
+type NonceTracker struct {
+	path   string
+	Nonces map[string]uint64 `json:"nonces"`
+}
+
+// LoadNonceTracker reads a nonces file. A missing file results in no reserved nonces.
+func LoadNonceTracker(path string) (*NonceTracker, error) {
+	tracker := &NonceTracker{path: path, Nonces: map[string]uint64{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return tracker, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, tracker); err != nil {
+		return nil, fmt.Errorf("failed to parse nonces file %s: %v", path, err)
+	}
+	if tracker.Nonces == nil {
+		tracker.Nonces = map[string]uint64{}
+	}
+	return tracker, nil
+}
+
+// Save writes the reserved nonces to their file
+func (n *NonceTracker) Save() error {
+	data, err := json.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WritePrivateFile(n.path, data)
+}
+
+// Reserved returns the last nonce used by an account, if any
+func (n *NonceTracker) Reserved(address gosmtypes.Address) (uint64, bool) {
+	nonce, ok := n.Nonces[address.Hex()]
+	return nonce, ok
+}
+
+// Next returns the nonce to use for the next transaction of an account given its projected counter
+func (n *NonceTracker) Next(address gosmtypes.Address, projected uint64) uint64 {
+	if reserved, ok := n.Reserved(address); ok && reserved+1 > projected {
+		return reserved + 1
+	}
+	return projected
+}
+
+// Use records that a transaction with nonce was submitted by an account
+func (n *NonceTracker) Use(address gosmtypes.Address, nonce uint64) {
+	if reserved, ok := n.Reserved(address); !ok || nonce > reserved {
+		n.Nonces[address.Hex()] = nonce
+	}
+}
+
+// Reset releases the nonces reserved by an account
+func (n *NonceTracker) Reset(address gosmtypes.Address) {
+	delete(n.Nonces, address.Hex())
+}
+
+// NonceCheck is the result of comparing a transaction nonce with the counters of its account. A
+// transaction with a Reject reason can't apply. A Warning needs confirmation.
+type NonceCheck struct {
+	Reject  string
+	Warning string
+}
+
+// CheckNonce compares a transaction nonce with the current counter of its account, the nonce of
+// the last applied transaction plus one, and next, the nonce following the pending transactions.
+// Nonces below current were used by applied transactions. Nonces from current to next are used by
+// pending transactions. Nonces above next leave a gap which blocks the transaction.
+func CheckNonce(nonce, current, next uint64) NonceCheck {
+	switch {
+	case nonce < current:
+		return NonceCheck{Reject: fmt.Sprintf("nonce %d was used by an applied transaction, the account counter is %d", nonce, current)}
+	case nonce < next:
+		return NonceCheck{Warning: fmt.Sprintf("nonce %d is used by a pending transaction, only one of the two can apply", nonce)}
+	case nonce == next:
+		return NonceCheck{}
+	case nonce == next+1:
+		return NonceCheck{Warning: fmt.Sprintf("this transaction cannot apply until nonce %d is used", next)}
+	}
+	return NonceCheck{Warning: fmt.Sprintf("this transaction cannot apply until nonces %d..%d are used", next, nonce-1)}
+}
+
+// NonceGap is a range of unused nonces, inclusive
+type NonceGap struct {
+	First, Last uint64
+}
+
+// NonceGaps returns the ranges of unused nonces from current, the counter of an account, up to the
+// highest nonce of its pending transactions
+func NonceGaps(current uint64, pending []uint64) []NonceGap {
+	used := make(map[uint64]bool, len(pending))
+	highest, found := uint64(0), false
+	for _, n := range pending {
+		used[n] = true
+		if !found || n > highest {
+			highest, found = n, true
+		}
+	}
+	var gaps []NonceGap
+	if !found {
+		return gaps
+	}
+	for n := current; n < highest; n++ {
+		if used[n] {
+			continue
+		}
+		if len(gaps) > 0 && gaps[len(gaps)-1].Last == n-1 {
+			gaps[len(gaps)-1].Last = n
+		} else {
+			gaps = append(gaps, NonceGap{n, n})
+		}
+	}
+	return gaps
+}
@@ -0,0 +1,100 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func TestNonceTracker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nonces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, NoncesFileName)
+	addr := gosmtypes.HexToAddress("0x7fa75881ca0050028b32f424f860e3a73d4bf168")
+
+	tracker, err := LoadNonceTracker(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := tracker.Next(addr, 7); next != 7 {
+		t.Fatalf("expected the projected counter without reservations, got %d", next)
+	}
+	tracker.Use(addr, 7)
+	if next := tracker.Next(addr, 7); next != 8 {
+		t.Fatalf("expected the nonce after the reserved one, got %d", next)
+	}
+	if next := tracker.Next(addr, 10); next != 10 {
+		t.Fatalf("expected the projected counter once it passed the reservation, got %d", next)
+	}
+	tracker.Use(addr, 5)
+	if reserved, _ := tracker.Reserved(addr); reserved != 7 {
+		t.Fatalf("expected an older nonce not to lower the reservation, got %d", reserved)
+	}
+	if err := tracker.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadNonceTracker(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := loaded.Next(addr, 7); next != 8 {
+		t.Fatalf("expected the reservation to survive a restart, got %d", next)
+	}
+	loaded.Reset(addr)
+	if _, ok := loaded.Reserved(addr); ok {
+		t.Fatal("expected no reservation after reset")
+	}
+}
+
+func TestCheckNonce(t *testing.T) {
+	for _, test := range []struct {
+		nonce, current, next uint64
+		reject, warn         bool
+	}{
+		{4, 5, 7, true, false},
+		{5, 5, 7, false, true},
+		{6, 5, 7, false, true},
+		{7, 5, 7, false, false},
+		{8, 5, 7, false, true},
+		{12, 5, 7, false, true},
+		{0, 0, 0, false, false},
+		{5, 5, 5, false, false},
+	} {
+		check := CheckNonce(test.nonce, test.current, test.next)
+		if (check.Reject != "") != test.reject || (check.Warning != "") != test.warn {
+			t.Fatalf("nonce %d, counter %d, next %d: unexpected result %+v", test.nonce, test.current, test.next, check)
+		}
+	}
+	if check := CheckNonce(12, 5, 7); check.Warning != "this transaction cannot apply until nonces 7..11 are used" {
+		t.Fatalf("unexpected warning %q", check.Warning)
+	}
+	if check := CheckNonce(8, 5, 7); check.Warning != "this transaction cannot apply until nonce 7 is used" {
+		t.Fatalf("unexpected warning %q", check.Warning)
+	}
+}
+
+func TestNonceGaps(t *testing.T) {
+	if gaps := NonceGaps(5, nil); len(gaps) != 0 {
+		t.Fatalf("expected no gaps without pending transactions, got %v", gaps)
+	}
+	if gaps := NonceGaps(5, []uint64{5, 6, 7}); len(gaps) != 0 {
+		t.Fatalf("expected no gaps for consecutive nonces, got %v", gaps)
+	}
+	gaps := NonceGaps(5, []uint64{9, 6, 12})
+	expected := []NonceGap{{5, 5}, {7, 8}, {10, 11}}
+	if len(gaps) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, gaps)
+	}
+	for i := range expected {
+		if gaps[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, gaps)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"fmt"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// Account origins describe where the key of an account comes from
+const (
+	// OriginDerived is an account whose key derives from the wallet mnemonic
+	OriginDerived = "derived"
+	// OriginImported is an account whose key doesn't derive from the wallet mnemonic
+	OriginImported = "imported"
+	// OriginHardware is an account whose key is held by a hardware device
+	OriginHardware = "hardware"
+	// OriginWatchOnly is an account without a key
+	OriginWatchOnly = "watch-only"
+)
+
+// AccountOrigin describes where the key of an account comes from
+type AccountOrigin struct {
+	Kind string
+	// Index is the derivation index of derived accounts
+	Index uint64
+}
+
+func (o AccountOrigin) String() string {
+	if o.Kind == OriginDerived {
+		return fmt.Sprintf("derived, index %d", o.Index)
+	}
+	return o.Kind
+}
+
+// DerivedAddress is the address of the key derived from a wallet mnemonic at an index
+type DerivedAddress struct {
+	Index   uint64
+	Address gosmtypes.Address
+}
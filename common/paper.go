@@ -0,0 +1,90 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// paperChunkSize is the number of hex characters of a private key chunk on a paper wallet
+const paperChunkSize = 4
+
+// paperChunksPerLine is the number of numbered chunks or words per line on a paper wallet
+const paperChunksPerLine = 4
+
+// ChecksumWord returns a BIP39 word derived from the sha256 digest of a secret. Writing it next
+// to a transcribed secret lets a typo be detected when the secret is entered again.
+func ChecksumWord(secret string) string {
+	digest := sha256.Sum256([]byte(secret))
+	words := bip39.GetWordList()
+	return words[int(binary.BigEndian.Uint16(digest[:2]))%len(words)]
+}
+
+// KeyChunks splits a hex encoded private key into chunks of paperChunkSize characters
+func KeyChunks(key string) []string {
+	chunks := make([]string, 0, (len(key)+paperChunkSize-1)/paperChunkSize)
+	for i := 0; i < len(key); i += paperChunkSize {
+		end := i + paperChunkSize
+		if end > len(key) {
+			end = len(key)
+		}
+		chunks = append(chunks, key[i:end])
+	}
+	return chunks
+}
+
+// PaperWallet is a printable backup of an account. It holds either the wallet mnemonic or the
+// account private key.
+type PaperWallet struct {
+	WalletName string
+	Alias      string
+	Address    string
+	Created    string
+	// Mnemonic is set when the paper wallet holds the wallet mnemonic
+	Mnemonic string
+	// PrivateKey is the hex encoded account private key, set when the paper wallet holds the key
+	PrivateKey string
+}
+
+// Render returns the paper wallet as a single page of plain text
+func (p *PaperWallet) Render() string {
+	var b strings.Builder
+	rule := strings.Repeat("=", 72)
+	fmt.Fprintln(&b, rule)
+	fmt.Fprintln(&b, "SPACEMESH PAPER WALLET")
+	fmt.Fprintln(&b, rule)
+	fmt.Fprintln(&b, "Wallet: ", p.WalletName)
+	fmt.Fprintln(&b, "Account:", p.Alias)
+	fmt.Fprintln(&b, "Created:", p.Created)
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "Address:")
+	fmt.Fprintln(&b, "  ", p.Address)
+	fmt.Fprintln(&b)
+
+	secret, chunks := p.PrivateKey, KeyChunks(p.PrivateKey)
+	if p.Mnemonic != "" {
+		secret, chunks = p.Mnemonic, strings.Fields(p.Mnemonic)
+		fmt.Fprintln(&b, "Wallet mnemonic (restores every account of the wallet):")
+	} else {
+		fmt.Fprintln(&b, "Private key (hex, restores this account only):")
+	}
+	for i, chunk := range chunks {
+		if i%paperChunksPerLine == 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, " %2d. %-10s", i+1, chunk)
+		if i%paperChunksPerLine == paperChunksPerLine-1 || i == len(chunks)-1 {
+			b.WriteString("\n")
+		}
+	}
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "Checksum word:", ChecksumWord(secret))
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "Anyone who reads this page controls the funds. Store it offline and never")
+	fmt.Fprintln(&b, "photograph it. Enter the chunks in order, the checksum word detects typos.")
+	fmt.Fprintln(&b, rule)
+	return b.String()
+}
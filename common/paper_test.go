@@ -0,0 +1,50 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+const paperTestKey = "2bd806c97f0e00af1a1fc3328fa763a9269723c8db8fac4f93af71db186d6e90"
+
+func TestChecksumWord(t *testing.T) {
+	word := ChecksumWord(paperTestKey)
+	if word == "" || word != ChecksumWord(paperTestKey) {
+		t.Fatal("expected a stable checksum word")
+	}
+	typo := "3" + paperTestKey[1:]
+	if ChecksumWord(typo) == word {
+		t.Fatal("expected a typo to change the checksum word")
+	}
+}
+
+func TestKeyChunks(t *testing.T) {
+	chunks := KeyChunks(paperTestKey)
+	if len(chunks) != 16 {
+		t.Fatalf("expected 16 chunks, got %d", len(chunks))
+	}
+	if strings.Join(chunks, "") != paperTestKey {
+		t.Fatal("chunks don't join back to the key")
+	}
+	if chunks := KeyChunks("abcdef"); len(chunks) != 2 || chunks[1] != "ef" {
+		t.Fatalf("unexpected chunks %v", chunks)
+	}
+}
+
+func TestRenderPaperWallet(t *testing.T) {
+	p := PaperWallet{WalletName: "test", Alias: "cold", Address: "0x7fa75881ca0050028b32f424f860e3a73d4bf168",
+		PrivateKey: paperTestKey}
+	page := p.Render()
+	for _, expected := range []string{p.Address, " 1. 2bd8", "16. 6e90", "Checksum word: " + ChecksumWord(paperTestKey)} {
+		if !strings.Contains(page, expected) {
+			t.Fatalf("expected the page to contain %q:\n%s", expected, page)
+		}
+	}
+
+	p = PaperWallet{Alias: "cold", Address: p.Address,
+		Mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"}
+	page = p.Render()
+	if !strings.Contains(page, "12. about") || strings.Contains(page, paperTestKey[:4]) {
+		t.Fatalf("expected the page to hold the numbered mnemonic only:\n%s", page)
+	}
+}
@@ -0,0 +1,45 @@
+package common
+
+import (
+	"encoding/binary"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// PoST labels are scrypt hashes with these cost parameters
+const (
+	postLabelN = 512
+	postLabelR = 1
+	postLabelP = 1
+)
+
+// SamplePostLabels computes PoST labels on one core of this machine for at least d and returns the
+// number computed per second. It approximates the speed of the CPU provider of a node running here.
+func SamplePostLabels(d time.Duration) float64 {
+	salt := make([]byte, 32)
+	start := time.Now()
+	var n uint64
+	for time.Since(start) < d {
+		var index [8]byte
+		binary.LittleEndian.PutUint64(index[:], n)
+		if _, err := scrypt.Key(index[:], salt, postLabelN, postLabelR, postLabelP, 1); err != nil {
+			// the parameters are constant and valid
+			panic(err)
+		}
+		n++
+	}
+	return float64(n) / time.Since(start).Seconds()
+}
+
+// FastestProvider returns the index of the highest of the speeds of PoST providers, or -1 when none
+// is known. Unknown speeds are 0.
+func FastestProvider(speeds []float64) int {
+	fastest := -1
+	for i, speed := range speeds {
+		if speed > 0 && (fastest < 0 || speed > speeds[fastest]) {
+			fastest = i
+		}
+	}
+	return fastest
+}
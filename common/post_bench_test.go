@@ -0,0 +1,18 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFastestProvider(t *testing.T) {
+	if i := FastestProvider([]float64{10, 0, 30, 20}); i != 2 {
+		t.Fatalf("expected the third provider, got %d", i)
+	}
+	if i := FastestProvider([]float64{0, 0}); i != -1 {
+		t.Fatalf("expected no provider without speeds, got %d", i)
+	}
+	if speed := SamplePostLabels(10 * time.Millisecond); speed <= 0 {
+		t.Fatalf("expected a positive speed, got %v", speed)
+	}
+}
@@ -0,0 +1,86 @@
+package common
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// postMetadataFile is the name of the file describing the commitment of PoST data, written next to
+// the data files by PoST versions which record it
+const postMetadataFile = "postdata_metadata.json"
+
+// postMetadata is the part of the PoST metadata file which describes the commitment
+type postMetadata struct {
+	NodeId        []byte
+	BitsPerLabel  uint64
+	LabelsPerUnit uint64
+	NumUnits      uint64
+}
+
+// PostDataInfo describes the PoST data found in a directory
+type PostDataInfo struct {
+	// Files is the number of data files
+	Files int
+	// Written is the size in bytes of the data files
+	Written uint64
+	// Committed is the size in bytes of the commitment according to the metadata file, or Written
+	// when there is none
+	Committed uint64
+	// NodeId is the id of the smesher the data was created for according to the metadata file, nil
+	// when there is none
+	NodeId []byte
+}
+
+// InspectPostData returns the PoST data in a directory, or nil when there is none
+func InspectPostData(dir string) (*PostDataInfo, error) {
+	files, written, err := ExistingPostData(dir)
+	if err != nil {
+		return nil, err
+	}
+	info := &PostDataInfo{Files: files, Written: written, Committed: written}
+	data, err := ioutil.ReadFile(filepath.Join(dir, postMetadataFile))
+	if os.IsNotExist(err) {
+		if written == 0 {
+			return nil, nil
+		}
+		return info, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var metadata postMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse the PoST metadata file: %v", err)
+	}
+	info.NodeId = metadata.NodeId
+	if committed := metadata.NumUnits * metadata.LabelsPerUnit * metadata.BitsPerLabel / 8; committed > written {
+		info.Committed = committed
+	}
+	return info, nil
+}
+
+// Fingerprint returns the first bytes of the node id of the data in hex, or an empty string when
+// it isn't known
+func (i *PostDataInfo) Fingerprint() string {
+	if len(i.NodeId) < 4 {
+		return hex.EncodeToString(i.NodeId)
+	}
+	return hex.EncodeToString(i.NodeId[:4])
+}
+
+// BelongsTo tells whether the data was created for a smesher. A smesher id in the 20 byte address
+// form matches the end of the node id. known is false when the data doesn't record its node id.
+func (i *PostDataInfo) BelongsTo(smesherId []byte) (match, known bool) {
+	if len(i.NodeId) == 0 {
+		return false, false
+	}
+	if bytes.Equal(i.NodeId, smesherId) {
+		return true, true
+	}
+	return len(smesherId) == smesherIdAddressLen && bytes.HasSuffix(i.NodeId, smesherId), true
+}
@@ -0,0 +1,56 @@
+package common
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInspectPostData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smrepl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if info, err := InspectPostData(dir); info != nil || err != nil {
+		t.Fatalf("expected no data, got %+v %v", info, err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "postdata_0.bin"), make([]byte, 100), 0600); err != nil {
+		t.Fatal(err)
+	}
+	info, err := InspectPostData(dir)
+	if err != nil || info.Written != 100 || info.Committed != 100 || info.NodeId != nil {
+		t.Fatalf("expected 100 bytes without metadata, got %+v %v", info, err)
+	}
+	if _, known := info.BelongsTo([]byte{1}); known {
+		t.Fatal("expected the owner to be unknown without metadata")
+	}
+
+	nodeId := bytes.Repeat([]byte{0xab}, 12)
+	nodeId = append(nodeId, bytes.Repeat([]byte{0xcd}, 20)...)
+	metadata := fmt.Sprintf(`{"NodeId":"%s","BitsPerLabel":8,"LabelsPerUnit":100,"NumUnits":4}`, base64.StdEncoding.EncodeToString(nodeId))
+	if err := ioutil.WriteFile(filepath.Join(dir, postMetadataFile), []byte(metadata), 0600); err != nil {
+		t.Fatal(err)
+	}
+	info, err = InspectPostData(dir)
+	if err != nil || info.Committed != 400 || !bytes.Equal(info.NodeId, nodeId) || info.Fingerprint() != "abababab" {
+		t.Fatalf("expected the metadata commitment, got %+v %v", info, err)
+	}
+	for _, test := range []struct {
+		id    []byte
+		match bool
+	}{
+		{nodeId, true},
+		{nodeId[12:], true},
+		{bytes.Repeat([]byte{0xcd}, 32), false},
+	} {
+		if match, known := info.BelongsTo(test.id); match != test.match || !known {
+			t.Fatalf("%x: expected match %v, got %v %v", test.id, test.match, match, known)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package common
+
+import "time"
+
+// bytesPerGiB is the size of a GiB of PoST data
+const bytesPerGiB = 1 << 30
+
+// PostSample is the number of bytes of PoST data the node reported it had written at a time
+type PostSample struct {
+	Time         time.Time
+	BytesWritten uint64
+}
+
+// PostProgress describes PoST data creation at a sample
+type PostProgress struct {
+	// Percent is the share of the total size written, or -1 when the total size isn't known
+	Percent float64
+	// MBps is the throughput in MB/s since the previous sample, or 0 without one
+	MBps float64
+	// Remaining is the time left to write the total size at the throughput, or -1 when it isn't
+	// known
+	Remaining time.Duration
+}
+
+// NewPostProgress returns the progress of PoST data creation at a sample towards a total size in
+// bytes, which is 0 when it isn't known, and the throughput since the previous sample, which may
+// be nil
+func NewPostProgress(previous *PostSample, sample PostSample, total uint64) PostProgress {
+	progress := PostProgress{Percent: -1, Remaining: -1}
+	if total > 0 {
+		progress.Percent = float64(sample.BytesWritten) / float64(total) * 100
+		if progress.Percent > 100 {
+			progress.Percent = 100
+		}
+	}
+	if previous != nil && sample.BytesWritten >= previous.BytesWritten {
+		if elapsed := sample.Time.Sub(previous.Time).Seconds(); elapsed > 0 {
+			progress.MBps = float64(sample.BytesWritten-previous.BytesWritten) / 1e6 / elapsed
+		}
+	}
+	switch {
+	case total > 0 && sample.BytesWritten >= total:
+		progress.Remaining = 0
+	case total > 0 && progress.MBps > 0:
+		seconds := float64(total-sample.BytesWritten) / 1e6 / progress.MBps
+		progress.Remaining = time.Duration(seconds * float64(time.Second)).Round(time.Second)
+	}
+	return progress
+}
+
+// GiB returns a number of bytes in GiB
+func GiB(bytes uint64) float64 {
+	return float64(bytes) / bytesPerGiB
+}
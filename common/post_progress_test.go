@@ -0,0 +1,33 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewPostProgress(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	first := PostSample{Time: start, BytesWritten: 100e6}
+	second := PostSample{Time: start.Add(10 * time.Second), BytesWritten: 350e6}
+
+	progress := NewPostProgress(nil, first, 0)
+	if progress.Percent != -1 || progress.MBps != 0 || progress.Remaining != -1 {
+		t.Fatalf("expected no percentage, throughput and remaining time, got %+v", progress)
+	}
+	progress = NewPostProgress(&first, second, 1000e6)
+	if progress.Percent != 35 || progress.MBps != 25 || progress.Remaining != 26*time.Second {
+		t.Fatalf("expected 35%% at 25 MB/s with 26s left, got %+v", progress)
+	}
+	if progress = NewPostProgress(&first, second, 0); progress.Remaining != -1 {
+		t.Fatalf("expected no remaining time without a total, got %+v", progress)
+	}
+	if progress = NewPostProgress(&second, first, 200e6); progress.Percent != 50 || progress.MBps != 0 {
+		t.Fatalf("expected no throughput when fewer bytes are reported, got %+v", progress)
+	}
+	if progress = NewPostProgress(&first, second, 200e6); progress.Percent != 100 || progress.Remaining != 0 {
+		t.Fatalf("expected the percentage to be capped with no time left, got %+v", progress)
+	}
+	if gib := GiB(3 << 30); gib != 3 {
+		t.Fatalf("expected 3 GiB, got %v", gib)
+	}
+}
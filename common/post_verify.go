@@ -0,0 +1,93 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// PostDataCheck is the result of checking the PoST data files in a directory without a proof
+type PostDataCheck struct {
+	// Info is the data found, nil when there is none
+	Info *PostDataInfo
+	// MetadataChecksum is the SHA-256 of the metadata file in hex, empty when there is none
+	MetadataChecksum string
+	// Problems are the inconsistencies found, none when the check passed
+	Problems []string
+	// Duration is how long the check took
+	Duration time.Duration
+}
+
+// Passed tells whether PoST data was found without problems
+func (c *PostDataCheck) Passed() bool {
+	return c.Info != nil && len(c.Problems) == 0
+}
+
+// CheckPostData checks that the PoST data files of a directory are numbered from 0 without gaps,
+// that all but the last have the same size and that their sizes add up to the commitment of the
+// metadata file. The labels aren't read, so this doesn't prove that the data is correct.
+func CheckPostData(dir string) (*PostDataCheck, error) {
+	start := time.Now()
+	check := &PostDataCheck{}
+	info, err := InspectPostData(dir)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		check.Duration = time.Since(start)
+		return check, nil
+	}
+	check.Info = info
+
+	if data, err := ioutil.ReadFile(filepath.Join(dir, postMetadataFile)); err == nil {
+		sum := sha256.Sum256(data)
+		check.MetadataChecksum = hex.EncodeToString(sum[:])
+	} else {
+		check.Problems = append(check.Problems, "there is no metadata file, so the commitment size and smesher id can't be checked")
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[int]uint64)
+	var indexes []int
+	for _, f := range files {
+		if match, _ := filepath.Match(postDataPattern, f.Name()); !match || !f.Mode().IsRegular() {
+			continue
+		}
+		var index int
+		if _, err := fmt.Sscanf(f.Name(), "postdata_%d.bin", &index); err != nil || fmt.Sprintf("postdata_%d.bin", index) != f.Name() {
+			check.Problems = append(check.Problems, fmt.Sprintf("%s isn't a numbered data file", f.Name()))
+			continue
+		}
+		sizes[index] = uint64(f.Size())
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for i, index := range indexes {
+		if index != i {
+			check.Problems = append(check.Problems, fmt.Sprintf("data file %d is missing", i))
+			break
+		}
+	}
+	for i, index := range indexes {
+		switch {
+		case sizes[index] == 0:
+			check.Problems = append(check.Problems, fmt.Sprintf("data file %d is empty", index))
+		case i < len(indexes)-1 && sizes[index] != sizes[indexes[0]]:
+			check.Problems = append(check.Problems, fmt.Sprintf("data file %d has %d bytes, the first one %d", index, sizes[index], sizes[indexes[0]]))
+		case i == len(indexes)-1 && i > 0 && sizes[index] > sizes[indexes[0]]:
+			check.Problems = append(check.Problems, fmt.Sprintf("the last data file %d is larger than the others", index))
+		}
+	}
+	if info.Written != info.Committed {
+		check.Problems = append(check.Problems, fmt.Sprintf("%.2f GiB of the %.2f GiB commitment are written", GiB(info.Written), GiB(info.Committed)))
+	}
+	check.Duration = time.Since(start)
+	return check, nil
+}
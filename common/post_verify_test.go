@@ -0,0 +1,52 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckPostData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smrepl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name string, size int) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), make([]byte, size), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check, err := CheckPostData(dir)
+	if err != nil || check.Info != nil || check.Passed() {
+		t.Fatalf("expected no data to fail, got %+v %v", check, err)
+	}
+
+	write("postdata_0.bin", 100)
+	write("postdata_1.bin", 100)
+	write("postdata_2.bin", 50)
+	if err := ioutil.WriteFile(filepath.Join(dir, postMetadataFile), []byte(`{"BitsPerLabel":8,"LabelsPerUnit":250,"NumUnits":1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	check, err = CheckPostData(dir)
+	if err != nil || !check.Passed() || len(check.MetadataChecksum) != 64 || check.Info.Files != 3 {
+		t.Fatalf("expected consistent data to pass, got %+v %v", check, err)
+	}
+
+	write("postdata_1.bin", 90)
+	write("postdata_4.bin", 10)
+	check, err = CheckPostData(dir)
+	if err != nil || check.Passed() || len(check.Problems) != 3 {
+		t.Fatalf("expected a gap and two size mismatches, got %+v %v", check, err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, postMetadataFile)); err != nil {
+		t.Fatal(err)
+	}
+	check, err = CheckPostData(dir)
+	if err != nil || check.Passed() || check.MetadataChecksum != "" {
+		t.Fatalf("expected missing metadata to fail, got %+v %v", check, err)
+	}
+}
@@ -0,0 +1,139 @@
+package common
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Types of accounting report rows
+const (
+	ReportReward  = "reward"
+	ReportSend    = "send"
+	ReportReceive = "receive"
+	ReportFee     = "fee"
+)
+
+// reportDateFormat is the format of date arguments of a report, read as UTC
+const reportDateFormat = "2006-01-02"
+
+// ReportRow is a row of an accounting report. Amount is signed, negative when coins leave the
+// account. Balance is the balance after the row. Amounts are in Smidge and never rounded.
+type ReportRow struct {
+	Time         time.Time
+	Layer        uint32
+	Type         string
+	Counterparty string
+	Amount       *big.Int
+	Fee          uint64
+	TxID         string
+	Balance      *big.Int
+}
+
+// ParseReportBound parses a report range bound: a layer number, a date such as 2021-03-31 or an
+// RFC 3339 time. A date as the end of a range includes the whole day.
+func ParseReportBound(s string, info *NetInfo, end bool) (uint32, error) {
+	if layer, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return uint32(layer), nil
+	}
+	if t, err := time.Parse(reportDateFormat, s); err == nil {
+		if end {
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		return info.LayerAt(t), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return info.LayerAt(t), nil
+	}
+	return 0, fmt.Errorf("invalid range bound %s, expected a layer, a date such as 2021-03-31 or an RFC 3339 time", s)
+}
+
+// BuildReport converts the activity of an account to report rows for layers from to to, oldest
+// first. The running balance starts at 0 and includes every event with a layer, also those before
+// from, so the last balance is the balance of the account if the activity is complete. Events
+// without a layer haven't been applied and are left out. The final balance is returned as well.
+func BuildReport(events []ActivityEvent, from, to uint32, info *NetInfo) ([]ReportRow, *big.Int) {
+	applied := make([]ActivityEvent, 0, len(events))
+	for _, e := range events {
+		if e.Layer != nil {
+			applied = append(applied, e)
+		}
+	}
+	sort.SliceStable(applied, func(i, j int) bool { return *applied[i].Layer < *applied[j].Layer })
+
+	balance := new(big.Int)
+	var rows []ReportRow
+	add := func(e ActivityEvent, typ string, amount *big.Int, fee uint64) {
+		balance.Add(balance, amount)
+		layer := *e.Layer
+		if layer < from || layer > to {
+			return
+		}
+		rows = append(rows, ReportRow{
+			Time:         info.LayerTime(layer).UTC(),
+			Layer:        layer,
+			Type:         typ,
+			Counterparty: e.Counterparty,
+			Amount:       amount,
+			Fee:          fee,
+			TxID:         e.TxID,
+			Balance:      new(big.Int).Set(balance),
+		})
+	}
+	for _, e := range applied {
+		amount := new(big.Int).SetUint64(e.Amount)
+		fee := new(big.Int).SetUint64(e.Fee)
+		switch e.Kind {
+		case ActivityReward:
+			add(e, ReportReward, amount, 0)
+		case DirectionIn:
+			add(e, ReportReceive, amount, 0)
+		case DirectionOut:
+			add(e, ReportSend, amount.Neg(amount), 0)
+			add(e, ReportFee, fee.Neg(fee), e.Fee)
+		default:
+			// a transaction to self and a receipt without its transaction only cost the fee
+			add(e, ReportFee, fee.Neg(fee), e.Fee)
+		}
+	}
+	return rows, balance
+}
+
+// reportHeader is the header row of an accounting report
+var reportHeader = []string{"time", "layer", "type", "counterparty", "amount", "fee", "id", "balance"}
+
+// signedAmount formats an amount with an explicit sign
+func signedAmount(n *big.Int) string {
+	if n.Sign() > 0 {
+		return "+" + n.String()
+	}
+	return n.String()
+}
+
+// WriteReportCSV writes report rows as CSV with a header row
+func WriteReportCSV(w io.Writer, rows []ReportRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(reportHeader); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := cw.Write([]string{
+			r.Time.Format(time.RFC3339),
+			strconv.FormatUint(uint64(r.Layer), 10),
+			r.Type,
+			r.Counterparty,
+			signedAmount(r.Amount),
+			strconv.FormatUint(r.Fee, 10),
+			r.TxID,
+			r.Balance.String(),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
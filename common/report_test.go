@@ -0,0 +1,63 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseReportBound(t *testing.T) {
+	info := &NetInfo{GenesisTime: 1600000000, LayerDuration: 30}
+	for _, test := range []struct {
+		arg      string
+		end      bool
+		expected uint32
+	}{
+		{"1234", false, 1234},
+		{"2020-09-14", false, 1386},
+		{"2020-09-14", true, 4266},
+		{"2020-09-13T12:27:10Z", false, 1},
+		{"2020-01-01", false, 0},
+	} {
+		layer, err := ParseReportBound(test.arg, info, test.end)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if layer != test.expected {
+			t.Fatalf("%s: expected layer %d, got %d", test.arg, test.expected, layer)
+		}
+	}
+	if _, err := ParseReportBound("yesterday", info, false); err == nil {
+		t.Fatal("expected an error for an invalid bound")
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	info := &NetInfo{GenesisTime: 1600000000, LayerDuration: 30}
+	events := []ActivityEvent{
+		{Layer: layerPtr(12), Kind: DirectionOut, Amount: 18446744073709551615, Fee: 7, Counterparty: "0x02", TxID: "0x01"},
+		{Layer: layerPtr(10), Kind: ActivityReward, Amount: 18446744073709551615},
+		{Layer: layerPtr(11), Kind: DirectionIn, Amount: 100, Counterparty: "0x03", TxID: "0x04"},
+		{Layer: layerPtr(9), Kind: ActivityReward, Amount: 50},
+		{Kind: DirectionOut, Amount: 1, Fee: 1, TxID: "0x05"},
+	}
+	rows, balance := BuildReport(events, 10, 12, info)
+	if balance.String() != "143" {
+		t.Fatalf("expected a final balance of 143, got %s", balance)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("expected 4 rows in layers 10 to 12, got %d", len(rows))
+	}
+
+	var buf bytes.Buffer
+	if err := WriteReportCSV(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	expected := "time,layer,type,counterparty,amount,fee,id,balance\n" +
+		"2020-09-13T12:31:40Z,10,reward,,+18446744073709551615,0,,18446744073709551665\n" +
+		"2020-09-13T12:32:10Z,11,receive,0x03,+100,0,0x04,18446744073709551765\n" +
+		"2020-09-13T12:32:40Z,12,send,0x02,-18446744073709551615,0,0x01,150\n" +
+		"2020-09-13T12:32:40Z,12,fee,0x02,-7,7,0x01,143\n"
+	if buf.String() != expected {
+		t.Fatalf("unexpected CSV:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,48 @@
+package common
+
+import (
+	"sort"
+	"strings"
+)
+
+// EpochRewards sums the rewards awarded in an epoch. Amounts are in Smidge.
+type EpochRewards struct {
+	Epoch uint64
+	Count int
+	Total uint64
+}
+
+// GroupRewardsByEpoch sums reward records by the epoch of their layer, in epoch order. Epochs
+// without rewards are left out.
+func GroupRewardsByEpoch(records []RewardRecord, layersPerEpoch uint64) []EpochRewards {
+	if layersPerEpoch == 0 {
+		return nil
+	}
+	index := make(map[uint64]int)
+	var res []EpochRewards
+	for _, r := range records {
+		epoch := uint64(r.Layer) / layersPerEpoch
+		i, ok := index[epoch]
+		if !ok {
+			i = len(res)
+			index[epoch] = i
+			res = append(res, EpochRewards{Epoch: epoch})
+		}
+		res[i].Count++
+		res[i].Total += r.Total
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Epoch < res[j].Epoch })
+	return res
+}
+
+// RewardBar returns a bar of up to width blocks showing an amount relative to the largest one
+func RewardBar(amount, largest uint64, width int) string {
+	if largest == 0 {
+		return ""
+	}
+	n := int(float64(amount) / float64(largest) * float64(width))
+	if n == 0 && amount > 0 {
+		n = 1
+	}
+	return strings.Repeat("█", n)
+}
@@ -0,0 +1,40 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupRewardsByEpoch(t *testing.T) {
+	// layers 9 and 10 are on both sides of the boundary of epochs 0 and 1 with 10 layers per epoch
+	records := []RewardRecord{
+		{Layer: 25, Total: 7},
+		{Layer: 9, Total: 10},
+		{Layer: 10, Total: 20},
+		{Layer: 0, Total: 5},
+		{Layer: 19, Total: 1},
+	}
+	expected := []EpochRewards{
+		{Epoch: 0, Count: 2, Total: 15},
+		{Epoch: 1, Count: 2, Total: 21},
+		{Epoch: 2, Count: 1, Total: 7},
+	}
+	if epochs := GroupRewardsByEpoch(records, 10); !reflect.DeepEqual(epochs, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, epochs)
+	}
+	if epochs := GroupRewardsByEpoch(records, 0); epochs != nil {
+		t.Fatalf("expected no epochs without layers per epoch, got %+v", epochs)
+	}
+}
+
+func TestRewardBar(t *testing.T) {
+	if bar := RewardBar(21, 21, 10); bar != "██████████" {
+		t.Fatalf("expected a full bar, got %q", bar)
+	}
+	if bar := RewardBar(1, 21, 10); bar != "█" {
+		t.Fatalf("expected a small amount to show, got %q", bar)
+	}
+	if bar := RewardBar(0, 0, 10); bar != "" {
+		t.Fatalf("expected no bar, got %q", bar)
+	}
+}
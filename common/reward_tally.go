@@ -0,0 +1,32 @@
+package common
+
+// RewardTally sums the rewards of a smesher as they are fetched page by page, in total and by
+// epoch. The node appends new rewards to the end of its results, so Count is also the offset of the
+// first reward which hasn't been summed yet.
+type RewardTally struct {
+	// Count is the number of rewards summed
+	Count uint32
+	// Total is the sum of the rewards in Smidge
+	Total  uint64
+	epochs map[uint64]uint64
+}
+
+// Add sums a reward awarded in an epoch
+func (t *RewardTally) Add(epoch, amount uint64) {
+	if t.epochs == nil {
+		t.epochs = make(map[uint64]uint64)
+	}
+	t.Count++
+	t.Total += amount
+	t.epochs[epoch] += amount
+}
+
+// EpochTotal returns the sum of the rewards awarded in an epoch
+func (t *RewardTally) EpochTotal(epoch uint64) uint64 {
+	return t.epochs[epoch]
+}
+
+// Reset clears the tally, so the rewards are summed again from the first one
+func (t *RewardTally) Reset() {
+	*t = RewardTally{}
+}
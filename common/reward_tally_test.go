@@ -0,0 +1,17 @@
+package common
+
+import "testing"
+
+func TestRewardTally(t *testing.T) {
+	var tally RewardTally
+	tally.Add(1, 10)
+	tally.Add(2, 20)
+	tally.Add(2, 5)
+	if tally.Count != 3 || tally.Total != 35 || tally.EpochTotal(2) != 25 || tally.EpochTotal(3) != 0 {
+		t.Fatalf("unexpected tally %+v", tally)
+	}
+	tally.Reset()
+	if tally.Count != 0 || tally.Total != 0 || tally.EpochTotal(2) != 0 {
+		t.Fatalf("expected an empty tally, got %+v", tally)
+	}
+}
@@ -0,0 +1,96 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits are the suffixes of sizes parsed by ParseSize, longest first
+var sizeUnits = []struct {
+	suffix string
+	bytes  uint64
+}{
+	{"tib", 1 << 40},
+	{"gib", 1 << 30},
+	{"mib", 1 << 20},
+	{"tb", 1 << 40},
+	{"gb", 1 << 30},
+	{"mb", 1 << 20},
+	{"t", 1 << 40},
+	{"g", 1 << 30},
+	{"m", 1 << 20},
+}
+
+// ParseSize parses a size of PoST data to bytes. Sizes have a TiB, GiB or MiB suffix, e.g. 1.5TiB,
+// or are in GiB without one. The suffixes T, G, M, TB, GB and MB are accepted for the same binary
+// units.
+func ParseSize(s string) (uint64, error) {
+	str := strings.ToLower(strings.TrimSpace(s))
+	unit := uint64(bytesPerGiB)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(str, u.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, u.suffix))
+			unit = u.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(str, 64)
+	if err != nil || n <= 0 || math.IsInf(n, 0) || n*float64(unit) >= math.MaxUint64 {
+		return 0, fmt.Errorf("invalid size %q, expected e.g. 256GiB or 1TiB", s)
+	}
+	return uint64(n * float64(unit)), nil
+}
+
+// RewardEstimateInput are the figures smeshing rewards are estimated from
+type RewardEstimateInput struct {
+	// Space is the smesher's PoST data size in bytes
+	Space uint64
+	// NetworkSpace is the PoST data size of all the smeshers of the network in bytes
+	NetworkSpace uint64
+	// Joining tells that Space isn't part of NetworkSpace yet, since the smesher plans to commit it
+	Joining bool
+	// LayerReward is the reward of a layer in Smidge, shared by the smeshers
+	LayerReward uint64
+	// LayersPerEpoch and LayerDuration, in seconds, give the length of an epoch
+	LayersPerEpoch uint64
+	LayerDuration  uint64
+}
+
+// RewardEstimate is the expected reward of a smesher
+type RewardEstimate struct {
+	// Share is the smesher's share of the network space, between 0 and 1
+	Share float64
+	// EpochsPerMonth is the number of epochs in 30 days
+	EpochsPerMonth float64
+	// PerEpoch and PerMonth are the expected rewards in Smidge
+	PerEpoch uint64
+	PerMonth uint64
+}
+
+// daySeconds is the length of a day in seconds
+const daySeconds = 24 * 60 * 60
+
+// EstimateRewards returns the expected reward of a smesher, which earns the share of every layer
+// reward its space is of the network space. A month is 30 days.
+func EstimateRewards(in RewardEstimateInput) (RewardEstimate, error) {
+	if in.Space == 0 || in.LayersPerEpoch == 0 || in.LayerDuration == 0 {
+		return RewardEstimate{}, errors.New("the space, layers per epoch and layer duration must be positive")
+	}
+	total := in.NetworkSpace
+	if in.Joining {
+		total += in.Space
+	}
+	if total < in.Space {
+		return RewardEstimate{}, errors.New("the network space must include the space of the smesher")
+	}
+	var e RewardEstimate
+	e.Share = float64(in.Space) / float64(total)
+	e.EpochsPerMonth = 30 * daySeconds / float64(in.LayersPerEpoch*in.LayerDuration)
+	perEpoch := e.Share * float64(in.LayerReward) * float64(in.LayersPerEpoch)
+	e.PerEpoch = uint64(math.Round(perEpoch))
+	e.PerMonth = uint64(math.Round(perEpoch * e.EpochsPerMonth))
+	return e, nil
+}
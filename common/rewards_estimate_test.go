@@ -0,0 +1,56 @@
+package common
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	for _, test := range []struct {
+		in       string
+		expected uint64
+	}{
+		{"256", 256 << 30},
+		{"256GiB", 256 << 30},
+		{"1.5 TiB", 3 << 39},
+		{"512mb", 512 << 20},
+		{"2T", 2 << 40},
+	} {
+		if size, err := ParseSize(test.in); err != nil || size != test.expected {
+			t.Fatalf("%s: expected %d, got %d %v", test.in, test.expected, size, err)
+		}
+	}
+	for _, in := range []string{"", "GiB", "-1GiB", "0", "lots"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Fatalf("%q: expected an error", in)
+		}
+	}
+}
+
+func TestEstimateRewards(t *testing.T) {
+	in := RewardEstimateInput{
+		Space:          1 << 40,
+		NetworkSpace:   3 << 40,
+		Joining:        true,
+		LayerReward:    50 * SmidgePerSmesh,
+		LayersPerEpoch: 288,
+		LayerDuration:  300,
+	}
+	e, err := EstimateRewards(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1 TiB of 4 TiB earns a quarter of 288 layers of 50 SMH, and 30 days hold 30 epochs of a day
+	if e.Share != 0.25 || e.EpochsPerMonth != 30 || e.PerEpoch != 3600*SmidgePerSmesh || e.PerMonth != 108000*SmidgePerSmesh {
+		t.Fatalf("unexpected estimate %+v", e)
+	}
+
+	in.Joining = false
+	if e, err = EstimateRewards(in); err != nil || e.Share != 1.0/3 {
+		t.Fatalf("expected a third of the network space, got %+v %v", e, err)
+	}
+	in.NetworkSpace = 1 << 39
+	if _, err = EstimateRewards(in); err == nil {
+		t.Fatal("expected an error when the network space is smaller than the smesher's")
+	}
+	if _, err = EstimateRewards(RewardEstimateInput{}); err == nil {
+		t.Fatal("expected an error without inputs")
+	}
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// RewardRecord is an exported smeshing reward. Amounts are in Smidge. Time is empty when the
+// layer time isn't known.
+type RewardRecord struct {
+	Layer       uint32
+	Time        string
+	LayerReward uint64
+	Fees        uint64
+	Total       uint64
+	Coinbase    string
+}
+
+// RewardTotals sums smeshing rewards. Amounts are in Smidge.
+type RewardTotals struct {
+	Count       int
+	LayerReward uint64
+	Fees        uint64
+	Total       uint64
+}
+
+// InLayerRange tells whether a record's layer is within from and to, inclusive. A nil bound
+// doesn't limit the range.
+func (r *RewardRecord) InLayerRange(from, to *uint32) bool {
+	return (from == nil || r.Layer >= *from) && (to == nil || r.Layer <= *to)
+}
+
+// SumRewards returns the number of records and the sums of their amounts
+func SumRewards(records []RewardRecord) RewardTotals {
+	totals := RewardTotals{Count: len(records)}
+	for _, r := range records {
+		totals.LayerReward += r.LayerReward
+		totals.Fees += r.Fees
+		totals.Total += r.Total
+	}
+	return totals
+}
+
+// rewardRecordsHeader is the header row of a rewards CSV export
+var rewardRecordsHeader = []string{"layer", "time", "layer_reward", "fees", "total", "coinbase"}
+
+// WriteRewardRecordsCSV writes reward records as CSV with a header row
+func WriteRewardRecordsCSV(w io.Writer, records []RewardRecord) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(rewardRecordsHeader); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := cw.Write([]string{
+			strconv.FormatUint(uint64(r.Layer), 10),
+			r.Time,
+			strconv.FormatUint(r.LayerReward, 10),
+			strconv.FormatUint(r.Fees, 10),
+			strconv.FormatUint(r.Total, 10),
+			r.Coinbase,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,34 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRewardRecords(t *testing.T) {
+	records := []RewardRecord{
+		{Layer: 10, Time: "2020-12-01T10:00:00Z", LayerReward: 50, Fees: 2, Total: 52, Coinbase: "0x01"},
+		{Layer: 20, LayerReward: 50, Total: 50, Coinbase: "0x02"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRewardRecordsCSV(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+	expected := "layer,time,layer_reward,fees,total,coinbase\n" +
+		"10,2020-12-01T10:00:00Z,50,2,52,0x01\n" +
+		"20,,50,0,50,0x02\n"
+	if buf.String() != expected {
+		t.Fatalf("unexpected CSV:\n%s", buf.String())
+	}
+
+	totals := SumRewards(records)
+	if totals != (RewardTotals{Count: 2, LayerReward: 100, Fees: 2, Total: 102}) {
+		t.Fatalf("unexpected totals %+v", totals)
+	}
+
+	from, to := uint32(15), uint32(20)
+	if records[0].InLayerRange(&from, nil) || !records[1].InLayerRange(&from, &to) || !records[0].InLayerRange(nil, nil) {
+		t.Fatal("unexpected layer range match")
+	}
+}
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"sort"
+	"time"
+)
+
+// RichList is the accounts of a global state scan ranked by balance, largest first. Accounts with
+// the same balance are ranked by address.
+type RichList struct {
+	Accounts []SnapshotAccount
+	// Supply is the sum of the balances
+	Supply uint64
+	// State is the global state the accounts were scanned at and Time when
+	State SnapshotState
+	Time  time.Time
+}
+
+// NewRichList ranks the accounts of a scan. The accounts are sorted in place.
+func NewRichList(accounts []SnapshotAccount, state SnapshotState, t time.Time) *RichList {
+	l := &RichList{Accounts: accounts, State: state, Time: t}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Balance != accounts[j].Balance {
+			return accounts[i].Balance > accounts[j].Balance
+		}
+		return accounts[i].Address < accounts[j].Address
+	})
+	for _, a := range accounts {
+		l.Supply += a.Balance
+	}
+	return l
+}
+
+// Top returns the n accounts with the largest balances
+func (l *RichList) Top(n int) []SnapshotAccount {
+	if n > len(l.Accounts) {
+		n = len(l.Accounts)
+	}
+	return l.Accounts[:n]
+}
+
+// Rank returns the 1-based rank of an address and whether it was found
+func (l *RichList) Rank(address string) (int, bool) {
+	for i, a := range l.Accounts {
+		if a.Address == address {
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+// Share returns a balance as a percentage of the supply, 0 when the supply is 0
+func (l *RichList) Share(balance uint64) float64 {
+	if l.Supply == 0 {
+		return 0
+	}
+	return float64(balance) / float64(l.Supply) * 100
+}
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRichList(t *testing.T) {
+	l := NewRichList([]SnapshotAccount{
+		{Address: "0xc", Balance: 100},
+		{Address: "0xa", Balance: 500},
+		{Address: "0xd", Balance: 0},
+		{Address: "0xb", Balance: 400},
+		{Address: "0xe", Balance: 100},
+	}, SnapshotState{Layer: 7}, time.Now())
+
+	if l.Supply != 1100 {
+		t.Fatalf("expected a supply of 1100, got %d", l.Supply)
+	}
+	top := l.Top(3)
+	if len(top) != 3 || top[0].Address != "0xa" || top[1].Address != "0xb" || top[2].Address != "0xc" {
+		t.Fatalf("unexpected top 3 %+v", top)
+	}
+	if len(l.Top(10)) != 5 {
+		t.Fatal("expected all accounts when asking for more than there are")
+	}
+	if rank, ok := l.Rank("0xe"); !ok || rank != 4 {
+		t.Fatalf("expected 0xe ranked 4th after 0xc with the same balance, got %d %v", rank, ok)
+	}
+	if _, ok := l.Rank("0xf"); ok {
+		t.Fatal("expected an unknown address not to be ranked")
+	}
+	if share := l.Share(550); share != 50 {
+		t.Fatalf("expected 50%%, got %f", share)
+	}
+	if share := (&RichList{}).Share(10); share != 0 {
+		t.Fatalf("expected no share of an empty supply, got %f", share)
+	}
+}
@@ -0,0 +1,40 @@
+package common
+
+import (
+	"github.com/spacemeshos/ed25519"
+)
+
+// Zero overwrites a byte slice holding secret data
+func Zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// SigningKey holds a private key borrowed from an account for signing. Release overwrites the key
+// so it doesn't linger in memory after use.
+type SigningKey struct {
+	key ed25519.PrivateKey
+}
+
+// Sign signs msg with ed25519.Sign2
+func (k *SigningKey) Sign(msg []byte) []byte {
+	return ed25519.Sign2(k.key, msg)
+}
+
+// SignEd25519 signs msg with standard ed25519, whose signatures nodes from v1.0 verify against the
+// public key of the account
+func (k *SigningKey) SignEd25519(msg []byte) []byte {
+	return ed25519.Sign(k.key, msg)
+}
+
+// PublicKey returns the public key of the signing key
+func (k *SigningKey) PublicKey() ed25519.PublicKey {
+	return k.key.Public().(ed25519.PublicKey)
+}
+
+// Release overwrites the private key. The signing key can't be used afterwards.
+func (k *SigningKey) Release() {
+	Zero(k.key)
+	k.key = nil
+}
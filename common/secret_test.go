@@ -0,0 +1,58 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spacemeshos/ed25519"
+)
+
+func TestSigningKeyRelease(t *testing.T) {
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{3}, ed25519.SeedSize))
+	pub := priv.Public().(ed25519.PublicKey)
+	acc := &LocalAccount{Name: "test", PrivKey: append(ed25519.PrivateKey{}, priv...), PubKey: pub}
+	backing := acc.PrivKey
+
+	key, err := acc.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.PrivKey != nil {
+		t.Fatal("expected the account to give up its private key")
+	}
+	if acc.IsWatchOnly() {
+		t.Fatal("an account that lent its key is not watch-only")
+	}
+	if _, err := acc.SigningKey(); err == nil {
+		t.Fatal("expected an error borrowing a released key")
+	}
+
+	msg := []byte("message")
+	if !bytes.Equal(key.Sign(msg), ed25519.Sign2(priv, msg)) {
+		t.Fatal("unexpected signature")
+	}
+	if !bytes.Equal(key.PublicKey(), pub) {
+		t.Fatal("unexpected public key")
+	}
+
+	key.Release()
+	if !bytes.Equal(backing, make([]byte, len(backing))) {
+		t.Fatal("expected the private key to be overwritten")
+	}
+}
+
+func TestWatchOnlySigningKey(t *testing.T) {
+	acc := &LocalAccount{Name: "watched"}
+	if _, err := acc.SigningKey(); err != ErrWatchOnly {
+		t.Fatalf("expected %v, got %v", ErrWatchOnly, err)
+	}
+}
+
+func TestLocalAccountWipe(t *testing.T) {
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{4}, ed25519.SeedSize))
+	acc := &LocalAccount{Name: "test", PrivKey: priv, PubKey: priv.Public().(ed25519.PublicKey)}
+	acc.Wipe()
+	if acc.PrivKey != nil || !bytes.Equal(priv, make([]byte, len(priv))) {
+		t.Fatal("expected the private key to be overwritten")
+	}
+}
@@ -0,0 +1,34 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/ed25519"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// VerifySignature returns true if sig is a valid Sign2 signature of msg by the public key pub
+func VerifySignature(pub ed25519.PublicKey, msg, sig []byte) bool {
+	if len(pub) != ed25519.PublicKeySize || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify2(pub, msg, sig)
+}
+
+// ExtractPublicKey recovers the public key which produced the Sign2 signature sig of msg
+func ExtractPublicKey(msg, sig []byte) (ed25519.PublicKey, error) {
+	if len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("invalid signature length %d, expected %d bytes", len(sig), ed25519.SignatureSize)
+	}
+	return ed25519.ExtractPublicKey(msg, sig)
+}
+
+// VerifySignatureByAddress returns true if sig is a valid Sign2 signature of msg by the account
+// with address. The public key is extracted from the signature.
+func VerifySignatureByAddress(address gosmtypes.Address, msg, sig []byte) bool {
+	pub, err := ExtractPublicKey(msg, sig)
+	if err != nil {
+		return false
+	}
+	return gosmtypes.BytesToAddress(pub) == address && VerifySignature(pub, msg, sig)
+}
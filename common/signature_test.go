@@ -0,0 +1,71 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/spacemeshos/ed25519"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func testKey() ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+}
+
+// signVectors returns the messages signed by account sign, account text-sign and account sign-file
+func signVectors(t *testing.T) [][]byte {
+	hexMsg, err := hex.DecodeString("0102030405")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("file content"))
+	return [][]byte{hexMsg, []byte("hello spacemesh"), digest[:]}
+}
+
+func TestVerifySignature(t *testing.T) {
+	key := testKey()
+	pub := key.Public().(ed25519.PublicKey)
+	for _, msg := range signVectors(t) {
+		sig := ed25519.Sign2(key, msg)
+		if !VerifySignature(pub, msg, sig) {
+			t.Fatalf("expected valid signature for %x", msg)
+		}
+		if VerifySignature(pub, append([]byte{0}, msg...), sig) {
+			t.Fatalf("expected invalid signature for a modified message")
+		}
+		tampered := append([]byte{}, sig...)
+		tampered[0] ^= 0xff
+		if VerifySignature(pub, msg, tampered) {
+			t.Fatalf("expected invalid signature for a modified signature")
+		}
+		if VerifySignature(pub, msg, sig[:10]) {
+			t.Fatalf("expected invalid signature for a short signature")
+		}
+	}
+}
+
+func TestExtractPublicKey(t *testing.T) {
+	key := testKey()
+	pub := key.Public().(ed25519.PublicKey)
+	for _, msg := range signVectors(t) {
+		sig := ed25519.Sign2(key, msg)
+		extracted, err := ExtractPublicKey(msg, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(extracted, pub) {
+			t.Fatalf("expected public key %x, got %x", []byte(pub), []byte(extracted))
+		}
+		if !VerifySignatureByAddress(gosmtypes.BytesToAddress(pub), msg, sig) {
+			t.Fatal("expected signature to verify by address")
+		}
+		if VerifySignatureByAddress(gosmtypes.HexToAddress("0x7fa75881ca0050028b32f424f860e3a73d4bf168"), msg, sig) {
+			t.Fatal("expected signature not to verify for another address")
+		}
+	}
+	if _, err := ExtractPublicKey([]byte("msg"), []byte{1, 2, 3}); err == nil {
+		t.Fatal("expected an error for a short signature")
+	}
+}
@@ -0,0 +1,86 @@
+package common
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SignedBatchTx is a transaction of a batch signed offline, as written by tx sign-batch and read by
+// tx broadcast-batch. The payment fields repeat what is signed, so the online side can check the
+// decoded transaction against them.
+type SignedBatchTx struct {
+	Line      int    `json:"line"` // line in the batch file
+	ID        string `json:"id"`
+	Tx        string `json:"tx"` // serialized signed transaction in hex
+	Recipient string `json:"recipient"`
+	Amount    uint64 `json:"amount"` // in Smidge
+	Nonce     uint64 `json:"nonce"`
+	Note      string `json:"note,omitempty"`
+	// Format is the TxCodec format of the transaction. Empty in files written before it was
+	// recorded, whose transactions are legacy.
+	Format string `json:"format,omitempty"`
+}
+
+// NewSignedBatchTx returns the batch entry of a payment signed offline
+func NewSignedBatchTx(row BatchRow, tx *SignedTransfer) SignedBatchTx {
+	return SignedBatchTx{
+		Line:      row.Line,
+		ID:        "0x" + hex.EncodeToString(tx.ID),
+		Tx:        hex.EncodeToString(tx.Signed),
+		Recipient: tx.Recipient.Hex(),
+		Amount:    tx.Amount,
+		Nonce:     tx.Nonce,
+		Note:      row.Note,
+		Format:    tx.Format,
+	}
+}
+
+// Bytes returns the serialized transaction and its id. The id must be the id of the transaction in
+// its format.
+func (t SignedBatchTx) Bytes() ([]byte, []byte, error) {
+	tx, err := hex.DecodeString(strings.TrimPrefix(t.Tx, "0x"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("line %d: invalid transaction hex: %v", t.Line, err)
+	}
+	id, err := hex.DecodeString(strings.TrimPrefix(t.ID, "0x"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("line %d: invalid transaction id: %v", t.Line, err)
+	}
+	sum, err := TxID(t.Format, tx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("line %d: %v", t.Line, err)
+	}
+	if !bytes.Equal(sum, id) {
+		return nil, nil, fmt.Errorf("line %d: the transaction id 0x%x doesn't match the transaction, its hash is 0x%x", t.Line, id, sum)
+	}
+	return tx, id, nil
+}
+
+// WriteSignedBatch writes the transactions of a batch signed offline as a JSON array
+func WriteSignedBatch(w io.Writer, txs []SignedBatchTx) error {
+	if txs == nil {
+		txs = []SignedBatchTx{}
+	}
+	data, err := json.MarshalIndent(txs, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ReadSignedBatch reads the transactions of a batch signed offline
+func ReadSignedBatch(r io.Reader) ([]SignedBatchTx, error) {
+	var txs []SignedBatchTx
+	if err := json.NewDecoder(r).Decode(&txs); err != nil {
+		return nil, fmt.Errorf("invalid signed batch file: %v", err)
+	}
+	if len(txs) == 0 {
+		return nil, fmt.Errorf("the signed batch file has no transactions")
+	}
+	return txs, nil
+}
@@ -0,0 +1,45 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestSignedBatchTxBytes(t *testing.T) {
+	tx := []byte{1, 2, 3}
+	id := sha256.Sum256(tx)
+	entry := SignedBatchTx{Line: 2, ID: "0x" + hex.EncodeToString(id[:]), Tx: hex.EncodeToString(tx)}
+	data, got, err := entry.Bytes()
+	if err != nil || !bytes.Equal(data, tx) || !bytes.Equal(got, id[:]) {
+		t.Fatalf("unexpected result: %x %x %v", data, got, err)
+	}
+	entry.Tx = "010204"
+	if _, _, err := entry.Bytes(); err == nil {
+		t.Fatal("expected an error for an id that doesn't match the transaction")
+	}
+	entry.Tx = "zz"
+	if _, _, err := entry.Bytes(); err == nil {
+		t.Fatal("expected an error for invalid hex")
+	}
+}
+
+func TestReadSignedBatch(t *testing.T) {
+	var buf bytes.Buffer
+	txs := []SignedBatchTx{{Line: 2, ID: "0x01", Tx: "02", Recipient: "0x03", Amount: 4, Nonce: 5, Note: "rent"}}
+	if err := WriteSignedBatch(&buf, txs); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadSignedBatch(&buf)
+	if err != nil || len(read) != 1 || read[0] != txs[0] {
+		t.Fatalf("expected the batch to read back: %v %+v", err, read)
+	}
+	if _, err := ReadSignedBatch(strings.NewReader("[]")); err == nil {
+		t.Fatal("expected an error for an empty batch")
+	}
+	if _, err := ReadSignedBatch(strings.NewReader("{")); err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+}
@@ -0,0 +1,95 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// IdFormatHex displays smesher ids as 0x prefixed hex strings
+	IdFormatHex = "hex"
+	// IdFormatBase64 displays smesher ids as standard base64 strings, as explorers do
+	IdFormatBase64 = "base64"
+)
+
+// Smesher ids are 20 bytes in the address form SmesherID reports, or 32 bytes as node public keys
+const (
+	smesherIdAddressLen = 20
+	smesherIdKeyLen     = 32
+)
+
+// CheckSmesherIdLength returns an error when an id has neither length of a smesher id
+func CheckSmesherIdLength(id []byte) error {
+	if len(id) != smesherIdAddressLen && len(id) != smesherIdKeyLen {
+		return fmt.Errorf("a smesher id is %d or %d bytes long, this one is %d", smesherIdAddressLen, smesherIdKeyLen, len(id))
+	}
+	return nil
+}
+
+// FormatSmesherId returns a smesher id in a display format: IdFormatHex or IdFormatBase64. Other
+// formats are treated as hex.
+func FormatSmesherId(id []byte, format string) string {
+	if format == IdFormatBase64 {
+		return base64.StdEncoding.EncodeToString(id)
+	}
+	return "0x" + hex.EncodeToString(id)
+}
+
+// ParseSmesherId parses a smesher id given as hex, with or without 0x, or as standard or URL safe
+// base64, padded or not. A string of hex digits of even length is read as hex: the base64 forms of
+// smesher ids are padded or contain other letters.
+func ParseSmesherId(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("empty smesher id")
+	}
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if id, err := hex.DecodeString(trimmed); err == nil && (trimmed != s || len(trimmed)%2 == 0) {
+		return id, nil
+	} else if trimmed != s {
+		return nil, fmt.Errorf("invalid hex smesher id %s: %v", s, err)
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if id, err := enc.DecodeString(s); err == nil {
+			return id, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid smesher id %s: expected hex or base64", s)
+}
+
+// SelectSmesherId returns the smesher id a selector picks from the ids of a node: its position in
+// the list from 1, or a prefix of the id in hex or base64 which no other id has. An empty selector
+// picks the only id.
+func SelectSmesherId(ids [][]byte, selector string) ([]byte, error) {
+	selector = strings.TrimSpace(selector)
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("the node reports no smesher identity")
+	}
+	if selector == "" {
+		if len(ids) > 1 {
+			return nil, fmt.Errorf("the node smeshes with %d identities, select one with --identity <index or id prefix>", len(ids))
+		}
+		return ids[0], nil
+	}
+	if i, err := strconv.Atoi(selector); err == nil && i >= 1 && i <= len(ids) {
+		return ids[i-1], nil
+	}
+	prefix := strings.TrimPrefix(strings.TrimPrefix(selector, "0x"), "0X")
+	var found []byte
+	for _, id := range ids {
+		if strings.HasPrefix(hex.EncodeToString(id), strings.ToLower(prefix)) ||
+			strings.HasPrefix(base64.StdEncoding.EncodeToString(id), selector) {
+			if found != nil {
+				return nil, fmt.Errorf("more than one smesher identity starts with %s", selector)
+			}
+			found = id
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no smesher identity is number %s or starts with it", selector)
+	}
+	return found, nil
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestSmesherIdRoundTrip(t *testing.T) {
+	for _, id := range [][]byte{
+		bytes.Repeat([]byte{0xab}, 20),
+		bytes.Repeat([]byte{0x01, 0xfe}, 16),
+		{0xff, 0xef, 0xbe}, // base64 of these contains / and + in the standard encoding
+		{0x12, 0x34, 0x56}, // base64 EjRW is hex-like in length but not in charset
+	} {
+		for _, format := range []string{IdFormatHex, IdFormatBase64} {
+			s := FormatSmesherId(id, format)
+			parsed, err := ParseSmesherId(s)
+			if err != nil || !bytes.Equal(parsed, id) {
+				t.Fatalf("%s %s: expected %x, got %x %v", format, s, id, parsed, err)
+			}
+		}
+		for _, s := range []string{base64.URLEncoding.EncodeToString(id), base64.RawStdEncoding.EncodeToString(id)} {
+			if parsed, err := ParseSmesherId(s); err != nil || !bytes.Equal(parsed, id) {
+				t.Fatalf("%s: expected %x, got %x %v", s, id, parsed, err)
+			}
+		}
+	}
+}
+
+func TestParseSmesherId(t *testing.T) {
+	if id, err := ParseSmesherId(" abcd "); err != nil || !bytes.Equal(id, []byte{0xab, 0xcd}) {
+		t.Fatalf("expected hex without 0x, got %x %v", id, err)
+	}
+	for _, s := range []string{"", "0xabc", "0xzz", "not an id!"} {
+		if _, err := ParseSmesherId(s); err == nil {
+			t.Fatalf("%q: expected an error", s)
+		}
+	}
+	if CheckSmesherIdLength(make([]byte, 20)) != nil || CheckSmesherIdLength(make([]byte, 32)) != nil {
+		t.Fatal("expected 20 and 32 byte ids to be valid")
+	}
+	if CheckSmesherIdLength(make([]byte, 31)) == nil {
+		t.Fatal("expected a 31 byte id to be invalid")
+	}
+	if FormatSmesherId([]byte{1}, "other") != "0x01" {
+		t.Fatal("expected unknown formats to be hex")
+	}
+}
+
+func TestSelectSmesherId(t *testing.T) {
+	first, second := []byte{0xab, 0xcd, 0x01}, []byte{0xab, 0x12, 0x02}
+	ids := [][]byte{first, second}
+	for _, test := range []struct {
+		selector string
+		expected []byte
+	}{
+		{"1", first},
+		{"2", second},
+		{"abc", first},
+		{"0xAB1", second},
+		{base64.StdEncoding.EncodeToString(second)[:3], second},
+	} {
+		if id, err := SelectSmesherId(ids, test.selector); err != nil || !bytes.Equal(id, test.expected) {
+			t.Fatalf("%s: expected %x, got %x %v", test.selector, test.expected, id, err)
+		}
+	}
+	for _, selector := range []string{"", "ab", "3", "ff"} {
+		if _, err := SelectSmesherId(ids, selector); err == nil {
+			t.Fatalf("%q: expected an error", selector)
+		}
+	}
+	if id, err := SelectSmesherId(ids[:1], ""); err != nil || !bytes.Equal(id, first) {
+		t.Fatalf("expected the only id, got %x %v", id, err)
+	}
+	if _, err := SelectSmesherId(nil, ""); err == nil {
+		t.Fatal("expected an error without ids")
+	}
+}
@@ -0,0 +1,37 @@
+package common
+
+import "bytes"
+
+// StateHash is the global state hash a server reports and the layer it was computed at
+type StateHash struct {
+	Server string
+	Layer  uint32
+	Hash   []byte
+}
+
+// StateComparison is the outcome of comparing the global state hashes of two servers
+type StateComparison int
+
+const (
+	// StateMatch is two servers reporting the same hash at the same layer
+	StateMatch StateComparison = iota
+	// StateMismatch is two servers reporting different hashes at the same layer, so one of them
+	// is forked or corrupted
+	StateMismatch
+	// StateLayersDiffer is two servers reporting hashes at different layers, which can't be
+	// compared, usually because one of them lags behind
+	StateLayersDiffer
+)
+
+// CompareStateHash compares the global state hashes of two servers, which are comparable only at
+// the same layer
+func CompareStateHash(a, b StateHash) StateComparison {
+	switch {
+	case a.Layer != b.Layer:
+		return StateLayersDiffer
+	case bytes.Equal(a.Hash, b.Hash):
+		return StateMatch
+	default:
+		return StateMismatch
+	}
+}
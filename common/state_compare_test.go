@@ -0,0 +1,21 @@
+package common
+
+import "testing"
+
+func TestCompareStateHash(t *testing.T) {
+	local := StateHash{Server: "localhost:9092", Layer: 100, Hash: []byte{1, 2, 3}}
+	tests := []struct {
+		name  string
+		other StateHash
+		want  StateComparison
+	}{
+		{"same hash", StateHash{Server: "b:9092", Layer: 100, Hash: []byte{1, 2, 3}}, StateMatch},
+		{"different hash", StateHash{Server: "b:9092", Layer: 100, Hash: []byte{1, 2, 4}}, StateMismatch},
+		{"lagging", StateHash{Server: "b:9092", Layer: 99, Hash: []byte{1, 2, 4}}, StateLayersDiffer},
+	}
+	for _, tt := range tests {
+		if got := CompareStateHash(local, tt.other); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"bytes"
+	"time"
+)
+
+// DefaultStateHistorySize is the number of observations a StateHistory keeps when Max isn't set
+const DefaultStateHistorySize = 500
+
+// StateObservation is a global state hash seen for a layer
+type StateObservation struct {
+	Layer uint32
+	Hash  []byte
+	Time  time.Time
+	// Previous is the hash seen for the layer before, set only when it's another hash
+	Previous []byte
+}
+
+// Changed tells whether another hash was seen for the layer before
+func (o *StateObservation) Changed() bool {
+	return o.Previous != nil
+}
+
+// StateHistory is the global state hashes observed during a session, oldest first. The oldest
+// observations are dropped beyond Max. The zero value is an empty history.
+type StateHistory struct {
+	Max     int
+	entries []StateObservation
+}
+
+// Record adds the hash seen for a layer and returns the observation, which tells whether another
+// hash was seen for the layer before. Seeing the hash of the latest observation again isn't
+// recorded and returns nil.
+func (h *StateHistory) Record(layer uint32, hash []byte, t time.Time) *StateObservation {
+	if n := len(h.entries); n > 0 && h.entries[n-1].Layer == layer && bytes.Equal(h.entries[n-1].Hash, hash) {
+		return nil
+	}
+	o := StateObservation{Layer: layer, Hash: hash, Time: t}
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		if h.entries[i].Layer == layer {
+			if !bytes.Equal(h.entries[i].Hash, hash) {
+				o.Previous = h.entries[i].Hash
+			}
+			break
+		}
+	}
+	max := h.Max
+	if max <= 0 {
+		max = DefaultStateHistorySize
+	}
+	if len(h.entries) >= max {
+		h.entries = append(h.entries[:0], h.entries[len(h.entries)-max+1:]...)
+	}
+	h.entries = append(h.entries, o)
+	return &h.entries[len(h.entries)-1]
+}
+
+// Recent returns the last n observations, oldest first, or all of them when n isn't positive
+func (h *StateHistory) Recent(n int) []StateObservation {
+	if n <= 0 || n > len(h.entries) {
+		n = len(h.entries)
+	}
+	return h.entries[len(h.entries)-n:]
+}
+
+// Changes returns the number of observations whose layer had another hash before
+func (h *StateHistory) Changes() int {
+	count := 0
+	for i := range h.entries {
+		if h.entries[i].Changed() {
+			count++
+		}
+	}
+	return count
+}
+
+// Clear forgets all observations
+func (h *StateHistory) Clear() {
+	h.entries = nil
+}
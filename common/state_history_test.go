@@ -0,0 +1,43 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStateHistory(t *testing.T) {
+	h := StateHistory{Max: 3}
+	start := time.Now()
+	if o := h.Record(1, []byte{1}, start); o == nil || o.Changed() {
+		t.Fatalf("expected a new observation, got %+v", o)
+	}
+	if o := h.Record(1, []byte{1}, start.Add(time.Second)); o != nil {
+		t.Fatalf("expected the same hash again not to be recorded, got %+v", o)
+	}
+	h.Record(2, []byte{2}, start.Add(2*time.Second))
+	if o := h.Record(1, []byte{9}, start.Add(3*time.Second)); o == nil || !o.Changed() || o.Previous[0] != 1 {
+		t.Fatalf("expected the changed hash of layer 1 to be flagged, got %+v", o)
+	}
+	if o := h.Record(1, []byte{9}, start.Add(4*time.Second)); o != nil {
+		t.Fatalf("expected the changed hash seen again not to be recorded, got %+v", o)
+	}
+	h.Record(3, []byte{3}, start.Add(5*time.Second))
+
+	recent := h.Recent(0)
+	if len(recent) != 3 || recent[0].Layer != 2 || recent[2].Layer != 3 {
+		t.Fatalf("expected the last 3 observations, got %+v", recent)
+	}
+	if last := h.Recent(1); len(last) != 1 || last[0].Layer != 3 {
+		t.Fatalf("expected the last observation, got %+v", last)
+	}
+	if h.Changes() != 1 {
+		t.Fatalf("expected 1 change, got %d", h.Changes())
+	}
+	h.Clear()
+	if len(h.Recent(0)) != 0 || h.Changes() != 0 {
+		t.Fatal("expected an empty history after clearing")
+	}
+	if o := h.Record(1, []byte{1}, start); o == nil || o.Changed() {
+		t.Fatalf("expected a cleared history to forget the layer's hashes, got %+v", o)
+	}
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	"context"
+	"time"
+)
+
+// SubmitProgress is an event of the submission of a transaction, which is retried when the node is
+// unavailable
+type SubmitProgress struct {
+	// Attempt is the number of the attempt, from 1
+	Attempt int
+	// Err is the transient error the attempt failed with. It's nil when the attempt got the
+	// transaction to the node.
+	Err error
+	// RetryIn is the wait before the next attempt after a failed one
+	RetryIn time.Duration
+	// Landed tells that the failed attempt reached the node after all
+	Landed bool
+}
+
+// submitProgressKey marks a context whose transaction submissions report their progress
+type submitProgressKey struct{}
+
+// WithSubmitProgress returns a context whose transaction submissions pass their retries to report
+func WithSubmitProgress(ctx context.Context, report func(SubmitProgress)) context.Context {
+	return context.WithValue(ctx, submitProgressKey{}, report)
+}
+
+// ReportSubmitProgress passes an event of a submission to the report of its context, if any
+func ReportSubmitProgress(ctx context.Context, progress SubmitProgress) {
+	if report, ok := ctx.Value(submitProgressKey{}).(func(SubmitProgress)); ok {
+		report(progress)
+	}
+}
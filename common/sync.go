@@ -0,0 +1,101 @@
+package common
+
+import "time"
+
+// Sync verdicts of a node
+const (
+	SyncVerdictSynced       = "SYNCED"
+	SyncVerdictSyncing      = "SYNCING"
+	SyncVerdictNotConnected = "NOT CONNECTED"
+)
+
+// SyncSample is the sync state a node reported at a time
+type SyncSample struct {
+	Time     time.Time
+	IsSynced bool
+	Synced   uint32
+	Top      uint32
+	Verified uint32
+	Peers    uint64
+}
+
+// SyncReport is how far a node is behind the network
+type SyncReport struct {
+	Verdict string
+	// SyncedPercent is the synced layer as a percentage of the top layer
+	SyncedPercent float64
+	// VerifiedPercent is the verified layer as a percentage of the synced layer
+	VerifiedPercent float64
+	// Rate is the number of layers per second the node catches up, 0 when unknown
+	Rate float64
+	// Remaining is the estimated time until the node is synced, 0 when unknown
+	Remaining time.Duration
+}
+
+// percent returns part as a percentage of whole, 100 for an empty whole
+func percent(part, whole uint32) float64 {
+	if whole == 0 || part >= whole {
+		return 100
+	}
+	return float64(part) * 100 / float64(whole)
+}
+
+// SyncProgress reports the sync state of the later sample. The catch up rate and the remaining time
+// are estimated from how much the gap between the synced and the top layer shrank between the
+// samples, so they are unknown when first is nil or the gap didn't shrink.
+func SyncProgress(first *SyncSample, second SyncSample) SyncReport {
+	report := SyncReport{
+		Verdict:         SyncVerdictSyncing,
+		SyncedPercent:   percent(second.Synced, second.Top),
+		VerifiedPercent: percent(second.Verified, second.Synced),
+	}
+	switch {
+	case second.Peers == 0:
+		report.Verdict = SyncVerdictNotConnected
+	case second.IsSynced:
+		report.Verdict = SyncVerdictSynced
+	}
+	if first == nil || second.IsSynced {
+		return report
+	}
+	gap := func(s SyncSample) int64 { return int64(s.Top) - int64(s.Synced) }
+	elapsed := second.Time.Sub(first.Time).Seconds()
+	if shrunk := gap(*first) - gap(second); elapsed > 0 && shrunk > 0 {
+		report.Rate = float64(shrunk) / elapsed
+		report.Remaining = time.Duration(float64(gap(second)) / report.Rate * float64(time.Second)).Round(time.Second)
+	}
+	return report
+}
+
+// SyncChange is how the status a node reports changed from one sample to the next
+type SyncChange int
+
+const (
+	// SyncUnchanged is no change of the sync state, peer count, top or verified layer
+	SyncUnchanged SyncChange = iota
+	// SyncChanged is a change which is neither up nor down, such as a new top layer
+	SyncChanged
+	// SyncUp is the node becoming synced or finding peers after it had none
+	SyncUp
+	// SyncDown is the node falling out of sync or losing all its peers
+	SyncDown
+)
+
+// CompareSyncSamples tells how the status of a node changed from previous, nil for the first
+// sample, to next
+func CompareSyncSamples(previous *SyncSample, next SyncSample) SyncChange {
+	if previous == nil {
+		return SyncChanged
+	}
+	switch {
+	case previous.IsSynced && !next.IsSynced, previous.Peers > 0 && next.Peers == 0:
+		return SyncDown
+	case !previous.IsSynced && next.IsSynced, previous.Peers == 0 && next.Peers > 0:
+		return SyncUp
+	case previous.Peers != next.Peers, previous.Top != next.Top, previous.Verified != next.Verified,
+		previous.Synced != next.Synced:
+		return SyncChanged
+	default:
+		return SyncUnchanged
+	}
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSyncProgress(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	first := SyncSample{Time: start, Synced: 100, Top: 1000, Verified: 90, Peers: 5}
+	second := SyncSample{Time: start.Add(10 * time.Second), Synced: 201, Top: 1001, Verified: 180, Peers: 5}
+
+	report := SyncProgress(&first, second)
+	if report.Verdict != SyncVerdictSyncing {
+		t.Fatalf("expected %s, got %s", SyncVerdictSyncing, report.Verdict)
+	}
+	if report.SyncedPercent < 20.07 || report.SyncedPercent > 20.09 {
+		t.Fatalf("unexpected synced percentage %f", report.SyncedPercent)
+	}
+	if report.Rate != 10 || report.Remaining != 80*time.Second {
+		t.Fatalf("expected 10 layers per second and 80s remaining, got %f and %v", report.Rate, report.Remaining)
+	}
+
+	if report := SyncProgress(nil, second); report.Rate != 0 || report.Remaining != 0 {
+		t.Fatalf("expected no estimate from one sample, got %+v", report)
+	}
+	stalled := second
+	stalled.Time = second.Time.Add(10 * time.Second)
+	stalled.Top++
+	if report := SyncProgress(&second, stalled); report.Remaining != 0 {
+		t.Fatalf("expected no estimate when the gap grows, got %+v", report)
+	}
+
+	synced := SyncSample{IsSynced: true, Synced: 1000, Top: 1000, Verified: 999, Peers: 3}
+	if report := SyncProgress(nil, synced); report.Verdict != SyncVerdictSynced || report.SyncedPercent != 100 {
+		t.Fatalf("unexpected report for a synced node: %+v", report)
+	}
+	if report := SyncProgress(nil, SyncSample{IsSynced: true}); report.Verdict != SyncVerdictNotConnected {
+		t.Fatalf("expected %s without peers, got %s", SyncVerdictNotConnected, report.Verdict)
+	}
+}
+
+func TestCompareSyncSamples(t *testing.T) {
+	synced := SyncSample{IsSynced: true, Synced: 1000, Top: 1000, Verified: 999, Peers: 3}
+	syncing := SyncSample{Synced: 900, Top: 1000, Verified: 899, Peers: 3}
+	noPeers := SyncSample{Synced: 900, Top: 1000, Verified: 899}
+	nextLayer := synced
+	nextLayer.Synced, nextLayer.Top = 1001, 1001
+
+	for _, test := range []struct {
+		name           string
+		previous       *SyncSample
+		next           SyncSample
+		expectedChange SyncChange
+	}{
+		{"first sample", nil, synced, SyncChanged},
+		{"same status", &synced, synced, SyncUnchanged},
+		{"new layer", &synced, nextLayer, SyncChanged},
+		{"lost sync", &synced, syncing, SyncDown},
+		{"synced", &syncing, synced, SyncUp},
+		{"lost peers", &syncing, noPeers, SyncDown},
+		{"found peers", &noPeers, syncing, SyncUp},
+	} {
+		if change := CompareSyncSamples(test.previous, test.next); change != test.expectedChange {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expectedChange, change)
+		}
+	}
+}
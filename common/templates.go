@@ -0,0 +1,103 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// TemplatesFileName is the name of the transaction templates file in the wallets directory
+const TemplatesFileName = "smrepl_templates.json"
+
+// TxTemplate is a named transaction which is sent repeatedly, e.g. a monthly payment. The
+// recipient is stored as an address so the template keeps working when the contact is deleted.
+type TxTemplate struct {
+	Name      string `json:"name"`
+	Recipient string `json:"recipient"`
+	// Amount is in Smidge
+	Amount   uint64 `json:"amount"`
+	GasPrice uint64 `json:"gasPrice"`
+	GasLimit uint64 `json:"gasLimit"`
+	Note     string `json:"note,omitempty"`
+}
+
+// RecipientAddress returns the address of the template recipient
+func (t *TxTemplate) RecipientAddress() gosmtypes.Address {
+	return gosmtypes.HexToAddress(t.Recipient)
+}
+
+// TemplateBook holds the transaction templates. It is stored in its own file and not in the wallet.
+type TemplateBook struct {
+	path      string
+	Templates []TxTemplate `json:"templates"`
+}
+
+// LoadTemplateBook reads a templates file. A missing file results in an empty template book.
+func LoadTemplateBook(path string) (*TemplateBook, error) {
+	book := &TemplateBook{path: path, Templates: []TxTemplate{}}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return book, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, book); err != nil {
+		return nil, fmt.Errorf("failed to parse transaction templates %s: %v", path, err)
+	}
+	return book, nil
+}
+
+// Save writes the template book to its file
+func (b *TemplateBook) Save() error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WritePrivateFile(b.path, data)
+}
+
+// Add adds a template. Names must be unique.
+func (b *TemplateBook) Add(t TxTemplate) error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return errors.New("template name can not be blank")
+	}
+	if _, ok := b.Lookup(t.Name); ok {
+		return fmt.Errorf("a template named %s already exists", t.Name)
+	}
+	if t.Recipient == "" {
+		return errors.New("template recipient can not be blank")
+	}
+	b.Templates = append(b.Templates, t)
+	sort.Slice(b.Templates, func(i, j int) bool { return b.Templates[i].Name < b.Templates[j].Name })
+	return nil
+}
+
+// Delete removes a template
+func (b *TemplateBook) Delete(name string) error {
+	for i, t := range b.Templates {
+		if t.Name == name {
+			b.Templates = append(b.Templates[:i], b.Templates[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no template named %s", name)
+}
+
+// Lookup returns a template by name
+func (b *TemplateBook) Lookup(name string) (*TxTemplate, bool) {
+	for i := range b.Templates {
+		if b.Templates[i].Name == name {
+			t := b.Templates[i]
+			return &t, true
+		}
+	}
+	return nil, false
+}
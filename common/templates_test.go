@@ -0,0 +1,59 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func TestTemplateBook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, TemplatesFileName)
+
+	book, err := LoadTemplateBook(path)
+	if err != nil || len(book.Templates) != 0 {
+		t.Fatalf("expected an empty book for a missing file: %v %+v", err, book)
+	}
+	rent := TxTemplate{Name: " rent ", Recipient: "0x7fa75881ca0050028b32f424f860e3a73d4bf168", Amount: 2500000000000, GasPrice: 2, GasLimit: 100, Note: "monthly rent"}
+	if err := book.Add(rent); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.Add(TxTemplate{Name: "rent", Recipient: rent.Recipient}); err == nil {
+		t.Fatal("expected an error for a duplicate name")
+	}
+	if err := book.Add(TxTemplate{Name: " ", Recipient: rent.Recipient}); err == nil {
+		t.Fatal("expected an error for a blank name")
+	}
+	if err := book.Add(TxTemplate{Name: "gym", Recipient: "0x0000000000000000000000000000000000000001", Amount: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadTemplateBook(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Templates) != 2 || loaded.Templates[0].Name != "gym" {
+		t.Fatalf("expected the templates sorted by name, got %+v", loaded.Templates)
+	}
+	got, ok := loaded.Lookup("rent")
+	if !ok || got.Amount != rent.Amount || got.GasPrice != 2 || got.GasLimit != 100 || got.Note != "monthly rent" ||
+		got.RecipientAddress() != gosmtypes.HexToAddress(rent.Recipient) {
+		t.Fatalf("unexpected template: %+v", got)
+	}
+	if err := loaded.Delete("rent"); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Delete("rent"); err == nil {
+		t.Fatal("expected an error deleting a missing template")
+	}
+}
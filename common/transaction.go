@@ -11,8 +11,44 @@ type InnerSerializableSignedTransaction struct {
 	Amount       uint64
 }
 
+// TxRequest returns the payment fields of the transaction
+func (t InnerSerializableSignedTransaction) TxRequest() TxRequest {
+	return TxRequest{Recipient: t.Recipient, Amount: t.Amount, GasPrice: t.Price, GasLimit: t.GasLimit, Nonce: t.AccountNonce}
+}
+
 // Once we support signed txs we should replace SerializableTransaction with this struct. Currently it is only used in the rpc server.
 type SerializableSignedTransaction struct {
 	InnerSerializableSignedTransaction
 	Signature [64]byte
 }
+
+// SerializableMultisigSignature is the signature of a multisig transaction by one participant
+type SerializableMultisigSignature struct {
+	Signer    [32]byte
+	Signature [64]byte
+}
+
+// SerializableMultisigTransaction is a transaction of an m-of-n account with the signatures of the
+// participants. It records the signatures collected offline, no node accepts it.
+type SerializableMultisigTransaction struct {
+	InnerSerializableSignedTransaction
+	Threshold  uint32
+	Signatures []SerializableMultisigSignature
+}
+
+// SignedTransfer is a signed coin transaction with the buffers it was made from, so the bytes that
+// were signed and the bytes that are submitted can be checked independently
+type SignedTransfer struct {
+	TxRequest
+	// Format is the TxCodec format of the transaction
+	Format string
+	// Sender is the address of the signing key
+	Sender types.Address
+	// Unsigned is the message that is signed: the serialized InnerSerializableSignedTransaction of
+	// a legacy transaction, the genesis id and the unsigned spend of a genvm transaction
+	Unsigned  []byte
+	Signature []byte
+	// Signed is the serialized transaction with its signature which is submitted to the node
+	Signed []byte
+	ID     []byte
+}
@@ -0,0 +1,112 @@
+package common
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// Transaction formats of the tx-format setting
+const (
+	// TxFormatAuto uses the format of the connected node
+	TxFormatAuto = "auto"
+	// TxFormatLegacy is the XDR encoding of nodes before v1.0, signed with ed25519.Sign2 so the
+	// node extracts the public key of the sender from the signature
+	TxFormatLegacy = "legacy"
+	// TxFormatGenvm is the SCALE encoding of the spend transactions of single-signature wallets of
+	// nodes from v1.0, signed with ed25519 over the genesis id and the transaction
+	TxFormatGenvm = "genvm"
+)
+
+// genvmNodeVersion is the first node version which only accepts TxFormatGenvm transactions
+const genvmNodeVersion = "v1.0.0"
+
+// ErrTxFormatUnknown is returned by NewTxCodec when the tx-format setting is auto and the version of
+// the node isn't known, e.g. when signing offline. Signing in the wrong format would produce a
+// transaction the node rejects.
+var ErrTxFormatUnknown = errors.New("the node version isn't known, so the transaction format can't be picked: set it with config set tx-format legacy or config set tx-format genvm")
+
+// GenesisIDLength is the length in bytes of the genesis id signed with TxFormatGenvm transactions
+const GenesisIDLength = 20
+
+// TxCodec serializes, signs and decodes single-signature coin transactions in the wire format of a
+// node generation
+type TxCodec interface {
+	// Format returns TxFormatLegacy or TxFormatGenvm
+	Format() string
+	// SignTransfer serializes a transfer and signs it with the key
+	SignTransfer(req TxRequest, key *SigningKey) (*SignedTransfer, error)
+	// Decode strictly decodes a signed transaction. Errors name the field that can't be decoded.
+	Decode(data []byte) (*DecodedTransfer, error)
+	// ID returns the id the node gives a signed transaction
+	ID(signed []byte) []byte
+}
+
+// DecodedTransfer is a signed coin transaction decoded by a TxCodec
+type DecodedTransfer struct {
+	TxRequest
+	Format string
+	// Sender is the address of the account which signed the transaction
+	Sender    types.Address
+	Signature []byte
+}
+
+// NewTxCodec returns the codec of a tx-format setting. With TxFormatAuto the format is the one of
+// the node version, and ErrTxFormatUnknown is returned when the version is unknown, such as when
+// signing offline. The genesis id is only needed to sign TxFormatGenvm transactions and may be nil
+// otherwise.
+func NewTxCodec(format, nodeVersion string, genesisID []byte) (TxCodec, error) {
+	if format == "" || format == TxFormatAuto {
+		c, ok := CompareVersions(nodeVersion, genvmNodeVersion)
+		if !ok {
+			return nil, ErrTxFormatUnknown
+		}
+		format = TxFormatLegacy
+		if c >= 0 {
+			format = TxFormatGenvm
+		}
+	}
+	switch format {
+	case TxFormatLegacy:
+		return legacyCodec{}, nil
+	case TxFormatGenvm:
+		if genesisID != nil && len(genesisID) != GenesisIDLength {
+			return nil, fmt.Errorf("invalid genesis id: expected %d bytes, got %d", GenesisIDLength, len(genesisID))
+		}
+		return genvmCodec{genesisID: genesisID}, nil
+	}
+	return nil, fmt.Errorf("unknown transaction format %s", format)
+}
+
+// DecodeSignedTransfer decodes a signed transaction of either format. Legacy transactions have a
+// fixed size which is below the smallest genvm spend, so the size tells the formats apart.
+func DecodeSignedTransfer(data []byte) (*DecodedTransfer, error) {
+	if len(data) == legacySignedTxLen {
+		return legacyCodec{}.Decode(data)
+	}
+	return genvmCodec{}.Decode(data)
+}
+
+// TxID returns the id of a signed transaction of a format. An empty format is TxFormatLegacy, the
+// format of signed batches written before formats were recorded.
+func TxID(format string, signed []byte) ([]byte, error) {
+	switch format {
+	case "", TxFormatLegacy:
+		return legacyCodec{}.ID(signed), nil
+	case TxFormatGenvm:
+		return genvmCodec{}.ID(signed), nil
+	}
+	return nil, fmt.Errorf("unknown transaction format %s", format)
+}
+
+// ParseGenesisID parses the hex genesis id of a network, with an optional 0x prefix
+func ParseGenesisID(s string) ([]byte, error) {
+	id, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+	if err != nil || len(id) != GenesisIDLength {
+		return nil, fmt.Errorf("the genesis id must be %d hex characters", GenesisIDLength*2)
+	}
+	return id, nil
+}
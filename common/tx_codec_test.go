@@ -0,0 +1,285 @@
+package common
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/spacemeshos/ed25519"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/zeebo/blake3"
+)
+
+// The golden transactions are transfers to goldenRecipient signed with the key of seed 0x01 * 32.
+// A change of their bytes means the encoding or the signature scheme changed, and the nodes of the
+// generation would no longer accept the transactions.
+const (
+	goldenRecipient = "0x7fa75881ca0050028b32f424f860e3a73d4bf168"
+
+	// legacy transfer of 2500000000000 with nonce 7, gas limit 100 and gas price 1, accepted by
+	// nodes before v1.0
+	goldenLegacyTx = "0000000000000007" + // nonce
+		"7fa75881ca0050028b32f424f860e3a73d4bf168" + // recipient
+		"0000000000000064" + // gas limit
+		"0000000000000001" + // gas price
+		"00000246139ca800" // amount
+	goldenLegacySignature = "f2184284b02aab3deac4419b7e210fc9b590407397ade22d8181d25339404824" +
+		"c4427bd71b5ed6a3536044a3e0905d273b79f44b5d07aa1326f867e277e8d908"
+
+	// genesis id of a network of nodes from v1.0, and the principal of the key on it: the last 20
+	// bytes of blake3(wallet template 0x00..01 || public key)
+	goldenGenesisID = "4ab8dd4df9abba836365d3cdda324c3bcde59ee8"
+	goldenPrincipal = "0xf7dad779faac3ca12775381302ad1104ed8cf5ef"
+)
+
+// goldenGenvmTxs are spends of the genvm wallet template. They weren't captured from a node: the
+// transactions are laid out field by field from the spend of the go-spacemesh v1.0 wallet SDK,
+// with the SCALE compact integers worked out by hand (n < 64 is n<<2, n < 2^14 is n<<2|1 little
+// endian, n < 2^30 is n<<2|2 little endian, larger n is a byte (bytes-4)<<2|3 and the bytes). The
+// test checks the signatures with ed25519 and the ids with zeebo/blake3 rather than with the
+// codec. Transactions of a v1 node should replace them once they can be collected.
+var goldenGenvmTxs = []struct {
+	nonce, gasPrice, amount uint64
+	tx, signature, id       string
+}{
+	{
+		nonce: 7, gasPrice: 1, amount: 2500000000000,
+		tx: "00" + // version
+			"00000000f7dad779faac3ca12775381302ad1104ed8cf5ef" + // principal
+			"40" + // method: 16<<2
+			"1c" + // nonce: 7<<2
+			"04" + // gas price: 1<<2
+			"000000007fa75881ca0050028b32f424f860e3a73d4bf168" + // recipient
+			"0b00a89c134602", // amount: 6 bytes, (6-4)<<2|3, then 2500000000000 little endian
+		signature: "4e2c97dec1b07cf084e50f98b37c8e28ac58122131fad9aea83fc8d9b54fc8e4" +
+			"9b8f1447868bf4b94d083126451a39851d7f863e8216cab36041e326309b700d",
+		id: "b735db4bb713bc11b3a7f199fd5388c4119b5e6f597f723b3f64f5e0d1ac1b61",
+	},
+	{
+		nonce: 0, gasPrice: 0, amount: 0,
+		tx: "00" +
+			"00000000f7dad779faac3ca12775381302ad1104ed8cf5ef" +
+			"40" +
+			"00" +
+			"00" +
+			"000000007fa75881ca0050028b32f424f860e3a73d4bf168" +
+			"00",
+		signature: "3cf87ec4fe305f845e9b35266c32f7182bfe831cf19a5ad0f918487a937aa29f" +
+			"49eafc87ac6a5ccf931c62d6e5a26c2a234327edee84fee42731656d79cf010c",
+		id: "9dcaad8251be6d72d1ea98f750381e2dd16f9e614f8f20f56d777f0e31912a8e",
+	},
+	{
+		nonce: 1 << 20, gasPrice: 1 << 40, amount: ^uint64(0),
+		tx: "00" +
+			"00000000f7dad779faac3ca12775381302ad1104ed8cf5ef" +
+			"40" +
+			"02004000" + // nonce: 2^20<<2|2 little endian
+			"0b000000000001" + // gas price: 6 bytes, 2^40 little endian
+			"000000007fa75881ca0050028b32f424f860e3a73d4bf168" +
+			"13ffffffffffffffff", // amount: 8 bytes, (8-4)<<2|3
+		signature: "7871150587d6b010d3472f32a883fd38aeb3a3b2c647a3143bc1cca22550295f" +
+			"17832a6569831f85f31454694f3780ad71ccc1cdca8c00d0807babd0a11c9a03",
+		id: "b34fdedcace2a9d9d395d12ae4687dd84c21e2c5788e5de3d7c49fdbc2ce5008",
+	},
+}
+
+func goldenKey(t *testing.T) *SigningKey {
+	t.Helper()
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	acc := &LocalAccount{Name: "golden", PubKey: priv.Public().(ed25519.PublicKey), PrivKey: priv}
+	key, err := acc.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func goldenGenesis(t *testing.T) []byte {
+	t.Helper()
+	id, err := ParseGenesisID("0x" + goldenGenesisID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestLegacyCodecGolden(t *testing.T) {
+	key := goldenKey(t)
+	defer key.Release()
+	codec, err := NewTxCodec(TxFormatLegacy, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := TxRequest{Recipient: types.HexToAddress(goldenRecipient), Amount: 2500000000000, GasPrice: 1, GasLimit: 100, Nonce: 7}
+	tx, err := codec.SignTransfer(req, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed := hex.EncodeToString(tx.Signed); signed != goldenLegacyTx+goldenLegacySignature {
+		t.Fatalf("expected signed bytes %s, got %s", goldenLegacyTx+goldenLegacySignature, signed)
+	}
+	if unsigned := hex.EncodeToString(tx.Unsigned); unsigned != goldenLegacyTx {
+		t.Fatalf("expected unsigned bytes %s, got %s", goldenLegacyTx, unsigned)
+	}
+	if tx.Format != TxFormatLegacy {
+		t.Fatalf("expected format %s, got %s", TxFormatLegacy, tx.Format)
+	}
+
+	decoded, err := DecodeSignedTransfer(tx.Signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Format != TxFormatLegacy || decoded.TxRequest != req {
+		t.Fatalf("expected legacy transaction %+v, got %s transaction %+v", req, decoded.Format, decoded.TxRequest)
+	}
+	if decoded.Sender != tx.Sender {
+		t.Fatalf("expected sender %s, got %s", tx.Sender.Hex(), decoded.Sender.Hex())
+	}
+	id, err := TxID(TxFormatLegacy, tx.Signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(id, tx.ID) {
+		t.Fatalf("expected id %x, got %x", tx.ID, id)
+	}
+}
+
+func TestGenvmCodecGolden(t *testing.T) {
+	key := goldenKey(t)
+	defer key.Release()
+	template := make([]byte, genvmAddressLength)
+	template[len(template)-1] = 1
+	if hash := blake3.Sum256(append(template, key.PublicKey()...)); "0x"+hex.EncodeToString(hash[12:]) != goldenPrincipal {
+		t.Fatalf("expected the principal of the fixture to be the end of the blake3 hash %x", hash)
+	}
+	if principal := GenvmPrincipal(key.PublicKey()); principal != types.HexToAddress(goldenPrincipal) {
+		t.Fatalf("expected principal %s, got %s", goldenPrincipal, principal.Hex())
+	}
+	codec, err := NewTxCodec(TxFormatGenvm, "", goldenGenesis(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, golden := range goldenGenvmTxs {
+		fixture, err := hex.DecodeString(golden.tx + golden.signature)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unsigned := fixture[:len(fixture)-ed25519.SignatureSize]
+		if !ed25519.Verify(key.PublicKey(), append(goldenGenesis(t), unsigned...), fixture[len(unsigned):]) {
+			t.Fatalf("nonce %d: expected the signature of the fixture to sign the genesis id and the transaction", golden.nonce)
+		}
+		if id := blake3.Sum256(fixture); hex.EncodeToString(id[:]) != golden.id {
+			t.Fatalf("nonce %d: expected the id of the fixture to be its blake3 hash %x", golden.nonce, id)
+		}
+
+		req := TxRequest{Recipient: types.HexToAddress(goldenRecipient), Amount: golden.amount, GasPrice: golden.gasPrice, GasLimit: 100, Nonce: golden.nonce}
+		tx, err := codec.SignTransfer(req, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if signed := hex.EncodeToString(tx.Signed); signed != golden.tx+golden.signature {
+			t.Fatalf("nonce %d: expected signed bytes %s, got %s", golden.nonce, golden.tx+golden.signature, signed)
+		}
+		if id := hex.EncodeToString(tx.ID); id != golden.id {
+			t.Fatalf("nonce %d: expected id %s, got %s", golden.nonce, golden.id, id)
+		}
+		if unsigned := hex.EncodeToString(tx.Unsigned); unsigned != goldenGenesisID+golden.tx {
+			t.Fatalf("nonce %d: expected the genesis id and the transaction to be signed, got %s", golden.nonce, unsigned)
+		}
+		if !ed25519.Verify(key.PublicKey(), tx.Unsigned, tx.Signature) {
+			t.Fatalf("nonce %d: expected the signature to verify against the public key", golden.nonce)
+		}
+		if tx.GasLimit != 0 {
+			t.Fatalf("nonce %d: expected no gas limit, got %d", golden.nonce, tx.GasLimit)
+		}
+
+		decoded, err := DecodeSignedTransfer(tx.Signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.GasLimit = 0
+		if decoded.Format != TxFormatGenvm || decoded.TxRequest != req {
+			t.Fatalf("nonce %d: expected genvm transaction %+v, got %s transaction %+v", golden.nonce, req, decoded.Format, decoded.TxRequest)
+		}
+		if decoded.Sender != types.HexToAddress(goldenPrincipal) {
+			t.Fatalf("nonce %d: expected sender %s, got %s", golden.nonce, goldenPrincipal, decoded.Sender.Hex())
+		}
+		id, err := TxID(TxFormatGenvm, tx.Signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(id) != golden.id {
+			t.Fatalf("nonce %d: expected id %s, got %x", golden.nonce, golden.id, id)
+		}
+	}
+}
+
+func TestGenvmCodecNeedsGenesisID(t *testing.T) {
+	key := goldenKey(t)
+	defer key.Release()
+	codec, err := NewTxCodec(TxFormatGenvm, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := codec.SignTransfer(TxRequest{Recipient: types.HexToAddress(goldenRecipient)}, key); err == nil {
+		t.Fatal("expected an error signing without the genesis id")
+	}
+	if _, err := NewTxCodec(TxFormatGenvm, "", []byte{1, 2, 3}); err == nil {
+		t.Fatal("expected an error for a short genesis id")
+	}
+}
+
+func TestNewTxCodecFormat(t *testing.T) {
+	for _, c := range []struct {
+		format, nodeVersion, expected string
+	}{
+		{"", "v0.1.17", TxFormatLegacy},
+		{TxFormatAuto, "v0.1.17", TxFormatLegacy},
+		{TxFormatAuto, "v1.0.0", TxFormatGenvm},
+		{TxFormatAuto, "v1.2.3-rc1", TxFormatGenvm},
+		{TxFormatLegacy, "v1.0.0", TxFormatLegacy},
+		{TxFormatGenvm, "", TxFormatGenvm},
+	} {
+		codec, err := NewTxCodec(c.format, c.nodeVersion, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if codec.Format() != c.expected {
+			t.Fatalf("%q with node %q: expected format %s, got %s", c.format, c.nodeVersion, c.expected, codec.Format())
+		}
+	}
+	if _, err := NewTxCodec("scale", "", nil); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+	for _, format := range []string{"", TxFormatAuto} {
+		if _, err := NewTxCodec(format, "", nil); err != ErrTxFormatUnknown {
+			t.Fatalf("%q without a node version: expected the format to be asked for, got %v", format, err)
+		}
+	}
+}
+
+func TestDecodeSignedTransferInvalid(t *testing.T) {
+	golden := goldenGenvmTxs[0]
+	for name, data := range map[string]string{
+		"truncated signature":   golden.tx + golden.signature[:100],
+		"trailing bytes":        golden.tx + golden.signature + "00",
+		"reserved address byte": "00" + "01000000f7dad779faac3ca12775381302ad1104ed8cf5ef" + golden.tx[50:] + golden.signature,
+		"not a spend":           golden.tx[:50] + "00" + golden.tx[52:] + golden.signature,
+		"non-minimal nonce":     golden.tx[:52] + "1d00" + golden.tx[54:] + golden.signature,
+	} {
+		b, err := hex.DecodeString(data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, err := DecodeSignedTransfer(b); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestTxIDUnknownFormat(t *testing.T) {
+	if _, err := TxID("scale", []byte{1}); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,133 @@
+package common
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// Directions of a transaction relative to an account
+const (
+	DirectionIn   = "in"
+	DirectionOut  = "out"
+	DirectionSelf = "self"
+)
+
+// Types of transactions
+const (
+	TxTypeCoin     = "coin"
+	TxTypeContract = "contract"
+	TxTypeUnknown  = "unknown"
+)
+
+// TxRecord is an exported transaction of an account. Amounts are in Smidge and exported as strings
+// so no tool reads them as floats. Layer and Time are empty when the node has no receipt for the
+// transaction. Recipient is empty for transactions other than coin transfers. Fee is the fee paid when the transaction has been applied, otherwise the maximum fee,
+// as told by FeeKind.
+type TxRecord struct {
+	ID        string  `json:"id"`
+	Layer     *uint32 `json:"layer"`
+	Time      string  `json:"time,omitempty"`
+	Sender    string  `json:"sender"`
+	Recipient string  `json:"recipient"`
+	Amount    uint64  `json:"amount,string"`
+	Fee       uint64  `json:"fee,string"`
+	FeeKind   string  `json:"feeKind"`
+	Nonce     uint64  `json:"nonce"`
+	Direction string  `json:"direction"`
+	Type      string  `json:"type"`
+}
+
+// TxDirection returns the direction of a transaction relative to an account
+func TxDirection(account, sender, recipient gosmtypes.Address) string {
+	switch {
+	case sender == account && recipient == account:
+		return DirectionSelf
+	case sender == account:
+		return DirectionOut
+	default:
+		return DirectionIn
+	}
+}
+
+// txRecordsHeader is the header row of a transaction CSV export
+var txRecordsHeader = []string{"id", "layer", "time", "sender", "recipient", "amount", "fee", "fee_kind", "nonce", "direction", "type"}
+
+// WriteTxRecordsCSV writes transaction records as CSV with a header row
+func WriteTxRecordsCSV(w io.Writer, records []TxRecord) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(txRecordsHeader); err != nil {
+		return err
+	}
+	for _, r := range records {
+		layer := ""
+		if r.Layer != nil {
+			layer = strconv.FormatUint(uint64(*r.Layer), 10)
+		}
+		if err := cw.Write([]string{
+			r.ID,
+			layer,
+			r.Time,
+			r.Sender,
+			r.Recipient,
+			strconv.FormatUint(r.Amount, 10),
+			strconv.FormatUint(r.Fee, 10),
+			r.FeeKind,
+			strconv.FormatUint(r.Nonce, 10),
+			r.Direction,
+			r.Type,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteTxRecordsJSON writes transaction records as an indented JSON array
+func WriteTxRecordsJSON(w io.Writer, records []TxRecord) error {
+	if records == nil {
+		records = []TxRecord{}
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// TxFilter selects transaction records. The zero value matches every record.
+type TxFilter struct {
+	// In and Out select incoming and outgoing transactions. A self transaction is both. When
+	// neither is set both directions match.
+	In, Out bool
+	// FromLayer and ToLayer bound the layer of the transactions, inclusive. When one is set, records
+	// without a layer don't match.
+	FromLayer, ToLayer *uint32
+	// MinAmount is the lowest amount in Smidge that matches
+	MinAmount uint64
+}
+
+// Match tells whether a record is selected by the filter
+func (f *TxFilter) Match(r *TxRecord) bool {
+	if f.In || f.Out {
+		in := r.Direction == DirectionIn || r.Direction == DirectionSelf
+		out := r.Direction == DirectionOut || r.Direction == DirectionSelf
+		if !(f.In && in || f.Out && out) {
+			return false
+		}
+	}
+	if f.FromLayer != nil || f.ToLayer != nil {
+		if r.Layer == nil {
+			return false
+		}
+		if f.FromLayer != nil && *r.Layer < *f.FromLayer || f.ToLayer != nil && *r.Layer > *f.ToLayer {
+			return false
+		}
+	}
+	return r.Amount >= f.MinAmount
+}
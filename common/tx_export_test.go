@@ -0,0 +1,92 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func TestTxDirection(t *testing.T) {
+	a := gosmtypes.HexToAddress("0x01")
+	b := gosmtypes.HexToAddress("0x02")
+	if d := TxDirection(a, a, b); d != DirectionOut {
+		t.Fatalf("expected out, got %s", d)
+	}
+	if d := TxDirection(a, b, a); d != DirectionIn {
+		t.Fatalf("expected in, got %s", d)
+	}
+	if d := TxDirection(a, a, a); d != DirectionSelf {
+		t.Fatalf("expected self, got %s", d)
+	}
+}
+
+func TestWriteTxRecords(t *testing.T) {
+	layer := uint32(12)
+	records := []TxRecord{
+		{ID: "0xaa", Layer: &layer, Time: "2020-12-01T10:00:00Z", Sender: "0x01", Recipient: "0x02",
+			Amount: 18446744073709551615, Fee: 100, FeeKind: FeePaid, Nonce: 3, Direction: DirectionOut, Type: TxTypeCoin},
+		{ID: "0xbb", Sender: "0x02", Amount: 5, Fee: 1, FeeKind: FeeMax, Nonce: 0, Direction: DirectionIn, Type: TxTypeContract},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTxRecordsCSV(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+	expected := "id,layer,time,sender,recipient,amount,fee,fee_kind,nonce,direction,type\n" +
+		"0xaa,12,2020-12-01T10:00:00Z,0x01,0x02,18446744073709551615,100,paid,3,out,coin\n" +
+		"0xbb,,,0x02,,5,1,max,0,in,contract\n"
+	if buf.String() != expected {
+		t.Fatalf("unexpected CSV:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteTxRecordsJSON(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"amount": "18446744073709551615"`) {
+		t.Fatalf("expected the amount as a string:\n%s", buf.String())
+	}
+	var decoded []TxRecord
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded[0].Amount != records[0].Amount || *decoded[0].Layer != 12 || decoded[1].Layer != nil {
+		t.Fatalf("unexpected round trip: %+v", decoded)
+	}
+
+	buf.Reset()
+	if err := WriteTxRecordsJSON(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Fatalf("expected an empty array, got %s", buf.String())
+	}
+}
+
+func TestTxFilter(t *testing.T) {
+	l5, l10 := uint32(5), uint32(10)
+	in := TxRecord{Direction: DirectionIn, Layer: &l5, Amount: 100}
+	out := TxRecord{Direction: DirectionOut, Layer: &l10, Amount: 50}
+	self := TxRecord{Direction: DirectionSelf, Amount: 10}
+
+	match := func(f TxFilter, expected ...bool) {
+		t.Helper()
+		for i, r := range []*TxRecord{&in, &out, &self} {
+			if f.Match(r) != expected[i] {
+				t.Fatalf("filter %+v: record %d expected %v", f, i, expected[i])
+			}
+		}
+	}
+	match(TxFilter{}, true, true, true)
+	match(TxFilter{In: true}, true, false, true)
+	match(TxFilter{Out: true}, false, true, true)
+	match(TxFilter{In: true, Out: true}, true, true, true)
+	match(TxFilter{FromLayer: &l10}, false, true, false)
+	match(TxFilter{ToLayer: &l5}, true, false, false)
+	match(TxFilter{FromLayer: &l5, ToLayer: &l10}, true, true, false)
+	match(TxFilter{MinAmount: 50}, true, true, false)
+	match(TxFilter{Out: true, MinAmount: 51}, false, false, false)
+}
@@ -0,0 +1,245 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/spacemeshos/ed25519"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/zeebo/blake3"
+)
+
+// genvm transactions are SCALE encoded: a version, the principal (the address of the sending
+// account), a method and its arguments, followed by an ed25519 signature of the genesis id and the
+// transaction. Addresses are 24 bytes: 4 reserved zero bytes and the 20 bytes of a legacy address.
+const (
+	genvmTxVersion   = 0
+	genvmMethodSpend = 16
+
+	genvmAddressLength   = 24
+	genvmAddressReserved = genvmAddressLength - types.AddressLength
+)
+
+// genvmWalletTemplate is the address of the single-signature wallet template
+var genvmWalletTemplate = func() [genvmAddressLength]byte {
+	var a [genvmAddressLength]byte
+	a[len(a)-1] = 1
+	return a
+}()
+
+// GenvmPrincipal returns the address a node from v1.0 gives the single-signature wallet of a
+// public key: the last 20 bytes of the blake3 hash of the wallet template and the key
+func GenvmPrincipal(pub ed25519.PublicKey) types.Address {
+	hash := blake3Sum(genvmWalletTemplate[:], pub)
+	return types.BytesToAddress(hash)
+}
+
+// genvmCodec encodes spend transactions of single-signature wallets in TxFormatGenvm. Their id is
+// the blake3 hash of the signed bytes. There is no gas limit: the node charges the gas of the
+// method, so the GasLimit of a TxRequest is ignored and decoded as 0.
+type genvmCodec struct {
+	genesisID []byte
+}
+
+func (genvmCodec) Format() string {
+	return TxFormatGenvm
+}
+
+func (c genvmCodec) SignTransfer(req TxRequest, key *SigningKey) (*SignedTransfer, error) {
+	if len(c.genesisID) != GenesisIDLength {
+		return nil, errors.New("signing genvm transactions needs the genesis id of the network: set it with config genesis-id")
+	}
+	sender := GenvmPrincipal(key.PublicKey())
+	tx := []byte{genvmTxVersion << 2}
+	tx = appendGenvmAddress(tx, sender)
+	tx = append(tx, genvmMethodSpend<<2)
+	tx = appendCompact(tx, req.Nonce)
+	tx = appendCompact(tx, req.GasPrice)
+	tx = appendGenvmAddress(tx, req.Recipient)
+	tx = appendCompact(tx, req.Amount)
+
+	msg := append(append([]byte(nil), c.genesisID...), tx...)
+	signature := key.SignEd25519(msg)
+	if len(signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("invalid signature length %d, expected %d", len(signature), ed25519.SignatureSize)
+	}
+	signed := append(tx, signature...)
+	req.GasLimit = 0
+	return &SignedTransfer{
+		TxRequest: req,
+		Format:    TxFormatGenvm,
+		Sender:    sender,
+		Unsigned:  msg,
+		Signature: signature,
+		Signed:    signed,
+		ID:        c.ID(signed),
+	}, nil
+}
+
+// Decode decodes a spend transaction. The signature can't be checked without the public key, which
+// a genvm transaction doesn't hold, so the node is left to verify it.
+func (genvmCodec) Decode(data []byte) (*DecodedTransfer, error) {
+	d := &scaleDecoder{data: data}
+	tx := &DecodedTransfer{Format: TxFormatGenvm}
+	if version := d.compact("version"); d.err == nil && version != genvmTxVersion {
+		return nil, fmt.Errorf("invalid transaction: unsupported version %d", version)
+	}
+	tx.Sender = d.address("principal")
+	if method := d.compact("method"); d.err == nil && method != genvmMethodSpend {
+		return nil, fmt.Errorf("invalid transaction: method %d isn't a spend", method)
+	}
+	tx.Nonce = d.compact("nonce")
+	tx.GasPrice = d.compact("gas price")
+	tx.Recipient = d.address("recipient")
+	tx.Amount = d.compact("amount")
+	tx.Signature = d.bytes("signature", ed25519.SignatureSize)
+	if d.err != nil {
+		return nil, d.err
+	}
+	if rest := len(data) - d.offset; rest > 0 {
+		return nil, fmt.Errorf("invalid transaction: %d unexpected bytes after the signature", rest)
+	}
+	return tx, nil
+}
+
+func (genvmCodec) ID(signed []byte) []byte {
+	return blake3Sum(signed)
+}
+
+// blake3Sum returns the 32 bytes blake3 hash of the concatenated parts, the hash nodes from v1.0
+// use for addresses and transaction ids
+func blake3Sum(parts ...[]byte) []byte {
+	h := blake3.New()
+	for _, p := range parts {
+		_, _ = h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+func appendGenvmAddress(b []byte, address types.Address) []byte {
+	b = append(b, make([]byte, genvmAddressReserved)...)
+	return append(b, address.Bytes()...)
+}
+
+// appendCompact appends the SCALE compact encoding of an integer: values up to 2^30 take 1, 2 or 4
+// little endian bytes whose 2 low bits are the mode, larger ones a byte with the length followed
+// by the bytes of the value
+func appendCompact(b []byte, v uint64) []byte {
+	switch {
+	case v < 1<<6:
+		return append(b, byte(v<<2))
+	case v < 1<<14:
+		v = v<<2 | 0b01
+		return append(b, byte(v), byte(v>>8))
+	case v < 1<<30:
+		v = v<<2 | 0b10
+		return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+	}
+	n := 0
+	for x := v; x > 0; x >>= 8 {
+		n++
+	}
+	b = append(b, byte(n-4)<<2|0b11)
+	for i := 0; i < n; i++ {
+		b = append(b, byte(v>>(8*i)))
+	}
+	return b
+}
+
+// scaleDecoder reads the fields of a SCALE encoded value. After the first error the reads return
+// zero values and err names the field which couldn't be decoded.
+type scaleDecoder struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (d *scaleDecoder) fail(field, format string, args ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf("invalid transaction: can't decode the %s at byte %d: %s", field, d.offset, fmt.Sprintf(format, args...))
+	}
+}
+
+func (d *scaleDecoder) bytes(field string, n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.data)-d.offset < n {
+		d.fail(field, "expected %d bytes, got %d", n, len(d.data)-d.offset)
+		return nil
+	}
+	b := d.data[d.offset : d.offset+n]
+	d.offset += n
+	return b
+}
+
+func (d *scaleDecoder) address(field string) types.Address {
+	start := d.offset
+	b := d.bytes(field, genvmAddressLength)
+	if b == nil {
+		return types.Address{}
+	}
+	if !bytes.Equal(b[:genvmAddressReserved], make([]byte, genvmAddressReserved)) {
+		d.offset = start
+		d.fail(field, "the reserved bytes aren't zero")
+		return types.Address{}
+	}
+	return types.BytesToAddress(b[genvmAddressReserved:])
+}
+
+// compact reads a compact integer, rejecting values which have a shorter encoding like the node does
+func (d *scaleDecoder) compact(field string) uint64 {
+	start := d.offset
+	head := d.bytes(field, 1)
+	if head == nil {
+		return 0
+	}
+	var v uint64
+	switch head[0] & 0b11 {
+	case 0b00:
+		return uint64(head[0] >> 2)
+	case 0b01:
+		d.offset = start
+		b := d.bytes(field, 2)
+		if b == nil {
+			return 0
+		}
+		if v = (uint64(b[0]) | uint64(b[1])<<8) >> 2; v < 1<<6 {
+			d.offset = start
+			d.fail(field, "value %d is out of range", v)
+			return 0
+		}
+	case 0b10:
+		d.offset = start
+		b := d.bytes(field, 4)
+		if b == nil {
+			return 0
+		}
+		if v = (uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16 | uint64(b[3])<<24) >> 2; v < 1<<14 {
+			d.offset = start
+			d.fail(field, "value %d is out of range", v)
+			return 0
+		}
+	default:
+		n := int(head[0]>>2) + 4
+		if n > 8 {
+			d.offset = start
+			d.fail(field, "a compact integer of %d bytes overflows 64 bits", n)
+			return 0
+		}
+		b := d.bytes(field, n)
+		if b == nil {
+			return 0
+		}
+		for i := 0; i < n; i++ {
+			v |= uint64(b[i]) << (8 * i)
+		}
+		if v < 1<<30 {
+			d.offset = start
+			d.fail(field, "value %d is out of range", v)
+			return 0
+		}
+	}
+	return v
+}
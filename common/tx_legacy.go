@@ -0,0 +1,110 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	xdr "github.com/davecgh/go-xdr/xdr2"
+	"github.com/spacemeshos/ed25519"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// legacySignedTxLen is the size of a serialized SerializableSignedTransaction: five fixed-size
+// fields and the signature
+const legacySignedTxLen = 8 + types.AddressLength + 8 + 8 + 8 + ed25519.SignatureSize
+
+// legacyCodec encodes transactions in TxFormatLegacy. Their id is the sha256 of the signed bytes.
+type legacyCodec struct{}
+
+func (legacyCodec) Format() string {
+	return TxFormatLegacy
+}
+
+func (c legacyCodec) SignTransfer(req TxRequest, key *SigningKey) (*SignedTransfer, error) {
+	tx := SerializableSignedTransaction{InnerSerializableSignedTransaction: InnerSerializableSignedTransaction{
+		AccountNonce: req.Nonce,
+		Recipient:    req.Recipient,
+		GasLimit:     req.GasLimit,
+		Price:        req.GasPrice,
+		Amount:       req.Amount,
+	}}
+	buf, err := xdrBytes(&tx.InnerSerializableSignedTransaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize the transaction: %v", err)
+	}
+	// signing an empty message would produce a transaction the node rejects or, worse, accepts
+	// with different fields than the ones shown to the user
+	if len(buf) == 0 {
+		return nil, errors.New("failed to serialize the transaction: no data to sign")
+	}
+	signature := key.Sign(buf)
+	if n := copy(tx.Signature[:], signature); n != len(tx.Signature) {
+		return nil, fmt.Errorf("invalid signature length %d, expected %d", n, len(tx.Signature))
+	}
+	b, err := xdrBytes(&tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize the signed transaction: %v", err)
+	}
+	return &SignedTransfer{
+		TxRequest: req,
+		Format:    TxFormatLegacy,
+		Sender:    types.BytesToAddress(key.PublicKey()),
+		Unsigned:  buf,
+		Signature: signature,
+		Signed:    b,
+		ID:        c.ID(b),
+	}, nil
+}
+
+func (legacyCodec) Decode(data []byte) (*DecodedTransfer, error) {
+	var tx SerializableSignedTransaction
+	r := bytes.NewReader(data)
+	for _, f := range []struct {
+		name  string
+		value interface{}
+	}{
+		{"nonce", &tx.AccountNonce},
+		{"recipient", &tx.Recipient},
+		{"gas limit", &tx.GasLimit},
+		{"gas price", &tx.Price},
+		{"amount", &tx.Amount},
+		{"signature", &tx.Signature},
+	} {
+		offset := len(data) - r.Len()
+		if _, err := xdr.Unmarshal(r, f.value); err != nil {
+			return nil, fmt.Errorf("invalid transaction: can't decode the %s at byte %d: %v", f.name, offset, err)
+		}
+	}
+	if r.Len() > 0 {
+		return nil, fmt.Errorf("invalid transaction: %d unexpected bytes after the signature", r.Len())
+	}
+
+	// the signed message is the start of the transaction, which was decoded from fixed-size fields
+	msg := data[:len(data)-len(tx.Signature)]
+	pub, err := ed25519.ExtractPublicKey(msg, tx.Signature[:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction signature: %v", err)
+	}
+	return &DecodedTransfer{
+		TxRequest: tx.TxRequest(),
+		Format:    TxFormatLegacy,
+		Sender:    types.BytesToAddress(pub),
+		Signature: tx.Signature[:],
+	}, nil
+}
+
+func (legacyCodec) ID(signed []byte) []byte {
+	id := sha256.Sum256(signed)
+	return id[:]
+}
+
+// xdrBytes returns the XDR encoding of a value
+func xdrBytes(i interface{}) ([]byte, error) {
+	var w bytes.Buffer
+	if _, err := xdr.Marshal(&w, &i); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
@@ -0,0 +1,155 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// TxRequest describes a coin transaction to sign, e.g. on an offline machine
+type TxRequest struct {
+	Recipient gosmtypes.Address
+	Amount    uint64 // in Smidge
+	GasPrice  uint64
+	GasLimit  uint64
+	Nonce     uint64
+}
+
+// txRequestFile is the JSON layout of a transaction request file. All fields are required.
+type txRequestFile struct {
+	Recipient *string          `json:"recipient"`
+	Amount    *json.RawMessage `json:"amount"`
+	GasPrice  *uint64          `json:"gasprice"`
+	GasLimit  *uint64          `json:"gaslimit"`
+	Nonce     *uint64          `json:"nonce"`
+}
+
+// TxRequestExample is shown in transaction request file errors
+const TxRequestExample = `{"recipient":"0x7fa75881ca0050028b32f424f860e3a73d4bf168","amount":"2.5smh","gasprice":1,"gaslimit":100,"nonce":7}`
+
+// ParseTxRequest parses and validates a transaction request file. The amount is either a string
+// accepted by ParseAmount or a whole number of Smidge.
+func ParseTxRequest(data []byte) (*TxRequest, error) {
+	var file txRequestFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid transaction file: %v. Example: %s", err, TxRequestExample)
+	}
+
+	var missing []string
+	if file.Recipient == nil {
+		missing = append(missing, "recipient")
+	}
+	if file.Amount == nil {
+		missing = append(missing, "amount")
+	}
+	if file.GasPrice == nil {
+		missing = append(missing, "gasprice")
+	}
+	if file.GasLimit == nil {
+		missing = append(missing, "gaslimit")
+	}
+	if file.Nonce == nil {
+		missing = append(missing, "nonce")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("invalid transaction file: missing %s. Example: %s", strings.Join(missing, ", "), TxRequestExample)
+	}
+
+	recipient, err := ParseAddress(*file.Recipient)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient: %v", err)
+	}
+	amount, err := parseRawAmount(*file.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if *file.GasLimit == 0 {
+		return nil, errors.New("invalid gaslimit: must be greater than 0")
+	}
+	return &TxRequest{
+		Recipient: recipient,
+		Amount:    amount,
+		GasPrice:  *file.GasPrice,
+		GasLimit:  *file.GasLimit,
+		Nonce:     *file.Nonce,
+	}, nil
+}
+
+// parseRawAmount parses a JSON amount which is either a string or a whole number of Smidge
+func parseRawAmount(raw json.RawMessage) (uint64, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		s = string(raw)
+	}
+	amount, err := ParseAmount(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount: %v", err)
+	}
+	return amount, nil
+}
+
+// MaxGasLimit is the highest gas limit accepted by Check. A coin transaction needs far less.
+const MaxGasLimit = 1000000
+
+// Check returns the problems that would make the node reject the transaction or leave it pending:
+// an empty recipient, a gas limit out of range, a zero gas price, an amount plus maximum fee
+// above the projected balance, or a nonce other than the projected counter of the account.
+func (r *TxRequest) Check(balance, counter uint64) []string {
+	var problems []string
+	if r.Recipient == (gosmtypes.Address{}) {
+		problems = append(problems, "the recipient is the zero address")
+	}
+	if r.GasPrice == 0 {
+		problems = append(problems, "the gas price is zero")
+	}
+	if r.GasLimit == 0 || r.GasLimit > MaxGasLimit {
+		problems = append(problems, fmt.Sprintf("the gas limit %d is not between 1 and %d", r.GasLimit, MaxGasLimit))
+	}
+	fee := r.GasPrice * r.GasLimit
+	if r.GasLimit != 0 && fee/r.GasLimit != r.GasPrice || r.Amount+fee < r.Amount {
+		problems = append(problems, "the amount plus the maximum fee overflows")
+	} else if r.Amount+fee > balance {
+		problems = append(problems, fmt.Sprintf("the amount plus the maximum fee %d exceeds the projected balance %d", r.Amount+fee, balance))
+	}
+	if r.Nonce != counter {
+		problems = append(problems, fmt.Sprintf("the nonce %d is not the projected counter %d", r.Nonce, counter))
+	}
+	return problems
+}
+
+const (
+	// MinTransferGasLimit is the lowest gas limit of a coin transfer the node accepts
+	MinTransferGasLimit = 1
+	// DefaultTransferGasLimit is the built-in gas limit of a coin transfer
+	DefaultTransferGasLimit = 100
+	// GasLimitWarningFactor is how many times the default gas limit a gas limit can be before it is
+	// likely a mistake
+	GasLimitWarningFactor = 100
+)
+
+// CheckGasLimit returns warnings about a gas limit of a coin transfer: below MinTransferGasLimit,
+// which gets the transaction rejected, or more than GasLimitWarningFactor times defaultLimit,
+// which may waste fees.
+func CheckGasLimit(limit, defaultLimit uint64) []string {
+	var warnings []string
+	if limit < MinTransferGasLimit {
+		warnings = append(warnings, fmt.Sprintf("the gas limit %d is below the minimum of %d for a coin transfer, the transaction will be rejected", limit, MinTransferGasLimit))
+	}
+	if defaultLimit != 0 && defaultLimit <= math.MaxUint64/GasLimitWarningFactor && limit > GasLimitWarningFactor*defaultLimit {
+		warnings = append(warnings, fmt.Sprintf("the gas limit %d is more than %d times the default of %d", limit, GasLimitWarningFactor, defaultLimit))
+	}
+	return warnings
+}
+
+// SubmittedTx is a transaction submitted from this wallet during the session
+type SubmittedTx struct {
+	ID []byte
+	TxRequest
+}
@@ -0,0 +1,101 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func TestParseTxRequest(t *testing.T) {
+	req, err := ParseTxRequest([]byte(TxRequestExample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := TxRequest{
+		Recipient: gosmtypes.HexToAddress("0x7fa75881ca0050028b32f424f860e3a73d4bf168"),
+		Amount:    2500000000000,
+		GasPrice:  1,
+		GasLimit:  100,
+		Nonce:     7,
+	}
+	if *req != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *req)
+	}
+
+	req, err = ParseTxRequest([]byte(`{"recipient":"0x7fa75881ca0050028b32f424f860e3a73d4bf168","amount":1000,"gasprice":1,"gaslimit":100,"nonce":0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Amount != 1000 {
+		t.Fatalf("expected a numeric amount in Smidge, got %d", req.Amount)
+	}
+}
+
+func TestParseTxRequestErrors(t *testing.T) {
+	cases := map[string]string{
+		`{"recipient":"0x7fa75881ca0050028b32f424f860e3a73d4bf168"}`:                                                               "missing amount, gasprice, gaslimit, nonce",
+		`{"recipient":"0x7fa7","amount":"1smh","gasprice":1,"gaslimit":100,"nonce":7}`:                                             "invalid recipient",
+		`{"recipient":"0x7fa75881ca0050028b32f424f860e3a73d4bf168","amount":"1.5","gasprice":1,"gaslimit":100,"nonce":7}`:          "invalid amount",
+		`{"recipient":"0x7fa75881ca0050028b32f424f860e3a73d4bf168","amount":"1smh","gasprice":-1,"gaslimit":100,"nonce":7}`:        "invalid transaction file",
+		`{"recipient":"0x7fa75881ca0050028b32f424f860e3a73d4bf168","amount":"1smh","gasprice":1,"gaslimit":0,"nonce":7}`:           "invalid gaslimit",
+		`{"recipient":"0x7fa75881ca0050028b32f424f860e3a73d4bf168","amount":"1smh","gasprice":1,"gaslimit":100,"nonce":7,"fee":1}`: "unknown field",
+		`not json`: "invalid transaction file",
+	}
+	for data, expected := range cases {
+		_, err := ParseTxRequest([]byte(data))
+		if err == nil {
+			t.Fatalf("%s: expected an error", data)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("%s: expected error containing %q, got %v", data, expected, err)
+		}
+	}
+}
+
+func TestTxRequestCheck(t *testing.T) {
+	req := TxRequest{
+		Recipient: gosmtypes.HexToAddress("0x7fa75881ca0050028b32f424f860e3a73d4bf168"),
+		Amount:    900,
+		GasPrice:  1,
+		GasLimit:  100,
+		Nonce:     7,
+	}
+	if problems := req.Check(1000, 7); len(problems) != 0 {
+		t.Fatalf("expected no problems, got %v", problems)
+	}
+	if problems := req.Check(999, 7); len(problems) != 1 || !strings.Contains(problems[0], "balance") {
+		t.Fatalf("expected a balance problem, got %v", problems)
+	}
+	if problems := req.Check(1000, 6); len(problems) != 1 || !strings.Contains(problems[0], "nonce") {
+		t.Fatalf("expected a nonce problem, got %v", problems)
+	}
+
+	bad := TxRequest{GasPrice: 1 << 40, GasLimit: 1 << 30}
+	if problems := bad.Check(1000, 0); len(problems) != 3 {
+		t.Fatalf("expected recipient, gas limit and overflow problems, got %v", problems)
+	}
+	bad = TxRequest{Recipient: req.Recipient, Amount: 1}
+	if problems := bad.Check(1000, 0); len(problems) != 2 {
+		t.Fatalf("expected gas price and gas limit problems, got %v", problems)
+	}
+}
+
+func TestCheckGasLimit(t *testing.T) {
+	for _, test := range []struct {
+		limit, defaultLimit uint64
+		warnings            int
+	}{
+		{MinTransferGasLimit, DefaultTransferGasLimit, 0},
+		{MinTransferGasLimit - 1, DefaultTransferGasLimit, 1},
+		{GasLimitWarningFactor * DefaultTransferGasLimit, DefaultTransferGasLimit, 0},
+		{GasLimitWarningFactor*DefaultTransferGasLimit + 1, DefaultTransferGasLimit, 1},
+		{GasLimitWarningFactor * 7, 7, 0},
+		{GasLimitWarningFactor*7 + 1, 7, 1},
+		{18446744073709551615, 18446744073709551615, 0},
+	} {
+		if warnings := CheckGasLimit(test.limit, test.defaultLimit); len(warnings) != test.warnings {
+			t.Fatalf("gas limit %d with default %d: expected %d warnings, got %v", test.limit, test.defaultLimit, test.warnings, warnings)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package common
+
+import (
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// Version is the smrepl version. Release builds set it with
+// -ldflags "-X github.com/spacemeshos/smrepl/common.Version=<version>".
+var Version = "dev"
+
+// WriterName identifies this program and its version in the files it writes
+func WriterName() string {
+	return "smrepl " + Version
+}
+
+// Modules of the node and of its API which this program is built with
+const (
+	NodeModule = "github.com/spacemeshos/go-spacemesh"
+	APIModule  = "github.com/spacemeshos/api/release/go"
+)
+
+// DependencyVersion returns the version of a module this program was built with, or an empty
+// string when the build doesn't record it
+func DependencyVersion(module string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == module {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return ""
+}
+
+// parseVersion returns the major, minor and patch numbers of a version like v1.2.3 or 1.2.3-rc1
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+ "); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) != 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// CompareVersions compares two semantic versions. It returns -1, 0 or 1 when a is older than, the
+// same as or newer than b, and false when either isn't a semantic version.
+func CompareVersions(a, b string) (int, bool) {
+	va, ok := parseVersion(a)
+	if !ok {
+		return 0, false
+	}
+	vb, ok := parseVersion(b)
+	if !ok {
+		return 0, false
+	}
+	for i := range va {
+		switch {
+		case va[i] < vb[i]:
+			return -1, true
+		case va[i] > vb[i]:
+			return 1, true
+		}
+	}
+	return 0, true
+}
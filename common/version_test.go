@@ -0,0 +1,24 @@
+package common
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	for _, test := range []struct {
+		a, b     string
+		expected int
+		ok       bool
+	}{
+		{"v0.1.17", "v0.1.17", 0, true},
+		{"0.1.16", "v0.1.17", -1, true},
+		{"v0.2.0-rc1", "v0.1.17", 1, true},
+		{"v1.0.0+build", "v0.9.9", 1, true},
+		{"v0.1.10", "v0.1.9", 1, true},
+		{"dev", "v0.1.17", 0, false},
+		{"v0.1", "v0.1.17", 0, false},
+	} {
+		c, ok := CompareVersions(test.a, test.b)
+		if ok != test.ok || c != test.expected {
+			t.Fatalf("%s vs %s: expected %d %v, got %d %v", test.a, test.b, test.expected, test.ok, c, ok)
+		}
+	}
+}
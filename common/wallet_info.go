@@ -0,0 +1,20 @@
+package common
+
+import "time"
+
+// WalletInfo describes a wallet file and its accounts without any key material
+type WalletInfo struct {
+	Name              string    `json:"name"`
+	Path              string    `json:"path"`
+	Version           int       `json:"version"`
+	Encrypted         bool      `json:"encrypted"`
+	Cipher            string    `json:"cipher"`
+	Created           time.Time `json:"created"`
+	Modified          time.Time `json:"modified"`
+	Accounts          int       `json:"accounts"`
+	DerivedAccounts   int       `json:"derivedAccounts"`
+	ImportedAccounts  int       `json:"importedAccounts"`
+	WatchOnlyAccounts int       `json:"watchOnlyAccounts"`
+	CurrentAccount    string    `json:"currentAccount"`
+	UnsavedChanges    bool      `json:"unsavedChanges"`
+}
@@ -5,6 +5,7 @@ package log
 import (
 	"os"
 	"path/filepath"
+	"sync/atomic"
 
 	"gopkg.in/natefinch/lumberjack.v2"
 	"gopkg.in/op/go-logging.v1"
@@ -18,7 +19,85 @@ type Log struct {
 
 // smlogger is the local app singleton logger.
 var AppLog Log
-var debugMode = false
+
+// Level is the severity of a log message. The console and the log file each drop the messages
+// below their level.
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// consoleLevel and fileLevel are the levels of the console and log file backends of all the
+// loggers, accessed atomically so they can change while messages are logged
+var consoleLevel = int32(LevelInfo)
+var fileLevel = int32(LevelDebug)
+
+// SetConsoleLevel sets the level of the messages printed to the console
+func SetConsoleLevel(level Level) {
+	atomic.StoreInt32(&consoleLevel, int32(level))
+}
+
+// SetFileLevel sets the level of the messages written to the log file
+func SetFileLevel(level Level) {
+	atomic.StoreInt32(&fileLevel, int32(level))
+}
+
+// leveledBackend is a backend which drops the messages below the level at level. Unlike the
+// module levels of go-logging, the level can be changed while other goroutines log.
+type leveledBackend struct {
+	logging.Backend
+	level *int32
+}
+
+// threshold returns the least severe go-logging level of the backend. Notices are info messages
+// and critical messages are errors.
+func (b *leveledBackend) threshold() logging.Level {
+	switch Level(atomic.LoadInt32(b.level)) {
+	case LevelDebug:
+		return logging.DEBUG
+	case LevelInfo:
+		return logging.INFO
+	case LevelWarn:
+		return logging.WARNING
+	default:
+		return logging.ERROR
+	}
+}
+
+func (b *leveledBackend) GetLevel(string) logging.Level {
+	return b.threshold()
+}
+
+// SetLevel does nothing, the levels are set with SetConsoleLevel and SetFileLevel
+func (b *leveledBackend) SetLevel(logging.Level, string) {}
+
+func (b *leveledBackend) IsEnabledFor(level logging.Level, _ string) bool {
+	return level <= b.threshold()
+}
+
+func (b *leveledBackend) Log(level logging.Level, calldepth int, rec *logging.Record) error {
+	if !b.IsEnabledFor(level, rec.Module) {
+		return nil
+	}
+	return b.Backend.Log(level, calldepth+1, rec)
+}
 
 func init() {
 
@@ -32,22 +111,14 @@ func init() {
 	AppLog = Log{Logger: log}
 }
 
-// getAllBackend returns level backends with an exception to Debug leve
-// which is only returned if the flag debug is set
+// getBackendLevel returns the console backend, which drops the messages below the console level
 func getBackendLevel(module, prefix, format string) logging.LeveledBackend {
 	logFormat := logging.MustStringFormatter(format)
 
 	backend := logging.NewLogBackend(os.Stdout, prefix, 0)
 	backendFormatter := logging.NewBackendFormatter(backend, logFormat)
-	leveledBackend := logging.AddModuleLevel(backendFormatter)
-
-	if debugMode {
-		leveledBackend.SetLevel(logging.DEBUG, module)
-	} else {
-		leveledBackend.SetLevel(logging.INFO, module)
-	}
 
-	return leveledBackend
+	return &leveledBackend{Backend: backendFormatter, level: &consoleLevel}
 }
 
 // New creates a logger for a module. e.g. p2p instance logger.
@@ -83,7 +154,7 @@ func getBackendLevelWithFileBackend(module, prefix, logFormat, fileFormat, dataF
 		fileLoggerBackend := logging.NewLogBackend(fileLogger, "", 0)
 		logFileFormat := logging.MustStringFormatter(fileFormat)
 		fileBackendFormatter := logging.NewBackendFormatter(fileLoggerBackend, logFileFormat)
-		leveledBackends = append(leveledBackends, logging.AddModuleLevel(fileBackendFormatter))
+		leveledBackends = append(leveledBackends, &leveledBackend{Backend: fileBackendFormatter, level: &fileLevel})
 	}
 
 	return leveledBackends
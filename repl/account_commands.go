@@ -1,11 +1,16 @@
 package repl
 
 import (
+	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
 
 	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
-	"github.com/spacemeshos/ed25519"
 	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/smrepl/common"
 	"github.com/spacemeshos/smrepl/log"
@@ -16,14 +21,45 @@ func (r *repl) printWalletMnemonic() {
 }
 
 func (r *repl) walletInfo() {
-	r.client.WalletInfo()
+	info, err := r.client.WalletInfo()
+	if err != nil {
+		log.Error("failed to get wallet info: %v", err)
+		return
+	}
+	if hasFlag(r.args, "--json") {
+		r.printJSON(info)
+		return
+	}
+
+	fmt.Fprintln(r.out, printPrefix, "Name:", info.Name)
+	fmt.Fprintln(r.out, printPrefix, "File path:", info.Path)
+	fmt.Fprintln(r.out, printPrefix, "File version:", info.Version)
+	if info.Encrypted {
+		fmt.Fprintln(r.out, printPrefix, "Encrypted:", "yes,", info.Cipher)
+	} else {
+		fmt.Fprintln(r.out, printPrefix, "Encrypted:", "no")
+	}
+	fmt.Fprintln(r.out, printPrefix, "Created:", formatTime(info.Created))
+	fmt.Fprintln(r.out, printPrefix, "Last modified:", formatTime(info.Modified))
+	fmt.Fprintln(r.out, printPrefix, "Accounts:", info.Accounts, fmt.Sprintf("(%d derived, %d imported, %d watch-only)",
+		info.DerivedAccounts, info.ImportedAccounts, info.WatchOnlyAccounts))
+	if info.CurrentAccount == "" {
+		fmt.Fprintln(r.out, printPrefix, "Current account: none")
+	} else {
+		fmt.Fprintln(r.out, printPrefix, "Current account:", info.CurrentAccount)
+	}
+	if info.UnsavedChanges {
+		fmt.Fprintln(r.out, printPrefix, "Unsaved changes: yes")
+	} else {
+		fmt.Fprintln(r.out, printPrefix, "Unsaved changes: no")
+	}
 }
 
 // openWallet opens a wallet from locally stored wallet data file
 func (r *repl) openWallet() {
 	r.clientOpen = r.client.OpenWallet()
 	if !r.clientOpen {
-		fmt.Println("Wallet NOT opened")
+		fmt.Fprintln(r.out, "Wallet NOT opened")
 		return
 	}
 	r.client.WalletInfo()
@@ -32,15 +68,280 @@ func (r *repl) openWallet() {
 
 // createWallet creates a new wallet
 func (r *repl) createWallet() {
-	r.clientOpen = r.client.NewWallet()
+	name := ""
+	if args := positionalArgs(r.args, "--seed", "--entropy-file"); len(args) > 0 {
+		name = args[0]
+	}
+	entropy, ok := r.walletEntropy()
+	if !ok {
+		return
+	}
+	r.clientOpen = r.client.NewWallet(name, entropy)
+	if entropy != nil {
+		common.Zero(entropy)
+	}
 	if !r.clientOpen {
-		fmt.Println("Wallet NOT created")
+		fmt.Fprintln(r.out, "Wallet NOT created")
 		return
 	}
+	r.walletInfo()
+	r.initializeCommands()
+}
+
+// walletEntropy returns the entropy of a new wallet from the --seed or --entropy-file flags, or nil
+// for a random or user provided mnemonic. It returns false if the wallet should not be created.
+func (r *repl) walletEntropy() ([]byte, bool) {
+	if seedHex, ok := flagValue(r.args, "--seed"); ok {
+		seed, err := hex.DecodeString(trimHexPrefix(seedHex))
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, "invalid seed hex string:", err)
+			return nil, false
+		}
+		defer common.Zero(seed)
+		entropy, err := common.SeedEntropy(seed)
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return nil, false
+		}
+		fmt.Fprintln(r.out, printPrefix, seedWarningMsg)
+		if r.yesOrNoQuestion(confirmSeedMsg) != "y" {
+			return nil, false
+		}
+		return entropy, true
+	}
+	if path, ok := flagValue(r.args, "--entropy-file"); ok {
+		extra, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Error("failed to read entropy file: %v", err)
+			return nil, false
+		}
+		defer common.Zero(extra)
+		entropy, err := common.MixEntropy(extra)
+		if err != nil {
+			log.Error("failed to generate entropy: %v", err)
+			return nil, false
+		}
+		return entropy, true
+	}
+	return nil, true
+}
+
+// listWallets prints the wallet files found in the wallets directory
+func (r *repl) listWallets() {
+	wallets, err := r.client.ListWallets()
+	if err != nil {
+		log.Error("failed to list wallets: %v", err)
+		return
+	}
+	if len(wallets) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "No wallet files found")
+		return
+	}
+	for _, w := range wallets {
+		fmt.Fprintln(r.out, printPrefix, w)
+	}
+}
+
+// switchWallet closes the open wallet and opens another wallet from the wallets directory
+func (r *repl) switchWallet() {
+	if len(r.args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: wallet switch <name>")
+		return
+	}
+	if err := r.client.SwitchWallet(r.args[0]); err != nil {
+		fmt.Fprintln(r.out, printPrefix, "Wallet NOT opened:", err)
+		return
+	}
+	r.clientOpen = r.client.IsOpen()
 	r.client.WalletInfo()
 	r.initializeCommands()
 }
 
+// backupWallet copies the open wallet file to a user provided path
+func (r *repl) backupWallet() {
+	if len(r.args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: wallet backup <path>")
+		return
+	}
+	path := r.args[0]
+	checksum, err := r.client.BackupWallet(path)
+	if err != nil {
+		log.Error("failed to back up wallet: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Wallet backed up to:", path)
+	fmt.Fprintln(r.out, printPrefix, "SHA-256 checksum:", checksum)
+}
+
+// verifyWalletBackup checks a wallet backup file against its checksum and lists its accounts
+func (r *repl) verifyWalletBackup() {
+	if len(r.args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: wallet verify-backup <path>")
+		return
+	}
+	accounts, err := r.client.VerifyBackup(r.args[0])
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "Backup verification FAILED:", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Backup verified. Accounts:", len(accounts))
+	for _, a := range accounts {
+		fmt.Fprintln(r.out, printPrefix, a.Name, r.formatAddress(a.Address))
+	}
+}
+
+// verifyBackupPhrase checks a mnemonic entered by the user against the keys of the open wallet
+func (r *repl) verifyBackupPhrase() {
+	ok, err := r.client.VerifyMnemonic()
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "MISMATCH:", err)
+		return
+	}
+	if !ok {
+		fmt.Fprintln(r.out, printPrefix, "MISMATCH: the mnemonic does not derive the wallet keys")
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "MATCH: the mnemonic derives the wallet keys")
+}
+
+// importSmappWallet converts a Smapp wallet file into a wallet in the wallets directory
+func (r *repl) importSmappWallet() {
+	if len(r.args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: wallet import-smapp <file>")
+		return
+	}
+	path, accounts, err := r.client.ImportSmappWallet(r.args[0])
+	if err != nil {
+		log.Error("failed to import Smapp wallet: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Wallet imported to:", path)
+	for _, a := range accounts {
+		fmt.Fprintln(r.out, printPrefix, a.Name, r.formatAddress(a.Address))
+	}
+	fmt.Fprintln(r.out, printPrefix, "Use `wallet switch` to open it.")
+}
+
+// mergeWallet adds the accounts of another wallet file to the open wallet
+func (r *repl) mergeWallet() {
+	args := positionalArgs(r.args)
+	if len(args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: wallet merge <path> [--dry-run]")
+		return
+	}
+	dryRun := hasFlag(r.args, "--dry-run")
+	res, err := r.client.MergeWallet(args[0], dryRun)
+	if err != nil {
+		log.Error("failed to merge wallet: %v", err)
+		return
+	}
+	summary := r.printMergeResult(res)
+	if dryRun {
+		fmt.Fprintln(r.out, printPrefix, "Dry run, the wallet was not changed:", summary)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, summary)
+}
+
+// printMergeResult prints the accounts of a wallet merge and returns its summary
+func (r *repl) printMergeResult(res *common.MergeResult) string {
+	for _, name := range res.Imported {
+		fmt.Fprintln(r.out, printPrefix, "import:", name)
+	}
+	for _, name := range res.Skipped {
+		fmt.Fprintln(r.out, printPrefix, "skip duplicate:", name)
+	}
+	renamed := make([]string, 0, len(res.Renamed))
+	for from := range res.Renamed {
+		renamed = append(renamed, from)
+	}
+	sort.Strings(renamed)
+	for _, from := range renamed {
+		fmt.Fprintln(r.out, printPrefix, "rename:", from, "->", res.Renamed[from])
+	}
+	return fmt.Sprintf("%d imported, %d skipped as duplicates, %d renamed", len(res.Imported), len(res.Skipped), len(res.Renamed))
+}
+
+// checkWalletConflict runs after every command. When another program changed the wallet file and
+// the changes of the open wallet weren't saved over it, it shows the accounts which differ and
+// offers to reload the file, merge it or overwrite it.
+func (r *repl) checkWalletConflict() {
+	if !r.clientOpen {
+		return
+	}
+	conflict, err := r.client.WalletConflict()
+	if err != nil {
+		log.Error("failed to check the wallet file: %v", err)
+		return
+	}
+	if conflict == nil {
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, colorRed+fmt.Sprintf(walletChangedMsg, conflict.Path)+colorReset)
+	if conflict.DiskError != nil {
+		fmt.Fprintln(r.out, printPrefix, "The accounts of the file can't be compared:", conflict.DiskError)
+	}
+	for _, acc := range conflict.OnDiskOnly {
+		fmt.Fprintln(r.out, printPrefix, "only in the file:", acc.Name, r.addressString(acc.Address))
+	}
+	for _, acc := range conflict.InMemoryOnly {
+		fmt.Fprintln(r.out, printPrefix, "only in the open wallet:", acc.Name, r.addressString(acc.Address))
+	}
+
+	switch r.multipleChoice(walletConflictChoices) {
+	case 1:
+		if err := r.client.ReloadWallet(); err != nil {
+			log.Error("failed to reload the wallet: %v", err)
+			return
+		}
+		fmt.Fprintln(r.out, printPrefix, "Reloaded the wallet file, the unsaved changes were dropped.")
+	case 2:
+		res, err := r.client.MergeWalletFile()
+		if err != nil {
+			log.Error("failed to merge the wallet file: %v", err)
+			return
+		}
+		fmt.Fprintln(r.out, printPrefix, "Merged the wallet file and saved the wallet:", r.printMergeResult(res))
+	case 3:
+		if r.yesOrNoQuestion(confirmOverwriteWalletMsg) != "y" {
+			return
+		}
+		if err := r.client.OverwriteWalletFile(); err != nil {
+			log.Error("failed to save the wallet: %v", err)
+			return
+		}
+		fmt.Fprintln(r.out, printPrefix, "Saved the wallet over the file.")
+	default:
+		fmt.Fprintln(r.out, printPrefix, "The changes are not saved. You will be asked again after the next command.")
+	}
+}
+
+// exportSmappWallet writes a copy of the open wallet that Smapp can open
+func (r *repl) exportSmappWallet() {
+	if len(r.args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: wallet export-smapp <file>")
+		return
+	}
+	watchOnly, err := r.client.ExportSmappWallet(r.args[0])
+	if err != nil {
+		log.Error("failed to export wallet: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Wallet exported to:", r.args[0])
+	if watchOnly > 0 {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%d watch-only accounts were left out, Smapp has no watch-only accounts.", watchOnly))
+	}
+}
+
+// changeWalletPassword re-encrypts the open wallet with a new password
+func (r *repl) changeWalletPassword() {
+	if err := r.client.ChangePassword(); err != nil {
+		fmt.Fprintln(r.out, printPrefix, "Password NOT changed:", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Wallet password changed")
+}
+
 // closeWallet closes an open wallet
 func (r *repl) closeWallet() {
 	r.client.CloseWallet()
@@ -48,48 +349,253 @@ func (r *repl) closeWallet() {
 	r.initializeCommands()
 }
 
-// chooseAccount sets the current account to one of the open wallet's accounts
+// chooseAccount sets the current account to one of the open wallet's accounts. When the chosen
+// account can't be loaded, e.g. because the accounts file changed meanwhile, the accounts are
+// listed and offered again once.
 func (r *repl) chooseAccount() {
-	accs, err := r.client.ListAccounts()
+	if r.pickAccount() {
+		fmt.Fprintln(r.out, printPrefix, "The wallet's accounts may have changed, choose again.")
+		r.pickAccount()
+	}
+}
+
+// pickAccount prompts for one of the open wallet's accounts and makes it the current one. It
+// returns true when the chosen account couldn't be loaded.
+func (r *repl) pickAccount() bool {
+	accs, err := r.localAccounts()
 	if err != nil {
 		log.Error("failure to choose account", err)
-		return
+		return false
 	}
 	if len(accs) == 0 {
 		r.createAccount()
-		return
+		return false
+	}
+
+	tag, filter := flagValue(r.args, "--tag")
+	if filter {
+		if tag, err = common.NormalizeTag(tag); err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return false
+		}
 	}
+	var matching []*common.LocalAccount
+	var positions []int
+	for pos, acc := range accs {
+		if filter && !common.HasTag(acc.Tags, tag) {
+			continue
+		}
+		matching = append(matching, acc)
+		positions = append(positions, pos)
+	}
+	if len(matching) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "No account is tagged", tag)
+		return false
+	}
+	choices := r.accountListing(matching)
 
-	fmt.Println(printPrefix, "Choose an account to load:")
-	accNumber := multipleChoice(accs)
+	fmt.Fprintln(r.out, printPrefix, "Choose an account to load:")
+	accNumber := r.multipleChoice(choices)
 	if accNumber == 0 {
-		fmt.Println("none selected")
+		fmt.Fprintln(r.out, "none selected")
+		return false
+	}
+	if err = r.client.SetCurrentAccount(positions[accNumber-1]); err != nil {
+		fmt.Fprintln(r.out, printPrefix, "Failed to set the current account:", err)
+		return true
+	}
+
+	account, err := r.client.CurrentAccount()
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "Failed to load the chosen account:", err)
+		return true
+	}
+	if account.Name != matching[accNumber-1].Name {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Loaded account %s instead of %s.", account.Name, matching[accNumber-1].Name))
+		return true
+	}
+
+	fmt.Fprintf(r.out, "%s Loaded account alias: `%s`, address: %s \n", printPrefix, account.Name, r.formatAddress(account.Address()))
+	return false
+}
+
+// localAccounts returns all the accounts of the open wallet in wallet order
+func (r *repl) localAccounts() ([]*common.LocalAccount, error) {
+	names, err := r.client.ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]*common.LocalAccount, 0, len(names))
+	for _, name := range names {
+		acc, err := r.client.GetAccount(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get account %s: %v", name, err)
+		}
+		accounts = append(accounts, acc)
+	}
+	return accounts, nil
+}
+
+// accountAnnotation returns the tags and note of an account for display, e.g. [smesher] coinbase
+func accountAnnotation(acc *common.LocalAccount) string {
+	res := acc.Note
+	if len(acc.Tags) > 0 {
+		res = strings.TrimSpace("[" + strings.Join(acc.Tags, ", ") + "] " + res)
+	}
+	return res
+}
+
+// noteAccount attaches a note to the current account. Without text the note is removed.
+func (r *repl) noteAccount() {
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
 		return
 	}
-	accNumber = accNumber - 1
-	err = r.client.SetCurrentAccount(accNumber)
+	note := strings.Join(r.args, " ")
+	if err := r.client.SetAccountNote(acc.Name, note); err != nil {
+		log.Error("failed to set account note: %v", err)
+		return
+	}
+	if err := r.client.StoreAccounts(); err != nil {
+		log.Error("failed to save the account note: %v", err)
+		return
+	}
+	if note == "" {
+		fmt.Fprintln(r.out, printPrefix, "Removed the note of account", acc.Name)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Updated the note of account", acc.Name)
+}
+
+// resetNonce releases the nonces reserved by the current account's transactions
+func (r *repl) resetNonce() {
+	acc, err := r.getCurrent()
 	if err != nil {
-		log.Error("failure to set current account", err)
+		log.Error("failed to get account", err)
+		return
+	}
+	defer acc.Wipe()
+	if err := r.client.ResetNonce(acc.Address()); err != nil {
+		log.Error("failed to reset the nonce: %v", err)
 		return
 	}
+	fmt.Fprintln(r.out, printPrefix, "Released the nonces reserved by account", acc.Name)
+}
 
-	account, err := r.client.CurrentAccount()
+// setAccountGas sets the default gas price and gas limit of the current account
+func (r *repl) setAccountGas() {
+	if len(r.args) != 2 {
+		fmt.Fprintln(r.out, printPrefix, "usage: account set-gas <price> <limit>")
+		return
+	}
+	gasPrice, err := strconv.ParseUint(r.args[0], 10, 64)
 	if err != nil {
-		log.Error("error getting current account", err)
+		fmt.Fprintln(r.out, printPrefix, "invalid gas price:", r.args[0])
+		return
+	}
+	gasLimit, err := strconv.ParseUint(r.args[1], 10, 64)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "invalid gas limit:", r.args[1])
+		return
+	}
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
 		return
 	}
+	defer acc.Wipe()
+	if err := r.client.SetAccountGas(acc.Name, gasPrice, gasLimit); err != nil {
+		log.Error("failed to set gas defaults: %v", err)
+		return
+	}
+	if err := r.client.StoreAccounts(); err != nil {
+		log.Error("failed to save gas defaults: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Gas defaults of account", acc.Name, "set to price", gasPrice, "and limit", gasLimit)
+}
 
-	fmt.Printf("%s Loaded account alias: `%s`, address: %s \n", printPrefix, account.Name, account.Address().String())
+// tagAccount adds a tag to or removes a tag from the current account
+func (r *repl) tagAccount() {
+	if len(r.args) != 2 || (r.args[0] != "add" && r.args[0] != "rm") {
+		fmt.Fprintln(r.out, printPrefix, "usage: account tag add|rm <tag>")
+		return
+	}
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	if r.args[0] == "add" {
+		err = r.client.TagAccount(acc.Name, r.args[1])
+	} else {
+		err = r.client.UntagAccount(acc.Name, r.args[1])
+	}
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	if err := r.client.StoreAccounts(); err != nil {
+		log.Error("failed to save the account tags: %v", err)
+		return
+	}
+	updated, err := r.client.GetAccount(acc.Name)
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Tags of account", acc.Name+":", strings.Join(updated.Tags, ", "))
 }
 
 // createAccount creates a new account in the currently open wallet
 func (r *repl) createAccount() {
-	fmt.Println(printPrefix, "Create a new account")
-	alias := inputNotBlank(createAccountMsg)
+	fmt.Fprintln(r.out, printPrefix, "Create a new account")
+	var ac *common.LocalAccount
+	for {
+		alias := r.inputNotBlank(createAccountMsg)
+
+		var err error
+		ac, err = r.client.CreateAccount(alias)
+		if err == common.ErrAliasTaken || err == common.ErrInvalidAlias {
+			fmt.Fprintln(r.out, printPrefix, err)
+			if r.yesOrNoQuestion(pickAnotherAliasMsg) == "y" {
+				continue
+			}
+			return
+		}
+		if err != nil {
+			log.Error("Failed to create a new account: %v", err)
+			return
+		}
+		break
+	}
 
-	ac, err := r.client.CreateAccount(alias)
+	err := r.client.StoreAccounts()
 	if err != nil {
-		log.Error("Failed to create a new account: %v", err)
+		log.Error("Failed to save the new account: %v", err)
+		return
+	}
+
+	fmt.Fprintf(r.out, "%s Created account: %s, address: %s \n", printPrefix, ac.Name, r.formatAddress(ac.Address()))
+}
+
+// watchAccount adds a watch-only account to the currently open wallet
+func (r *repl) watchAccount() {
+	if len(r.args) != 2 {
+		fmt.Fprintln(r.out, printPrefix, "usage: account watch <alias> <address>")
+		return
+	}
+
+	address, err := common.ParseAddress(r.args[1])
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+
+	ac, err := r.client.WatchAccount(r.args[0], address)
+	if err != nil {
+		log.Error("Failed to add a watch-only account: %v", err)
 		return
 	}
 	err = r.client.StoreAccounts()
@@ -98,21 +604,78 @@ func (r *repl) createAccount() {
 		return
 	}
 
-	fmt.Printf("%s Created account: %s, address: %s \n", printPrefix, ac.Name, ac.Address().String())
+	fmt.Fprintf(r.out, "%s Added watch-only account: %s, address: %s \n", printPrefix, ac.Name, r.formatAddress(ac.Address()))
+}
+
+// deleteAccount removes one of the open wallet's accounts after the user confirms by typing its alias
+func (r *repl) deleteAccount() {
+	accs, err := r.client.ListAccounts()
+	if err != nil {
+		log.Error("failed to list accounts: %v", err)
+		return
+	}
+	if len(accs) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "The wallet has no accounts")
+		return
+	}
+
+	fmt.Fprintln(r.out, printPrefix, "Choose an account to delete:")
+	accNumber := r.multipleChoice(accs)
+	if accNumber == 0 {
+		fmt.Fprintln(r.out, "none selected")
+		return
+	}
+	alias := accs[accNumber-1]
+
+	acc, err := r.client.GetAccount(alias)
+	if err != nil {
+		log.Error("failed to get account: %v", err)
+		return
+	}
+
+	state, err := r.client.AccountState(r.ctx, acc.Address())
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "WARNING: failed to get the account balance from the node. The account may hold coins.")
+	} else if state.StateProjected.Balance != nil && state.StateProjected.Balance.Value > 0 {
+		fmt.Fprintln(r.out, printPrefix, "WARNING: this account holds", common.FormatAmount(state.StateProjected.Balance.Value))
+		fmt.Fprintln(r.out, printPrefix, "WARNING: the coins will be lost unless you have another backup of its private key!")
+	}
+
+	if strings.TrimSpace(r.inputNotBlank(confirmDeleteAccountMsg)) != alias {
+		fmt.Fprintln(r.out, printPrefix, "Alias does not match. Account NOT deleted")
+		return
+	}
+
+	if err := r.client.DeleteAccount(alias); err != nil {
+		log.Error("failed to delete account: %v", err)
+		return
+	}
+	if err := r.client.StoreAccounts(); err != nil {
+		log.Error("failed to save the wallet: %v", err)
+		return
+	}
+
+	fmt.Fprintf(r.out, "%s Deleted account alias: `%s`, address: %s \n", printPrefix, alias, r.formatAddress(acc.Address()))
 }
 
-// One smesh in base coin units
-const onesmh = 1000000000000
+// renameAccount changes the alias of one of the open wallet's accounts
+func (r *repl) renameAccount() {
+	if len(r.args) != 2 {
+		fmt.Fprintln(r.out, printPrefix, "usage: account rename <old alias> <new alias>")
+		return
+	}
+	oldName, newName := r.args[0], r.args[1]
 
-// coinAmount formats an amount in base coin units to a display string
-func coinAmount(val uint64) string {
-	if val >= 1000000000000 {
-		return fmt.Sprintf("%d.%012d SMH", val/onesmh, val%onesmh)
-	} else if val >= 10000000000 {
-		return fmt.Sprintf("0.%012d SMH", val%onesmh)
-	} else {
-		return fmt.Sprint(val, " Smidge")
+	if err := r.client.RenameAccount(oldName, newName); err != nil {
+		log.Error("failed to rename account: %v", err)
+		return
 	}
+	if err := r.client.StoreAccounts(); err != nil {
+		log.Error("failed to save the wallet: %v", err)
+		return
+	}
+
+	fmt.Fprintf(r.out, "%s Renamed account `%s` to `%s`\n", printPrefix, oldName, newName)
 }
 
 // printAccountInfo prints current wallet's account info from global state
@@ -122,18 +685,56 @@ func (r *repl) printAccountInfo() {
 		log.Error("failed to get account", err)
 		return
 	}
+	defer acc.Wipe()
 
-	address := gosmtypes.BytesToAddress(acc.PubKey)
-	account, err := r.client.AccountState(address)
+	address := acc.Address()
+	account, err := r.client.AccountState(r.ctx, address)
 	if err != nil {
 		log.Error("failed to get account info: %v", err)
 		return
 	}
 
-	fmt.Println(printPrefix, "Local alias:", acc.Name)
-	printAccount(account, address)
-	fmt.Println(printPrefix, fmt.Sprintf("Public key: 0x%s", hex.EncodeToString(acc.PubKey)))
-	fmt.Println(printPrefix, fmt.Sprintf("Private key: 0x%s", hex.EncodeToString(acc.PrivKey)))
+	fmt.Fprintln(r.out, printPrefix, "Local alias:", acc.Name)
+	fmt.Fprintln(r.out, printPrefix, "Origin:", acc.Origin)
+	if len(acc.Tags) > 0 {
+		fmt.Fprintln(r.out, printPrefix, "Tags:", strings.Join(acc.Tags, ", "))
+	}
+	if acc.Note != "" {
+		fmt.Fprintln(r.out, printPrefix, "Note:", acc.Note)
+	}
+	gasPrice, gasLimit := r.defaultGas(acc)
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Default gas price: %d (%s), gas limit: %d (%s)",
+		gasPrice.value, gasPrice.source, gasLimit.value, gasLimit.source))
+	r.printAccount(account, address)
+	if reserved, ok, err := r.client.ReservedNonce(address); err != nil {
+		log.Error("failed to get the locally reserved nonce: %v", err)
+	} else if ok {
+		fmt.Fprintln(r.out, printPrefix, "Locally reserved nonce:", reserved)
+	}
+	if acc.IsWatchOnly() {
+		fmt.Fprintln(r.out, printPrefix, "Watch-only account. No keys are stored in this wallet.")
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Public key: 0x%s", hex.EncodeToString(acc.PubKey)))
+}
+
+// exportPrivateKey prints the private key of the current account after confirmation. This is the
+// only command which displays private keys.
+func (r *repl) exportPrivateKey() {
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	defer acc.Wipe()
+	if acc.IsWatchOnly() {
+		fmt.Fprintln(r.out, printPrefix, common.ErrWatchOnly)
+		return
+	}
+	if r.yesOrNoQuestion(confirmExportKeyMsg) != "y" {
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Private key: 0x%s", hex.EncodeToString(acc.PrivKey)))
 }
 
 // printAccountRewards prints all rewards awarded to the current account
@@ -147,7 +748,7 @@ func (r *repl) printLocalAccountRewards() {
 }
 
 // printAccountState prints the account data member
-func printAccount(account *apitypes.Account, address gosmtypes.Address) {
+func (r *repl) printAccount(account *apitypes.Account, address gosmtypes.Address) {
 	currBalance := uint64(0)
 	if account.StateCurrent.Balance != nil {
 		currBalance = account.StateCurrent.Balance.Value
@@ -158,33 +759,73 @@ func printAccount(account *apitypes.Account, address gosmtypes.Address) {
 		projectedBalance = account.StateProjected.Balance.Value
 	}
 
-	fmt.Println(printPrefix, "Address:", address.String())
-	fmt.Println(printPrefix, "Balance:", coinAmount(currBalance)) // currBalance, coinUnitName)
-	fmt.Println(printPrefix, "Nonce:", account.StateCurrent.Counter)
-	fmt.Println(printPrefix, "Projected Balance:", coinAmount(projectedBalance)) // projectedBalance, coinUnitName)
-	fmt.Println(printPrefix, "Projected Nonce:", account.StateProjected.Counter)
-	fmt.Println(printPrefix, "Projected state includes all pending transactions that haven't been added to the mesh yet.")
+	fmt.Fprintln(r.out, printPrefix, "Address:", r.addressString(address))
+	fmt.Fprintln(r.out, printPrefix, "Balance:", common.FormatAmount(currBalance))
+	fmt.Fprintln(r.out, printPrefix, "Nonce:", account.StateCurrent.Counter)
+	fmt.Fprintln(r.out, printPrefix, "Projected Balance:", common.FormatAmount(projectedBalance))
+	fmt.Fprintln(r.out, printPrefix, "Projected Nonce:", account.StateProjected.Counter)
+	fmt.Fprintln(r.out, printPrefix, "Projected state includes all pending transactions that haven't been added to the mesh yet.")
+}
+
+// printRewardList prints rewards in the order given with --sort and --desc, newest layer first by default
+func (r *repl) printRewardList(rewards []*apitypes.Reward, total uint32) {
+	order, err := sortArgs(r.args, sortByLayer, sortByAmount)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	key := func(i int) (uint64, bool) {
+		if order.key == sortByAmount {
+			if rewards[i].Total == nil {
+				return 0, false
+			}
+			return rewards[i].Total.Value, true
+		}
+		if rewards[i].Layer == nil {
+			return 0, false
+		}
+		return uint64(rewards[i].Layer.Number), true
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Total rewards: %d", total))
+	for _, i := range sortedOrder(len(rewards), key, order.desc) {
+		r.printReward(r.ctx, rewards[i])
+		fmt.Fprintln(r.out, printPrefix, "-----")
+	}
 }
 
 // printReward prints a Reward
-func printReward(r *apitypes.Reward) {
-	fmt.Println(printPrefix, "Rewarded on layer:", r.Layer.Number)
-	//fmt.Println(printPrefix, "Rewarded for layer:", r.LayerComputed.Number)
-	fmt.Println(printPrefix, "Layer reward", r.LayerReward.Value, coinUnitName)
-	fmt.Println(printPrefix, "Transaction fees", r.Total.Value-r.LayerReward.Value, coinUnitName)
-	fmt.Println(printPrefix, "Total reward", r.Total.Value, coinUnitName)
-	//fmt.Println(printPrefix, "Smesher id", "0x"+hex.EncodeToString(r.Smesher.Id))
-	fmt.Println(printPrefix, "Rewards account:", gosmtypes.BytesToAddress(r.Coinbase.Address).String())
-}
-
-// getCurrent returns the current open wallet's account. If there is no current account
-// then it prompts the user to choose one of the wallet's accounts.
+func (r *repl) printReward(ctx context.Context, reward *apitypes.Reward) {
+	fmt.Fprintln(r.out, printPrefix, "Rewarded on layer:", reward.Layer.Number)
+	if params, err := r.client.NetworkParams(ctx, false); err == nil {
+		fmt.Fprintln(r.out, printPrefix, "Time (approximately):", params.LayerTime(reward.Layer.Number).Local().Format(layerTimeFormat))
+	}
+	//fmt.Println(printPrefix, "Rewarded for layer:", reward.LayerComputed.Number)
+	fmt.Fprintln(r.out, printPrefix, "Layer reward", common.FormatSmidge(reward.LayerReward.Value))
+	fmt.Fprintln(r.out, printPrefix, "Transaction fees", common.FormatSmidge(reward.Total.Value-reward.LayerReward.Value))
+	fmt.Fprintln(r.out, printPrefix, "Total reward", common.FormatSmidge(reward.Total.Value))
+	//fmt.Println(printPrefix, "Smesher id", "0x"+hex.EncodeToString(reward.Smesher.Id))
+	fmt.Fprintln(r.out, printPrefix, "Rewards account:", r.addressString(gosmtypes.BytesToAddress(reward.Coinbase.Address)))
+}
+
+// getCurrent returns the current open wallet's account, or the account selected with --account or
+// @alias for the executed command. If there is no current account then it prompts the user to
+// choose one of the wallet's accounts. It fails when the wallet was closed, so callers never get a
+// nil account without an error.
 func (r *repl) getCurrent() (acc *common.LocalAccount, err error) {
+	if !r.client.IsOpen() {
+		return nil, common.ErrNoWallet
+	}
+	if r.accountOverride != "" {
+		return r.client.GetAccount(r.accountOverride)
+	}
 	acc, err = r.client.CurrentAccount()
 	if err != nil {
 		r.chooseAccount()
 		acc, err = r.client.CurrentAccount()
 	}
+	if err == nil && acc == nil {
+		err = errors.New("no current account")
+	}
 	return
 }
 
@@ -195,15 +836,21 @@ func (r *repl) sign() {
 		log.Error("failed to get account", err)
 		return
 	}
+	key, err := acc.SigningKey()
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	defer key.Release()
 
-	msgStr := inputNotBlank(msgSignMsg)
+	msgStr := r.inputNotBlank(msgSignMsg)
 	msg, err := hex.DecodeString(msgStr)
 	if err != nil {
 		log.Error("failed to decode msg hex string: %v", err)
 		return
 	}
-	signature := ed25519.Sign2(acc.PrivKey, msg)
-	fmt.Println(printPrefix, fmt.Sprintf("signature (in hex): %x", signature))
+	signature := key.Sign(msg)
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("signature (in hex): %x", signature))
 }
 
 // signText signs a string with the current account
@@ -213,7 +860,13 @@ func (r *repl) signText() {
 		log.Error("failed to get account", err)
 		return
 	}
-	msg := inputNotBlank(msgTextSignMsg)
-	signature := ed25519.Sign2(acc.PrivKey, []byte(msg))
-	fmt.Println(printPrefix, fmt.Sprintf("signature (in hex): %x", signature))
+	key, err := acc.SigningKey()
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	defer key.Release()
+	msg := r.inputNotBlank(msgTextSignMsg)
+	signature := key.Sign([]byte(msg))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("signature (in hex): %x", signature))
 }
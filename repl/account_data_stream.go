@@ -0,0 +1,117 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// accountDataTypes are the types of account data state stream subscribes to, by flag
+var accountDataTypes = []struct {
+	flag string
+	data apitypes.AccountDataFlag
+}{
+	{"--rewards", apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_REWARD},
+	{"--receipts", apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_TRANSACTION_RECEIPT},
+	{"--updates", apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_ACCOUNT},
+}
+
+// accountDataFlags returns the types of account data selected by the flags in args, all of them
+// when none is given
+func accountDataFlags(args []string) apitypes.AccountDataFlag {
+	var flags, all apitypes.AccountDataFlag
+	for _, t := range accountDataTypes {
+		all |= t.data
+		if hasFlag(args, t.flag) {
+			flags |= t.data
+		}
+	}
+	if flags == 0 {
+		return all
+	}
+	return flags
+}
+
+// followAccountData follows the account data stream of an address for the types in flags in the
+// background, as followInBackground does, and passes each datum to handle with the context of the
+// stream
+func (r *repl) followAccountData(description, name string, address gosmtypes.Address, flags apitypes.AccountDataFlag,
+	handle func(ctx context.Context, datum *apitypes.AccountData), gap func(ctx context.Context) string) error {
+	open := func(ctx context.Context) (streamReceiver, error) {
+		stream, err := r.client.AccountDataStream(ctx, address, flags)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			resp, err := stream.Recv()
+			if err != nil {
+				return err
+			}
+			if datum := resp.GetDatum(); datum != nil {
+				handle(ctx, datum)
+			}
+			return nil
+		}, nil
+	}
+	return r.followInBackground(description, name, open, gap)
+}
+
+// printAccountDataStream prints a line tagged with its type for each reward, transaction receipt
+// and update of an account in the background, subscribing once to the types selected with
+// --rewards, --receipts and --updates, all of them by default
+func (r *repl) printAccountDataStream() {
+	var address gosmtypes.Address
+	if args := positionalArgs(r.args); len(args) == 1 {
+		var err error
+		if address, err = r.resolveAddress(args[0]); err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+	} else {
+		address = r.inputAddress(enterAddressMsg)
+	}
+	// previous is the last update, which handle reads and replaces from the goroutine of the stream
+	var (
+		mu       sync.Mutex
+		previous *apitypes.Account
+	)
+	handle := func(_ context.Context, datum *apitypes.AccountData) {
+		switch {
+		case datum.GetReward() != nil:
+			reward := datum.GetReward()
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("[reward]  layer %d, %s (layer reward %s)", reward.GetLayer().GetNumber(),
+				common.FormatAmount(reward.GetTotal().GetValue()), common.FormatAmount(reward.GetLayerReward().GetValue())))
+		case datum.GetReceipt() != nil:
+			receipt := datum.GetReceipt()
+			result, _ := receiptResult(receipt.GetResult())
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("[receipt] 0x%x layer %d, %s, fee %s", receipt.GetId().GetId(),
+				receipt.GetLayer().GetNumber(), result, common.FormatAmount(receipt.GetFee().GetValue())))
+		case datum.GetAccountWrapper() != nil:
+			account := datum.GetAccountWrapper()
+			mu.Lock()
+			defer mu.Unlock()
+			line := fmt.Sprintf("[update]  balance %s", common.FormatAmount(currentBalance(account)))
+			if previous != nil {
+				line += " (" + amountChange(currentBalance(previous), currentBalance(account)) + ")"
+			}
+			fmt.Fprintln(r.out, printPrefix, line+fmt.Sprintf(", nonce %d", account.GetStateCurrent().GetCounter()))
+			previous = account
+		}
+	}
+	gap := func(context.Context) string {
+		return "Data of the time it was down isn't resent, it's listed by state rewards, state receipts and state account."
+	}
+	if err := r.followAccountData("data stream of "+r.addressString(address), "account data", address,
+		accountDataFlags(r.args), handle, gap); err != nil {
+		log.Error("failed to get data stream for account: %v", err)
+		return
+	}
+
+	fmt.Fprintln(r.out, printPrefix, "Listening to the account data of", r.addressString(address))
+}
@@ -0,0 +1,87 @@
+package repl
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// listBalancesTimeout is how long account listings wait for the node balances
+const listBalancesTimeout = 2 * time.Second
+
+// shortAddress shortens a displayed address to its first and last characters, e.g. 0x7fa758...f168
+func shortAddress(addr string) string {
+	if len(addr) <= 16 {
+		return addr
+	}
+	return addr[:8] + "..." + addr[len(addr)-4:]
+}
+
+// fetchBalancesTimeout gets the balances of the provided accounts. It returns nil when the node
+// doesn't answer within the timeout.
+func (r *repl) fetchBalancesTimeout(accounts []*common.LocalAccount, timeout time.Duration) []accountBalance {
+	done := make(chan []accountBalance, 1)
+	ctx := r.ctx
+	go func() {
+		done <- r.fetchBalances(ctx, accounts)
+	}()
+	select {
+	case res := <-done:
+		return res
+	case <-time.After(timeout):
+		log.Info("timed out fetching account balances")
+		return nil
+	}
+}
+
+// accountListing returns one aligned line per account with a current account marker, alias, short
+// address, balance when the node is reachable, origin, tags and note.
+func (r *repl) accountListing(accounts []*common.LocalAccount) []string {
+	currentName := ""
+	if current, err := r.client.CurrentAccount(); err == nil {
+		currentName = current.Name
+	}
+	balances := r.fetchBalancesTimeout(accounts, listBalancesTimeout)
+
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for i, acc := range accounts {
+		marker := " "
+		if acc.Name == currentName {
+			marker = "*"
+		}
+		balance := ""
+		if balances != nil && balances[i].err == nil {
+			balance = common.FormatAmount(balanceValue(balances[i].state.StateCurrent))
+		}
+		fmt.Fprintf(tw, "%s %s\t%s\t%s\t%s\t%s\n", marker, acc.Name, shortAddress(r.formatAddress(acc.Address())), balance,
+			acc.Origin, accountAnnotation(acc))
+	}
+	_ = tw.Flush()
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	return lines
+}
+
+// listAccounts prints the open wallet's accounts
+func (r *repl) listAccounts() {
+	accounts, err := r.localAccounts()
+	if err != nil {
+		log.Error("failed to list accounts: %v", err)
+		return
+	}
+	if len(accounts) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "The wallet has no accounts")
+		return
+	}
+	for i, line := range r.accountListing(accounts) {
+		fmt.Fprintln(r.out, i+1, printPrefix, line)
+	}
+}
@@ -0,0 +1,202 @@
+package repl
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// activityPageSize is the number of events printed per page of the activity feed
+const activityPageSize = 25
+
+// accountActivity fetches the mesh transactions, receipts and rewards of an account and merges them
+// into one feed, newest first. A source which can't be read is reported and left out.
+func (r *repl) accountActivity(address gosmtypes.Address) []common.ActivityEvent {
+	txs, err := allMeshTransactions(r.ctx, r.client, address)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "WARNING: can't get the transactions, they are left out:", err)
+	}
+	receipts, err := allReceipts(r.ctx, r.client, address)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "WARNING: can't get the transaction receipts, they are left out:", err)
+	}
+	rewards, _, err := r.client.AccountRewards(r.ctx, address, 0, 0)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "WARNING: can't get the rewards, they are left out:", err)
+	}
+
+	var txEvents, receiptEvents, rewardEvents []common.ActivityEvent
+	for _, record := range accountTxRecords(address, txs, receipts, nil) {
+		event := common.ActivityEvent{Layer: record.Layer, Kind: record.Direction, Amount: record.Amount, TxID: record.ID}
+		if record.Direction == common.DirectionIn {
+			event.Counterparty = record.Sender
+		} else {
+			event.Counterparty = record.Recipient
+			event.Fee = record.Fee
+		}
+		txEvents = append(txEvents, event)
+	}
+	for _, receipt := range receipts {
+		event := common.ActivityEvent{Kind: common.ActivityReceipt, Fee: receipt.GetFee().GetValue(), TxID: fmt.Sprintf("0x%x", receipt.GetId().GetId())}
+		if receipt.GetLayer() != nil {
+			layer := receipt.GetLayer().GetNumber()
+			event.Layer = &layer
+		}
+		receiptEvents = append(receiptEvents, event)
+	}
+	for _, reward := range rewards {
+		event := common.ActivityEvent{Kind: common.ActivityReward, Amount: reward.GetTotal().GetValue()}
+		if reward.Layer != nil {
+			layer := reward.Layer.Number
+			event.Layer = &layer
+		}
+		rewardEvents = append(rewardEvents, event)
+	}
+	return common.MergeActivity(txEvents, receiptEvents, rewardEvents)
+}
+
+// activityLine returns the display line of an activity event
+func (r *repl) activityLine(e common.ActivityEvent) string {
+	layer := "pending     "
+	if e.Layer != nil {
+		layer = fmt.Sprintf("layer %-6d", *e.Layer)
+	}
+	counterparty := ""
+	if e.Counterparty != "" {
+		counterparty = r.addressString(gosmtypes.HexToAddress(e.Counterparty))
+	}
+	switch e.Kind {
+	case common.ActivityReward:
+		return fmt.Sprintf("%s  +%s  reward", layer, common.FormatAmount(e.Amount))
+	case common.DirectionIn:
+		return fmt.Sprintf("%s  +%s ← %s", layer, common.FormatAmount(e.Amount), counterparty)
+	case common.DirectionOut:
+		if counterparty == "" {
+			return fmt.Sprintf("%s  -%s  contract transaction %s (fee %s)", layer, common.FormatAmount(e.Amount), e.TxID, common.FormatAmount(e.Fee))
+		}
+		return fmt.Sprintf("%s  -%s → %s (fee %s)", layer, common.FormatAmount(e.Amount), counterparty, common.FormatAmount(e.Fee))
+	case common.DirectionSelf:
+		return fmt.Sprintf("%s   %s to self (fee %s)", layer, common.FormatAmount(e.Amount), common.FormatAmount(e.Fee))
+	}
+	return fmt.Sprintf("%s  -%s  fee of transaction %s", layer, common.FormatAmount(e.Fee), e.TxID)
+}
+
+// printAccountActivity prints the activity feed of the current account a page at a time, or
+// exports all of it with --csv
+func (r *repl) printAccountActivity() {
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	page := 1
+	if s, ok := flagValue(r.args, "--page"); ok {
+		if page, err = strconv.Atoi(s); err != nil || page < 1 {
+			fmt.Fprintln(r.out, printPrefix, "invalid page:", s)
+			return
+		}
+	}
+	events := r.accountActivity(acc.Address())
+
+	if path, ok := flagValue(r.args, "--csv"); ok {
+		f, err := os.Create(path)
+		if err != nil {
+			log.Error("failed to create export file: %v", err)
+			return
+		}
+		err = common.WriteActivityCSV(f, events)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			log.Error("failed to write export file: %v", err)
+			return
+		}
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Exported %d events to: %s", len(events), path))
+		return
+	}
+
+	if len(events) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "No activity")
+		return
+	}
+	pages := (len(events) + activityPageSize - 1) / activityPageSize
+	if page > pages {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("There are only %d pages", pages))
+		return
+	}
+	first := (page - 1) * activityPageSize
+	last := first + activityPageSize
+	if last > len(events) {
+		last = len(events)
+	}
+	for _, e := range events[first:last] {
+		fmt.Fprintln(r.out, printPrefix, r.activityLine(e))
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Page %d of %d, %d events. Use --page <n> for other pages.", page, pages, len(events)))
+}
+
+// exportAccountReport writes an accounting report of the current account for a range of layers or
+// dates and checks its final running balance against the balance of the account
+func (r *repl) exportAccountReport() {
+	if len(r.args) != 3 {
+		fmt.Fprintln(r.out, printPrefix, "usage: account report <from layer|date> <to layer|date> <file.csv>")
+		return
+	}
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	info, err := r.client.GetMeshInfo(r.ctx)
+	if err != nil {
+		log.Error("failed to get mesh info: %v", err)
+		return
+	}
+	from, err := common.ParseReportBound(r.args[0], info, false)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	to, err := common.ParseReportBound(r.args[1], info, true)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	if from > to {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("The range is empty: layer %d is after layer %d", from, to))
+		return
+	}
+
+	address := acc.Address()
+	rows, balance := common.BuildReport(r.accountActivity(address), from, to, info)
+	path := r.args[2]
+	f, err := os.Create(path)
+	if err != nil {
+		log.Error("failed to create report file: %v", err)
+		return
+	}
+	err = common.WriteReportCSV(f, rows)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Error("failed to write report file: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Wrote %d rows for layers %d to %d to: %s", len(rows), from, to, path))
+
+	state, err := r.client.AccountState(r.ctx, address)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "WARNING: can't get the account balance to check the report:", err)
+		return
+	}
+	if actual := currentBalance(state); !balance.IsUint64() || balance.Uint64() != actual {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("WARNING: the running balance %s Smidge differs from the account balance %s. Some activity may be missing.", balance, common.FormatSmidge(actual)))
+	}
+}
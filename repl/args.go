@@ -0,0 +1,70 @@
+package repl
+
+import "strings"
+
+// hasFlag returns true if a boolean flag such as --total is present in args
+func hasFlag(args []string, name string) bool {
+	for _, a := range args {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+// flagValue returns the value following a flag such as --out <path> in args
+func flagValue(args []string, name string) (string, bool) {
+	for i, a := range args {
+		if a == name && i+1 < len(args) {
+			return args[i+1], true
+		}
+		if strings.HasPrefix(a, name+"=") {
+			return strings.TrimPrefix(a, name+"="), true
+		}
+	}
+	return "", false
+}
+
+// positionalArgs returns the args which are not flags or flag values. valueFlags lists the flags
+// which take a value.
+func positionalArgs(args []string, valueFlags ...string) []string {
+	res := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if strings.HasPrefix(a, "--") {
+			if !strings.Contains(a, "=") {
+				for _, f := range valueFlags {
+					if a == f {
+						i++
+						break
+					}
+				}
+			}
+			continue
+		}
+		res = append(res, a)
+	}
+	return res
+}
+
+// accountArg removes an --account <alias> flag or @alias shorthand from args. It returns the
+// selected account alias, empty if none, and the remaining args.
+func accountArg(args []string) (string, []string) {
+	alias := ""
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--account" && i+1 < len(args):
+			alias = args[i+1]
+			i++
+		case strings.HasPrefix(a, "--account="):
+			alias = strings.TrimPrefix(a, "--account=")
+		case len(a) > 1 && strings.HasPrefix(a, "@"):
+			alias = strings.TrimPrefix(a, "@")
+		default:
+			rest = append(rest, a)
+		}
+	}
+	return alias, rest
+}
@@ -0,0 +1,68 @@
+package repl
+
+import (
+	"fmt"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// printAccountStateAt prints the balance and nonce of an account at the end of a layer. The API
+// has no layer-scoped account state, so unless the layer is the one of the current global state
+// they are derived from the current state by undoing the account's rewards and transactions of
+// later layers, and labelled as not authoritative.
+func (r *repl) printAccountStateAt(account *apitypes.Account, address gosmtypes.Address, layer uint32) {
+	hash, err := r.client.GlobalStateHash(r.ctx)
+	if err != nil {
+		r.printNodeError(common.CallError("get global state", err))
+		return
+	}
+	stateLayer := hash.GetLayer().GetNumber()
+	fmt.Fprintln(r.out, printPrefix, "Address:", r.addressString(address))
+	if layer >= stateLayer {
+		if layer > stateLayer {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Layer %d isn't applied yet, the global state is at layer %d.", layer, stateLayer))
+			return
+		}
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Balance at layer %d: %s", layer, common.FormatAmount(currentBalance(account))))
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Nonce at layer %d: %d", layer, account.GetStateCurrent().GetCounter()))
+		return
+	}
+
+	rewards, err := allRewardPages(func(offset, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+		return r.client.AccountRewards(r.ctx, address, offset, maxResults)
+	})
+	if err != nil {
+		r.printNodeError(common.CallError("get rewards", err))
+		return
+	}
+	txs, err := allMeshTransactions(r.ctx, r.client, address)
+	if err != nil {
+		r.printNodeError(common.CallError("get transactions", err))
+		return
+	}
+	receipts, err := allReceipts(r.ctx, r.client, address)
+	if err != nil {
+		r.printNodeError(common.CallError("get transaction receipts", err))
+		return
+	}
+
+	derived, err := common.DeriveBalanceAt(layer, currentBalance(account), account.GetStateCurrent().GetCounter(),
+		rewardRecords(rewards, nil), accountTxRecords(address, txs, receipts, nil))
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "The balance can't be derived:", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, colorYellow+"Derived, not authoritative: the node can't report past account state."+colorReset)
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Balance at layer %d: %s", layer, common.FormatAmount(derived.Balance)))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Nonce at layer %d: %d", layer, derived.Nonce))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Worked out from the balance of %s at layer %d by undoing %d rewards and transactions:",
+		common.FormatAmount(currentBalance(account)), stateLayer, derived.Undone))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("  rewards -%s, received -%s, sent +%s, fees +%s",
+		common.FormatAmount(derived.Rewards), common.FormatAmount(derived.Received), common.FormatAmount(derived.Sent), common.FormatAmount(derived.Fees)))
+	if derived.Unplaced > 0 {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%d transactions without a layer yet were left out.", derived.Unplaced))
+	}
+}
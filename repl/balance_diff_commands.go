@@ -0,0 +1,161 @@
+package repl
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// accountSnapshot is the state of an account and the layer of the global state it was read at
+type accountSnapshot struct {
+	account *apitypes.Account
+	layer   uint32
+}
+
+// snapshotAccount reads the layer of the global state and the state of an account. They are two
+// calls, so a layer applied in between is attributed to the next snapshot.
+func (r *repl) snapshotAccount(address gosmtypes.Address) (*accountSnapshot, error) {
+	hash, err := r.client.GlobalStateHash(r.ctx)
+	if err != nil {
+		return nil, common.CallError("get global state", err)
+	}
+	account, err := r.client.AccountState(r.ctx, address)
+	if err != nil {
+		return nil, common.CallError("get the account state", err)
+	}
+	return &accountSnapshot{account: account, layer: hash.GetLayer().GetNumber()}, nil
+}
+
+// diffAccountState reads the state of an account, waits for Enter or --wait <duration>, reads it
+// again and prints the changes of the balances and nonces. The change of the balance is itemized by
+// the rewards and transactions of the layers applied in between, and the part they don't explain
+// is flagged.
+func (r *repl) diffAccountState() {
+	args := positionalArgs(r.args, "--wait")
+	if len(args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: state diff <address> [--wait <duration>]")
+		return
+	}
+	address, err := r.resolveAddress(args[0])
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	wait := time.Duration(0)
+	if s, ok := flagValue(r.args, "--wait"); ok {
+		if wait, err = time.ParseDuration(s); err != nil || wait <= 0 {
+			fmt.Fprintln(r.out, printPrefix, "invalid wait, expected a duration such as 30s or 10m:", s)
+			return
+		}
+	}
+
+	first, err := r.snapshotAccount(address)
+	if err != nil {
+		r.printNodeError(err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Balance %s, nonce %d at layer %d.", common.FormatAmount(currentBalance(first.account)),
+		first.account.GetStateCurrent().GetCounter(), first.layer))
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	enter := r.enterPressed()
+	var timeout <-chan time.Time
+	if wait > 0 {
+		timeout = time.After(wait)
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Comparing again in %s, press Enter to compare now or Ctrl+C to cancel...", wait))
+	} else {
+		fmt.Fprintln(r.out, printPrefix, "Press Enter to compare again or Ctrl+C to cancel...")
+	}
+	select {
+	case <-interrupt:
+		fmt.Fprintln(r.out, printPrefix, "Cancelled, press Enter to return to the prompt.")
+		<-enter
+		return
+	case <-enter:
+		timeout = nil
+	case <-timeout:
+	}
+
+	if second, err := r.snapshotAccount(address); err != nil {
+		r.printNodeError(err)
+	} else {
+		r.printAccountDiff(address, first, second)
+	}
+	if timeout != nil {
+		// the input is still read until Enter
+		fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+		<-enter
+	}
+}
+
+// printAccountDiff prints the changes of an account between two snapshots with the rewards and
+// transactions which explain them
+func (r *repl) printAccountDiff(address gosmtypes.Address, first, second *accountSnapshot) {
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Changes of %s from layer %d to layer %d:", r.addressString(address), first.layer, second.layer))
+	for _, state := range []struct {
+		name          string
+		before, after *apitypes.AccountState
+	}{
+		{"", first.account.GetStateCurrent(), second.account.GetStateCurrent()},
+		{"Projected ", first.account.GetStateProjected(), second.account.GetStateProjected()},
+	} {
+		balance, nonce := state.after.GetBalance().GetValue(), state.after.GetCounter()
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%sBalance: %s (%s)", state.name, common.FormatAmount(balance), amountChange(state.before.GetBalance().GetValue(), balance)))
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%sNonce: %d (%+d)", state.name, nonce, int64(nonce)-int64(state.before.GetCounter())))
+	}
+
+	rewards, err := allRewardPages(func(offset, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+		return r.client.AccountRewards(r.ctx, address, offset, maxResults)
+	})
+	if err != nil {
+		r.printNodeError(common.CallError("get rewards", err))
+		return
+	}
+	txs, err := allMeshTransactions(r.ctx, r.client, address)
+	if err != nil {
+		r.printNodeError(common.CallError("get transactions", err))
+		return
+	}
+	receipts, err := allReceipts(r.ctx, r.client, address)
+	if err != nil {
+		r.printNodeError(common.CallError("get transaction receipts", err))
+		return
+	}
+	reconciliation := common.ReconcileBalance(currentBalance(first.account), currentBalance(second.account),
+		first.account.GetStateCurrent().GetCounter(), second.account.GetStateCurrent().GetCounter(),
+		first.layer, second.layer, rewardRecords(rewards, nil), accountTxRecords(address, txs, receipts, nil))
+
+	if len(reconciliation.Items) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "No rewards or transactions were applied in between.")
+	}
+	for _, item := range reconciliation.Items {
+		sign := "-"
+		if item.Credit {
+			sign = "+"
+		}
+		line := fmt.Sprintf("  layer %-6d %-8s %s%s", item.Layer, item.Kind, sign, common.FormatAmount(item.Amount))
+		if item.ID != "" {
+			line += "  " + item.ID
+		}
+		fmt.Fprintln(r.out, printPrefix, line)
+	}
+	if amount, decrease := reconciliation.Unexplained(); amount != 0 {
+		sign := "+"
+		if decrease {
+			sign = "-"
+		}
+		fmt.Fprintln(r.out, printPrefix, colorRed+fmt.Sprintf("Unexplained: %s%s of the balance change isn't explained by the rewards and transactions the node reports.", sign, common.FormatAmount(amount))+colorReset)
+	} else {
+		fmt.Fprintln(r.out, printPrefix, colorGreen+"The balance change is fully explained."+colorReset)
+	}
+	if n := reconciliation.UnexplainedNonce(); n != 0 {
+		fmt.Fprintln(r.out, printPrefix, colorRed+fmt.Sprintf("Unexplained: the nonce changed by %+d beyond the %d transactions sent.", n, reconciliation.Sent)+colorReset)
+	}
+}
@@ -0,0 +1,137 @@
+package repl
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// defaultBalancePollInterval is the time between account state polls of state watch when the node
+// doesn't stream account updates and --poll isn't given
+const defaultBalancePollInterval = 10 * time.Second
+
+// watchBalance prints a line whenever the balance or nonce of an address changes, until Enter or
+// Ctrl+C is pressed, then a summary. The account updates are streamed, or the account state is
+// polled every --poll seconds or when the node doesn't stream them. --alert-below <amount> and
+// --alert-on-increase ring the bell and run the notify hook with the balance, the address and the
+// alert.
+func (r *repl) watchBalance() {
+	args := positionalArgs(r.args, "--poll", "--alert-below")
+	if len(args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: state watch <address> [--poll <seconds>] [--alert-below <amount>] [--alert-on-increase]")
+		return
+	}
+	address, err := r.resolveAddress(args[0])
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	alert := common.BalanceAlert{OnIncrease: hasFlag(r.args, "--alert-on-increase")}
+	if s, ok := flagValue(r.args, "--alert-below"); ok {
+		if alert.Below, err = common.ParseAmount(s); err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+		alert.BelowSet = true
+	}
+	interval := time.Duration(0)
+	if s, ok := flagValue(r.args, "--poll"); ok {
+		seconds, err := strconv.ParseUint(s, 10, 32)
+		if err != nil || seconds == 0 {
+			fmt.Fprintln(r.out, printPrefix, "invalid poll interval, expected a number of seconds:", s)
+			return
+		}
+		interval = time.Duration(seconds) * time.Second
+	}
+
+	state, err := r.client.AccountState(r.ctx, address)
+	if err != nil {
+		r.printNodeError(common.CallError("get the account state", err))
+		return
+	}
+	start := currentBalance(state)
+	balance, nonce := start, state.GetStateCurrent().GetCounter()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	enter := r.enterPressed()
+	updates := make(chan *apitypes.Account)
+	failed := make(chan error)
+	stop := make(chan struct{})
+	defer close(stop)
+	var poll <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		poll = ticker.C
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Polling %s every %s, press Enter or Ctrl+C to stop...", r.addressString(address), interval))
+	} else {
+		go r.streamAccountUpdates(r.ctx, address, updates, failed, stop)
+		fmt.Fprintln(r.out, printPrefix, "Watching", r.addressString(address)+", press Enter or Ctrl+C to stop...")
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Balance: %s, nonce %d", common.FormatAmount(balance), nonce))
+
+	changes, alerts := 0, 0
+	update := func(account *apitypes.Account) {
+		newBalance, newNonce := currentBalance(account), account.GetStateCurrent().GetCounter()
+		if newBalance == balance && newNonce == nonce {
+			return
+		}
+		changes++
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%s  balance %s (%s), nonce %d", time.Now().Format(layerTimeFormat),
+			common.FormatAmount(newBalance), amountChange(balance, newBalance), newNonce))
+		switch alert.Check(balance, newBalance) {
+		case common.AlertBelow:
+			alerts++
+			fmt.Fprint(r.out, "\a")
+			fmt.Fprintln(r.out, printPrefix, colorRed+"ALERT: the balance dropped below "+common.FormatAmount(alert.Below)+colorReset)
+			r.runNotifyHook(strconv.FormatUint(newBalance, 10), address.String(), common.AlertBelow)
+		case common.AlertIncrease:
+			alerts++
+			fmt.Fprint(r.out, "\a")
+			fmt.Fprintln(r.out, printPrefix, colorGreen+"ALERT: the balance increased by "+common.FormatAmount(newBalance-balance)+colorReset)
+			r.runNotifyHook(strconv.FormatUint(newBalance, 10), address.String(), common.AlertIncrease)
+		}
+		balance, nonce = newBalance, newNonce
+	}
+
+	for {
+		select {
+		case account := <-updates:
+			update(account)
+			continue
+		case <-poll:
+			if account, err := r.client.AccountState(r.ctx, address); err != nil {
+				fmt.Fprintln(r.out, printPrefix, "Can't get the account state:", err)
+			} else {
+				update(account)
+			}
+			continue
+		case err := <-failed:
+			if permanentStreamError(err) && poll == nil {
+				fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("The node doesn't stream account updates, polling every %s instead.", defaultBalancePollInterval))
+				ticker := time.NewTicker(defaultBalancePollInterval)
+				defer ticker.Stop()
+				poll = ticker.C
+				continue
+			}
+			r.printNodeError(common.CallError("watch the account", err))
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-interrupt:
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-enter:
+		}
+		break
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Stopped watching after %d changes and %d alerts. Balance %s, %s since the start.",
+		changes, alerts, common.FormatAmount(balance), amountChange(start, balance)))
+}
@@ -0,0 +1,102 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"text/tabwriter"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// balanceWorkers is the max number of concurrent account state requests
+const balanceWorkers = 4
+
+type accountBalance struct {
+	account *common.LocalAccount
+	state   *apitypes.Account
+	err     error
+}
+
+// fetchBalances gets the global state of the provided accounts using a bounded pool of workers.
+// Accounts unknown to the node get an empty state.
+func (r *repl) fetchBalances(ctx context.Context, accounts []*common.LocalAccount) []accountBalance {
+	res := make([]accountBalance, len(accounts))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < balanceWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				state, err := r.client.AccountState(ctx, accounts[i].Address())
+				if status.Code(err) == codes.NotFound {
+					state, err = &apitypes.Account{}, nil
+				}
+				res[i] = accountBalance{account: accounts[i], state: state, err: err}
+			}
+		}()
+	}
+	for i := range accounts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return res
+}
+
+// balanceValue returns the balance of an account state or 0 when the state has no balance
+func balanceValue(state *apitypes.AccountState) uint64 {
+	if state == nil || state.Balance == nil {
+		return 0
+	}
+	return state.Balance.Value
+}
+
+// printBalances prints the balances of all the wallet's accounts
+func (r *repl) printBalances() {
+	accounts, err := r.localAccounts()
+	if err != nil {
+		log.Error("failed to list accounts: %v", err)
+		return
+	}
+
+	currentName := ""
+	if current, err := r.client.CurrentAccount(); err == nil {
+		currentName = current.Name
+	}
+
+	var total, totalProjected uint64
+	tw := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, " \tAlias\tAddress\tBalance\tProjected balance\tNonce\tNotes")
+	for _, b := range r.fetchBalances(r.ctx, accounts) {
+		marker := " "
+		if b.account.Name == currentName {
+			marker = "*"
+		}
+		if b.err != nil {
+			fmt.Fprintf(tw, "%s\t%s\t%s\terror: %v\t\t\t%s\n", marker, b.account.Name, r.formatAddress(b.account.Address()), b.err,
+				accountAnnotation(b.account))
+			continue
+		}
+		balance := balanceValue(b.state.StateCurrent)
+		projected := balanceValue(b.state.StateProjected)
+		total += balance
+		totalProjected += projected
+		nonce := uint64(0)
+		if b.state.StateCurrent != nil {
+			nonce = b.state.StateCurrent.Counter
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", marker, b.account.Name, r.formatAddress(b.account.Address()),
+			common.FormatAmount(balance), common.FormatAmount(projected), nonce, accountAnnotation(b.account))
+	}
+	if hasFlag(r.args, "--total") {
+		fmt.Fprintf(tw, " \tTotal\t\t%s\t%s\t\t\n", common.FormatAmount(total), common.FormatAmount(totalProjected))
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,212 @@
+package repl
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/c-bata/go-prompt"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// remainderFileSuffix is appended to a batch file name to get the name of the file with the unsent payments
+const remainderFileSuffix = ".remaining.csv"
+
+// sendBatch sends the payments of a CSV file of address,amount,note rows from the current account.
+// When a transaction fails, the unsent rows are written to a file so the batch can be resumed with it.
+func (r *repl) sendBatch() {
+	args := positionalArgs(r.args, "--nonce")
+	if len(args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: account send-batch <file> [--dry-run [--show-raw]] [--nonce <n>]")
+		return
+	}
+	path := args[0]
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Error("failed to read batch file: %v", err)
+		return
+	}
+	rows, err := common.ParseBatchCSV(bytes.NewReader(data), r.resolveAddress)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	r.sendPayments(rows, path+remainderFileSuffix)
+}
+
+// sendPayments sends payments from the current account. All payments are checked before anything
+// is sent and one confirmation covers them all. The transactions are submitted one by one with
+// consecutive nonces. When one fails, sending stops and the unsent payments are written to
+// remainderPath, or printed when it is empty. With --dry-run the transactions are signed but not
+// submitted.
+func (r *repl) sendPayments(rows []common.BatchRow, remainderPath string) {
+	dryRun := hasFlag(r.args, "--dry-run")
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	key, err := acc.SigningKey()
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	defer key.Release()
+
+	if !dryRun && !r.canSubmitTransactions() {
+		fmt.Fprintln(r.out, printPrefix, "Can't submit a new transaction. Please try again later")
+		return
+	}
+	srcAddress := acc.Address()
+	state, err := r.client.AccountState(r.ctx, srcAddress)
+	if err != nil {
+		log.Error("failed to get account info: %v", err)
+		return
+	}
+	balance := uint64(0)
+	if state.StateProjected.Balance != nil {
+		balance = state.StateProjected.Balance.Value
+	}
+	nonce, err := r.transactionNonce(srcAddress)
+	if err != nil {
+		log.Error("invalid nonce", err)
+		return
+	}
+
+	gasPrice, gasLimit := r.defaultGas(acc)
+	amounts, fees, err := common.BatchTotal(rows, gasPrice.value, gasLimit.value)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+
+	tw := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, printPrefix+"\tLine\tTo\tAmount\tNote")
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", printPrefix, row.Line, r.addressString(row.Recipient), common.FormatAmount(row.Amount), row.Note)
+	}
+	tw.Flush()
+	fmt.Fprintln(r.out, printPrefix, "Payments:", len(rows))
+	fmt.Fprintln(r.out, printPrefix, "Total amount:", common.FormatAmount(amounts))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Maximum fees: %s (gas price %d, gas limit %d)", common.FormatAmount(fees), gasPrice.value, gasLimit.value))
+	fmt.Fprintln(r.out, printPrefix, "Grand total:", common.FormatAmount(amounts+fees))
+	fmt.Fprintln(r.out, printPrefix, "Projected balance:", common.FormatAmount(balance))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Nonces: %d to %d (%s)", nonce.value, nonce.value+uint64(len(rows))-1, nonce.source))
+	if amounts+fees > balance {
+		fmt.Fprintln(r.out, printPrefix, "The grand total exceeds the projected balance of the account.")
+		return
+	}
+
+	recipients := make([]gosmtypes.Address, 0, len(rows))
+	for _, row := range rows {
+		recipients = append(recipients, row.Recipient)
+	}
+	if !r.confirmNonce(srcAddress, nonce) || !r.confirmGasLimit(gasLimit.value) || !r.confirmRecipients(srcAddress, recipients...) {
+		return
+	}
+
+	if dryRun {
+		for i, row := range rows {
+			tx, err := r.client.SignTransfer(row.Recipient, nonce.value+uint64(i), row.Amount, gasPrice.value, gasLimit.value, key)
+			if err != nil {
+				log.Error("failed to sign transaction: %v", err)
+				return
+			}
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Line %d: transaction id 0x%x, %d bytes", row.Line, tx.ID, len(tx.Signed)))
+			if hasFlag(r.args, "--show-raw") {
+				r.printRawTransaction(tx)
+			}
+		}
+		fmt.Fprintln(r.out, printPrefix, "Dry run: transactions NOT SUBMITTED.")
+		return
+	}
+	if !r.confirmSpendLimit(amounts) {
+		return
+	}
+	if r.yesOrNoQuestion(fmt.Sprintf(confirmBatchMsg, len(rows))) != "y" {
+		return
+	}
+
+	for i, row := range rows {
+		txState, err := r.client.Transfer(r.ctx, row.Recipient, nonce.value+uint64(i), row.Amount, gasPrice.value, gasLimit.value, key)
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Line %d failed:", row.Line))
+			r.printNodeError(err)
+			r.reportBatchFailure(rows, i, remainderPath)
+			return
+		}
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Line %d: transaction id 0x%x", row.Line, txState.Id.Id))
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Sent: %d submitted, 0 failed.", len(rows)))
+}
+
+// reportBatchFailure reports the payments sent before the one at failed and writes the unsent ones
+// to a remainder file, or prints them when remainderPath is empty
+func (r *repl) reportBatchFailure(rows []common.BatchRow, failed int, remainderPath string) {
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Stopped: %d submitted, 1 failed, %d not sent.", failed, len(rows)-failed-1))
+	for _, row := range rows[:failed] {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Submitted: line %d", row.Line))
+	}
+	var buf bytes.Buffer
+	if err := common.WriteBatchCSV(&buf, rows[failed:]); err != nil {
+		log.Error("failed to encode the unsent payments: %v", err)
+		return
+	}
+	if remainderPath == "" {
+		fmt.Fprintln(r.out, printPrefix, "Unsent payments:")
+		fmt.Fprint(r.out, buf.String())
+		return
+	}
+	if err := common.WritePrivateFile(remainderPath, buf.Bytes()); err != nil {
+		log.Error("failed to write the unsent payments: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Unsent payments written to:", remainderPath)
+}
+
+// sendMulti prompts for recipients and amounts until a blank recipient is entered and sends the
+// payments like send-batch
+func (r *repl) sendMulti() {
+	var rows []common.BatchRow
+	var total uint64
+	for {
+		input := r.promptInput(r.prefix+multiRecipientMsg, r.contactsCompleter, prompt.OptionPrefixTextColor(prompt.LightGray))
+		input = strings.TrimSpace(input)
+		if input == "" {
+			break
+		}
+		recipient, source, err := r.resolveRecipient(input)
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			continue
+		}
+		amount, err := common.ParseAmount(r.inputNotBlank(multiAmountMsg))
+		if err != nil || amount == 0 {
+			fmt.Fprintln(r.out, printPrefix, "invalid amount, the recipient is skipped")
+			continue
+		}
+		if total+amount < total {
+			fmt.Fprintln(r.out, printPrefix, "the total overflows, the recipient is skipped")
+			continue
+		}
+		total += amount
+		rows = append(rows, common.BatchRow{
+			Line:      len(rows) + 1,
+			Address:   input,
+			Recipient: recipient,
+			Amount:    amount,
+			Note:      source,
+		})
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%d recipients, running total: %s", len(rows), common.FormatAmount(total)))
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "No recipients entered.")
+		return
+	}
+	r.sendPayments(rows, "")
+}
@@ -0,0 +1,177 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+const (
+	// defaultBenchCount is how many times each call is made unless --count is given
+	defaultBenchCount = 20
+	// defaultBenchParallel is how many calls run at once unless --parallel is given
+	defaultBenchParallel = 4
+	// benchRewardsPage is the number of rewards fetched by the rewards call
+	benchRewardsPage = 100
+)
+
+// benchCall is a read call of the node benchmark
+type benchCall struct {
+	name string
+	call func() error
+}
+
+// benchCalls returns the read calls of the node benchmark, made with ctx. The account calls are
+// made when there is an address. It never includes calls which submit transactions or change the
+// smesher.
+func (r *repl) benchCalls(ctx context.Context, address *gosmtypes.Address) []benchCall {
+	calls := []benchCall{
+		{"Echo", func() error { return r.client.Echo(ctx) }},
+		{"NodeStatus", func() error { _, err := r.client.NodeStatus(ctx); return err }},
+		{"GetMeshInfo", func() error { _, err := r.client.GetMeshInfo(ctx); return err }},
+	}
+	if address != nil {
+		calls = append(calls,
+			benchCall{"AccountState", func() error { _, err := r.client.AccountState(ctx, *address); return err }},
+			benchCall{"AccountRewards", func() error {
+				_, _, err := r.client.AccountRewards(ctx, *address, 0, benchRewardsPage)
+				return err
+			}})
+	}
+	return calls
+}
+
+// benchAddress returns the address of the account calls of the benchmark: the --address flag, or
+// the current account, or nil when there is neither
+func (r *repl) benchAddress() (*gosmtypes.Address, error) {
+	if s, ok := flagValue(r.args, "--address"); ok {
+		address, err := r.resolveAddress(s)
+		if err != nil {
+			return nil, err
+		}
+		return &address, nil
+	}
+	if r.clientOpen {
+		if account, err := r.client.CurrentAccount(); err == nil {
+			address := account.Address()
+			return &address, nil
+		}
+	}
+	return nil, nil
+}
+
+// positiveIntFlag returns the value of a flag which must be a positive number, or def when the flag
+// isn't given
+func positiveIntFlag(args []string, name string, def int) (int, error) {
+	s, ok := flagValue(args, name)
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid %s: %s", name, s)
+	}
+	return n, nil
+}
+
+// nodeBench makes each read call of the benchmark count times, up to parallel calls at once, and
+// prints the latency percentiles and the errors of each call type. Ctrl+C stops the calls not
+// started yet. The calls bypass the client cache so every one reaches the node.
+func (r *repl) nodeBench() {
+	const usage = "usage: status node-bench [--count <n>] [--parallel <n>] [--address <address>] [--json]"
+	count, err := positiveIntFlag(r.args, "--count", defaultBenchCount)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		fmt.Fprintln(r.out, printPrefix, usage)
+		return
+	}
+	parallel, err := positiveIntFlag(r.args, "--parallel", defaultBenchParallel)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		fmt.Fprintln(r.out, printPrefix, usage)
+		return
+	}
+	address, err := r.benchAddress()
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	calls := r.benchCalls(common.WithoutCache(r.ctx), address)
+	jsonOutput := hasFlag(r.args, "--json")
+	if !jsonOutput {
+		if address == nil {
+			fmt.Fprintln(r.out, printPrefix, "No address given and no current account, skipping the account calls.")
+		}
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Benchmarking %s: %d calls of each type, %d at a time...",
+			r.client.ServerInfo(), count, parallel))
+	}
+
+	type sample struct {
+		call    int
+		latency time.Duration
+		err     error
+	}
+	jobs := make(chan int)
+	samples := make(chan sample)
+	var workers sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for call := range jobs {
+				start := time.Now()
+				err := calls[call].call()
+				samples <- sample{call, time.Since(start), err}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := 0; i < count; i++ {
+			for call := range calls {
+				if r.ctx.Err() != nil {
+					// Ctrl+C was pressed
+					return
+				}
+				jobs <- call
+			}
+		}
+	}()
+	go func() {
+		workers.Wait()
+		close(samples)
+	}()
+
+	latencies := make([][]time.Duration, len(calls))
+	errors := make([]int, len(calls))
+	for s := range samples {
+		if s.err != nil {
+			errors[s.call]++
+		} else {
+			latencies[s.call] = append(latencies[s.call], s.latency)
+		}
+	}
+	results := make([]common.BenchResult, len(calls))
+	for i, c := range calls {
+		results[i] = common.NewBenchResult(c.name, latencies[i], errors[i])
+	}
+
+	if jsonOutput {
+		r.printJSON(results)
+		return
+	}
+	tw := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, printPrefix+"\tCall\tCalls\tErrors\tp50 ms\tp90 ms\tp99 ms\tmax ms")
+	for _, result := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.1f\t%.1f\t%.1f\t%.1f\n", printPrefix, result.Call, result.Calls,
+			result.Errors, result.P50, result.P90, result.P99, result.Max)
+	}
+	tw.Flush()
+}
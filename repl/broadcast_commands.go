@@ -0,0 +1,95 @@
+package repl
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// readSignedTransaction returns the bytes of a signed transaction given as a hex string or as the
+// path of a file. The file holds hex, the JSON written by tx sign --json, or raw bytes.
+func readSignedTransaction(arg string) ([]byte, error) {
+	if _, err := os.Stat(arg); err != nil {
+		tx, err := hex.DecodeString(trimHexPrefix(arg))
+		if err != nil {
+			return nil, fmt.Errorf("%s is neither a file nor hex data", arg)
+		}
+		return tx, nil
+	}
+	data, err := ioutil.ReadFile(arg)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var envelope signedTxEnvelope
+		if err := json.Unmarshal(trimmed, &envelope); err != nil {
+			return nil, fmt.Errorf("invalid signed transaction file: %v", err)
+		}
+		trimmed = []byte(envelope.Tx)
+	}
+	if tx, err := hex.DecodeString(trimHexPrefix(string(trimmed))); err == nil {
+		return tx, nil
+	}
+	return data, nil
+}
+
+// printDecodedTransaction prints the fields of a transaction to broadcast
+func (r *repl) printDecodedTransaction(tx common.TxRequest) {
+	fmt.Fprintln(r.out, printPrefix, "To:    ", r.addressString(tx.Recipient))
+	fmt.Fprintln(r.out, printPrefix, "Amount:", common.FormatSmidge(tx.Amount))
+	fmt.Fprintln(r.out, printPrefix, "Gas price:", tx.GasPrice, coinUnitName)
+	// genvm transactions have no gas limit
+	if tx.GasLimit != 0 {
+		fmt.Fprintln(r.out, printPrefix, "Gas limit:", tx.GasLimit)
+	}
+	fmt.Fprintln(r.out, printPrefix, "Nonce: ", tx.Nonce)
+}
+
+// broadcastTransaction submits a transaction signed with tx sign, tx sign-offline or another tool.
+// The transaction is decoded and shown for confirmation before it is sent to the node. Multisig
+// transactions written by tx combine are shown and refused.
+func (r *repl) broadcastTransaction() {
+	if len(r.args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: tx broadcast <hex|file>")
+		return
+	}
+	data, err := readSignedTransaction(r.args[0])
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+
+	// the multisig encoding is checked first as it is strict: it rejects trailing bytes
+	if mtx, err := r.client.DecodeMultisigTransaction(data); err == nil {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Multisig transaction with %d signatures, threshold %d:", len(mtx.Signatures), mtx.Threshold))
+		r.printDecodedTransaction(mtx.InnerSerializableSignedTransaction.TxRequest())
+		fmt.Fprintln(r.out, printPrefix, multisigNotBroadcastMsg)
+		return
+	}
+	tx, err := r.client.DecodeSignedTransaction(data)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Signed %s transaction:", tx.Format))
+	fmt.Fprintln(r.out, printPrefix, "From:  ", r.addressString(tx.Sender))
+	r.printDecodedTransaction(tx.TxRequest)
+
+	if r.yesOrNoQuestion(confirmTransactionMsg) != "y" {
+		return
+	}
+	txState, err := r.client.SubmitCoinTransaction(r.ctx, data)
+	if err != nil {
+		r.printNodeError(err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Transaction submitted.")
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Transaction id: 0x%v", hex.EncodeToString(txState.Id.Id)))
+	fmt.Fprintln(r.out, printPrefix, "Transaction state:", transactionStateDisStringsMap[int32(txState.State.Number())])
+}
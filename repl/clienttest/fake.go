@@ -0,0 +1,328 @@
+// Package clienttest provides an in-memory fake of the client the REPL drives, so that command
+// handlers can be tested without a node or a wallet file.
+//
+// A Fake is seeded with local accounts, account states, rewards, transactions and receipts,
+// and records every call it receives. Any method can be made to fail with Errors and to be
+// slow with Latency. Streams send the messages of their slices and then wait for their
+// context to be cancelled.
+package clienttest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// ErrNotFaked is returned by the methods the fake has no behaviour for, e.g. the ones reading
+// wallet files, unless another error is scripted for them
+var ErrNotFaked = errors.New("not supported by the fake client")
+
+// Call is a call the fake received
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// String formats a call as Method(arg, ...)
+func (c Call) String() string {
+	s := c.Method + "("
+	for i, arg := range c.Args {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprint(arg)
+	}
+	return s + ")"
+}
+
+// Fake is an in-memory client. The zero value isn't usable, create one with New. Its exported
+// fields are read on each call and are set up before the fake is used.
+type Fake struct {
+	// Errors are returned by the methods they are keyed by, e.g. "AccountState"
+	Errors map[string]error
+	// Latency delays the calls of the methods it is keyed by. The delay ends early when the
+	// context of the call is cancelled.
+	Latency map[string]time.Duration
+
+	// Settings are returned by Config, the default settings when nil
+	Settings *common.Config
+	// Net is the network info returned by GetMeshInfo and NetworkParams, an error when nil
+	Net *common.NetInfo
+	// Status is returned by NodeStatus and ProbeStatus, an error when nil
+	Status *apitypes.NodeStatus
+	// StateHash is returned by GlobalStateHash and ServerStateHash, an error when nil
+	StateHash *apitypes.GlobalStateHash
+	// Stats are returned by CallStats until ResetCallStats clears them
+	Stats []common.CallStats
+	// Debug is set by SetGRPCDebug and returned by GRPCDebug
+	Debug bool
+	// Input is set by SetInput
+	Input *bufio.Reader
+	// Smeshing and SmesherId are the state of the node's smesher
+	Smeshing  bool
+	SmesherId []byte
+
+	// Messages sent by the streams of each kind, in order
+	AccountData  []*apitypes.AccountDataStreamResponse
+	GlobalState  []*apitypes.GlobalStateStreamResponse
+	Layers       []*apitypes.LayerStreamResponse
+	NodeStatuses []*apitypes.StatusStreamResponse
+	NodeErrors   []*apitypes.ErrorStreamResponse
+	PostProgress []*apitypes.PostDataCreationProgressStreamResponse
+	// StreamEnd is returned by streams once they have sent their messages. When nil they wait
+	// for their context to be cancelled instead.
+	StreamEnd error
+
+	mu       sync.Mutex
+	calls    []Call
+	open     bool
+	wallet   string
+	accounts []*common.LocalAccount
+	current  int
+	nonces   map[gosmtypes.Address]uint64
+	contacts []common.Contact
+	smeshers []common.SmesherContact
+	tmpl     []common.TxTemplate
+	multisig []common.MultisigAccount
+
+	states       map[gosmtypes.Address]*apitypes.Account
+	rewards      map[gosmtypes.Address][]*apitypes.Reward
+	smesherRew   map[string][]*apitypes.Reward
+	transactions map[gosmtypes.Address][]*apitypes.Transaction
+	receipts     map[gosmtypes.Address][]*apitypes.TransactionReceipt
+	txStates     map[string]*apitypes.TransactionState
+	txs          map[string]*apitypes.Transaction
+	activations  map[gosmtypes.Address][]*apitypes.Activation
+	submitted    map[gosmtypes.Address][]common.SubmittedTx
+	// streams is the number of streams whose context isn't done yet
+	streams int
+}
+
+// New returns a fake with an open wallet named test and no accounts
+func New() *Fake {
+	return &Fake{
+		Errors:       map[string]error{},
+		Latency:      map[string]time.Duration{},
+		open:         true,
+		wallet:       "test",
+		current:      -1,
+		nonces:       map[gosmtypes.Address]uint64{},
+		states:       map[gosmtypes.Address]*apitypes.Account{},
+		rewards:      map[gosmtypes.Address][]*apitypes.Reward{},
+		smesherRew:   map[string][]*apitypes.Reward{},
+		transactions: map[gosmtypes.Address][]*apitypes.Transaction{},
+		receipts:     map[gosmtypes.Address][]*apitypes.TransactionReceipt{},
+		txStates:     map[string]*apitypes.TransactionState{},
+		txs:          map[string]*apitypes.Transaction{},
+		activations:  map[gosmtypes.Address][]*apitypes.Activation{},
+		submitted:    map[gosmtypes.Address][]common.SubmittedTx{},
+	}
+}
+
+// call records a call of a method which takes no context, as callContext does
+func (f *Fake) call(method string, args ...interface{}) error {
+	return f.callContext(context.Background(), method, args...)
+}
+
+// callContext records a call, waits for its scripted latency unless ctx is cancelled first and
+// returns its scripted error
+func (f *Fake) callContext(ctx context.Context, method string, args ...interface{}) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+	delay, err := f.Latency[method], f.Errors[method]
+	f.mu.Unlock()
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// Calls returns the calls the fake received, oldest first
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo returns the calls of a method the fake received, oldest first
+func (f *Fake) CallsTo(method string) []Call {
+	var res []Call
+	for _, c := range f.Calls() {
+		if c.Method == method {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// Called tells whether a method was called
+func (f *Fake) Called(method string) bool {
+	return len(f.CallsTo(method)) > 0
+}
+
+// ResetCalls forgets the calls received so far
+func (f *Fake) ResetCalls() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+// AddAccount adds a local account to the wallet. The first account added becomes the current one.
+func (f *Fake) AddAccount(acc *common.LocalAccount) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.accounts = append(f.accounts, acc)
+	if f.current < 0 {
+		f.current = 0
+	}
+}
+
+// SetCurrent makes the local account at index i the current one, or none when i is negative
+func (f *Fake) SetCurrent(i int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.current = i
+}
+
+// SetOpen opens or closes the wallet
+func (f *Fake) SetOpen(open bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.open = open
+}
+
+// SetAccount sets the global state of an account, replacing the one SetBalance set
+func (f *Fake) SetAccount(account *apitypes.Account) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.states[gosmtypes.BytesToAddress(account.GetAccountId().GetAddress())] = account
+}
+
+// SetBalance sets the current and projected balance and nonce of an account
+func (f *Fake) SetBalance(address gosmtypes.Address, balance, nonce uint64) {
+	f.SetAccount(Account(address, balance, nonce))
+}
+
+// AddRewards adds rewards of an account. Rewards of a smesher are added with AddSmesherRewards.
+func (f *Fake) AddRewards(address gosmtypes.Address, rewards ...*apitypes.Reward) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rewards[address] = append(f.rewards[address], rewards...)
+}
+
+// AddSmesherRewards adds rewards of a smesher
+func (f *Fake) AddSmesherRewards(smesherId []byte, rewards ...*apitypes.Reward) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.smesherRew[string(smesherId)] = append(f.smesherRew[string(smesherId)], rewards...)
+}
+
+// AddTransactions adds mesh transactions of an account, which TransactionState also finds by id
+// as processed
+func (f *Fake) AddTransactions(address gosmtypes.Address, txs ...*apitypes.Transaction) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.transactions[address] = append(f.transactions[address], txs...)
+	for _, tx := range txs {
+		id := string(tx.GetId().GetId())
+		f.txs[id] = tx
+		if _, ok := f.txStates[id]; !ok {
+			f.txStates[id] = &apitypes.TransactionState{Id: tx.GetId(), State: apitypes.TransactionState_TRANSACTION_STATE_PROCESSED}
+		}
+	}
+}
+
+// AddReceipts adds transaction receipts of an account
+func (f *Fake) AddReceipts(address gosmtypes.Address, receipts ...*apitypes.TransactionReceipt) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.receipts[address] = append(f.receipts[address], receipts...)
+}
+
+// AddActivations adds activations whose coinbase is an account
+func (f *Fake) AddActivations(address gosmtypes.Address, activations ...*apitypes.Activation) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.activations[address] = append(f.activations[address], activations...)
+}
+
+// SetTransactionState sets the state TransactionState returns for a transaction id
+func (f *Fake) SetTransactionState(id []byte, state apitypes.TransactionState_TransactionState) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.txStates[string(id)] = &apitypes.TransactionState{Id: &apitypes.TransactionId{Id: id}, State: state}
+}
+
+// Account returns an account whose current and projected state have a balance and nonce
+func Account(address gosmtypes.Address, balance, nonce uint64) *apitypes.Account {
+	state := func() *apitypes.AccountState {
+		return &apitypes.AccountState{Counter: nonce, Balance: &apitypes.Amount{Value: balance}}
+	}
+	return &apitypes.Account{
+		AccountId:      &apitypes.AccountId{Address: address.Bytes()},
+		StateCurrent:   state(),
+		StateProjected: state(),
+	}
+}
+
+// Reward returns a reward of a layer paid to a coinbase, of which fees are the transaction fees
+func Reward(coinbase gosmtypes.Address, layer uint32, total, fees uint64) *apitypes.Reward {
+	return &apitypes.Reward{
+		Layer:       &apitypes.LayerNumber{Number: layer},
+		Total:       &apitypes.Amount{Value: total},
+		LayerReward: &apitypes.Amount{Value: total - fees},
+		Coinbase:    &apitypes.AccountId{Address: coinbase.Bytes()},
+	}
+}
+
+// Receipt returns the receipt of a transaction applied in a layer
+func Receipt(id []byte, layer uint32, result apitypes.TransactionReceipt_TransactionResult, gasUsed, fee uint64) *apitypes.TransactionReceipt {
+	return &apitypes.TransactionReceipt{
+		Id:      &apitypes.TransactionId{Id: id},
+		Result:  result,
+		GasUsed: gasUsed,
+		Fee:     &apitypes.Amount{Value: fee},
+		Layer:   &apitypes.LayerNumber{Number: layer},
+	}
+}
+
+// Transfer returns a coin transfer transaction
+func Transfer(id []byte, sender, recipient gosmtypes.Address, amount, nonce, gasPrice, gasLimit uint64) *apitypes.Transaction {
+	return &apitypes.Transaction{
+		Id:     &apitypes.TransactionId{Id: id},
+		Sender: &apitypes.AccountId{Address: sender.Bytes()},
+		Datum: &apitypes.Transaction_CoinTransfer{CoinTransfer: &apitypes.CoinTransferTransaction{
+			Receiver: &apitypes.AccountId{Address: recipient.Bytes()},
+		}},
+		Amount:     &apitypes.Amount{Value: amount},
+		Counter:    nonce,
+		GasOffered: &apitypes.GasOffered{GasPrice: gasPrice, GasProvided: gasLimit},
+	}
+}
+
+// page returns the results from offset to offset+max of total results, failing as the node does
+// for an offset past them
+func page(total, offset, max uint32) (uint32, uint32, error) {
+	if offset > 0 && offset >= total {
+		return 0, 0, status.Error(codes.InvalidArgument, "offset exceeds the number of results")
+	}
+	end := offset + max
+	if end > total || max == 0 {
+		end = total
+	}
+	return offset, end, nil
+}
@@ -0,0 +1,95 @@
+package clienttest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFakePages(t *testing.T) {
+	f := New()
+	address := LocalAccount("main", 1).Address()
+	for layer := uint32(1); layer <= 5; layer++ {
+		f.AddRewards(address, Reward(address, layer, 100, 0))
+	}
+	rewards, total, err := f.AccountRewards(context.Background(), address, 3, 10)
+	if err != nil || total != 5 || len(rewards) != 2 || rewards[0].Layer.Number != 4 {
+		t.Fatalf("expected the last 2 of 5 rewards, got %v of %d %v", rewards, total, err)
+	}
+	if _, _, err := f.AccountRewards(context.Background(), address, 5, 10); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected an offset past the rewards to be refused as the node does, got %v", err)
+	}
+	if rewards, total, err := f.AccountRewards(context.Background(), LocalAccount("other", 2).Address(), 0, 10); err != nil || total != 0 || len(rewards) != 0 {
+		t.Fatalf("expected no rewards, got %v of %d %v", rewards, total, err)
+	}
+}
+
+func TestFakeScript(t *testing.T) {
+	f := New()
+	f.AddAccount(LocalAccount("main", 1))
+	broken := errors.New("broken")
+	f.Errors["AccountState"] = broken
+	if _, err := f.AccountState(context.Background(), LocalAccount("main", 1).Address()); err != broken {
+		t.Fatalf("expected the scripted error, got %v", err)
+	}
+
+	acc, _ := f.CurrentAccount()
+	acc.Wipe()
+	if again, _ := f.GetAccount("main"); len(again.PrivKey) == 0 {
+		t.Fatal("expected wiping a returned account to leave the stored one")
+	}
+
+	f.Latency["Echo"] = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := f.Echo(ctx); err != context.Canceled {
+		t.Fatalf("expected cancelling the call to end the latency, got %v", err)
+	}
+
+	want := []string{"AccountState", "CurrentAccount", "GetAccount", "Echo"}
+	calls := f.Calls()
+	if len(calls) != len(want) {
+		t.Fatalf("expected calls %v, got %v", want, calls)
+	}
+	for i := range want {
+		if calls[i].Method != want[i] {
+			t.Fatalf("expected calls %v, got %v", want, calls)
+		}
+	}
+}
+
+func TestFakeStreams(t *testing.T) {
+	f := New()
+	address := LocalAccount("main", 1).Address()
+	f.AccountData = []*apitypes.AccountDataStreamResponse{
+		{Datum: &apitypes.AccountData{Datum: &apitypes.AccountData_Reward{Reward: Reward(address, 1, 100, 0)}}},
+		{Datum: &apitypes.AccountData{Datum: &apitypes.AccountData_AccountWrapper{AccountWrapper: Account(address, 100, 0)}}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := f.AccountDataStream(ctx, address, apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_ACCOUNT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := stream.Recv()
+	if err != nil || resp.GetDatum().GetAccountWrapper() == nil {
+		t.Fatalf("expected only the account update subscribed to, got %v %v", resp, err)
+	}
+	cancel()
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Fatalf("expected the stream to end when cancelled, got %v", err)
+	}
+
+	f.StreamEnd = status.Error(codes.Unavailable, "node restarting")
+	stream, _ = f.AccountDataStream(context.Background(), address, apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_REWARD)
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != f.StreamEnd {
+		t.Fatalf("expected the scripted end of the stream, got %v", err)
+	}
+}
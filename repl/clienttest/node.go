@@ -0,0 +1,512 @@
+package clienttest
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"google.golang.org/genproto/googleapis/rpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// server is the address the fake reports for its node
+const server = "fake:9092"
+
+// errNotSeeded is returned by the node methods whose result wasn't set up
+func errNotSeeded(what string) error {
+	return fmt.Errorf("the fake client has no %s", what)
+}
+
+func (f *Fake) ServerInfo() string {
+	return server
+}
+
+func (f *Fake) ActiveServer() string {
+	return server
+}
+
+func (f *Fake) Reachable() (bool, bool) {
+	return f.call("Reachable") == nil, true
+}
+
+func (f *Fake) CheckServers() []common.ServerHealth {
+	return []common.ServerHealth{{Server: server, Active: true, Err: f.call("CheckServers")}}
+}
+
+func (f *Fake) CallStats() []common.CallStats {
+	f.call("CallStats")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]common.CallStats(nil), f.Stats...)
+}
+
+func (f *Fake) ResetCallStats() {
+	f.call("ResetCallStats")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Stats = nil
+}
+
+func (f *Fake) SetGRPCDebug(on bool) {
+	f.call("SetGRPCDebug", on)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Debug = on
+}
+
+func (f *Fake) GRPCDebug() bool {
+	f.call("GRPCDebug")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Debug
+}
+
+func (f *Fake) SwitchServer(ctx context.Context, server string, force bool) (*common.NetInfo, error) {
+	if err := f.callContext(ctx, "SwitchServer", server, force); err != nil {
+		return nil, err
+	}
+	return f.GetMeshInfo(ctx)
+}
+
+func (f *Fake) NodeStatus(ctx context.Context) (*apitypes.NodeStatus, error) {
+	if err := f.callContext(ctx, "NodeStatus"); err != nil {
+		return nil, err
+	}
+	if f.Status == nil {
+		return nil, errNotSeeded("node status")
+	}
+	return f.Status, nil
+}
+
+func (f *Fake) NodeInfo(ctx context.Context) (*common.NodeInfo, error) {
+	if err := f.callContext(ctx, "NodeInfo"); err != nil {
+		return nil, err
+	}
+	return &common.NodeInfo{Version: "fake", Build: "test"}, nil
+}
+
+func (f *Fake) Echo(ctx context.Context) error {
+	return f.callContext(ctx, "Echo")
+}
+
+func (f *Fake) Health(ctx context.Context) (bool, error) {
+	if err := f.callContext(ctx, "Health"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (f *Fake) EchoTimeout(ctx context.Context, timeout time.Duration) error {
+	return f.callContext(ctx, "EchoTimeout", timeout)
+}
+
+func (f *Fake) Shutdown(ctx context.Context) (*status.Status, error) {
+	if err := f.callContext(ctx, "Shutdown"); err != nil {
+		return nil, err
+	}
+	return &status.Status{}, nil
+}
+
+func (f *Fake) ProbeStatus() (*apitypes.NodeStatus, error) {
+	if err := f.call("ProbeStatus"); err != nil {
+		return nil, err
+	}
+	if f.Status == nil {
+		return nil, errNotSeeded("node status")
+	}
+	return f.Status, nil
+}
+
+// GetMeshTransactions returns the page of the transactions added with AddTransactions
+func (f *Fake) GetMeshTransactions(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Transaction, uint32, error) {
+	if err := f.callContext(ctx, "GetMeshTransactions", address, offset, maxResults); err != nil {
+		return nil, 0, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	txs := f.transactions[address]
+	first, end, err := page(uint32(len(txs)), offset, maxResults)
+	if err != nil {
+		return nil, 0, err
+	}
+	return append([]*apitypes.Transaction(nil), txs[first:end]...), uint32(len(txs)), nil
+}
+
+// GetMeshActivations returns the page of the activations added with AddActivations
+func (f *Fake) GetMeshActivations(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Activation, uint32, error) {
+	if err := f.callContext(ctx, "GetMeshActivations", address, offset, maxResults); err != nil {
+		return nil, 0, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	activations := f.activations[address]
+	first, end, err := page(uint32(len(activations)), offset, maxResults)
+	if err != nil {
+		return nil, 0, err
+	}
+	return append([]*apitypes.Activation(nil), activations[first:end]...), uint32(len(activations)), nil
+}
+
+func (f *Fake) GetMeshInfo(ctx context.Context) (*common.NetInfo, error) {
+	if err := f.callContext(ctx, "GetMeshInfo"); err != nil {
+		return nil, err
+	}
+	if f.Net == nil {
+		return nil, errNotSeeded("network info")
+	}
+	return f.Net, nil
+}
+
+func (f *Fake) NetworkParams(ctx context.Context, refresh bool) (*common.NetInfo, error) {
+	if err := f.callContext(ctx, "NetworkParams", refresh); err != nil {
+		return nil, err
+	}
+	if f.Net == nil {
+		return nil, errNotSeeded("network info")
+	}
+	return f.Net, nil
+}
+
+func (f *Fake) FeeEstimate(ctx context.Context) (*common.FeeEstimate, error) {
+	if err := f.callContext(ctx, "FeeEstimate"); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotFaked
+}
+
+func (f *Fake) GasOracle(ctx context.Context, window uint32) (*common.GasOracleReport, error) {
+	if err := f.callContext(ctx, "GasOracle", window); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotFaked
+}
+
+func (f *Fake) UnsignedTransaction(recipient gosmtypes.Address, nonce, amount, gasPrice, gasLimit uint64) ([]byte, error) {
+	if err := f.call("UnsignedTransaction", recipient, nonce, amount, gasPrice, gasLimit); err != nil {
+		return nil, err
+	}
+	return unsignedTransfer(common.TxRequest{Recipient: recipient, Amount: amount, GasPrice: gasPrice, GasLimit: gasLimit, Nonce: nonce}), nil
+}
+
+// unsignedTransfer returns the bytes the fake signs for a transfer. They aren't the node's encoding.
+func unsignedTransfer(r common.TxRequest) []byte {
+	return []byte(fmt.Sprintf("%x:%d:%d:%d:%d", r.Recipient.Bytes(), r.Nonce, r.Amount, r.GasPrice, r.GasLimit))
+}
+
+func (f *Fake) DecodeTransaction(data []byte) (*common.InnerSerializableSignedTransaction, error) {
+	if err := f.call("DecodeTransaction"); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotFaked
+}
+
+// SignTransfer signs a transfer with the key. Its id is the hash of the signed bytes, which
+// aren't the node's encoding.
+func (f *Fake) SignTransfer(recipient gosmtypes.Address, nonce, amount, gasPrice, gasLimit uint64, key *common.SigningKey) (*common.SignedTransfer, error) {
+	if err := f.call("SignTransfer", recipient, nonce, amount, gasPrice, gasLimit); err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, common.ErrWatchOnly
+	}
+	tx := &common.SignedTransfer{
+		TxRequest: common.TxRequest{Recipient: recipient, Amount: amount, GasPrice: gasPrice, GasLimit: gasLimit, Nonce: nonce},
+		Format:    common.TxFormatLegacy,
+		Sender:    gosmtypes.BytesToAddress(key.PublicKey()),
+	}
+	tx.Unsigned = unsignedTransfer(tx.TxRequest)
+	tx.Signature = key.Sign(tx.Unsigned)
+	tx.Signed = append(append([]byte(nil), tx.Unsigned...), tx.Signature...)
+	id := sha256.Sum256(tx.Signed)
+	tx.ID = id[:]
+	return tx, nil
+}
+
+func (f *Fake) Transfer(ctx context.Context, recipient gosmtypes.Address, nonce, amount, gasPrice, gasLimit uint64, key *common.SigningKey) (*apitypes.TransactionState, error) {
+	tx, err := f.SignTransfer(recipient, nonce, amount, gasPrice, gasLimit, key)
+	if err != nil {
+		return nil, err
+	}
+	return f.SubmitTransfer(ctx, tx)
+}
+
+// SubmitTransfer adds a signed transfer to the mempool, which SubmittedTransactions and
+// TransactionState report
+func (f *Fake) SubmitTransfer(ctx context.Context, tx *common.SignedTransfer) (*apitypes.TransactionState, error) {
+	if err := f.callContext(ctx, "SubmitTransfer", tx.Recipient, tx.Nonce, tx.Amount, tx.GasPrice, tx.GasLimit); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.submitted[tx.Sender] = append(f.submitted[tx.Sender], common.SubmittedTx{ID: tx.ID, TxRequest: tx.TxRequest})
+	return f.mempool(tx.ID), nil
+}
+
+// mempool records a submitted transaction as pending and returns its state. It's called with
+// mu held.
+func (f *Fake) mempool(id []byte) *apitypes.TransactionState {
+	state := &apitypes.TransactionState{Id: &apitypes.TransactionId{Id: id}, State: apitypes.TransactionState_TRANSACTION_STATE_MEMPOOL}
+	f.txStates[string(id)] = state
+	return state
+}
+
+func (f *Fake) VerifySignedBatchTx(t common.SignedBatchTx) ([]byte, []byte, gosmtypes.Address, error) {
+	if err := f.call("VerifySignedBatchTx"); err != nil {
+		return nil, nil, gosmtypes.Address{}, err
+	}
+	return nil, nil, gosmtypes.Address{}, ErrNotFaked
+}
+
+func (f *Fake) SubmitSignedTx(ctx context.Context, tx, id []byte) (*apitypes.TransactionState, error) {
+	if err := f.callContext(ctx, "SubmitSignedTx", fmt.Sprintf("0x%x", id)); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mempool(id), nil
+}
+
+// SubmitCoinTransaction adds a transaction to the mempool with the hash of its bytes as id
+func (f *Fake) SubmitCoinTransaction(ctx context.Context, tx []byte) (*apitypes.TransactionState, error) {
+	if err := f.callContext(ctx, "SubmitCoinTransaction"); err != nil {
+		return nil, err
+	}
+	id := sha256.Sum256(tx)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mempool(id[:]), nil
+}
+
+func (f *Fake) DecodeSignedTransaction(data []byte) (*common.DecodedTransfer, error) {
+	if err := f.call("DecodeSignedTransaction"); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotFaked
+}
+
+// DecodeMultisigTransaction decodes the transactions of MultisigTx
+func (f *Fake) DecodeMultisigTransaction(data []byte) (*common.SerializableMultisigTransaction, error) {
+	if err := f.call("DecodeMultisigTransaction"); err != nil {
+		return nil, err
+	}
+	var threshold uint32
+	var signatures int
+	if _, err := fmt.Sscanf(string(data), multisigTxFormat, &threshold, &signatures); err != nil {
+		return nil, fmt.Errorf("invalid multisig transaction: %v", err)
+	}
+	return &common.SerializableMultisigTransaction{Threshold: threshold,
+		Signatures: make([]common.SerializableMultisigSignature, signatures)}, nil
+}
+
+// multisigTxFormat is the layout of the multisig transactions of the fake, which isn't the node's
+const multisigTxFormat = "multisig:%d:%d"
+
+// MultisigTx returns a multisig transaction with a threshold and a number of signatures, as the fake
+// encodes them
+func MultisigTx(threshold, signatures int) []byte {
+	return []byte(fmt.Sprintf(multisigTxFormat, threshold, signatures))
+}
+
+func (f *Fake) SubmittedTransactions(address gosmtypes.Address) []common.SubmittedTx {
+	f.call("SubmittedTransactions", address)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]common.SubmittedTx(nil), f.submitted[address]...)
+}
+
+// TransactionState returns the state of a transaction added or submitted before, and the
+// transaction when it was added with AddTransactions and includeTx is set
+func (f *Fake) TransactionState(ctx context.Context, txId []byte, includeTx bool) (*apitypes.TransactionState, *apitypes.Transaction, error) {
+	if err := f.callContext(ctx, "TransactionState", fmt.Sprintf("0x%x", txId), includeTx); err != nil {
+		return nil, nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	state, ok := f.txStates[string(txId)]
+	if !ok {
+		return nil, nil, nil
+	}
+	if !includeTx {
+		return state, nil, nil
+	}
+	return state, f.txs[string(txId)], nil
+}
+
+func (f *Fake) GetSmesherId(ctx context.Context) ([]byte, error) {
+	if err := f.callContext(ctx, "GetSmesherId"); err != nil {
+		return nil, err
+	}
+	if f.SmesherId == nil {
+		return nil, errNotSeeded("smesher id")
+	}
+	return f.SmesherId, nil
+}
+
+func (f *Fake) SmesherIds(ctx context.Context) ([][]byte, error) {
+	if err := f.callContext(ctx, "SmesherIds"); err != nil {
+		return nil, err
+	}
+	if f.SmesherId == nil {
+		return nil, nil
+	}
+	return [][]byte{f.SmesherId}, nil
+}
+
+func (f *Fake) IsSmeshing(ctx context.Context) (bool, error) {
+	if err := f.callContext(ctx, "IsSmeshing"); err != nil {
+		return false, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.Smeshing, nil
+}
+
+func (f *Fake) StartSmeshing(ctx context.Context, address gosmtypes.Address, dataDir string, dataSizeBytes uint64) (*status.Status, error) {
+	if err := f.callContext(ctx, "StartSmeshing", address, dataDir, dataSizeBytes); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Smeshing = true
+	return &status.Status{}, nil
+}
+
+func (f *Fake) StopSmeshing(ctx context.Context, deleteFiles bool) (*status.Status, error) {
+	if err := f.callContext(ctx, "StopSmeshing", deleteFiles); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Smeshing = false
+	return &status.Status{}, nil
+}
+
+func (f *Fake) GetRewardsAddress(ctx context.Context) (*gosmtypes.Address, error) {
+	if err := f.callContext(ctx, "GetRewardsAddress"); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotFaked
+}
+
+func (f *Fake) SetRewardsAddress(ctx context.Context, coinbase gosmtypes.Address) (*status.Status, error) {
+	if err := f.callContext(ctx, "SetRewardsAddress", coinbase); err != nil {
+		return nil, err
+	}
+	return &status.Status{}, nil
+}
+
+func (f *Fake) GetPostStatus(ctx context.Context) (*apitypes.PostStatus, error) {
+	if err := f.callContext(ctx, "GetPostStatus"); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotFaked
+}
+
+func (f *Fake) GetPostComputeProviders(ctx context.Context) ([]*apitypes.PostComputeProvider, error) {
+	if err := f.callContext(ctx, "GetPostComputeProviders"); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (f *Fake) CreatePostData(ctx context.Context, data *apitypes.PostData) (*status.Status, error) {
+	if err := f.callContext(ctx, "CreatePostData"); err != nil {
+		return nil, err
+	}
+	return &status.Status{}, nil
+}
+
+// DebugAllAccounts returns the accounts set with SetBalance and SetAccount, in no order
+func (f *Fake) DebugAllAccounts(ctx context.Context) ([]*apitypes.Account, error) {
+	if err := f.callContext(ctx, "DebugAllAccounts"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	accounts := make([]*apitypes.Account, 0, len(f.states))
+	for _, account := range f.states {
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// AccountState returns the account set with SetBalance or SetAccount. Other accounts have an empty
+// state, as the node reports them.
+func (f *Fake) AccountState(ctx context.Context, address gosmtypes.Address) (*apitypes.Account, error) {
+	if err := f.callContext(ctx, "AccountState", address); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if account, ok := f.states[address]; ok {
+		return account, nil
+	}
+	return Account(address, 0, 0), nil
+}
+
+// AccountRewards returns the page of the rewards added with AddRewards
+func (f *Fake) AccountRewards(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+	if err := f.callContext(ctx, "AccountRewards", address, offset, maxResults); err != nil {
+		return nil, 0, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return rewardsPage(f.rewards[address], offset, maxResults)
+}
+
+func rewardsPage(rewards []*apitypes.Reward, offset, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+	first, end, err := page(uint32(len(rewards)), offset, maxResults)
+	if err != nil {
+		return nil, 0, err
+	}
+	return append([]*apitypes.Reward(nil), rewards[first:end]...), uint32(len(rewards)), nil
+}
+
+// AccountTransactionsReceipts returns the page of the receipts added with AddReceipts
+func (f *Fake) AccountTransactionsReceipts(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.TransactionReceipt, uint32, error) {
+	if err := f.callContext(ctx, "AccountTransactionsReceipts", address, offset, maxResults); err != nil {
+		return nil, 0, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	receipts := f.receipts[address]
+	first, end, err := page(uint32(len(receipts)), offset, maxResults)
+	if err != nil {
+		return nil, 0, err
+	}
+	return append([]*apitypes.TransactionReceipt(nil), receipts[first:end]...), uint32(len(receipts)), nil
+}
+
+func (f *Fake) GlobalStateHash(ctx context.Context) (*apitypes.GlobalStateHash, error) {
+	if err := f.callContext(ctx, "GlobalStateHash"); err != nil {
+		return nil, err
+	}
+	if f.StateHash == nil {
+		return nil, errNotSeeded("global state hash")
+	}
+	return f.StateHash, nil
+}
+
+func (f *Fake) ServerStateHash(ctx context.Context, server string) (*apitypes.GlobalStateHash, error) {
+	if err := f.callContext(ctx, "ServerStateHash", server); err != nil {
+		return nil, err
+	}
+	if f.StateHash == nil {
+		return nil, errNotSeeded("global state hash")
+	}
+	return f.StateHash, nil
+}
+
+// SmesherRewards returns the page of the rewards added with AddSmesherRewards
+func (f *Fake) SmesherRewards(ctx context.Context, smesherId []byte, offset uint32, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+	if err := f.callContext(ctx, "SmesherRewards", fmt.Sprintf("0x%x", smesherId), offset, maxResults); err != nil {
+		return nil, 0, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return rewardsPage(f.smesherRew[string(smesherId)], offset, maxResults)
+}
@@ -0,0 +1,205 @@
+package clienttest
+
+import (
+	"context"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// stream is the part of a fake stream all kinds share. It sends n messages and then returns end,
+// or waits for its context to be cancelled when end is nil.
+type stream struct {
+	grpc.ClientStream
+	ctx  context.Context
+	n    int
+	sent int
+	end  error
+}
+
+// next returns the index of the next message to send, or the error ending the stream
+func (s *stream) next() (int, error) {
+	if err := s.ctx.Err(); err != nil {
+		return 0, status.Error(codes.Canceled, err.Error())
+	}
+	if s.sent < s.n {
+		s.sent++
+		return s.sent - 1, nil
+	}
+	if s.end != nil {
+		return 0, s.end
+	}
+	<-s.ctx.Done()
+	return 0, status.Error(codes.Canceled, s.ctx.Err().Error())
+}
+
+func (s *stream) Header() (metadata.MD, error) { return metadata.MD{}, nil }
+func (s *stream) Trailer() metadata.MD         { return metadata.MD{} }
+func (s *stream) CloseSend() error             { return nil }
+func (s *stream) Context() context.Context     { return s.ctx }
+
+// openStream records the opening of a stream and returns its shared part. The stream counts as
+// open until its context is done, as a gRPC stream is closed when its context is cancelled.
+func (f *Fake) openStream(ctx context.Context, method string, n int, args ...interface{}) (*stream, error) {
+	if err := f.callContext(ctx, method, args...); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.streams++
+	f.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		f.streams--
+		f.mu.Unlock()
+	}()
+	return &stream{ctx: ctx, n: n, end: f.StreamEnd}, nil
+}
+
+// OpenStreams returns the number of streams whose context isn't cancelled yet
+func (f *Fake) OpenStreams() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.streams
+}
+
+type accountDataStream struct {
+	*stream
+	messages []*apitypes.AccountDataStreamResponse
+}
+
+func (s *accountDataStream) Recv() (*apitypes.AccountDataStreamResponse, error) {
+	i, err := s.next()
+	if err != nil {
+		return nil, err
+	}
+	return s.messages[i], nil
+}
+
+// AccountDataStream sends the AccountData messages whose datum is one of the types in flags
+func (f *Fake) AccountDataStream(ctx context.Context, address gosmtypes.Address, flags apitypes.AccountDataFlag) (apitypes.GlobalStateService_AccountDataStreamClient, error) {
+	var messages []*apitypes.AccountDataStreamResponse
+	for _, m := range f.AccountData {
+		datum := m.GetDatum()
+		switch {
+		case datum.GetReward() != nil && flags&apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_REWARD != 0,
+			datum.GetReceipt() != nil && flags&apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_TRANSACTION_RECEIPT != 0,
+			datum.GetAccountWrapper() != nil && flags&apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_ACCOUNT != 0:
+			messages = append(messages, m)
+		}
+	}
+	s, err := f.openStream(ctx, "AccountDataStream", len(messages), address, flags)
+	if err != nil {
+		return nil, err
+	}
+	return &accountDataStream{s, messages}, nil
+}
+
+type globalStateStream struct {
+	*stream
+	messages []*apitypes.GlobalStateStreamResponse
+}
+
+func (s *globalStateStream) Recv() (*apitypes.GlobalStateStreamResponse, error) {
+	i, err := s.next()
+	if err != nil {
+		return nil, err
+	}
+	return s.messages[i], nil
+}
+
+func (f *Fake) GlobalStateStream(ctx context.Context) (apitypes.GlobalStateService_GlobalStateStreamClient, error) {
+	s, err := f.openStream(ctx, "GlobalStateStream", len(f.GlobalState))
+	if err != nil {
+		return nil, err
+	}
+	return &globalStateStream{s, f.GlobalState}, nil
+}
+
+type layerStream struct {
+	*stream
+	messages []*apitypes.LayerStreamResponse
+}
+
+func (s *layerStream) Recv() (*apitypes.LayerStreamResponse, error) {
+	i, err := s.next()
+	if err != nil {
+		return nil, err
+	}
+	return s.messages[i], nil
+}
+
+func (f *Fake) LayerStream(ctx context.Context) (apitypes.MeshService_LayerStreamClient, error) {
+	s, err := f.openStream(ctx, "LayerStream", len(f.Layers))
+	if err != nil {
+		return nil, err
+	}
+	return &layerStream{s, f.Layers}, nil
+}
+
+type statusStream struct {
+	*stream
+	messages []*apitypes.StatusStreamResponse
+}
+
+func (s *statusStream) Recv() (*apitypes.StatusStreamResponse, error) {
+	i, err := s.next()
+	if err != nil {
+		return nil, err
+	}
+	return s.messages[i], nil
+}
+
+func (f *Fake) StatusStream(ctx context.Context) (apitypes.NodeService_StatusStreamClient, error) {
+	s, err := f.openStream(ctx, "StatusStream", len(f.NodeStatuses))
+	if err != nil {
+		return nil, err
+	}
+	return &statusStream{s, f.NodeStatuses}, nil
+}
+
+type errorStream struct {
+	*stream
+	messages []*apitypes.ErrorStreamResponse
+}
+
+func (s *errorStream) Recv() (*apitypes.ErrorStreamResponse, error) {
+	i, err := s.next()
+	if err != nil {
+		return nil, err
+	}
+	return s.messages[i], nil
+}
+
+func (f *Fake) ErrorStream(ctx context.Context) (apitypes.NodeService_ErrorStreamClient, error) {
+	s, err := f.openStream(ctx, "ErrorStream", len(f.NodeErrors))
+	if err != nil {
+		return nil, err
+	}
+	return &errorStream{s, f.NodeErrors}, nil
+}
+
+type postStream struct {
+	*stream
+	messages []*apitypes.PostDataCreationProgressStreamResponse
+}
+
+func (s *postStream) Recv() (*apitypes.PostDataCreationProgressStreamResponse, error) {
+	i, err := s.next()
+	if err != nil {
+		return nil, err
+	}
+	return s.messages[i], nil
+}
+
+func (f *Fake) PostStatusStream(ctx context.Context) (apitypes.SmesherService_PostDataCreationProgressStreamClient, error) {
+	s, err := f.openStream(ctx, "PostStatusStream", len(f.PostProgress))
+	if err != nil {
+		return nil, err
+	}
+	return &postStream{s, f.PostProgress}, nil
+}
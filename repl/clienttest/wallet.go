@@ -0,0 +1,687 @@
+package clienttest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+
+	"github.com/spacemeshos/ed25519"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// LocalAccount returns a derived account whose key is made of seed bytes, so that tests get the
+// same address for the same seed
+func LocalAccount(name string, seed byte) *common.LocalAccount {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{seed}, ed25519.SeedSize))
+	return &common.LocalAccount{
+		Name:    name,
+		PrivKey: key,
+		PubKey:  key.Public().(ed25519.PublicKey),
+		Origin:  common.AccountOrigin{Kind: common.OriginDerived, Index: uint64(seed)},
+	}
+}
+
+// WatchOnlyAccount returns a watch-only account of an address
+func WatchOnlyAccount(name string, address gosmtypes.Address) *common.LocalAccount {
+	return &common.LocalAccount{Name: name, WatchAddress: address, Origin: common.AccountOrigin{Kind: common.OriginWatchOnly}}
+}
+
+// copyAccount returns a copy of a stored account, which the caller may wipe
+func copyAccount(acc *common.LocalAccount) *common.LocalAccount {
+	res := *acc
+	res.PrivKey = append(ed25519.PrivateKey(nil), acc.PrivKey...)
+	res.Tags = append([]string(nil), acc.Tags...)
+	return &res
+}
+
+// account returns the index of the local account of a name. It's called with mu held.
+func (f *Fake) account(name string) (int, error) {
+	if !f.open {
+		return -1, common.ErrNoWallet
+	}
+	for i, acc := range f.accounts {
+		if acc.Name == name {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("account %s not found", name)
+}
+
+// updateAccount applies update to the local account of a name
+func (f *Fake) updateAccount(name string, update func(acc *common.LocalAccount) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i, err := f.account(name)
+	if err != nil {
+		return err
+	}
+	return update(f.accounts[i])
+}
+
+func (f *Fake) PrintWalletMnemonic() {
+	f.call("PrintWalletMnemonic")
+}
+
+func (f *Fake) WalletMnemonic() (string, error) {
+	if err := f.call("WalletMnemonic"); err != nil {
+		return "", err
+	}
+	return "", ErrNotFaked
+}
+
+func (f *Fake) WalletInfo() (*common.WalletInfo, error) {
+	if err := f.call("WalletInfo"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	info := &common.WalletInfo{Name: f.wallet, Accounts: len(f.accounts)}
+	for _, acc := range f.accounts {
+		if acc.Origin.Kind == common.OriginDerived {
+			info.DerivedAccounts++
+		} else if acc.Origin.Kind == common.OriginImported {
+			info.ImportedAccounts++
+		}
+	}
+	return info, nil
+}
+
+func (f *Fake) IsOpen() bool {
+	f.call("IsOpen")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.open
+}
+
+func (f *Fake) OpenWallet() bool {
+	if err := f.call("OpenWallet"); err != nil {
+		return false
+	}
+	f.SetOpen(true)
+	return true
+}
+
+func (f *Fake) NewWallet(name string, entropy []byte) bool {
+	if err := f.call("NewWallet", name); err != nil {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.wallet, f.open, f.accounts, f.current = name, true, nil, -1
+	return true
+}
+
+func (f *Fake) CloseWallet() {
+	f.call("CloseWallet")
+	f.SetOpen(false)
+}
+
+func (f *Fake) ChangePassword() error {
+	return f.call("ChangePassword")
+}
+
+func (f *Fake) ConfirmPassword() error {
+	return f.call("ConfirmPassword")
+}
+
+func (f *Fake) WalletName() string {
+	f.call("WalletName")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.wallet
+}
+
+func (f *Fake) ListWallets() ([]string, error) {
+	if err := f.call("ListWallets"); err != nil {
+		return nil, err
+	}
+	return []string{f.WalletName()}, nil
+}
+
+func (f *Fake) SwitchWallet(name string) error {
+	if err := f.call("SwitchWallet", name); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.wallet = name
+	return nil
+}
+
+func (f *Fake) BackupWallet(path string) (string, error) {
+	if err := f.call("BackupWallet", path); err != nil {
+		return "", err
+	}
+	return "", ErrNotFaked
+}
+
+func (f *Fake) VerifyBackup(path string) ([]common.AccountSummary, error) {
+	if err := f.call("VerifyBackup", path); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotFaked
+}
+
+func (f *Fake) ImportSmappWallet(path string) (string, []common.AccountSummary, error) {
+	if err := f.call("ImportSmappWallet", path); err != nil {
+		return "", nil, err
+	}
+	return "", nil, ErrNotFaked
+}
+
+func (f *Fake) ExportSmappWallet(path string) (int, error) {
+	if err := f.call("ExportSmappWallet", path); err != nil {
+		return 0, err
+	}
+	return 0, ErrNotFaked
+}
+
+func (f *Fake) MergeWallet(path string, dryRun bool) (*common.MergeResult, error) {
+	if err := f.call("MergeWallet", path, dryRun); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotFaked
+}
+
+// CreateAccount adds a derived account, whose key is made of its index + 1 as LocalAccount
+// does, and makes it the current one
+func (f *Fake) CreateAccount(alias string) (*common.LocalAccount, error) {
+	if err := f.call("CreateAccount", alias); err != nil {
+		return nil, err
+	}
+	alias, err := common.NormalizeAlias(alias)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.open {
+		return nil, common.ErrNoWallet
+	}
+	if _, err := f.account(alias); err == nil {
+		return nil, common.ErrAliasTaken
+	}
+	acc := LocalAccount(alias, byte(len(f.accounts)+1))
+	f.accounts = append(f.accounts, acc)
+	f.current = len(f.accounts) - 1
+	return copyAccount(acc), nil
+}
+
+func (f *Fake) WatchAccount(alias string, address gosmtypes.Address) (*common.LocalAccount, error) {
+	if err := f.call("WatchAccount", alias, address); err != nil {
+		return nil, err
+	}
+	alias, err := common.NormalizeAlias(alias)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.open {
+		return nil, common.ErrNoWallet
+	}
+	if _, err := f.account(alias); err == nil {
+		return nil, common.ErrAliasTaken
+	}
+	acc := WatchOnlyAccount(alias, address)
+	f.accounts = append(f.accounts, acc)
+	return copyAccount(acc), nil
+}
+
+func (f *Fake) CurrentAccount() (*common.LocalAccount, error) {
+	if err := f.call("CurrentAccount"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.open {
+		return nil, common.ErrNoWallet
+	}
+	if f.current < 0 || f.current >= len(f.accounts) {
+		return nil, fmt.Errorf("no current account")
+	}
+	return copyAccount(f.accounts[f.current]), nil
+}
+
+func (f *Fake) SetCurrentAccount(accountNumber int) error {
+	if err := f.call("SetCurrentAccount", accountNumber); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.open {
+		return common.ErrNoWallet
+	}
+	if accountNumber < 0 || accountNumber >= len(f.accounts) {
+		return fmt.Errorf("invalid account number %d", accountNumber)
+	}
+	f.current = accountNumber
+	return nil
+}
+
+func (f *Fake) ListAccounts() ([]string, error) {
+	if err := f.call("ListAccounts"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.open {
+		return nil, common.ErrNoWallet
+	}
+	names := make([]string, len(f.accounts))
+	for i, acc := range f.accounts {
+		names[i] = acc.Name
+	}
+	return names, nil
+}
+
+// DeriveAddresses returns the addresses of the keys CreateAccount would make at the first n indexes
+func (f *Fake) DeriveAddresses(n int) ([]common.DerivedAddress, error) {
+	if err := f.call("DeriveAddresses", n); err != nil {
+		return nil, err
+	}
+	res := make([]common.DerivedAddress, n)
+	for i := range res {
+		res[i] = common.DerivedAddress{Index: uint64(i), Address: LocalAccount("", byte(i+1)).Address()}
+	}
+	return res, nil
+}
+
+func (f *Fake) AddDerivedAccount(displayName string, index uint64) error {
+	if err := f.call("AddDerivedAccount", displayName, index); err != nil {
+		return err
+	}
+	acc := LocalAccount(displayName, byte(index+1))
+	acc.Origin.Index = index
+	f.AddAccount(acc)
+	return nil
+}
+
+func (f *Fake) VerifyMnemonic() (bool, error) {
+	if err := f.call("VerifyMnemonic"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (f *Fake) RecoverKey(index uint64) (ed25519.PrivateKey, error) {
+	if err := f.call("RecoverKey", index); err != nil {
+		return nil, err
+	}
+	return LocalAccount("", byte(index+1)).PrivKey, nil
+}
+
+func (f *Fake) AddKeyAccount(displayName string, key ed25519.PrivateKey) error {
+	if err := f.call("AddKeyAccount", displayName); err != nil {
+		return err
+	}
+	f.AddAccount(&common.LocalAccount{
+		Name:    displayName,
+		PrivKey: key,
+		PubKey:  key.Public().(ed25519.PublicKey),
+		Origin:  common.AccountOrigin{Kind: common.OriginImported},
+	})
+	return nil
+}
+
+func (f *Fake) GetAccount(name string) (*common.LocalAccount, error) {
+	if err := f.call("GetAccount", name); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i, err := f.account(name)
+	if err != nil {
+		return nil, err
+	}
+	return copyAccount(f.accounts[i]), nil
+}
+
+func (f *Fake) DeleteAccount(name string) error {
+	if err := f.call("DeleteAccount", name); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i, err := f.account(name)
+	if err != nil {
+		return err
+	}
+	f.accounts = append(f.accounts[:i], f.accounts[i+1:]...)
+	if f.current == i {
+		f.current = -1
+	} else if f.current > i {
+		f.current--
+	}
+	return nil
+}
+
+func (f *Fake) RenameAccount(oldName, newName string) error {
+	if err := f.call("RenameAccount", oldName, newName); err != nil {
+		return err
+	}
+	newName, err := common.NormalizeAlias(newName)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.account(newName); err == nil {
+		return common.ErrAliasTaken
+	}
+	i, err := f.account(oldName)
+	if err != nil {
+		return err
+	}
+	f.accounts[i].Name = newName
+	return nil
+}
+
+func (f *Fake) SetAccountNote(name, note string) error {
+	if err := f.call("SetAccountNote", name, note); err != nil {
+		return err
+	}
+	return f.updateAccount(name, func(acc *common.LocalAccount) error {
+		acc.Note = note
+		return nil
+	})
+}
+
+func (f *Fake) TagAccount(name, tag string) error {
+	if err := f.call("TagAccount", name, tag); err != nil {
+		return err
+	}
+	tag, err := common.NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+	return f.updateAccount(name, func(acc *common.LocalAccount) error {
+		if !common.HasTag(acc.Tags, tag) {
+			acc.Tags = append(acc.Tags, tag)
+		}
+		return nil
+	})
+}
+
+func (f *Fake) UntagAccount(name, tag string) error {
+	if err := f.call("UntagAccount", name, tag); err != nil {
+		return err
+	}
+	return f.updateAccount(name, func(acc *common.LocalAccount) error {
+		for i, t := range acc.Tags {
+			if t == tag {
+				acc.Tags = append(acc.Tags[:i], acc.Tags[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("account %s isn't tagged %s", name, tag)
+	})
+}
+
+func (f *Fake) SetAccountGas(name string, gasPrice, gasLimit uint64) error {
+	if err := f.call("SetAccountGas", name, gasPrice, gasLimit); err != nil {
+		return err
+	}
+	return f.updateAccount(name, func(acc *common.LocalAccount) error {
+		acc.GasPrice, acc.GasLimit = gasPrice, gasLimit
+		return nil
+	})
+}
+
+func (f *Fake) StoreAccounts() error {
+	return f.call("StoreAccounts")
+}
+
+// WalletConflict reports no conflict: the fake has no wallet file another program could change
+func (f *Fake) WalletConflict() (*common.WalletConflict, error) {
+	return nil, f.call("WalletConflict")
+}
+
+func (f *Fake) ReloadWallet() error {
+	if err := f.call("ReloadWallet"); err != nil {
+		return err
+	}
+	return ErrNotFaked
+}
+
+func (f *Fake) MergeWalletFile() (*common.MergeResult, error) {
+	if err := f.call("MergeWalletFile"); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotFaked
+}
+
+func (f *Fake) OverwriteWalletFile() error {
+	if err := f.call("OverwriteWalletFile"); err != nil {
+		return err
+	}
+	return ErrNotFaked
+}
+
+func (f *Fake) MultisigAccounts() ([]common.MultisigAccount, error) {
+	if err := f.call("MultisigAccounts"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]common.MultisigAccount(nil), f.multisig...), nil
+}
+
+func (f *Fake) MultisigAccount(name string) (*common.MultisigAccount, error) {
+	if err := f.call("MultisigAccount", name); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, m := range f.multisig {
+		if m.Name == name {
+			res := m
+			return &res, nil
+		}
+	}
+	return nil, fmt.Errorf("multisig account %s not found", name)
+}
+
+func (f *Fake) AddMultisigAccount(account *common.MultisigAccount) error {
+	if err := f.call("AddMultisigAccount", account.Name); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.multisig = append(f.multisig, *account)
+	return nil
+}
+
+// MultisigTransaction encodes the envelope as MultisigTx does
+func (f *Fake) MultisigTransaction(e *common.MultisigEnvelope) ([]byte, error) {
+	if err := f.call("MultisigTransaction"); err != nil {
+		return nil, err
+	}
+	return MultisigTx(e.Threshold, len(e.Signatures)), nil
+}
+
+// NextNonce reserves the larger of the projected nonce and the one after the last reserved
+func (f *Fake) NextNonce(address gosmtypes.Address, projected uint64) (uint64, error) {
+	if err := f.call("NextNonce", address, projected); err != nil {
+		return 0, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	nonce := projected
+	if reserved, ok := f.nonces[address]; ok && reserved+1 > nonce {
+		nonce = reserved + 1
+	}
+	f.nonces[address] = nonce
+	return nonce, nil
+}
+
+func (f *Fake) ReservedNonce(address gosmtypes.Address) (uint64, bool, error) {
+	if err := f.call("ReservedNonce", address); err != nil {
+		return 0, false, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	nonce, ok := f.nonces[address]
+	return nonce, ok, nil
+}
+
+func (f *Fake) ResetNonce(address gosmtypes.Address) error {
+	if err := f.call("ResetNonce", address); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.nonces, address)
+	return nil
+}
+
+func (f *Fake) Contacts() ([]common.Contact, error) {
+	if err := f.call("Contacts"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]common.Contact(nil), f.contacts...), nil
+}
+
+func (f *Fake) AddContact(name string, address gosmtypes.Address) error {
+	if err := f.call("AddContact", name, address); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.contacts = append(f.contacts, common.Contact{Name: name, Address: address.String()})
+	return nil
+}
+
+func (f *Fake) DeleteContact(name string) error {
+	if err := f.call("DeleteContact", name); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, c := range f.contacts {
+		if c.Name == name {
+			f.contacts = append(f.contacts[:i], f.contacts[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("contact %s not found", name)
+}
+
+func (f *Fake) SmesherContacts() ([]common.SmesherContact, error) {
+	if err := f.call("SmesherContacts"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]common.SmesherContact(nil), f.smeshers...), nil
+}
+
+func (f *Fake) AddSmesherContact(name string, id []byte) error {
+	if err := f.call("AddSmesherContact", name, id); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.smeshers = append(f.smeshers, common.SmesherContact{Name: name, Id: fmt.Sprintf("0x%x", id)})
+	return nil
+}
+
+func (f *Fake) DeleteSmesherContact(name string) error {
+	if err := f.call("DeleteSmesherContact", name); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, c := range f.smeshers {
+		if c.Name == name {
+			f.smeshers = append(f.smeshers[:i], f.smeshers[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("smesher %s not found", name)
+}
+
+func (f *Fake) TxTemplates() ([]common.TxTemplate, error) {
+	if err := f.call("TxTemplates"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]common.TxTemplate(nil), f.tmpl...), nil
+}
+
+func (f *Fake) TxTemplate(name string) (*common.TxTemplate, error) {
+	if err := f.call("TxTemplate", name); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range f.tmpl {
+		if t.Name == name {
+			res := t
+			return &res, nil
+		}
+	}
+	return nil, fmt.Errorf("template %s not found", name)
+}
+
+func (f *Fake) AddTxTemplate(t common.TxTemplate) error {
+	if err := f.call("AddTxTemplate", t.Name); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tmpl = append(f.tmpl, t)
+	return nil
+}
+
+func (f *Fake) DeleteTxTemplate(name string) error {
+	if err := f.call("DeleteTxTemplate", name); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, t := range f.tmpl {
+		if t.Name == name {
+			f.tmpl = append(f.tmpl[:i], f.tmpl[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("template %s not found", name)
+}
+
+// SetInput keeps the input of the session in Input, the fake doesn't prompt
+func (f *Fake) SetInput(in *bufio.Reader) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Input = in
+}
+
+// ApplyConfig does nothing, the fake has no call settings
+func (f *Fake) ApplyConfig() {}
+
+func (f *Fake) ReloadConfig() error {
+	return f.call("ReloadConfig")
+}
+
+func (f *Fake) InvalidateCache() {
+	f.call("InvalidateCache")
+}
+
+func (f *Fake) Close() error {
+	return f.call("Close")
+}
+
+func (f *Fake) Config() (*common.Config, error) {
+	if err := f.call("Config"); err != nil {
+		return nil, err
+	}
+	if f.Settings == nil {
+		return common.DefaultConfig(), nil
+	}
+	return f.Settings, nil
+}
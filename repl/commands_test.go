@@ -0,0 +1,87 @@
+package repl
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+
+	"github.com/spacemeshos/smrepl/repl/clienttest"
+)
+
+// waitForOutput waits until the output has all the wanted lines, which streams print in the background
+func waitForOutput(t *testing.T, output *output, want ...string) string {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		out := output.String()
+		missing := ""
+		for _, w := range want {
+			if !strings.Contains(out, w) {
+				missing = w
+				break
+			}
+		}
+		if missing == "" {
+			return out
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %q in the output, got:\n%s", missing, out)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStateStreamCommands(t *testing.T) {
+	for _, test := range []struct {
+		command string
+		want    []string
+		absent  []string
+	}{
+		{"state stream-rewards", []string{"Listening to new rewards", "Rewarded on layer: 7"}, []string{"Account update:", "[update]"}},
+		{"state stream-account", []string{"Listening for new updates", "Account update:", "Balance: 150 Smidge (+50 Smidge)", "Nonce: 2 (+1)"},
+			[]string{"Rewarded on layer:", "[reward]"}},
+		{"state stream", []string{"Listening to the account data", "[reward]  layer 7", "[update]  balance 100 Smidge",
+			"[update]  balance 150 Smidge (+50 Smidge), nonce 2"}, []string{"Account update:", "Rewarded on layer:"}},
+	} {
+		t.Run(test.command, func(t *testing.T) {
+			f := clienttest.New()
+			f.AccountData = []*apitypes.AccountDataStreamResponse{
+				{Datum: &apitypes.AccountData{Datum: &apitypes.AccountData_Reward{Reward: clienttest.Reward(mainAddress, 7, 5000, 100)}}},
+				{Datum: &apitypes.AccountData{Datum: &apitypes.AccountData_AccountWrapper{AccountWrapper: clienttest.Account(mainAddress, 100, 1)}}},
+				{Datum: &apitypes.AccountData{Datum: &apitypes.AccountData_AccountWrapper{AccountWrapper: clienttest.Account(mainAddress, 150, 2)}}},
+			}
+			r, output := newTestRepl(f, WithoutSplash(), WithInput(strings.NewReader(test.command+"\n"+mainAddress.String()+"\n")))
+			r.runScript()
+			out := waitForOutput(t, output, test.want...)
+			r.stopBackgroundStreams()
+			for _, absent := range test.absent {
+				if strings.Contains(out, absent) {
+					t.Fatalf("expected no %q in the output, got:\n%s", absent, out)
+				}
+			}
+		})
+	}
+}
+
+func TestAccountDataFlags(t *testing.T) {
+	all := apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_REWARD | apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_TRANSACTION_RECEIPT |
+		apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_ACCOUNT
+	if flags := accountDataFlags([]string{"0x1234"}); flags != all {
+		t.Fatalf("expected all account data by default, got %d", flags)
+	}
+	want := apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_REWARD | apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_ACCOUNT
+	if flags := accountDataFlags([]string{"--rewards", "--updates"}); flags != want {
+		t.Fatalf("expected rewards and updates, got %d", flags)
+	}
+}
+
+func TestAmountChange(t *testing.T) {
+	if change := amountChange(100, 150); change != "+50 Smidge" {
+		t.Fatalf("expected an increase, got %s", change)
+	}
+	if change := amountChange(150, 100); change != "-50 Smidge" {
+		t.Fatalf("expected a decrease, got %s", change)
+	}
+}
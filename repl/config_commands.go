@@ -0,0 +1,94 @@
+package repl
+
+import (
+	"fmt"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// config returns the settings or the default settings if they can't be read
+func (r *repl) config() *common.Config {
+	if r.settings != nil {
+		return r.settings
+	}
+	config, err := r.client.Config()
+	if err != nil {
+		log.Error("failed to read settings: %v", err)
+		return common.DefaultConfig()
+	}
+	return config
+}
+
+// setConfig changes a setting and saves it
+func (r *repl) setConfig() {
+	if len(r.args) != 2 {
+		fmt.Fprintln(r.out, printPrefix, "usage: config set <key> <value>")
+		return
+	}
+	config := r.settings
+	if config == nil {
+		var err error
+		if config, err = r.client.Config(); err != nil {
+			log.Error("failed to read settings: %v", err)
+			return
+		}
+	}
+	if err := config.Set(r.args[0], r.args[1]); err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	// the settings given to the session with WithConfig aren't saved
+	if r.settings == nil {
+		if err := config.Save(); err != nil {
+			log.Error("failed to save settings: %v", err)
+			return
+		}
+	}
+	// the value is displayed as Get returns it, which hides secrets such as the auth token
+	value, _ := config.Get(r.args[0])
+	fmt.Fprintln(r.out, printPrefix, r.args[0], "set to", value)
+}
+
+// getConfig prints a setting
+func (r *repl) getConfig() {
+	if len(r.args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: config get <key>")
+		return
+	}
+	value, err := r.config().Get(r.args[0])
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, r.args[0], value)
+}
+
+// listConfig prints all settings
+func (r *repl) listConfig() {
+	config := r.config()
+	for _, key := range common.ConfigKeys {
+		value, _ := config.Get(key)
+		fmt.Fprintln(r.out, printPrefix, key, value)
+	}
+}
+
+// formatAddress returns the display string of an address in the configured address format.
+// In verbose mode bech32 addresses are followed by their hex form.
+func (r *repl) formatAddress(address gosmtypes.Address) string {
+	config := r.config()
+	if config.AddrFormat != common.AddrFormatBech32 {
+		return address.String()
+	}
+	if config.Verbose {
+		return fmt.Sprintf("%s (%s)", common.EncodeBech32Address(address), address.String())
+	}
+	return common.EncodeBech32Address(address)
+}
+
+// formatSmesherId returns the display string of a smesher id in the configured id format
+func (r *repl) formatSmesherId(id []byte) string {
+	return common.FormatSmesherId(id, r.config().IdFormat)
+}
@@ -0,0 +1,245 @@
+package repl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// addContact adds a named address to the address book
+func (r *repl) addContact() {
+	if len(r.args) != 2 {
+		fmt.Fprintln(r.out, printPrefix, "usage: contact add <name> <address>")
+		return
+	}
+	name := r.args[0]
+	address, err := common.ParseAddress(r.args[1])
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+
+	if err = r.client.AddContact(name, address); err != nil {
+		log.Error("failed to add contact: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Added contact", name, r.formatAddress(address))
+}
+
+// listContacts prints the address book, or its smesher ids with --smeshers
+func (r *repl) listContacts() {
+	if hasFlag(r.args, "--smeshers") {
+		r.listSmesherContacts()
+		return
+	}
+	contacts, err := r.client.Contacts()
+	if err != nil {
+		log.Error("failed to read address book: %v", err)
+		return
+	}
+	if len(contacts) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "The address book is empty")
+		return
+	}
+	for _, c := range contacts {
+		fmt.Fprintln(r.out, printPrefix, c.Name, c.Address)
+	}
+}
+
+// listSmesherContacts prints the smesher ids saved in the address book
+func (r *repl) listSmesherContacts() {
+	smeshers, err := r.client.SmesherContacts()
+	if err != nil {
+		log.Error("failed to read address book: %v", err)
+		return
+	}
+	if len(smeshers) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "No smesher ids are saved, save one with smesher id --save <name>")
+		return
+	}
+	for _, s := range smeshers {
+		if id, err := common.ParseSmesherId(s.Id); err == nil {
+			fmt.Fprintln(r.out, printPrefix, s.Name, r.formatSmesherId(id))
+		}
+	}
+}
+
+// deleteContact removes a named address, or a named smesher id with --smesher, from the address book
+func (r *repl) deleteContact() {
+	args := positionalArgs(r.args)
+	if len(args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: contact delete <name> [--smesher]")
+		return
+	}
+	if hasFlag(r.args, "--smesher") {
+		if err := r.client.DeleteSmesherContact(args[0]); err != nil {
+			log.Error("failed to delete smesher: %v", err)
+			return
+		}
+		fmt.Fprintln(r.out, printPrefix, "Deleted smesher", args[0])
+		return
+	}
+	if err := r.client.DeleteContact(args[0]); err != nil {
+		log.Error("failed to delete contact: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Deleted contact", args[0])
+}
+
+// resolveAddress returns the address of a contact name or a local account alias, or strictly parses
+// a hex or bech32 address
+func (r *repl) resolveAddress(input string) (gosmtypes.Address, error) {
+	address, _, err := r.resolveRecipient(input)
+	return address, err
+}
+
+// resolveRecipient is resolveAddress that also returns where a name was found, e.g. "contact bob" or
+// "account savings", or an empty string for a plain address. A name that is both a contact and a
+// local account with another address is an error.
+func (r *repl) resolveRecipient(input string) (gosmtypes.Address, string, error) {
+	input = strings.TrimSpace(input)
+	var contact *gosmtypes.Address
+	if contacts, err := r.client.Contacts(); err == nil {
+		for _, c := range contacts {
+			if c.Name == input {
+				address, err := common.ParseAddress(c.Address)
+				if err != nil {
+					return gosmtypes.Address{}, "", err
+				}
+				contact = &address
+				break
+			}
+		}
+	}
+	var account *gosmtypes.Address
+	if names, err := r.client.ListAccounts(); err == nil {
+		for _, name := range names {
+			if name != input {
+				continue
+			}
+			if acc, err := r.client.GetAccount(name); err == nil {
+				address := acc.Address()
+				acc.Wipe()
+				account = &address
+			}
+			break
+		}
+	}
+
+	switch {
+	case contact != nil && account != nil && *contact != *account:
+		return gosmtypes.Address{}, "", fmt.Errorf("%s is both a contact and a local account, enter the address instead", input)
+	case contact != nil:
+		return *contact, "contact " + input, nil
+	case account != nil:
+		return *account, "account " + input, nil
+	}
+	address, err := common.ParseAddress(input)
+	return address, "", err
+}
+
+// addressString returns the display string of an address annotated with its contact name when known
+func (r *repl) addressString(address gosmtypes.Address) string {
+	if contacts, err := r.client.Contacts(); err == nil {
+		for _, c := range contacts {
+			if gosmtypes.HexToAddress(c.Address) == address {
+				return fmt.Sprintf("%s (%s)", r.formatAddress(address), c.Name)
+			}
+		}
+	}
+	return r.formatAddress(address)
+}
+
+// contactsCompleter suggests address book names and local account aliases
+func (r *repl) contactsCompleter(d prompt.Document) []prompt.Suggest {
+	var suggests []prompt.Suggest
+	if contacts, err := r.client.Contacts(); err == nil {
+		for _, c := range contacts {
+			suggests = append(suggests, prompt.Suggest{Text: c.Name, Description: "contact " + c.Address})
+		}
+	}
+	if names, err := r.client.ListAccounts(); err == nil {
+		for _, name := range names {
+			suggests = append(suggests, prompt.Suggest{Text: name, Description: "local account"})
+		}
+	}
+	return prompt.FilterHasPrefix(suggests, d.GetWordBeforeCursor(), true)
+}
+
+// inputAddress prompts for an address, a contact name or a local account alias until a valid
+// address is entered
+func (r *repl) inputAddress(msg string) gosmtypes.Address {
+	address, _ := r.inputRecipient(msg)
+	return address
+}
+
+// inputRecipient is inputAddress that also returns where an entered name was found
+func (r *repl) inputRecipient(msg string) (gosmtypes.Address, string) {
+	for {
+		input := r.promptInput(r.prefix+msg,
+			r.contactsCompleter,
+			prompt.OptionPrefixTextColor(prompt.LightGray))
+
+		if strings.TrimSpace(input) == "" {
+			fmt.Fprintln(r.out, printPrefix, "please enter a value.")
+			continue
+		}
+
+		address, source, err := r.resolveRecipient(input)
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			continue
+		}
+		return address, source
+	}
+}
+
+// zeroAddressConfirmation must be typed to send coins to the zero address
+const zeroAddressConfirmation = "I understand"
+
+// confirmRecipients prints the warnings about transaction recipients and asks to continue. Sending
+// to the zero address requires typing zeroAddressConfirmation. It returns true when there is no
+// warning or the user confirmed.
+func (r *repl) confirmRecipients(sender gosmtypes.Address, recipients ...gosmtypes.Address) bool {
+	warned, zero := false, false
+	for _, recipient := range recipients {
+		check := common.CheckRecipient(sender, recipient)
+		for _, w := range check.Warnings {
+			fmt.Fprintln(r.out, printPrefix, "WARNING:", r.formatAddress(recipient)+":", w)
+			warned = true
+		}
+		zero = zero || check.Zero
+	}
+	switch {
+	case zero:
+		return strings.TrimSpace(r.inputNotBlank(fmt.Sprintf(zeroAddressConfirmMsg, zeroAddressConfirmation))) == zeroAddressConfirmation
+	case warned:
+		return r.yesOrNoQuestion(recipientWarningMsg) == "y"
+	}
+	return true
+}
+
+// confirmSpendLimit asks to type an amount back when it is above the configured spend limit, then
+// for the wallet password. It returns true when the amount is within the limit or confirmed.
+func (r *repl) confirmSpendLimit(amount uint64) bool {
+	config := r.config()
+	if !config.ExceedsSpendLimit(amount) {
+		return true
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("WARNING: %s is above the spend limit of %s.", common.FormatAmount(amount), common.FormatAmount(config.SpendLimit)))
+	typed, err := common.ParseAmount(r.inputNotBlank(fmt.Sprintf(spendLimitConfirmMsg, common.FormatAmount(amount))))
+	if err != nil || typed != amount {
+		fmt.Fprintln(r.out, printPrefix, "The amount doesn't match. Nothing was sent.")
+		return false
+	}
+	if err := r.client.ConfirmPassword(); err != nil {
+		fmt.Fprintln(r.out, printPrefix, "Wrong password. Nothing was sent.")
+		return false
+	}
+	return true
+}
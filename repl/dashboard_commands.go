@@ -0,0 +1,88 @@
+package repl
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// defaultDashboardInterval is the time between dashboard refreshes with --watch and without
+// --interval
+const defaultDashboardInterval = 10 * time.Second
+
+// overviewRows returns the node, account, smesher and global state sections of the dashboard.
+// Every value is fetched on its own, so one the node doesn't report is shown as unavailable and
+// the others are still shown.
+func (r *repl) overviewRows(d *smesherDashboard) []dashboardRow {
+	rows := []dashboardRow{{label: "Node"}}
+	add := func(label, value string) {
+		rows = append(rows, dashboardRow{label, value})
+	}
+
+	if sample, err := r.syncSample(); err != nil {
+		add("Sync", unavailable(err))
+	} else {
+		r.setNodeStatus(sample.IsSynced, sample.Peers)
+		report := common.SyncProgress(nil, *sample)
+		add("Sync", fmt.Sprintf("%s, layer %d of %d, %d peers", report.Verdict, sample.Synced, sample.Top, sample.Peers))
+	}
+	info, err := r.client.GetMeshInfo(r.ctx)
+	rows = append(rows, layerRow(info, err))
+	add("Server", r.client.ServerInfo())
+
+	rows = append(rows, dashboardRow{label: "Account"})
+	var acc *common.LocalAccount
+	if r.client.IsOpen() && r.accountOverride != "" {
+		acc, err = r.client.GetAccount(r.accountOverride)
+	} else if r.client.IsOpen() {
+		acc, err = r.client.CurrentAccount()
+	}
+	if !r.client.IsOpen() {
+		add("Account", "no wallet open")
+	} else if err != nil {
+		add("Account", "none selected")
+	} else {
+		add("Account", fmt.Sprintf("%s, %s", acc.Name, r.formatAddress(acc.Address())))
+		if state, err := r.client.AccountState(r.ctx, acc.Address()); err != nil {
+			add("Balance", unavailable(err))
+		} else {
+			current, projected := state.GetStateCurrent(), state.GetStateProjected()
+			add("Balance", fmt.Sprintf("%s, nonce %d", common.FormatAmount(current.GetBalance().GetValue()), current.GetCounter()))
+			pending := uint64(0)
+			if projected.GetCounter() > current.GetCounter() {
+				pending = projected.GetCounter() - current.GetCounter()
+			}
+			add("Pending", fmt.Sprintf("%d outgoing transactions, projected balance %s", pending, common.FormatAmount(projected.GetBalance().GetValue())))
+		}
+	}
+
+	rows = append(rows, dashboardRow{label: "Smesher"})
+	rows = append(rows, r.smesherRows(d, info)...)
+
+	rows = append(rows, dashboardRow{label: "Global state"})
+	if hash, err := r.client.GlobalStateHash(r.ctx); err != nil {
+		add("State", unavailable(err))
+	} else {
+		add("State", fmt.Sprintf("layer %d, 0x%s", hash.GetLayer().GetNumber(), hex.EncodeToString(hash.GetRootHash())))
+	}
+	return rows
+}
+
+// printOverview prints the node, account, smesher and global state on one screen, refreshed every
+// --interval seconds until Enter or Ctrl+C with --watch
+func (r *repl) printOverview() {
+	dashboard := &smesherDashboard{}
+	if hasFlag(r.args, "--watch") {
+		interval, ok := r.watchInterval(defaultDashboardInterval)
+		if !ok {
+			return
+		}
+		r.watchDashboard("Dashboard", interval, func() []dashboardRow {
+			return r.overviewRows(dashboard)
+		})
+		return
+	}
+	r.printDashboard(fmt.Sprintf("Dashboard at %s", time.Now().Format(layerTimeFormat)), r.overviewRows(dashboard), make(map[string]string))
+}
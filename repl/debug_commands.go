@@ -1,30 +1,324 @@
 package repl
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
 	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
 	"github.com/spacemeshos/smrepl/log"
 )
 
+// accountsPageSize is the number of accounts printed per page of dbg all-accounts
+const accountsPageSize = 50
+
+// Keys dbg all-accounts sorts by with --sort
+const (
+	sortByBalance = "balance"
+	sortByAddress = "address"
+)
+
+// selectAccounts returns the accounts with at least minBalance in the given order, only the top
+// largest of them when top isn't 0
+func selectAccounts(accounts []*apitypes.Account, minBalance uint64, order listingSort, top int) []*apitypes.Account {
+	var res []*apitypes.Account
+	for _, a := range accounts {
+		if currentBalance(a) >= minBalance {
+			res = append(res, a)
+		}
+	}
+	if top > 0 {
+		sort.SliceStable(res, func(i, j int) bool { return currentBalance(res[i]) > currentBalance(res[j]) })
+		if len(res) > top {
+			res = res[:top]
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		if order.key == sortByAddress {
+			c := bytes.Compare(res[i].GetAccountId().GetAddress(), res[j].GetAccountId().GetAddress())
+			return (c < 0 && !order.desc) || (c > 0 && order.desc)
+		}
+		if order.desc {
+			return currentBalance(res[i]) > currentBalance(res[j])
+		}
+		return currentBalance(res[i]) < currentBalance(res[j])
+	})
+	return res
+}
+
+// printAllAccounts prints a page of the global state accounts, largest balance first. --sort
+// balance|address with --desc changes the order, --min-balance <amount> leaves out smaller
+// accounts, --top <n> keeps the n largest, and --page <n> selects the page. --all --raw prints
+// every account in the order of the node.
 func (r *repl) printAllAccounts() {
-	accounts, err := r.client.DebugAllAccounts()
+	accounts, err := r.client.DebugAllAccounts(r.ctx)
 	if err != nil {
-		log.Error("failed to get debug all accounts: %v", err)
+		r.printNodeError(common.CallError("get all accounts", err))
+		return
+	}
+	if hasFlag(r.args, "--all") && hasFlag(r.args, "--raw") {
+		for _, a := range accounts {
+			fmt.Fprintln(r.out, printPrefix, "Address:", r.formatAddress(gosmtypes.BytesToAddress(a.AccountId.Address)))
+			fmt.Fprintln(r.out, printPrefix, "Balance:", common.FormatSmidge(currentBalance(a)))
+			fmt.Fprintln(r.out, printPrefix, "Nonce:", a.GetStateCurrent().GetCounter())
+			fmt.Fprintln(r.out, printPrefix, "-----")
+		}
 		return
 	}
 
-	for _, a := range accounts {
+	order := listingSort{key: sortByBalance, desc: true}
+	if key, ok := flagValue(r.args, "--sort"); ok {
+		if key != sortByBalance && key != sortByAddress {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("can't sort by %s, expected one of [%s %s]", key, sortByBalance, sortByAddress))
+			return
+		}
+		order = listingSort{key: key, desc: hasFlag(r.args, "--desc")}
+	}
+	minBalance := uint64(0)
+	if s, ok := flagValue(r.args, "--min-balance"); ok {
+		if minBalance, err = common.ParseAmount(s); err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+	}
+	top, page := 0, 1
+	for flag, value := range map[string]*int{"--top": &top, "--page": &page} {
+		if s, ok := flagValue(r.args, flag); ok {
+			if *value, err = strconv.Atoi(s); err != nil || *value < 1 {
+				fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("invalid %s value: %s", flag, s))
+				return
+			}
+		}
+	}
+
+	selected := selectAccounts(accounts, minBalance, order, top)
+	if len(selected) == 0 {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("No accounts of %d match", len(accounts)))
+		return
+	}
+	pages := (len(selected) + accountsPageSize - 1) / accountsPageSize
+	if page > pages {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("There are only %d pages", pages))
+		return
+	}
+	first := (page - 1) * accountsPageSize
+	last := first + accountsPageSize
+	if last > len(selected) {
+		last = len(selected)
+	}
+	sum := uint64(0)
+	for _, a := range selected[first:last] {
+		sum += currentBalance(a)
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%s  %s  nonce %d", r.formatAddress(gosmtypes.BytesToAddress(a.GetAccountId().GetAddress())),
+			common.FormatAmount(currentBalance(a)), a.GetStateCurrent().GetCounter()))
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Page %d of %d, %d of %d accounts. The balances shown sum to %s. Use --page <n> for other pages.",
+		page, pages, len(selected), len(accounts), common.FormatAmount(sum)))
+}
+
+// exportProgressInterval is the number of accounts between the progress lines of dbg
+// export-accounts
+const exportProgressInterval = 10000
 
-		fmt.Println(printPrefix, "Address:", gosmtypes.BytesToAddress(a.AccountId.Address).String())
+// snapshotState returns the node's current global state for an accounts snapshot
+func (r *repl) snapshotState() (common.SnapshotState, error) {
+	hash, err := r.client.GlobalStateHash(r.ctx)
+	if err != nil {
+		return common.SnapshotState{}, err
+	}
+	return common.SnapshotState{Layer: hash.GetLayer().GetNumber(), Hash: "0x" + hex.EncodeToString(hash.GetRootHash())}, nil
+}
 
-		balance := uint64(0)
-		if a.StateCurrent.Balance != nil {
-			balance = a.StateCurrent.Balance.Value
+// exportAllAccounts writes the address, balance and nonce of every global state account to a JSON
+// file, with the global state read before and after the accounts. The node returns the accounts
+// in one response, which isn't paged, but they are written to the file one at a time. A warning is
+// printed when the global state changed while the accounts were read.
+func (r *repl) exportAllAccounts() {
+	args := positionalArgs(r.args)
+	if len(args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: dbg export-accounts <file.json>")
+		return
+	}
+	path := args[0]
+	before, err := r.snapshotState()
+	if err != nil {
+		r.printNodeError(common.CallError("get global state", err))
+		return
+	}
+	accounts, err := r.client.DebugAllAccounts(r.ctx)
+	if err != nil {
+		r.printNodeError(common.CallError("get all accounts", err))
+		return
+	}
+	after, err := r.snapshotState()
+	if err != nil {
+		r.printNodeError(common.CallError("get global state", err))
+		return
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		log.Error("failed to create export file: %v", err)
+		return
+	}
+	w, err := common.NewAccountsSnapshotWriter(f, before)
+	for i := 0; err == nil && i < len(accounts); i++ {
+		err = w.Add(common.SnapshotAccount{
+			Address: gosmtypes.BytesToAddress(accounts[i].GetAccountId().GetAddress()).String(),
+			Balance: currentBalance(accounts[i]),
+			Counter: accounts[i].GetStateCurrent().GetCounter(),
+		})
+		if err == nil && w.Count()%exportProgressInterval == 0 {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Exported %d of %d accounts...", w.Count(), len(accounts)))
 		}
+	}
+	if err == nil {
+		err = w.Close(after)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Error("failed to write export file: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Exported %d accounts at layer %d to: %s", len(accounts), after.Layer, path))
+	if before != after {
+		fmt.Fprintln(r.out, printPrefix, colorYellow+fmt.Sprintf("The global state changed from layer %d %s to layer %d %s during the export, so the snapshot may mix both states.",
+			before.Layer, before.Hash, after.Layer, after.Hash)+colorReset)
+	}
+}
 
-		fmt.Println(printPrefix, "Balance:", balance, coinUnitName)
-		fmt.Println(printPrefix, "Nonce:", a.StateCurrent.Counter)
-		fmt.Println(printPrefix, "-----")
+// streamNodeErrors sends the errors of the node's error stream to nodeErrors until stop is closed.
+// The stream is opened again after transient errors. The error which ends streaming, e.g. because
+// the node doesn't expose the service, is sent to failed.
+func (r *repl) streamNodeErrors(ctx context.Context, nodeErrors chan<- *apitypes.NodeError, failed chan<- error, stop <-chan struct{}) {
+	open := func() (streamReceiver, error) {
+		stream, err := r.client.ErrorStream(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			resp, err := stream.Recv()
+			if err != nil || resp.GetError() == nil {
+				return err
+			}
+			select {
+			case nodeErrors <- resp.GetError():
+				return nil
+			case <-stop:
+				return errStreamStopped
+			}
+		}, nil
+	}
+	gap := func() string {
+		return "Errors the node logged while it was down are missing."
+	}
+	if err := r.followStream("error", r.streamBackoff(), open, gap, stop); err != errStreamStopped {
+		select {
+		case failed <- err:
+		case <-stop:
+		}
+	}
+}
+
+// ANSI escape codes of the colors node errors, status changes and warnings are printed in
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorGray   = "\033[90m"
+)
+
+// logLevelColor returns the color of a log level: red for errors and worse, yellow for warnings and
+// gray for the rest
+func logLevelColor(level apitypes.LogLevel) string {
+	switch {
+	case level >= apitypes.LogLevel_LOG_LEVEL_ERROR:
+		return colorRed
+	case level == apitypes.LogLevel_LOG_LEVEL_WARN:
+		return colorYellow
+	default:
+		return colorGray
+	}
+}
+
+// streamErrors prints the errors the node logs with their severity and the time they were received
+// until Enter or Ctrl+C is pressed
+func (r *repl) streamErrors() {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	enter := r.enterPressed()
+	nodeErrors := make(chan *apitypes.NodeError)
+	failed := make(chan error)
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.streamNodeErrors(r.ctx, nodeErrors, failed, stop)
+
+	fmt.Fprintln(r.out, printPrefix, "Streaming node errors, press Enter or Ctrl+C to stop...")
+	count := 0
+	for {
+		select {
+		case nodeError := <-nodeErrors:
+			count++
+			r.printNodeErrorEvent(time.Now(), nodeError)
+			continue
+		case err := <-failed:
+			r.printNodeError(common.CallError("stream node errors", err))
+			if permanentStreamError(err) {
+				fmt.Fprintln(r.out, printPrefix, "The node must expose the debug services to stream its errors.")
+			}
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-interrupt:
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-enter:
+		}
+		break
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Stopped streaming after %d node errors.", count))
+}
+
+// printNodeErrorEvent prints a node error colored by its severity, followed by its stack trace
+func (r *repl) printNodeErrorEvent(received time.Time, nodeError *apitypes.NodeError) {
+	level := strings.TrimPrefix(nodeError.Level.String(), "LOG_LEVEL_")
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%s%s  %-7s%s %s", logLevelColor(nodeError.Level),
+		received.Format(layerTimeFormat), level, colorReset, nodeError.Msg))
+	if nodeError.StackTrace != "" {
+		fmt.Fprintln(r.out, colorGray+nodeError.StackTrace+colorReset)
+	}
+}
+
+// debugGRPC starts or stops logging the node API calls with their requests, response sizes,
+// status and latency to the log file, or prints whether they are logged
+func (r *repl) debugGRPC() {
+	switch {
+	case len(r.args) == 0:
+		state := "off"
+		if r.client.GRPCDebug() {
+			state = "on"
+		}
+		fmt.Fprintln(r.out, printPrefix, "Logging of the node API calls is", state)
+	case len(r.args) == 1 && r.args[0] == "on":
+		r.client.SetGRPCDebug(true)
+		fmt.Fprintln(r.out, printPrefix, "Logging the node API calls to log.txt, keys and signatures redacted. They are printed in verbose mode.")
+	case len(r.args) == 1 && r.args[0] == "off":
+		r.client.SetGRPCDebug(false)
+		fmt.Fprintln(r.out, printPrefix, "Stopped logging the node API calls.")
+	default:
+		fmt.Fprintln(r.out, printPrefix, "usage: grpc [on|off]")
 	}
 }
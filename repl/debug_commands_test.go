@@ -0,0 +1,60 @@
+package repl
+
+import (
+	"reflect"
+	"testing"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+
+	"github.com/spacemeshos/smrepl/repl/clienttest"
+)
+
+func TestSelectAccounts(t *testing.T) {
+	account := func(address byte, balance uint64) *apitypes.Account {
+		return &apitypes.Account{
+			AccountId:    &apitypes.AccountId{Address: []byte{address}},
+			StateCurrent: &apitypes.AccountState{Balance: &apitypes.Amount{Value: balance}},
+		}
+	}
+	accounts := []*apitypes.Account{account(3, 10), account(1, 500), account(4, 0), account(2, 70)}
+	addresses := func(accounts []*apitypes.Account) []byte {
+		var res []byte
+		for _, a := range accounts {
+			res = append(res, a.AccountId.Address[0])
+		}
+		return res
+	}
+
+	if got := addresses(selectAccounts(accounts, 0, listingSort{key: sortByBalance, desc: true}, 0)); !reflect.DeepEqual(got, []byte{1, 2, 3, 4}) {
+		t.Fatalf("expected the largest balance first, got %v", got)
+	}
+	if got := addresses(selectAccounts(accounts, 10, listingSort{key: sortByAddress}, 0)); !reflect.DeepEqual(got, []byte{1, 2, 3}) {
+		t.Fatalf("expected the accounts with at least 10 by address, got %v", got)
+	}
+	if got := addresses(selectAccounts(accounts, 0, listingSort{key: sortByAddress, desc: true}, 2)); !reflect.DeepEqual(got, []byte{2, 1}) {
+		t.Fatalf("expected the 2 largest accounts by descending address, got %v", got)
+	}
+}
+
+func TestDebugGRPCCommand(t *testing.T) {
+	runCommandTests(t, fakeWallet, []commandTest{
+		{
+			name:  "on",
+			line:  "dbg grpc on",
+			want:  []string{"Logging the node API calls to log.txt"},
+			calls: []string{"SetGRPCDebug"},
+		},
+		{
+			name:  "state",
+			line:  "dbg grpc",
+			setup: func(f *clienttest.Fake) { f.Debug = true },
+			want:  []string{"Logging of the node API calls is on"},
+		},
+		{
+			name:   "usage",
+			line:   "dbg grpc maybe",
+			want:   []string{"usage: grpc [on|off]"},
+			absent: []string{"Logging"},
+		},
+	})
+}
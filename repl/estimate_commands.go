@@ -0,0 +1,114 @@
+package repl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// estimateSmeshingRewards estimates the rewards of a smesher from its space, the space of the
+// network and the layer reward. The space is the planned one when given, otherwise the PoST data
+// the node created. The network space and the layer reward aren't reported by the node, so they
+// are prompted for unless given with --network-space and --layer-reward, and so are the epoch
+// parameters when the node can't be reached.
+func (r *repl) estimateSmeshingRewards() {
+	in := common.RewardEstimateInput{}
+	if args := positionalArgs(r.args, "--network-space", "--layer-reward"); len(args) > 0 {
+		size, err := common.ParseSize(args[0])
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+		in.Space, in.Joining = size, true
+	} else if postStatus, err := r.client.GetPostStatus(r.ctx); err == nil && postStatus.GetBytesWritten() > 0 {
+		in.Space = postStatus.GetBytesWritten()
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Using the %.2f GiB of PoST data the node created.", common.GiB(in.Space)))
+	} else {
+		in.Space, in.Joining = r.inputSize(estimateSpaceMsg), true
+	}
+
+	if value, ok := flagValue(r.args, "--network-space"); ok {
+		size, err := common.ParseSize(value)
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+		in.NetworkSpace = size
+	} else {
+		in.NetworkSpace = r.inputSize(estimateNetworkSpaceMsg)
+	}
+
+	if value, ok := flagValue(r.args, "--layer-reward"); ok {
+		reward, err := common.ParseAmount(value)
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+		in.LayerReward = reward
+	} else {
+		in.LayerReward = r.inputAmount(estimateLayerRewardMsg)
+	}
+
+	if params, err := r.client.NetworkParams(r.ctx, false); err == nil {
+		in.LayersPerEpoch, in.LayerDuration = params.LayerPerEpoch, params.LayerDuration
+	} else {
+		fmt.Fprintln(r.out, printPrefix, "The node didn't report the epoch parameters:", err)
+		in.LayersPerEpoch = r.inputPositive(estimateLayersPerEpochMsg)
+		in.LayerDuration = r.inputPositive(estimateLayerDurationMsg)
+	}
+
+	estimate, err := common.EstimateRewards(in)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	epoch := time.Duration(in.LayersPerEpoch*in.LayerDuration) * time.Second
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Share of the network space: %.4f%%", estimate.Share*100))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Estimated reward per epoch of %d layers (%s): %s",
+		in.LayersPerEpoch, common.HumanDuration(epoch), common.FormatAmount(estimate.PerEpoch)))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Estimated reward per month of 30 days (%.1f epochs): %s",
+		estimate.EpochsPerMonth, common.FormatAmount(estimate.PerMonth)))
+	fmt.Fprintln(r.out, printPrefix, estimateCaveats)
+}
+
+// estimateCaveats explains why actual rewards differ from the estimate
+const estimateCaveats = "These are expected values. Smeshers are eligible for layers at random, so actual rewards " +
+	"vary widely from epoch to epoch, the more so the smaller the share. A new smesher earns nothing until its " +
+	"PoST data is created and its first activation is accepted, at least an epoch later. The network space and " +
+	"the layer reward change over time."
+
+// inputSize prompts until a valid PoST data size is entered
+func (r *repl) inputSize(msg string) uint64 {
+	for {
+		size, err := common.ParseSize(r.inputNotBlank(msg))
+		if err == nil {
+			return size
+		}
+		fmt.Fprintln(r.out, printPrefix, err)
+	}
+}
+
+// inputAmount prompts until a valid coin amount is entered
+func (r *repl) inputAmount(msg string) uint64 {
+	for {
+		amount, err := common.ParseAmount(r.inputNotBlank(msg))
+		if err == nil {
+			return amount
+		}
+		fmt.Fprintln(r.out, printPrefix, err)
+	}
+}
+
+// inputPositive prompts until a positive whole number is entered
+func (r *repl) inputPositive(msg string) uint64 {
+	for {
+		n, err := strconv.ParseUint(strings.TrimSpace(r.inputNotBlank(msg)), 10, 64)
+		if err == nil && n > 0 {
+			return n
+		}
+		fmt.Fprintln(r.out, printPrefix, "please enter a positive whole number.")
+	}
+}
@@ -0,0 +1,378 @@
+package repl
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/repl/clienttest"
+)
+
+var _ Client = (*clienttest.Fake)(nil)
+
+// output collects what a session prints, from its commands and its background streams
+type output struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.Write(p)
+}
+
+func (o *output) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+// newTestRepl returns a session with the fake as client and what it prints
+func newTestRepl(f *clienttest.Fake, opts ...Option) (*repl, *output) {
+	out := &output{}
+	return newRepl(f, append([]Option{WithOutput(out)}, opts...)...), out
+}
+
+// runCommand runs a command line against a session with the fake as client, as the prompt does,
+// and returns what it printed
+func runCommand(t *testing.T, f *clienttest.Fake, line string) string {
+	r, out := newTestRepl(f)
+	r.executor(line)
+	return out.String()
+}
+
+// commandTest runs a command line against a fake seeded by setup
+type commandTest struct {
+	name  string
+	line  string
+	setup func(f *clienttest.Fake)
+	// want are printed in order and absent aren't printed
+	want, absent []string
+	// calls are the methods the fake received, in order, among other calls
+	calls []string
+}
+
+func runCommandTests(t *testing.T, seed func(f *clienttest.Fake), tests []commandTest) {
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := clienttest.New()
+			seed(f)
+			if test.setup != nil {
+				test.setup(f)
+			}
+			f.ResetCalls()
+			out := runCommand(t, f, test.line)
+
+			rest := out
+			for _, want := range test.want {
+				i := strings.Index(rest, want)
+				if i < 0 {
+					t.Fatalf("%s: expected %q in order in the output:\n%s", test.line, want, out)
+				}
+				rest = rest[i+len(want):]
+			}
+			for _, absent := range test.absent {
+				if strings.Contains(out, absent) {
+					t.Fatalf("%s: expected no %q in the output:\n%s", test.line, absent, out)
+				}
+			}
+			calls := f.Calls()
+			for _, want := range test.calls {
+				for len(calls) > 0 && calls[0].Method != want {
+					calls = calls[1:]
+				}
+				if len(calls) == 0 {
+					t.Fatalf("%s: expected a call to %s in order among %v", test.line, want, f.Calls())
+				}
+				calls = calls[1:]
+			}
+		})
+	}
+}
+
+// fakeWallet seeds a wallet with the accounts main, the current one, and savings, with balances
+func fakeWallet(f *clienttest.Fake) {
+	main, savings := clienttest.LocalAccount("main", 1), clienttest.LocalAccount("savings", 2)
+	savings.Tags = []string{"cold"}
+	f.AddAccount(main)
+	f.AddAccount(savings)
+	f.SetBalance(main.Address(), 1500000000000, 3)
+	f.SetBalance(savings.Address(), 200, 0)
+}
+
+var (
+	mainAddress    = clienttest.LocalAccount("main", 1).Address()
+	savingsAddress = clienttest.LocalAccount("savings", 2).Address()
+	otherAddress   = gosmtypes.HexToAddress("0x1234567890123456789012345678901234567890")
+)
+
+func TestAccountCommands(t *testing.T) {
+	runCommandTests(t, fakeWallet, []commandTest{
+		{
+			name:  "info",
+			line:  "account info",
+			want:  []string{"Local alias: main", "Balance: 1.5 SMH", "Nonce: 3"},
+			calls: []string{"CurrentAccount", "AccountState", "ReservedNonce"},
+		},
+		{
+			name:   "info of another account",
+			line:   "account info @savings",
+			want:   []string{"Local alias: savings", "Tags: cold", "Balance: 200 Smidge"},
+			absent: []string{"Local alias: main"},
+			calls:  []string{"GetAccount", "AccountState"},
+		},
+		{
+			name: "info with a reserved nonce",
+			line: "account info",
+			setup: func(f *clienttest.Fake) {
+				f.NextNonce(mainAddress, 5)
+			},
+			want: []string{"Nonce: 3", "Locally reserved nonce: 5"},
+		},
+		{
+			name:  "balances",
+			line:  "account balances --total",
+			want:  []string{"*", "main", "1.5 SMH", "savings", "200 Smidge", "[cold]", "Total", "1.5000000002 SMH"},
+			calls: []string{"ListAccounts", "GetAccount", "GetAccount", "CurrentAccount"},
+		},
+		{
+			name: "balances with a failing node",
+			line: "account balances",
+			setup: func(f *clienttest.Fake) {
+				f.Errors["AccountState"] = status.Error(codes.Unavailable, "node down")
+			},
+			want:   []string{"main", "error:", "savings", "error:"},
+			absent: []string{"Smidge", "SMH"},
+		},
+		{
+			name: "rewards",
+			line: "account rewards --sort amount --desc",
+			setup: func(f *clienttest.Fake) {
+				f.AddRewards(mainAddress,
+					clienttest.Reward(mainAddress, 10, 5000, 100),
+					clienttest.Reward(mainAddress, 12, 9000, 0),
+					clienttest.Reward(mainAddress, 11, 7000, 0))
+			},
+			want:  []string{"Total rewards: 3", "layer: 12", "layer: 11", "layer: 10", "Transaction fees 100", "Showing 1–3 of 3."},
+			calls: []string{"CurrentAccount", "AccountRewards"},
+		},
+		{
+			name: "rewards with a failing node",
+			line: "account rewards",
+			setup: func(f *clienttest.Fake) {
+				f.Errors["AccountRewards"] = status.Error(codes.Unavailable, "node down")
+			},
+			want:   []string{"get rewards failed"},
+			absent: []string{"Total rewards"},
+		},
+		{
+			name: "receipts",
+			line: "account receipts",
+			setup: func(f *clienttest.Fake) {
+				f.AddReceipts(mainAddress,
+					clienttest.Receipt([]byte{0xaa}, 20, apitypes.TransactionReceipt_TRANSACTION_RESULT_EXECUTED, 1, 1),
+					clienttest.Receipt([]byte{0xbb}, 21, apitypes.TransactionReceipt_TRANSACTION_RESULT_INSUFFICIENT_FUNDS, 1, 1))
+			},
+			want:  []string{"0xbb", "FAILED: insufficient funds", "21", "0xaa", "success", "20", "2 receipts, 1 failed", "Showing 1–2 of 2."},
+			calls: []string{"CurrentAccount", "AccountTransactionsReceipts"},
+		},
+		{
+			name:  "note",
+			line:  "account note paid monthly",
+			calls: []string{"CurrentAccount", "SetAccountNote"},
+		},
+	})
+}
+
+func TestAccountNoteIsStored(t *testing.T) {
+	f := clienttest.New()
+	fakeWallet(f)
+	runCommand(t, f, "account note paid monthly")
+	acc, err := f.GetAccount("main")
+	if err != nil || acc.Note != "paid monthly" {
+		t.Fatalf("expected the note to be stored, got %+v %v", acc, err)
+	}
+	if calls := f.CallsTo("SetAccountNote"); len(calls) != 1 || calls[0].String() != "SetAccountNote(main, paid monthly)" {
+		t.Fatalf("expected one call setting the note, got %v", calls)
+	}
+}
+
+func TestTransactionCommands(t *testing.T) {
+	id := bytes.Repeat([]byte{0xcd}, txIDLength)
+	hexID := "0x" + strings.Repeat("cd", txIDLength)
+	runCommandTests(t, fakeWallet, []commandTest{
+		{
+			name: "status of a processed transaction",
+			line: "status tx " + hexID,
+			setup: func(f *clienttest.Fake) {
+				f.AddTransactions(mainAddress, clienttest.Transfer(id, mainAddress, otherAddress, 42, 2, 1, 100))
+				f.AddReceipts(mainAddress, clienttest.Receipt(id, 30, apitypes.TransactionReceipt_TRANSACTION_RESULT_EXECUTED, 1, 1))
+			},
+			want:  []string{"State: Processed", "Transaction id: " + hexID, "Amount: 0.000000000042 SMH (42 Smidge)", "Nonce: 2", "Layer: 30"},
+			calls: []string{"TransactionState", "AccountTransactionsReceipts"},
+		},
+		{
+			name: "status of a pending transaction",
+			line: "status tx " + hexID,
+			setup: func(f *clienttest.Fake) {
+				f.SetTransactionState(id, apitypes.TransactionState_TRANSACTION_STATE_MEMPOOL)
+			},
+			want: []string{"State: Submitted to the network", "doesn't know the content"},
+		},
+		{
+			name:   "status of an invalid id",
+			line:   "status tx 0x12",
+			want:   []string{"invalid transaction id 0x12"},
+			absent: []string{"State:"},
+		},
+		{
+			name: "receipts of an address",
+			line: "state receipts " + otherAddress.String(),
+			setup: func(f *clienttest.Fake) {
+				f.AddReceipts(otherAddress, clienttest.Receipt(id, 30, apitypes.TransactionReceipt_TRANSACTION_RESULT_EXECUTED, 1, 7))
+			},
+			want:  []string{"Transaction receipts of " + otherAddress.String(), "success", "7 Smidge", "1 receipts, 0 failed"},
+			calls: []string{"AccountTransactionsReceipts"},
+		},
+		{
+			name: "receipts past the last page",
+			line: "state receipts " + otherAddress.String() + " --offset 5",
+			setup: func(f *clienttest.Fake) {
+				f.AddReceipts(otherAddress, clienttest.Receipt(id, 30, apitypes.TransactionReceipt_TRANSACTION_RESULT_EXECUTED, 1, 7))
+			},
+			want: []string{"Nothing at offset 5"},
+		},
+		{
+			name:   "broadcast of a multisig transaction",
+			line:   "tx broadcast " + hex.EncodeToString(clienttest.MultisigTx(2, 2)),
+			want:   []string{"Multisig transaction with 2 signatures, threshold 2", "can't be broadcast"},
+			absent: []string{confirmTransactionMsg, "Transaction submitted."},
+			calls:  []string{"DecodeMultisigTransaction"},
+		},
+	})
+}
+
+func TestStateCommands(t *testing.T) {
+	runCommandTests(t, fakeWallet, []commandTest{
+		{
+			name:  "account",
+			line:  "state account " + savingsAddress.String(),
+			want:  []string{"Address: " + savingsAddress.String(), "Balance: 200 Smidge", "Nonce: 0"},
+			calls: []string{"AccountState"},
+		},
+		{
+			name:  "unknown account",
+			line:  "state account " + otherAddress.String(),
+			want:  []string{"Balance: 0 Smidge", "Projected Nonce: 0"},
+			calls: []string{"AccountState"},
+		},
+		{
+			name: "account with a failing node",
+			line: "state account " + savingsAddress.String(),
+			setup: func(f *clienttest.Fake) {
+				f.Errors["AccountState"] = status.Error(codes.Unavailable, "node down")
+			},
+			absent: []string{"Balance"},
+			calls:  []string{"AccountState"},
+		},
+		{
+			name: "global state",
+			line: "state global",
+			setup: func(f *clienttest.Fake) {
+				f.StateHash = &apitypes.GlobalStateHash{RootHash: []byte{0x01, 0x02}, Layer: &apitypes.LayerNumber{Number: 7}}
+			},
+			want:  []string{"Hash: 0x0102", "Layer: 7"},
+			calls: []string{"GlobalStateHash"},
+		},
+		{
+			name: "top accounts",
+			line: "state top 1",
+			setup: func(f *clienttest.Fake) {
+				f.StateHash = &apitypes.GlobalStateHash{RootHash: []byte{0x01}, Layer: &apitypes.LayerNumber{Number: 9}}
+			},
+			want:   []string{mainAddress.String(), "1.5 SMH"},
+			absent: []string{savingsAddress.String()},
+			calls:  []string{"GlobalStateHash", "DebugAllAccounts"},
+		},
+		{
+			name: "top accounts without the global state",
+			line: "state top",
+			setup: func(f *clienttest.Fake) {
+				f.Errors["GlobalStateHash"] = status.Error(codes.Unavailable, "node down")
+			},
+			want:   []string{"get global state failed"},
+			absent: []string{"Rank"},
+		},
+	})
+}
+
+func TestExecutorRecoversPanics(t *testing.T) {
+	f := clienttest.New()
+	r, output := newTestRepl(f)
+	r.commands = []command{{commandStateRoot, "boom", commandStateLeaf, "", func() { panic("boom") }}}
+	r.executor("boom @main")
+	out := output.String()
+	if !strings.Contains(out, "failed because of a bug: boom") || !strings.Contains(out, "report it") {
+		t.Fatalf("expected the panic to be reported, got:\n%s", out)
+	}
+	if r.accountOverride != "" {
+		t.Fatalf("expected the account override to be reset, got %s", r.accountOverride)
+	}
+}
+
+func TestAccountCommandsWithoutWallet(t *testing.T) {
+	f := clienttest.New()
+	fakeWallet(f)
+	r, output := newTestRepl(f)
+	// the wallet is closed after the session listed the account commands
+	f.SetOpen(false)
+	r.executor("account info")
+	out := output.String()
+	if strings.Contains(out, "Balance") || f.Called("CurrentAccount") || f.Called("AccountState") {
+		t.Fatalf("expected no account with the wallet closed, got %v:\n%s", f.Calls(), out)
+	}
+}
+
+func TestStatsCommand(t *testing.T) {
+	runCommandTests(t, func(f *clienttest.Fake) {
+		f.Stats = []common.CallStats{
+			{Method: "/spacemesh.v1.NodeService/Status", Calls: 4, Errors: 1, Total: 40 * time.Millisecond, Max: 25 * time.Millisecond},
+		}
+	}, []commandTest{
+		{
+			name:  "table",
+			line:  "stats",
+			want:  []string{"Method", "Calls", "Errors", "/spacemesh.v1.NodeService/Status", "4", "1", "10ms", "25ms"},
+			calls: []string{"CallStats"},
+		},
+		{
+			name:   "no calls",
+			line:   "stats",
+			setup:  func(f *clienttest.Fake) { f.Stats = nil },
+			want:   []string{"No node API calls"},
+			absent: []string{"Method"},
+		},
+		{
+			name:   "reset",
+			line:   "stats reset",
+			want:   []string{"Call statistics cleared"},
+			absent: []string{"Status"},
+			calls:  []string{"ResetCallStats"},
+		},
+		{
+			name: "usage",
+			line: "stats clear",
+			want: []string{"usage: stats [reset]"},
+		},
+	})
+}
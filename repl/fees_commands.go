@@ -0,0 +1,89 @@
+package repl
+
+import (
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// printFees prints the gas prices suggested from the transactions of recent layers
+func (r *repl) printFees() {
+	estimate, err := r.client.FeeEstimate(r.ctx)
+	if err != nil {
+		log.Error("failed to estimate fees: %v", err)
+		return
+	}
+	if hasFlag(r.args, "--json") {
+		r.printJSON(estimate)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Low:   ", estimate.Low, coinUnitName)
+	fmt.Fprintln(r.out, printPrefix, "Normal:", estimate.Normal, coinUnitName)
+	fmt.Fprintln(r.out, printPrefix, "Fast:  ", estimate.Fast, coinUnitName)
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Computed from %d transactions in layers %d to %d at %s",
+		estimate.Samples, estimate.FirstLayer, estimate.LastLayer, formatTime(estimate.Computed)))
+}
+
+const (
+	// defaultOracleLayers is the number of recent layers scanned by the gas oracle unless --layers is given
+	defaultOracleLayers = 100
+	// minEstimateSamples is the number of transactions below which the fee estimate is considered
+	// too little data and the gas oracle is consulted instead
+	minEstimateSamples = 10
+)
+
+// printGasOracle prints the percentiles and the histogram of the gas prices of the transactions
+// included in recent layers
+func (r *repl) printGasOracle() {
+	layers := uint64(defaultOracleLayers)
+	if s, ok := flagValue(r.args, "--layers"); ok {
+		n, err := strconv.ParseUint(s, 10, 32)
+		if err != nil || n == 0 {
+			fmt.Fprintln(r.out, printPrefix, "invalid number of layers:", s)
+			return
+		}
+		layers = n
+	}
+	report, err := r.client.GasOracle(r.ctx, uint32(layers))
+	if err != nil {
+		log.Error("failed to scan gas prices: %v", err)
+		return
+	}
+	if hasFlag(r.args, "--json") {
+		r.printJSON(report)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "10th percentile:", report.P10, coinUnitName)
+	fmt.Fprintln(r.out, printPrefix, "50th percentile:", report.P50, coinUnitName)
+	fmt.Fprintln(r.out, printPrefix, "90th percentile:", report.P90, coinUnitName)
+	if len(report.Buckets) > 0 {
+		tw := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, printPrefix+"\tGas price\tIncluded")
+		for _, bucket := range report.Buckets {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", printPrefix, bucket.Price, bucket.Count)
+		}
+		tw.Flush()
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Computed from %d transactions in layers %d to %d at %s",
+		report.Samples, report.FirstLayer, report.LastLayer, formatTime(report.Computed)))
+}
+
+// suggestedGasPrice returns the gas price suggested for a new transaction: the normal fee estimate,
+// or the gas oracle median when the estimate is based on too few transactions. It returns false
+// when neither has seen any transaction.
+func (r *repl) suggestedGasPrice() (txSetting, bool) {
+	estimate, err := r.client.FeeEstimate(r.ctx)
+	if err == nil && estimate.Samples >= minEstimateSamples {
+		return txSetting{estimate.Normal, "estimated from recent transactions"}, true
+	}
+	if report, err := r.client.GasOracle(r.ctx, defaultOracleLayers); err == nil && report.Samples > 0 &&
+		(estimate == nil || report.Samples > estimate.Samples) {
+		return txSetting{report.P50, fmt.Sprintf("median of the last %d layers", defaultOracleLayers)}, true
+	}
+	if err == nil && estimate.Samples > 0 {
+		return txSetting{estimate.Normal, "estimated from recent transactions"}, true
+	}
+	return txSetting{}, false
+}
@@ -1,122 +1,320 @@
 package repl
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
-	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
-	"github.com/spacemeshos/go-spacemesh/common/util"
 
+	"github.com/spacemeshos/smrepl/common"
 	"github.com/spacemeshos/smrepl/log"
 )
 
-// printRewards prints all rewards awarded to an account
+// printRewards prints the rewards awarded to an account as printRewardPages does
 func (r *repl) printRewards(address gosmtypes.Address) {
-	// todo: request offset and total from user
-	rewards, total, err := r.client.AccountRewards(address, 0, 0)
-	if err != nil {
-		log.Error("failed to get rewards: %v", err)
-		return
-	}
-
-	fmt.Println(printPrefix, fmt.Sprintf("Total rewards: %d", total))
-	for _, r := range rewards {
-		printReward(r)
-		fmt.Println(printPrefix, "-----")
-	}
+	r.printRewardPages(func(offset, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+		return r.client.AccountRewards(r.ctx, address, offset, maxResults)
+	})
 }
 
 // printAccountRewards prints all rewards awarded to an account
 func (r *repl) printAccountRewards() {
-	addrStr := inputNotBlank(enterAddressMsg)
-	addr := gosmtypes.HexToAddress(addrStr)
+	addr := r.inputAddress(enterAddressMsg)
 	r.printRewards(addr)
 }
 
-// printAccountRewardsStream prints new rewards awarded to an account
+// missedRewards returns the rewards of the layers after a layer, oldest first
+func missedRewards(rewards []*apitypes.Reward, after uint32) []*apitypes.Reward {
+	var res []*apitypes.Reward
+	for _, reward := range rewards {
+		if reward.GetLayer().GetNumber() > after {
+			res = append(res, reward)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].GetLayer().GetNumber() < res[j].GetLayer().GetNumber() })
+	return res
+}
+
+// printAccountRewardsStream prints new rewards awarded to an account in the background. The stream
+// is opened again after transient errors and stops when another node is connected. When it is back
+// the rewards of the layers applied after the last reward seen, or after the layer of the global
+// state when the stream was opened, are queried and printed marked as backfilled.
 func (r *repl) printAccountRewardsStream() {
-	addrStr := inputNotBlank(enterAddressMsg)
-	addr := gosmtypes.HexToAddress(addrStr)
-	streamClient, err := r.client.AccountRewardsStream(addr)
-	if err != nil {
+	addr := r.inputAddress(enterAddressMsg)
+	// last is the layer of the last reward seen and backfilled the last layer printed by a
+	// backfill, whose rewards the reopened stream may send again
+	var last, backfilled uint32
+	hash, err := r.client.GlobalStateHash(r.ctx)
+	known := err == nil
+	if known {
+		last = hash.GetLayer().GetNumber()
+	}
+	handle := func(ctx context.Context, datum *apitypes.AccountData) {
+		if reward := datum.GetReward(); reward != nil && reward.GetLayer().GetNumber() > backfilled {
+			r.printReward(ctx, reward)
+			if layer := reward.GetLayer().GetNumber(); layer > last {
+				last = layer
+			}
+			known = true
+		}
+	}
+	gap := func(ctx context.Context) string {
+		if !known {
+			return "Rewards awarded while it was down are listed by state rewards."
+		}
+		rewards, err := allRewardPages(func(offset, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+			return r.client.AccountRewards(ctx, addr, offset, maxResults)
+		})
+		if err != nil {
+			return fmt.Sprintf("Rewards awarded while it was down can't be queried: %v. They are listed by state rewards.", err)
+		}
+		missed := missedRewards(rewards, last)
+		for _, reward := range missed {
+			fmt.Fprintln(r.out, printPrefix, colorYellow+"[backfilled]"+colorReset)
+			r.printReward(ctx, reward)
+			last, backfilled = reward.GetLayer().GetNumber(), reward.GetLayer().GetNumber()
+		}
+		if len(missed) == 0 {
+			return "No rewards were awarded while it was down."
+		}
+		return fmt.Sprintf("%d rewards awarded while it was down are printed above, marked as backfilled.", len(missed))
+	}
+	if err := r.followAccountData("rewards stream of "+r.addressString(addr), "rewards", addr,
+		apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_REWARD, handle, gap); err != nil {
 		log.Error("failed to get rewards stream for account: %v", err)
 		return
 	}
 
-	fmt.Println(printPrefix, "Listening to new rewards for address: ", addr.String())
-
-	done := make(chan bool)
-	go func() {
-		for {
-			resp, err := streamClient.Recv()
-			if err == io.EOF {
-				// server closed the stream
-				log.Info("api server closed the server-side stream")
-				done <- true
-			} else if err != nil {
-				log.Error("error reading from rewards stream: %v", err)
-				done <- true
-			}
-
-			reward := resp.GetDatum().GetReward()
-			printReward(reward)
-		}
-	}()
+	fmt.Fprintln(r.out, printPrefix, "Listening to new rewards for address: ", r.addressString(addr))
 }
 
-// printAccountRewardsStream prints account state updates
+// printAccountUpdatesStream prints account state updates in the background, with the changes of
+// the balances and nonces since the previous update. The stream is opened again after transient
+// errors and stops when another node is connected.
 func (r *repl) printAccountUpdatesStream() {
-	addrStr := inputNotBlank(enterAddressMsg)
-	address := gosmtypes.HexToAddress(addrStr)
-	streamClient, err := r.client.AccountRewardsStream(address)
-	if err != nil {
+	address := r.inputAddress(enterAddressMsg)
+	// previous is the last update, which handle reads and replaces from the goroutine of the stream
+	var (
+		mu       sync.Mutex
+		previous *apitypes.Account
+	)
+	handle := func(_ context.Context, datum *apitypes.AccountData) {
+		if account := datum.GetAccountWrapper(); account != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			r.printAccountUpdate(previous, account, address)
+			previous = account
+		}
+	}
+	gap := func(context.Context) string {
+		return "The next update has the current state of the account, its changes include the missed updates."
+	}
+	if err := r.followAccountData("updates stream of "+r.addressString(address), "account", address,
+		apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_ACCOUNT, handle, gap); err != nil {
 		log.Error("failed to get updates stream for account: %v", err)
 		return
 	}
 
-	fmt.Println(printPrefix, "Listening for new updates for address: ", address.String())
-
-	done := make(chan bool)
-	go func() {
-		for {
-			resp, err := streamClient.Recv()
-			if err == io.EOF {
-				// server closed the stream
-				log.Info("api server closed the server-side stream")
-				done <- true
-			} else if err != nil {
-				log.Error("error reading from stream: %v", err)
-				done <- true
-			}
+	fmt.Fprintln(r.out, printPrefix, "Listening for new updates for address: ", r.addressString(address))
+}
 
-			account := resp.GetDatum().GetAccountWrapper()
-			printAccount(account, address)
+// printAccountUpdate prints the state of an account with the changes since a previous state, which
+// is nil for the first update
+func (r *repl) printAccountUpdate(previous, account *apitypes.Account, address gosmtypes.Address) {
+	fmt.Fprintln(r.out, printPrefix, "Account update:", r.addressString(address))
+	for _, state := range []struct {
+		name          string
+		before, after *apitypes.AccountState
+	}{
+		{"", previous.GetStateCurrent(), account.GetStateCurrent()},
+		{"Projected ", previous.GetStateProjected(), account.GetStateProjected()},
+	} {
+		balance, nonce := state.after.GetBalance().GetValue(), state.after.GetCounter()
+		balanceLine := fmt.Sprintf("%sBalance: %s", state.name, common.FormatAmount(balance))
+		nonceLine := fmt.Sprintf("%sNonce: %d", state.name, nonce)
+		if previous != nil {
+			balanceLine += " (" + amountChange(state.before.GetBalance().GetValue(), balance) + ")"
+			nonceLine += fmt.Sprintf(" (%+d)", int64(nonce)-int64(state.before.GetCounter()))
 		}
-	}()
+		fmt.Fprintln(r.out, printPrefix, balanceLine)
+		fmt.Fprintln(r.out, printPrefix, nonceLine)
+	}
+}
+
+// amountChange returns the change from one amount to another, e.g. +1.5 SMH
+func amountChange(before, after uint64) string {
+	if after < before {
+		return "-" + common.FormatAmount(before-after)
+	}
+	return "+" + common.FormatAmount(after-before)
 }
 
-// printGlobalState prints the current global state
+// stateHistoryShown is the number of observations global --history prints without --last
+const stateHistoryShown = 20
+
+// printGlobalState prints the current global state and records it in the session's history.
+// --history prints the recent history instead and --clear-history clears it.
 func (r *repl) printGlobalState() {
-	resp, err := r.client.GlobalStateHash()
+	if hasFlag(r.args, "--clear-history") {
+		r.stateHistory.Clear()
+		fmt.Fprintln(r.out, printPrefix, "Global state history cleared.")
+		return
+	}
+	if hasFlag(r.args, "--history") {
+		r.printStateHistory()
+		return
+	}
+	resp, err := r.client.GlobalStateHash(r.ctx)
 	if err != nil {
 		log.Error("failed to get global state: %v", err)
 		return
 	}
 
-	fmt.Println(printPrefix, "Hash:", "0x"+hex.EncodeToString(resp.RootHash))
-	fmt.Println(printPrefix, "Layer:", resp.Layer.Number)
+	fmt.Fprintln(r.out, printPrefix, "Hash:", "0x"+hex.EncodeToString(resp.RootHash))
+	fmt.Fprintln(r.out, printPrefix, "Layer:", resp.Layer.Number)
+	r.printStateChange(r.stateHistory.Record(resp.GetLayer().GetNumber(), resp.GetRootHash(), time.Now()))
 }
 
-// printAccountState prints an account's global state
+// printStateChange warns when another global state hash was seen for the layer of an observation
+// before. o may be nil.
+func (r *repl) printStateChange(o *common.StateObservation) {
+	if o != nil && o.Changed() {
+		fmt.Fprintln(r.out, printPrefix, colorRed+fmt.Sprintf("WARNING: the global state hash of layer %d changed from 0x%s to 0x%s since it was last seen.",
+			o.Layer, hex.EncodeToString(o.Previous), hex.EncodeToString(o.Hash))+colorReset)
+	}
+}
+
+// printStateHistory prints the last --last global state hashes seen this session, oldest first,
+// with the time between them, flagging layers whose hash changed
+func (r *repl) printStateHistory() {
+	n := stateHistoryShown
+	if s, ok := flagValue(r.args, "--last"); ok {
+		var err error
+		if n, err = strconv.Atoi(s); err != nil || n < 1 {
+			fmt.Fprintln(r.out, printPrefix, "invalid --last value:", s)
+			return
+		}
+	}
+	entries := r.stateHistory.Recent(n)
+	if len(entries) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "No global state seen this session, run state global or state stream-global.")
+		return
+	}
+	for i, o := range entries {
+		line := fmt.Sprintf("%s  layer %-6d 0x%s", o.Time.Format(layerTimeFormat), o.Layer, hex.EncodeToString(o.Hash))
+		if i > 0 {
+			line += fmt.Sprintf("  +%s", o.Time.Sub(entries[i-1].Time).Round(time.Second))
+		}
+		if o.Changed() {
+			line += colorRed + fmt.Sprintf("  CHANGED from 0x%s", hex.EncodeToString(o.Previous)) + colorReset
+		}
+		fmt.Fprintln(r.out, printPrefix, line)
+	}
+	summary := fmt.Sprintf("%d of %d hashes seen this session shown", len(entries), len(r.stateHistory.Recent(0)))
+	if changes := r.stateHistory.Changes(); changes > 0 {
+		summary += colorRed + fmt.Sprintf(", %d layers changed hash", changes) + colorReset
+	}
+	fmt.Fprintln(r.out, printPrefix, summary+".")
+}
+
+// printAccountState prints an account's global state, given as an argument or prompted for. With
+// --at-layer <n> the balance and nonce at the end of layer n are printed instead.
 func (r *repl) printAccountState() {
-	addressStr := inputNotBlank(enterAddressMsg)
-	address := gosmtypes.BytesToAddress(util.FromHex(addressStr))
-	account, err := r.client.AccountState(address)
+	var address gosmtypes.Address
+	if args := positionalArgs(r.args, "--at-layer"); len(args) == 1 {
+		var err error
+		if address, err = r.resolveAddress(args[0]); err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+	} else {
+		address = r.inputAddress(enterAddressMsg)
+	}
+	account, err := r.client.AccountState(r.ctx, address)
 	if err != nil {
 		log.Error("failed to get account info: %v", err)
 		return
 	}
 
-	printAccount(account, address)
+	if s, ok := flagValue(r.args, "--at-layer"); ok {
+		layer, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, "invalid layer, expected a number:", s)
+			return
+		}
+		r.printAccountStateAt(account, address, uint32(layer))
+		return
+	}
+	r.printAccount(account, address)
+}
+
+// compareState compares the global state hash of the connected node with the hashes of other
+// nodes, fetched at once over temporary connections, and prints whether each pair matches. Hashes
+// reported at different layers are skipped, since a lagging node can't be compared.
+func (r *repl) compareState() {
+	servers := positionalArgs(r.args)
+	if len(servers) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "usage: state compare <host:port|unix:///path> [<host:port|unix:///path>...]")
+		return
+	}
+	for _, server := range servers {
+		if err := common.ValidateServer(server); err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+	}
+
+	resp, err := r.client.GlobalStateHash(r.ctx)
+	if err != nil {
+		r.printNodeError(common.CallError("get global state", err))
+		return
+	}
+	local := common.StateHash{Server: r.client.ServerInfo(), Layer: resp.GetLayer().GetNumber(), Hash: resp.RootHash}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%s: 0x%s at layer %d", local.Server, hex.EncodeToString(local.Hash), local.Layer))
+
+	others := make([]common.StateHash, len(servers))
+	errs := make([]error, len(servers))
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func(i int, server string) {
+			defer wg.Done()
+			others[i].Server = server
+			resp, err := r.client.ServerStateHash(r.ctx, server)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			others[i].Layer, others[i].Hash = resp.GetLayer().GetNumber(), resp.RootHash
+		}(i, server)
+	}
+	wg.Wait()
+
+	mismatches := 0
+	for i, other := range others {
+		if errs[i] != nil {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%s: UNREACHABLE: %v", other.Server, errs[i]))
+			continue
+		}
+		switch common.CompareStateHash(local, other) {
+		case common.StateMatch:
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%s: MATCH at layer %d", other.Server, other.Layer))
+		case common.StateMismatch:
+			mismatches++
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%s%s: MISMATCH at layer %d: 0x%s%s", colorRed, other.Server,
+				other.Layer, hex.EncodeToString(other.Hash), colorReset))
+		case common.StateLayersDiffer:
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%s: SKIPPED, at layer %d instead of %d", other.Server, other.Layer, local.Layer))
+		}
+	}
+	if mismatches > 0 {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%s%d of %d nodes disagree with %s, one of them may be forked or corrupted.%s",
+			colorRed, mismatches, len(others), local.Server, colorReset))
+	}
 }
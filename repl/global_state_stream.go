@@ -0,0 +1,125 @@
+package repl
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// globalStateEvent is a global state update in the --json form of state stream-global
+type globalStateEvent struct {
+	Layer    uint32  `json:"layer"`
+	RootHash string  `json:"root_hash"`
+	Time     string  `json:"time"`
+	Since    float64 `json:"seconds_since_previous,omitempty"`
+	// ChangedFrom is the hash seen for the layer earlier this session, set only when it differs
+	ChangedFrom string `json:"changed_from,omitempty"`
+}
+
+// streamGlobalStateHashes sends the global state hashes of the node's global state stream to
+// hashes until stop is closed. The stream is opened again after transient errors. The error which
+// ends streaming is sent to failed.
+func (r *repl) streamGlobalStateHashes(ctx context.Context, hashes chan<- *apitypes.GlobalStateHash, failed chan<- error, stop <-chan struct{}) {
+	var last uint32
+	open := func() (streamReceiver, error) {
+		stream, err := r.client.GlobalStateStream(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			resp, err := stream.Recv()
+			if err != nil || resp.GetDatum().GetGlobalState() == nil {
+				return err
+			}
+			last = resp.GetDatum().GetGlobalState().GetLayer().GetNumber()
+			select {
+			case hashes <- resp.GetDatum().GetGlobalState():
+				return nil
+			case <-stop:
+				return errStreamStopped
+			}
+		}, nil
+	}
+	gap := func() string {
+		return fmt.Sprintf("Resuming after layer %d, the states applied while it was down aren't resent.", last)
+	}
+	if err := r.followStream("global state", r.streamBackoff(), open, gap, stop); err != errStreamStopped {
+		select {
+		case failed <- err:
+		case <-stop:
+		}
+	}
+}
+
+// streamGlobalState prints the layer and state root hash of every global state update the node
+// streams, with the time since the previous update, until Enter or Ctrl+C is pressed. With --json
+// each update is printed as an object on its own line. The updates are recorded in the session's
+// global state history.
+func (r *repl) streamGlobalState() {
+	asJSON := hasFlag(r.args, "--json")
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	enter := r.enterPressed()
+	hashes := make(chan *apitypes.GlobalStateHash)
+	failed := make(chan error)
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.streamGlobalStateHashes(r.ctx, hashes, failed, stop)
+
+	if !asJSON {
+		fmt.Fprintln(r.out, printPrefix, "Streaming the global state, press Enter or Ctrl+C to stop...")
+	}
+	var previous time.Time
+	count := 0
+	for {
+		select {
+		case hash := <-hashes:
+			count++
+			now := time.Now()
+			o := r.stateHistory.Record(hash.GetLayer().GetNumber(), hash.GetRootHash(), now)
+			event := globalStateEvent{
+				Layer:    hash.GetLayer().GetNumber(),
+				RootHash: "0x" + hex.EncodeToString(hash.GetRootHash()),
+				Time:     now.UTC().Format(time.RFC3339),
+			}
+			if !previous.IsZero() {
+				event.Since = now.Sub(previous).Seconds()
+			}
+			previous = now
+			if o != nil && o.Changed() {
+				event.ChangedFrom = "0x" + hex.EncodeToString(o.Previous)
+			}
+			if asJSON {
+				r.printJSONLine(event)
+				continue
+			}
+			line := fmt.Sprintf("%s  layer %-6d %s", now.Format(layerTimeFormat), event.Layer, event.RootHash)
+			if event.Since > 0 {
+				line += fmt.Sprintf("  +%s", time.Duration(event.Since*float64(time.Second)).Round(time.Second))
+			}
+			fmt.Fprintln(r.out, printPrefix, line)
+			r.printStateChange(o)
+			continue
+		case err := <-failed:
+			r.printNodeError(common.CallError("stream global state", err))
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-interrupt:
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-enter:
+		}
+		break
+	}
+	if !asJSON {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Stopped streaming after %d global state updates.", count))
+	}
+}
@@ -0,0 +1,173 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// streamAccountUpdates sends the account updates of an address to updates until stop is closed. The
+// stream is opened again after transient errors. The error which ends streaming is sent to failed.
+func (r *repl) streamAccountUpdates(ctx context.Context, address gosmtypes.Address, updates chan<- *apitypes.Account, failed chan<- error, stop <-chan struct{}) {
+	open := func() (streamReceiver, error) {
+		stream, err := r.client.AccountDataStream(ctx, address, apitypes.AccountDataFlag_ACCOUNT_DATA_FLAG_ACCOUNT)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			resp, err := stream.Recv()
+			account := resp.GetDatum().GetAccountWrapper()
+			if err != nil || account == nil {
+				return err
+			}
+			select {
+			case updates <- account:
+				return nil
+			case <-stop:
+				return errStreamStopped
+			}
+		}, nil
+	}
+	gap := func() string {
+		return "Transactions received while it was down are reported with the next balance change."
+	}
+	if err := r.followStream("account", r.streamBackoff(), open, gap, stop); err != errStreamStopped {
+		select {
+		case failed <- err:
+		case <-stop:
+		}
+	}
+}
+
+// currentBalance returns the current balance of an account update
+func currentBalance(account *apitypes.Account) uint64 {
+	if account.StateCurrent == nil || account.StateCurrent.Balance == nil {
+		return 0
+	}
+	return account.StateCurrent.Balance.Value
+}
+
+// runNotifyHook runs the configured notify hook with args: the amount, sender and id of an incoming
+// transaction, or the balance, address and alert of a watched balance
+func (r *repl) runNotifyHook(args ...string) {
+	hook := r.config().NotifyHook
+	if hook == "" {
+		return
+	}
+	cmd := exec.Command(hook, args...)
+	if err := cmd.Start(); err != nil {
+		log.Error("failed to run the notify hook: %v", err)
+		return
+	}
+	go cmd.Wait()
+}
+
+// reportIncoming prints the incoming transactions of an account which aren't known yet and adds
+// them to known. It returns the number of incoming transactions found.
+func (r *repl) reportIncoming(address gosmtypes.Address, known map[string]bool) int {
+	txs, err := allMeshTransactions(r.ctx, r.client, address)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "Can't get the account transactions:", err)
+		return 0
+	}
+	found := 0
+	for _, tx := range txs {
+		if known[string(tx.Id.Id)] {
+			continue
+		}
+		known[string(tx.Id.Id)] = true
+		ct := tx.GetCoinTransfer()
+		if ct == nil || !bytes.Equal(ct.Receiver.Address, address.Bytes()) {
+			continue
+		}
+		amount := uint64(0)
+		if tx.Amount != nil {
+			amount = tx.Amount.Value
+		}
+		sender := r.addressString(gosmtypes.BytesToAddress(tx.Sender.Address))
+		fmt.Fprint(r.out, "\a")
+		fmt.Fprintln(r.out, printPrefix, formatTime(time.Now()), "Received", common.FormatAmount(amount), "from", sender,
+			fmt.Sprintf("(transaction 0x%x)", tx.Id.Id))
+		r.runNotifyHook(strconv.FormatUint(amount, 10), sender, fmt.Sprintf("0x%x", tx.Id.Id))
+		found++
+	}
+	return found
+}
+
+// watchIncoming prints the incoming transactions and balance increases of the current account until
+// Enter or Ctrl+C is pressed, then the total received
+func (r *repl) watchIncoming() {
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	address := acc.Address()
+	state, err := r.client.AccountState(r.ctx, address)
+	if err != nil {
+		log.Error("failed to get account info: %v", err)
+		return
+	}
+	balance := currentBalance(state)
+	txs, err := allMeshTransactions(r.ctx, r.client, address)
+	if err != nil {
+		log.Error("failed to get account transactions: %v", err)
+		return
+	}
+	known := make(map[string]bool)
+	for _, tx := range txs {
+		known[string(tx.Id.Id)] = true
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	enter := r.enterPressed()
+	updates := make(chan *apitypes.Account)
+	failed := make(chan error)
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.streamAccountUpdates(r.ctx, address, updates, failed, stop)
+
+	fmt.Fprintln(r.out, printPrefix, "Watching incoming transactions to", r.addressString(address)+", press Enter or Ctrl+C to stop...")
+	received, count := uint64(0), 0
+	for {
+		select {
+		case account := <-updates:
+			newBalance := currentBalance(account)
+			if newBalance > balance {
+				increase := newBalance - balance
+				received += increase
+				found := r.reportIncoming(address, known)
+				count += found
+				if found == 0 {
+					fmt.Fprint(r.out, "\a")
+					fmt.Fprintln(r.out, printPrefix, formatTime(time.Now()), "Balance increased by", common.FormatAmount(increase))
+				}
+			}
+			balance = newBalance
+			continue
+		case err := <-failed:
+			r.printNodeError(common.CallError("watch the account", err))
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-interrupt:
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-enter:
+		}
+		break
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Stopped watching. Received %s in %d incoming transactions.", common.FormatAmount(received), count))
+}
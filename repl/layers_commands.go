@@ -0,0 +1,114 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// streamLayerUpdates sends the layers of the node's layer stream to layers until stop is closed.
+// The stream is opened again after transient errors. The error which ends streaming, e.g. because
+// the node doesn't implement the stream, is sent to failed.
+func (r *repl) streamLayerUpdates(ctx context.Context, layers chan<- *apitypes.Layer, failed chan<- error, stop <-chan struct{}) {
+	var last uint32
+	open := func() (streamReceiver, error) {
+		stream, err := r.client.LayerStream(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			resp, err := stream.Recv()
+			if err != nil || resp.GetLayer() == nil {
+				return err
+			}
+			last = resp.GetLayer().GetNumber().GetNumber()
+			select {
+			case layers <- resp.GetLayer():
+				return nil
+			case <-stop:
+				return errStreamStopped
+			}
+		}, nil
+	}
+	gap := func() string {
+		return fmt.Sprintf("Resuming after layer %d, the node can't resend the updates streamed while it was down.", last)
+	}
+	if err := r.followStream("layer", r.streamBackoff(), open, gap, stop); err != errStreamStopped {
+		select {
+		case failed <- err:
+		case <-stop:
+		}
+	}
+}
+
+// layerStatusName returns the status of a layer in lower case without the enum prefix, e.g. approved
+func layerStatusName(s apitypes.Layer_LayerStatus) string {
+	return strings.ToLower(strings.TrimPrefix(s.String(), "LAYER_STATUS_"))
+}
+
+// layerTxCount returns the number of transactions in the blocks of a layer. A transaction included
+// in more than one block is counted once.
+func layerTxCount(layer *apitypes.Layer) int {
+	seen := make(map[string]bool)
+	for _, block := range layer.Blocks {
+		for _, tx := range block.Transactions {
+			seen[string(tx.GetId().GetId())] = true
+		}
+	}
+	return len(seen)
+}
+
+// streamLayers prints a line for every layer the node streams until Enter or Ctrl+C is pressed
+func (r *repl) streamLayers() {
+	info, err := r.client.GetMeshInfo(r.ctx)
+	if err != nil {
+		log.Error("failed to get mesh info: %v", err)
+		return
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	enter := r.enterPressed()
+	layers := make(chan *apitypes.Layer)
+	failed := make(chan error)
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.streamLayerUpdates(r.ctx, layers, failed, stop)
+
+	fmt.Fprintln(r.out, printPrefix, "Streaming layers, press Enter or Ctrl+C to stop...")
+	count := 0
+	for {
+		select {
+		case layer := <-layers:
+			count++
+			r.printStreamedLayer(info, layer)
+			continue
+		case err := <-failed:
+			r.printNodeError(common.CallError("stream layers", err))
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-interrupt:
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-enter:
+		}
+		break
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Stopped streaming after %d layer updates.", count))
+}
+
+// printStreamedLayer prints the number, status, transaction count and start time of a layer
+func (r *repl) printStreamedLayer(info *common.NetInfo, layer *apitypes.Layer) {
+	number := layer.GetNumber().GetNumber()
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%s  layer %-6d %-9s %d blocks, %d transactions",
+		info.LayerTime(number).Local().Format(layerTimeFormat), number, layerStatusName(layer.Status),
+		len(layer.Blocks), layerTxCount(layer)))
+}
@@ -2,29 +2,140 @@ package repl
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
-
+	"github.com/spacemeshos/smrepl/common"
 	"github.com/spacemeshos/smrepl/log"
 )
 
 func (r *repl) printMeshInfo() {
-	info, err := r.client.GetMeshInfo()
+	info, err := r.client.GetMeshInfo(r.ctx)
 	if err != nil {
 		log.Error("failed to get mesh info: %v", err)
 		return
 	}
+	r.printNetInfo(info)
+}
 
+// printNetInfo prints the mesh info of the network
+func (r *repl) printNetInfo(info *common.NetInfo) {
 	localGenesisTime := time.Unix(int64(info.GenesisTime), 0)
 
-	fmt.Println(printPrefix, "Network id:", info.NetId)
-	fmt.Println(printPrefix, "Max transactions per second:", info.MaxTxsPerSec)
-	fmt.Println(printPrefix, "Layers per epoch:", info.LayerPerEpoch)
-	fmt.Println(printPrefix, fmt.Sprintf("Layer duration: %d seconds", info.LayerDuration))
-	fmt.Println(printPrefix, "Current layer:", info.CurrentLayer)
-	fmt.Println(printPrefix, "Current epoch:", info.CurrentEpoch)
-	fmt.Println(printPrefix, "Genesis time:", localGenesisTime.Local().String())
+	fmt.Fprintln(r.out, printPrefix, "Network id:", info.NetId)
+	fmt.Fprintln(r.out, printPrefix, "Max transactions per second:", info.MaxTxsPerSec)
+	fmt.Fprintln(r.out, printPrefix, "Layers per epoch:", info.LayerPerEpoch)
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Layer duration: %d seconds", info.LayerDuration))
+	fmt.Fprintln(r.out, printPrefix, "Current layer:", info.CurrentLayer)
+	fmt.Fprintln(r.out, printPrefix, "Current epoch:", info.CurrentEpoch)
+	fmt.Fprintln(r.out, printPrefix, "Genesis time:", localGenesisTime.Local().String())
+	fmt.Fprintln(r.out, printPrefix, "Summary:", info.EpochSummary(time.Now()))
+}
+
+// genesisInfo is the --json form of status genesis
+type genesisInfo struct {
+	NetID          uint64 `json:"net_id"`
+	GenesisTime    string `json:"genesis_time"`
+	GenesisUnix    uint64 `json:"genesis_unix"`
+	LayerDuration  uint64 `json:"layer_duration_seconds"`
+	LayersPerEpoch uint64 `json:"layers_per_epoch"`
+	MaxTxsPerSec   uint64 `json:"max_txs_per_second"`
+}
+
+// printGenesis prints the network parameters, which are fetched once per session unless --refresh
+// is given
+func (r *repl) printGenesis() {
+	params, err := r.client.NetworkParams(r.ctx, hasFlag(r.args, "--refresh"))
+	if err != nil {
+		r.printNodeError(common.CallError("get network parameters", err))
+		return
+	}
+	genesis := time.Unix(int64(params.GenesisTime), 0)
+	if hasFlag(r.args, "--json") {
+		r.printJSON(genesisInfo{
+			NetID:          params.NetId,
+			GenesisTime:    genesis.UTC().Format(time.RFC3339),
+			GenesisUnix:    params.GenesisTime,
+			LayerDuration:  params.LayerDuration,
+			LayersPerEpoch: params.LayerPerEpoch,
+			MaxTxsPerSec:   params.MaxTxsPerSec,
+		})
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Network id:", params.NetId)
+	fmt.Fprintln(r.out, printPrefix, "Genesis time (UTC):", genesis.UTC().Format(layerTimeFormat))
+	fmt.Fprintln(r.out, printPrefix, "Genesis time (local):", genesis.Local().Format(layerTimeFormat))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Layer duration: %d seconds", params.LayerDuration))
+	fmt.Fprintln(r.out, printPrefix, "Layers per epoch:", params.LayerPerEpoch)
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Epoch duration: %v", time.Duration(params.LayerDuration*params.LayerPerEpoch)*time.Second))
+	fmt.Fprintln(r.out, printPrefix, "Max transactions per second:", params.MaxTxsPerSec)
+}
+
+// refreshNode drops the cached account states, mesh info and node status and fetches the cached
+// network parameters and the node status again, which updates the prompt. A change of network is
+// reported with a warning.
+func (r *repl) refreshNode() {
+	r.client.InvalidateCache()
+	params, err := r.client.NetworkParams(r.ctx, true)
+	if err != nil {
+		r.printNodeError(common.CallError("get network parameters", err))
+		return
+	}
+	nodeStatus, err := r.client.NodeStatus(r.ctx)
+	if err != nil {
+		r.printNodeError(common.CallError("get node status", err))
+		return
+	}
+	r.setNodeStatus(nodeStatus.IsSynced, nodeStatus.ConnectedPeers)
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Network id: %d, genesis time: %s", params.NetId,
+		time.Unix(int64(params.GenesisTime), 0).Local().Format(layerTimeFormat)))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Synced: %v, top layer: %d", nodeStatus.IsSynced, nodeStatus.TopLayer.GetNumber()))
+	fmt.Fprintln(r.out, printPrefix, peersLine(nodeStatus.ConnectedPeers))
+}
+
+// layerTimeFormat shows layer boundaries to the second, since layers are short
+const layerTimeFormat = "2006-01-02 15:04:05"
+
+// layerSummary returns a line with the current layer, its epoch and the layers left until the next
+// epoch
+func layerSummary(info *common.NetInfo) string {
+	s := info.LayerStatus(info.CurrentLayer)
+	return fmt.Sprintf("Layer %d of epoch %d, %d layers until epoch %d at %s", s.Layer, s.Epoch, s.LayersLeft, s.Epoch+1, s.NextEpoch.Local().Format(layerTimeFormat))
+}
+
+// printLayerStatus prints the current layer and epoch, the time of the layer boundaries computed
+// from the genesis time and the layer duration, and the layers the node reports as verified and
+// synced
+func (r *repl) printLayerStatus() {
+	info, err := r.client.GetMeshInfo(r.ctx)
+	if err != nil {
+		log.Error("failed to get mesh info: %v", err)
+		return
+	}
+	s := info.LayerStatus(info.CurrentLayer)
+	now := time.Now()
+	fmt.Fprintln(r.out, printPrefix, "Current layer:", s.Layer)
+	fmt.Fprintln(r.out, printPrefix, "Epoch:", s.Epoch)
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Layers until epoch %d: %d (at %s)", s.Epoch+1, s.LayersLeft, s.NextEpoch.Local().Format(layerTimeFormat)))
+	fmt.Fprintln(r.out, printPrefix, "Current layer started:", s.Start.Local().Format(layerTimeFormat))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Next layer starts: %s (in %s)", s.End.Local().Format(layerTimeFormat), common.HumanDuration(s.End.Sub(now))))
+
+	status, err := r.client.NodeStatus(r.ctx)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "Can't get the verified layer from the node:", err)
+		return
+	}
+	if status.TopLayer != nil {
+		fmt.Fprintln(r.out, printPrefix, "Latest layer:", status.TopLayer.Number)
+	}
+	if status.VerifiedLayer != nil {
+		fmt.Fprintln(r.out, printPrefix, "Verified layer:", status.VerifiedLayer.Number)
+	}
+	if status.SyncedLayer != nil {
+		fmt.Fprintln(r.out, printPrefix, "Synced layer:", status.SyncedLayer.Number)
+	}
 }
 
 // printCurrAccountMeshTransactions displays mesh transactions for the current account
@@ -34,29 +145,213 @@ func (r *repl) printCurrAccountMeshTransactions() {
 		log.Error("failed to get account", err)
 		return
 	}
+	if path, ok := flagValue(r.args, "--json"); ok {
+		r.exportAccountTransactions(acc.Address(), path, false)
+		return
+	}
+	if path, ok := flagValue(r.args, "--csv"); ok {
+		r.exportAccountTransactions(acc.Address(), path, true)
+		return
+	}
 	r.printAccountMeshTransactions(acc.Address())
 }
 
-// printAccountMeshTransactions displays mesh transactions for an account
+// txFilterValueFlags are the flags of transaction listings which take a value
+var txFilterValueFlags = []string{"--from-layer", "--to-layer", "--min-amount", "--sort"}
+
+// printMeshTransactions displays the mesh transactions of any address, given as an address, a
+// contact or an account alias, or prompted for
 func (r *repl) printMeshTransactions() {
-	addrStr := inputNotBlank(enterAddressMsg)
-	addr := gosmtypes.HexToAddress(addrStr)
+	args := positionalArgs(r.args, txFilterValueFlags...)
+	if len(args) > 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: state account-txs [address|contact] [--in] [--out] [--from-layer <n>] [--to-layer <n>] [--min-amount <amount>] [--sort layer|amount|nonce] [--desc]")
+		return
+	}
+	var addr gosmtypes.Address
+	if len(args) == 1 {
+		var err error
+		if addr, err = r.resolveAddress(args[0]); err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+	} else {
+		addr = r.inputAddress(enterAddressMsg)
+	}
+	fmt.Fprintln(r.out, printPrefix, "Mesh transactions of", r.addressString(addr))
 	r.printAccountMeshTransactions(addr)
 }
 
-// Print transaction for an account from mesh data
-func (r *repl) printAccountMeshTransactions(address gosmtypes.Address) {
+// layerRangeArgs returns the inclusive layer range given with --from-layer and --to-layer. A bound
+// which isn't given is nil.
+func layerRangeArgs(args []string) (from, to *uint32, err error) {
+	for _, f := range []struct {
+		flag  string
+		layer **uint32
+	}{{"--from-layer", &from}, {"--to-layer", &to}} {
+		if s, ok := flagValue(args, f.flag); ok {
+			n, err := strconv.ParseUint(s, 10, 32)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid %s value %s", f.flag, s)
+			}
+			layer := uint32(n)
+			*f.layer = &layer
+		}
+	}
+	return from, to, nil
+}
+
+// txFilterArgs returns the transaction filter given with --in, --out, --from-layer, --to-layer and
+// --min-amount
+func txFilterArgs(args []string) (common.TxFilter, error) {
+	filter := common.TxFilter{In: hasFlag(args, "--in"), Out: hasFlag(args, "--out")}
+	var err error
+	if filter.FromLayer, filter.ToLayer, err = layerRangeArgs(args); err != nil {
+		return filter, err
+	}
+	if s, ok := flagValue(args, "--min-amount"); ok {
+		amount, err := common.ParseAmount(s)
+		if err != nil {
+			return filter, err
+		}
+		filter.MinAmount = amount
+	}
+	return filter, nil
+}
 
-	// todo: request offset and total from user
-	txs, total, err := r.client.GetMeshTransactions(address, 0, 1000)
+// Print transaction for an account from mesh data. All pages are fetched, then the filter and sort
+// flags are applied to the results.
+func (r *repl) printAccountMeshTransactions(address gosmtypes.Address) {
+	filter, err := txFilterArgs(r.args)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	order, err := sortArgs(r.args, sortByLayer, sortByAmount, sortByNonce)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	txs, err := allMeshTransactions(r.ctx, r.client, address)
 	if err != nil {
 		log.Error("failed to print transactions: %v", err)
 		return
 	}
+	receipts, err := allReceipts(r.ctx, r.client, address)
+	if err != nil && (filter.FromLayer != nil || filter.ToLayer != nil) {
+		fmt.Fprintln(r.out, printPrefix, "Can't get the transaction receipts, transactions without a known layer don't match:", err)
+	}
+	info, err := r.client.GetMeshInfo(r.ctx)
+	if err != nil {
+		info = nil
+	}
 
-	fmt.Println(printPrefix, fmt.Sprintf("Total mesh transactions: %d", total))
+	byID := make(map[string]*apitypes.Transaction, len(txs))
 	for _, tx := range txs {
-		printTransaction(tx)
-		fmt.Println(printPrefix, "-----")
+		byID[fmt.Sprintf("0x%x", tx.Id.Id)] = tx
+	}
+	var matched []common.TxRecord
+	for _, record := range accountTxRecords(address, txs, receipts, info) {
+		if filter.Match(&record) {
+			matched = append(matched, record)
+		}
+	}
+	key := func(i int) (uint64, bool) {
+		switch order.key {
+		case sortByAmount:
+			return matched[i].Amount, true
+		case sortByNonce:
+			return matched[i].Nonce, true
+		}
+		if matched[i].Layer == nil {
+			return 0, false
+		}
+		return uint64(*matched[i].Layer), true
+	}
+	for _, i := range sortedOrder(len(matched), key, order.desc) {
+		record := matched[i]
+		tx := byID[record.ID]
+		r.printTransaction(tx, transactionFee(tx, receipts[string(tx.Id.Id)]))
+		fmt.Fprintln(r.out, printPrefix, "Direction:", record.Direction)
+		if record.Layer != nil {
+			fmt.Fprintln(r.out, printPrefix, "Layer:", *record.Layer)
+		}
+		fmt.Fprintln(r.out, printPrefix, "-----")
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%d of %d fetched mesh transactions match", len(matched), len(txs)))
+}
+
+// allActivations pages through the activations of a coinbase address until the node returns an
+// empty page
+func (r *repl) allActivations(address gosmtypes.Address) ([]*apitypes.Activation, error) {
+	var res []*apitypes.Activation
+	for offset := uint32(0); ; offset += exportPageSize {
+		page, _, err := r.client.GetMeshActivations(r.ctx, address, offset, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			return res, nil
+		}
+		res = append(res, page...)
+	}
+}
+
+// printActivations prints the activations whose coinbase is an address, or the current account,
+// with their target epoch, smesher, commitment size and previous activation, then their number and
+// total committed space
+func (r *repl) printActivations() {
+	var address gosmtypes.Address
+	switch len(r.args) {
+	case 0:
+		acc, err := r.getCurrent()
+		if err != nil {
+			log.Error("failed to get account", err)
+			return
+		}
+		address = acc.Address()
+	case 1:
+		var err error
+		if address, err = r.resolveAddress(r.args[0]); err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+	default:
+		fmt.Fprintln(r.out, printPrefix, "usage: state activations [address|contact]")
+		return
+	}
+	activations, err := r.allActivations(address)
+	if err != nil {
+		log.Error("failed to get activations: %v", err)
+		return
+	}
+	if len(activations) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "No activations have", r.addressString(address), "as coinbase.")
+		return
+	}
+	info, err := r.client.GetMeshInfo(r.ctx)
+	if err != nil {
+		info = nil
+	}
+
+	var committed uint64
+	for _, a := range activations {
+		committed += a.CommitmentSize
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Activation id: 0x%x", a.GetId().GetId()))
+		layer := a.GetLayer().GetNumber()
+		if info != nil && info.LayerPerEpoch != 0 {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Target epoch: %d (published in layer %d)", uint64(layer)/info.LayerPerEpoch+1, layer))
+		} else {
+			fmt.Fprintln(r.out, printPrefix, "Published in layer:", layer)
+		}
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Smesher id: 0x%x", a.GetSmesherId().GetId()))
+		fmt.Fprintln(r.out, printPrefix, "Commitment size:", a.CommitmentSize, "bytes")
+		if prev := a.GetPrevAtx().GetId(); len(prev) > 0 {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Previous activation: 0x%x", prev))
+		} else {
+			fmt.Fprintln(r.out, printPrefix, "Previous activation: none")
+		}
+		fmt.Fprintln(r.out, printPrefix, "-----")
 	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%d activations of %s, %d bytes committed in total",
+		len(activations), r.addressString(address), committed))
 }
@@ -2,23 +2,71 @@ package repl
 
 const (
 	initialTransferMsg         = "Transfer coins from local account to another account."
-	destAddressMsg             = "Enter destination address: "
+	destAddressMsg             = "Enter destination address, contact or account alias: "
 	enterAddressMsg            = "Enter an address: "
 	txIdMsg                    = "Enter transaction id: "
-	smesherIdMsg               = "Enter Smesher id: "
-	amountToTransferMsg        = "Enter amount to transfer in Smidge: "
+	smesherIdMsg               = "Enter the smesher id in hex or base64, or a saved smesher name: "
+	amountToTransferMsg        = "Enter amount to transfer in Smidge or max for the whole balance: "
 	confirmTransactionMsg      = "Confirm transaction (y/n): "
-	confirmDeleteDataMsg       = "Delete smeshing smeshing data files (y/n)"
+	confirmSignTransactionMsg  = "Sign transaction (y/n): "
+	confirmStopSmeshingMsg     = "Stop smeshing? (y/n) "
+	confirmDeleteDataMsg       = "Also delete the PoST data files? (y/n) "
+	deletePostDataMsg          = "Type %s to delete the PoST data: "
 	createAccountMsg           = "Account alias (name): "
-	useDefaultGasMsg           = "Use default transaction fee of 1 Smidge? (y/n) "
-	enterGasPrice              = "Enter transaction fee (Smidge):"
+	addScannedAccountMsg       = "Add the account at index %d with address %s? (y/n) "
+	confirmRecoverAccountMsg   = "Add the account with address %s? (y/n) "
+	pickAnotherAliasMsg        = "Choose a different alias? (y/n) "
+	confirmDeleteAccountMsg    = "Type the account alias to confirm deletion: "
+	useDefaultGasMsg           = "Use default gas price of %d Smidge and gas limit of %d? (y/n) "
+	enterGasPrice              = "Enter gas price (Smidge): "
+	enterGasLimit              = "Enter gas limit: "
+	enterNonceMsg              = "Enter the transaction nonce: "
+	confirmBatchMsg            = "Send %d transactions (y/n): "
+	multiRecipientMsg          = "Enter a recipient address, contact or account alias, or nothing to finish: "
+	multiAmountMsg             = "Enter the amount: "
+	gasLimitWarningMsg         = "Continue with this gas limit? (y/n) "
+	recipientWarningMsg        = "Continue despite the warning? (y/n) "
+	nonceWarningMsg            = "Continue with this nonce? (y/n) "
+	zeroAddressConfirmMsg      = "Type %s to send to the zero address: "
+	replaceGasPriceMsg         = "The original gas price is %d. Use %d? (y or the gas price): "
+	spendLimitConfirmMsg       = "Type the amount of %s to confirm: "
+	templateNoteMsg            = "Enter a note for the template (optional): "
 	smeshingDatadirMsg         = "Enter data file directory: "
-	smeshingSpaceAllocationMsg = "Enter space allocation (GB): "
+	smeshingSpaceAllocationMsg = "Enter space allocation, e.g. 256GiB, 1TiB or 4 units: "
+	otherCoinbaseMsg           = "Send the rewards to another address? (y/n) "
+	confirmStartSmeshingMsg    = "Start smeshing with this setup? (y/n) "
+	resumeCommitmentMsg        = "Resume the existing %.2f GiB commitment instead of starting a new one? (y/n) "
+	newRewardsAddressMsg       = "Enter the new rewards address, contact or account alias: "
+	confirmRewardsAddressMsg   = "Change the rewards address? (y/n) "
+	openWalletForRewardsMsg    = "No wallet is open. Open one to send the rewards to one of its accounts? (y/n) "
+	estimateSpaceMsg           = "Enter the space you plan to commit, e.g. 256GiB or 1TiB: "
+	estimateNetworkSpaceMsg    = "Enter the space committed by the whole network, e.g. 500TiB: "
+	estimateLayerRewardMsg     = "Enter the reward of a layer, e.g. 50smh: "
+	estimateLayersPerEpochMsg  = "Enter the number of layers per epoch: "
+	estimateLayerDurationMsg   = "Enter the layer duration in seconds: "
 	msgSignMsg                 = "Enter message to sign (in hex): "
 	msgTextSignMsg             = "Enter text message to sign: "
+	msgVerifyTextMsg           = "Enter signed text message: "
+	multisigOfflineMsg         = "Multisig accounts only coordinate the signatures of their participants offline: no node account template matches their address or transactions."
+	multisigNotBroadcastMsg    = "This multisig transaction can't be broadcast, no node accepts it. It only records the signatures collected offline."
+	seedWarningMsg             = "WARNING: anyone who knows this seed controls every account of the wallet. Only use seeds for test environments. The seed is the wallet backup."
+	confirmSeedMsg             = "Create the wallet from this seed? (y/n) "
+	confirmPaperWalletMsg      = "The %s will be written in plain text to %s. Anyone who reads the file or its printout can spend the account coins. Continue? (y/n) "
+	confirmExportKeyMsg        = "Anyone who sees the private key can spend the account coins. Display it? (y/n) "
+	confirmShutdownMsg         = "The node at %s will stop serving the wallet and stop smeshing. Type its hostname to confirm: "
+	walletChangedMsg           = "The wallet file %s was changed by another program, the changes of the open wallet were not saved over it."
+	confirmOverwriteWalletMsg  = "The accounts only in the file will be lost unless they are in a backup. Overwrite it? (y/n) "
 	coinUnitName               = "Smidge"
 )
 
+// walletConflictChoices are the ways to resolve a wallet file changed by another program
+var walletConflictChoices = []string{
+	"Reload the file, dropping the unsaved changes of the open wallet",
+	"Merge the accounts of the file into the open wallet and save it",
+	"Overwrite the file with the open wallet",
+	"Decide later",
+}
+
 const splash = `
 
                                     .++++++++++++++++++++++++++.
@@ -0,0 +1,266 @@
+package repl
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/spacemeshos/ed25519"
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// cosignedFileSuffix is appended to a transaction file name and the signer alias to get the name
+// of the file holding the signature of a multisig participant
+const cosignedFileSuffix = ".cosigned"
+
+// multisigTxFileSuffix is appended to a multisig transaction file name to get the name of the file
+// holding the transaction with all the signatures
+const multisigTxFileSuffix = ".multisig"
+
+// participantKey resolves a hex public key or the alias of a local account to a public key
+func (r *repl) participantKey(s string) (ed25519.PublicKey, error) {
+	if pub, err := hex.DecodeString(trimHexPrefix(s)); err == nil && len(pub) == ed25519.PublicKeySize {
+		return pub, nil
+	}
+	acc, err := r.client.GetAccount(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a public key or a local account", s)
+	}
+	defer acc.Wipe()
+	if acc.IsWatchOnly() {
+		return nil, fmt.Errorf("the public key of watch-only account %s is unknown", s)
+	}
+	return acc.PubKey, nil
+}
+
+// newMultisigAccount defines an m-of-n account from the public keys of its participants. The
+// account and its address are only known to the participants, the node has no such account.
+func (r *repl) newMultisigAccount() {
+	if len(r.args) < 3 {
+		fmt.Fprintln(r.out, printPrefix, "usage: account multisig-new <name> <m> <public key|alias>...")
+		return
+	}
+	threshold, err := strconv.Atoi(r.args[1])
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "invalid threshold:", r.args[1])
+		return
+	}
+	var participants []ed25519.PublicKey
+	for _, s := range r.args[2:] {
+		pub, err := r.participantKey(s)
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+		participants = append(participants, pub)
+	}
+	account, err := common.NewMultisigAccount(r.args[0], threshold, participants)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	if err := r.client.AddMultisigAccount(account); err != nil {
+		log.Error("failed to add multisig account: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Added %d-of-%d account %s, offline coordination address: %s", account.Threshold,
+		len(account.Participants), account.Name, r.formatAddress(account.Address())))
+	fmt.Fprintln(r.out, printPrefix, multisigOfflineMsg)
+}
+
+// listMultisigAccounts prints the multisig accounts and their participants
+func (r *repl) listMultisigAccounts() {
+	accounts, err := r.client.MultisigAccounts()
+	if err != nil {
+		log.Error("failed to list multisig accounts: %v", err)
+		return
+	}
+	if len(accounts) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "No multisig accounts. Use `account multisig-new` to define one.")
+		return
+	}
+	for _, a := range accounts {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%s: %d-of-%d, offline coordination address: %s", a.Name, a.Threshold, len(a.Participants),
+			r.formatAddress(a.Address())))
+		for _, p := range a.Participants {
+			fmt.Fprintln(r.out, printPrefix, "  participant:", "0x"+p)
+		}
+	}
+	fmt.Fprintln(r.out, printPrefix, multisigOfflineMsg)
+}
+
+// readMultisigEnvelope reads a multisig transaction file
+func readMultisigEnvelope(path string) (*common.MultisigEnvelope, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	e, err := common.ParseMultisigEnvelope(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return e, nil
+}
+
+// writeJSONFile writes a value as indented JSON to a private file
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return common.WritePrivateFile(path, append(data, '\n'))
+}
+
+// printMultisigTransaction prints the transaction of a multisig envelope for review before signing
+func (r *repl) printMultisigTransaction(e *common.MultisigEnvelope) error {
+	txBytes, err := e.TxBytes()
+	if err != nil {
+		return err
+	}
+	tx, err := r.client.DecodeTransaction(txBytes)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(r.out, printPrefix, "Multisig transaction:")
+	fmt.Fprintln(r.out, printPrefix, "To:    ", r.addressString(tx.Recipient))
+	fmt.Fprintln(r.out, printPrefix, "Amount:", common.FormatSmidge(tx.Amount))
+	fmt.Fprintln(r.out, printPrefix, "Gas price:", tx.Price, coinUnitName)
+	fmt.Fprintln(r.out, printPrefix, "Gas limit:", tx.GasLimit)
+	fmt.Fprintln(r.out, printPrefix, "Nonce: ", tx.AccountNonce)
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Signatures: %d of %d", len(e.Signatures), e.Threshold))
+	return nil
+}
+
+// cosignTransaction adds the signature of the current account to a multisig transaction. The file
+// is either a multisig transaction file from another participant or, with --multisig, a
+// transaction request file as read by tx sign.
+func (r *repl) cosignTransaction() {
+	args := positionalArgs(r.args, "--multisig", "--out")
+	if len(args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: tx cosign <file> [--multisig <name>] [--out <path>]")
+		return
+	}
+	path := args[0]
+
+	var e *common.MultisigEnvelope
+	if name, ok := flagValue(r.args, "--multisig"); ok {
+		account, err := r.client.MultisigAccount(name)
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Error("failed to read transaction file: %v", err)
+			return
+		}
+		req, err := common.ParseTxRequest(data)
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+		txBytes, err := r.client.UnsignedTransaction(req.Recipient, req.Nonce, req.Amount, req.GasPrice, req.GasLimit)
+		if err != nil {
+			log.Error("failed to encode transaction: %v", err)
+			return
+		}
+		e = common.NewMultisigEnvelope(account, txBytes)
+	} else {
+		var err error
+		if e, err = readMultisigEnvelope(path); err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+	}
+
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	key, err := acc.SigningKey()
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	defer key.Release()
+
+	if err := r.printMultisigTransaction(e); err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	if r.yesOrNoQuestion(confirmSignTransactionMsg) != "y" {
+		return
+	}
+	if err := e.Sign(key); err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+
+	outPath, ok := flagValue(r.args, "--out")
+	if !ok {
+		outPath = path + "." + acc.Name + cosignedFileSuffix
+	}
+	if err := writeJSONFile(outPath, e); err != nil {
+		log.Error("failed to write multisig transaction: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Signature %d of %d written to: %s", len(e.Signatures), e.Threshold, outPath))
+}
+
+// combineTransaction merges the signatures of multisig transaction files. With enough signatures
+// it writes the serialized multisig transaction, otherwise the combined multisig transaction file.
+// Neither can be broadcast: they record the signatures collected offline.
+func (r *repl) combineTransaction() {
+	paths := positionalArgs(r.args, "--out")
+	if len(paths) < 2 {
+		fmt.Fprintln(r.out, printPrefix, "usage: tx combine <file> <file>... [--out <path>]")
+		return
+	}
+	envelopes := make([]*common.MultisigEnvelope, 0, len(paths))
+	for _, path := range paths {
+		e, err := readMultisigEnvelope(path)
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+		envelopes = append(envelopes, e)
+	}
+	combined, err := common.CombineMultisigEnvelopes(envelopes)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+
+	outPath, ok := flagValue(r.args, "--out")
+	if !combined.Complete() {
+		if !ok {
+			outPath = paths[0] + ".combined"
+		}
+		if err := writeJSONFile(outPath, combined); err != nil {
+			log.Error("failed to write multisig transaction: %v", err)
+			return
+		}
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%d of %d signatures, more participants must cosign: %s",
+			len(combined.Signatures), combined.Threshold, outPath))
+		return
+	}
+
+	tx, err := r.client.MultisigTransaction(combined)
+	if err != nil {
+		log.Error("failed to build multisig transaction: %v", err)
+		return
+	}
+	if !ok {
+		outPath = paths[0] + multisigTxFileSuffix
+	}
+	if err := common.WritePrivateFile(outPath, []byte(fmt.Sprintf("%x\n", tx))); err != nil {
+		log.Error("failed to write multisig transaction: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Multisig transaction with all the signatures written to:", outPath)
+	fmt.Fprintln(r.out, printPrefix, multisigNotBroadcastMsg)
+}
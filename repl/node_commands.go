@@ -1,31 +1,428 @@
 package repl
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
 	"github.com/spacemeshos/smrepl/log"
 )
 
+// syncSampleInterval is the time between the two node status samples the sync rate is estimated
+// from, and between the refreshes of status node --watch
+const syncSampleInterval = 3 * time.Second
+
+// syncSample returns the sync state the node reports now
+func (r *repl) syncSample() (*common.SyncSample, error) {
+	nodeStatus, err := r.client.NodeStatus(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &common.SyncSample{
+		Time:     time.Now(),
+		IsSynced: nodeStatus.IsSynced,
+		Synced:   nodeStatus.GetSyncedLayer().GetNumber(),
+		Top:      nodeStatus.GetTopLayer().GetNumber(),
+		Verified: nodeStatus.GetVerifiedLayer().GetNumber(),
+		Peers:    nodeStatus.ConnectedPeers,
+	}, nil
+}
+
+// peersLine returns the peer count for display, in red with a hint when there are none
+func peersLine(peers uint64) string {
+	if peers == 0 {
+		return colorRed + "Peers: 0, the node has no peers, which is the most likely reason nothing is syncing" + colorReset
+	}
+	return fmt.Sprintf("Peers: %d", peers)
+}
+
+// syncEstimate returns the sync rate and remaining time of a report for display
+func syncEstimate(report common.SyncReport) string {
+	if report.Rate == 0 {
+		return "unknown"
+	}
+	return fmt.Sprintf("%.1f layers/s, about %v remaining", report.Rate, report.Remaining)
+}
+
+// nodeInfo prints whether the node is synced, its version and how far it is behind the network.
+// When it is syncing, the node status is sampled twice to estimate the time remaining.
 func (r *repl) nodeInfo() {
-	info, err := r.client.NodeInfo()
+	if hasFlag(r.args, "--watch") {
+		r.watchNodeSync()
+		return
+	}
+	sample, err := r.syncSample()
 	if err != nil {
-		log.Error("failed to get node info: %v", err)
+		fmt.Fprintln(r.out, printPrefix, common.SyncVerdictNotConnected)
+		log.Error("failed to get node status: %v", err)
 		return
 	}
+	r.setNodeStatus(sample.IsSynced, sample.Peers)
+	var first *common.SyncSample
+	if sample.Peers > 0 && !sample.IsSynced {
+		fmt.Fprintln(r.out, printPrefix, "Measuring the sync rate...")
+		time.Sleep(syncSampleInterval)
+		if second, err := r.syncSample(); err == nil {
+			first, sample = sample, second
+		}
+	}
+	report := common.SyncProgress(first, *sample)
 
-	fmt.Println(printPrefix, "Version:", info.Version)
-	fmt.Println(printPrefix, "Build:", info.Build)
-	fmt.Println(printPrefix, "API server:", r.client.ServerInfo())
+	fmt.Fprintln(r.out, printPrefix, report.Verdict)
+	fmt.Fprintln(r.out, printPrefix, peersLine(sample.Peers))
+	if info, err := r.client.NodeInfo(r.ctx); err == nil {
+		fmt.Fprintln(r.out, printPrefix, "Version:", info.Version)
+		fmt.Fprintln(r.out, printPrefix, "Build:", info.Build)
+	}
+	fmt.Fprintln(r.out, printPrefix, "API server:", r.client.ServerInfo())
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Synced layer: %d of %d (%.1f%%)", sample.Synced, sample.Top, report.SyncedPercent))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Verified layer: %d (%.1f%% of synced)", sample.Verified, report.VerifiedPercent))
+	if report.Verdict == common.SyncVerdictSyncing {
+		fmt.Fprintln(r.out, printPrefix, "Sync rate:", syncEstimate(report))
+	}
+}
 
-	status, err := r.client.NodeStatus()
+// printPeers prints the number of peers the node is connected to. The node API reports only the
+// count, not the peers themselves.
+func (r *repl) printPeers() {
+	nodeStatus, err := r.client.NodeStatus(r.ctx)
 	if err != nil {
-		log.Error("failed to get node status: %v", err)
+		r.printNodeError(common.CallError("get node status", err))
+		return
+	}
+	r.setNodeStatus(nodeStatus.IsSynced, nodeStatus.ConnectedPeers)
+	fmt.Fprintln(r.out, printPrefix, peersLine(nodeStatus.ConnectedPeers))
+	fmt.Fprintln(r.out, printPrefix, "The node API doesn't expose peer ids and addresses, so only the count is shown.")
+}
+
+// watchNodeSync prints a line with the sync state of the node every syncSampleInterval until Enter
+// or Ctrl+C is pressed
+func (r *repl) watchNodeSync() {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	enter := r.enterPressed()
+	ticker := time.NewTicker(syncSampleInterval)
+	defer ticker.Stop()
+
+	fmt.Fprintln(r.out, printPrefix, "Watching the node sync, press Enter or Ctrl+C to stop...")
+	var previous *common.SyncSample
+	for {
+		sample, err := r.syncSample()
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, time.Now().Format(layerTimeFormat), common.SyncVerdictNotConnected+":", err)
+			previous = nil
+		} else {
+			r.setNodeStatus(sample.IsSynced, sample.Peers)
+			report := common.SyncProgress(previous, *sample)
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%s  %-13s layer %d of %d (%.1f%%), verified %d, %d peers, rate: %s",
+				sample.Time.Format(layerTimeFormat), report.Verdict, sample.Synced, sample.Top, report.SyncedPercent,
+				sample.Verified, sample.Peers, syncEstimate(report)))
+			previous = sample
+		}
+		select {
+		case <-ticker.C:
+			continue
+		case <-interrupt:
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-enter:
+		}
 		return
 	}
+}
+
+// printVersions prints the version and build of the node next to the version of smrepl and the
+// node and API versions it was built with, and warns when the node is older than those
+func (r *repl) printVersions() {
+	fmt.Fprintln(r.out, printPrefix, "smrepl version:", common.Version)
+	builtFor := common.DependencyVersion(common.NodeModule)
+	if builtFor != "" {
+		fmt.Fprintln(r.out, printPrefix, "Built for node version:", builtFor)
+	}
+	if api := common.DependencyVersion(common.APIModule); api != "" {
+		fmt.Fprintln(r.out, printPrefix, "API version:", api)
+	}
+
+	info, err := r.client.NodeInfo(r.ctx)
+	if status.Code(err) == codes.Unimplemented {
+		fmt.Fprintln(r.out, printPrefix, "The node does not report version info.")
+		return
+	}
+	if err != nil {
+		r.printNodeError(common.CallError("get node version", err))
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Node version:", info.Version)
+	fmt.Fprintln(r.out, printPrefix, "Node build:", info.Build)
+	r.printVersionWarnings(info.Version, builtFor)
+}
+
+// checkNodeVersion asks the node for its version and warns when it is older or newer than the
+// version smrepl was built for, with the commands which may not work against it. Nodes which don't
+// report their version aren't checked.
+func (r *repl) checkNodeVersion(ctx context.Context) {
+	info, err := r.client.NodeInfo(ctx)
+	if err != nil {
+		return
+	}
+	r.printVersionWarnings(info.Version, common.DependencyVersion(common.NodeModule))
+}
+
+// printVersionWarnings warns when a node version is older or newer than the version smrepl was
+// built for, and lists the commands which may not work against it
+func (r *repl) printVersionWarnings(nodeVersion, builtFor string) {
+	switch drift, _ := common.NodeVersionDrift(nodeVersion, builtFor); drift {
+	case -1:
+		fmt.Fprintln(r.out, printPrefix, colorYellow+fmt.Sprintf("WARNING: the node version %s is older than %s which smrepl was built for. Some commands may fail.", nodeVersion, builtFor)+colorReset)
+	case 1:
+		fmt.Fprintln(r.out, printPrefix, colorYellow+fmt.Sprintf("WARNING: the node version %s is newer than %s which smrepl was built for. Some commands may fail.", nodeVersion, builtFor)+colorReset)
+	}
+	for _, warning := range common.CompatWarnings(nodeVersion) {
+		fmt.Fprintln(r.out, printPrefix, colorYellow+"WARNING: "+warning+colorReset)
+	}
+}
+
+const (
+	// defaultPingCount is the number of echo calls of ping
+	defaultPingCount = 5
+	// pingTimeout is how long an echo call of ping may take before it counts as failed
+	pingTimeout = 2 * time.Second
+	// pingInterval is the pause between the echo calls of ping
+	pingInterval = 500 * time.Millisecond
+)
+
+// ping calls the node echo service a number of times and prints the round trip time of every call
+// and then the statistics and the number of failed calls, like ping does for a host
+func (r *repl) ping() {
+	count := defaultPingCount
+	if len(r.args) > 0 {
+		n, err := strconv.Atoi(r.args[0])
+		if err != nil || n < 1 {
+			fmt.Fprintln(r.out, printPrefix, "usage: ping [count]")
+			return
+		}
+		count = n
+	}
 
-	fmt.Println(printPrefix, "Synced:", status.IsSynced)
-	fmt.Println(printPrefix, "Synced layer:", status.SyncedLayer.Number)
-	fmt.Println(printPrefix, "Current layer:", status.TopLayer.Number)
-	fmt.Println(printPrefix, "Verified layer:", status.VerifiedLayer.Number)
-	fmt.Println(printPrefix, "Peers:", status.ConnectedPeers)
+	var samples []time.Duration
+	for i := 1; i <= count; i++ {
+		if i > 1 {
+			time.Sleep(pingInterval)
+		}
+		if r.ctx.Err() != nil {
+			// Ctrl+C was pressed
+			count = i - 1
+			break
+		}
+		start := time.Now()
+		err := r.client.EchoTimeout(r.ctx, pingTimeout)
+		elapsed := time.Since(start)
+		if err != nil {
+			if status.Code(err) == codes.DeadlineExceeded {
+				fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("echo %d: timed out after %v", i, pingTimeout))
+			} else {
+				fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("echo %d: failed: %v", i, err))
+			}
+			continue
+		}
+		samples = append(samples, elapsed)
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("echo %d: %v", i, elapsed.Round(time.Microsecond)))
+	}
+
+	if count == 0 {
+		return
+	}
+	failed := count - len(samples)
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%s: %d calls, %d succeeded, %d failed (%.0f%% loss)",
+		r.client.ServerInfo(), count, len(samples), failed, float64(failed)*100/float64(count)))
+	if len(samples) > 0 {
+		stats := common.Latency(samples)
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("round trip min/avg/max/stddev = %v/%v/%v/%v", stats.Min.Round(time.Microsecond),
+			stats.Avg.Round(time.Microsecond), stats.Max.Round(time.Microsecond), stats.StdDev.Round(time.Microsecond)))
+	}
+}
+
+// printCallStats prints the calls, errors and latency of each node API method called in the
+// session, the costliest first, or clears them with stats reset
+func (r *repl) printCallStats() {
+	if len(r.args) > 0 {
+		if len(r.args) > 1 || r.args[0] != "reset" {
+			fmt.Fprintln(r.out, printPrefix, "usage: stats [reset]")
+			return
+		}
+		r.client.ResetCallStats()
+		fmt.Fprintln(r.out, printPrefix, "Call statistics cleared")
+		return
+	}
+	stats := r.client.CallStats()
+	if len(stats) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "No node API calls in this session")
+		return
+	}
+	tw := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, printPrefix+"\tMethod\tCalls\tErrors\tAvg\tMax")
+	for _, s := range stats {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%v\t%v\n", printPrefix, s.Method, s.Calls, s.Errors,
+			s.Avg().Round(time.Microsecond), s.Max.Round(time.Microsecond))
+	}
+	tw.Flush()
+}
+
+// listServers prints the configured servers with their role and the round trip time of an echo
+// call, or why the call failed
+func (r *repl) listServers() {
+	tw := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, printPrefix+"\tServer\tRole\tHealth")
+	for _, s := range r.client.CheckServers() {
+		role := "standby"
+		if s.Active {
+			role = "active"
+		}
+		health := fmt.Sprintf("ok, %v", s.Latency.Round(time.Microsecond))
+		if s.Err != nil {
+			health = fmt.Sprintf("failed: %v", s.Err)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", printPrefix, s.Server, role, health)
+	}
+	tw.Flush()
+}
+
+// connectNode switches to another node, which must answer an echo call and be on the network of
+// the current node unless --force is given. The streams printing in the background are stopped,
+// since they follow the previous node.
+func (r *repl) connectNode() {
+	args := positionalArgs(r.args)
+	var server string
+	switch len(args) {
+	case 1:
+		if err := common.ValidateServer(args[0]); err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+		server = args[0]
+	case 2:
+		if _, err := strconv.ParseUint(args[1], 10, 16); err != nil {
+			fmt.Fprintln(r.out, printPrefix, "invalid port:", args[1])
+			return
+		}
+		server = net.JoinHostPort(args[0], args[1])
+	default:
+		fmt.Fprintln(r.out, printPrefix, "usage: status node-connect <host> <port> | unix:///path [--force]")
+		return
+	}
+	params, err := r.client.SwitchServer(r.ctx, server, hasFlag(r.args, "--force"))
+	var mismatch *common.NetworkMismatchError
+	if errors.As(err, &mismatch) {
+		fmt.Fprintln(r.out, printPrefix, err)
+		fmt.Fprintln(r.out, printPrefix, "Not switching. Use --force to connect anyway.")
+		return
+	}
+	if err != nil {
+		r.printNodeError(common.CallError("connect to "+server, err))
+		return
+	}
+	for _, description := range r.stopBackgroundStreams() {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Stopped the %s. Start it again to follow the new node.", description))
+	}
+	r.connectedTo = server
+	r.resetNodeStatus()
+	fmt.Fprintln(r.out, printPrefix, "Connected to", r.client.ServerInfo())
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Network id: %d, genesis time: %s", params.NetId,
+		time.Unix(int64(params.GenesisTime), 0).Local().Format(layerTimeFormat)))
+}
+
+// checkNode checks the node at startup with its health service, or with the mesh info calls on
+// nodes which don't expose it, and returns the mesh info when the node answers. A node which is
+// reachable but not ready or not synced is reported with a warning. It fails only when the node
+// can't be reached.
+func (r *repl) checkNode(ctx context.Context) (*common.NetInfo, error) {
+	ready, err := r.client.Health(ctx)
+	healthChecked := err == nil
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+		// the node doesn't expose the health service, the mesh info calls tell if it is reachable
+	case err != nil:
+		return nil, err
+	case !ready:
+		log.Warning("The node is reachable but reports it isn't ready to serve calls yet.")
+	}
+
+	info, err := r.client.GetMeshInfo(ctx)
+	if err != nil {
+		if !healthChecked {
+			return nil, err
+		}
+		log.Warning("The node is reachable but its mesh info isn't available: %v", err)
+	}
+	if nodeStatus, err := r.client.NodeStatus(ctx); err == nil {
+		r.setNodeStatus(nodeStatus.IsSynced, nodeStatus.ConnectedPeers)
+		if !nodeStatus.IsSynced {
+			log.Warning("The node is reachable but not synced, so balances and transactions may be out of date.")
+		}
+	}
+	return info, nil
+}
+
+const (
+	// shutdownPollInterval is the time between the echo calls which tell whether the node stopped
+	shutdownPollInterval = time.Second
+	// shutdownWait is how long to wait for the node to stop answering after asking it to shut down
+	shutdownWait = 2 * time.Minute
+)
+
+// shutdownNode asks the node to shut down after the user types its hostname, then calls echo
+// until the node stops answering
+func (r *repl) shutdownNode() {
+	server := r.client.ActiveServer()
+	host, _, err := net.SplitHostPort(server)
+	if _, ok := common.SocketPath(server); ok {
+		// a node behind a Unix socket runs on this machine
+		host = "localhost"
+	} else if err != nil {
+		host = server
+	}
+	if strings.TrimSpace(r.inputNotBlank(fmt.Sprintf(confirmShutdownMsg, server))) != host {
+		fmt.Fprintln(r.out, printPrefix, "The hostname doesn't match, not shutting down.")
+		return
+	}
+
+	resp, err := r.client.Shutdown(r.ctx)
+	if status.Code(err) == codes.Unimplemented {
+		fmt.Fprintln(r.out, printPrefix, "admin service not available on this node")
+		return
+	}
+	if err == nil {
+		err = common.StatusError("shut down the node", resp.GetCode(), resp.GetMessage())
+	}
+	if err != nil {
+		r.printNodeError(common.CallError("shut down the node", err))
+		return
+	}
+
+	fmt.Fprintln(r.out, printPrefix, "The node is shutting down, waiting for it to stop answering...")
+	start := time.Now()
+	for time.Since(start) < shutdownWait {
+		if r.ctx.Err() != nil {
+			// Ctrl+C was pressed
+			return
+		}
+		if r.client.EchoTimeout(r.ctx, pingTimeout) != nil {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("The node stopped after %s.", common.HumanDuration(time.Since(start))))
+			return
+		}
+		time.Sleep(shutdownPollInterval)
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("The node still answers after %s.", common.HumanDuration(shutdownWait)))
 }
@@ -0,0 +1,85 @@
+package repl
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// nodeProbeInterval is the time between the background status calls which tell whether the node
+// is online
+const nodeProbeInterval = 30 * time.Second
+
+// Connectivity tags of the prompt
+const (
+	tagOnline  = "online"
+	tagOffline = "offline"
+	tagSyncing = "syncing"
+)
+
+// setNodeStatus records the sync state and the peer count the node reported, which the prompt shows
+func (r *repl) setNodeStatus(synced bool, peers uint64) {
+	r.nodeMu.Lock()
+	defer r.nodeMu.Unlock()
+	r.syncing = !synced
+	r.noPeers = peers == 0
+}
+
+// resetNodeStatus forgets the status of the node, e.g. after switching to another one
+func (r *repl) resetNodeStatus() {
+	r.nodeMu.Lock()
+	defer r.nodeMu.Unlock()
+	r.syncing = false
+	r.noPeers = false
+}
+
+// connectionTag returns the connectivity of the node shown in the prompt: offline when it didn't
+// answer the last call, syncing when it reported it isn't synced and online otherwise, with a
+// note when it has no peers. It is empty before any call to the node ended.
+func (r *repl) connectionTag() string {
+	reachable, known := r.client.Reachable()
+	if !known {
+		return ""
+	}
+	if !reachable {
+		return tagOffline
+	}
+	r.nodeMu.Lock()
+	defer r.nodeMu.Unlock()
+	tag := tagOnline
+	if r.syncing {
+		tag = tagSyncing
+	}
+	if r.noPeers {
+		tag += ", no peers"
+	}
+	return tag
+}
+
+// probeNode asks the node for its status every nodeProbeInterval while the node-probe setting is
+// on, so the prompt shows whether the node is online even between commands. The probe is a short
+// unary call which opens no stream, so the node may still close the idle connection between
+// probes. A line is printed when the node goes offline or comes back.
+func (r *repl) probeNode() {
+	ticker := time.NewTicker(nodeProbeInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if config := r.config(); config.NodeProbe == common.SettingOff {
+			continue
+		}
+		before := r.connectionTag()
+		if nodeStatus, err := r.client.ProbeStatus(); err == nil {
+			r.setNodeStatus(nodeStatus.IsSynced, nodeStatus.ConnectedPeers)
+		}
+		after := r.connectionTag()
+		switch {
+		case after == tagOffline && before != tagOffline:
+			fmt.Fprintln(r.out)
+			fmt.Fprintln(r.out, printPrefix, "The node at", r.client.ActiveServer(), "stopped answering.")
+		case before == tagOffline && after != tagOffline:
+			fmt.Fprintln(r.out)
+			fmt.Fprintln(r.out, printPrefix, "The node at", r.client.ActiveServer(), "is answering again.")
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// streamNodeSamples sends the node status of the node's status stream to samples until stop is
+// closed. The stream is opened again after transient errors. The error which ends streaming, e.g.
+// because the node doesn't implement the stream, is sent to failed.
+func (r *repl) streamNodeSamples(ctx context.Context, samples chan<- common.SyncSample, failed chan<- error, stop <-chan struct{}) {
+	open := func() (streamReceiver, error) {
+		stream, err := r.client.StatusStream(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			resp, err := stream.Recv()
+			if err != nil || resp.GetStatus() == nil {
+				return err
+			}
+			nodeStatus := resp.GetStatus()
+			sample := common.SyncSample{
+				Time:     time.Now(),
+				IsSynced: nodeStatus.IsSynced,
+				Synced:   nodeStatus.GetSyncedLayer().GetNumber(),
+				Top:      nodeStatus.GetTopLayer().GetNumber(),
+				Verified: nodeStatus.GetVerifiedLayer().GetNumber(),
+				Peers:    nodeStatus.ConnectedPeers,
+			}
+			select {
+			case samples <- sample:
+				return nil
+			case <-stop:
+				return errStreamStopped
+			}
+		}, nil
+	}
+	gap := func() string {
+		return "Status changes while the node was unreachable are missing."
+	}
+	if err := r.followStream("node status", r.streamBackoff(), open, gap, stop); err != errStreamStopped {
+		select {
+		case failed <- err:
+		case <-stop:
+		}
+	}
+}
+
+// syncChangeColor returns the color of a status change: green when the node became synced or
+// found peers, red when it lost them, none otherwise
+func syncChangeColor(change common.SyncChange) string {
+	switch change {
+	case common.SyncUp:
+		return colorGreen
+	case common.SyncDown:
+		return colorRed
+	default:
+		return ""
+	}
+}
+
+// streamNodeStatus prints a line whenever the sync state, the peer count or the top, synced or
+// verified layer of the node changes, until Enter or Ctrl+C is pressed
+func (r *repl) streamNodeStatus() {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	enter := r.enterPressed()
+	samples := make(chan common.SyncSample)
+	failed := make(chan error)
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.streamNodeSamples(r.ctx, samples, failed, stop)
+
+	fmt.Fprintln(r.out, printPrefix, "Streaming node status changes, press Enter or Ctrl+C to stop...")
+	count := 0
+	var previous *common.SyncSample
+	for {
+		select {
+		case sample := <-samples:
+			change := common.CompareSyncSamples(previous, sample)
+			if change == common.SyncUnchanged {
+				continue
+			}
+			count++
+			r.setNodeStatus(sample.IsSynced, sample.Peers)
+			report := common.SyncProgress(previous, sample)
+			color, reset := syncChangeColor(change), ""
+			if color != "" {
+				reset = colorReset
+			}
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%s%s  %-13s layer %d of %d (%.1f%%), verified %d, %d peers%s",
+				color, sample.Time.Format(layerTimeFormat), report.Verdict, sample.Synced, sample.Top,
+				report.SyncedPercent, sample.Verified, sample.Peers, reset))
+			previous = &sample
+			continue
+		case err := <-failed:
+			r.printNodeError(common.CallError("stream node status", err))
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-interrupt:
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-enter:
+		}
+		break
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Stopped streaming after %d status changes.", count))
+}
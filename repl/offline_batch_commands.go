@@ -0,0 +1,193 @@
+package repl
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// signedRemainderFileSuffix is appended to a signed batch file name to get the name of the file
+// with the transactions that were not broadcast
+const signedRemainderFileSuffix = ".remaining.json"
+
+// signBatch signs the payments of a CSV file of address,amount,note rows with the current account
+// and writes the signed transactions to a JSON file for tx broadcast-batch. It never talks to the
+// node, so the nonce of the first payment must be given. The others get the following nonces.
+func (r *repl) signBatch() {
+	args := positionalArgs(r.args, "--nonce", "--gas-price", "--gas-limit")
+	nonceStr, ok := flagValue(r.args, "--nonce")
+	if len(args) != 2 || !ok {
+		fmt.Fprintln(r.out, printPrefix, "usage: tx sign-batch <plan.csv> <out.json> --nonce <n> [--gas-price <p>] [--gas-limit <l>]")
+		return
+	}
+	nonce, err := strconv.ParseUint(nonceStr, 10, 64)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "invalid nonce:", nonceStr)
+		return
+	}
+	data, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		log.Error("failed to read batch file: %v", err)
+		return
+	}
+	rows, err := common.ParseBatchCSV(bytes.NewReader(data), r.resolveAddress)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	key, err := acc.SigningKey()
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	defer key.Release()
+	gasPrice, gasLimit, err := r.offlineGas(acc)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	amounts, fees, err := common.BatchTotal(rows, gasPrice.value, gasLimit.value)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+
+	tw := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, printPrefix+"\tLine\tNonce\tTo\tAmount\tNote")
+	for i, row := range rows {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\n", printPrefix, row.Line, nonce+uint64(i), r.addressString(row.Recipient), common.FormatAmount(row.Amount), row.Note)
+	}
+	tw.Flush()
+	fmt.Fprintln(r.out, printPrefix, "From:  ", r.formatAddress(acc.Address()))
+	fmt.Fprintln(r.out, printPrefix, "Total amount:", common.FormatAmount(amounts))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Maximum fees: %s (gas price %d, gas limit %d)", common.FormatAmount(fees), gasPrice.value, gasLimit.value))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Nonces: %d to %d", nonce, nonce+uint64(len(rows))-1))
+	if !r.confirmGasLimit(gasLimit.value) || r.yesOrNoQuestion(confirmSignTransactionMsg) != "y" {
+		return
+	}
+
+	signed := make([]common.SignedBatchTx, 0, len(rows))
+	for i, row := range rows {
+		tx, err := r.client.SignTransfer(row.Recipient, nonce+uint64(i), row.Amount, gasPrice.value, gasLimit.value, key)
+		if err != nil {
+			log.Error("failed to sign transaction: %v", err)
+			return
+		}
+		signed = append(signed, common.NewSignedBatchTx(row, tx))
+	}
+	var buf bytes.Buffer
+	if err := common.WriteSignedBatch(&buf, signed); err != nil {
+		log.Error("failed to encode the signed transactions: %v", err)
+		return
+	}
+	if err := ioutil.WriteFile(args[1], buf.Bytes(), 0644); err != nil {
+		log.Error("failed to write signed batch file: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Signed %d transactions, written to: %s", len(signed), args[1]))
+}
+
+// broadcastBatch submits the transactions of a file written by tx sign-batch in order. Every
+// transaction is decoded and checked before anything is sent. Submitting stops at the first
+// transaction the node rejects, and the transactions not broadcast are written to a file which
+// broadcast-batch accepts to resume.
+func (r *repl) broadcastBatch() {
+	if len(r.args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: tx broadcast-batch <file.json>")
+		return
+	}
+	path := r.args[0]
+	f, err := os.Open(path)
+	if err != nil {
+		log.Error("failed to read signed batch file: %v", err)
+		return
+	}
+	txs, err := common.ReadSignedBatch(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+
+	type verifiedTx struct {
+		data, id []byte
+	}
+	verified := make([]verifiedTx, 0, len(txs))
+	var sender gosmtypes.Address
+	var total uint64
+	for i, t := range txs {
+		data, id, from, err := r.client.VerifySignedBatchTx(t)
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+		if i == 0 {
+			sender = from
+		} else if from != sender {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("line %d: signed by %s, the other transactions by %s", t.Line, r.formatAddress(from), r.formatAddress(sender)))
+			return
+		}
+		if i > 0 && t.Nonce != txs[i-1].Nonce+1 {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("line %d: nonce %d doesn't follow nonce %d", t.Line, t.Nonce, txs[i-1].Nonce))
+			return
+		}
+		total += t.Amount
+		verified = append(verified, verifiedTx{data, id})
+	}
+
+	tw := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, printPrefix+"\tLine\tNonce\tTo\tAmount\tNote")
+	for _, t := range txs {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\t%s\n", printPrefix, t.Line, t.Nonce, r.addressString(gosmtypes.HexToAddress(t.Recipient)), common.FormatAmount(t.Amount), t.Note)
+	}
+	tw.Flush()
+	fmt.Fprintln(r.out, printPrefix, "From:  ", r.addressString(sender))
+	fmt.Fprintln(r.out, printPrefix, "Total amount:", common.FormatAmount(total))
+	if !r.canSubmitTransactions() {
+		fmt.Fprintln(r.out, printPrefix, "Can't submit a new transaction. Please try again later")
+		return
+	}
+	if r.yesOrNoQuestion(fmt.Sprintf(confirmBatchMsg, len(txs))) != "y" {
+		return
+	}
+
+	for i, t := range txs {
+		if _, err := r.client.SubmitSignedTx(r.ctx, verified[i].data, verified[i].id); err != nil {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Line %d failed:", t.Line))
+			r.printNodeError(err)
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Stopped: %d submitted, 1 failed, %d not sent.", i, len(txs)-i-1))
+			r.writeSignedRemainder(txs[i:], path+signedRemainderFileSuffix)
+			return
+		}
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Line %d: transaction id %s", t.Line, t.ID))
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Sent: %d submitted, 0 failed.", len(txs)))
+}
+
+// writeSignedRemainder writes the signed transactions that were not broadcast to a file
+func (r *repl) writeSignedRemainder(txs []common.SignedBatchTx, path string) {
+	var buf bytes.Buffer
+	if err := common.WriteSignedBatch(&buf, txs); err != nil {
+		log.Error("failed to encode the transactions not broadcast: %v", err)
+		return
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		log.Error("failed to write the transactions not broadcast: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Transactions not broadcast written to:", path)
+}
@@ -0,0 +1,141 @@
+package repl
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// Option customizes a session started with Start
+type Option func(*repl)
+
+// WithOutput prints the output of the commands to w instead of the standard output. The prompt
+// itself is still drawn on the terminal unless the input is given with WithInput.
+func WithOutput(w io.Writer) Option {
+	return func(r *repl) {
+		r.out = &syncWriter{w: w}
+	}
+}
+
+// WithInput reads the command lines and the answers to the questions of the commands from in
+// instead of the terminal, e.g. a script. The wallet prompts of the client, such as the passwords
+// and the mnemonic, read it too. The session ends with the input.
+func WithInput(in io.Reader) Option {
+	return func(r *repl) {
+		r.in = bufio.NewReader(in)
+	}
+}
+
+// WithConfig makes the commands use config instead of the settings of the client, which still
+// applies its own to its calls. config set changes config without saving it.
+func WithConfig(config *common.Config) Option {
+	return func(r *repl) {
+		r.settings = config
+	}
+}
+
+// WithPrompt replaces the prompt, $ by default
+func WithPrompt(prompt string) Option {
+	return func(r *repl) {
+		r.prefix = prompt
+	}
+}
+
+// WithHistoryFile recalls the commands of earlier sessions from a file with the up arrow, and
+// appends the commands of the session to it
+func WithHistoryFile(path string) Option {
+	return func(r *repl) {
+		r.historyFile = path
+	}
+}
+
+// WithoutSplash starts the session without printing the splash
+func WithoutSplash() Option {
+	return func(r *repl) {
+		r.noSplash = true
+	}
+}
+
+// newRepl returns a session of a client customized by opts, printing to the standard output and
+// reading from the terminal by default
+func newRepl(c Client, opts ...Option) *repl {
+	r := &repl{
+		client: c,
+		ctx:    context.Background(),
+		out:    &syncWriter{w: os.Stdout},
+		prefix: prefix,
+		exit:   os.Exit,
+	}
+	r.session, r.endSession = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.in != nil {
+		c.SetInput(r.in)
+	}
+	r.clientOpen = c.IsOpen()
+	r.initializeCommands()
+	return r
+}
+
+// syncWriter serializes the writes of the commands and of the streams printing in the background
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// HistoryFileName is the name of the command history file in the wallets directory
+const HistoryFileName = "smrepl_history"
+
+// historyLimit is the number of commands of the history file the prompt recalls
+const historyLimit = 1000
+
+// loadHistory returns the last commands of a history file, none when it doesn't exist yet
+func loadHistory(path string) []string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > historyLimit {
+		lines = lines[len(lines)-historyLimit:]
+	}
+	return lines
+}
+
+// historySecret tells whether a command line holds a secret, which is never written to the history
+// file
+func historySecret(line string) bool {
+	fields := strings.Fields(line)
+	for _, field := range fields {
+		if field == "--seed" || strings.HasPrefix(field, "--seed=") {
+			return true
+		}
+	}
+	return len(fields) > 2 && fields[0] == "config" && fields[1] == "set" && fields[2] == "auth-token"
+}
+
+// appendHistory appends a command line to the history file of the session
+func (r *repl) appendHistory(line string) {
+	if r.historyFile == "" || strings.TrimSpace(line) == "" || historySecret(line) {
+		return
+	}
+	f, err := os.OpenFile(r.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = f.WriteString(line + "\n")
+}
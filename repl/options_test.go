@@ -0,0 +1,91 @@
+package repl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/repl/clienttest"
+)
+
+// askCommand adds a command to a session which asks a yes or no question and prints the answer
+func askCommand(r *repl) {
+	r.commands = append(r.commands, command{commandStateRoot, "ask", commandStateLeaf, "", func() {
+		fmt.Fprintln(r.out, printPrefix, "answered", r.yesOrNoQuestion("sure? "))
+	}})
+}
+
+func TestScriptedSession(t *testing.T) {
+	f := clienttest.New()
+	r, output := newTestRepl(f, WithoutSplash(), WithInput(strings.NewReader("stats reset\n\nask\nmaybe\ny\nstats")))
+	askCommand(r)
+	r.runScript()
+	out := output.String()
+	for _, want := range []string{"Call statistics cleared", "sure? maybe", "invalid command", "answered y", "No node API calls"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in the output of the script, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, splash) {
+		t.Fatalf("expected no splash, got:\n%s", out)
+	}
+	if f.Input != r.in {
+		t.Fatal("expected the prompts of the client to read the input of the script")
+	}
+}
+
+func TestScriptEndsBeforeAnswer(t *testing.T) {
+	r, output := newTestRepl(clienttest.New(), WithoutSplash(), WithInput(strings.NewReader("ask\n")))
+	askCommand(r)
+	r.runScript()
+	out := output.String()
+	if !strings.Contains(out, "The input ended before the command got its answers") || strings.Contains(out, "bug") {
+		t.Fatalf("expected the end of the input to be reported, got:\n%s", out)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	f := clienttest.New()
+	f.SetOpen(false)
+	config := common.DefaultConfig()
+	r, output := newTestRepl(f, WithPrompt("wallet> "), WithConfig(config))
+	if p, _ := r.livePrefix(); !strings.HasSuffix(p, "] wallet> ") {
+		t.Fatalf("expected the connection before the prompt wallet> , got %q", p)
+	}
+	r.executor("config set verbose true")
+	if !config.Verbose {
+		t.Fatalf("expected the setting to change the given settings, got:\n%s", output.String())
+	}
+	if f.Called("Config") {
+		t.Fatalf("expected the settings of the client to be left alone, got %v", f.Calls())
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, HistoryFileName)
+	r, _ := newTestRepl(clienttest.New(), WithHistoryFile(path))
+	for _, line := range []string{
+		"account info",
+		"wallet create --seed 0a0b0c",
+		"config set auth-token secret",
+		"  ",
+		"tx send",
+	} {
+		r.appendHistory(line)
+	}
+	if history := loadHistory(path); strings.Join(history, "|") != "account info|tx send" {
+		t.Fatalf("expected the commands without secrets in the history, got %q", history)
+	}
+	if history := loadHistory(filepath.Join(dir, "missing")); len(history) != 0 {
+		t.Fatalf("expected no history without a file, got %q", history)
+	}
+}
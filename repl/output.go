@@ -0,0 +1,50 @@
+package repl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// printJSON prints a value as indented JSON for the --json form of commands
+func (r *repl) printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Error("failed to encode JSON: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, string(data))
+}
+
+// printJSONLine prints a value as JSON on one line, for commands which print an object per event
+// in their --json form
+func (r *repl) printJSONLine(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Error("failed to encode JSON: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, string(data))
+}
+
+// formatTime returns the display string of a time or unknown for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Local().Format("Jan 02 2006 03:04 PM")
+}
+
+// printNodeError prints an error of a node request. In verbose mode the status reported by the node
+// follows the advice, unless the error already shows it.
+func (r *repl) printNodeError(err error) {
+	fmt.Fprintln(r.out, printPrefix, err)
+	var nodeErr *common.NodeError
+	if errors.As(err, &nodeErr) && r.config().Verbose && !nodeErr.ShowStatus {
+		fmt.Fprintln(r.out, printPrefix, "Node status:", nodeErr.Raw())
+	}
+}
@@ -0,0 +1,61 @@
+package repl
+
+import (
+	"fmt"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultPageSize is the number of rewards or receipts listed without --max
+const defaultPageSize = 50
+
+// page is the part of a listing selected with --offset and --max
+type page struct {
+	offset, max uint32
+}
+
+// pageArgs returns the page given with --offset and --max, the first defaultPageSize results by
+// default
+func pageArgs(args []string) (page, error) {
+	p := page{max: defaultPageSize}
+	if s, ok := flagValue(args, "--offset"); ok {
+		offset, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return p, fmt.Errorf("invalid offset %s, expected a number", s)
+		}
+		p.offset = uint32(offset)
+	}
+	if s, ok := flagValue(args, "--max"); ok {
+		max, err := strconv.ParseUint(s, 10, 32)
+		if err != nil || max == 0 || max > exportPageSize {
+			return p, fmt.Errorf("invalid max %s, expected a number from 1 to %d", s, exportPageSize)
+		}
+		p.max = uint32(max)
+	}
+	return p, nil
+}
+
+// outOfRange tells whether the node refused a page because its offset is past the results, which
+// is shown as an empty page
+func (p page) outOfRange(err error) bool {
+	c := status.Code(err)
+	return p.offset > 0 && (c == codes.OutOfRange || c == codes.InvalidArgument)
+}
+
+// footer describes which of total results the page shows, and how to list the next page
+func (p page) footer(shown int, total uint32) string {
+	if shown == 0 {
+		if p.offset > 0 {
+			return fmt.Sprintf("Nothing at offset %d, there are %d results.", p.offset, total)
+		}
+		return "No results."
+	}
+	last := p.offset + uint32(shown)
+	res := fmt.Sprintf("Showing %d–%d of %d", p.offset+1, last, total)
+	if last < total {
+		res += fmt.Sprintf("; use --offset %d for the next page", last)
+	}
+	return res + "."
+}
@@ -0,0 +1,38 @@
+package repl
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPageArgs(t *testing.T) {
+	if p, err := pageArgs(nil); err != nil || p != (page{max: defaultPageSize}) {
+		t.Fatalf("expected the first page by default, got %+v %v", p, err)
+	}
+	if p, err := pageArgs([]string{"--offset", "50", "--max", "10"}); err != nil || p != (page{offset: 50, max: 10}) {
+		t.Fatalf("expected offset 50 and max 10, got %+v %v", p, err)
+	}
+	for _, args := range [][]string{{"--offset", "-1"}, {"--max", "0"}, {"--max", "1001"}} {
+		if _, err := pageArgs(args); err == nil {
+			t.Fatalf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestPageFooter(t *testing.T) {
+	p := page{offset: 0, max: 50}
+	if footer := p.footer(50, 1234); footer != "Showing 1–50 of 1234; use --offset 50 for the next page." {
+		t.Fatalf("unexpected footer %q", footer)
+	}
+	if footer := (page{offset: 1200, max: 50}).footer(34, 1234); footer != "Showing 1201–1234 of 1234." {
+		t.Fatalf("unexpected last page footer %q", footer)
+	}
+	if footer := (page{offset: 2000, max: 50}).footer(0, 1234); footer != "Nothing at offset 2000, there are 1234 results." {
+		t.Fatalf("unexpected empty page footer %q", footer)
+	}
+	if !(page{offset: 2000}).outOfRange(status.Error(codes.InvalidArgument, "offset")) || p.outOfRange(status.Error(codes.InvalidArgument, "offset")) {
+		t.Fatal("expected only a refused page past the first to be out of range")
+	}
+}
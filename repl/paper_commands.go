@@ -0,0 +1,73 @@
+package repl
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// paperWalletFileSuffix is appended to the account alias to get the default paper wallet file name
+const paperWalletFileSuffix = "_paper_wallet.txt"
+
+// paperWallet writes a printable backup of an account holding its private key, or the wallet
+// mnemonic with --mnemonic. The secret is only written to the file and never displayed.
+func (r *repl) paperWallet() {
+	args := positionalArgs(r.args, "--out")
+	if len(args) > 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: account paper [alias] [--mnemonic] [--out <path>]")
+		return
+	}
+	var acc *common.LocalAccount
+	var err error
+	if len(args) == 1 {
+		acc, err = r.client.GetAccount(args[0])
+	} else {
+		acc, err = r.getCurrent()
+	}
+	if err != nil {
+		log.Error("failed to get account: %v", err)
+		return
+	}
+	defer acc.Wipe()
+	if acc.IsWatchOnly() {
+		fmt.Fprintln(r.out, printPrefix, common.ErrWatchOnly)
+		return
+	}
+
+	path, ok := flagValue(r.args, "--out")
+	if !ok {
+		path = acc.Name + paperWalletFileSuffix
+	}
+	useMnemonic := hasFlag(r.args, "--mnemonic")
+	secretName := "account private key"
+	if useMnemonic {
+		secretName = "wallet mnemonic"
+	}
+	if r.yesOrNoQuestion(fmt.Sprintf(confirmPaperWalletMsg, secretName, path)) != "y" {
+		return
+	}
+
+	paper := common.PaperWallet{
+		WalletName: r.client.WalletName(),
+		Alias:      acc.Name,
+		Address:    r.formatAddress(acc.Address()),
+		Created:    formatTime(time.Now()),
+	}
+	if useMnemonic {
+		if paper.Mnemonic, err = r.client.WalletMnemonic(); err != nil {
+			log.Error("failed to read the wallet mnemonic: %v", err)
+			return
+		}
+	} else {
+		paper.PrivateKey = hex.EncodeToString(acc.PrivKey)
+	}
+	if err := common.WritePrivateFile(path, []byte(paper.Render())); err != nil {
+		log.Error("failed to write paper wallet: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Paper wallet written to:", path)
+	fmt.Fprintln(r.out, printPrefix, "Print it, then delete the file securely.")
+}
@@ -0,0 +1,119 @@
+package repl
+
+import (
+	"fmt"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// meshTransactionsLimit is the number of mesh transactions of an account searched for pending ones
+const meshTransactionsLimit = 1000
+
+// isPending tells whether a transaction state is neither processed nor rejected
+func isPending(state apitypes.TransactionState_TransactionState) bool {
+	return state == apitypes.TransactionState_TRANSACTION_STATE_MEMPOOL ||
+		state == apitypes.TransactionState_TRANSACTION_STATE_MESH
+}
+
+// printPendingTransaction prints a line about a pending transaction of an account
+func (r *repl) printPendingTransaction(address, sender gosmtypes.Address, tx common.SubmittedTx, status string) {
+	direction := "in from " + r.addressString(sender)
+	if sender == address {
+		direction = "out to " + r.addressString(tx.Recipient)
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("0x%x %s, amount: %s, gas: %d x %d, nonce: %d, %s",
+		tx.ID, direction, common.FormatAmount(tx.Amount), tx.GasPrice, tx.GasLimit, tx.Nonce, status))
+}
+
+// listPendingTransactions prints the transactions of the current account that are in the mempool or
+// in a layer that is not applied yet. Transactions sent this session are listed even when the node
+// doesn't know them.
+func (r *repl) listPendingTransactions() {
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	address := acc.Address()
+
+	found := 0
+	seen := make(map[string]bool)
+	var nonces []uint64
+	txs, _, err := r.client.GetMeshTransactions(r.ctx, address, 0, meshTransactionsLimit)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "Can't get the mesh transactions from the node:", err)
+	}
+	for _, tx := range txs {
+		seen[string(tx.Id.Id)] = true
+		state, _, err := r.client.TransactionState(r.ctx, tx.Id.Id, false)
+		if err != nil || state == nil || !isPending(state.State) {
+			continue
+		}
+		ct := tx.GetCoinTransfer()
+		if ct == nil {
+			continue
+		}
+		found++
+		sender := gosmtypes.BytesToAddress(tx.Sender.Address)
+		if sender == address {
+			nonces = append(nonces, tx.Counter)
+		}
+		r.printPendingTransaction(address, sender, common.SubmittedTx{
+			ID: tx.Id.Id,
+			TxRequest: common.TxRequest{
+				Recipient: gosmtypes.BytesToAddress(ct.Receiver.Address),
+				Amount:    tx.Amount.Value,
+				GasPrice:  tx.GasOffered.GasPrice,
+				GasLimit:  tx.GasOffered.GasProvided,
+				Nonce:     tx.Counter,
+			},
+		}, transactionStateDisStringsMap[int32(state.State.Number())])
+	}
+
+	for _, sub := range r.client.SubmittedTransactions(address) {
+		if seen[string(sub.ID)] {
+			continue
+		}
+		status := "submitted locally"
+		if state, _, err := r.client.TransactionState(r.ctx, sub.ID, false); err == nil && state != nil {
+			if !isPending(state.State) && state.State != apitypes.TransactionState_TRANSACTION_STATE_UNSPECIFIED {
+				continue
+			}
+			if isPending(state.State) {
+				status = transactionStateDisStringsMap[int32(state.State.Number())]
+			}
+		}
+		found++
+		nonces = append(nonces, sub.Nonce)
+		r.printPendingTransaction(address, address, sub, status)
+	}
+
+	if found == 0 {
+		fmt.Fprintln(r.out, printPrefix, "No pending transactions.")
+		return
+	}
+	r.printNonceGaps(address, nonces)
+}
+
+// printNonceGaps prints the unused nonces below the highest nonce of pending outgoing transactions.
+// Transactions after a gap can't apply until it is filled.
+func (r *repl) printNonceGaps(address gosmtypes.Address, nonces []uint64) {
+	if len(nonces) == 0 {
+		return
+	}
+	state, err := r.client.AccountState(r.ctx, address)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "Can't get the account nonce to check for gaps:", err)
+		return
+	}
+	for _, gap := range common.NonceGaps(state.StateCurrent.Counter, nonces) {
+		if gap.First == gap.Last {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Nonce gap: nonce %d is unused, pending transactions with higher nonces can't apply until it is.", gap.First))
+		} else {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Nonce gap: nonces %d..%d are unused, pending transactions with higher nonces can't apply until they are.", gap.First, gap.Last))
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package repl
+
+import (
+	"fmt"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// postSampleDuration is how long the PoST labels of a CPU provider are sampled on this machine
+const postSampleDuration = 3 * time.Second
+
+// benchPostProviders prints the speed of each PoST provider of the node with a recommendation. The
+// speed is the performance the node reports for the provider. The API has no call to run a provider
+// benchmark, so a CPU provider without a reported speed is timed by computing labels on this
+// machine when the node runs here.
+func (r *repl) benchPostProviders() {
+	providers, available, err := r.postProviders()
+	if err != nil {
+		r.printNodeError(common.CallError("get PoST providers", err))
+		return
+	}
+	if !available || len(providers) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "The node reports no PoST providers.")
+		return
+	}
+
+	local := r.nodeIsLocal()
+	speeds := make([]float64, len(providers))
+	sources := make([]string, len(providers))
+	for i, p := range providers {
+		switch {
+		case p.GetPerformance() > 0:
+			speeds[i], sources[i] = float64(p.GetPerformance()), "reported by the node"
+		case p.GetComputeApi() != apitypes.ComputeApiClass_COMPUTE_API_CLASS_CPU:
+			sources[i] = "not reported by the node"
+		case !local:
+			sources[i] = "not reported, the node doesn't run on this machine"
+		default:
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Sampling %s on this machine for %s...", p.GetModel(), postSampleDuration))
+			speeds[i], sources[i] = common.SamplePostLabels(postSampleDuration), "one core of this machine"
+		}
+	}
+
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%-4s %-30s %-28s %-16s %s", "ID", "NAME", "TYPE", "SPEED", "MEASURED BY"))
+	for i, p := range providers {
+		speed := "unknown"
+		if speeds[i] > 0 {
+			speed = fmt.Sprintf("%.0f hashes/s", speeds[i])
+		}
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%-4d %-30s %-28s %-16s %s", p.GetId(), p.GetModel(), p.GetComputeApi(), speed, sources[i]))
+	}
+	if fastest := common.FastestProvider(speeds); fastest >= 0 {
+		fmt.Fprintln(r.out, printPrefix, "Recommended:", providerName(providers[fastest]))
+	} else {
+		fmt.Fprintln(r.out, printPrefix, "No provider speed is known, so none is recommended.")
+	}
+}
@@ -0,0 +1,115 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// streamPostStatuses sends the proof of space status of the node's PoST data creation stream to
+// statuses until stop is closed. The stream is opened again after transient errors. The error
+// which ends streaming is sent to failed.
+func (r *repl) streamPostStatuses(ctx context.Context, statuses chan<- *apitypes.PostStatus, failed chan<- error, stop <-chan struct{}) {
+	open := func() (streamReceiver, error) {
+		stream, err := r.client.PostStatusStream(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			resp, err := stream.Recv()
+			if err != nil || resp.GetStatus() == nil {
+				return err
+			}
+			select {
+			case statuses <- resp.GetStatus():
+				return nil
+			case <-stop:
+				return errStreamStopped
+			}
+		}, nil
+	}
+	gap := func() string {
+		return "Progress made while the node was unreachable is included in the next update."
+	}
+	if err := r.followStream("PoST status", r.streamBackoff(), open, gap, stop); err != errStreamStopped {
+		select {
+		case failed <- err:
+		case <-stop:
+		}
+	}
+}
+
+// streamPostStatus prints the proof of space data creation progress as the node reports it, with
+// the percentage of the size given with --size and the throughput since the previous update, until
+// creation completes or Enter or Ctrl+C is pressed
+func (r *repl) streamPostStatus() {
+	var total uint64
+	if size, ok := flagValue(r.args, "--size"); ok {
+		gib, err := strconv.ParseUint(size, 10, 64)
+		if err != nil || gib == 0 {
+			fmt.Fprintln(r.out, printPrefix, "invalid size, expected a number of GiB:", size)
+			return
+		}
+		total = gib << 30
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	enter := r.enterPressed()
+	statuses := make(chan *apitypes.PostStatus)
+	failed := make(chan error)
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.streamPostStatuses(r.ctx, statuses, failed, stop)
+
+	fmt.Fprintln(r.out, printPrefix, "Streaming the PoST data creation progress, press Enter or Ctrl+C to stop...")
+	var previous *common.PostSample
+	for {
+		select {
+		case postStatus := <-statuses:
+			sample := common.PostSample{Time: time.Now(), BytesWritten: postStatus.GetBytesWritten()}
+			progress := common.NewPostProgress(previous, sample, total)
+			line := fmt.Sprintf("%s  %s, %.2f GiB written", sample.Time.Format(layerTimeFormat),
+				postStatus.GetFilesStatus(), common.GiB(sample.BytesWritten))
+			if progress.Percent >= 0 {
+				line += fmt.Sprintf(" (%.1f%%)", progress.Percent)
+			}
+			if previous != nil {
+				line += fmt.Sprintf(", %.1f MB/s", progress.MBps)
+			}
+			if progress.Remaining > 0 {
+				line += fmt.Sprintf(", %s left", progress.Remaining)
+			}
+			fmt.Fprintln(r.out, printPrefix, line)
+			if msg := postStatus.GetErrorMessage(); msg != "" {
+				fmt.Fprintln(r.out, printPrefix, colorRed+"Error: "+msg+colorReset)
+			}
+			if postStatus.GetFilesStatus() == apitypes.PostStatus_FILES_STATUS_COMPLETE && !postStatus.GetInitInProgress() {
+				fmt.Fprintln(r.out, printPrefix, colorGreen+fmt.Sprintf("PoST initialization complete — %.2f GiB committed", common.GiB(sample.BytesWritten))+colorReset)
+				fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+				<-enter
+				return
+			}
+			previous = &sample
+			continue
+		case err := <-failed:
+			r.printNodeError(common.CallError("stream PoST status", err))
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-interrupt:
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-enter:
+		}
+		break
+	}
+	fmt.Fprintln(r.out, printPrefix, "Stopped streaming the PoST status.")
+}
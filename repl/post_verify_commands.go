@@ -0,0 +1,50 @@
+package repl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// verifyPost checks the PoST data of the directory given with --data-dir or prompted for. The API
+// has no call to generate or fetch a proof, so no labels are verified: the data files are checked
+// for consistency with the metadata on this machine and the level of the check is printed.
+func (r *repl) verifyPost() {
+	dataDir, ok := flagValue(r.args, "--data-dir")
+	if !ok {
+		dataDir = strings.TrimSpace(r.inputNotBlank(smeshingDatadirMsg))
+	}
+	if !r.nodeIsLocal() {
+		fmt.Fprintln(r.out, printPrefix, "The node doesn't run on this machine, so the directory is read here and may not be the node's.")
+	}
+	check, err := common.CheckPostData(dataDir)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "The PoST data in the directory can't be read:", err)
+		return
+	}
+
+	fmt.Fprintln(r.out, printPrefix, "Verification level: data files and metadata checked on this machine.")
+	fmt.Fprintln(r.out, printPrefix, "No proof was generated or verified, the node has no call for one, so no labels were checked.")
+	if check.Info == nil {
+		fmt.Fprintln(r.out, printPrefix, colorRed+"FAIL: there is no PoST data in the directory"+colorReset)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Data files: %d, %.2f GiB of a %.2f GiB commitment", check.Info.Files, common.GiB(check.Info.Written), common.GiB(check.Info.Committed)))
+	if check.MetadataChecksum != "" {
+		fmt.Fprintln(r.out, printPrefix, "Metadata SHA-256:", check.MetadataChecksum)
+	}
+	if id, err := r.client.GetSmesherId(r.ctx); err == nil {
+		if match, known := check.Info.BelongsTo(id); known && !match {
+			check.Problems = append(check.Problems, "the data was created for another smesher than the node's, "+r.formatSmesherId(id))
+		}
+	}
+	for _, problem := range check.Problems {
+		fmt.Fprintln(r.out, printPrefix, "  "+problem)
+	}
+	if len(check.Problems) == 0 {
+		fmt.Fprintln(r.out, printPrefix, colorGreen+fmt.Sprintf("PASS in %s", check.Duration)+colorReset)
+	} else {
+		fmt.Fprintln(r.out, printPrefix, colorRed+fmt.Sprintf("FAIL in %s", check.Duration)+colorReset)
+	}
+}
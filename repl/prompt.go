@@ -1,6 +1,8 @@
 package repl
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -11,25 +13,98 @@ import (
 
 var emptyComplete = func(prompt.Document) []prompt.Suggest { return []prompt.Suggest{} }
 
-func runPrompt(executor func(string), completer func(prompt.Document) []prompt.Suggest,
-	firstTime func(), length uint16) {
+// errInputEnded is raised by promptInput when the input given with WithInput ends before a command
+// got its answers, and reported by recoverCommand
+var errInputEnded = errors.New("the input ended")
+
+func (r *repl) runPrompt() {
 	p := prompt.New(
-		executor,
-		completer,
-		prompt.OptionPrefix(prefix),
+		func(text string) {
+			r.appendHistory(text)
+			r.executor(text)
+		},
+		r.completer,
+		prompt.OptionPrefix(r.prefix),
+		prompt.OptionLivePrefix(r.livePrefix),
 		prompt.OptionPrefixTextColor(prompt.LightGray),
-		prompt.OptionMaxSuggestion(length),
+		prompt.OptionMaxSuggestion(uint16(len(r.commands))),
 		prompt.OptionShowCompletionAtStart(),
+		prompt.OptionHistory(loadHistory(r.historyFile)),
+		prompt.OptionParser(r.terminal),
 	)
-	firstTime()
+	r.firstTime()
 	p.Run()
 }
 
+// runScript executes the command lines of the input given with WithInput until it ends
+func (r *repl) runScript() {
+	r.firstTime()
+	for {
+		line, err := r.readLine()
+		if strings.TrimSpace(line) != "" {
+			r.executor(line)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readLine reads a line of the input given with WithInput without its line break
+func (r *repl) readLine() (string, error) {
+	line, err := r.in.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// promptInput asks for a line on the terminal, or reads it from the input given with WithInput
+func (r *repl) promptInput(msg string, completer prompt.Completer, opts ...prompt.Option) string {
+	if r.in == nil {
+		return prompt.Input(msg, completer, opts...)
+	}
+	fmt.Fprint(r.out, msg)
+	line, err := r.readLine()
+	if err != nil && line == "" {
+		fmt.Fprintln(r.out)
+		panic(errInputEnded)
+	}
+	fmt.Fprintln(r.out, line)
+	return line
+}
+
+// enterPressed returns a channel closed when Enter is pressed. With the input given with WithInput,
+// which holds the next command lines, it is closed when the command returns instead, so the streams
+// of a script run until they end or Ctrl+C is pressed.
+func (r *repl) enterPressed() <-chan struct{} {
+	if r.in != nil {
+		return r.ctx.Done()
+	}
+	enter := make(chan struct{})
+	go func() {
+		_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+		close(enter)
+	}()
+	return enter
+}
+
+// inputWithDefault prompts for a value showing def, which an empty answer accepts
+func (r *repl) inputWithDefault(msg, def string) string {
+	if def != "" {
+		msg = strings.TrimSuffix(msg, ": ") + " [" + def + "]: "
+	}
+	input := r.promptInput(r.prefix+msg,
+		emptyComplete,
+		prompt.OptionPrefixTextColor(prompt.LightGray))
+	if strings.TrimSpace(input) == "" {
+		return def
+	}
+	return input
+}
+
 // executes prompt waiting for an input with y or n
-func yesOrNoQuestion(msg string) string {
+func (r *repl) yesOrNoQuestion(msg string) string {
 	var input string
 	for {
-		input = prompt.Input(prefix+msg,
+		input = r.promptInput(r.prefix+msg,
 			emptyComplete,
 			prompt.OptionPrefixTextColor(prompt.LightGray))
 
@@ -37,22 +112,22 @@ func yesOrNoQuestion(msg string) string {
 			break
 		}
 
-		fmt.Println(printPrefix, "invalid command.")
+		fmt.Fprintln(r.out, printPrefix, "invalid command.")
 	}
 
 	return input
 }
 
-func multipleChoice(names []string) int {
+func (r *repl) multipleChoice(names []string) int {
 	var input string
 	if len(names) == 0 {
 		return 0
 	}
 	for {
 		for n, ac := range names {
-			fmt.Println(n+1, printPrefix, ac)
+			fmt.Fprintln(r.out, n+1, printPrefix, ac)
 		}
-		input = prompt.Input(prefix,
+		input = r.promptInput(r.prefix,
 			emptyComplete,
 			prompt.OptionPrefixTextColor(prompt.LightGray))
 
@@ -64,21 +139,21 @@ func multipleChoice(names []string) int {
 
 		s := strings.TrimSpace(input)
 		if s == "quit" || s == "exit" {
-			fmt.Println("Bye!")
+			fmt.Fprintln(r.out, "Bye!")
 			os.Exit(0)
 			return 0
 		}
 
-		fmt.Println(printPrefix, "invalid command.")
+		fmt.Fprintln(r.out, printPrefix, "invalid command.")
 
 	}
 }
 
 // executes prompt waiting an input not blank
-func inputNotBlank(msg string) string {
+func (r *repl) inputNotBlank(msg string) string {
 	var input string
 	for {
-		input = prompt.Input(prefix+msg,
+		input = r.promptInput(r.prefix+msg,
 			emptyComplete,
 			prompt.OptionPrefixTextColor(prompt.LightGray))
 
@@ -86,7 +161,7 @@ func inputNotBlank(msg string) string {
 			break
 		}
 
-		fmt.Println(printPrefix, "please enter a value.")
+		fmt.Fprintln(r.out, printPrefix, "please enter a value.")
 	}
 
 	return input
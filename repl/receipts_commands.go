@@ -0,0 +1,104 @@
+package repl
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// receiptResultStrings describes the results of transaction receipts. Every result other than
+// executed is a failure and its description is the failure reason.
+var receiptResultStrings = map[apitypes.TransactionReceipt_TransactionResult]string{
+	apitypes.TransactionReceipt_TRANSACTION_RESULT_UNSPECIFIED:        "unspecified",
+	apitypes.TransactionReceipt_TRANSACTION_RESULT_EXECUTED:           "success",
+	apitypes.TransactionReceipt_TRANSACTION_RESULT_BAD_COUNTER:        "bad nonce",
+	apitypes.TransactionReceipt_TRANSACTION_RESULT_RUNTIME_EXCEPTION:  "runtime exception",
+	apitypes.TransactionReceipt_TRANSACTION_RESULT_INSUFFICIENT_GAS:   "insufficient gas",
+	apitypes.TransactionReceipt_TRANSACTION_RESULT_INSUFFICIENT_FUNDS: "insufficient funds",
+}
+
+// receiptResult returns the display string of a receipt result and whether it is a failure
+func receiptResult(result apitypes.TransactionReceipt_TransactionResult) (string, bool) {
+	s, ok := receiptResultStrings[result]
+	if !ok {
+		s = result.String()
+	}
+	if result == apitypes.TransactionReceipt_TRANSACTION_RESULT_EXECUTED {
+		return s, false
+	}
+	return "FAILED: " + s, true
+}
+
+// printReceipts prints the page of transaction receipts of an account given with --offset and
+// --max, newest layer first
+func (r *repl) printReceipts(address gosmtypes.Address) {
+	p, err := pageArgs(r.args)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	receipts, total, err := r.client.AccountTransactionsReceipts(r.ctx, address, p.offset, p.max)
+	if err != nil && !p.outOfRange(err) {
+		log.Error("failed to get transaction receipts: %v", err)
+		return
+	}
+	order := sortedOrder(len(receipts), func(i int) (uint64, bool) {
+		if receipts[i].GetLayer() == nil {
+			return 0, false
+		}
+		return uint64(receipts[i].GetLayer().GetNumber()), true
+	}, true)
+
+	fmt.Fprintln(r.out, printPrefix, "Transaction receipts of", r.addressString(address))
+	if len(receipts) == 0 {
+		fmt.Fprintln(r.out, printPrefix, p.footer(0, total))
+		return
+	}
+	failed := 0
+	tw := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, printPrefix+"\tTransaction id\tResult\tGas used\tFee\tLayer")
+	for _, i := range order {
+		receipt := receipts[i]
+		result, isFailure := receiptResult(receipt.Result)
+		if isFailure {
+			failed++
+		}
+		layer := "-"
+		if receipt.GetLayer() != nil {
+			layer = fmt.Sprint(receipt.GetLayer().GetNumber())
+		}
+		fmt.Fprintf(tw, "%s\t0x%x\t%s\t%d\t%s\t%s\n", printPrefix, receipt.GetId().GetId(), result, receipt.GetGasUsed(), common.FormatAmount(receipt.GetFee().GetValue()), layer)
+	}
+	tw.Flush()
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%d receipts, %d failed", len(receipts), failed))
+	fmt.Fprintln(r.out, printPrefix, p.footer(len(receipts), total))
+}
+
+// printCurrAccountReceipts prints the transaction receipts of the current account
+func (r *repl) printCurrAccountReceipts() {
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	r.printReceipts(acc.Address())
+}
+
+// printAccountReceipts prints the transaction receipts of an account given as argument or entered
+func (r *repl) printAccountReceipts() {
+	if args := positionalArgs(r.args, "--offset", "--max"); len(args) > 0 {
+		address, err := r.resolveAddress(args[0])
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+		r.printReceipts(address)
+		return
+	}
+	r.printReceipts(r.inputAddress(enterAddressMsg))
+}
@@ -0,0 +1,116 @@
+package repl
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spacemeshos/ed25519"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// scanAccounts derives the next addresses of the wallet mnemonic and offers to add the ones with
+// activity on the mesh. This recovers the accounts of a wallet restored from its mnemonic.
+func (r *repl) scanAccounts() {
+	args := positionalArgs(r.args)
+	if len(args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: account scan <n>")
+		return
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		fmt.Fprintln(r.out, printPrefix, "the number of addresses to scan must be a positive number")
+		return
+	}
+	candidates, err := r.client.DeriveAddresses(n)
+	if err != nil {
+		log.Error("failed to derive addresses: %v", err)
+		return
+	}
+
+	var active []common.DerivedAddress
+	for _, c := range candidates {
+		state, err := r.client.AccountState(r.ctx, c.Address)
+		if status.Code(err) == codes.NotFound {
+			continue
+		}
+		if err != nil {
+			log.Error("failed to get account state: %v", err)
+			return
+		}
+		if state.StateCurrent != nil && (state.StateCurrent.Counter > 0 || balanceValue(state.StateCurrent) > 0) {
+			active = append(active, c)
+		}
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Scanned indexes %d to %d, found %d active addresses",
+		candidates[0].Index, candidates[len(candidates)-1].Index, len(active)))
+
+	for _, c := range active {
+		if r.yesOrNoQuestion(fmt.Sprintf(addScannedAccountMsg, c.Index, r.formatAddress(c.Address))) != "y" {
+			continue
+		}
+		for {
+			alias := r.inputNotBlank(createAccountMsg)
+			err := r.client.AddDerivedAccount(alias, c.Index)
+			if err == nil {
+				fmt.Fprintln(r.out, printPrefix, "Added account", alias)
+				break
+			}
+			fmt.Fprintln(r.out, printPrefix, err)
+			if err != common.ErrAliasTaken || r.yesOrNoQuestion(pickAnotherAliasMsg) != "y" {
+				break
+			}
+		}
+	}
+}
+
+// recoverAccount adds the account derived from a mnemonic at an optional derivation index
+func (r *repl) recoverAccount() {
+	index := uint64(0)
+	if s, ok := flagValue(r.args, "--index"); ok {
+		var err error
+		if index, err = strconv.ParseUint(s, 10, 64); err != nil {
+			fmt.Fprintln(r.out, printPrefix, "invalid derivation index:", s)
+			return
+		}
+	}
+	key, err := r.client.RecoverKey(index)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	defer common.Zero(key)
+	address := gosmtypes.BytesToAddress(key.Public().(ed25519.PublicKey))
+
+	accounts, err := r.localAccounts()
+	if err != nil {
+		log.Error("failed to list accounts: %v", err)
+		return
+	}
+	for _, acc := range accounts {
+		if acc.Address() == address {
+			fmt.Fprintln(r.out, printPrefix, "The wallet already has this account:", acc.Name, r.formatAddress(address))
+			return
+		}
+	}
+
+	if r.yesOrNoQuestion(fmt.Sprintf(confirmRecoverAccountMsg, r.formatAddress(address))) != "y" {
+		return
+	}
+	for {
+		alias := r.inputNotBlank(createAccountMsg)
+		err := r.client.AddKeyAccount(alias, key)
+		if err == nil {
+			fmt.Fprintln(r.out, printPrefix, "Added account", alias)
+			return
+		}
+		fmt.Fprintln(r.out, printPrefix, err)
+		if err != common.ErrAliasTaken || r.yesOrNoQuestion(pickAnotherAliasMsg) != "y" {
+			return
+		}
+	}
+}
@@ -1,9 +1,16 @@
 package repl
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"runtime/debug"
 	"strings"
+	"sync"
+	"time"
 
 	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	"github.com/spacemeshos/ed25519"
@@ -29,12 +36,13 @@ const (
 	commandStatePOS
 	commandStateSmesher
 	commandStateDBG
+	commandStateContact
+	commandStateConfig
+	commandStateTx
+	commandStateTemplate
 	commandStateLeaf
 )
 
-// TestMode variable used for check if unit test is running
-var TestMode = false
-
 type command struct {
 	parent      int
 	text        string
@@ -48,62 +56,209 @@ type repl struct {
 	client     Client
 	clientOpen bool
 	input      string
+	args       []string // command line params following the executed command
+	// account alias selected for the executed command with --account <alias> or @alias
+	accountOverride string
+	// lastSent is the last coin transfer sent this session, which template save stores
+	lastSent *common.TxTemplate
+	// ctx is the context of the executed command. It is cancelled when the command returns or
+	// Ctrl+C is pressed, which cancels the calls and streams of the command.
+	ctx context.Context
+	// session is the context the commands' contexts derive from, cancelled by endSession when the
+	// process is terminated
+	session    context.Context
+	endSession context.CancelFunc
+	// shutdownOnce runs the cleanup of a terminated session once
+	shutdownOnce sync.Once
+	// exit ends the process, os.Exit unless replaced by tests
+	exit func(code int)
+	// connectedTo is the server status node-connect switched to, shown in the prompt. It is empty
+	// while the session uses the servers it started with.
+	connectedTo string
+	// nodeMu guards syncing and noPeers, which are set when the node last reported it isn't synced
+	// or has no peers and shown in the prompt
+	nodeMu  sync.Mutex
+	syncing bool
+	noPeers bool
+	// streamsMu guards backgroundStreams, the stop channels of the streams printing in the
+	// background by description
+	streamsMu         sync.Mutex
+	backgroundStreams map[string]chan struct{}
+	// stateHistory is the global state hashes seen this session by state global and
+	// stream-global
+	stateHistory common.StateHistory
+	// richList is the accounts scanned by state top this session, nil before the first scan
+	richList *common.RichList
+	// out is where the commands print, the standard output unless given with WithOutput
+	out io.Writer
+	// in is the input given with WithInput, nil when the session reads the terminal
+	in *bufio.Reader
+	// prefix is the prompt, $ unless given with WithPrompt
+	prefix string
+	// historyFile is the file of the commands recalled with the up arrow, none when empty
+	historyFile string
+	// noSplash starts the session without the splash
+	noSplash bool
+	// settings replace the settings of the client when given with WithConfig
+	settings *common.Config
+	// terminal is the input of the prompt, which a shutdown restores. It is nil when the session
+	// reads the input given with WithInput.
+	terminal *terminal
 }
 
 // Client interface to REPL clients.
+//
+// The node service calls, IsOpen, Config, ReloadConfig, Close, SubmittedTransactions and the local
+// account management methods which don't prompt are safe to call from several goroutines, e.g. a
+// watch loop, a background stream or the signal handler while a command runs. The wallet lifecycle methods which prompt on the
+// terminal, and the address book, template, multisig and nonce files, are used from the command
+// goroutine only.
 type Client interface {
 	PrintWalletMnemonic()
-	WalletInfo()
+	WalletMnemonic() (string, error)
+	WalletInfo() (*common.WalletInfo, error)
 	IsOpen() bool
 	OpenWallet() bool
-	NewWallet() bool
+	NewWallet(name string, entropy []byte) bool
 	CloseWallet()
-
-	// Local account management methods
+	ChangePassword() error
+	ConfirmPassword() error
+	WalletName() string
+	ListWallets() ([]string, error)
+	SwitchWallet(name string) error
+	BackupWallet(path string) (string, error)
+	VerifyBackup(path string) ([]common.AccountSummary, error)
+	ImportSmappWallet(path string) (string, []common.AccountSummary, error)
+	ExportSmappWallet(path string) (int, error)
+	MergeWallet(path string, dryRun bool) (*common.MergeResult, error)
+	// SetInput makes the methods which prompt read their answers from in instead of the terminal
+	SetInput(in *bufio.Reader)
+
+	// Local account management methods. All but VerifyMnemonic and RecoverKey, which prompt, are
+	// safe for concurrent use.
 	CreateAccount(alias string) (*common.LocalAccount, error)
+	WatchAccount(alias string, address gosmtypes.Address) (*common.LocalAccount, error)
 	CurrentAccount() (*common.LocalAccount, error)
 	SetCurrentAccount(accountNumber int) error
 	ListAccounts() ([]string, error)
+	DeriveAddresses(n int) ([]common.DerivedAddress, error)
+	AddDerivedAccount(displayName string, index uint64) error
+	VerifyMnemonic() (bool, error)
+	RecoverKey(index uint64) (ed25519.PrivateKey, error)
+	AddKeyAccount(displayName string, key ed25519.PrivateKey) error
 	GetAccount(name string) (*common.LocalAccount, error)
+	DeleteAccount(name string) error
+	RenameAccount(oldName, newName string) error
+	SetAccountNote(name, note string) error
+	TagAccount(name, tag string) error
+	UntagAccount(name, tag string) error
+	SetAccountGas(name string, gasPrice, gasLimit uint64) error
 	StoreAccounts() error
+	WalletConflict() (*common.WalletConflict, error)
+	ReloadWallet() error
+	MergeWalletFile() (*common.MergeResult, error)
+	OverwriteWalletFile() error
+
+	// Multisig accounts
+	MultisigAccounts() ([]common.MultisigAccount, error)
+	MultisigAccount(name string) (*common.MultisigAccount, error)
+	AddMultisigAccount(account *common.MultisigAccount) error
+	MultisigTransaction(e *common.MultisigEnvelope) ([]byte, error)
+
+	// Locally reserved nonces
+	NextNonce(address gosmtypes.Address, projected uint64) (uint64, error)
+	ReservedNonce(address gosmtypes.Address) (uint64, bool, error)
+	ResetNonce(address gosmtypes.Address) error
+
+	// Address book
+	Contacts() ([]common.Contact, error)
+	AddContact(name string, address gosmtypes.Address) error
+	DeleteContact(name string) error
+	SmesherContacts() ([]common.SmesherContact, error)
+	AddSmesherContact(name string, id []byte) error
+	DeleteSmesherContact(name string) error
+
+	// Transaction templates
+	TxTemplates() ([]common.TxTemplate, error)
+	TxTemplate(name string) (*common.TxTemplate, error)
+	AddTxTemplate(t common.TxTemplate) error
+	DeleteTxTemplate(name string) error
 
 	// Local config
+	ApplyConfig()
+	ReloadConfig() error
+	InvalidateCache()
+	Close() error
 	ServerInfo() string
+	ActiveServer() string
+	Reachable() (reachable bool, known bool)
+	CheckServers() []common.ServerHealth
+	CallStats() []common.CallStats
+	ResetCallStats()
+	SetGRPCDebug(on bool)
+	GRPCDebug() bool
+	SwitchServer(ctx context.Context, server string, force bool) (*common.NetInfo, error)
+	Config() (*common.Config, error)
 
 	// Node service
-	NodeStatus() (*apitypes.NodeStatus, error)
-	NodeInfo() (*common.NodeInfo, error)
-	Echo() error
+	NodeStatus(ctx context.Context) (*apitypes.NodeStatus, error)
+	NodeInfo(ctx context.Context) (*common.NodeInfo, error)
+	Echo(ctx context.Context) error
+	Health(ctx context.Context) (bool, error)
+	EchoTimeout(ctx context.Context, timeout time.Duration) error
+	Shutdown(ctx context.Context) (*status.Status, error)
+	ProbeStatus() (*apitypes.NodeStatus, error)
+	ErrorStream(ctx context.Context) (apitypes.NodeService_ErrorStreamClient, error)
+	StatusStream(ctx context.Context) (apitypes.NodeService_StatusStreamClient, error)
 
 	// Mesh service
-	GetMeshTransactions(address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Transaction, uint32, error)
-	GetMeshActivations(address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Activation, uint32, error)
-	GetMeshInfo() (*common.NetInfo, error)
+	GetMeshTransactions(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Transaction, uint32, error)
+	GetMeshActivations(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Activation, uint32, error)
+	GetMeshInfo(ctx context.Context) (*common.NetInfo, error)
+	NetworkParams(ctx context.Context, refresh bool) (*common.NetInfo, error)
+	LayerStream(ctx context.Context) (apitypes.MeshService_LayerStreamClient, error)
+	FeeEstimate(ctx context.Context) (*common.FeeEstimate, error)
+	GasOracle(ctx context.Context, window uint32) (*common.GasOracleReport, error)
 
 	// Transaction service
-	Transfer(recipient gosmtypes.Address, nonce, amount, gasPrice, gasLimit uint64, key ed25519.PrivateKey) (*apitypes.TransactionState, error)
-	TransactionState(txId []byte, includeTx bool) (*apitypes.TransactionState, *apitypes.Transaction, error)
+	UnsignedTransaction(recipient gosmtypes.Address, nonce, amount, gasPrice, gasLimit uint64) ([]byte, error)
+	DecodeTransaction(data []byte) (*common.InnerSerializableSignedTransaction, error)
+	SignTransfer(recipient gosmtypes.Address, nonce, amount, gasPrice, gasLimit uint64, key *common.SigningKey) (*common.SignedTransfer, error)
+	Transfer(ctx context.Context, recipient gosmtypes.Address, nonce, amount, gasPrice, gasLimit uint64, key *common.SigningKey) (*apitypes.TransactionState, error)
+	SubmitTransfer(ctx context.Context, tx *common.SignedTransfer) (*apitypes.TransactionState, error)
+	VerifySignedBatchTx(t common.SignedBatchTx) ([]byte, []byte, gosmtypes.Address, error)
+	SubmitSignedTx(ctx context.Context, tx, id []byte) (*apitypes.TransactionState, error)
+	SubmitCoinTransaction(ctx context.Context, tx []byte) (*apitypes.TransactionState, error)
+	DecodeSignedTransaction(data []byte) (*common.DecodedTransfer, error)
+	DecodeMultisigTransaction(data []byte) (*common.SerializableMultisigTransaction, error)
+	SubmittedTransactions(address gosmtypes.Address) []common.SubmittedTx
+	TransactionState(ctx context.Context, txId []byte, includeTx bool) (*apitypes.TransactionState, *apitypes.Transaction, error)
 
 	// Smesher service
-	GetSmesherId() ([]byte, error)
-	IsSmeshing() (bool, error)
-	StartSmeshing(address gosmtypes.Address, dataDir string, dataSizeBytes uint64) (*status.Status, error)
-	StopSmeshing(deleteFiles bool) (*status.Status, error)
-	GetRewardsAddress() (*gosmtypes.Address, error)
-	SetRewardsAddress(coinbase gosmtypes.Address) (*status.Status, error)
-	GetPostStatus() (*apitypes.PostStatus, error)
+	GetSmesherId(ctx context.Context) ([]byte, error)
+	SmesherIds(ctx context.Context) ([][]byte, error)
+	IsSmeshing(ctx context.Context) (bool, error)
+	StartSmeshing(ctx context.Context, address gosmtypes.Address, dataDir string, dataSizeBytes uint64) (*status.Status, error)
+	StopSmeshing(ctx context.Context, deleteFiles bool) (*status.Status, error)
+	GetRewardsAddress(ctx context.Context) (*gosmtypes.Address, error)
+	SetRewardsAddress(ctx context.Context, coinbase gosmtypes.Address) (*status.Status, error)
+	GetPostStatus(ctx context.Context) (*apitypes.PostStatus, error)
+	GetPostComputeProviders(ctx context.Context) ([]*apitypes.PostComputeProvider, error)
+	CreatePostData(ctx context.Context, data *apitypes.PostData) (*status.Status, error)
+	PostStatusStream(ctx context.Context) (apitypes.SmesherService_PostDataCreationProgressStreamClient, error)
 
 	// debug service
-	DebugAllAccounts() ([]*apitypes.Account, error)
+	DebugAllAccounts(ctx context.Context) ([]*apitypes.Account, error)
 
 	// global state service
-	AccountState(address gosmtypes.Address) (*apitypes.Account, error)
-	AccountRewards(address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Reward, uint32, error)
-	AccountRewardsStream(address gosmtypes.Address) (apitypes.GlobalStateService_AccountDataStreamClient, error)
-	AccountUpdatesStream(address gosmtypes.Address) (apitypes.GlobalStateService_AccountDataStreamClient, error)
-	AccountTransactionsReceipts(address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.TransactionReceipt, uint32, error)
-	GlobalStateHash() (*apitypes.GlobalStateHash, error)
-	SmesherRewards(smesherId []byte, offset uint32, maxResults uint32) ([]*apitypes.Reward, uint32, error)
+	AccountState(ctx context.Context, address gosmtypes.Address) (*apitypes.Account, error)
+	AccountRewards(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Reward, uint32, error)
+	AccountDataStream(ctx context.Context, address gosmtypes.Address, flags apitypes.AccountDataFlag) (apitypes.GlobalStateService_AccountDataStreamClient, error)
+	GlobalStateStream(ctx context.Context) (apitypes.GlobalStateService_GlobalStateStreamClient, error)
+	AccountTransactionsReceipts(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.TransactionReceipt, uint32, error)
+	GlobalStateHash(ctx context.Context) (*apitypes.GlobalStateHash, error)
+	ServerStateHash(ctx context.Context, server string) (*apitypes.GlobalStateHash, error)
+	SmesherRewards(ctx context.Context, smesherId []byte, offset uint32, maxResults uint32) ([]*apitypes.Reward, uint32, error)
 }
 
 func (r *repl) initializeCommands() {
@@ -113,74 +268,181 @@ func (r *repl) initializeCommands() {
 		{commandStateRoot, "status", commandStateStatus, "Status commands", nil},
 		{commandStateRoot, "pos", commandStatePOS, "Proof of spacetime commands", nil},
 		{commandStateRoot, "dbg", commandStateDBG, "Debugging commands", nil},
+		{commandStateRoot, "contact", commandStateContact, "Address book commands", nil},
+		{commandStateRoot, "template", commandStateTemplate, "Transaction template commands", nil},
+		{commandStateRoot, "config", commandStateConfig, "Settings commands", nil},
+		{commandStateRoot, "verify", commandStateLeaf, "Verify a signature: verify <public key|alias|contact|address|-> <signature> [--hex <message> | --file <path> [--raw]]", r.verifySignature},
+		{commandStateRoot, "fees", commandStateLeaf, "Display gas prices suggested from recent transactions: fees [--json]", r.printFees},
+		{commandStateRoot, "gas-oracle", commandStateLeaf, "Display the gas price percentiles and histogram of the transactions in recent layers: gas-oracle [--layers <n>] [--json]", r.printGasOracle},
+		{commandStateRoot, "dashboard", commandStateLeaf, "Display the node, current account, smesher and global state on one screen, refreshed until Enter or Ctrl+C with --watch: dashboard [--watch] [--interval <seconds>]", r.printOverview},
+		{commandStateRoot, "ping", commandStateLeaf, "Measure the round trip time of node API calls: ping [count]", r.ping},
+		{commandStateRoot, "stats", commandStateLeaf, "Display the calls, errors and latency of each node API method called in this session, or clear them: stats [reset]", r.printCallStats},
 		{commandStateRoot, "quit", commandStateLeaf, "Quit app", r.quit},
 	}
+	walletFileCommands := []command{
+		{commandStateWallet, "create", commandStateLeaf, "Create a wallet: create [name] [--seed <hex> | --entropy-file <path>]", r.createWallet},
+		{commandStateWallet, "list", commandStateLeaf, "List the wallet files in the wallets directory", r.listWallets},
+		{commandStateWallet, "switch", commandStateLeaf, "Close the open wallet and open another one: switch <name>", r.switchWallet},
+		{commandStateWallet, "verify-backup", commandStateLeaf, "Verify a wallet backup file without importing it: verify-backup <path>", r.verifyWalletBackup},
+		{commandStateWallet, "import-smapp", commandStateLeaf, "Import a wallet file created by Smapp: import-smapp <file>", r.importSmappWallet},
+	}
 	accountCommands := []command{
 		// wallets
 		{commandStateWallet, "open", commandStateLeaf, "Open a wallet", r.openWallet},
-		{commandStateWallet, "create", commandStateLeaf, "Create a wallet", r.createWallet},
 	}
 	if r.clientOpen {
 		firstStageCommands = append(firstStageCommands,
-			command{commandStateRoot, "account", commandStateAccount, "Wallet's accounts commands", nil})
+			command{commandStateRoot, "account", commandStateAccount, "Wallet's accounts commands, use --account <alias> or @alias to run one against another account", nil},
+			command{commandStateRoot, "tx", commandStateTx, "Offline transaction commands", nil})
 
 		accountCommands = []command{
 			// local wallet account commands
-			{commandStateWallet, "info", commandStateLeaf, "Display wallet info", r.walletInfo},
+			{commandStateWallet, "info", commandStateLeaf, "Display wallet info: info [--json]", r.walletInfo},
 			{commandStateWallet, "mnemonic", commandStateLeaf, "Display wallet mnemonic", r.printWalletMnemonic},
+			{commandStateWallet, "verify-backup-phrase", commandStateLeaf, "Check that a written down mnemonic matches the wallet keys", r.verifyBackupPhrase},
 			{commandStateWallet, "close", commandStateLeaf, "Close current wallet", r.closeWallet},
+			{commandStateWallet, "passwd", commandStateLeaf, "Change the wallet password. Wallets are always encrypted, the new password can't be empty.", r.changeWalletPassword},
+			{commandStateWallet, "backup", commandStateLeaf, "Copy the wallet file and its checksum to a backup file: backup <path>", r.backupWallet},
+			{commandStateWallet, "merge", commandStateLeaf, "Add the accounts of another wallet file to this wallet: merge <path> [--dry-run]", r.mergeWallet},
+			{commandStateWallet, "export-smapp", commandStateLeaf, "Export the wallet to a file Smapp can open: export-smapp <file>", r.exportSmappWallet},
 
 			{commandStateAccount, "new", commandStateLeaf, "Create a new account (key pair) and set as current", r.createAccount},
-			{commandStateAccount, "set", commandStateLeaf, "Set one of the previously created accounts as current", r.chooseAccount},
+			{commandStateAccount, "recover", commandStateLeaf, "Add the account derived from a mnemonic: recover [--index <n>]", r.recoverAccount},
+			{commandStateAccount, "scan", commandStateLeaf, "Find the active accounts derived from the wallet mnemonic and offer to add them: scan <n>", r.scanAccounts},
+			{commandStateAccount, "watch", commandStateLeaf, "Add a watch-only account (address without private key): watch <alias> <address>", r.watchAccount},
+			{commandStateAccount, "list", commandStateLeaf, "Display the wallet's accounts with their balances", r.listAccounts},
+			{commandStateAccount, "set", commandStateLeaf, "Set one of the previously created accounts as current: set [--tag <tag>]", r.chooseAccount},
+			{commandStateAccount, "delete", commandStateLeaf, "Delete one of the wallet's accounts", r.deleteAccount},
+			{commandStateAccount, "rename", commandStateLeaf, "Rename an account: rename <old alias> <new alias>", r.renameAccount},
+			{commandStateAccount, "note", commandStateLeaf, "Attach a note to the current account: note <text>", r.noteAccount},
+			{commandStateAccount, "tag", commandStateLeaf, "Tag the current account: tag add|rm <tag>", r.tagAccount},
+			{commandStateAccount, "set-gas", commandStateLeaf, "Set the current account default gas price and gas limit, 0 to remove: set-gas <price> <limit>", r.setAccountGas},
+			{commandStateAccount, "multisig-new", commandStateLeaf, "Define an m-of-n account to coordinate signatures offline, the node has no such account: multisig-new <name> <m> <public key|alias>...", r.newMultisigAccount},
+			{commandStateAccount, "multisig-list", commandStateLeaf, "Display the multisig accounts", r.listMultisigAccounts},
 			{commandStateAccount, "info", commandStateLeaf, "Display the current account info", r.printAccountInfo},
-			{commandStateAccount, "rewards", commandStateLeaf, "Display all rewards awarded to the current account", r.printLocalAccountRewards},
+			{commandStateAccount, "reset-nonce", commandStateLeaf, "Release the nonces reserved by the current account's transactions", r.resetNonce},
+			{commandStateAccount, "balances", commandStateLeaf, "Display the balances of all accounts: balances [--total]", r.printBalances},
+			{commandStateAccount, "rewards", commandStateLeaf, "Display the rewards awarded to the current account, newest first: rewards [--offset <n>] [--max <n>] [--from-layer <n>] [--to-layer <n>] [--sort layer|amount] [--desc] [--by-epoch] [--total] [--csv <file>]", r.printLocalAccountRewards},
+			{commandStateAccount, "export-key", commandStateLeaf, "Display the current account private key", r.exportPrivateKey},
+			{commandStateAccount, "paper", commandStateLeaf, "Write a printable paper wallet of an account to a file: paper [alias] [--mnemonic] [--out <path>]", r.paperWallet},
 			{commandStateAccount, "sign", commandStateLeaf, "Sign a hex message with the current account private key", r.sign},
 			{commandStateAccount, "text-sign", commandStateLeaf, "Sign a text message with the current account private key", r.signText},
-			{commandStateAccount, "txs", commandStateLeaf, "Display all outgoing and incoming transactions for the current account that are on the mesh", r.printCurrAccountMeshTransactions},
-			{commandStateAccount, "send-coin", commandStateLeaf, "Transfer coins from current account to another account", r.submitCoinTransaction},
+			{commandStateAccount, "sign-file", commandStateLeaf, "Sign the sha256 digest of a file with the current account: sign-file <path> [--raw] [--out]", r.signFile},
+			{commandStateAccount, "txs", commandStateLeaf, "Display all outgoing and incoming transactions for the current account that are on the mesh: txs [--in] [--out] [--from-layer <n>] [--to-layer <n>] [--min-amount <amount>] [--sort layer|amount|nonce] [--desc] [--json <file> | --csv <file>]", r.printCurrAccountMeshTransactions},
+			{commandStateAccount, "rewards-sum", commandStateLeaf, "Display the number and total of the rewards of the current account, or the smesher with --smesher, from the start of a date to the end of another: rewards-sum <from> <to> [--smesher] [--identity <n|id>] with dates as YYYY-MM-DD, last-month, this-month or epoch:<n>", r.sumRewards},
+			{commandStateAccount, "receipts", commandStateLeaf, "Display the transaction receipts of the current account: result, gas used, fee and layer: receipts [--offset <n>] [--max <n>]", r.printCurrAccountReceipts},
+			{commandStateAccount, "activity", commandStateLeaf, "Display the transactions, receipts and rewards of the current account, newest first: activity [--page <n>] [--csv <file>]", r.printAccountActivity},
+			{commandStateAccount, "report", commandStateLeaf, "Export an accounting report of the current account with a running balance: report <from layer|date> <to layer|date> <file.csv>", r.exportAccountReport},
+			{commandStateAccount, "watch-incoming", commandStateLeaf, "Print incoming transactions to the current account as they arrive, until Enter or Ctrl+C", r.watchIncoming},
+			{commandStateAccount, "send-coin", commandStateLeaf, "Transfer coins from current account to another account: send-coin [--nonce <n>] [--dry-run] [--show-raw] [--wait [--timeout <duration>]] [--save-template <name>]", r.submitCoinTransaction},
+			{commandStateAccount, "send-batch", commandStateLeaf, "Send the payments of a CSV file of address,amount,note rows: send-batch <file> [--dry-run [--show-raw]] [--nonce <n>]", r.sendBatch},
+			{commandStateAccount, "send-multi", commandStateLeaf, "Send coins to several recipients entered one by one: send-multi [--dry-run] [--nonce <n>]", r.sendMulti},
+			{commandStateAccount, "sweep", commandStateLeaf, "Send the whole balance of the current account minus the fee: sweep <recipient> [--nonce <n>] [--dry-run] [--show-raw] [--wait [--timeout <duration>]]", r.sweepAccount},
+			{commandStateAccount, "pending", commandStateLeaf, "Display the transactions of the current account that are not processed yet", r.listPendingTransactions},
+
+			{commandStateTx, "sign", commandStateLeaf, "Sign a transaction described in a JSON file without submitting it: sign <file> [--out <path>] [--json]", r.signTransactionFile},
+			{commandStateTx, "sign-offline", commandStateLeaf, "Sign a transaction without the node and print it as hex: sign-offline <recipient> <amount> --nonce <n> [--gas-price <p>] [--gas-limit <l>]", r.signOffline},
+			{commandStateTx, "validate", commandStateLeaf, "Check and sign a transaction file without submitting it: validate <file>", r.validateTransactionFile},
+			{commandStateTx, "cosign", commandStateLeaf, "Add the current account signature to a multisig transaction: cosign <file> [--multisig <name>] [--out <path>]", r.cosignTransaction},
+			{commandStateTx, "combine", commandStateLeaf, "Combine the signatures of multisig transaction files, the result can't be broadcast: combine <file> <file>... [--out <path>]", r.combineTransaction},
+			{commandStateTx, "broadcast", commandStateLeaf, "Submit a transaction signed offline: broadcast <hex|file>", r.broadcastTransaction},
+			{commandStateTx, "sign-batch", commandStateLeaf, "Sign the payments of a CSV file of address,amount,note rows without the node: sign-batch <plan.csv> <out.json> --nonce <n> [--gas-price <p>] [--gas-limit <l>]", r.signBatch},
+			{commandStateTx, "broadcast-batch", commandStateLeaf, "Submit the transactions signed with sign-batch in order, stopping at the first rejection: broadcast-batch <file.json>", r.broadcastBatch},
+			{commandStateTx, "wait", commandStateLeaf, "Wait until a transaction is processed or rejected: wait <transaction id> [--timeout <duration>]", r.waitTransaction},
+			{commandStateTx, "replace", commandStateLeaf, "Replace a pending transaction of the current account with a higher gas price: replace <transaction id>", r.replacePendingTransaction},
+			{commandStateTx, "cancel", commandStateLeaf, "Cancel a pending transaction of the current account with a zero transfer to itself: cancel <transaction id>", r.cancelPendingTransaction},
 		}
 	}
 
 	otherCommands := []command{
 		// Misc entities status
-		{commandStateStatus, "node", commandStateLeaf, "Display node status", r.nodeInfo},
+		{commandStateStatus, "node", commandStateLeaf, "Display whether the node is synced, its sync progress and peers: node [--watch]", r.nodeInfo},
+		{commandStateStatus, "node-list", commandStateLeaf, "Display the configured node api servers, which one is active and the echo latency of each", r.listServers},
+		{commandStateStatus, "node-bench", commandStateLeaf, "Measure the latency percentiles and errors of read calls made concurrently, with the account calls for the given address or the current account: node-bench [--count <n>] [--parallel <n>] [--address <address>] [--json]", r.nodeBench},
+		{commandStateStatus, "node-connect", commandStateLeaf, "Switch to another node, which must be on the network of the current one unless --force is given. It replaces the configured servers: node-connect <host> <port> | unix:///path [--force]", r.connectNode},
+		{commandStateStatus, "peers", commandStateLeaf, "Display the number of peers the node is connected to", r.printPeers},
+		{commandStateStatus, "node-shutdown", commandStateLeaf, "Shut down the node gracefully after typing its hostname to confirm. The node must expose its admin endpoints", r.shutdownNode},
+		{commandStateStatus, "version", commandStateLeaf, "Display the node version and build next to the smrepl version and the node version it was built for", r.printVersions},
+		{commandStateStatus, "genesis", commandStateLeaf, "Display the network id, genesis time and layer parameters: genesis [--refresh] [--json]", r.printGenesis},
 		{commandStateStatus, "net", commandStateLeaf, "Display network information", r.printMeshInfo},
-		{commandStateStatus, "tx", commandStateLeaf, "Display a transaction status", r.printTransactionStatus},
+		{commandStateStatus, "refresh", commandStateLeaf, "Fetch the cached network parameters, node status and account states again, warning when the node moved to another network", r.refreshNode},
+		{commandStateStatus, "stream-node", commandStateLeaf, "Print a line whenever the node sync state, peer count, top or verified layer changes, until Enter or Ctrl+C", r.streamNodeStatus},
+		{commandStateStatus, "stream-layers", commandStateLeaf, "Print the layers as the node produces them with their status and number of transactions, until Enter or Ctrl+C", r.streamLayers},
+		{commandStateStatus, "layer", commandStateLeaf, "Display the current layer and epoch, the layers left in the epoch and the layer boundary times", r.printLayerStatus},
+		{commandStateStatus, "tx", commandStateLeaf, "Display a transaction status and content: tx [transaction id]", r.printTransactionStatus},
 
 		// global state
-		{commandStateState, "account", commandStateLeaf, "Display an account balance and nonce", r.printAccountState},
+		{commandStateState, "account", commandStateLeaf, "Display an account balance and nonce: account [<address>] [--at-layer <n>], past layers are derived from rewards and transactions", r.printAccountState},
 
 		// Note this hack - we currently use the MeshService to display transactions because transaction receipts api is not implemented yet in node
-		{commandStateState, "account-txs", commandStateLeaf, "Display account transactions in global state", r.printMeshTransactions},
-		{commandStateState, "rewards", commandStateLeaf, "Display an account rewards ", r.printAccountRewards},
+		{commandStateState, "account-txs", commandStateLeaf, "Display the mesh transactions of any address or contact: account-txs [address|contact] [--in] [--out] [--from-layer <n>] [--to-layer <n>] [--min-amount <amount>] [--sort layer|amount|nonce] [--desc]", r.printMeshTransactions},
+		{commandStateState, "receipts", commandStateLeaf, "Display the transaction receipts of an account: receipts [address] [--offset <n>] [--max <n>]", r.printAccountReceipts},
+		{commandStateState, "activations", commandStateLeaf, "Display the activations with an address or the current account as coinbase: activations [address|contact]", r.printActivations},
+		{commandStateState, "rewards", commandStateLeaf, "Display an account rewards, newest first: rewards [--offset <n>] [--max <n>] [--from-layer <n>] [--to-layer <n>] [--sort layer|amount] [--desc] [--by-epoch] [--total] [--csv <file>]", r.printAccountRewards},
 
 		// global state streams
+		{commandStateState, "stream", commandStateLeaf, "Stream the rewards, transaction receipts and updates of an account in one subscription, a tagged line per event: stream <address> [--rewards] [--receipts] [--updates], all of them by default", r.printAccountDataStream},
 		{commandStateState, "stream-rewards", commandStateLeaf, "Stream new rewards for an account", r.printAccountRewardsStream},
 		{commandStateState, "stream-account", commandStateLeaf, "Stream account updates", r.printAccountUpdatesStream},
+		{commandStateState, "watch", commandStateLeaf, "Print the changes of the balance and nonce of any address until Enter or Ctrl+C, with alerts: watch <address> [--poll <seconds>] [--alert-below <amount>] [--alert-on-increase]", r.watchBalance},
+		{commandStateState, "stream-global", commandStateLeaf, "Print the global state hash of every layer the node applies with the time since the previous one, until Enter or Ctrl+C: stream-global [--json]", r.streamGlobalState},
 
-		{commandStateState, "smesher-rewards", commandStateLeaf, "Display smesher rewards", r.printSmesherRewards},
-		{commandStateState, "global", commandStateLeaf, "Display the most recent network global state", r.printGlobalState},
+		{commandStateState, "smesher-rewards", commandStateLeaf, "Display smesher rewards, newest first: smesher-rewards [<smesher id or name> | @current] [--offset <n>] [--max <n>] [--from-layer <n>] [--to-layer <n>] [--sort layer|amount] [--desc] [--by-epoch] [--total] [--csv <file>]", r.printSmesherRewards},
+		{commandStateState, "diff", commandStateLeaf, "Compare an account's state now and after Enter or a wait, itemizing the rewards and transactions in between and flagging what they don't explain: diff <address> [--wait <duration>]", r.diffAccountState},
+		{commandStateState, "top", commandStateLeaf, "Display the accounts with the largest balances and their share of the supply, with the current account's rank: top [<n>] [--refresh]. The accounts are scanned once per session unless --refresh is given. Requires the node to expose the debug services", r.printTopAccounts},
+		{commandStateState, "global", commandStateLeaf, "Display the most recent network global state, or the hashes seen this session with global --history [--last <n>], cleared with global --clear-history", r.printGlobalState},
+		{commandStateState, "compare", commandStateLeaf, "Compare the global state hash of the connected node with other nodes to detect a forked or corrupted node: compare <host:port> [<host:port>...]", r.compareState},
 
 		// smesher ops
-		{commandStateSmesher, "id", commandStateLeaf, "Display current smesher id", r.printSmesherId},
+		{commandStateSmesher, "id", commandStateLeaf, "Display current smesher id, and save it in the address book with --save <name>", r.printSmesherId},
+		{commandStateSmesher, "list", commandStateLeaf, "List the identities the node smeshes with, which start, stop, status and rewards select with --identity <number or id prefix> when there are several", r.listSmeshers},
 		{commandStateSmesher, "rewards-address", commandStateLeaf, "Display current smesher rewards address", r.printRewardsAddress},
 		{commandStateSmesher, "set-rewards-address", commandStateLeaf, "Set the smesher's rewards address", r.setRewardsAddress},
+		{commandStateSmesher, "claim-rewards", commandStateLeaf, "Send the smesher's rewards to the current account", r.claimRewards},
 
-		{commandStateSmesher, "rewards", commandStateLeaf, "Display current smesher rewards", r.printCurrentSmesherRewards},
-		{commandStateSmesher, "stop", commandStateLeaf, "Stop smeshing", r.stopSmeshing},
+		{commandStateSmesher, "rewards", commandStateLeaf, "Display current smesher rewards, newest first: rewards [--offset <n>] [--max <n>] [--from-layer <n>] [--to-layer <n>] [--sort layer|amount] [--desc] [--by-epoch] [--total] [--csv <file>]", r.printCurrentSmesherRewards},
+		{commandStateSmesher, "stop", commandStateLeaf, "Stop smeshing, optionally deleting the PoST data after typing a confirmation phrase: stop [--data-dir <dir>]", r.stopSmeshing},
 		{commandStateSmesher, "status", commandStateLeaf, "Display smesher status", r.printSmeshingStatus},
-		{commandStateSmesher, "post-status", commandStateLeaf, "Display the proof of space status", r.printPostStatus},
+		{commandStateSmesher, "post-status", commandStateLeaf, "Display the proof of space status with the creation progress and time left: post-status [--size <size>] [--data-dir <dir>]", r.printPostStatus},
+		{commandStateSmesher, "watch", commandStateLeaf, "Show the smesher state, rewards and layer on one screen, refreshed until Enter or Ctrl+C: watch [--interval <seconds>]", r.watchSmesher},
+		{commandStateSmesher, "post-status-stream", commandStateLeaf, "Print the proof of space data creation progress with its throughput until it completes, Enter or Ctrl+C: post-status-stream [--size <GiB>]", r.streamPostStatus},
+		{commandStateSmesher, "verify-post", commandStateLeaf, "Check the PoST data files of a directory against their metadata and report PASS or FAIL: verify-post [--data-dir <dir>]", r.verifyPost},
+		{commandStateSmesher, "post-config", commandStateLeaf, "Display the proof of space parameters the node reports for smesher start", r.printPostConfig},
 		{commandStateSmesher, "post-providers", commandStateLeaf, "Display the available proof of space providers", r.printPostProviders},
-		{commandStateSmesher, "start", commandStateLeaf, "Start smeshing using the current wallet account as the rewards account", r.startSmeshing},
+		{commandStateSmesher, "bench-providers", commandStateLeaf, "Compare the speed of the proof of space providers and recommend one", r.benchPostProviders},
+		{commandStateSmesher, "estimate", commandStateLeaf, "Estimate the rewards per epoch and month of a planned space, or of the PoST data of the node: estimate [<space>] [--network-space <size>] [--layer-reward <amount>]", r.estimateSmeshingRewards},
+		{commandStateSmesher, "start", commandStateLeaf, "Set up and start smeshing: start [--force] to start even when the disk of the data directory looks too small", r.startSmeshing},
+
+		// address book
+		{commandStateContact, "add", commandStateLeaf, "Add an address to the address book: add <name> <address>", r.addContact},
+		{commandStateContact, "list", commandStateLeaf, "Display the address book, or the saved smesher ids with --smeshers", r.listContacts},
+		{commandStateContact, "delete", commandStateLeaf, "Delete an address book entry: delete <name> [--smesher] to delete a saved smesher id", r.deleteContact},
+
+		// transaction templates
+		{commandStateTemplate, "save", commandStateLeaf, "Save the last transaction sent this session as a template: save <name> [note]", r.saveLastSentTemplate},
+		{commandStateTemplate, "use", commandStateLeaf, "Send the transaction of a template from the current account: use <name> [--nonce <n>] [--dry-run] [--wait [--timeout <duration>]]", r.useTemplate},
+		{commandStateTemplate, "list", commandStateLeaf, "Display the transaction templates", r.listTemplates},
+		{commandStateTemplate, "delete", commandStateLeaf, "Delete a transaction template: delete <name>", r.deleteTemplate},
+
+		// settings
+		{commandStateConfig, "set", commandStateLeaf, "Change a setting: set <key> <value>", r.setConfig},
+		{commandStateConfig, "get", commandStateLeaf, "Display a setting: get <key>", r.getConfig},
+		{commandStateConfig, "list", commandStateLeaf, "Display all settings", r.listConfig},
 
 		// debug commands
-		{commandStateDBG, "all-accounts", commandStateLeaf, "Display all global state accounts", r.printAllAccounts},
+		{commandStateDBG, "all-accounts", commandStateLeaf, "Display the global state accounts, largest balance first: all-accounts [--sort balance|address] [--desc] [--min-balance <amount>] [--top <n>] [--page <n>], or all-accounts --all --raw for every account in node order", r.printAllAccounts},
+		{commandStateDBG, "export-accounts", commandStateLeaf, "Export the address, balance and nonce of every global state account with the global state before and after to a JSON file: export-accounts <file.json>", r.exportAllAccounts},
+		{commandStateDBG, "grpc", commandStateLeaf, "Log the node API calls with their requests, response sizes, status and latency to log.txt, or stop: grpc [on|off]", r.debugGRPC},
+		{commandStateDBG, "stream-errors", commandStateLeaf, "Print the errors the node logs until Enter or Ctrl+C is pressed. Requires the node to expose the debug services", r.streamErrors},
 	}
+	accountCommands = append(accountCommands, walletFileCommands...)
 	r.commands = append(firstStageCommands, append(accountCommands, otherCommands...)...)
 }
 
-// Start starts the REPL
-func Start(c Client) {
+// Start starts the REPL, which reads the commands from the terminal and prints to the standard
+// output unless opts say otherwise. With an input given with WithInput it returns when the input
+// ends.
+func Start(c Client, opts ...Option) {
 
 	// init logging system
 	path, err := os.Getwd()
@@ -193,29 +455,36 @@ func Start(c Client) {
 
 	log.Info("new session started")
 
-	if !TestMode {
-		r := &repl{client: c}
-		r.clientOpen = c.IsOpen()
-		r.initializeCommands()
-		runPrompt(r.executor, r.completer, r.firstTime, uint16(len(r.commands)))
-	} else {
-		// holds for unit test purposes
-		hold := make(chan bool)
-		<-hold
+	r := newRepl(c, opts...)
+	if r.in == nil {
+		r.terminal = &terminal{ConsoleParser: prompt.NewStandardInputParser()}
 	}
+	stopSignals := r.notifySignals()
+	defer stopSignals()
+	go r.probeNode()
+	if r.in == nil {
+		r.runPrompt()
+		return
+	}
+	r.runScript()
+	r.stopBackgroundStreams()
+	_ = r.client.Close()
 }
 
 func (r *repl) executor(text string) {
+	defer r.recoverCommand(text)
 	// All commands currently follows a format of `FirstStageCommand SecondStageCommand ...`
-	textSlice := strings.Split(text, " ")
+	textSlice := strings.Fields(text)
 	parseState := commandStateRoot
-	for _, s := range textSlice {
+	for i, s := range textSlice {
 		for _, c := range r.commands {
 			if parseState == c.parent && s == c.text {
 				if c.state == commandStateLeaf {
 					r.input = text
+					r.accountOverride, r.args = accountArg(textSlice[i+1:])
 					//log.Debug(userExecutingCommandMsg, c.text)
-					c.fn()
+					r.run(c.fn)
+					r.accountOverride = ""
 					return
 				} else {
 					parseState = c.state
@@ -224,7 +493,48 @@ func (r *repl) executor(text string) {
 		}
 	}
 
-	fmt.Println(printPrefix, "invalid command.")
+	fmt.Fprintln(r.out, printPrefix, "invalid command.")
+}
+
+// recoverCommand reports a command which panicked and returns to the prompt instead of exiting.
+// The stack trace goes to the log file for the bug report.
+func (r *repl) recoverCommand(text string) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	r.accountOverride = ""
+	if p == errInputEnded {
+		fmt.Fprintln(r.out, printPrefix, "The input ended before the command got its answers.")
+		return
+	}
+	log.Error("command %q panicked: %v\n%s", text, p, debug.Stack())
+	fmt.Fprintln(r.out, printPrefix, colorRed+fmt.Sprintf("The command failed because of a bug: %v", p)+colorReset)
+	fmt.Fprintln(r.out, printPrefix, "Please report it at https://github.com/spacemeshos/smrepl/issues with the command and the stack trace in log.txt.")
+}
+
+// run executes a command with its own context, which Ctrl+C cancels while the command runs and
+// which reports the retries of the transactions the command submits
+func (r *repl) run(fn func()) {
+	ctx, cancel := context.WithCancel(r.session)
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	r.ctx = common.WithSubmitProgress(ctx, r.printSubmitProgress)
+	r.client.ApplyConfig()
+	defer func() {
+		signal.Stop(interrupt)
+		cancel()
+		r.ctx = context.Background()
+	}()
+	fn()
+	r.checkWalletConflict()
 }
 
 func (r *repl) completer(in prompt.Document) []prompt.Suggest {
@@ -264,19 +574,57 @@ func (r *repl) commandLineParams(idx int, input string) string {
 	return strings.TrimSpace(params)
 }
 
+// firstTime prints the splash and starts checking the node. The commands which don't need the
+// node, such as creating wallets and signing offline, work whether or not it can be reached.
 func (r *repl) firstTime() {
-	fmt.Print(printPrefix, splash)
+	if !r.noSplash {
+		fmt.Fprint(r.out, printPrefix, splash)
+	}
+
+	fmt.Fprintln(r.out, "Welcome to Spacemesh. Connecting to api server at", r.client.ServerInfo(), "...")
+	go r.reportConnection()
+}
 
-	_, err := r.client.GetMeshInfo()
+// reportConnection checks the node in the background at startup, so the wallet can be used while
+// it connects, and prints whether it answered. It isn't cancelled by the commands run meanwhile.
+func (r *repl) reportConnection() {
+	ctx := context.Background()
+	info, err := r.checkNode(ctx)
+	fmt.Fprintln(r.out)
 	if err != nil {
-		log.Error("Failed to connect to mesh service at %v: %v", r.client.ServerInfo(), err)
-		r.quit()
+		log.Error("Failed to connect to the node at %v: %v", r.client.ServerInfo(), err)
+		fmt.Fprintln(r.out, printPrefix, "Commands which call the node fail until it is reachable.")
+		return
 	}
 
-	fmt.Println("Welcome to Spacemesh. Connected to api server at", r.client.ServerInfo())
-	r.printMeshInfo()
+	fmt.Fprintln(r.out, printPrefix, "Connected to api server at", r.client.ServerInfo())
+	r.checkNodeVersion(ctx)
+	if info == nil {
+		return
+	}
+	r.printNetInfo(info)
+	fmt.Fprintln(r.out, printPrefix, layerSummary(info))
+}
+
+// livePrefix returns the prompt prefix which includes the name of the open wallet, the node
+// switched to with status node-connect and whether the node is online
+func (r *repl) livePrefix() (string, bool) {
+	p := r.prefix
+	if r.clientOpen {
+		p = r.client.WalletName() + " " + p
+	}
+	if tag := r.connectionTag(); tag != "" {
+		p = "[" + tag + "] " + p
+	}
+	if r.connectedTo != "" {
+		p = "[" + r.connectedTo + "] " + p
+	}
+	return p, p != r.prefix
 }
 
+// quit stops the background streams and closes the connection to the node before exiting
 func (r *repl) quit() {
+	r.stopBackgroundStreams()
+	_ = r.client.Close()
 	os.Exit(0)
 }
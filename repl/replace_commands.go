@@ -0,0 +1,147 @@
+package repl
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// replacementGasPrice suggests the gas price of a transaction replacing one with gas price original:
+// the current normal fee estimate when it is higher, otherwise original + 1
+func replacementGasPrice(original, estimate uint64) uint64 {
+	if estimate > original {
+		return estimate
+	}
+	return original + 1
+}
+
+// replaceableTransaction looks up a transaction of the current account which hasn't been applied
+func (r *repl) replaceableTransaction(idArg string) (*apitypes.Transaction, error) {
+	id, err := parseTxID(idArg)
+	if err != nil {
+		return nil, err
+	}
+	state, tx, err := r.client.TransactionState(r.ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil || tx.GetCoinTransfer() == nil {
+		return nil, fmt.Errorf("the node doesn't know the coin transaction 0x%x", id)
+	}
+	if state != nil {
+		switch state.State {
+		case apitypes.TransactionState_TRANSACTION_STATE_PROCESSED:
+			return nil, fmt.Errorf("transaction 0x%x has already been applied and can't be replaced", id)
+		case apitypes.TransactionState_TRANSACTION_STATE_MESH:
+			return nil, fmt.Errorf("transaction 0x%x is already in a layer and will be applied", id)
+		}
+	}
+	acc, err := r.getCurrent()
+	if err != nil {
+		return nil, err
+	}
+	if address := acc.Address(); !bytes.Equal(tx.GetSender().GetAddress(), address.Bytes()) {
+		return nil, fmt.Errorf("transaction 0x%x wasn't sent from the current account %s", id, r.formatAddress(address))
+	}
+	return tx, nil
+}
+
+// replaceTransaction submits a transaction with the nonce of a pending one. Cancelling sends 0 to
+// the sender instead of the original transfer.
+func (r *repl) replaceTransaction(cancel bool) {
+	usage := "usage: tx replace <transaction id>"
+	if cancel {
+		usage = "usage: tx cancel <transaction id>"
+	}
+	if len(r.args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, usage)
+		return
+	}
+	orig, err := r.replaceableTransaction(r.args[0])
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	key, err := acc.SigningKey()
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	defer key.Release()
+
+	origPrice, gasLimit := uint64(0), uint64(0)
+	if orig.GasOffered != nil {
+		origPrice, gasLimit = orig.GasOffered.GasPrice, orig.GasOffered.GasProvided
+	}
+	recipient := gosmtypes.BytesToAddress(orig.GetCoinTransfer().GetReceiver().GetAddress())
+	amount := uint64(0)
+	if orig.Amount != nil {
+		amount = orig.Amount.Value
+	}
+	if cancel {
+		recipient, amount = acc.Address(), 0
+	}
+
+	estimate := uint64(0)
+	if suggested, ok := r.suggestedGasPrice(); ok {
+		estimate = suggested.value
+	}
+	gasPrice := replacementGasPrice(origPrice, estimate)
+	input := strings.TrimSpace(r.inputNotBlank(fmt.Sprintf(replaceGasPriceMsg, origPrice, gasPrice)))
+	if !strings.EqualFold(input, "y") {
+		if gasPrice, err = strconv.ParseUint(input, 10, 64); err != nil {
+			fmt.Fprintln(r.out, printPrefix, "invalid gas price:", input)
+			return
+		}
+	}
+	if gasPrice <= origPrice {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("The gas price must be higher than the original %d to replace it.", origPrice))
+		return
+	}
+
+	fmt.Fprintln(r.out, printPrefix, "Replacement transaction summary:")
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Replaces: 0x%x", orig.Id.Id))
+	fmt.Fprintln(r.out, printPrefix, "To:    ", r.addressString(recipient))
+	fmt.Fprintln(r.out, printPrefix, "Amount:", common.FormatAmount(amount))
+	fmt.Fprintln(r.out, printPrefix, "Gas price:", gasPrice, coinUnitName, fmt.Sprintf("(was %d)", origPrice))
+	fmt.Fprintln(r.out, printPrefix, "Gas limit:", gasLimit)
+	fmt.Fprintln(r.out, printPrefix, "Nonce: ", orig.Counter, "(of the original)")
+	fmt.Fprintln(r.out, printPrefix, "WARNING: both transactions have the same nonce, so only one of them can ever apply.")
+
+	if !r.confirmSpendLimit(amount) {
+		return
+	}
+	if r.yesOrNoQuestion(confirmTransactionMsg) != "y" {
+		return
+	}
+	txState, err := r.client.Transfer(r.ctx, recipient, orig.Counter, amount, gasPrice, gasLimit, key)
+	if err != nil {
+		r.printNodeError(err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Transaction submitted.")
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Transaction id: 0x%x", txState.Id.Id))
+	fmt.Fprintln(r.out, printPrefix, "Transaction state:", transactionStateDisStringsMap[int32(txState.State.Number())])
+}
+
+// replacePendingTransaction replaces a pending transaction with one paying a higher gas price
+func (r *repl) replacePendingTransaction() {
+	r.replaceTransaction(false)
+}
+
+// cancelPendingTransaction replaces a pending transaction with a zero transfer to the sender
+func (r *repl) cancelPendingTransaction() {
+	r.replaceTransaction(true)
+}
@@ -0,0 +1,215 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// smesherRewardSource is the part of Client that smesher reward listings read from
+type smesherRewardSource interface {
+	SmesherRewards(ctx context.Context, smesherId []byte, offset uint32, maxResults uint32) ([]*apitypes.Reward, uint32, error)
+}
+
+// allRewardPages pages through rewards until the node returns an empty page, so listings aren't cut
+// at the node's page size limit
+func allRewardPages(page func(offset, maxResults uint32) ([]*apitypes.Reward, uint32, error)) ([]*apitypes.Reward, error) {
+	var res []*apitypes.Reward
+	for offset := uint32(0); ; offset += exportPageSize {
+		rewards, _, err := page(offset, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(rewards) == 0 {
+			return res, nil
+		}
+		res = append(res, rewards...)
+	}
+}
+
+// allSmesherRewards pages through the rewards of a smesher
+func allSmesherRewards(ctx context.Context, src smesherRewardSource, smesherId []byte) ([]*apitypes.Reward, error) {
+	return allRewardPages(func(offset, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+		return src.SmesherRewards(ctx, smesherId, offset, maxResults)
+	})
+}
+
+// updateRewardTally sums the rewards of a smesher which were awarded since the tally was last
+// updated. When the node reports fewer rewards than were summed, such as after switching to another
+// node, the rewards are summed again from the first one.
+func updateRewardTally(ctx context.Context, src smesherRewardSource, smesherId []byte, tally *common.RewardTally, layersPerEpoch uint64) error {
+	for {
+		page, total, err := src.SmesherRewards(ctx, smesherId, tally.Count, exportPageSize)
+		if err != nil {
+			return err
+		}
+		if total < tally.Count {
+			tally.Reset()
+			continue
+		}
+		if len(page) == 0 {
+			return nil
+		}
+		for _, reward := range page {
+			epoch := uint64(0)
+			if layersPerEpoch > 0 {
+				epoch = uint64(reward.GetLayer().GetNumber()) / layersPerEpoch
+			}
+			tally.Add(epoch, reward.GetTotal().GetValue())
+		}
+	}
+}
+
+// rewardRecords converts rewards to export records. The fees are the part of the total which isn't
+// the layer reward. info may be nil, in which case times are left out.
+func rewardRecords(rewards []*apitypes.Reward, info *common.NetInfo) []common.RewardRecord {
+	records := make([]common.RewardRecord, 0, len(rewards))
+	for _, reward := range rewards {
+		record := common.RewardRecord{
+			Layer:       reward.GetLayer().GetNumber(),
+			LayerReward: reward.GetLayerReward().GetValue(),
+			Total:       reward.GetTotal().GetValue(),
+			Coinbase:    gosmtypes.BytesToAddress(reward.GetCoinbase().GetAddress()).String(),
+		}
+		if record.Total > record.LayerReward {
+			record.Fees = record.Total - record.LayerReward
+		}
+		if info != nil {
+			record.Time = info.LayerTime(record.Layer).UTC().Format(time.RFC3339)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// printSmesherRewardList prints the rewards awarded to a smesher as printRewardPages does
+func (r *repl) printSmesherRewardList(smesherId []byte) {
+	r.printRewardPages(func(offset, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+		return r.client.SmesherRewards(r.ctx, smesherId, offset, maxResults)
+	})
+}
+
+// rewardSummaryFlags need all the rewards rather than a page of them
+var rewardSummaryFlags = []string{"--from-layer", "--to-layer", "--by-epoch", "--total", "--csv"}
+
+// printRewardPages prints the page of rewards given with --offset and --max. With a flag of
+// rewardSummaryFlags it fetches all the rewards for printRewardQuery instead.
+func (r *repl) printRewardPages(fetch func(offset, maxResults uint32) ([]*apitypes.Reward, uint32, error)) {
+	for _, flag := range rewardSummaryFlags {
+		if hasFlag(r.args, flag) {
+			all, err := allRewardPages(fetch)
+			if err != nil {
+				r.printNodeError(common.CallError("get rewards", err))
+				return
+			}
+			r.printRewardQuery(all)
+			return
+		}
+	}
+	p, err := pageArgs(r.args)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	rewards, total, err := fetch(p.offset, p.max)
+	if err != nil && !p.outOfRange(err) {
+		r.printNodeError(common.CallError("get rewards", err))
+		return
+	}
+	r.printRewardList(rewards, total)
+	fmt.Fprintln(r.out, printPrefix, p.footer(len(rewards), total))
+}
+
+// printRewardQuery prints the rewards in the layer range given with --from-layer and --to-layer.
+// With --by-epoch or --total it prints their sums instead, and with --csv <file> it exports them.
+func (r *repl) printRewardQuery(all []*apitypes.Reward) {
+	from, to, err := layerRangeArgs(r.args)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	path, export := flagValue(r.args, "--csv")
+	var info *common.NetInfo
+	if export {
+		if info, err = r.client.GetMeshInfo(r.ctx); err != nil {
+			fmt.Fprintln(r.out, printPrefix, "Can't get the layer times, they are left out:", err)
+			info = nil
+		}
+	}
+	var rewards []*apitypes.Reward
+	var records []common.RewardRecord
+	for i, record := range rewardRecords(all, info) {
+		if record.InLayerRange(from, to) {
+			rewards = append(rewards, all[i])
+			records = append(records, record)
+		}
+	}
+
+	if export {
+		f, err := os.Create(path)
+		if err != nil {
+			log.Error("failed to create export file: %v", err)
+			return
+		}
+		err = common.WriteRewardRecordsCSV(f, records)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			log.Error("failed to write export file: %v", err)
+			return
+		}
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Exported %d rewards to: %s", len(records), path))
+		return
+	}
+	if hasFlag(r.args, "--by-epoch") {
+		r.printRewardsByEpoch(rewards)
+		return
+	}
+	if hasFlag(r.args, "--total") {
+		totals := common.SumRewards(records)
+		fmt.Fprintln(r.out, printPrefix, "Rewards:", totals.Count)
+		fmt.Fprintln(r.out, printPrefix, "Layer rewards:", common.FormatAmount(totals.LayerReward))
+		fmt.Fprintln(r.out, printPrefix, "Transaction fees:", common.FormatAmount(totals.Fees))
+		fmt.Fprintln(r.out, printPrefix, "Total:", common.FormatAmount(totals.Total))
+		return
+	}
+	r.printRewardList(rewards, uint32(len(rewards)))
+}
+
+// epochBarWidth is the width of the bars of epoch reward sums
+const epochBarWidth = 20
+
+// printRewardsByEpoch prints one row per epoch with the number and sum of the rewards awarded in
+// it, its dates and a bar of its sum relative to the largest one
+func (r *repl) printRewardsByEpoch(rewards []*apitypes.Reward) {
+	params, err := r.client.NetworkParams(r.ctx, false)
+	if err != nil {
+		r.printNodeError(common.CallError("get the epoch parameters", err))
+		return
+	}
+	epochs := common.GroupRewardsByEpoch(rewardRecords(rewards, nil), params.LayerPerEpoch)
+	if len(epochs) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "No rewards")
+		return
+	}
+	largest := uint64(0)
+	for _, e := range epochs {
+		if e.Total > largest {
+			largest = e.Total
+		}
+	}
+	for _, e := range epochs {
+		start := params.LayerTime(uint32(e.Epoch * params.LayerPerEpoch))
+		end := params.LayerTime(uint32((e.Epoch + 1) * params.LayerPerEpoch))
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Epoch %d  %s to %s  %d rewards  %s  %s", e.Epoch,
+			start.Format(layerTimeFormat), end.Format(layerTimeFormat), e.Count, common.FormatAmount(e.Total),
+			common.RewardBar(e.Total, largest, epochBarWidth)))
+	}
+}
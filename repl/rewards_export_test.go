@@ -0,0 +1,80 @@
+package repl
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// fakeSmesherRewards serves smesher rewards in pages the way the node does
+type fakeSmesherRewards struct {
+	rewards []*apitypes.Reward
+	offsets []uint32
+}
+
+func (f *fakeSmesherRewards) SmesherRewards(ctx context.Context, smesherId []byte, offset uint32, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+	f.offsets = append(f.offsets, offset)
+	if int(offset) >= len(f.rewards) {
+		return nil, uint32(len(f.rewards)), nil
+	}
+	end := int(offset + maxResults)
+	if end > len(f.rewards) {
+		end = len(f.rewards)
+	}
+	return f.rewards[offset:end], uint32(len(f.rewards)), nil
+}
+
+func TestAllSmesherRewardsPages(t *testing.T) {
+	src := &fakeSmesherRewards{}
+	for i := 0; i < 10*exportPageSize+5; i++ {
+		src.rewards = append(src.rewards, &apitypes.Reward{
+			Layer:       &apitypes.LayerNumber{Number: uint32(i)},
+			LayerReward: &apitypes.Amount{Value: 50},
+			Total:       &apitypes.Amount{Value: 60},
+		})
+	}
+	rewards, err := allSmesherRewards(context.Background(), src, []byte{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rewards) != len(src.rewards) {
+		t.Fatalf("expected %d rewards, got %d", len(src.rewards), len(rewards))
+	}
+	records := rewardRecords(rewards[:1], nil)
+	if records[0].Fees != 10 || records[0].Time != "" {
+		t.Fatalf("unexpected record %+v", records[0])
+	}
+}
+
+func TestUpdateRewardTally(t *testing.T) {
+	reward := func(layer uint32) *apitypes.Reward {
+		return &apitypes.Reward{Layer: &apitypes.LayerNumber{Number: layer}, Total: &apitypes.Amount{Value: 10}}
+	}
+	src := &fakeSmesherRewards{rewards: []*apitypes.Reward{reward(1), reward(5)}}
+	var tally common.RewardTally
+	if err := updateRewardTally(context.Background(), src, []byte{1}, &tally, 4); err != nil {
+		t.Fatal(err)
+	}
+	src.rewards = append(src.rewards, reward(6))
+	src.offsets = nil
+	if err := updateRewardTally(context.Background(), src, []byte{1}, &tally, 4); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(src.offsets, []uint32{2, 3}) {
+		t.Fatalf("expected only the new rewards to be fetched, got offsets %v", src.offsets)
+	}
+	if tally.Count != 3 || tally.Total != 30 || tally.EpochTotal(1) != 20 {
+		t.Fatalf("unexpected tally %+v", tally)
+	}
+
+	src.rewards = src.rewards[:1]
+	if err := updateRewardTally(context.Background(), src, []byte{1}, &tally, 4); err != nil {
+		t.Fatal(err)
+	}
+	if tally.Count != 1 || tally.Total != 10 {
+		t.Fatalf("expected the tally to be summed again, got %+v", tally)
+	}
+}
@@ -0,0 +1,73 @@
+package repl
+
+import (
+	"fmt"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// sumRewards prints the number and total of the rewards of the current account, or of the smesher
+// with --smesher, awarded from the start of a date to the end of another. Dates are days
+// (YYYY-MM-DD), last-month, this-month or epochs (epoch:N), converted to layers with the genesis
+// time and layer duration.
+func (r *repl) sumRewards() {
+	args := positionalArgs(r.args, identityFlag)
+	if len(args) != 2 {
+		fmt.Fprintln(r.out, printPrefix, "usage: rewards-sum <from> <to> [--smesher] with dates as YYYY-MM-DD, last-month, this-month or epoch:<n>")
+		return
+	}
+	params, err := r.client.NetworkParams(r.ctx, false)
+	if err != nil {
+		r.printNodeError(common.CallError("get network parameters", err))
+		return
+	}
+	first, last, err := params.DateRangeLayers(args[0], args[1], time.Now())
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+
+	var page func(offset, maxResults uint32) ([]*apitypes.Reward, uint32, error)
+	var whose string
+	if hasFlag(r.args, "--smesher") {
+		id, ok := r.selectSmesher()
+		if !ok {
+			return
+		}
+		whose = "smesher " + r.formatSmesherId(id)
+		page = func(offset, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+			return r.client.SmesherRewards(r.ctx, id, offset, maxResults)
+		}
+	} else {
+		acc, err := r.getCurrent()
+		if err != nil {
+			log.Error("failed to get account", err)
+			return
+		}
+		whose = r.addressString(acc.Address())
+		page = func(offset, maxResults uint32) ([]*apitypes.Reward, uint32, error) {
+			return r.client.AccountRewards(r.ctx, acc.Address(), offset, maxResults)
+		}
+	}
+	rewards, err := allRewardPages(page)
+	if err != nil {
+		r.printNodeError(common.CallError("get rewards", err))
+		return
+	}
+	var records []common.RewardRecord
+	for _, record := range rewardRecords(rewards, nil) {
+		if record.InLayerRange(&first, &last) {
+			records = append(records, record)
+		}
+	}
+
+	totals := common.SumRewards(records)
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Rewards of %s from layer %d (%s) to layer %d (until %s):", whose,
+		first, params.LayerTime(first).Local().Format(layerTimeFormat), last, params.LayerTime(last+1).Local().Format(layerTimeFormat)))
+	fmt.Fprintln(r.out, printPrefix, "Count:", totals.Count)
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Total: %s (%d Smidge)", common.FormatAmount(totals.Total), totals.Total))
+}
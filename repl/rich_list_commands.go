@@ -0,0 +1,85 @@
+package repl
+
+import (
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// defaultTopAccounts is the number of accounts state top lists without an argument
+const defaultTopAccounts = 20
+
+// rankAccounts returns the global state accounts ranked by balance. The scan reads every account,
+// so it's kept for the session and done again only when refresh is set.
+func (r *repl) rankAccounts(refresh bool) (*common.RichList, error) {
+	if r.richList != nil && !refresh {
+		return r.richList, nil
+	}
+	state, err := r.snapshotState()
+	if err != nil {
+		return nil, common.CallError("get global state", err)
+	}
+	accounts, err := r.client.DebugAllAccounts(r.ctx)
+	if err != nil {
+		return nil, common.CallError("get all accounts", err)
+	}
+	snapshot := make([]common.SnapshotAccount, 0, len(accounts))
+	for _, a := range accounts {
+		snapshot = append(snapshot, common.SnapshotAccount{
+			Address: gosmtypes.BytesToAddress(a.GetAccountId().GetAddress()).String(),
+			Balance: currentBalance(a),
+			Counter: a.GetStateCurrent().GetCounter(),
+		})
+	}
+	r.richList = common.NewRichList(snapshot, state, time.Now())
+	return r.richList, nil
+}
+
+// printTopAccounts prints the accounts with the largest balances with their rank, address book
+// name, balance and share of the supply, and the rank of the current account when it isn't among
+// them. The accounts scanned by a previous run in the session are reused unless --refresh is given.
+func (r *repl) printTopAccounts() {
+	n := defaultTopAccounts
+	if args := positionalArgs(r.args); len(args) == 1 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+			fmt.Fprintln(r.out, printPrefix, "invalid number of accounts:", args[0])
+			return
+		}
+	}
+	list, err := r.rankAccounts(hasFlag(r.args, "--refresh"))
+	if err != nil {
+		r.printNodeError(err)
+		return
+	}
+	names := make(map[string]string)
+	if contacts, err := r.client.Contacts(); err == nil {
+		for _, c := range contacts {
+			names[gosmtypes.HexToAddress(c.Address).String()] = c.Name
+		}
+	}
+
+	tw := tabwriter.NewWriter(r.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, printPrefix+"\tRank\tAddress\tName\tBalance\tShare")
+	row := func(rank int, a common.SnapshotAccount) {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%.4f%%\n", printPrefix, rank, r.formatAddress(gosmtypes.HexToAddress(a.Address)),
+			names[a.Address], common.FormatAmount(a.Balance), list.Share(a.Balance))
+	}
+	for i, a := range list.Top(n) {
+		row(i+1, a)
+	}
+	if acc, err := r.client.CurrentAccount(); err == nil {
+		if rank, ok := list.Rank(acc.Address().String()); ok && rank > n {
+			fmt.Fprintln(tw, printPrefix+"\t...")
+			row(rank, list.Accounts[rank-1])
+		}
+	}
+	tw.Flush()
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%d accounts, supply %s, scanned at layer %d %s. Use --refresh to scan again.",
+		len(list.Accounts), common.FormatAmount(list.Supply), list.State.Layer, list.Time.Format(layerTimeFormat)))
+}
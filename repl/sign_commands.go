@@ -0,0 +1,179 @@
+package repl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spacemeshos/ed25519"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+const (
+	// maxRawSignSize is the largest file signed with --raw. Larger files must be signed by digest.
+	maxRawSignSize = 1 << 20
+	// signatureFileSuffix is appended to a file name to get the name of its detached signature file
+	signatureFileSuffix = ".sig"
+)
+
+// fileDigest returns the sha256 digest of a file. The file is hashed streaming.
+func fileDigest(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// signFile signs the sha256 digest of a file, or its raw content with --raw, with the current account
+func (r *repl) signFile() {
+	args := positionalArgs(r.args)
+	if len(args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: account sign-file <path> [--raw] [--out]")
+		return
+	}
+	path := args[0]
+
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	key, err := acc.SigningKey()
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	defer key.Release()
+
+	digest, err := fileDigest(path)
+	if err != nil {
+		log.Error("failed to hash file: %v", err)
+		return
+	}
+
+	msg := digest
+	if hasFlag(r.args, "--raw") {
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Error("failed to read file: %v", err)
+			return
+		}
+		if info.Size() > maxRawSignSize {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("file is larger than %d bytes, sign its digest instead", maxRawSignSize))
+			return
+		}
+		if msg, err = ioutil.ReadFile(path); err != nil {
+			log.Error("failed to read file: %v", err)
+			return
+		}
+	}
+
+	signature := key.Sign(msg)
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("signature (in hex): %x", signature))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("sha256 digest: %x", digest))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("public key: %x", []byte(acc.PubKey)))
+
+	if hasFlag(r.args, "--out") {
+		sigPath := path + signatureFileSuffix
+		if err := ioutil.WriteFile(sigPath, []byte(fmt.Sprintf("%x\n", signature)), 0644); err != nil {
+			log.Error("failed to write signature file: %v", err)
+			return
+		}
+		fmt.Fprintln(r.out, printPrefix, "Signature written to:", sigPath)
+	}
+}
+
+// trimHexPrefix removes an optional 0x prefix from a hex string
+func trimHexPrefix(s string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+}
+
+// verifyMessage returns the message to verify from the --hex or --file flags, or prompts for a text message
+func (r *repl) verifyMessage() ([]byte, error) {
+	if msgStr, ok := flagValue(r.args, "--hex"); ok {
+		return hex.DecodeString(msgStr)
+	}
+	if path, ok := flagValue(r.args, "--file"); ok {
+		if hasFlag(r.args, "--raw") {
+			return ioutil.ReadFile(path)
+		}
+		return fileDigest(path)
+	}
+	return []byte(r.inputNotBlank(msgVerifyTextMsg)), nil
+}
+
+// verifySigner resolves a public key, local account alias, contact name or address. It returns
+// the public key when it is known and the address of the signer otherwise.
+func (r *repl) verifySigner(signer string) (ed25519.PublicKey, gosmtypes.Address, error) {
+	if pub, err := hex.DecodeString(trimHexPrefix(signer)); err == nil && len(pub) == ed25519.PublicKeySize {
+		return pub, gosmtypes.Address{}, nil
+	}
+	if r.client.IsOpen() {
+		if acc, err := r.client.GetAccount(signer); err == nil {
+			return acc.PubKey, acc.Address(), nil
+		}
+	}
+	address, err := r.resolveAddress(signer)
+	return nil, address, err
+}
+
+// verifySignature checks a Sign2 signature made by a Spacemesh account
+func (r *repl) verifySignature() {
+	args := positionalArgs(r.args, "--hex", "--file")
+	if len(args) != 2 {
+		fmt.Fprintln(r.out, printPrefix, "usage: verify <public key|alias|contact|address|-> <signature> [--hex <message> | --file <path> [--raw]]")
+		return
+	}
+	sig, err := hex.DecodeString(trimHexPrefix(args[1]))
+	if err != nil {
+		log.Error("failed to decode signature hex string: %v", err)
+		return
+	}
+	msg, err := r.verifyMessage()
+	if err != nil {
+		log.Error("failed to read message: %v", err)
+		return
+	}
+
+	var valid bool
+	if args[0] == "-" {
+		// no signer given, report the public key recovered from the signature
+		pub, err := common.ExtractPublicKey(msg, sig)
+		if err != nil {
+			log.Error("failed to extract public key: %v", err)
+			return
+		}
+		valid = common.VerifySignature(pub, msg, sig)
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("public key: %x", []byte(pub)))
+		fmt.Fprintln(r.out, printPrefix, "address:", r.addressString(gosmtypes.BytesToAddress(pub)))
+	} else {
+		pub, address, err := r.verifySigner(args[0])
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+		if len(pub) > 0 {
+			valid = common.VerifySignature(pub, msg, sig)
+		} else {
+			valid = common.VerifySignatureByAddress(address, msg, sig)
+		}
+	}
+
+	if valid {
+		fmt.Fprintln(r.out, printPrefix, "VALID")
+	} else {
+		fmt.Fprintln(r.out, printPrefix, "INVALID")
+	}
+}
@@ -0,0 +1,106 @@
+package repl
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/c-bata/go-prompt"
+
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// terminal is the input of the prompt. Its setup and teardown are serialized, so a shutdown can
+// restore the terminal while the prompt runs.
+type terminal struct {
+	prompt.ConsoleParser
+	mu       sync.Mutex
+	restored bool
+}
+
+// Setup puts the terminal in raw mode for the prompt, unless it was restored for good
+func (t *terminal) Setup() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.restored {
+		return nil
+	}
+	return t.ConsoleParser.Setup()
+}
+
+func (t *terminal) TearDown() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ConsoleParser.TearDown()
+}
+
+// restore gives the terminal back the state it had before the prompt set it up, and keeps the
+// prompt from setting it up again
+func (t *terminal) restore() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.restored = true
+	return t.ConsoleParser.TearDown()
+}
+
+// handleSignals handles the signals of the session until signals is closed: SIGTERM ends the
+// session and SIGHUP reloads the settings
+func (r *repl) handleSignals(signals <-chan os.Signal) {
+	for sig := range signals {
+		r.handleSignal(sig)
+	}
+}
+
+// notifySignals sends SIGTERM and SIGHUP to handleSignals until the returned function is called
+func (r *repl) notifySignals() func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGHUP)
+	go r.handleSignals(signals)
+	return func() {
+		signal.Stop(signals)
+		close(signals)
+	}
+}
+
+func (r *repl) handleSignal(sig os.Signal) {
+	switch sig {
+	case syscall.SIGTERM:
+		r.shutdown()
+	case syscall.SIGHUP:
+		r.reloadConfig()
+	}
+}
+
+// shutdown ends the session when the process is terminated: it cancels the executed command and
+// the background streams, saves the accounts, closes the connection to the node and restores the
+// terminal before exiting. It runs once, later calls do nothing.
+func (r *repl) shutdown() {
+	r.shutdownOnce.Do(func() {
+		log.Info("session terminated")
+		r.endSession()
+		r.stopBackgroundStreams()
+		if r.client.IsOpen() {
+			if err := r.client.StoreAccounts(); err != nil {
+				log.Error("failed to save the accounts on exit: %v", err)
+			}
+		}
+		_ = r.client.Close()
+		if r.terminal != nil {
+			_ = r.terminal.restore()
+		}
+		r.exit(0)
+	})
+}
+
+// reloadConfig reads the settings file again, e.g. after it was edited, and applies it to the
+// following calls
+func (r *repl) reloadConfig() {
+	if err := r.client.ReloadConfig(); err != nil {
+		log.Error("failed to reload the settings: %v", err)
+		fmt.Fprintln(r.out, printPrefix, "The settings can't be reloaded, the current ones are kept:", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Settings reloaded.")
+}
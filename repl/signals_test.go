@@ -0,0 +1,105 @@
+package repl
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/c-bata/go-prompt"
+
+	"github.com/spacemeshos/smrepl/repl/clienttest"
+)
+
+// fakeTerminal counts the setups and teardowns of the terminal by the prompt and a shutdown
+type fakeTerminal struct {
+	prompt.ConsoleParser
+	setups, teardowns int
+}
+
+func (t *fakeTerminal) Setup() error {
+	t.setups++
+	return nil
+}
+
+func (t *fakeTerminal) TearDown() error {
+	t.teardowns++
+	return nil
+}
+
+func TestShutdown(t *testing.T) {
+	f := clienttest.New()
+	r, output := newTestRepl(f)
+	tty := &fakeTerminal{}
+	r.terminal = &terminal{ConsoleParser: tty}
+	var exits []int
+	r.exit = func(code int) { exits = append(exits, code) }
+
+	r.executor("state stream " + otherAddress.Hex())
+	waitForStreams(t, f, 1)
+	r.commands = append(r.commands, command{commandStateRoot, "wait", commandStateLeaf, "", func() {
+		<-r.ctx.Done()
+	}})
+	returned := make(chan struct{})
+	go func() {
+		r.executor("wait")
+		close(returned)
+	}()
+
+	f.ResetCalls()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.handleSignal(syscall.SIGTERM)
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("expected the executed command to be cancelled")
+	}
+	waitForStreams(t, f, 0)
+	if stores, closes := len(f.CallsTo("StoreAccounts")), len(f.CallsTo("Close")); stores != 1 || closes != 1 {
+		t.Fatalf("expected the accounts to be saved and the connection closed once, got %v", f.Calls())
+	}
+	if tty.teardowns != 1 || len(exits) != 1 || exits[0] != 0 {
+		t.Fatalf("expected the terminal to be restored and the process to exit with 0 once, got %d teardowns and exits %v:\n%s",
+			tty.teardowns, exits, output.String())
+	}
+	_ = r.terminal.Setup()
+	if tty.setups != 0 {
+		t.Fatal("expected the prompt not to set the restored terminal up again")
+	}
+}
+
+func TestShutdownWithoutWallet(t *testing.T) {
+	f := clienttest.New()
+	f.SetOpen(false)
+	r, _ := newTestRepl(f)
+	r.exit = func(int) {}
+	r.handleSignal(syscall.SIGTERM)
+	if f.Called("StoreAccounts") || !f.Called("Close") {
+		t.Fatalf("expected the connection to be closed without saving accounts, got %v", f.Calls())
+	}
+}
+
+func TestReloadConfigSignal(t *testing.T) {
+	f := clienttest.New()
+	r, output := newTestRepl(f)
+	r.handleSignal(syscall.SIGHUP)
+	if !f.Called("ReloadConfig") || !strings.Contains(output.String(), "Settings reloaded.") {
+		t.Fatalf("expected the settings to be reloaded, got %v:\n%s", f.Calls(), output.String())
+	}
+
+	f.Errors["ReloadConfig"] = errors.New("invalid settings file")
+	r.handleSignal(syscall.SIGHUP)
+	if out := output.String(); !strings.Contains(out, "current ones are kept: invalid settings file") {
+		t.Fatalf("expected the failure to be reported, got:\n%s", out)
+	}
+}
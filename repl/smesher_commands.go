@@ -1,111 +1,516 @@
 package repl
 
 import (
-	"encoding/hex"
+	"errors"
 	"fmt"
-	"strconv"
+	"net"
+	"strings"
+	"time"
 
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
 	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
-	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/smrepl/common"
 	"github.com/spacemeshos/smrepl/log"
 )
 
-// printSmesherRewards prints all rewards awarded to a smesher identified by an id
+// currentSmesherArg selects the smesher of the node in state smesher-rewards @current. The executor
+// takes @ arguments for account shorthands, so it arrives as the account override.
+const currentSmesherArg = "current"
+
+// printSmesherRewards prints all rewards awarded to a smesher identified by an id given as an
+// argument or prompted for, in hex or base64, or to the smesher of the node with @current
 func (r *repl) printSmesherRewards() {
+	var smesherId []byte
+	if r.accountOverride == currentSmesherArg {
+		id, ok := r.selectSmesher()
+		if !ok {
+			return
+		}
+		fmt.Fprintln(r.out, printPrefix, "Smesher id:", r.formatSmesherId(id))
+		smesherId = id
+	} else if args := positionalArgs(r.args, "--from-layer", "--to-layer", "--csv", "--sort", "--offset", "--max", identityFlag); len(args) > 0 {
+		id, err := r.resolveSmesherId(args[0])
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+		smesherId = id
+	} else {
+		smesherId = r.inputSmesherId(smesherIdMsg)
+	}
+	r.printSmesherRewardList(smesherId)
+}
+
+// startSmeshing walks through the smeshing setup: the PoST provider, the data directory and size,
+// which are checked when the node runs on this machine, and the rewards address. The node is asked
+// to start after the summary is confirmed.
+func (r *repl) startSmeshing() {
+	if _, ok := r.selectSmesher(); !ok {
+		return
+	}
+	provider, ok := r.choosePostProvider()
+	if !ok {
+		return
+	}
 
-	smesherIdStr := inputNotBlank(smesherIdMsg)
-	smesherId := util.FromHex(smesherIdStr)
+	local := r.nodeIsLocal()
+	if !local {
+		fmt.Fprintln(r.out, printPrefix, "The node doesn't run on this machine, so the data directory can't be checked here.")
+	}
+	limits := r.commitmentLimits()
+	var dataDir string
+	var size uint64
+	for {
+		dataDir = strings.TrimSpace(r.inputNotBlank(smeshingDatadirMsg))
+		size = 0
+		var written uint64
+		if local {
+			existing, ok := r.existingCommitment(dataDir)
+			if !ok {
+				continue
+			}
+			if existing != nil {
+				size, written = existing.Committed, existing.Written
+			}
+		}
+		if size == 0 {
+			size = r.inputCommitmentSize(smeshingSpaceAllocationMsg, limits)
+		}
+		if !local {
+			break
+		}
+		if err := common.CheckDataDir(dataDir, size-written); err != nil {
+			var spaceErr *common.SpaceError
+			if !errors.As(err, &spaceErr) || !hasFlag(r.args, "--force") {
+				fmt.Fprintln(r.out, printPrefix, err)
+				if spaceErr != nil {
+					fmt.Fprintln(r.out, printPrefix, "Choose another directory or size, or start with --force to smesh anyway.")
+				}
+				continue
+			}
+			fmt.Fprintln(r.out, printPrefix, colorYellow+"WARNING: "+err.Error()+colorReset)
+		}
+		r.printDataDirWarnings(dataDir, size)
+		break
+	}
 
-	// todo: request offset and total from user
-	rewards, total, err := r.client.SmesherRewards(smesherId, 0, 0)
+	coinbase, err := r.chooseCoinbase()
 	if err != nil {
-		log.Error("failed to get rewards: %v", err)
+		log.Error("failed to get account: %v", err)
 		return
 	}
 
-	fmt.Println(printPrefix, fmt.Sprintf("Total rewards: %d", total))
-	for _, r := range rewards {
-		printReward(r)
-		fmt.Println(printPrefix, "-----")
+	fmt.Fprintln(r.out, printPrefix, "Smeshing setup:")
+	if provider != nil {
+		fmt.Fprintln(r.out, printPrefix, "  PoST provider:", providerName(provider))
+	} else {
+		fmt.Fprintln(r.out, printPrefix, "  PoST provider: chosen by the node")
+	}
+	fmt.Fprintln(r.out, printPrefix, "  Data directory:", dataDir)
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("  Data size: %.2f GiB", common.GiB(size)))
+	fmt.Fprintln(r.out, printPrefix, "  Rewards address:", r.addressString(coinbase))
+	if r.yesOrNoQuestion(confirmStartSmeshingMsg) != "y" {
+		fmt.Fprintln(r.out, printPrefix, "Not starting.")
+		return
 	}
-}
 
-func (r *repl) startSmeshing() {
-	addr, err := r.getCurrent()
+	if provider != nil {
+		resp, err := r.client.CreatePostData(r.ctx, &apitypes.PostData{Path: dataDir, DataSize: size, ProviderId: provider.GetId()})
+		if err == nil {
+			err = common.StatusError("create PoST data", resp.GetCode(), resp.GetMessage())
+		}
+		if err != nil {
+			r.printNodeError(common.CallError("create PoST data", err))
+			return
+		}
+	}
+	resp, err := r.client.StartSmeshing(r.ctx, coinbase, dataDir, size)
+	if err == nil {
+		err = common.StatusError("start smeshing", resp.GetCode(), resp.GetMessage())
+	}
 	if err != nil {
-		log.Error("failed to get account", err)
+		r.printNodeError(common.CallError("start smeshing", err))
 		return
 	}
 
-	dataDir := inputNotBlank(smeshingDatadirMsg)
+	fmt.Fprintln(r.out, printPrefix, "Smeshing started")
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Follow the PoST data creation with smesher post-status-stream --size %d", (size+1<<30-1)>>30))
+}
 
-	spaceGBStr := inputNotBlank(smeshingSpaceAllocationMsg)
-	dataSizeGB, err := strconv.ParseUint(spaceGBStr, 10, 64)
+// printDataDirWarnings warns when a PoST data directory is on a filesystem too slow for proofs, or
+// already has PoST data of another size than size bytes from a previous commitment
+func (r *repl) printDataDirWarnings(dataDir string, size uint64) {
+	if fs, slow := common.SlowFilesystem(dataDir); slow {
+		fmt.Fprintln(r.out, printPrefix, colorYellow+fmt.Sprintf("WARNING: the data directory is on a %s filesystem, which may be too slow to read the PoST data for proofs in time.", fs)+colorReset)
+	}
+	if existing, err := common.InspectPostData(dataDir); err == nil && existing != nil && existing.Committed != size {
+		fmt.Fprintln(r.out, printPrefix, colorYellow+fmt.Sprintf("WARNING: the data directory has %.2f GiB of PoST data from a previous commitment of another size.", common.GiB(existing.Written))+colorReset)
+	}
+}
+
+// existingCommitment describes the PoST data already in a data directory and offers to resume its
+// commitment. It returns the data to resume, nil to start a new commitment, and false when another
+// directory must be chosen because the data was created for another smesher.
+func (r *repl) existingCommitment(dataDir string) (*common.PostDataInfo, bool) {
+	info, err := common.InspectPostData(dataDir)
 	if err != nil {
-		log.Error("failed to parse: %v", err)
-		return
+		fmt.Fprintln(r.out, printPrefix, "The PoST data in the directory can't be inspected:", err)
+		return nil, true
 	}
+	if info == nil {
+		return nil, true
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("The data directory has PoST data: %.2f GiB written of a %.2f GiB commitment.",
+		common.GiB(info.Written), common.GiB(info.Committed)))
+	if fingerprint := info.Fingerprint(); fingerprint != "" {
+		fmt.Fprintln(r.out, printPrefix, "It was created for the node id starting with", fingerprint)
+	}
+	smesherId, err := r.client.GetSmesherId(r.ctx)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "The node's smesher id can't be read to check the data against it:", err)
+	} else {
+		switch match, known := info.BelongsTo(smesherId); {
+		case !known:
+			fmt.Fprintln(r.out, printPrefix, "The data doesn't record its smesher id, so it can't be checked against the node's.")
+		case match:
+			fmt.Fprintln(r.out, printPrefix, "It matches the node's smesher id", r.formatSmesherId(smesherId))
+		default:
+			fmt.Fprintln(r.out, printPrefix, colorRed+"WARNING: the PoST data was created for another smesher than the node's, "+
+				r.formatSmesherId(smesherId)+". The node can't prove its space with it."+colorReset)
+			fmt.Fprintln(r.out, printPrefix, "Either choose another directory for a new commitment, move or delete these files, "+
+				"or run the node with the identity which created them.")
+			return nil, false
+		}
+	}
+	if r.yesOrNoQuestion(fmt.Sprintf(resumeCommitmentMsg, common.GiB(info.Committed))) == "y" {
+		return info, true
+	}
+	return nil, true
+}
 
-	resp, err := r.client.StartSmeshing(addr.Address(), dataDir, dataSizeGB<<20)
+// choosePostProvider lists the PoST providers of the node and lets the user pick one. It returns
+// nil when the node doesn't list providers, and false when they can't be fetched.
+func (r *repl) choosePostProvider() (*apitypes.PostComputeProvider, bool) {
+	providers, available, err := r.postProviders()
+	if err != nil {
+		r.printNodeError(common.CallError("get PoST providers", err))
+		return nil, false
+	}
+	if !available {
+		return nil, true
+	}
+	switch len(providers) {
+	case 0:
+		return nil, true
+	case 1:
+		fmt.Fprintln(r.out, printPrefix, "PoST provider:", providerName(providers[0]))
+		return providers[0], true
+	}
+	fmt.Fprintln(r.out, printPrefix, "Choose the PoST provider:")
+	names := make([]string, len(providers))
+	for i, p := range providers {
+		names[i] = providerName(p)
+	}
+	return providers[r.multipleChoice(names)-1], true
+}
 
+// postProviders returns the PoST providers of the node. available is false when the node doesn't
+// implement the call.
+func (r *repl) postProviders() (providers []*apitypes.PostComputeProvider, available bool, err error) {
+	providers, err = r.client.GetPostComputeProviders(r.ctx)
+	if status.Code(err) == codes.Unimplemented {
+		return nil, false, nil
+	}
+	return providers, err == nil, err
+}
+
+// printPostConfig prints the PoST parameters which constrain smesher start. The API smrepl is
+// built against has no call for the commitment size limits and the label parameters, so only the
+// compute providers the start wizard chooses from are reported.
+func (r *repl) printPostConfig() {
+	fmt.Fprintln(r.out, printPrefix, "Commitment size limits: not available on this node")
+	fmt.Fprintln(r.out, printPrefix, "Labels per unit and bits per label: not available on this node")
+	providers, available, err := r.postProviders()
 	if err != nil {
-		log.Error("failed to start smeshing: %v", err)
+		r.printNodeError(common.CallError("get PoST providers", err))
 		return
 	}
-
-	if resp.Code != 0 {
-		log.Error("failed to start smeshing. Response status: %d", resp.Code)
+	if !available {
+		fmt.Fprintln(r.out, printPrefix, "PoST providers: not available on this node")
 		return
 	}
+	if len(providers) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "PoST providers: none reported")
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "PoST providers:")
+	for _, p := range providers {
+		fmt.Fprintln(r.out, printPrefix, "  "+providerName(p))
+	}
+}
+
+// providerName describes a PoST provider with the speed the node reports for it
+func providerName(p *apitypes.PostComputeProvider) string {
+	speed := "speed unknown"
+	if p.GetPerformance() > 0 {
+		speed = fmt.Sprintf("%d hashes/s", p.GetPerformance())
+	}
+	return fmt.Sprintf("%s (%s, id %d, %s)", p.GetModel(), p.GetComputeApi(), p.GetId(), speed)
+}
+
+// chooseCoinbase returns the current account address as the rewards address unless the user enters
+// another address, account alias or contact
+func (r *repl) chooseCoinbase() (gosmtypes.Address, error) {
+	acc, err := r.getCurrent()
+	if err != nil {
+		return gosmtypes.Address{}, err
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Rewards go to the current account %s: %s", acc.Name, r.addressString(acc.Address())))
+	if r.yesOrNoQuestion(otherCoinbaseMsg) == "y" {
+		return r.inputAddress(enterAddressMsg), nil
+	}
+	return acc.Address(), nil
+}
 
-	fmt.Println(printPrefix, "Smeshing started")
+// resolveSmesherId returns the id of a smesher saved in the address book under a name, or parses a
+// smesher id of a valid length in hex or base64
+func (r *repl) resolveSmesherId(input string) ([]byte, error) {
+	input = strings.TrimSpace(input)
+	if smeshers, err := r.client.SmesherContacts(); err == nil {
+		for _, s := range smeshers {
+			if s.Name == input {
+				return common.ParseSmesherId(s.Id)
+			}
+		}
+	}
+	id, err := common.ParseSmesherId(input)
+	if err == nil {
+		err = common.CheckSmesherIdLength(id)
+	}
+	return id, err
+}
 
+// inputSmesherId prompts until a saved smesher name or a smesher id of a valid length is entered
+func (r *repl) inputSmesherId(msg string) []byte {
+	for {
+		id, err := r.resolveSmesherId(r.inputNotBlank(msg))
+		if err == nil {
+			return id
+		}
+		fmt.Fprintln(r.out, printPrefix, err)
+	}
 }
 
-func (r *repl) stopSmeshing() {
-	deleteData := yesOrNoQuestion(confirmDeleteDataMsg) == "y"
-	resp, err := r.client.StopSmeshing(deleteData)
+// commitmentLimits returns the PoST commitment sizes the node accepts. The node API has no call for
+// its PoST config, so they are unknown and a size is only checked by the node.
+func (r *repl) commitmentLimits() common.CommitmentLimits {
+	return common.CommitmentLimits{}
+}
+
+// inputCommitmentSize prompts until a PoST commitment size within the limits is entered, and returns
+// it in bytes rounded to a whole number of units
+func (r *repl) inputCommitmentSize(msg string, limits common.CommitmentLimits) uint64 {
+	for {
+		size, err := common.ParseCommitmentSize(r.inputNotBlank(msg), limits)
+		if err == nil {
+			var snapped bool
+			if size, snapped, err = limits.Snap(size); err == nil {
+				if snapped {
+					fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Rounded to %.2f GiB, a whole number of %.2f GiB units.", common.GiB(size), common.GiB(limits.UnitSize)))
+				}
+				return size
+			}
+		}
+		fmt.Fprintln(r.out, printPrefix, err)
+	}
+}
 
+// nodeIsLocal tells whether the node runs on this machine, so paths entered for it can be checked
+// here
+func (r *repl) nodeIsLocal() bool {
+	server := r.client.ActiveServer()
+	if _, ok := common.SocketPath(server); ok {
+		return true
+	}
+	host, _, err := net.SplitHostPort(server)
 	if err != nil {
-		log.Error("failed to stop smeshing: %v", err)
-		return
+		return false
+	}
+	if host == "localhost" {
+		return true
 	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// deletePostDataPhrase must be typed verbatim for smesher stop to ask the node to delete the PoST
+// data, which takes weeks to create again
+const deletePostDataPhrase = "delete my post data"
 
-	if resp.Code != 0 {
-		log.Error("failed to stop smeshing. Response status: %d", resp.Code)
+// stopSmeshing asks to confirm stopping, then whether to delete the PoST data. Deletion shows the
+// data directory and its size and needs deletePostDataPhrase, otherwise the data is kept. The
+// status of the node is read back to confirm smeshing stopped.
+func (r *repl) stopSmeshing() {
+	if _, ok := r.selectSmesher(); !ok {
+		return
+	}
+	if r.yesOrNoQuestion(confirmStopSmeshingMsg) != "y" {
 		return
 	}
+	deleteData := r.yesOrNoQuestion(confirmDeleteDataMsg) == "y" && r.confirmDeletePostData()
+	resp, err := r.client.StopSmeshing(r.ctx, deleteData)
+	if err == nil {
+		fmt.Fprintln(r.out, printPrefix, "Node status:", codes.Code(resp.Code).String())
+		err = common.StatusError("stop smeshing", resp.Code, resp.Message)
+	}
+	if err != nil {
+		r.printNodeError(common.CallError("stop smeshing", err))
+		return
+	}
+
+	smeshing, err := r.client.IsSmeshing(r.ctx)
+	switch {
+	case err != nil:
+		r.printNodeError(common.CallError("get smeshing status", err))
+	case smeshing:
+		fmt.Fprintln(r.out, printPrefix, colorRed+"The node still reports smeshing."+colorReset)
+	case deleteData:
+		fmt.Fprintln(r.out, printPrefix, "Smeshing stopped and the PoST data was deleted")
+	default:
+		fmt.Fprintln(r.out, printPrefix, "Smeshing stopped, the PoST data was kept")
+	}
+}
+
+// confirmDeletePostData shows the PoST data directory given with --data-dir or prompted for, with
+// its size when the node runs here, and tells whether deletePostDataPhrase was typed
+func (r *repl) confirmDeletePostData() bool {
+	dataDir, ok := flagValue(r.args, "--data-dir")
+	if !ok {
+		dataDir = strings.TrimSpace(r.inputNotBlank(smeshingDatadirMsg))
+	}
+	fmt.Fprintln(r.out, printPrefix, "Data directory:", dataDir)
+	if r.nodeIsLocal() {
+		if size, err := common.DirSize(dataDir); err != nil {
+			fmt.Fprintln(r.out, printPrefix, "The size of the data directory can't be read:", err)
+		} else {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Size on disk: %.2f GiB", common.GiB(size)))
+		}
+	} else {
+		fmt.Fprintln(r.out, printPrefix, "Size on disk: unknown, the node runs on another machine")
+	}
+	if strings.TrimSpace(r.inputNotBlank(fmt.Sprintf(deletePostDataMsg, deletePostDataPhrase))) != deletePostDataPhrase {
+		fmt.Fprintln(r.out, printPrefix, "The phrase doesn't match, the PoST data will be kept.")
+		return false
+	}
+	return true
+}
 
-	fmt.Println(printPrefix, "Smeshing started")
+// postStatusSampleInterval is the time between the two statuses smesher post-status takes to measure
+// the throughput of PoST data creation
+const postStatusSampleInterval = 2 * time.Second
 
+// postFilesState returns the name of the state of PoST data files, e.g. partial for
+// FILES_STATUS_PARTIAL
+func postFilesState(status apitypes.PostStatus_FilesStatus) string {
+	name := strings.TrimPrefix(status.String(), "FILES_STATUS_")
+	return strings.ToLower(strings.ReplaceAll(name, "_", " "))
 }
 
+// printPostStatus prints the state of the PoST data. While it is created, the status is taken twice
+// to print the throughput and the time left. The total size is taken from --size, or from the data
+// files in the directory given with --data-dir when the node runs here.
 func (r *repl) printPostStatus() {
-	status, err := r.client.GetPostStatus()
+	var total uint64
+	var data *common.PostDataInfo
+	if dir, ok := flagValue(r.args, "--data-dir"); ok && r.nodeIsLocal() {
+		info, err := common.InspectPostData(dir)
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, "The PoST data in the directory can't be inspected:", err)
+		} else if info != nil {
+			data, total = info, info.Committed
+		}
+	}
+	if s, ok := flagValue(r.args, "--size"); ok {
+		size, err := common.ParseSize(s)
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, err)
+			return
+		}
+		total = size
+	}
+
+	status, err := r.client.GetPostStatus(r.ctx)
 	if err != nil {
-		log.Error("failed to get post status: %v", err)
+		r.printNodeError(common.CallError("get PoST status", err))
 		return
 	}
-
-	fmt.Println(printPrefix, "File status:", status.GetFilesStatus().String())
-	fmt.Println(printPrefix, "File creating in progress:", status.GetInitInProgress())
-	fmt.Println(printPrefix, "Bytes written: ", status.GetBytesWritten())
-
-	lastErr := status.GetErrorMessage()
-	if lastErr != "" {
-		fmt.Println(printPrefix, "Last error: ", status.GetErrorMessage())
+	fmt.Fprintln(r.out, printPrefix, "State:", postFilesState(status.GetFilesStatus()))
+	if msg := status.GetErrorMessage(); msg != "" {
+		fmt.Fprintln(r.out, printPrefix, colorRed+"Last error: "+msg+colorReset)
 	}
 
-	fmt.Println(printPrefix, "Not yet implemented :-(")
+	switch {
+	case status.GetInitInProgress():
+		first := common.PostSample{Time: time.Now(), BytesWritten: status.GetBytesWritten()}
+		time.Sleep(postStatusSampleInterval)
+		if status, err = r.client.GetPostStatus(r.ctx); err != nil {
+			r.printNodeError(common.CallError("get PoST status", err))
+			return
+		}
+		sample := common.PostSample{Time: time.Now(), BytesWritten: status.GetBytesWritten()}
+		progress := common.NewPostProgress(&first, sample, total)
+		if progress.Percent >= 0 {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Written: %.2f GiB of %.2f GiB (%.1f%%)", common.GiB(sample.BytesWritten), common.GiB(total), progress.Percent))
+		} else {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Written: %.2f GiB, start with --size to see the percentage", common.GiB(sample.BytesWritten)))
+		}
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Throughput: %.1f MB/s", progress.MBps))
+		if progress.Remaining >= 0 {
+			fmt.Fprintln(r.out, printPrefix, "Time left:", progress.Remaining)
+		} else {
+			fmt.Fprintln(r.out, printPrefix, "Time left: unknown")
+		}
+	case status.GetFilesStatus() == apitypes.PostStatus_FILES_STATUS_COMPLETE:
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Initialization is complete: %.2f GiB committed", common.GiB(status.GetBytesWritten())))
+		if data != nil {
+			fmt.Fprintln(r.out, printPrefix, "Data files:", data.Files)
+		}
+	}
+	if status.GetInitInProgress() {
+		return
+	}
+	if smeshing, err := r.client.IsSmeshing(r.ctx); err == nil && smeshing {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Smeshing with a commitment of %.2f GiB", common.GiB(status.GetBytesWritten())))
+		if coinbase, err := r.client.GetRewardsAddress(r.ctx); err == nil {
+			fmt.Fprintln(r.out, printPrefix, "Rewards address:", r.addressString(*coinbase))
+		}
+	}
 }
 
+// printPostProviders prints the PoST providers the node can create data with
 func (r *repl) printPostProviders() {
-	fmt.Println(printPrefix, "Not yet implemented :-(")
+	providers, err := r.client.GetPostComputeProviders(r.ctx)
+	if err != nil {
+		r.printNodeError(common.CallError("get PoST providers", err))
+		return
+	}
+	if len(providers) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "The node reports no PoST providers.")
+		return
+	}
+	for _, p := range providers {
+		fmt.Fprintln(r.out, printPrefix, providerName(p))
+	}
 }
 
 func (r *repl) printSmeshingStatus() {
-	isSmeshing, err := r.client.IsSmeshing()
+	if _, ok := r.selectSmesher(); !ok {
+		return
+	}
+	isSmeshing, err := r.client.IsSmeshing(r.ctx)
 
 	if err != nil {
 		log.Error("failed to get smeshing status: %v", err)
@@ -113,67 +518,113 @@ func (r *repl) printSmeshingStatus() {
 	}
 
 	if isSmeshing {
-		fmt.Println(printPrefix, "Smeshing is enabled")
+		fmt.Fprintln(r.out, printPrefix, "Smeshing is enabled")
 	} else {
-		fmt.Println(printPrefix, "Smeshing is disabled")
+		fmt.Fprintln(r.out, printPrefix, "Smeshing is disabled")
 	}
 }
 
 func (r *repl) printRewardsAddress() {
-	if resp, err := r.client.GetRewardsAddress(); err != nil {
+	if resp, err := r.client.GetRewardsAddress(r.ctx); err != nil {
 		log.Error("failed to get rewards address: %v", err)
 	} else {
-		fmt.Println(printPrefix, "Rewards address is:", resp.String())
+		fmt.Fprintln(r.out, printPrefix, "Rewards address is:", r.addressString(*resp))
 	}
 }
 
-// setRewardsAddress sets the smesher's reward address to a user provider address
+// setRewardsAddress sets the smesher's rewards address to an address, contact or account alias
+// entered by the user
 func (r *repl) setRewardsAddress() {
-	addrStr := inputNotBlank(enterAddressMsg)
-	addr := gosmtypes.HexToAddress(addrStr)
-
-	resp, err := r.client.SetRewardsAddress(addr)
-
+	current, err := r.client.GetRewardsAddress(r.ctx)
 	if err != nil {
-		log.Error("failed to set rewards address: %v", err)
+		r.printNodeError(common.CallError("get rewards address", err))
 		return
 	}
+	fmt.Fprintln(r.out, printPrefix, "Current rewards address:", r.addressString(*current))
+	r.changeRewardsAddress(*current, r.inputAddress(newRewardsAddressMsg))
+}
 
-	if resp.Code == 0 {
-		fmt.Println(printPrefix, "Rewards address set to:", addr.String())
-	} else {
-		// todo: what are the possible non-zero status codes here?
-		fmt.Println(printPrefix, fmt.Sprintf("Response status code: %d", resp.Code))
+// claimRewards sets the smesher's rewards address to the current account, opening a wallet first
+// when none is open
+func (r *repl) claimRewards() {
+	if !r.client.IsOpen() {
+		if r.yesOrNoQuestion(openWalletForRewardsMsg) != "y" {
+			return
+		}
+		r.openWallet()
+		if !r.clientOpen {
+			return
+		}
 	}
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account: %v", err)
+		return
+	}
+	current, err := r.client.GetRewardsAddress(r.ctx)
+	if err != nil {
+		r.printNodeError(common.CallError("get rewards address", err))
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Current rewards address:", r.addressString(*current))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Current account %s: %s", acc.Name, r.addressString(acc.Address())))
+	r.changeRewardsAddress(*current, acc.Address())
 }
 
-func (r *repl) printSmesherId() {
-	if resp, err := r.client.GetSmesherId(); err != nil {
-		log.Error("failed to get smesher id: %v", err)
-	} else {
-		fmt.Println(printPrefix, "Smesher id:", "0x"+hex.EncodeToString(resp))
+// changeRewardsAddress changes the smesher's rewards address from current to addr once the change
+// is confirmed, then reads it back from the node to check it was applied
+func (r *repl) changeRewardsAddress(current, addr gosmtypes.Address) {
+	if addr == current {
+		fmt.Fprintln(r.out, printPrefix, "Rewards address unchanged.")
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Rewards address: %s → %s", r.addressString(current), r.addressString(addr)))
+	if r.yesOrNoQuestion(confirmRewardsAddressMsg) != "y" {
+		fmt.Fprintln(r.out, printPrefix, "Rewards address unchanged.")
+		return
 	}
-}
 
-// printSmesherRewards prints all rewards awarded to a smesher identified by an id
-func (r *repl) printCurrentSmesherRewards() {
-	if smesherId, err := r.client.GetSmesherId(); err != nil {
-		log.Error("failed to get smesher id: %v", err)
-	} else {
+	resp, err := r.client.SetRewardsAddress(r.ctx, addr)
+	if err == nil {
+		err = common.StatusError("set rewards address", resp.GetCode(), resp.GetMessage())
+	}
+	if err != nil {
+		r.printNodeError(common.CallError("set rewards address", err))
+		return
+	}
 
-		fmt.Println(printPrefix, "Smesher id:", "0x"+hex.EncodeToString(smesherId))
+	applied, err := r.client.GetRewardsAddress(r.ctx)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "The node accepted the change but the rewards address can't be read back:", err)
+		return
+	}
+	if *applied != addr {
+		fmt.Fprintln(r.out, printPrefix, colorRed+"The node accepted the change but still reports rewards address "+r.addressString(*applied)+colorReset)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Rewards address set to:", r.addressString(addr))
+}
 
-		// todo: request offset and total from user
-		rewards, total, err := r.client.SmesherRewards(smesherId, 0, 10000)
-		if err != nil {
-			log.Error("failed to get rewards: %v", err)
+func (r *repl) printSmesherId() {
+	id, ok := r.selectSmesher()
+	if !ok {
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Smesher id (hex):", common.FormatSmesherId(id, common.IdFormatHex))
+	fmt.Fprintln(r.out, printPrefix, "Smesher id (base64):", common.FormatSmesherId(id, common.IdFormatBase64))
+	if name, ok := flagValue(r.args, "--save"); ok {
+		if err := r.client.AddSmesherContact(name, id); err != nil {
+			log.Error("failed to save smesher id: %v", err)
 			return
 		}
+		fmt.Fprintln(r.out, printPrefix, "Saved the smesher id in the address book as", name)
+	}
+}
 
-		fmt.Println(printPrefix, fmt.Sprintf("Total rewards: %d", total))
-		for _, r := range rewards {
-			printReward(r)
-			fmt.Println(printPrefix, "-----")
-		}
+// printCurrentSmesherRewards prints all rewards awarded to the smesher of the node
+func (r *repl) printCurrentSmesherRewards() {
+	if smesherId, ok := r.selectSmesher(); ok {
+		fmt.Fprintln(r.out, printPrefix, "Smesher id:", r.formatSmesherId(smesherId))
+		r.printSmesherRewardList(smesherId)
 	}
 }
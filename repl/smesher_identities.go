@@ -0,0 +1,52 @@
+package repl
+
+import (
+	"fmt"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// identityFlag selects one of the identities of a node which smeshes with several
+const identityFlag = "--identity"
+
+// selectSmesher returns the id of the smesher identity selected with --identity, or of the only
+// identity of the node. The selected identity is printed when the node has several, and
+// --identity is ignored with a note when it has one.
+func (r *repl) selectSmesher() ([]byte, bool) {
+	ids, err := r.client.SmesherIds(r.ctx)
+	if err != nil {
+		r.printNodeError(common.CallError("get smesher id", err))
+		return nil, false
+	}
+	selector, selected := flagValue(r.args, identityFlag)
+	if selected && len(ids) == 1 {
+		fmt.Fprintln(r.out, printPrefix, "The node smeshes with a single identity, --identity is ignored.")
+		selector = ""
+	}
+	id, err := common.SelectSmesherId(ids, selector)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return nil, false
+	}
+	if len(ids) > 1 {
+		fmt.Fprintln(r.out, printPrefix, "Smesher identity:", r.formatSmesherId(id))
+	}
+	return id, true
+}
+
+// listSmeshers prints the identities the node smeshes with, numbered for --identity
+func (r *repl) listSmeshers() {
+	ids, err := r.client.SmesherIds(r.ctx)
+	if err != nil {
+		r.printNodeError(common.CallError("get smesher id", err))
+		return
+	}
+	for i, id := range ids {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%d  %s", i+1, r.formatSmesherId(id)))
+	}
+	if len(ids) == 1 {
+		fmt.Fprintln(r.out, printPrefix, "The node smeshes with a single identity.")
+	} else {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Select an identity with %s <number or id prefix> in smesher start, stop, status and rewards.", identityFlag))
+	}
+}
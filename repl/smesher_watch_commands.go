@@ -0,0 +1,171 @@
+package repl
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// defaultSmesherWatchInterval is the time between smesher watch refreshes without --interval
+const defaultSmesherWatchInterval = 10 * time.Second
+
+// clearScreen moves the cursor to the top of the terminal and clears it
+const clearScreen = "\033[H\033[2J"
+
+// dashboardRow is a labeled value of a dashboard. A row without a value is a section heading.
+type dashboardRow struct {
+	label, value string
+}
+
+// unavailable is the dashboard value of something the node doesn't report, with the error
+func unavailable(err error) string {
+	return "unavailable: " + err.Error()
+}
+
+// smesherDashboard collects the smesher state shown by smesher watch and dashboard. The rewards are
+// summed as they are awarded, so a refresh only fetches the new ones.
+type smesherDashboard struct {
+	smesherId []byte
+	tally     common.RewardTally
+}
+
+// smesherRows returns the current smesher state. info is the mesh info the rewards of the epoch
+// are summed with, the rewards are left out when it's nil.
+func (r *repl) smesherRows(d *smesherDashboard, info *common.NetInfo) []dashboardRow {
+	var rows []dashboardRow
+	add := func(label, value string) {
+		rows = append(rows, dashboardRow{label, value})
+	}
+
+	if smeshing, err := r.client.IsSmeshing(r.ctx); err != nil {
+		add("Smeshing", unavailable(err))
+	} else if smeshing {
+		add("Smeshing", "on")
+	} else {
+		add("Smeshing", "off")
+	}
+	if postStatus, err := r.client.GetPostStatus(r.ctx); err != nil {
+		add("PoST data", unavailable(err))
+	} else if postStatus.GetInitInProgress() {
+		add("PoST data", fmt.Sprintf("creating, %.2f GiB written", common.GiB(postStatus.GetBytesWritten())))
+	} else {
+		add("PoST data", fmt.Sprintf("%s, %.2f GiB", postStatus.GetFilesStatus(), common.GiB(postStatus.GetBytesWritten())))
+	}
+	if coinbase, err := r.client.GetRewardsAddress(r.ctx); err != nil {
+		add("Rewards address", unavailable(err))
+	} else {
+		add("Rewards address", r.addressString(*coinbase))
+	}
+
+	if d.smesherId == nil {
+		if id, err := r.client.GetSmesherId(r.ctx); err == nil {
+			d.smesherId = id
+		} else {
+			add("Smesher id", unavailable(err))
+		}
+	}
+	if d.smesherId != nil {
+		add("Smesher id", r.formatSmesherId(d.smesherId))
+	}
+	if d.smesherId != nil && info != nil {
+		if err := updateRewardTally(r.ctx, r.client, d.smesherId, &d.tally, info.LayerPerEpoch); err != nil {
+			add("Rewards", unavailable(err))
+		} else {
+			add("Rewards this epoch", common.FormatAmount(d.tally.EpochTotal(info.CurrentEpoch)))
+			add("Rewards in total", fmt.Sprintf("%s from %d rewards", common.FormatAmount(d.tally.Total), d.tally.Count))
+		}
+	}
+	return rows
+}
+
+// layerRow returns the current layer and epoch of mesh info, or why they are unavailable
+func layerRow(info *common.NetInfo, err error) dashboardRow {
+	if err != nil {
+		return dashboardRow{"Layer", unavailable(err)}
+	}
+	return dashboardRow{"Layer", fmt.Sprintf("%d, epoch %d", info.CurrentLayer, info.CurrentEpoch)}
+}
+
+// dashboardRows returns the current smesher state and layer. A value the node doesn't report is
+// shown with the error.
+func (r *repl) dashboardRows(d *smesherDashboard) []dashboardRow {
+	info, err := r.client.GetMeshInfo(r.ctx)
+	return append(r.smesherRows(d, info), layerRow(info, err))
+}
+
+// printDashboard prints the rows of a dashboard with a title. Values which changed since the
+// previous rows are highlighted and previous is updated.
+func (r *repl) printDashboard(title string, rows []dashboardRow, previous map[string]string) {
+	fmt.Fprintln(r.out, printPrefix, title)
+	for _, row := range rows {
+		if row.value == "" {
+			fmt.Fprintln(r.out, printPrefix, "-- "+row.label+" --")
+			continue
+		}
+		value := row.value
+		if old, ok := previous[row.label]; ok && old != value {
+			value = colorYellow + value + colorReset
+		}
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("%-19s %s", row.label+":", value))
+		previous[row.label] = row.value
+	}
+}
+
+// watchInterval returns the interval given with --interval, or the default one
+func (r *repl) watchInterval(defaultInterval time.Duration) (time.Duration, bool) {
+	s, ok := flagValue(r.args, "--interval")
+	if !ok {
+		return defaultInterval, true
+	}
+	seconds, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || seconds == 0 {
+		fmt.Fprintln(r.out, printPrefix, "invalid interval, expected a number of seconds:", s)
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// watchDashboard shows the rows returned by rows on one screen, refreshed every interval until
+// Enter or Ctrl+C is pressed
+func (r *repl) watchDashboard(title string, interval time.Duration, rows func() []dashboardRow) {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	enter := r.enterPressed()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	previous := make(map[string]string)
+	for {
+		current := rows()
+		fmt.Fprint(r.out, clearScreen)
+		r.printDashboard(fmt.Sprintf("%s at %s, %s", title, r.client.ServerInfo(), time.Now().Format(layerTimeFormat)), current, previous)
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Refreshing every %s, press Enter or Ctrl+C to stop...", interval))
+		select {
+		case <-ticker.C:
+			continue
+		case <-interrupt:
+			fmt.Fprintln(r.out, printPrefix, "Press Enter to return to the prompt.")
+			<-enter
+		case <-enter:
+		}
+		return
+	}
+}
+
+// watchSmesher shows the smesher state on one screen, refreshed every --interval seconds until
+// Enter or Ctrl+C is pressed. Values which changed since the previous refresh are highlighted.
+func (r *repl) watchSmesher() {
+	interval, ok := r.watchInterval(defaultSmesherWatchInterval)
+	if !ok {
+		return
+	}
+	dashboard := &smesherDashboard{}
+	r.watchDashboard("Smesher", interval, func() []dashboardRow {
+		return r.dashboardRows(dashboard)
+	})
+}
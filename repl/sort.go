@@ -0,0 +1,55 @@
+package repl
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Keys listings are sorted by with --sort
+const (
+	sortByLayer  = "layer"
+	sortByAmount = "amount"
+	sortByNonce  = "nonce"
+)
+
+// listingSort is the order of a listing
+type listingSort struct {
+	key  string
+	desc bool
+}
+
+// sortArgs returns the order given with --sort <key> and --desc, one of keys. Without --sort
+// listings are sorted newest layer first.
+func sortArgs(args []string, keys ...string) (listingSort, error) {
+	key, ok := flagValue(args, "--sort")
+	if !ok {
+		return listingSort{key: sortByLayer, desc: true}, nil
+	}
+	for _, k := range keys {
+		if key == k {
+			return listingSort{key: key, desc: hasFlag(args, "--desc")}, nil
+		}
+	}
+	return listingSort{}, fmt.Errorf("can't sort by %s, expected one of %v", key, keys)
+}
+
+// sortedOrder returns the indexes of n items stably sorted by key. Items with equal keys keep
+// their order in both directions. Items without a key come last.
+func sortedOrder(n int, key func(i int) (uint64, bool), desc bool) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ka, okA := key(order[a])
+		kb, okB := key(order[b])
+		if !okA || !okB {
+			return okA && !okB
+		}
+		if desc {
+			return ka > kb
+		}
+		return ka < kb
+	})
+	return order
+}
@@ -0,0 +1,42 @@
+package repl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortedOrder(t *testing.T) {
+	// keys of the items, -1 for an item without a key
+	keys := []int{5, 3, -1, 5, 1, 3}
+	key := func(i int) (uint64, bool) {
+		return uint64(keys[i]), keys[i] >= 0
+	}
+
+	if order := sortedOrder(len(keys), key, false); !reflect.DeepEqual(order, []int{4, 1, 5, 0, 3, 2}) {
+		t.Fatalf("unexpected ascending order %v", order)
+	}
+	if order := sortedOrder(len(keys), key, true); !reflect.DeepEqual(order, []int{0, 3, 1, 5, 4, 2}) {
+		t.Fatalf("unexpected descending order %v", order)
+	}
+	if order := sortedOrder(0, key, true); len(order) != 0 {
+		t.Fatalf("expected an empty order, got %v", order)
+	}
+}
+
+func TestSortArgs(t *testing.T) {
+	s, err := sortArgs(nil, sortByLayer, sortByAmount)
+	if err != nil || s != (listingSort{key: sortByLayer, desc: true}) {
+		t.Fatalf("expected newest layer first by default, got %+v, %v", s, err)
+	}
+	s, err = sortArgs([]string{"--sort", "amount"}, sortByLayer, sortByAmount)
+	if err != nil || s != (listingSort{key: sortByAmount}) {
+		t.Fatalf("expected ascending amount, got %+v, %v", s, err)
+	}
+	s, err = sortArgs([]string{"--sort", "layer", "--desc"}, sortByLayer, sortByAmount)
+	if err != nil || s != (listingSort{key: sortByLayer, desc: true}) {
+		t.Fatalf("expected descending layer, got %+v, %v", s, err)
+	}
+	if _, err := sortArgs([]string{"--sort", "nonce"}, sortByLayer, sortByAmount); err == nil {
+		t.Fatal("expected an error for an unsupported key")
+	}
+}
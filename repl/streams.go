@@ -0,0 +1,160 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// errStreamStopped is returned by a stream receiver when the command following the stream ends
+var errStreamStopped = errors.New("stream stopped")
+
+// streamBackoff is the wait before reopening an interrupted stream. Reconnecting gives up when the
+// attempts, the stream-retries setting, are used up, so a dead node isn't called in a loop.
+var streamBackoff = common.Backoff{Initial: 2 * time.Second, Max: time.Minute}
+
+// streamBackoff returns the wait before reopening an interrupted stream with the attempts of the
+// stream-retries setting
+func (r *repl) streamBackoff() common.Backoff {
+	backoff := streamBackoff
+	backoff.Attempts = r.config().StreamAttempts()
+	return backoff
+}
+
+// streamReceiver receives and handles the next message of a stream
+type streamReceiver func() error
+
+// permanentStreamError tells whether the node will keep returning a stream error, e.g. because it
+// doesn't implement the stream, so reconnecting is pointless
+func permanentStreamError(err error) bool {
+	c := status.Code(err)
+	return c == codes.Unimplemented || c == codes.PermissionDenied || c == codes.Unauthenticated
+}
+
+// followStream opens a stream with open and calls the receiver it returns until it fails. A stream
+// interrupted by a transient error is opened again with backoff. When it is back gap is called,
+// which may print what was missed meanwhile, and the notice it returns is printed. It returns
+// errStreamStopped when stop is closed, otherwise the error which ended streaming.
+func (r *repl) followStream(name string, backoff common.Backoff, open func() (streamReceiver, error), gap func() string, stop <-chan struct{}) error {
+	attempt := 0
+	for {
+		recv, err := open()
+		if err == nil && attempt > 0 {
+			fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("The %s stream reconnected.", name))
+			if notice := gap(); notice != "" {
+				fmt.Fprintln(r.out, printPrefix, notice)
+			}
+		}
+		for err == nil {
+			if err = recv(); err == nil {
+				attempt = 0
+			}
+		}
+		if err == errStreamStopped || status.Code(err) == codes.Canceled {
+			// the command following the stream ended
+			return errStreamStopped
+		}
+		if permanentStreamError(err) {
+			return err
+		}
+		attempt++
+		delay, ok := backoff.Delay(attempt)
+		if !ok {
+			return fmt.Errorf("gave up reconnecting the %s stream after %d attempts: %v", name, attempt-1, err)
+		}
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("The %s stream was interrupted: %v. Reconnecting in %v...", name, err, delay))
+		select {
+		case <-time.After(delay):
+		case <-stop:
+			return errStreamStopped
+		}
+	}
+}
+
+// followInBackground opens a stream with open and follows it in the background until it fails or
+// the background streams are stopped. The stream, and the calls of gap, get a context of their own,
+// since they outlive the command. Following a stream with the same description again replaces it.
+// It returns the error when the stream can't be opened.
+func (r *repl) followInBackground(description, name string, open func(ctx context.Context) (streamReceiver, error), gap func(ctx context.Context) string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	first, err := open(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	stop := make(chan struct{})
+	r.streamsMu.Lock()
+	if r.backgroundStreams == nil {
+		r.backgroundStreams = make(map[string]chan struct{})
+	}
+	if previous, ok := r.backgroundStreams[description]; ok {
+		close(previous)
+	}
+	r.backgroundStreams[description] = stop
+	r.streamsMu.Unlock()
+
+	reopen := func() (streamReceiver, error) {
+		recv := first
+		first = nil
+		if recv == nil {
+			var err error
+			if recv, err = open(ctx); err != nil {
+				return nil, err
+			}
+		}
+		return func() error {
+			err := recv()
+			select {
+			case <-stop:
+				return errStreamStopped
+			default:
+				return err
+			}
+		}, nil
+	}
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	backoff := r.streamBackoff()
+	go func() {
+		err := r.followStream(name, backoff, reopen, func() string { return gap(ctx) }, stop)
+		cancel()
+		r.streamsMu.Lock()
+		if r.backgroundStreams[description] == stop {
+			delete(r.backgroundStreams, description)
+		}
+		r.streamsMu.Unlock()
+		if err != errStreamStopped {
+			log.Error("stopped following the %s: %v", description, err)
+		}
+	}()
+	return nil
+}
+
+// stopBackgroundStreams stops all streams printing in the background and returns their
+// descriptions
+func (r *repl) stopBackgroundStreams() []string {
+	r.streamsMu.Lock()
+	defer r.streamsMu.Unlock()
+	descriptions := make([]string, 0, len(r.backgroundStreams))
+	for description, stop := range r.backgroundStreams {
+		close(stop)
+		descriptions = append(descriptions, description)
+	}
+	r.backgroundStreams = nil
+	sort.Strings(descriptions)
+	return descriptions
+}
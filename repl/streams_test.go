@@ -0,0 +1,176 @@
+package repl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/repl/clienttest"
+)
+
+// scriptedStream is a fake stream which opens with the errors of opens and then sends the messages
+// of each connection in turn, failing with the error ending the connection
+type scriptedStream struct {
+	opens       []error
+	connections []scriptedConnection
+	received    []string
+}
+
+type scriptedConnection struct {
+	messages []string
+	end      error
+}
+
+// open opens the next connection of the script
+func (s *scriptedStream) open() (streamReceiver, error) {
+	if len(s.opens) > 0 {
+		err := s.opens[0]
+		s.opens = s.opens[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(s.connections) == 0 {
+		return nil, status.Error(codes.Unavailable, "script ended")
+	}
+	c := s.connections[0]
+	s.connections = s.connections[1:]
+	return func() error {
+		if len(c.messages) == 0 {
+			return c.end
+		}
+		s.received = append(s.received, c.messages[0])
+		c.messages = c.messages[1:]
+		return nil
+	}, nil
+}
+
+// testBackoff retries at once
+var testBackoff = common.Backoff{Initial: time.Millisecond, Max: time.Millisecond, Attempts: 3}
+
+func TestFollowStreamReconnects(t *testing.T) {
+	r, _ := newTestRepl(clienttest.New())
+	unavailable := status.Error(codes.Unavailable, "node restarting")
+	s := &scriptedStream{
+		opens: []error{nil, unavailable, nil},
+		connections: []scriptedConnection{
+			{messages: []string{"a", "b"}, end: unavailable},
+			{messages: []string{"c"}, end: status.Error(codes.Unimplemented, "gone")},
+		},
+	}
+	gaps := 0
+	err := r.followStream("test", testBackoff, s.open, func() string { gaps++; return "" }, make(chan struct{}))
+	if status.Code(err) != codes.Unimplemented {
+		t.Fatalf("expected the permanent error to end streaming, got %v", err)
+	}
+	if strings.Join(s.received, "") != "abc" || gaps != 1 {
+		t.Fatalf("expected all messages and one gap after reconnecting, got %v and %d gaps", s.received, gaps)
+	}
+}
+
+func TestFollowStreamGivesUp(t *testing.T) {
+	r, _ := newTestRepl(clienttest.New())
+	unavailable := status.Error(codes.Unavailable, "node down")
+	s := &scriptedStream{
+		opens:       []error{nil, unavailable, unavailable, unavailable},
+		connections: []scriptedConnection{{messages: []string{"a"}, end: unavailable}},
+	}
+	gaps := 0
+	err := r.followStream("test", testBackoff, s.open, func() string { gaps++; return "" }, make(chan struct{}))
+	if err == nil || !strings.Contains(err.Error(), "gave up reconnecting the test stream after 3 attempts") {
+		t.Fatalf("expected to give up after 3 attempts, got %v", err)
+	}
+	if gaps != 0 {
+		t.Fatalf("expected no gap without a reconnection, got %d", gaps)
+	}
+
+	// a received message resets the attempts
+	s = &scriptedStream{
+		opens: []error{nil, unavailable, unavailable, nil, unavailable, unavailable, nil},
+		connections: []scriptedConnection{
+			{messages: []string{"a"}, end: unavailable},
+			{messages: []string{"b"}, end: unavailable},
+			{messages: []string{"c"}, end: errStreamStopped},
+		},
+	}
+	if err := r.followStream("test", testBackoff, s.open, func() string { return "" }, make(chan struct{})); err != errStreamStopped {
+		t.Fatalf("expected the stream to recover after each message, got %v", err)
+	}
+}
+
+func TestFollowStreamStops(t *testing.T) {
+	r, _ := newTestRepl(clienttest.New())
+	stop := make(chan struct{})
+	close(stop)
+	s := &scriptedStream{connections: []scriptedConnection{{end: status.Error(codes.Unavailable, "down")}}}
+	backoff := common.Backoff{Initial: time.Hour, Max: time.Hour, Attempts: 3}
+	if err := r.followStream("test", backoff, s.open, func() string { return "" }, stop); err != errStreamStopped {
+		t.Fatalf("expected stopping to end the wait for a reconnection, got %v", err)
+	}
+	s = &scriptedStream{connections: []scriptedConnection{{end: status.Error(codes.Canceled, "cancelled")}}}
+	if err := r.followStream("test", testBackoff, s.open, func() string { return "" }, make(chan struct{})); err != errStreamStopped {
+		t.Fatalf("expected a cancelled stream to be stopped, got %v", err)
+	}
+	s = &scriptedStream{connections: []scriptedConnection{{end: errors.New("broken")}}, opens: []error{nil, status.Error(codes.PermissionDenied, "no")}}
+	if err := r.followStream("test", testBackoff, s.open, func() string { return "" }, make(chan struct{})); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected a permanent error when reopening to end streaming, got %v", err)
+	}
+}
+
+func TestMissedRewards(t *testing.T) {
+	reward := func(layer uint32) *apitypes.Reward {
+		return &apitypes.Reward{Layer: &apitypes.LayerNumber{Number: layer}}
+	}
+	missed := missedRewards([]*apitypes.Reward{reward(12), reward(10), reward(11), reward(9)}, 10)
+	if len(missed) != 2 || missed[0].Layer.Number != 11 || missed[1].Layer.Number != 12 {
+		t.Fatalf("expected the rewards of layers 11 and 12 in order, got %v", missed)
+	}
+	if len(missedRewards([]*apitypes.Reward{reward(3)}, 3)) != 0 {
+		t.Fatal("expected no rewards missed up to the last layer seen")
+	}
+}
+
+// waitForStreams waits until the fake has n open streams
+func waitForStreams(t *testing.T, f *clienttest.Fake, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for f.OpenStreams() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d open streams, got %d", n, f.OpenStreams())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStreamsClosedOnCancel(t *testing.T) {
+	f := clienttest.New()
+	r, output := newTestRepl(f)
+	r.commands = append(r.commands, command{commandStateRoot, "follow", commandStateLeaf, "", func() {
+		if _, err := r.client.LayerStream(r.ctx); err != nil {
+			t.Error(err)
+		}
+		waitForStreams(t, f, 1)
+	}})
+
+	// the stream of a command is closed when the command returns
+	r.executor("follow")
+	waitForStreams(t, f, 0)
+
+	// a background stream outlives its command and is closed when the background streams stop,
+	// as quit does
+	r.executor("state stream " + otherAddress.Hex())
+	if out := output.String(); !strings.Contains(out, "Listening") {
+		t.Fatalf("expected the stream to start, got:\n%s", out)
+	}
+	waitForStreams(t, f, 1)
+	if stopped := r.stopBackgroundStreams(); len(stopped) != 1 {
+		t.Fatalf("expected one background stream, got %v", stopped)
+	}
+	waitForStreams(t, f, 0)
+}
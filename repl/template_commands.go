@@ -0,0 +1,86 @@
+package repl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// saveTemplate saves the last transaction sent this session as a named template
+func (r *repl) saveTemplate(name, note string) {
+	if r.lastSent == nil {
+		fmt.Fprintln(r.out, printPrefix, "No transaction was sent this session. Send one first, or use send-coin --save-template <name>.")
+		return
+	}
+	t := *r.lastSent
+	t.Name = name
+	t.Note = strings.TrimSpace(note)
+	if err := r.client.AddTxTemplate(t); err != nil {
+		log.Error("failed to save template: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Saved template %s: %s to %s", strings.TrimSpace(name), common.FormatAmount(t.Amount), r.addressString(t.RecipientAddress())))
+}
+
+// saveLastSentTemplate saves the last transaction sent this session as a template, with the
+// arguments after the name as its note
+func (r *repl) saveLastSentTemplate() {
+	if len(r.args) < 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: template save <name> [note]")
+		return
+	}
+	r.saveTemplate(r.args[0], strings.Join(r.args[1:], " "))
+}
+
+// useTemplate sends the transaction of a template from the current account. The recipient, amount
+// and gas settings come from the template. The nonce is looked up again.
+func (r *repl) useTemplate() {
+	args := positionalArgs(r.args, "--nonce", "--timeout")
+	if len(args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: template use <name> [--nonce <n>] [--dry-run] [--wait [--timeout <duration>]]")
+		return
+	}
+	t, err := r.client.TxTemplate(args[0])
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	recipient := t.RecipientAddress()
+	r.sendCoins(&recipient, "", strconv.FormatUint(t.Amount, 10), t)
+}
+
+// listTemplates prints the transaction templates
+func (r *repl) listTemplates() {
+	templates, err := r.client.TxTemplates()
+	if err != nil {
+		log.Error("failed to read templates: %v", err)
+		return
+	}
+	if len(templates) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "There are no templates")
+		return
+	}
+	for _, t := range templates {
+		line := fmt.Sprintf("%s: %s to %s, gas: %d x %d", t.Name, common.FormatAmount(t.Amount), r.addressString(t.RecipientAddress()), t.GasPrice, t.GasLimit)
+		if t.Note != "" {
+			line += ", " + t.Note
+		}
+		fmt.Fprintln(r.out, printPrefix, line)
+	}
+}
+
+// deleteTemplate removes a transaction template
+func (r *repl) deleteTemplate() {
+	if len(r.args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: template delete <name>")
+		return
+	}
+	if err := r.client.DeleteTxTemplate(r.args[0]); err != nil {
+		log.Error("failed to delete template: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, "Deleted template", r.args[0])
+}
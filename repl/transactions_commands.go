@@ -4,11 +4,12 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strconv"
+	"strings"
 
 	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
-	"github.com/spacemeshos/go-spacemesh/common/util"
 
 	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/smrepl/common"
 	"github.com/spacemeshos/smrepl/log"
 )
 
@@ -22,11 +23,32 @@ var transactionStateDisStringsMap = map[int32]string{
 	6: "Processed",
 }
 
-// Print a transaction status
+// txIDLength is the length in bytes of a transaction id
+const txIDLength = 32
+
+// parseTxID parses a hex transaction id with or without the 0x prefix
+func parseTxID(s string) ([]byte, error) {
+	id, err := hex.DecodeString(trimHexPrefix(strings.TrimSpace(s)))
+	if err != nil || len(id) != txIDLength {
+		return nil, fmt.Errorf("invalid transaction id %s, expected %d hex bytes", s, txIDLength)
+	}
+	return id, nil
+}
+
+// Print a transaction status and, when the node knows it, the full transaction
 func (r *repl) printTransactionStatus() {
-	txIdStr := inputNotBlank(txIdMsg)
-	txId := util.FromHex(txIdStr)
-	txState, tx, err := r.client.TransactionState(txId, true)
+	var txIdStr string
+	if len(r.args) > 0 {
+		txIdStr = r.args[0]
+	} else {
+		txIdStr = r.inputNotBlank(txIdMsg)
+	}
+	txId, err := parseTxID(txIdStr)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	txState, tx, err := r.client.TransactionState(r.ctx, txId, true)
 	if err != nil {
 		log.Error(err.Error())
 		return
@@ -34,15 +56,54 @@ func (r *repl) printTransactionStatus() {
 
 	if txState != nil {
 		txStateDispString := transactionStateDisStringsMap[int32(txState.State.Number())]
-		fmt.Println(printPrefix, "State:", txStateDispString)
+		fmt.Fprintln(r.out, printPrefix, "State:", txStateDispString)
 	} else {
-		fmt.Println(printPrefix, "Unknown transaction state")
+		fmt.Fprintln(r.out, printPrefix, "Unknown transaction state")
 	}
 
 	if tx != nil {
-		printTransaction(tx)
+		r.printTransactionDetail(tx)
 	} else {
-		fmt.Println(printPrefix, "Unknown transaction")
+		fmt.Fprintln(r.out, printPrefix, "The node doesn't know the content of this transaction yet")
+	}
+}
+
+// transactionReceipt looks up the receipt of a transaction among the receipts of its sender. It
+// returns nil when there is none.
+func (r *repl) transactionReceipt(sender gosmtypes.Address, id []byte) *apitypes.TransactionReceipt {
+	receipts, err := allReceipts(r.ctx, r.client, sender)
+	if err != nil {
+		return nil
+	}
+	return receipts[string(id)]
+}
+
+// printTransactionDetail prints every field of a transaction
+func (r *repl) printTransactionDetail(t *apitypes.Transaction) {
+	sender := gosmtypes.BytesToAddress(t.GetSender().GetAddress())
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Transaction id: 0x%x", t.GetId().GetId()))
+	fmt.Fprintln(r.out, printPrefix, "From:", r.addressString(sender))
+	r.printTransactionType(t)
+	fmt.Fprintln(r.out, printPrefix, "Amount:", common.FormatSMHAndSmidge(t.GetAmount().GetValue()))
+	if t.GasOffered != nil {
+		fmt.Fprintln(r.out, printPrefix, "Gas price:", t.GasOffered.GasPrice, coinUnitName)
+		fmt.Fprintln(r.out, printPrefix, "Gas limit:", t.GasOffered.GasProvided)
+	}
+	fmt.Fprintln(r.out, printPrefix, "Nonce:", t.Counter)
+	receipt := r.transactionReceipt(sender, t.GetId().GetId())
+	fmt.Fprintln(r.out, printPrefix, "Fee:", transactionFee(t, receipt))
+	if receipt.GetLayer() != nil {
+		layer := receipt.GetLayer().GetNumber()
+		if info, err := r.client.GetMeshInfo(r.ctx); err == nil {
+			fmt.Fprintln(r.out, printPrefix, "Layer:", layer, "at about", formatTime(info.LayerTime(layer)))
+		} else {
+			fmt.Fprintln(r.out, printPrefix, "Layer:", layer)
+		}
+	} else {
+		fmt.Fprintln(r.out, printPrefix, "Layer: not known")
+	}
+	if t.Signature != nil {
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Signature: 0x%x", t.Signature.Signature))
 	}
 }
 
@@ -50,7 +111,7 @@ func (r *repl) printTransactionStatus() {
 // todo: this should move to a method in the transactions service.
 func (r *repl) canSubmitTransactions() bool {
 
-	status, err := r.client.NodeStatus()
+	status, err := r.client.NodeStatus(r.ctx)
 	if err != nil {
 		log.Error("failed to get node status: %v", err)
 		return false
@@ -60,87 +121,334 @@ func (r *repl) canSubmitTransactions() bool {
 	return status.IsSynced //&& status.TopLayer.Number > minVerifiedLayer
 }
 
+// printSubmitProgress prints the retries of a transaction submission failing with a transient error
+func (r *repl) printSubmitProgress(p common.SubmitProgress) {
+	switch {
+	case p.Landed:
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Attempt %d reached the node after all, not submitting again.", p.Attempt))
+	case p.Err != nil:
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Attempt %d failed: %v. Retrying in %v...", p.Attempt, p.Err, p.RetryIn))
+	default:
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Transaction submitted on attempt %d.", p.Attempt))
+	}
+}
+
+const (
+	// defaultGasPrice and defaultGasLimit are used when neither the account nor the settings have defaults
+	defaultGasPrice = 1
+	defaultGasLimit = common.DefaultTransferGasLimit
+)
+
+// txSetting is a transaction gas or nonce value and where it came from
+type txSetting struct {
+	value  uint64
+	source string
+}
+
+// defaultGas returns the default gas price and gas limit of an account's transactions. Account
+// defaults take precedence over the defaults in the settings, which take precedence over the
+// built-in defaults.
+func (r *repl) defaultGas(acc *common.LocalAccount) (txSetting, txSetting) {
+	config := r.config()
+	pick := func(account, configured, builtin uint64) txSetting {
+		switch {
+		case account != 0:
+			return txSetting{account, "account default"}
+		case configured != 0:
+			return txSetting{configured, "config default"}
+		default:
+			return txSetting{builtin, "default"}
+		}
+	}
+	return pick(acc.GasPrice, config.GasPrice, defaultGasPrice), pick(acc.GasLimit, config.GasLimit, defaultGasLimit)
+}
+
+// inputSetting prompts for a gas or nonce value
+func (r *repl) inputSetting(msg string) (txSetting, error) {
+	value, err := strconv.ParseUint(r.inputNotBlank(msg), 10, 64)
+	if err != nil {
+		return txSetting{}, err
+	}
+	return txSetting{value, "entered"}, nil
+}
+
+// inputGasLimit prompts for a gas limit with a suggested value already entered
+func (r *repl) inputGasLimit(suggested uint64) (txSetting, error) {
+	value, err := strconv.ParseUint(strings.TrimSpace(r.inputWithDefault(enterGasLimit, strconv.FormatUint(suggested, 10))), 10, 64)
+	if err != nil {
+		return txSetting{}, err
+	}
+	return txSetting{value, "entered"}, nil
+}
+
+// confirmGasLimit prints the warnings about a gas limit and asks to continue. It returns true when
+// there is no warning or the user confirmed.
+func (r *repl) confirmGasLimit(limit uint64) bool {
+	warnings := common.CheckGasLimit(limit, defaultGasLimit)
+	if len(warnings) == 0 {
+		return true
+	}
+	for _, w := range warnings {
+		fmt.Fprintln(r.out, printPrefix, "WARNING:", w)
+	}
+	return r.yesOrNoQuestion(gasLimitWarningMsg) == "y"
+}
+
+// confirmNonce checks an entered nonce against the account counter and the nonces reserved by
+// pending transactions. A nonce that is already used is rejected, a nonce that collides with a
+// pending transaction or leaves a gap needs confirmation. It returns true when the transaction may
+// be sent.
+func (r *repl) confirmNonce(address gosmtypes.Address, nonce txSetting) bool {
+	if nonce.source != "entered" {
+		return true
+	}
+	state, err := r.client.AccountState(r.ctx, address)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "WARNING: can't check the nonce against the account:", err)
+		return true
+	}
+	next, err := r.client.NextNonce(address, state.StateProjected.Counter)
+	if err != nil {
+		next = state.StateProjected.Counter
+	}
+	check := common.CheckNonce(nonce.value, state.StateCurrent.Counter, next)
+	if check.Reject != "" {
+		fmt.Fprintln(r.out, printPrefix, "ERROR:", check.Reject)
+		return false
+	}
+	if check.Warning == "" {
+		return true
+	}
+	fmt.Fprintln(r.out, printPrefix, "WARNING:", check.Warning)
+	return r.yesOrNoQuestion(nonceWarningMsg) == "y"
+}
+
+// transactionNonce returns the nonce of the next transaction of an account. The nonce given with
+// --nonce wins, e.g. to replace a stuck transaction. Otherwise it is the projected counter of the
+// account or the nonce after the last one submitted from this wallet, whichever is higher. The
+// user is prompted for it when the node can't be reached.
+func (r *repl) transactionNonce(address gosmtypes.Address) (txSetting, error) {
+	if s, ok := flagValue(r.args, "--nonce"); ok {
+		value, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return txSetting{}, err
+		}
+		return txSetting{value, "entered"}, nil
+	}
+	state, err := r.client.AccountState(r.ctx, address)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "Can't get the account nonce from the node:", err)
+		return r.inputSetting(enterNonceMsg)
+	}
+	projected := state.StateProjected.Counter
+	nonce, err := r.client.NextNonce(address, projected)
+	if err != nil {
+		return txSetting{}, err
+	}
+	if nonce > projected {
+		return txSetting{nonce, "after pending transactions from this wallet"}, nil
+	}
+	return txSetting{nonce, "from node"}, nil
+}
+
+// sweepAmountArg is the amount entered to send the whole balance of an account minus the fee
+const sweepAmountArg = "max"
+
 func (r *repl) submitCoinTransaction() {
+	r.sendCoins(nil, "", "", nil)
+}
 
+// sweepAccount sends the projected balance of the current account minus the maximum fee
+func (r *repl) sweepAccount() {
+	args := positionalArgs(r.args, "--nonce", "--timeout")
+	if len(args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: account sweep <recipient> [--nonce <n>] [--dry-run] [--wait [--timeout <duration>]]")
+		return
+	}
+	destAddress, destName, err := r.resolveRecipient(args[0])
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	r.sendCoins(&destAddress, destName, sweepAmountArg, nil)
+}
+
+// sendCoins sends coins from the current account. The recipient and amount are prompted for when
+// not provided. destName tells where the recipient address was found, if anywhere. The amount max
+// sends the projected balance minus the maximum fee. A template provides the gas settings, so only
+// the nonce is looked up and the transaction confirmed.
+func (r *repl) sendCoins(dest *gosmtypes.Address, destName string, amountStr string, template *common.TxTemplate) {
+	timeout, err := waitTimeout(r.args)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
 	if !r.canSubmitTransactions() {
-		fmt.Println(printPrefix, "Can't submit a new transaction. Please try again later")
+		fmt.Fprintln(r.out, printPrefix, "Can't submit a new transaction. Please try again later")
 		return
 	}
-	fmt.Println(printPrefix, initialTransferMsg)
+	fmt.Fprintln(r.out, printPrefix, initialTransferMsg)
 	acc, err := r.getCurrent()
 	if err != nil {
 		log.Error("failed to get account", err)
 		return
 	}
+	key, err := acc.SigningKey()
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	defer key.Release()
 
-	srcAddress := gosmtypes.BytesToAddress(acc.PubKey)
-	acctState, err := r.client.AccountState(srcAddress)
+	srcAddress := acc.Address()
+	nonce, err := r.transactionNonce(srcAddress)
 	if err != nil {
-		log.Error("failed to get account info: %v", err)
+		log.Error("invalid nonce", err)
 		return
 	}
 
-	destAddressStr := inputNotBlank(destAddressMsg)
-	destAddress := gosmtypes.HexToAddress(destAddressStr)
+	var destAddress gosmtypes.Address
+	if dest != nil {
+		destAddress = *dest
+	} else {
+		destAddress, destName = r.inputRecipient(destAddressMsg)
+	}
+	if amountStr == "" {
+		amountStr = r.inputNotBlank(amountToTransferMsg)
+	}
 
-	amountStr := inputNotBlank(amountToTransferMsg)
+	gasPrice, gasLimit := r.defaultGas(acc)
+	if template != nil {
+		gasPrice = txSetting{template.GasPrice, "template " + template.Name}
+		gasLimit = txSetting{template.GasLimit, "template " + template.Name}
+	} else if gasPrice.source == "default" {
+		if suggested, ok := r.suggestedGasPrice(); ok {
+			gasPrice = suggested
+		}
+	}
+	if template == nil && r.yesOrNoQuestion(fmt.Sprintf(useDefaultGasMsg, gasPrice.value, gasLimit.value)) == "n" {
+		if gasPrice, err = r.inputSetting(enterGasPrice); err != nil {
+			log.Error("invalid gas price", err)
+			return
+		}
+		if gasLimit, err = r.inputGasLimit(gasLimit.value); err != nil {
+			log.Error("invalid gas limit", err)
+			return
+		}
+	}
 
-	gas := uint64(1)
-	if yesOrNoQuestion(useDefaultGasMsg) == "n" {
-		gasStr := inputNotBlank(enterGasPrice)
-		gas, err = strconv.ParseUint(gasStr, 10, 64)
+	var amount uint64
+	amountSource := ""
+	if strings.EqualFold(strings.TrimSpace(amountStr), sweepAmountArg) {
+		state, err := r.client.AccountState(r.ctx, srcAddress)
 		if err != nil {
-			log.Error("invalid transaction fee", err)
+			log.Error("failed to get account info: %v", err)
+			return
+		}
+		balance := uint64(0)
+		if state.StateProjected.Balance != nil {
+			balance = state.StateProjected.Balance.Value
+		}
+		if amount, err = common.SweepAmount(balance, gasPrice.value, gasLimit.value); err != nil {
+			fmt.Fprintln(r.out, printPrefix, "Can't send the whole balance:", err)
 			return
 		}
+		amountSource = fmt.Sprintf(" (projected balance %s minus maximum fee %s)", common.FormatSmidge(balance), common.FormatSmidge(balance-amount))
+	} else if amount, err = strconv.ParseUint(amountStr, 10, 64); err != nil {
+		log.Error("invalid amount: %v", err)
+		return
 	}
 
-	fmt.Println(printPrefix, "New transaction summary:")
-	fmt.Println(printPrefix, "From:  ", srcAddress.String())
-	fmt.Println(printPrefix, "To:    ", destAddress.String())
-	fmt.Println(printPrefix, "Amount:", amountStr, coinUnitName)
-	fmt.Println(printPrefix, "Fee:   ", gas, coinUnitName)
-	fmt.Println(printPrefix, "Nonce: ", acctState.StateProjected.Counter)
+	fmt.Fprintln(r.out, printPrefix, "New transaction summary:")
+	fmt.Fprintln(r.out, printPrefix, "From:  ", r.formatAddress(srcAddress))
+	if destName != "" {
+		fmt.Fprintln(r.out, printPrefix, "To:    ", r.formatAddress(destAddress), "("+destName+")")
+	} else {
+		fmt.Fprintln(r.out, printPrefix, "To:    ", r.addressString(destAddress))
+	}
+	fmt.Fprintln(r.out, printPrefix, "Amount:", common.FormatSmidge(amount)+amountSource)
+	if template != nil && template.Note != "" {
+		fmt.Fprintln(r.out, printPrefix, "Note:  ", template.Note)
+	}
+	fmt.Fprintln(r.out, printPrefix, "Gas price:", gasPrice.value, coinUnitName, "("+gasPrice.source+")")
+	fmt.Fprintln(r.out, printPrefix, "Gas limit:", gasLimit.value, "("+gasLimit.source+")")
+	fmt.Fprintln(r.out, printPrefix, "Nonce: ", nonce.value, "("+nonce.source+")")
+	fmt.Fprintln(r.out, printPrefix, "Maximum fee:", common.FormatAmount(gasPrice.value*gasLimit.value), "(gas limit × gas price)")
 
-	amount, _ := strconv.ParseUint(amountStr, 10, 64)
-	// todo: handle error here!
+	if !r.confirmNonce(srcAddress, nonce) || !r.confirmGasLimit(gasLimit.value) || !r.confirmRecipients(srcAddress, destAddress) {
+		return
+	}
 
-	if yesOrNoQuestion(confirmTransactionMsg) == "y" {
-		txState, err := r.client.Transfer(destAddress, acctState.StateProjected.Counter, amount, gas, 100, acc.PrivKey)
+	if hasFlag(r.args, "--dry-run") {
+		r.dryRunTransaction(srcAddress, &common.TxRequest{
+			Recipient: destAddress,
+			Amount:    amount,
+			GasPrice:  gasPrice.value,
+			GasLimit:  gasLimit.value,
+			Nonce:     nonce.value,
+		}, key)
+		return
+	}
+
+	if !r.confirmSpendLimit(amount) {
+		return
+	}
+	tx, err := r.client.SignTransfer(destAddress, nonce.value, amount, gasPrice.value, gasLimit.value, key)
+	if err != nil {
+		log.Error("failed to sign transaction: %v", err)
+		return
+	}
+	if hasFlag(r.args, "--show-raw") {
+		r.printRawTransaction(tx)
+	}
+	if r.yesOrNoQuestion(confirmTransactionMsg) == "y" {
+		txState, err := r.client.SubmitTransfer(r.ctx, tx)
 		if err != nil {
-			log.Error(err.Error())
+			r.printNodeError(err)
 			return
 		}
 
 		txStateDispString := transactionStateDisStringsMap[int32(txState.State.Number())]
 
-		fmt.Println(printPrefix, "Transaction submitted.")
-		fmt.Println(printPrefix, fmt.Sprintf("Transaction id: 0x%v", hex.EncodeToString(txState.Id.Id)))
-		fmt.Println(printPrefix, "Transaction state:", txStateDispString)
+		fmt.Fprintln(r.out, printPrefix, "Transaction submitted.")
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Transaction id: 0x%v", hex.EncodeToString(txState.Id.Id)))
+		fmt.Fprintln(r.out, printPrefix, "Transaction state:", txStateDispString)
+		r.lastSent = &common.TxTemplate{Recipient: destAddress.Hex(), Amount: amount, GasPrice: gasPrice.value, GasLimit: gasLimit.value}
+		if name, ok := flagValue(r.args, "--save-template"); ok {
+			r.saveTemplate(name, r.inputWithDefault(templateNoteMsg, ""))
+		}
+		if hasFlag(r.args, "--wait") {
+			r.waitForTransaction(txState.Id.Id, timeout)
+		}
 	}
 }
 
-// helper method - prints tx info
-func printTransaction(t *apitypes.Transaction) {
-
-	txIdStr := "0x" + util.Bytes2Hex(t.Id.Id)
-	fmt.Println(printPrefix, fmt.Sprintf("Transaction id: %v", txIdStr))
-	fmt.Println(printPrefix, "From:", gosmtypes.BytesToAddress(t.Sender.Address).String())
-
-	ct := t.GetCoinTransfer()
-	if ct != nil {
-		fmt.Println(printPrefix, "To (coin account):", gosmtypes.BytesToAddress(ct.Receiver.Address).String())
-		fmt.Println(printPrefix, "Nonce:", t.Counter)
-		fmt.Println(printPrefix, "Amount:", t.Amount.Value, coinUnitName)
-		fmt.Println(printPrefix, "Fee:", t.GasOffered.GasProvided, coinUnitName)
-		return
-	}
-
-	sct := t.GetSmartContract()
-	if sct == nil {
-		log.Error("expected a smart contract transaction type")
-		return
+// printTransactionType prints the type of a transaction and the fields specific to it: the
+// recipient of a coin transfer, the template and data of a smart contract transaction. Unknown
+// types are printed with their Go type instead of being treated as transfers.
+func (r *repl) printTransactionType(t *apitypes.Transaction) {
+	switch datum := t.Datum.(type) {
+	case *apitypes.Transaction_CoinTransfer:
+		fmt.Fprintln(r.out, printPrefix, "Type: coin transfer")
+		fmt.Fprintln(r.out, printPrefix, "To (coin account):", r.addressString(gosmtypes.BytesToAddress(datum.CoinTransfer.GetReceiver().GetAddress())))
+	case *apitypes.Transaction_SmartContract:
+		sct := datum.SmartContract
+		fmt.Fprintln(r.out, printPrefix, "Type: smart contract,", sct.GetType().String())
+		if address := sct.GetAccountId().GetAddress(); address != nil {
+			fmt.Fprintln(r.out, printPrefix, "Template:", r.addressString(gosmtypes.BytesToAddress(address)))
+		}
+		fmt.Fprintln(r.out, printPrefix, "Data:", len(sct.GetData()), "bytes")
+	default:
+		fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Type: unknown tx type %T", t.Datum))
 	}
+}
 
-	// todo: printout smart contract transaction data here
+// helper method - prints tx info
+func (r *repl) printTransaction(t *apitypes.Transaction, fee common.TxFee) {
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Transaction id: 0x%x", t.GetId().GetId()))
+	fmt.Fprintln(r.out, printPrefix, "From:", r.addressString(gosmtypes.BytesToAddress(t.GetSender().GetAddress())))
+	r.printTransactionType(t)
+	fmt.Fprintln(r.out, printPrefix, "Nonce:", t.Counter)
+	fmt.Fprintln(r.out, printPrefix, "Amount:", common.FormatSmidge(t.GetAmount().GetValue()))
+	fmt.Fprintln(r.out, printPrefix, "Fee:", fee)
 }
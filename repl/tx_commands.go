@@ -0,0 +1,179 @@
+package repl
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// signedTxFileSuffix is appended to a transaction request file name to get the name of the signed transaction file
+const signedTxFileSuffix = ".signed"
+
+// signedTxEnvelope is the JSON layout of a signed transaction file written with --json
+type signedTxEnvelope struct {
+	ID string `json:"id"`
+	Tx string `json:"tx"`
+}
+
+// signTransactionFile signs the transaction described in a JSON file with the current account.
+// It doesn't talk to the node so it can be used on an offline machine.
+func (r *repl) signTransactionFile() {
+	args := positionalArgs(r.args, "--out")
+	if len(args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: tx sign <file> [--out <path>] [--json]")
+		return
+	}
+	path := args[0]
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Error("failed to read transaction file: %v", err)
+		return
+	}
+	req, err := common.ParseTxRequest(data)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	key, err := acc.SigningKey()
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	defer key.Release()
+
+	fmt.Fprintln(r.out, printPrefix, "Transaction to sign:")
+	fmt.Fprintln(r.out, printPrefix, "From:  ", r.formatAddress(acc.Address()))
+	fmt.Fprintln(r.out, printPrefix, "To:    ", r.addressString(req.Recipient))
+	fmt.Fprintln(r.out, printPrefix, "Amount:", common.FormatSmidge(req.Amount))
+	fmt.Fprintln(r.out, printPrefix, "Gas price:", req.GasPrice, coinUnitName)
+	fmt.Fprintln(r.out, printPrefix, "Gas limit:", req.GasLimit)
+	fmt.Fprintln(r.out, printPrefix, "Nonce: ", req.Nonce)
+	if r.yesOrNoQuestion(confirmSignTransactionMsg) != "y" {
+		return
+	}
+
+	tx, err := r.client.SignTransfer(req.Recipient, req.Nonce, req.Amount, req.GasPrice, req.GasLimit, key)
+	if err != nil {
+		log.Error("failed to sign transaction: %v", err)
+		return
+	}
+
+	out := fmt.Sprintf("%x\n", tx.Signed)
+	if hasFlag(r.args, "--json") {
+		b, err := json.MarshalIndent(signedTxEnvelope{
+			ID: "0x" + hex.EncodeToString(tx.ID),
+			Tx: hex.EncodeToString(tx.Signed),
+		}, "", "  ")
+		if err != nil {
+			log.Error("failed to encode signed transaction: %v", err)
+			return
+		}
+		out = string(b) + "\n"
+	}
+
+	outPath, ok := flagValue(r.args, "--out")
+	if !ok {
+		outPath = path + signedTxFileSuffix
+	}
+	if err := ioutil.WriteFile(outPath, []byte(out), 0644); err != nil {
+		log.Error("failed to write signed transaction: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Transaction id: 0x%x", tx.ID))
+	fmt.Fprintln(r.out, printPrefix, "Signed transaction written to:", outPath)
+}
+
+// offlineGas returns the gas price and gas limit of a transaction signed offline: the values of
+// --gas-price and --gas-limit, or the defaults of the account
+func (r *repl) offlineGas(acc *common.LocalAccount) (txSetting, txSetting, error) {
+	gasPrice, gasLimit := r.defaultGas(acc)
+	for _, g := range []struct {
+		flag    string
+		setting *txSetting
+	}{{"--gas-price", &gasPrice}, {"--gas-limit", &gasLimit}} {
+		if s, ok := flagValue(r.args, g.flag); ok {
+			value, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				return txSetting{}, txSetting{}, fmt.Errorf("invalid %s: %s", g.flag[2:], s)
+			}
+			*g.setting = txSetting{value, "entered"}
+		}
+	}
+	return gasPrice, gasLimit, nil
+}
+
+// signOffline signs a coin transaction from the current account and prints it as hex, for
+// tx broadcast on an online machine. It never talks to the node, so the nonce must be given.
+func (r *repl) signOffline() {
+	args := positionalArgs(r.args, "--nonce", "--gas-price", "--gas-limit")
+	nonceStr, ok := flagValue(r.args, "--nonce")
+	if len(args) != 2 || !ok {
+		fmt.Fprintln(r.out, printPrefix, "usage: tx sign-offline <recipient> <amount> --nonce <n> [--gas-price <p>] [--gas-limit <l>]")
+		return
+	}
+	recipient, err := r.resolveAddress(args[0])
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	amount, err := common.ParseAmount(args[1])
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	nonce, err := strconv.ParseUint(nonceStr, 10, 64)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "invalid nonce:", nonceStr)
+		return
+	}
+
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	key, err := acc.SigningKey()
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	defer key.Release()
+
+	gasPrice, gasLimit, err := r.offlineGas(acc)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+
+	fmt.Fprintln(r.out, printPrefix, "Transaction to sign:")
+	fmt.Fprintln(r.out, printPrefix, "From:  ", r.formatAddress(acc.Address()))
+	fmt.Fprintln(r.out, printPrefix, "To:    ", r.addressString(recipient))
+	fmt.Fprintln(r.out, printPrefix, "Amount:", common.FormatSmidge(amount))
+	fmt.Fprintln(r.out, printPrefix, "Gas price:", gasPrice.value, coinUnitName, "("+gasPrice.source+")")
+	fmt.Fprintln(r.out, printPrefix, "Gas limit:", gasLimit.value, "("+gasLimit.source+")")
+	fmt.Fprintln(r.out, printPrefix, "Nonce: ", nonce)
+	if r.yesOrNoQuestion(confirmSignTransactionMsg) != "y" {
+		return
+	}
+
+	tx, err := r.client.SignTransfer(recipient, nonce, amount, gasPrice.value, gasLimit.value, key)
+	if err != nil {
+		log.Error("failed to sign transaction: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Transaction id: 0x%x", tx.ID))
+	fmt.Fprintln(r.out, printPrefix, "Signed transaction:")
+	fmt.Fprintln(r.out, hex.EncodeToString(tx.Signed))
+}
@@ -0,0 +1,185 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// exportPageSize is the number of items requested from the node per page when exporting
+const exportPageSize = 1000
+
+// accountDataSource is the part of Client that transaction exports read from
+type accountDataSource interface {
+	GetMeshTransactions(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Transaction, uint32, error)
+	AccountTransactionsReceipts(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.TransactionReceipt, uint32, error)
+}
+
+// allMeshTransactions pages through the mesh transactions of an account until the node returns an
+// empty page or refuses the offset as past the results. A transaction included in several blocks is
+// returned once.
+func allMeshTransactions(ctx context.Context, src accountDataSource, address gosmtypes.Address) ([]*apitypes.Transaction, error) {
+	seen := make(map[string]bool)
+	var res []*apitypes.Transaction
+	for offset := uint32(0); ; offset += exportPageSize {
+		batch, _, err := src.GetMeshTransactions(ctx, address, offset, exportPageSize)
+		if (page{offset: offset}).outOfRange(err) {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			return res, nil
+		}
+		for _, tx := range batch {
+			if !seen[string(tx.Id.Id)] {
+				seen[string(tx.Id.Id)] = true
+				res = append(res, tx)
+			}
+		}
+	}
+}
+
+// allReceipts pages through the transaction receipts of an account like allMeshTransactions and
+// returns them by transaction id
+func allReceipts(ctx context.Context, src accountDataSource, address gosmtypes.Address) (map[string]*apitypes.TransactionReceipt, error) {
+	res := make(map[string]*apitypes.TransactionReceipt)
+	for offset := uint32(0); ; offset += exportPageSize {
+		batch, _, err := src.AccountTransactionsReceipts(ctx, address, offset, exportPageSize)
+		if (page{offset: offset}).outOfRange(err) {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			return res, nil
+		}
+		for _, receipt := range batch {
+			if receipt.Id != nil {
+				res[string(receipt.Id.Id)] = receipt
+			}
+		}
+	}
+}
+
+// transactionFee returns the fee paid by a transaction when it has a receipt, otherwise its maximum
+// fee. receipt may be nil.
+func transactionFee(tx *apitypes.Transaction, receipt *apitypes.TransactionReceipt) common.TxFee {
+	gasPrice, gasLimit := uint64(0), uint64(0)
+	if tx.GasOffered != nil {
+		gasPrice, gasLimit = tx.GasOffered.GasPrice, tx.GasOffered.GasProvided
+	}
+	if receipt == nil {
+		return common.MaxTxFee(gasPrice, gasLimit)
+	}
+	return common.PaidTxFee(gasPrice, gasLimit, receipt.GetGasUsed(), receipt.GetFee().GetValue())
+}
+
+// transactionType returns the type of a transaction: a coin transfer, a smart contract transaction
+// or an unknown type
+func transactionType(tx *apitypes.Transaction) string {
+	switch tx.Datum.(type) {
+	case *apitypes.Transaction_CoinTransfer:
+		return common.TxTypeCoin
+	case *apitypes.Transaction_SmartContract:
+		return common.TxTypeContract
+	}
+	return common.TxTypeUnknown
+}
+
+// accountTxRecords converts the transactions of an account to export records. The layer, time
+// and actual fee come from the receipt of a transaction. Without a receipt the fee is the maximum
+// fee, gas price times gas limit. info may be nil, in which case times are left out.
+func accountTxRecords(address gosmtypes.Address, txs []*apitypes.Transaction,
+	receipts map[string]*apitypes.TransactionReceipt, info *common.NetInfo) []common.TxRecord {
+	records := make([]common.TxRecord, 0, len(txs))
+	for _, tx := range txs {
+		sender := gosmtypes.BytesToAddress(tx.GetSender().GetAddress())
+		record := common.TxRecord{
+			ID:     fmt.Sprintf("0x%x", tx.GetId().GetId()),
+			Sender: sender.String(),
+			Nonce:  tx.Counter,
+			Type:   transactionType(tx),
+		}
+		if ct := tx.GetCoinTransfer(); ct != nil {
+			recipient := gosmtypes.BytesToAddress(ct.GetReceiver().GetAddress())
+			record.Recipient = recipient.String()
+			record.Direction = common.TxDirection(address, sender, recipient)
+		} else {
+			record.Direction = common.TxDirection(address, sender, gosmtypes.Address{})
+		}
+		if tx.Amount != nil {
+			record.Amount = tx.Amount.Value
+		}
+		receipt := receipts[string(tx.Id.Id)]
+		fee := transactionFee(tx, receipt)
+		record.Fee, record.FeeKind = fee.Amount, fee.Kind()
+		if receipt != nil {
+			if receipt.GetLayer() != nil {
+				layer := receipt.GetLayer().GetNumber()
+				record.Layer = &layer
+				if info != nil {
+					record.Time = info.LayerTime(layer).UTC().Format(time.RFC3339)
+				}
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// exportAccountTransactions writes the mesh transactions of an account selected by the filter flags
+// to a JSON or CSV file
+func (r *repl) exportAccountTransactions(address gosmtypes.Address, path string, csv bool) {
+	txs, err := allMeshTransactions(r.ctx, r.client, address)
+	if err != nil {
+		log.Error("failed to get transactions: %v", err)
+		return
+	}
+	receipts, err := allReceipts(r.ctx, r.client, address)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, "Can't get the transaction receipts, layers and times are left out:", err)
+	}
+	info, err := r.client.GetMeshInfo(r.ctx)
+	if err != nil {
+		info = nil
+	}
+	filter, err := txFilterArgs(r.args)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	var records []common.TxRecord
+	for _, record := range accountTxRecords(address, txs, receipts, info) {
+		if filter.Match(&record) {
+			records = append(records, record)
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		log.Error("failed to create export file: %v", err)
+		return
+	}
+	if csv {
+		err = common.WriteTxRecordsCSV(f, records)
+	} else {
+		err = common.WriteTxRecordsJSON(f, records)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Error("failed to write export file: %v", err)
+		return
+	}
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Exported %d transactions to: %s", len(records), path))
+}
@@ -0,0 +1,161 @@
+package repl
+
+import (
+	"context"
+	"testing"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// fakeAccountData serves mesh transactions and receipts in pages the way the node does
+type fakeAccountData struct {
+	txs      []*apitypes.Transaction
+	receipts []*apitypes.TransactionReceipt
+	calls    int
+}
+
+func (f *fakeAccountData) GetMeshTransactions(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.Transaction, uint32, error) {
+	f.calls++
+	if int(offset) >= len(f.txs) {
+		return nil, 0, nil
+	}
+	end := int(offset + maxResults)
+	if end > len(f.txs) {
+		end = len(f.txs)
+	}
+	return f.txs[offset:end], uint32(end - int(offset)), nil
+}
+
+func (f *fakeAccountData) AccountTransactionsReceipts(ctx context.Context, address gosmtypes.Address, offset uint32, maxResults uint32) ([]*apitypes.TransactionReceipt, uint32, error) {
+	if int(offset) >= len(f.receipts) {
+		return nil, 0, nil
+	}
+	end := int(offset + maxResults)
+	if end > len(f.receipts) {
+		end = len(f.receipts)
+	}
+	return f.receipts[offset:end], uint32(end - int(offset)), nil
+}
+
+func coinTx(id byte, sender, recipient gosmtypes.Address, amount, gasPrice, gasLimit, nonce uint64) *apitypes.Transaction {
+	return &apitypes.Transaction{
+		Id:     &apitypes.TransactionId{Id: []byte{id}},
+		Sender: &apitypes.AccountId{Address: sender.Bytes()},
+		Datum: &apitypes.Transaction_CoinTransfer{CoinTransfer: &apitypes.CoinTransferTransaction{
+			Receiver: &apitypes.AccountId{Address: recipient.Bytes()},
+		}},
+		Amount:     &apitypes.Amount{Value: amount},
+		GasOffered: &apitypes.GasOffered{GasProvided: gasLimit, GasPrice: gasPrice},
+		Counter:    nonce,
+	}
+}
+
+func TestAllMeshTransactionsPages(t *testing.T) {
+	account := gosmtypes.HexToAddress("0x01")
+	other := gosmtypes.HexToAddress("0x02")
+	src := &fakeAccountData{}
+	for i := 0; i < 2*exportPageSize+5; i++ {
+		src.txs = append(src.txs, coinTx(byte(i), account, other, uint64(i), 1, 100, uint64(i)))
+	}
+	txs, err := allMeshTransactions(context.Background(), src, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ids are a single byte, so the 2005 transactions have 256 distinct ids
+	if len(txs) != 256 {
+		t.Fatalf("expected 256 distinct transactions, got %d", len(txs))
+	}
+	if src.calls != 4 {
+		t.Fatalf("expected 3 pages and an empty one, got %d calls", src.calls)
+	}
+}
+
+func TestAccountTxRecords(t *testing.T) {
+	account := gosmtypes.HexToAddress("0x01")
+	other := gosmtypes.HexToAddress("0x02")
+	src := &fakeAccountData{
+		txs: []*apitypes.Transaction{
+			coinTx(1, account, other, 18446744073709551615, 2, 100, 7),
+			coinTx(2, other, account, 500, 1, 100, 0),
+			coinTx(1, account, other, 18446744073709551615, 2, 100, 7),
+		},
+		receipts: []*apitypes.TransactionReceipt{
+			{Id: &apitypes.TransactionId{Id: []byte{1}}, Fee: &apitypes.Amount{Value: 150}, Layer: &apitypes.LayerNumber{Number: 10}},
+		},
+	}
+	txs, err := allMeshTransactions(context.Background(), src, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipts, err := allReceipts(context.Background(), src, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &common.NetInfo{GenesisTime: 1600000000, LayerDuration: 30}
+	records := accountTxRecords(account, txs, receipts, info)
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	out := records[0]
+	if out.ID != "0x01" || out.Direction != common.DirectionOut || out.Amount != 18446744073709551615 ||
+		out.Fee != 150 || out.FeeKind != common.FeePaid || out.Nonce != 7 || out.Sender != account.String() || out.Recipient != other.String() {
+		t.Fatalf("unexpected outgoing record: %+v", out)
+	}
+	if out.Layer == nil || *out.Layer != 10 || out.Time != "2020-09-13T12:31:40Z" {
+		t.Fatalf("unexpected outgoing record layer or time: %+v", out)
+	}
+
+	in := records[1]
+	if in.Direction != common.DirectionIn || in.Amount != 500 || in.Fee != 100 || in.FeeKind != common.FeeMax || in.Layer != nil || in.Time != "" {
+		t.Fatalf("unexpected incoming record: %+v", in)
+	}
+}
+
+func TestTransactionFee(t *testing.T) {
+	account := gosmtypes.HexToAddress("0x01")
+	other := gosmtypes.HexToAddress("0x02")
+	tx := coinTx(1, account, other, 10, 3, 100, 0)
+
+	fee := transactionFee(tx, nil)
+	if fee.Paid || fee.Amount != 300 {
+		t.Fatalf("expected the maximum fee gas limit × price without a receipt, got %+v", fee)
+	}
+	fee = transactionFee(tx, &apitypes.TransactionReceipt{GasUsed: 21})
+	if !fee.Paid || fee.Amount != 63 || fee.GasUsed != 21 || fee.GasPrice != 3 {
+		t.Fatalf("expected the fee gas used × price, got %+v", fee)
+	}
+	fee = transactionFee(tx, &apitypes.TransactionReceipt{GasUsed: 21, Fee: &apitypes.Amount{Value: 70}})
+	if fee.Amount != 70 {
+		t.Fatalf("expected the fee charged by the receipt, got %+v", fee)
+	}
+}
+
+func TestTransactionTypes(t *testing.T) {
+	account := gosmtypes.HexToAddress("0x01")
+	contract := &apitypes.Transaction{
+		Id:     &apitypes.TransactionId{Id: []byte{2}},
+		Sender: &apitypes.AccountId{Address: account.Bytes()},
+		Datum:  &apitypes.Transaction_SmartContract{SmartContract: &apitypes.SmartContractTransaction{Data: []byte{1, 2, 3}}},
+	}
+	unknown := &apitypes.Transaction{Id: &apitypes.TransactionId{Id: []byte{3}}}
+	txs := []*apitypes.Transaction{coinTx(1, account, gosmtypes.HexToAddress("0x02"), 5, 1, 100, 0), contract, unknown}
+
+	records := accountTxRecords(account, txs, nil, nil)
+	if len(records) != 3 {
+		t.Fatalf("expected a record per transaction, got %d", len(records))
+	}
+	for i, expected := range []string{common.TxTypeCoin, common.TxTypeContract, common.TxTypeUnknown} {
+		if records[i].Type != expected {
+			t.Fatalf("record %d: expected type %s, got %s", i, expected, records[i].Type)
+		}
+	}
+	if records[1].Recipient != "" || records[1].Direction != common.DirectionOut {
+		t.Fatalf("unexpected contract record: %+v", records[1])
+	}
+	if records[2].Direction != common.DirectionIn || records[2].Fee != 0 {
+		t.Fatalf("unexpected record of an unknown transaction: %+v", records[2])
+	}
+}
@@ -0,0 +1,85 @@
+package repl
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+
+	gosmtypes "github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/spacemeshos/smrepl/log"
+)
+
+// printRawTransaction prints the bytes of a signed coin transaction in hex: the serialized
+// transaction which is signed, the signature, and the serialized signed transaction which is
+// submitted, followed by its id
+func (r *repl) printRawTransaction(tx *common.SignedTransfer) {
+	fmt.Fprintln(r.out, printPrefix, "Format:", tx.Format)
+	fmt.Fprintln(r.out, printPrefix, "Signed message:", hex.EncodeToString(tx.Unsigned))
+	fmt.Fprintln(r.out, printPrefix, "Signature:", hex.EncodeToString(tx.Signature))
+	fmt.Fprintln(r.out, printPrefix, "Submitted bytes:", hex.EncodeToString(tx.Signed))
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Transaction id: 0x%x", tx.ID))
+}
+
+// dryRunTransaction runs the local checks on a transaction of an account and signs it the way
+// Transfer does, but doesn't submit it
+func (r *repl) dryRunTransaction(from gosmtypes.Address, req *common.TxRequest, key *common.SigningKey) {
+	state, err := r.client.AccountState(r.ctx, from)
+	if err != nil {
+		log.Error("failed to get account info: %v", err)
+		return
+	}
+	balance := uint64(0)
+	if state.StateProjected.Balance != nil {
+		balance = state.StateProjected.Balance.Value
+	}
+	problems := req.Check(balance, state.StateProjected.Counter)
+
+	tx, err := r.client.SignTransfer(req.Recipient, req.Nonce, req.Amount, req.GasPrice, req.GasLimit, key)
+	if err != nil {
+		log.Error("failed to sign transaction: %v", err)
+		return
+	}
+	for _, p := range problems {
+		fmt.Fprintln(r.out, printPrefix, "Problem:", p)
+	}
+	if len(problems) == 0 {
+		fmt.Fprintln(r.out, printPrefix, "All checks passed.")
+	}
+	r.printRawTransaction(tx)
+	fmt.Fprintln(r.out, printPrefix, "Transaction size:", len(tx.Signed), "bytes")
+	fmt.Fprintln(r.out, printPrefix, "Dry run: transaction NOT SUBMITTED.")
+}
+
+// validateTransactionFile checks and signs the transaction described in a transaction request file
+// with the current account, without submitting it
+func (r *repl) validateTransactionFile() {
+	if len(r.args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: tx validate <file>")
+		return
+	}
+	data, err := ioutil.ReadFile(r.args[0])
+	if err != nil {
+		log.Error("failed to read transaction file: %v", err)
+		return
+	}
+	req, err := common.ParseTxRequest(data)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+
+	acc, err := r.getCurrent()
+	if err != nil {
+		log.Error("failed to get account", err)
+		return
+	}
+	key, err := acc.SigningKey()
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	defer key.Release()
+
+	r.dryRunTransaction(acc.Address(), req, key)
+}
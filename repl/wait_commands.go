@@ -0,0 +1,93 @@
+package repl
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	apitypes "github.com/spacemeshos/api/release/go/spacemesh/v1"
+)
+
+const (
+	// defaultWaitTimeout is how long a transaction is waited for unless --timeout is given
+	defaultWaitTimeout = 10 * time.Minute
+	// waitPollInterval is the time between two transaction state requests while waiting
+	waitPollInterval = 5 * time.Second
+)
+
+// waitTimeout returns the duration given with --timeout, e.g. 90s or 15m, or the default timeout
+func waitTimeout(args []string) (time.Duration, error) {
+	s, ok := flagValue(args, "--timeout")
+	if !ok {
+		return defaultWaitTimeout, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timeout %s, expected a duration such as 90s or 15m", s)
+	}
+	return d, nil
+}
+
+// waitForTransaction polls the state of a transaction and prints every change until the transaction
+// is processed or rejected, the timeout expires or Ctrl+C is pressed. Stopping to wait doesn't
+// affect the transaction.
+func (r *repl) waitForTransaction(id []byte, timeout time.Duration) {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	fmt.Fprintln(r.out, printPrefix, fmt.Sprintf("Waiting up to %v for transaction 0x%x, press Ctrl+C to stop waiting...", timeout, id))
+	last := apitypes.TransactionState_TransactionState(-1)
+	for {
+		state, _, err := r.client.TransactionState(r.ctx, id, false)
+		if err != nil {
+			fmt.Fprintln(r.out, printPrefix, "Can't get the transaction state:", err)
+		} else if state != nil && state.State != last {
+			last = state.State
+			fmt.Fprintln(r.out, printPrefix, formatTime(time.Now()), transactionStateDisStringsMap[int32(state.State.Number())])
+			switch state.State {
+			case apitypes.TransactionState_TRANSACTION_STATE_PROCESSED:
+				fmt.Fprintln(r.out, printPrefix, "Transaction confirmed.")
+				return
+			case apitypes.TransactionState_TRANSACTION_STATE_REJECTED,
+				apitypes.TransactionState_TRANSACTION_STATE_INSUFFICIENT_FUNDS,
+				apitypes.TransactionState_TRANSACTION_STATE_CONFLICTING:
+				fmt.Fprintln(r.out, printPrefix, "Transaction failed.")
+				return
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			fmt.Fprintln(r.out, printPrefix, "Timed out waiting for the transaction. It may still be processed.")
+			return
+		case <-interrupt:
+			fmt.Fprintln(r.out, printPrefix, "Stopped waiting. The transaction is not affected.")
+			return
+		}
+	}
+}
+
+// waitTransaction waits for a transaction given by its id
+func (r *repl) waitTransaction() {
+	args := positionalArgs(r.args, "--timeout")
+	if len(args) != 1 {
+		fmt.Fprintln(r.out, printPrefix, "usage: tx wait <transaction id> [--timeout <duration>]")
+		return
+	}
+	timeout, err := waitTimeout(r.args)
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	id, err := parseTxID(args[0])
+	if err != nil {
+		fmt.Fprintln(r.out, printPrefix, err)
+		return
+	}
+	r.waitForTransaction(id, timeout)
+}
@@ -0,0 +1,186 @@
+package smWallet
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/spacemeshos/ed25519"
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DeriveKey returns the key derived from a BIP39 mnemonic at index the same way wallet accounts are
+// derived. The intermediate seed is overwritten before returning.
+func DeriveKey(mnemonic string, index uint64) (ed25519.PrivateKey, error) {
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, errors.New("invalid mnemonic: unknown word or bad checksum")
+	}
+	seed := bip39.NewSeed(mnemonic, "")
+	defer common.Zero(seed)
+	return ed25519.NewDerivedKeyFromSeed(seed[:32], index, []byte(spaceSalt)), nil
+}
+
+// derivedKey returns the key derived from the wallet mnemonic at index
+func (w *Wallet) derivedKey(index uint64) ed25519.PrivateKey {
+	seed := bip39.NewSeed(string(w.Crypto.confidential.Mnemonic), "")
+	defer common.Zero(seed)
+	return ed25519.NewDerivedKeyFromSeed(seed[:32], index, []byte(spaceSalt))
+}
+
+// derivationIndexes returns the derivation index of every account with keys that derives from the
+// wallet mnemonic. Indexes are searched up to maxDerivationIndex.
+func (w *Wallet) derivationIndexes() (map[types.Address]uint64, error) {
+	if !w.unlocked {
+		return nil, errors.New(errorWalletNotUnlocked)
+	}
+	wanted := make(map[types.Address]bool)
+	for _, acc := range w.Crypto.confidential.Accounts {
+		if !acc.IsWatchOnly() {
+			wanted[acc.Address()] = true
+		}
+	}
+	res := make(map[types.Address]uint64, len(wanted))
+	if len(wanted) == 0 {
+		return res, nil
+	}
+	seed := bip39.NewSeed(string(w.Crypto.confidential.Mnemonic), "")
+	defer common.Zero(seed)
+	for i := uint64(0); i < maxDerivationIndex && len(res) < len(wanted); i++ {
+		pk := ed25519.NewDerivedKeyFromSeed(seed[:32], i, []byte(spaceSalt))
+		addr := types.BytesToAddress(PublicKey(pk))
+		if wanted[addr] {
+			res[addr] = i
+		}
+	}
+	return res, nil
+}
+
+// AccountOrigins returns where the key of each account comes from, in account order
+func (w *Wallet) AccountOrigins() ([]common.AccountOrigin, error) {
+	indexes, err := w.derivationIndexes()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]common.AccountOrigin, len(w.Crypto.confidential.Accounts))
+	for pos, acc := range w.Crypto.confidential.Accounts {
+		if acc.IsWatchOnly() {
+			res[pos] = common.AccountOrigin{Kind: common.OriginWatchOnly}
+		} else if index, ok := indexes[acc.Address()]; ok {
+			res[pos] = common.AccountOrigin{Kind: common.OriginDerived, Index: index}
+		} else {
+			res[pos] = common.AccountOrigin{Kind: common.OriginImported}
+		}
+	}
+	return res, nil
+}
+
+// HighestDerivationIndex returns the highest derivation index used by an account of the wallet.
+// It returns false when no account derives from the wallet mnemonic.
+func (w *Wallet) HighestDerivationIndex() (uint64, bool, error) {
+	indexes, err := w.derivationIndexes()
+	if err != nil {
+		return 0, false, err
+	}
+	highest, found := uint64(0), false
+	for _, index := range indexes {
+		if !found || index > highest {
+			highest, found = index, true
+		}
+	}
+	return highest, found, nil
+}
+
+// DeriveAddresses returns the addresses derived from the wallet mnemonic at the n indexes
+// following the highest index used by an account of the wallet
+func (w *Wallet) DeriveAddresses(n int) ([]common.DerivedAddress, error) {
+	highest, found, err := w.HighestDerivationIndex()
+	if err != nil {
+		return nil, err
+	}
+	next := uint64(0)
+	if found {
+		next = highest + 1
+	}
+	res := make([]common.DerivedAddress, 0, n)
+	for i := next; i < next+uint64(n); i++ {
+		res = append(res, common.DerivedAddress{Index: i, Address: types.BytesToAddress(PublicKey(w.derivedKey(i)))})
+	}
+	return res, nil
+}
+
+// AddDerivedAccount adds the account derived from the wallet mnemonic at index
+func (w *Wallet) AddDerivedAccount(displayName string, index uint64) (int, error) {
+	if !w.unlocked {
+		return 0, errors.New(errorWalletNotUnlocked)
+	}
+	return w.AddKeyAccount(displayName, w.derivedKey(index))
+}
+
+// AddKeyAccount adds an account for a private key
+func (w *Wallet) AddKeyAccount(displayName string, pk ed25519.PrivateKey) (int, error) {
+	if !w.unlocked {
+		return 0, errors.New(errorWalletNotUnlocked)
+	}
+	displayName, err := w.validateDisplayName(displayName, -1)
+	if err != nil {
+		return 0, err
+	}
+	pub := PublicKey(pk)
+	addr := types.BytesToAddress(pub)
+	for _, acc := range w.Crypto.confidential.Accounts {
+		if acc.Address() == addr {
+			return 0, errors.New(errorAddressExists)
+		}
+	}
+	w.Crypto.confidential.Accounts = append(w.Crypto.confidential.Accounts, account{
+		DisplayName: displayName,
+		Created:     nowTimeString(),
+		PublicKey:   hex.EncodeToString(pub),
+		SecretKey:   hexSecret(pk),
+	})
+	if err := w.reCrypt(); err != nil {
+		return 0, err
+	}
+	return len(w.Crypto.confidential.Accounts) - 1, nil
+}
+
+// MatchesMnemonic tells whether a mnemonic is the one the wallet keys derive from. The public keys
+// of the first n derivation indexes and of every derived account of the wallet are compared. No
+// key is returned and the seeds and keys derived for the comparison are overwritten.
+func (w *Wallet) MatchesMnemonic(mnemonic string, n int) (bool, error) {
+	if !w.unlocked {
+		return false, errors.New(errorWalletNotUnlocked)
+	}
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return false, errors.New("invalid mnemonic: unknown word or bad checksum")
+	}
+	indexes, err := w.derivationIndexes()
+	if err != nil {
+		return false, err
+	}
+	seed := bip39.NewSeed(mnemonic, "")
+	defer common.Zero(seed)
+	storedSeed := bip39.NewSeed(string(w.Crypto.confidential.Mnemonic), "")
+	defer common.Zero(storedSeed)
+
+	matches := func(index uint64, expected types.Address) bool {
+		pk := ed25519.NewDerivedKeyFromSeed(seed[:32], index, []byte(spaceSalt))
+		defer common.Zero(pk)
+		return types.BytesToAddress(PublicKey(pk)) == expected
+	}
+	for i := uint64(0); i < uint64(n); i++ {
+		pk := ed25519.NewDerivedKeyFromSeed(storedSeed[:32], i, []byte(spaceSalt))
+		expected := types.BytesToAddress(PublicKey(pk))
+		common.Zero(pk)
+		if !matches(i, expected) {
+			return false, nil
+		}
+	}
+	for addr, index := range indexes {
+		if !matches(index, addr) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
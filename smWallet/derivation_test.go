@@ -0,0 +1,116 @@
+package smWallet
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/smrepl/common"
+)
+
+func TestAccountOrigins(t *testing.T) {
+	w, err := NewWalletWithMnemonic("test", testPassword, testMnemonic)
+	chkTErr(t, err)
+	_, err = w.GenerateNewPair("second")
+	chkTErr(t, err)
+	_, err = w.AddWatchOnlyAccount("watched", types.HexToAddress("0x7fa75881ca0050028b32f424f860e3a73d4bf168"))
+	chkTErr(t, err)
+	other, err := NewWallet("other", testPassword)
+	chkTErr(t, err)
+	_, err = w.Merge(other, false)
+	chkTErr(t, err)
+
+	origins, err := w.AccountOrigins()
+	chkTErr(t, err)
+	expected := []common.AccountOrigin{
+		{Kind: common.OriginDerived, Index: 0},
+		{Kind: common.OriginDerived, Index: 1},
+		{Kind: common.OriginWatchOnly},
+		{Kind: common.OriginImported},
+	}
+	if len(origins) != len(expected) {
+		t.Fatalf("expected %d origins, got %d", len(expected), len(origins))
+	}
+	for i := range expected {
+		if origins[i] != expected[i] {
+			t.Fatalf("account %d: expected origin %v, got %v", i, expected[i], origins[i])
+		}
+	}
+
+	highest, found, err := w.HighestDerivationIndex()
+	chkTErr(t, err)
+	if !found || highest != 1 {
+		t.Fatalf("expected highest index 1, got %d (%v)", highest, found)
+	}
+}
+
+func TestDeriveAddresses(t *testing.T) {
+	w, err := NewWalletWithMnemonic("test", testPassword, testMnemonic)
+	chkTErr(t, err)
+	candidates, err := w.DeriveAddresses(2)
+	chkTErr(t, err)
+	if len(candidates) != 2 || candidates[0].Index != 1 || candidates[1].Index != 2 {
+		t.Fatalf("expected indexes 1 and 2, got %+v", candidates)
+	}
+
+	// a wallet restored from the same mnemonic finds the account created by the other wallet
+	_, err = w.GenerateNewPair("second")
+	chkTErr(t, err)
+	restored, err := NewWalletWithMnemonic("restored", testPassword, testMnemonic)
+	chkTErr(t, err)
+	pos, err := restored.AddDerivedAccount("second", candidates[0].Index)
+	chkTErr(t, err)
+	addr, err := restored.GetAddress(pos)
+	chkTErr(t, err)
+	expected, err := w.GetAddress(1)
+	chkTErr(t, err)
+	if addr != expected || addr != candidates[0].Address {
+		t.Fatalf("expected address %s, got %s", expected.Hex(), addr.Hex())
+	}
+	if _, err := restored.AddDerivedAccount("again", candidates[0].Index); err == nil {
+		t.Fatal("expected an error adding an account twice")
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	w, err := NewWalletWithMnemonic("test", testPassword, testMnemonic)
+	chkTErr(t, err)
+	key, err := DeriveKey(testMnemonic, 0)
+	chkTErr(t, err)
+	expected, err := w.GetPrivateKey(0)
+	chkTErr(t, err)
+	if string(key) != string(expected) {
+		t.Fatal("expected the key of the first wallet account")
+	}
+	if _, err := w.AddKeyAccount("copy", key); err == nil {
+		t.Fatal("expected an error adding an existing key")
+	}
+
+	if _, err := DeriveKey("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", 0); err == nil {
+		t.Fatal("expected a bad checksum error")
+	}
+	if _, err := DeriveKey("notaword abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", 0); err == nil {
+		t.Fatal("expected an unknown word error")
+	}
+}
+
+func TestMatchesMnemonic(t *testing.T) {
+	w, err := NewWalletWithMnemonic("test", testPassword, testMnemonic)
+	chkTErr(t, err)
+	ok, err := w.MatchesMnemonic(testMnemonic, 3)
+	chkTErr(t, err)
+	if !ok {
+		t.Fatal("expected the wallet mnemonic to match")
+	}
+
+	other, err := NewWallet("other", testPassword)
+	chkTErr(t, err)
+	ok, err = w.MatchesMnemonic(string(other.Crypto.confidential.Mnemonic), 3)
+	chkTErr(t, err)
+	if ok {
+		t.Fatal("expected another mnemonic not to match")
+	}
+
+	if _, err := w.MatchesMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", 3); err == nil {
+		t.Fatal("expected a bad checksum error")
+	}
+}
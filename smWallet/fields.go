@@ -0,0 +1,140 @@
+package smWallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Wallet files written by newer versions may hold fields this version doesn't know. They are kept
+// as raw JSON when a file is read and written back unchanged when it is saved, so opening a newer
+// wallet with an older version never drops data.
+
+// jsonFieldNames returns the JSON names of the fields of a struct type
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// unknownFields returns the fields of a JSON object which are not fields of the struct type t
+func unknownFields(data []byte, t reflect.Type) (map[string]json.RawMessage, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	known := jsonFieldNames(t)
+	for name := range raw {
+		if known[name] {
+			delete(raw, name)
+		}
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	return raw, nil
+}
+
+// withFields appends extra fields to a JSON object. The raw field values are written unchanged.
+func withFields(data []byte, extra map[string]json.RawMessage) ([]byte, error) {
+	if len(extra) == 0 {
+		return data, nil
+	}
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	b.Write(bytes.TrimSuffix(bytes.TrimSpace(data), []byte("}")))
+	for _, name := range names {
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(extra[name])
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// walletFields, secretFields and accountFields have the fields of the types they convert to but
+// not their JSON methods
+type walletFields Wallet
+type secretFields secretStuff
+type accountFields account
+
+// UnmarshalJSON reads a wallet file and keeps its unknown fields
+func (w *Wallet) UnmarshalJSON(data []byte) (err error) {
+	if err = json.Unmarshal(data, (*walletFields)(w)); err != nil {
+		return err
+	}
+	w.extra, err = unknownFields(data, reflect.TypeOf(walletFields{}))
+	return err
+}
+
+// MarshalJSON writes a wallet file with the unknown fields it was read with
+func (w *Wallet) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal((*walletFields)(w))
+	if err != nil {
+		return nil, err
+	}
+	return withFields(data, w.extra)
+}
+
+// UnmarshalJSON reads the decrypted wallet data and keeps its unknown fields
+func (s *secretStuff) UnmarshalJSON(data []byte) (err error) {
+	if err = json.Unmarshal(data, (*secretFields)(s)); err != nil {
+		return err
+	}
+	s.extra, err = unknownFields(data, reflect.TypeOf(secretFields{}))
+	return err
+}
+
+// MarshalJSON writes the wallet data with the unknown fields it was read with
+func (s secretStuff) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(secretFields(s))
+	if err != nil {
+		return nil, err
+	}
+	return withFields(data, s.extra)
+}
+
+// UnmarshalJSON reads an account and keeps its unknown fields
+func (a *account) UnmarshalJSON(data []byte) (err error) {
+	if err = json.Unmarshal(data, (*accountFields)(a)); err != nil {
+		return err
+	}
+	a.extra, err = unknownFields(data, reflect.TypeOf(accountFields{}))
+	return err
+}
+
+// MarshalJSON writes an account with the unknown fields it was read with
+func (a account) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(accountFields(a))
+	if err != nil {
+		return nil, err
+	}
+	return withFields(data, a.extra)
+}
@@ -0,0 +1,54 @@
+package smWallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+func TestUnknownFieldsRoundTrip(t *testing.T) {
+	w, cleanup := newTestWallet(t, 1)
+	defer cleanup()
+
+	// a newer version added a field to the first account and a top level field
+	accountField := `{"path":"m/44'/540'/0'","device":"ledger"}`
+	w.Crypto.confidential.Accounts[0].extra = map[string]json.RawMessage{"hardware": json.RawMessage(accountField)}
+	chkTErr(t, w.reCrypt())
+	data, err := ioutil.ReadFile(w.WalletPath())
+	chkTErr(t, err)
+	topField := `"sync":{ "lastLayer": 1234, "peers": [ "a", "b" ] }`
+	data = append([]byte("{"+topField+","), data[1:]...)
+	chkTErr(t, ioutil.WriteFile(w.WalletPath(), data, 0600))
+
+	loaded, err := LoadWallet(w.WalletPath())
+	chkTErr(t, err)
+	chkTErr(t, loaded.Unlock(testPassword))
+	_, err = loaded.GenerateNewPair("second")
+	chkTErr(t, err)
+
+	saved, err := ioutil.ReadFile(w.WalletPath())
+	chkTErr(t, err)
+	if !bytes.Contains(saved, []byte(topField)) {
+		t.Fatalf("expected the unknown top level field to be saved unchanged:\n%s", saved)
+	}
+	if !bytes.Contains(saved, []byte(`"writtenBy":"`+common.WriterName()+`"`)) {
+		t.Fatalf("expected the file to record the program which wrote it:\n%s", saved)
+	}
+
+	reloaded, err := LoadWallet(w.WalletPath())
+	chkTErr(t, err)
+	chkTErr(t, reloaded.Unlock(testPassword))
+	accounts := reloaded.Crypto.confidential.Accounts
+	if len(accounts) != 2 {
+		t.Fatalf("expected 2 accounts, got %d", len(accounts))
+	}
+	if string(accounts[0].extra["hardware"]) != accountField {
+		t.Fatalf("expected the unknown account field to be kept, got %s", accounts[0].extra["hardware"])
+	}
+	if accounts[1].extra != nil {
+		t.Fatal("expected no unknown fields on a new account")
+	}
+}
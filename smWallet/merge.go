@@ -0,0 +1,65 @@
+package smWallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// uniqueDisplayName returns displayName, or displayName with the first free -2, -3... suffix when
+// it is taken by one of accounts
+func uniqueDisplayName(displayName string, accounts []account) string {
+	taken := make(map[string]bool, len(accounts))
+	for _, acc := range accounts {
+		taken[acc.DisplayName] = true
+	}
+	name := displayName
+	for i := 2; taken[name]; i++ {
+		name = fmt.Sprintf("%s-%d", displayName, i)
+	}
+	return name
+}
+
+// Merge adds the accounts of other that are not in the wallet. Accounts are matched by address,
+// which derives from the public key. Display names used by the wallet get a numeric suffix. With
+// dryRun the wallet is left unchanged and the result only describes the merge. Both wallets must
+// be unlocked. The merged wallet is saved once with all the imported accounts.
+func (w *Wallet) Merge(other *Wallet, dryRun bool) (*common.MergeResult, error) {
+	if !w.unlocked || !other.unlocked {
+		return nil, errors.New(errorWalletNotUnlocked)
+	}
+	accounts := append([]account(nil), w.Crypto.confidential.Accounts...)
+	res := &common.MergeResult{Renamed: make(map[string]string)}
+	for _, acc := range other.Crypto.confidential.Accounts {
+		duplicate := false
+		for _, existing := range accounts {
+			if existing.Address() == acc.Address() {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			res.Skipped = append(res.Skipped, acc.DisplayName)
+			continue
+		}
+		name := uniqueDisplayName(acc.DisplayName, accounts)
+		if name != acc.DisplayName {
+			res.Renamed[acc.DisplayName] = name
+		}
+		acc.DisplayName = name
+		acc.Tags = append([]string(nil), acc.Tags...)
+		// the key is wiped with other when it is locked
+		acc.SecretKey = append(secret(nil), acc.SecretKey...)
+		accounts = append(accounts, acc)
+		res.Imported = append(res.Imported, name)
+	}
+	if dryRun || len(res.Imported) == 0 {
+		return res, nil
+	}
+	w.Crypto.confidential.Accounts = accounts
+	if err := w.reCrypt(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
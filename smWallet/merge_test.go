@@ -0,0 +1,72 @@
+package smWallet
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+func TestMerge(t *testing.T) {
+	w, err := NewWalletWithMnemonic("test", testPassword, testMnemonic)
+	chkTErr(t, err)
+	_, err = w.GenerateNewPair("shared")
+	chkTErr(t, err)
+
+	other, err := NewWalletWithMnemonic("other", testPassword, testMnemonic)
+	chkTErr(t, err)
+	_, err = other.GenerateNewPair("shared")
+	chkTErr(t, err)
+	other2, err := NewWallet("other2", testPassword)
+	chkTErr(t, err)
+	_, err = other2.GenerateNewPair("shared")
+	chkTErr(t, err)
+	_, err = other2.AddWatchOnlyAccount("watched", types.HexToAddress("0x7fa75881ca0050028b32f424f860e3a73d4bf168"))
+	chkTErr(t, err)
+
+	// same mnemonic, all accounts are duplicates
+	res, err := w.Merge(other, false)
+	chkTErr(t, err)
+	if len(res.Imported) != 0 || len(res.Skipped) != 2 {
+		t.Fatalf("expected 2 duplicates, got %+v", res)
+	}
+
+	before, err := w.GetNumberOfAccounts()
+	chkTErr(t, err)
+	res, err = w.Merge(other2, true)
+	chkTErr(t, err)
+	if len(res.Imported) != 3 {
+		t.Fatalf("expected 3 accounts to import, got %+v", res)
+	}
+	n, err := w.GetNumberOfAccounts()
+	chkTErr(t, err)
+	if n != before {
+		t.Fatal("dry run changed the wallet")
+	}
+
+	res, err = w.Merge(other2, false)
+	chkTErr(t, err)
+	if res.Renamed["shared"] != "shared-2" {
+		t.Fatalf("expected shared to be renamed shared-2, got %+v", res.Renamed)
+	}
+	n, err = w.GetNumberOfAccounts()
+	chkTErr(t, err)
+	if n != before+3 {
+		t.Fatalf("expected %d accounts, got %d", before+3, n)
+	}
+	for i := before; i < n; i++ {
+		expected, err := other2.GetAddress(i - before)
+		chkTErr(t, err)
+		addr, err := w.GetAddress(i)
+		chkTErr(t, err)
+		if addr != expected {
+			t.Fatalf("account %d: expected address %s, got %s", i, expected.Hex(), addr.Hex())
+		}
+	}
+	chkTErr(t, w.verifyAccounts())
+
+	res, err = w.Merge(other2, false)
+	chkTErr(t, err)
+	if len(res.Imported) != 0 || len(res.Skipped) != 3 {
+		t.Fatalf("expected a second merge to skip all accounts, got %+v", res)
+	}
+}
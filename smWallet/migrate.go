@@ -0,0 +1,101 @@
+package smWallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// WalletVersion is the version of the wallet file layout written by this package
+const WalletVersion = 1
+
+// walletMigrations upgrade the layout of a wallet file step by step. walletMigrations[i] upgrades a
+// file of version i to version i+1.
+var walletMigrations = []func(raw map[string]json.RawMessage) error{
+	migrateV0,
+}
+
+// migrateV0 upgrades wallet files created by Smapp and earlier versions of this wallet, which have
+// no version field. Their layout is the same as version 1.
+func migrateV0(raw map[string]json.RawMessage) error {
+	return nil
+}
+
+// walletFileVersion returns the version of the raw wallet file data
+func walletFileVersion(raw map[string]json.RawMessage) (int, error) {
+	if _, ok := raw["meta"]; !ok {
+		return 0, errors.New("not a wallet file: missing meta data")
+	}
+	if _, ok := raw["crypto"]; !ok {
+		return 0, errors.New("not a wallet file: missing encrypted data")
+	}
+	v, ok := raw["version"]
+	if !ok {
+		return 0, nil
+	}
+	var version int
+	if err := json.Unmarshal(v, &version); err != nil {
+		return 0, fmt.Errorf("invalid wallet file version %s", v)
+	}
+	if version < 0 || version > WalletVersion {
+		return 0, fmt.Errorf("unsupported wallet file version %d, this wallet supports versions up to %d", version, WalletVersion)
+	}
+	return version, nil
+}
+
+// migrateWalletData upgrades wallet file data to WalletVersion. It returns the upgraded data and the
+// version of the original data.
+func migrateWalletData(data []byte) ([]byte, int, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, 0, fmt.Errorf("not a wallet file: %v", err)
+	}
+	from, err := walletFileVersion(raw)
+	if err != nil {
+		return nil, 0, err
+	}
+	if from == WalletVersion {
+		return data, from, nil
+	}
+	for v := from; v < WalletVersion; v++ {
+		if err := walletMigrations[v](raw); err != nil {
+			return nil, from, fmt.Errorf("failed to upgrade wallet file from version %d: %v", v, err)
+		}
+		raw["version"] = json.RawMessage(fmt.Sprint(v + 1))
+	}
+	migrated, err := json.Marshal(raw)
+	if err != nil {
+		return nil, from, err
+	}
+	return migrated, from, nil
+}
+
+// MigrationBackupPath returns the path of the copy of a wallet file made before upgrading it from version
+func MigrationBackupPath(keystore string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", keystore, version)
+}
+
+// MigrateWalletFile upgrades a wallet file to WalletVersion in place. The original file is copied to
+// MigrationBackupPath first. It returns the path of the copy, or an empty string if the file is up to date.
+func MigrateWalletFile(keystore string) (string, error) {
+	data, err := ioutil.ReadFile(keystore)
+	if err != nil {
+		return "", err
+	}
+	migrated, from, err := migrateWalletData(data)
+	if err != nil {
+		return "", err
+	}
+	if from == WalletVersion {
+		return "", nil
+	}
+	backup := MigrationBackupPath(keystore, from)
+	if err := copyFile(keystore, backup, 0600); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(keystore, append(migrated, '\n'), 0600); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
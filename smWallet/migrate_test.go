@@ -0,0 +1,121 @@
+package smWallet
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// keys of the accounts held by the wallet fixtures
+var fixtureAccounts = []struct {
+	name, publicKey, secretKey string
+}{
+	{"Default", "8a88e3dd7409f195fd52db2d3cba5d72ca6709bf1d94121bf3748801b40f6f5c",
+		"01010101010101010101010101010101010101010101010101010101010101018a88e3dd7409f195fd52db2d3cba5d72ca6709bf1d94121bf3748801b40f6f5c"},
+	{"savings", "8139770ea87d175f56a35466c34c7ecccb8d8a91b4ee37a25df60f5b8fc9b394",
+		"02020202020202020202020202020202020202020202020202020202020202028139770ea87d175f56a35466c34c7ecccb8d8a91b4ee37a25df60f5b8fc9b394"},
+}
+
+// copyFixture copies a wallet fixture to a temporary directory
+func copyFixture(t *testing.T, name string) (string, func()) {
+	dir, err := ioutil.TempDir("", "smwallet")
+	chkTErr(t, err)
+	path := filepath.Join(dir, name)
+	chkTErr(t, copyFile(filepath.Join("testdata", name), path, 0600))
+	return path, func() { _ = os.RemoveAll(dir) }
+}
+
+func checkFixtureAccounts(t *testing.T, w *Wallet) {
+	chkTErr(t, w.Unlock(testPassword))
+	n, err := w.GetNumberOfAccounts()
+	chkTErr(t, err)
+	if n != len(fixtureAccounts) {
+		t.Fatalf("expected %d accounts, got %d", len(fixtureAccounts), n)
+	}
+	for i, expected := range fixtureAccounts {
+		acc := w.Crypto.confidential.Accounts[i]
+		if acc.DisplayName != expected.name || acc.PublicKey != expected.publicKey || string(acc.SecretKey) != expected.secretKey {
+			t.Fatalf("account %d: key material changed by migration", i)
+		}
+	}
+}
+
+func TestMigrateWalletFileV0(t *testing.T) {
+	path, cleanup := copyFixture(t, "wallet_v0.json")
+	defer cleanup()
+	original, err := ioutil.ReadFile(path)
+	chkTErr(t, err)
+
+	backup, err := MigrateWalletFile(path)
+	chkTErr(t, err)
+	if backup != MigrationBackupPath(path, 0) {
+		t.Fatalf("unexpected backup path %s", backup)
+	}
+	saved, err := ioutil.ReadFile(backup)
+	chkTErr(t, err)
+	if !bytes.Equal(saved, original) {
+		t.Fatal("backup differs from the original file")
+	}
+
+	w, err := LoadWallet(path)
+	chkTErr(t, err)
+	if w.Version != WalletVersion {
+		t.Fatalf("expected version %d, got %d", WalletVersion, w.Version)
+	}
+	checkFixtureAccounts(t, w)
+
+	backup, err = MigrateWalletFile(path)
+	chkTErr(t, err)
+	if backup != "" {
+		t.Fatal("expected an up to date file not to be migrated again")
+	}
+}
+
+func TestLoadWalletMigratesInMemory(t *testing.T) {
+	path, cleanup := copyFixture(t, "wallet_v0.json")
+	defer cleanup()
+	original, err := ioutil.ReadFile(path)
+	chkTErr(t, err)
+
+	w, err := LoadWallet(path)
+	chkTErr(t, err)
+	if w.Version != WalletVersion {
+		t.Fatalf("expected version %d, got %d", WalletVersion, w.Version)
+	}
+	checkFixtureAccounts(t, w)
+
+	data, err := ioutil.ReadFile(path)
+	chkTErr(t, err)
+	if !bytes.Equal(data, original) {
+		t.Fatal("LoadWallet modified the wallet file")
+	}
+}
+
+func TestLoadWalletRejectsUnknownFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smwallet")
+	chkTErr(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"empty.json":   `{}`,
+		"garbage.json": `not json`,
+		"future.json":  `{"version": 99, "meta": {}, "crypto": {}}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		chkTErr(t, ioutil.WriteFile(path, []byte(content), 0600))
+		if _, err := LoadWallet(path); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+		if _, err := MigrateWalletFile(path); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+	_, err = LoadWallet(filepath.Join(dir, "future.json"))
+	if !strings.Contains(err.Error(), "99") {
+		t.Fatalf("expected the error to name the version found: %v", err)
+	}
+}
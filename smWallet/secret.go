@@ -0,0 +1,64 @@
+package smWallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/spacemeshos/smrepl/common"
+)
+
+// secret holds decrypted wallet data such as the mnemonic or the hex encoded key of an account. It
+// is kept as bytes rather than a string so it can be overwritten when the wallet is locked. It is
+// written to the wallet data as a JSON string.
+type secret []byte
+
+// hexSecret returns the hex encoding of a key as a secret
+func hexSecret(key []byte) secret {
+	s := make(secret, hex.EncodedLen(len(key)))
+	hex.Encode(s, key)
+	return s
+}
+
+// decodeHex returns the key a hex encoded secret holds
+func (s secret) decodeHex() ([]byte, error) {
+	key := make([]byte, hex.DecodedLen(len(s)))
+	if _, err := hex.Decode(key, s); err != nil {
+		common.Zero(key)
+		return nil, err
+	}
+	return key, nil
+}
+
+// wipe overwrites a secret
+func (s secret) wipe() {
+	common.Zero(s)
+}
+
+// wipe overwrites the mnemonic and the keys of the accounts
+func (s *secretStuff) wipe() {
+	s.Mnemonic.wipe()
+	for _, acc := range s.Accounts {
+		acc.SecretKey.wipe()
+	}
+}
+
+// MarshalJSON writes a secret as a JSON string
+func (s secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(s))
+}
+
+// UnmarshalJSON reads a secret from a JSON string. Strings without escapes, which hex keys and
+// mnemonics are, are copied without going through a Go string.
+func (s *secret) UnmarshalJSON(data []byte) error {
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' && bytes.IndexByte(data, '\\') < 0 {
+		*s = append(secret(nil), data[1:len(data)-1]...)
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	*s = secret(str)
+	return nil
+}
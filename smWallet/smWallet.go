@@ -2,16 +2,20 @@ package smWallet
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"time"
 
 	xdr "github.com/davecgh/go-xdr/xdr2"
 	"github.com/spacemeshos/ed25519"
 	"github.com/spacemeshos/go-spacemesh/common/types"
 	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/smrepl/common"
 	"github.com/tyler-smith/go-bip39"
 )
 
@@ -23,6 +27,17 @@ const (
 	errorWalletNotUnlocked = "wallet has not been unlocked"
 	// errorWalletDoesNotHaveThatAddress if attempting to access an account that has not been generated
 	errorWalletDoesNotHaveThatAddress = "you are attempting to access an account that has not been generated"
+	// errorNoCurrentAccount thrown if the current account was deleted or never set
+	errorNoCurrentAccount = "no current account is set"
+	// errorWatchOnlyAccount thrown if attempting to access the private key of a watch-only account
+	errorWatchOnlyAccount = "watch-only account — no private key"
+	// errorAddressExists thrown if attempting to add an account for an address that is already in the wallet
+	errorAddressExists = "an account with this address already exists"
+	// errorWrongPassword thrown if the wallet data can't be decrypted with the provided password
+	errorWrongPassword = "wrong password"
+	// errorEmptyPassword thrown if attempting to encrypt the wallet data without a password. Every
+	// wallet file is encrypted, there are no wallets without a password.
+	errorEmptyPassword = "the wallet password can't be empty"
 
 	// entropySizeBytes is the number of bytes required for wallet entropy
 	entropySizeBytes = 16
@@ -33,29 +48,56 @@ type account struct {
 	Created     string `json:"created"`
 	Path        string `json:"path"`
 	PublicKey   string `json:"publicKey"`
-	SecretKey   string `json:"secretKey"`
+	SecretKey   secret `json:"secretKey"`
+	// WatchAddress is only set for watch-only accounts which have no keys
+	WatchAddress string `json:"watchAddress,omitempty"`
+	// Note and Tags are free-text annotations set by the user
+	Note string   `json:"note,omitempty"`
+	Tags []string `json:"tags,omitempty"`
+	// GasPrice and GasLimit are the transaction defaults of the account. 0 means no default.
+	GasPrice uint64 `json:"gasPrice,omitempty"`
+	GasLimit uint64 `json:"gasLimit,omitempty"`
+	// extra holds the fields written by newer versions
+	extra map[string]json.RawMessage
+}
+
+// IsWatchOnly returns true for accounts that only hold an address and no keys
+func (a *account) IsWatchOnly() bool {
+	return len(a.SecretKey) == 0 && a.WatchAddress != ""
 }
 
 func (a *account) Address() types.Address {
+	if a.IsWatchOnly() {
+		return types.HexToAddress(a.WatchAddress)
+	}
 	return types.BytesToAddress(util.Hex2Bytes(a.PublicKey))
 }
 
 func (a *account) PrivateKey() (pub ed25519.PrivateKey, err error) {
-	return hex.DecodeString(a.SecretKey)
+	if a.IsWatchOnly() {
+		return nil, errors.New(errorWatchOnlyAccount)
+	}
+	return a.SecretKey.decodeHex()
 }
 
 func (w *Wallet) CurrentAccount() (*account, error) {
 	if !w.unlocked {
 		return nil, errors.New(errorWalletNotUnlocked)
 	}
-	return &w.Crypto.confidential.Accounts[w.Crypto.confidential.accountNumber], nil
+	current := w.Crypto.confidential.accountNumber
+	if current < 0 || current >= len(w.Crypto.confidential.Accounts) {
+		return nil, errors.New(errorNoCurrentAccount)
+	}
+	return &w.Crypto.confidential.Accounts[current], nil
 }
 
 type secretStuff struct {
-	Mnemonic      string    `json:"mnemonic"`
+	Mnemonic      secret    `json:"mnemonic"`
 	Accounts      []account `json:"accounts"`
 	Contacts      []contact `json:"contacts"`
 	accountNumber int
+	// extra holds the fields written by newer versions
+	extra map[string]json.RawMessage
 }
 
 type contact struct {
@@ -81,10 +123,22 @@ type walletEncryptedData struct {
 // Wallet is the basic data structure.
 type Wallet struct {
 	keystore string
-	password string
+	password []byte
 	unlocked bool
-	Meta     walletMetadata      `json:"meta"`
-	Crypto   walletEncryptedData `json:"crypto"`
+	// dirty is set when changes have been encrypted but not written to the wallet file
+	dirty bool
+	// diskHash and diskModTime identify the content of the wallet file when it was loaded or last
+	// saved, to detect changes by other programs. diskHash is nil until the file is read or written.
+	diskHash    []byte
+	diskModTime time.Time
+	diskSize    int64
+	// extra holds the fields written by newer versions
+	extra   map[string]json.RawMessage
+	Version int `json:"version"`
+	// WrittenBy is the program and version which last saved the file
+	WrittenBy string              `json:"writtenBy,omitempty"`
+	Meta      walletMetadata      `json:"meta"`
+	Crypto    walletEncryptedData `json:"crypto"`
 }
 
 // NewWallet returns a brand shiny new wallet with random seed and mnemonic phrase
@@ -103,6 +157,16 @@ func NewWallet(walletName, password string) (w *Wallet, err error) {
 	return NewWalletWithMnemonic(walletName, password, mnemonic)
 }
 
+// NewWalletFromEntropy returns a new wallet with the mnemonic of the provided entropy. The same
+// entropy always results in the same mnemonic and accounts.
+func NewWalletFromEntropy(walletName, password string, entropy []byte) (*Wallet, error) {
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return nil, err
+	}
+	return NewWalletWithMnemonic(walletName, password, mnemonic)
+}
+
 // NewWalletWithMnemonic creates a new wallet with the provided mnemonic
 func NewWalletWithMnemonic(walletName, password string, mnemonic string) (w *Wallet, err error) {
 
@@ -111,14 +175,15 @@ func NewWalletWithMnemonic(walletName, password string, mnemonic string) (w *Wal
 	}
 
 	wx := new(Wallet)
-	wx.password = password
+	wx.password = []byte(password)
 	wx.unlocked = true
+	wx.Version = WalletVersion
 	wx.Meta.Created = nowTimeString()
 	wx.Meta.DisplayName = walletName
 	wx.Meta.NetID = 0
 	wx.Meta.Meta.Salt = spaceSalt
 	wx.Crypto.Cipher = "AES-128-CTR"
-	wx.Crypto.confidential.Mnemonic = mnemonic
+	wx.Crypto.confidential.Mnemonic = secret(mnemonic)
 	if err != nil {
 		return nil, err
 	}
@@ -136,19 +201,24 @@ func NewWalletWithMnemonic(walletName, password string, mnemonic string) (w *Wal
 	return wx, nil
 }
 
-// LoadWallet returns a wallet object for an existing file copy of a wallet
+// LoadWallet returns a wallet object for an existing file copy of a wallet. Files written by older
+// versions are upgraded in memory, use MigrateWalletFile to upgrade the file itself.
 func LoadWallet(keystore string) (w *Wallet, err error) {
-	w = new(Wallet)
-	var f *os.File
-	f, err = os.Open(keystore)
+	raw, err := ioutil.ReadFile(keystore)
 	if err != nil {
 		return nil, err
 	}
-	err = json.NewDecoder(f).Decode(w)
+	data, _, err := migrateWalletData(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", keystore, err)
+	}
+	w = new(Wallet)
+	err = json.Unmarshal(data, w)
 	if err != nil {
 		return nil, err
 	}
 	w.keystore = keystore
+	w.recordDiskState(raw)
 	err = w.verifyAccounts()
 	w.Crypto.confidential.accountNumber = 0
 	return
@@ -157,27 +227,152 @@ func LoadWallet(keystore string) (w *Wallet, err error) {
 // SaveWalletAs saves a wallet to a file and records the filename internally
 func (w *Wallet) SaveWalletAs(keystorePrefix string) (err error) {
 	w.keystore = keystorePrefix + "_" + w.Meta.Created + ".json"
+	w.diskHash = nil
 	return w.SaveWallet()
 }
 
-// SaveWallet saves a file only if it already has a filename
+// recordDiskState remembers the content of the wallet file, which the wallet was just loaded from
+// or saved to
+func (w *Wallet) recordDiskState(data []byte) {
+	sum := sha256.Sum256(data)
+	w.diskHash = sum[:]
+	w.diskModTime, w.diskSize = time.Time{}, -1
+	if info, err := os.Stat(w.keystore); err == nil {
+		w.diskModTime, w.diskSize = info.ModTime(), info.Size()
+	}
+}
+
+// ChangedOnDisk tells whether another program changed the wallet file since the wallet was loaded
+// from it or last saved to it. The content is only compared when the modification time or the size
+// changed. A removed file isn't a change: saving writes it again.
+func (w *Wallet) ChangedOnDisk() (bool, error) {
+	if len(w.keystore) == 0 {
+		return false, nil
+	}
+	info, err := os.Stat(w.keystore)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if w.diskHash == nil {
+		// a file this wallet neither read nor wrote
+		return true, nil
+	}
+	if info.ModTime().Equal(w.diskModTime) && info.Size() == w.diskSize {
+		return false, nil
+	}
+	data, err := ioutil.ReadFile(w.keystore)
+	if err != nil {
+		return false, err
+	}
+	sum := sha256.Sum256(data)
+	return !bytes.Equal(sum[:], w.diskHash), nil
+}
+
+// DiskVersion loads the wallet file as it is now on disk, unlocked with the password of the wallet
+// when the wallet is unlocked
+func (w *Wallet) DiskVersion() (*Wallet, error) {
+	if len(w.keystore) == 0 {
+		return nil, errors.New(errorNoFileName)
+	}
+	disk, err := LoadWallet(w.keystore)
+	if err != nil {
+		return nil, err
+	}
+	if w.unlocked {
+		if err := disk.unlock(w.password); err != nil {
+			return nil, fmt.Errorf("the wallet file can't be decrypted with the password of the open wallet: %v", err)
+		}
+	}
+	return disk, nil
+}
+
+// AcceptDiskVersion records the content of a DiskVersion as known, so the wallet can be saved over
+// it once its changes were merged. A later change of the file is still detected.
+func (w *Wallet) AcceptDiskVersion(disk *Wallet) {
+	w.diskHash, w.diskModTime, w.diskSize = disk.diskHash, disk.diskModTime, disk.diskSize
+}
+
+// BackupPath returns the path of the copy of the previous version of a wallet file
+func BackupPath(keystore string) string {
+	return keystore + ".bak"
+}
+
+// SaveWallet saves a file only if it already has a filename. The file is replaced atomically and
+// the previous version is kept as a backup file. A file changed by another program since it was
+// loaded or saved isn't replaced: the error wraps common.ErrWalletChanged and the changes stay
+// unsaved until the wallet is reloaded, merged with the file or saved with OverwriteWallet.
 func (w *Wallet) SaveWallet() (err error) {
 	if len(w.keystore) == 0 {
 		return errors.New(errorNoFileName)
 	}
-	f, err := os.Create(w.keystore)
+	changed, err := w.ChangedOnDisk()
 	if err != nil {
 		return err
 	}
-	return json.NewEncoder(f).Encode(w)
+	if changed {
+		return fmt.Errorf("%s: %w", w.keystore, common.ErrWalletChanged)
+	}
+	return w.OverwriteWallet()
+}
+
+// OverwriteWallet saves the wallet like SaveWallet, replacing the file even when another program
+// changed it
+func (w *Wallet) OverwriteWallet() error {
+	if len(w.keystore) == 0 {
+		return errors.New(errorNoFileName)
+	}
+	w.WrittenBy = common.WriterName()
+	data, err := w.MarshalJSON() // not json.Marshal, which would compact the unknown fields
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(w.keystore); err == nil {
+		if err := copyFile(w.keystore, BackupPath(w.keystore), 0600); err != nil {
+			return err
+		}
+	}
+	data = append(data, '\n')
+	if err := writeFileAtomic(w.keystore, data, 0600); err != nil {
+		return err
+	}
+	w.recordDiskState(data)
+	w.dirty = false
+	return nil
+}
+
+// RestoreFromBackup replaces a wallet file with its backup copy and returns the restored wallet
+func RestoreFromBackup(keystore string) (*Wallet, error) {
+	w, err := LoadWallet(BackupPath(keystore))
+	if err != nil {
+		return nil, err
+	}
+	if err := copyFile(BackupPath(keystore), keystore, 0600); err != nil {
+		return nil, err
+	}
+	w.keystore = keystore
+	data, err := ioutil.ReadFile(keystore)
+	if err != nil {
+		return nil, err
+	}
+	w.recordDiskState(data)
+	return w, nil
 }
 
 // Unlock a previously unlocked wallet
 func (w *Wallet) Unlock(password string) (err error) {
+	return w.unlock([]byte(password))
+}
+
+// unlock decrypts the wallet data with a password, which the wallet keeps a copy of. The decrypted
+// JSON is overwritten once it is read.
+func (w *Wallet) unlock(password []byte) (err error) {
 	if w.unlocked {
 		return nil
 	}
-	w.password = password
+	w.password = append([]byte(nil), password...)
 	ciphertext, err := hex.DecodeString(w.Crypto.CipherText)
 	if err != nil {
 		return
@@ -186,6 +381,7 @@ func (w *Wallet) Unlock(password string) (err error) {
 	if err != nil {
 		return
 	}
+	defer common.Zero(plaintextBytes)
 	err = json.Unmarshal(plaintextBytes, &w.Crypto.confidential)
 	if err != nil {
 		return err
@@ -194,6 +390,16 @@ func (w *Wallet) Unlock(password string) (err error) {
 	return
 }
 
+// Lock overwrites the decrypted wallet data and the password, then drops them. The encrypted data
+// is kept and the wallet can be unlocked again.
+func (w *Wallet) Lock() {
+	w.Crypto.confidential.wipe()
+	common.Zero(w.password)
+	w.Crypto.confidential = secretStuff{}
+	w.password = nil
+	w.unlocked = false
+}
+
 func interfaceToBytes(i interface{}) ([]byte, error) {
 	var w bytes.Buffer
 	if _, err := xdr.Marshal(&w, &i); err != nil {
@@ -207,8 +413,14 @@ func (w *Wallet) SignedTransaction(t *types.Transaction) ([]byte, error) {
 	if !w.unlocked {
 		return []byte{}, errors.New(errorWalletNotUnlocked)
 	}
-	acc, _ := w.CurrentAccount()
-	key, _ := acc.PrivateKey()
+	acc, err := w.CurrentAccount()
+	if err != nil {
+		return []byte{}, err
+	}
+	key, err := acc.PrivateKey()
+	if err != nil {
+		return []byte{}, err
+	}
 
 	tx := struct {
 		AccountNonce uint64
@@ -229,6 +441,68 @@ func (w *Wallet) SignedTransaction(t *types.Transaction) ([]byte, error) {
 	return buf, nil
 }
 
+// CheckPassword verifies a password by decrypting the wallet data with it
+func (w *Wallet) CheckPassword(password string) error {
+	ciphertext, err := hex.DecodeString(w.Crypto.CipherText)
+	if err != nil {
+		return err
+	}
+	key := []byte(password)
+	defer common.Zero(key)
+	plaintext, err := twoWayAES(key, w.Meta.Meta.Salt, ciphertext)
+	if err != nil {
+		return err
+	}
+	defer common.Zero(plaintext)
+	var check secretStuff
+	if err := json.Unmarshal(plaintext, &check); err != nil {
+		return errors.New(errorWrongPassword)
+	}
+	check.wipe()
+	return nil
+}
+
+// ChangePassword re-encrypts the wallet with a new password and a fresh salt. The current password
+// is verified by decrypting the wallet data with it. The previous wallet file is kept as a .bak file
+// until the re-encrypted wallet has been written to disk, then removed as it is encrypted with the
+// old password.
+func (w *Wallet) ChangePassword(oldPassword, newPassword string) error {
+	if !w.unlocked {
+		return errors.New(errorWalletNotUnlocked)
+	}
+	if len(newPassword) == 0 {
+		return errors.New(errorEmptyPassword)
+	}
+	if err := w.CheckPassword(oldPassword); err != nil {
+		return err
+	}
+
+	salt, err := newSalt()
+	if err != nil {
+		return err
+	}
+
+	prevPassword, prevSalt, prevCipherText := w.password, w.Meta.Meta.Salt, w.Crypto.CipherText
+	w.password = []byte(newPassword)
+	w.Meta.Meta.Salt = salt
+	if err := w.reCrypt(); err != nil {
+		common.Zero(w.password)
+		w.password, w.Meta.Meta.Salt, w.Crypto.CipherText = prevPassword, prevSalt, prevCipherText
+		return err
+	}
+	common.Zero(prevPassword)
+	if len(w.keystore) > 0 {
+		// the backup is encrypted with the old password
+		return os.Remove(BackupPath(w.keystore))
+	}
+	return nil
+}
+
 func (w *Wallet) WalletPath() string {
 	return w.keystore
 }
+
+// HasUnsavedChanges returns true if changes to the wallet have not been written to the wallet file
+func (w *Wallet) HasUnsavedChanges() bool {
+	return w.dirty
+}
@@ -2,9 +2,12 @@ package smWallet
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/spacemeshos/ed25519"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/smrepl/common"
 )
 
 // GetMnemonic returns the mnemonic string associated with the wallet
@@ -12,7 +15,7 @@ func (w Wallet) GetMnemonic() (string, error) {
 	if !w.unlocked {
 		return "", errors.New(errorWalletNotUnlocked)
 	}
-	return w.Crypto.confidential.Mnemonic, nil
+	return string(w.Crypto.confidential.Mnemonic), nil
 }
 
 // GetNumberOfAccounts returns the number of accounts held in said wallet
@@ -59,6 +62,17 @@ func (w *Wallet) GetPrivateKey(accountNumber int) (ed25519.PrivateKey, error) {
 	return private, nil
 }
 
+// IsWatchOnly returns true if the account only holds an address and no keys
+func (w *Wallet) IsWatchOnly(accountNumber int) (bool, error) {
+	if !w.unlocked {
+		return false, errors.New(errorWalletNotUnlocked)
+	}
+	if accountNumber < 0 || accountNumber >= len(w.Crypto.confidential.Accounts) {
+		return false, errors.New(errorWalletDoesNotHaveThatAddress)
+	}
+	return w.Crypto.confidential.Accounts[accountNumber].IsWatchOnly(), nil
+}
+
 // GetAccountDisplayName retrieves an account name from a wallet (if unlocked and account exists)
 func (w *Wallet) GetAccountDisplayName(accountNumber int) (string, error) {
 	if !w.unlocked {
@@ -82,6 +96,161 @@ func (w *Wallet) SetCurrent(accountNumber int) error {
 	return nil
 }
 
+// ClearCurrent leaves the wallet without a current account
+func (w *Wallet) ClearCurrent() {
+	w.Crypto.confidential.accountNumber = -1
+}
+
+// DeleteAccount removes an account from the wallet. If it was the current account
+// then the wallet is left without a current account.
+func (w *Wallet) DeleteAccount(accountNumber int) error {
+	if !w.unlocked {
+		return errors.New(errorWalletNotUnlocked)
+	}
+	accounts := w.Crypto.confidential.Accounts
+	if accountNumber < 0 || accountNumber >= len(accounts) {
+		return errors.New(errorWalletDoesNotHaveThatAddress)
+	}
+	// the key is wiped and the last slot cleared so no copy of it is left in the backing array
+	accounts[accountNumber].SecretKey.wipe()
+	copy(accounts[accountNumber:], accounts[accountNumber+1:])
+	accounts[len(accounts)-1] = account{}
+	w.Crypto.confidential.Accounts = accounts[:len(accounts)-1]
+
+	current := w.Crypto.confidential.accountNumber
+	if current == accountNumber {
+		w.Crypto.confidential.accountNumber = -1
+	} else if current > accountNumber {
+		w.Crypto.confidential.accountNumber = current - 1
+	}
+	return w.reCrypt()
+}
+
+// validateDisplayName normalizes an account display name and verifies that it is not used by
+// any account other than the one at position skip
+func (w *Wallet) validateDisplayName(displayName string, skip int) (string, error) {
+	displayName, err := common.NormalizeAlias(displayName)
+	if err != nil {
+		return "", err
+	}
+	for pos, acc := range w.Crypto.confidential.Accounts {
+		if pos != skip && acc.DisplayName == displayName {
+			return "", common.ErrAliasTaken
+		}
+	}
+	return displayName, nil
+}
+
+// RenameAccount sets a new display name for an account. The name must not be blank or used by another account.
+func (w *Wallet) RenameAccount(accountNumber int, displayName string) error {
+	if !w.unlocked {
+		return errors.New(errorWalletNotUnlocked)
+	}
+	if accountNumber < 0 || accountNumber >= len(w.Crypto.confidential.Accounts) {
+		return errors.New(errorWalletDoesNotHaveThatAddress)
+	}
+	displayName, err := w.validateDisplayName(displayName, accountNumber)
+	if err != nil {
+		return err
+	}
+	w.Crypto.confidential.Accounts[accountNumber].DisplayName = displayName
+	return w.reCrypt()
+}
+
+// accountAt returns the account at position accountNumber
+func (w *Wallet) accountAt(accountNumber int) (*account, error) {
+	if !w.unlocked {
+		return nil, errors.New(errorWalletNotUnlocked)
+	}
+	if accountNumber < 0 || accountNumber >= len(w.Crypto.confidential.Accounts) {
+		return nil, errors.New(errorWalletDoesNotHaveThatAddress)
+	}
+	return &w.Crypto.confidential.Accounts[accountNumber], nil
+}
+
+// GetAccountNote returns the note attached to an account
+func (w *Wallet) GetAccountNote(accountNumber int) (string, error) {
+	acc, err := w.accountAt(accountNumber)
+	if err != nil {
+		return "", err
+	}
+	return acc.Note, nil
+}
+
+// GetAccountTags returns the tags attached to an account
+func (w *Wallet) GetAccountTags(accountNumber int) ([]string, error) {
+	acc, err := w.accountAt(accountNumber)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{}, acc.Tags...), nil
+}
+
+// SetAccountNote attaches a note to an account. An empty note removes it.
+func (w *Wallet) SetAccountNote(accountNumber int, note string) error {
+	acc, err := w.accountAt(accountNumber)
+	if err != nil {
+		return err
+	}
+	acc.Note = strings.TrimSpace(note)
+	return w.reCrypt()
+}
+
+// AddAccountTag adds a tag to an account. Adding a tag the account already has is a no-op.
+func (w *Wallet) AddAccountTag(accountNumber int, tag string) error {
+	acc, err := w.accountAt(accountNumber)
+	if err != nil {
+		return err
+	}
+	tag, err = common.NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+	if common.HasTag(acc.Tags, tag) {
+		return nil
+	}
+	acc.Tags = append(acc.Tags, tag)
+	return w.reCrypt()
+}
+
+// RemoveAccountTag removes a tag from an account
+func (w *Wallet) RemoveAccountTag(accountNumber int, tag string) error {
+	acc, err := w.accountAt(accountNumber)
+	if err != nil {
+		return err
+	}
+	tag, err = common.NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+	for i, t := range acc.Tags {
+		if t == tag {
+			acc.Tags = append(acc.Tags[:i], acc.Tags[i+1:]...)
+			return w.reCrypt()
+		}
+	}
+	return fmt.Errorf("account %s is not tagged %s", acc.DisplayName, tag)
+}
+
+// GetAccountGas returns the default gas price and gas limit of an account. 0 means no default.
+func (w *Wallet) GetAccountGas(accountNumber int) (uint64, uint64, error) {
+	acc, err := w.accountAt(accountNumber)
+	if err != nil {
+		return 0, 0, err
+	}
+	return acc.GasPrice, acc.GasLimit, nil
+}
+
+// SetAccountGas sets the default gas price and gas limit of an account. 0 removes a default.
+func (w *Wallet) SetAccountGas(accountNumber int, gasPrice, gasLimit uint64) error {
+	acc, err := w.accountAt(accountNumber)
+	if err != nil {
+		return err
+	}
+	acc.GasPrice, acc.GasLimit = gasPrice, gasLimit
+	return w.reCrypt()
+}
+
 func (w *Wallet) AddContact(nickname string, address types.Address) error {
 	if !w.unlocked {
 		return errors.New(errorWalletNotUnlocked)
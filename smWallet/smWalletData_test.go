@@ -0,0 +1,368 @@
+package smWallet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/smrepl/common"
+)
+
+const testPassword = "test password"
+
+// newTestWallet creates a wallet with the provided number of accounts saved in a temporary directory
+func newTestWallet(t *testing.T, accounts int) (*Wallet, func()) {
+	dir, err := ioutil.TempDir("", "smwallet")
+	chkTErr(t, err)
+	w, err := NewWallet("test", testPassword)
+	chkTErr(t, err)
+	for i := 1; i < accounts; i++ {
+		_, err = w.GenerateNewPair(fmt.Sprintf("account%d", i))
+		chkTErr(t, err)
+	}
+	chkTErr(t, w.SaveWalletAs(filepath.Join(dir, "wallet")))
+	return w, func() { _ = os.RemoveAll(dir) }
+}
+
+func TestRenameCurrentAccount(t *testing.T) {
+	w, cleanup := newTestWallet(t, 2)
+	defer cleanup()
+
+	chkTErr(t, w.SetCurrent(1))
+	addr, err := w.GetAddress(1)
+	chkTErr(t, err)
+
+	chkTErr(t, w.RenameAccount(1, "  renamed "))
+	current, err := w.CurrentAccount()
+	chkTErr(t, err)
+	if current.DisplayName != "renamed" {
+		t.Fatalf("expected current account to be renamed, got %s", current.DisplayName)
+	}
+	if current.Address() != addr {
+		t.Fatal("current account changed after rename")
+	}
+}
+
+func TestRenameAccountRejected(t *testing.T) {
+	w, cleanup := newTestWallet(t, 2)
+	defer cleanup()
+
+	if err := w.RenameAccount(1, "Default"); err != common.ErrAliasTaken {
+		t.Fatalf("expected duplicate name error, got %v", err)
+	}
+	if err := w.RenameAccount(1, "   "); err != common.ErrInvalidAlias {
+		t.Fatalf("expected blank name error, got %v", err)
+	}
+	if err := w.RenameAccount(5, "other"); err == nil {
+		t.Fatal("expected error renaming a missing account")
+	}
+	// renaming to the same name is allowed
+	chkTErr(t, w.RenameAccount(0, "Default"))
+}
+
+func TestRenameAccountPersisted(t *testing.T) {
+	w, cleanup := newTestWallet(t, 2)
+	defer cleanup()
+
+	chkTErr(t, w.RenameAccount(1, "renamed"))
+	chkTErr(t, w.SaveWallet())
+
+	loaded, err := LoadWallet(w.WalletPath())
+	chkTErr(t, err)
+	chkTErr(t, loaded.Unlock(testPassword))
+	name, err := loaded.GetAccountDisplayName(1)
+	chkTErr(t, err)
+	if name != "renamed" {
+		t.Fatalf("expected renamed account after reload, got %s", name)
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	w, cleanup := newTestWallet(t, 3)
+	defer cleanup()
+
+	chkTErr(t, w.SetCurrent(2))
+	accounts := w.Crypto.confidential.Accounts
+	deleted := accounts[0].SecretKey
+	chkTErr(t, w.DeleteAccount(0))
+	if len(deleted) == 0 || !bytes.Equal(deleted, make([]byte, len(deleted))) {
+		t.Fatal("expected the key of the deleted account to be wiped")
+	}
+	if last := accounts[len(accounts)-1]; last.SecretKey != nil || last.DisplayName != "" {
+		t.Fatal("expected the last slot of the accounts to be cleared")
+	}
+	current, err := w.CurrentAccount()
+	chkTErr(t, err)
+	if current.DisplayName != "account2" {
+		t.Fatalf("expected current account to follow deletion, got %s", current.DisplayName)
+	}
+
+	chkTErr(t, w.DeleteAccount(1))
+	if _, err := w.CurrentAccount(); err == nil {
+		t.Fatal("expected no current account after deleting it")
+	}
+	n, err := w.GetNumberOfAccounts()
+	chkTErr(t, err)
+	if n != 1 {
+		t.Fatalf("expected 1 account, got %d", n)
+	}
+}
+
+func TestWatchOnlyAccount(t *testing.T) {
+	w, cleanup := newTestWallet(t, 1)
+	defer cleanup()
+
+	addr := types.HexToAddress("0x7fa75881ca0050028b32f424f860e3a73d4bf168")
+	pos, err := w.AddWatchOnlyAccount("cold", addr)
+	chkTErr(t, err)
+
+	watchOnly, err := w.IsWatchOnly(pos)
+	chkTErr(t, err)
+	if !watchOnly {
+		t.Fatal("expected a watch-only account")
+	}
+	got, err := w.GetAddress(pos)
+	chkTErr(t, err)
+	if got != addr {
+		t.Fatalf("expected address %s, got %s", addr.Hex(), got.Hex())
+	}
+	if _, err := w.GetPrivateKey(pos); err == nil || err.Error() != errorWatchOnlyAccount {
+		t.Fatalf("expected watch-only error, got %v", err)
+	}
+	if _, err := w.AddWatchOnlyAccount("cold2", addr); err == nil || err.Error() != errorAddressExists {
+		t.Fatalf("expected duplicate address error, got %v", err)
+	}
+
+	loaded, err := LoadWallet(w.WalletPath())
+	chkTErr(t, err)
+	chkTErr(t, loaded.Unlock(testPassword))
+	chkTErr(t, loaded.verifyAccounts())
+	watchOnly, err = loaded.IsWatchOnly(pos)
+	chkTErr(t, err)
+	if !watchOnly {
+		t.Fatal("expected a watch-only account after reload")
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	w, cleanup := newTestWallet(t, 1)
+	defer cleanup()
+
+	if err := w.ChangePassword("not the password", "new password"); err == nil {
+		t.Fatal("expected error changing password with a wrong current password")
+	}
+	if err := w.CheckPassword("not the password"); err == nil {
+		t.Fatal("expected error checking a wrong password")
+	}
+	if err := w.ChangePassword(testPassword, ""); err == nil || err.Error() != errorEmptyPassword {
+		t.Fatalf("expected error changing to an empty password, got %v", err)
+	}
+	chkTErr(t, w.CheckPassword(testPassword))
+	chkTErr(t, w.ChangePassword(testPassword, "new password"))
+	if _, err := os.Stat(w.WalletPath() + ".bak"); !os.IsNotExist(err) {
+		t.Fatal("expected backup file to be removed after a successful password change")
+	}
+
+	loaded, err := LoadWallet(w.WalletPath())
+	chkTErr(t, err)
+	if err := loaded.Unlock(testPassword); err == nil {
+		t.Fatal("expected the old password to fail")
+	}
+
+	loaded, err = LoadWallet(w.WalletPath())
+	chkTErr(t, err)
+	chkTErr(t, loaded.Unlock("new password"))
+	mnemonic, err := loaded.GetMnemonic()
+	chkTErr(t, err)
+	expected, err := w.GetMnemonic()
+	chkTErr(t, err)
+	if mnemonic != expected {
+		t.Fatal("mnemonic changed after password change")
+	}
+}
+
+func TestGenerateNewPairRejectsDuplicateAlias(t *testing.T) {
+	w, cleanup := newTestWallet(t, 1)
+	defer cleanup()
+
+	if _, err := w.GenerateNewPair("Default"); err != common.ErrAliasTaken {
+		t.Fatalf("expected duplicate alias error, got %v", err)
+	}
+	if _, err := w.GenerateNewPair(" Default "); err != common.ErrAliasTaken {
+		t.Fatalf("expected duplicate alias error for untrimmed alias, got %v", err)
+	}
+	if _, err := w.GenerateNewPair("\t"); err != common.ErrInvalidAlias {
+		t.Fatalf("expected invalid alias error, got %v", err)
+	}
+	pos, err := w.GenerateNewPair("  second  ")
+	chkTErr(t, err)
+	name, err := w.GetAccountDisplayName(pos)
+	chkTErr(t, err)
+	if name != "second" {
+		t.Fatalf("expected trimmed alias, got %q", name)
+	}
+}
+
+func TestAccountNoteAndTagsPersisted(t *testing.T) {
+	w, cleanup := newTestWallet(t, 2)
+	defer cleanup()
+
+	chkTErr(t, w.SetAccountNote(1, " exchange deposit key "))
+	chkTErr(t, w.AddAccountTag(1, "Smesher"))
+	chkTErr(t, w.AddAccountTag(1, "smesher"))
+	chkTErr(t, w.AddAccountTag(1, "cold"))
+	chkTErr(t, w.RemoveAccountTag(1, "cold"))
+	if err := w.RemoveAccountTag(1, "cold"); err == nil {
+		t.Fatal("expected an error removing a missing tag")
+	}
+	if err := w.AddAccountTag(1, "two words"); err != common.ErrInvalidTag {
+		t.Fatalf("expected %v, got %v", common.ErrInvalidTag, err)
+	}
+	chkTErr(t, w.SaveWallet())
+
+	loaded, err := LoadWallet(w.WalletPath())
+	chkTErr(t, err)
+	chkTErr(t, loaded.Unlock(testPassword))
+	note, err := loaded.GetAccountNote(1)
+	chkTErr(t, err)
+	if note != "exchange deposit key" {
+		t.Fatalf("unexpected note after reload: %q", note)
+	}
+	tags, err := loaded.GetAccountTags(1)
+	chkTErr(t, err)
+	if len(tags) != 1 || tags[0] != "smesher" {
+		t.Fatalf("unexpected tags after reload: %v", tags)
+	}
+	tags, err = loaded.GetAccountTags(0)
+	chkTErr(t, err)
+	if len(tags) != 0 {
+		t.Fatalf("expected no tags on an untouched account, got %v", tags)
+	}
+}
+
+func TestAccountGasPersisted(t *testing.T) {
+	w, cleanup := newTestWallet(t, 2)
+	defer cleanup()
+
+	chkTErr(t, w.SetAccountGas(1, 5, 250))
+	chkTErr(t, w.SaveWallet())
+
+	loaded, err := LoadWallet(w.WalletPath())
+	chkTErr(t, err)
+	chkTErr(t, loaded.Unlock(testPassword))
+	price, limit, err := loaded.GetAccountGas(1)
+	chkTErr(t, err)
+	if price != 5 || limit != 250 {
+		t.Fatalf("unexpected gas defaults after reload: %d %d", price, limit)
+	}
+	price, limit, err = loaded.GetAccountGas(0)
+	chkTErr(t, err)
+	if price != 0 || limit != 0 {
+		t.Fatalf("expected no gas defaults on an untouched account, got %d %d", price, limit)
+	}
+}
+
+func TestNewWalletFromEntropyDeterministic(t *testing.T) {
+	entropy := make([]byte, 32)
+	for i := range entropy {
+		entropy[i] = byte(i)
+	}
+	w1, err := NewWalletFromEntropy("one", testPassword, entropy)
+	chkTErr(t, err)
+	w2, err := NewWalletFromEntropy("two", "another password", entropy)
+	chkTErr(t, err)
+	_, err = w1.GenerateNewPair("second")
+	chkTErr(t, err)
+	_, err = w2.GenerateNewPair("second")
+	chkTErr(t, err)
+	for i := 0; i < 2; i++ {
+		a1, err := w1.GetAddress(i)
+		chkTErr(t, err)
+		a2, err := w2.GetAddress(i)
+		chkTErr(t, err)
+		if a1 != a2 {
+			t.Fatalf("account %d: expected the same address from the same entropy, got %s and %s", i, a1.Hex(), a2.Hex())
+		}
+	}
+}
+
+func TestSaveRefusesExternalChange(t *testing.T) {
+	w, cleanup := newTestWallet(t, 1)
+	defer cleanup()
+
+	// a file touched without a change of its content isn't a change
+	later := time.Now().Add(time.Minute)
+	chkTErr(t, os.Chtimes(w.WalletPath(), later, later))
+	if changed, err := w.ChangedOnDisk(); err != nil || changed {
+		t.Fatalf("expected a touched file to be unchanged, got %v %v", changed, err)
+	}
+
+	// another instance adds an account to the file
+	other, err := LoadWallet(w.WalletPath())
+	chkTErr(t, err)
+	chkTErr(t, other.Unlock(testPassword))
+	_, err = other.GenerateNewPair("other")
+	chkTErr(t, err)
+
+	if _, err := w.GenerateNewPair("mine"); !errors.Is(err, common.ErrWalletChanged) {
+		t.Fatalf("expected %v, got %v", common.ErrWalletChanged, err)
+	}
+	if !w.HasUnsavedChanges() {
+		t.Fatal("expected the new account to be unsaved")
+	}
+
+	disk, err := w.DiskVersion()
+	chkTErr(t, err)
+	w.AcceptDiskVersion(disk)
+	res, err := w.Merge(disk, false)
+	chkTErr(t, err)
+	if len(res.Imported) != 1 || res.Imported[0] != "other" {
+		t.Fatalf("expected the account of the file to be merged, got %+v", res)
+	}
+	loaded, err := LoadWallet(w.WalletPath())
+	chkTErr(t, err)
+	chkTErr(t, loaded.Unlock(testPassword))
+	if n, _ := loaded.GetNumberOfAccounts(); n != 3 {
+		t.Fatalf("expected the accounts of both instances in the file, got %d", n)
+	}
+
+	// the other instance is now behind the file and can only replace it with OverwriteWallet
+	if err := other.RenameAccount(1, "renamed"); !errors.Is(err, common.ErrWalletChanged) {
+		t.Fatalf("expected %v, got %v", common.ErrWalletChanged, err)
+	}
+	chkTErr(t, other.OverwriteWallet())
+	if changed, err := other.ChangedOnDisk(); err != nil || changed {
+		t.Fatalf("expected the overwritten file to be the saved version, got %v %v", changed, err)
+	}
+}
+
+func TestLockWipesSecrets(t *testing.T) {
+	w, cleanup := newTestWallet(t, 2)
+	defer cleanup()
+	mnemonic := w.Crypto.confidential.Mnemonic
+	key := w.Crypto.confidential.Accounts[1].SecretKey
+	password := w.password
+
+	w.Lock()
+	for _, b := range [][]byte{mnemonic, key, password} {
+		for _, c := range b {
+			if c != 0 {
+				t.Fatal("expected the secrets to be overwritten when the wallet is locked")
+			}
+		}
+	}
+	if _, err := w.GetMnemonic(); err == nil {
+		t.Fatal("expected no mnemonic once the wallet is locked")
+	}
+
+	chkTErr(t, w.Unlock(testPassword))
+	if _, err := w.GetMnemonic(); err != nil {
+		t.Fatalf("expected the wallet to unlock again, got %v", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/spacemeshos/ed25519"
 	"github.com/spacemeshos/go-spacemesh/common/types"
+	"github.com/spacemeshos/smrepl/common"
 	"github.com/tyler-smith/go-bip39"
 )
 
@@ -20,7 +21,8 @@ func (w *Wallet) newAccount(displayName string) (*account, error) {
 	if !w.unlocked {
 		return nil, errors.New(errorWalletNotUnlocked)
 	}
-	seed := bip39.NewSeed(w.Crypto.confidential.Mnemonic, "")
+	seed := bip39.NewSeed(string(w.Crypto.confidential.Mnemonic), "")
+	defer common.Zero(seed)
 	i := uint64(0)
 	for {
 		pk := ed25519.NewDerivedKeyFromSeed(seed[:32], i, []byte(spaceSalt))
@@ -39,7 +41,7 @@ func (w *Wallet) newAccount(displayName string) (*account, error) {
 				Created:     nowTimeString(),
 				Path:        "",
 				PublicKey:   hx.EncodeToString(pub),
-				SecretKey:   hx.EncodeToString(pk),
+				SecretKey:   hexSecret(pk),
 			}
 			return &ac, nil
 		}
@@ -49,6 +51,13 @@ func (w *Wallet) newAccount(displayName string) (*account, error) {
 
 // GenerateNewPair - add a new pair based on mnemonic key phrase
 func (w *Wallet) GenerateNewPair(displayName string) (int, error) {
+	if !w.unlocked {
+		return 0, errors.New(errorWalletNotUnlocked)
+	}
+	displayName, err := w.validateDisplayName(displayName, -1)
+	if err != nil {
+		return 0, err
+	}
 	ac, err := w.newAccount(displayName)
 	if err != nil {
 		return 0, err
@@ -61,12 +70,40 @@ func (w *Wallet) GenerateNewPair(displayName string) (int, error) {
 	return len(w.Crypto.confidential.Accounts) - 1, nil
 }
 
+// AddWatchOnlyAccount adds an account that tracks an address without holding its private key
+func (w *Wallet) AddWatchOnlyAccount(displayName string, address types.Address) (int, error) {
+	if !w.unlocked {
+		return 0, errors.New(errorWalletNotUnlocked)
+	}
+	displayName, err := w.validateDisplayName(displayName, -1)
+	if err != nil {
+		return 0, err
+	}
+	for _, acc := range w.Crypto.confidential.Accounts {
+		if acc.Address() == address {
+			return 0, errors.New(errorAddressExists)
+		}
+	}
+	w.Crypto.confidential.Accounts = append(w.Crypto.confidential.Accounts, account{
+		DisplayName:  displayName,
+		Created:      nowTimeString(),
+		WatchAddress: address.Hex(),
+	})
+	if err := w.reCrypt(); err != nil {
+		return 0, err
+	}
+	return len(w.Crypto.confidential.Accounts) - 1, nil
+}
+
 func (w *Wallet) verifyAccounts() (err error) {
 	message := []byte{5, 4, 3, 2, 1}
 	for pos, acc := range w.Crypto.confidential.Accounts {
+		if acc.IsWatchOnly() {
+			continue
+		}
 		var secret ed25519.PrivateKey
 		var public ed25519.PublicKey
-		secret, err = hex.DecodeString(acc.SecretKey)
+		secret, err = acc.SecretKey.decodeHex()
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,138 @@
+package smWallet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spacemeshos/smrepl/common"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// SmappCipher is the wallet data encryption used by Smapp. It identifies the version of the
+// Smapp wallet file format as the file does not carry a version number.
+const SmappCipher = "AES-128-CTR"
+
+// maxDerivationIndex bounds the search for the derivation index of an imported account
+const maxDerivationIndex = 1000
+
+// smappAccount, smappData and smappWallet are the fields of Smapp wallet files. Smapp doesn't know
+// the fields added by smrepl, such as the file version and the notes, tags and gas defaults of
+// the accounts.
+type smappAccount struct {
+	DisplayName string `json:"displayName"`
+	Created     string `json:"created"`
+	Path        string `json:"path"`
+	PublicKey   string `json:"publicKey"`
+	SecretKey   secret `json:"secretKey"`
+}
+
+type smappData struct {
+	Mnemonic secret         `json:"mnemonic"`
+	Accounts []smappAccount `json:"accounts"`
+	Contacts []contact      `json:"contacts"`
+}
+
+type smappWallet struct {
+	Meta   walletMetadata `json:"meta"`
+	Crypto struct {
+		Cipher     string `json:"cipher"`
+		CipherText string `json:"cipherText"`
+	} `json:"crypto"`
+}
+
+// ImportSmapp loads a Smapp wallet file, unlocks it and checks that the keys of its accounts derive
+// from its mnemonic the same way Smapp derives them. The returned wallet is not bound to a file.
+func ImportSmapp(path, password string) (*Wallet, error) {
+	w, err := LoadWallet(path)
+	if err != nil {
+		return nil, err
+	}
+	if w.Crypto.Cipher != SmappCipher {
+		return nil, fmt.Errorf("unsupported Smapp wallet format: cipher %q, expected %q", w.Crypto.Cipher, SmappCipher)
+	}
+	if err := w.Unlock(password); err != nil {
+		return nil, errors.New(errorWrongPassword)
+	}
+	if err := w.verifyDerivation(); err != nil {
+		return nil, err
+	}
+	w.keystore = ""
+	return w, nil
+}
+
+// ExportSmapp writes a copy of the wallet to path in the Smapp wallet file format. The data is
+// encrypted with the wallet password and the fixed salt Smapp uses, as Smapp can't open a wallet
+// whose salt was changed by ChangePassword. Smapp has no watch-only accounts: they are left out
+// and their number is returned.
+func (w *Wallet) ExportSmapp(path string) (int, error) {
+	if !w.unlocked {
+		return 0, errors.New(errorWalletNotUnlocked)
+	}
+	if w.Crypto.Cipher != SmappCipher {
+		return 0, fmt.Errorf("unsupported wallet format: cipher %q", w.Crypto.Cipher)
+	}
+	data := smappData{
+		Mnemonic: w.Crypto.confidential.Mnemonic,
+		Accounts: []smappAccount{},
+		Contacts: w.Crypto.confidential.Contacts,
+	}
+	if data.Contacts == nil {
+		data.Contacts = []contact{}
+	}
+	watchOnly := 0
+	for _, acc := range w.Crypto.confidential.Accounts {
+		if acc.IsWatchOnly() {
+			watchOnly++
+			continue
+		}
+		data.Accounts = append(data.Accounts, smappAccount{
+			DisplayName: acc.DisplayName,
+			Created:     acc.Created,
+			Path:        acc.Path,
+			PublicKey:   acc.PublicKey,
+			SecretKey:   acc.SecretKey,
+		})
+	}
+	plaintext, err := json.Marshal(data)
+	if err != nil {
+		return 0, err
+	}
+	defer common.Zero(plaintext)
+	ciphertext, err := twoWayAES(w.password, spaceSalt, plaintext)
+	if err != nil {
+		return 0, err
+	}
+
+	var file smappWallet
+	file.Meta = w.Meta
+	file.Meta.Meta.Salt = spaceSalt
+	file.Crypto.Cipher = SmappCipher
+	file.Crypto.CipherText = hex.EncodeToString(ciphertext)
+	out, err := json.Marshal(file)
+	if err != nil {
+		return 0, err
+	}
+	return watchOnly, writeFileAtomic(path, append(out, '\n'), 0600)
+}
+
+// verifyDerivation checks that every account with keys derives from the wallet mnemonic
+func (w *Wallet) verifyDerivation() error {
+	if !w.unlocked {
+		return errors.New(errorWalletNotUnlocked)
+	}
+	if !bip39.IsMnemonicValid(string(w.Crypto.confidential.Mnemonic)) {
+		return errors.New("invalid mnemonic in wallet")
+	}
+	indexes, err := w.derivationIndexes()
+	if err != nil {
+		return err
+	}
+	for pos, acc := range w.Crypto.confidential.Accounts {
+		if _, ok := indexes[acc.Address()]; !ok && !acc.IsWatchOnly() {
+			return fmt.Errorf("account %d (%s) does not derive from the wallet mnemonic", pos, acc.DisplayName)
+		}
+	}
+	return nil
+}
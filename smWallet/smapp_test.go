@@ -0,0 +1,199 @@
+package smWallet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/spacemeshos/go-spacemesh/common/types"
+)
+
+// testMnemonic is the BIP39 test vector mnemonic
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// exportTestWallet exports a wallet to a Smapp file in a temporary directory
+func exportTestWallet(t *testing.T, w *Wallet) (string, func()) {
+	dir, err := ioutil.TempDir("", "smapp")
+	chkTErr(t, err)
+	path := filepath.Join(dir, "smapp.json")
+	_, err = w.ExportSmapp(path)
+	chkTErr(t, err)
+	return path, func() { _ = os.RemoveAll(dir) }
+}
+
+// jsonKeys returns the sorted field names of a JSON object
+func jsonKeys(t *testing.T, data []byte) string {
+	var fields map[string]json.RawMessage
+	chkTErr(t, json.Unmarshal(data, &fields))
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// smappFixture was not saved by Smapp: it was written by a script following the wallet file
+// encryption of Smapp 0.1: the wallet data is JSON encrypted with AES-CTR, counter 5, and the
+// key pbkdf2-sha512(password, "Spacemesh blockmesh", 1000000 rounds, 32 bytes). Its accounts are
+// the first two accounts of testMnemonic.
+const smappFixture = "smapp_v0.1.json"
+
+func TestSmappImportFixture(t *testing.T) {
+	w, err := ImportSmapp(filepath.Join("testdata", smappFixture), testPassword)
+	chkTErr(t, err)
+	mnemonic, err := w.GetMnemonic()
+	chkTErr(t, err)
+	if mnemonic != testMnemonic {
+		t.Fatalf("unexpected mnemonic %q", mnemonic)
+	}
+	expected := []struct{ name, address string }{
+		{"Main Account", "0x81cfb95383819c399adbea7f6ad65bca955da017"},
+		{"Account 1", "0x0e90b1d2ca8ca87194ae416691b1947a5a049619"},
+	}
+	n, err := w.GetNumberOfAccounts()
+	chkTErr(t, err)
+	if n != len(expected) {
+		t.Fatalf("expected %d accounts, got %d", len(expected), n)
+	}
+	for i, e := range expected {
+		name, err := w.GetAccountDisplayName(i)
+		chkTErr(t, err)
+		addr, err := w.GetAddress(i)
+		chkTErr(t, err)
+		if name != e.name || addr != types.HexToAddress(e.address) {
+			t.Fatalf("account %d: expected %s %s, got %s %s", i, e.name, e.address, name, addr.Hex())
+		}
+	}
+	if contacts := w.Crypto.confidential.Contacts; len(contacts) != 1 || contacts[0].Nickname != "faucet" {
+		t.Fatalf("unexpected contacts %v", contacts)
+	}
+}
+
+func TestSmappExportFields(t *testing.T) {
+	w, err := NewWalletWithMnemonic("test", testPassword, testMnemonic)
+	chkTErr(t, err)
+	pos, err := w.GenerateNewPair("account1")
+	chkTErr(t, err)
+	chkTErr(t, w.SetAccountNote(pos, "savings"))
+	_, err = w.AddWatchOnlyAccount("watched", types.HexToAddress("0x7fa75881ca0050028b32f424f860e3a73d4bf168"))
+	chkTErr(t, err)
+	// a new password comes with a random salt, which Smapp can't use
+	chkTErr(t, w.ChangePassword(testPassword, "new password"))
+
+	dir, err := ioutil.TempDir("", "smapp")
+	chkTErr(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "smapp.json")
+	watchOnly, err := w.ExportSmapp(path)
+	chkTErr(t, err)
+	if watchOnly != 1 {
+		t.Fatalf("expected 1 watch-only account left out, got %d", watchOnly)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	chkTErr(t, err)
+	if keys := jsonKeys(t, data); keys != "crypto,meta" {
+		t.Fatalf("unexpected wallet fields %s", keys)
+	}
+	var file smappWallet
+	chkTErr(t, json.Unmarshal(data, &file))
+	if file.Meta.Meta.Salt != spaceSalt {
+		t.Fatalf("expected the Smapp salt, got %q", file.Meta.Meta.Salt)
+	}
+	ciphertext, err := hex.DecodeString(file.Crypto.CipherText)
+	chkTErr(t, err)
+	plaintext, err := twoWayAES([]byte("new password"), spaceSalt, ciphertext)
+	chkTErr(t, err)
+	if keys := jsonKeys(t, plaintext); keys != "accounts,contacts,mnemonic" {
+		t.Fatalf("unexpected wallet data fields %s", keys)
+	}
+	var decrypted struct {
+		Accounts []json.RawMessage `json:"accounts"`
+	}
+	chkTErr(t, json.Unmarshal(plaintext, &decrypted))
+	if len(decrypted.Accounts) != 2 {
+		t.Fatalf("expected the 2 accounts with keys, got %d", len(decrypted.Accounts))
+	}
+	for _, acc := range decrypted.Accounts {
+		if keys := jsonKeys(t, acc); keys != "created,displayName,path,publicKey,secretKey" {
+			t.Fatalf("unexpected account fields %s", keys)
+		}
+	}
+}
+
+func TestSmappRoundTrip(t *testing.T) {
+	w, err := NewWalletWithMnemonic("test", testPassword, testMnemonic)
+	chkTErr(t, err)
+	_, err = w.GenerateNewPair("account1")
+	chkTErr(t, err)
+
+	path, cleanup := exportTestWallet(t, w)
+	defer cleanup()
+
+	imported, err := ImportSmapp(path, testPassword)
+	chkTErr(t, err)
+	mnemonic, err := imported.GetMnemonic()
+	chkTErr(t, err)
+	if mnemonic != testMnemonic {
+		t.Fatal("mnemonic changed on import")
+	}
+	n, err := imported.GetNumberOfAccounts()
+	chkTErr(t, err)
+	if n != 2 {
+		t.Fatalf("expected 2 accounts, got %d", n)
+	}
+	for i := 0; i < n; i++ {
+		expected, err := w.GetAddress(i)
+		chkTErr(t, err)
+		addr, err := imported.GetAddress(i)
+		chkTErr(t, err)
+		if addr != expected {
+			t.Fatalf("account %d: expected address %s, got %s", i, expected.Hex(), addr.Hex())
+		}
+	}
+	if imported.WalletPath() != "" {
+		t.Fatal("imported wallet should not be bound to the Smapp file")
+	}
+}
+
+func TestSmappImportWrongPassword(t *testing.T) {
+	w, err := NewWalletWithMnemonic("test", testPassword, testMnemonic)
+	chkTErr(t, err)
+	path, cleanup := exportTestWallet(t, w)
+	defer cleanup()
+
+	if _, err := ImportSmapp(path, "wrong"); err == nil || err.Error() != errorWrongPassword {
+		t.Fatalf("expected %q, got %v", errorWrongPassword, err)
+	}
+}
+
+func TestSmappImportUnsupportedVersion(t *testing.T) {
+	_, err := ImportSmapp(filepath.Join("testdata", "smapp_unsupported.json"), testPassword)
+	if err == nil {
+		t.Fatal("expected unsupported format error")
+	}
+	if !strings.Contains(err.Error(), "AES-256-GCM") {
+		t.Fatalf("expected error to name the format found: %v", err)
+	}
+}
+
+func TestSmappImportForeignAccount(t *testing.T) {
+	w, err := NewWalletWithMnemonic("test", testPassword, testMnemonic)
+	chkTErr(t, err)
+	other, err := NewWallet("other", testPassword)
+	chkTErr(t, err)
+	w.Crypto.confidential.Accounts = append(w.Crypto.confidential.Accounts, other.Crypto.confidential.Accounts[0])
+	chkTErr(t, w.reCrypt())
+
+	path, cleanup := exportTestWallet(t, w)
+	defer cleanup()
+	if _, err := ImportSmapp(path, testPassword); err == nil {
+		t.Fatal("expected an error for an account that does not derive from the mnemonic")
+	}
+}
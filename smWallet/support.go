@@ -3,12 +3,18 @@ package smWallet
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/rand"
 	"crypto/sha512"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/spacemeshos/go-spacemesh/common/util"
+	"github.com/spacemeshos/smrepl/common"
 	"golang.org/x/crypto/pbkdf2"
 )
 
@@ -20,8 +26,12 @@ func crypt(block cipher.Block, ciphertext []byte, iv []byte) []byte {
 }
 
 func (w *Wallet) twoWayAES(in []byte) ([]byte, error) {
-	keyBytes := []byte(w.password)
-	key := pbkdf2.Key(keyBytes, []byte(w.Meta.Meta.Salt), 1000000, 32, sha512.New)
+	return twoWayAES(w.password, w.Meta.Meta.Salt, in)
+}
+
+func twoWayAES(password []byte, salt string, in []byte) ([]byte, error) {
+	key := pbkdf2.Key(password, []byte(salt), 1000000, 32, sha512.New)
+	defer common.Zero(key)
 	c, err := aes.NewCipher(key)
 	if err != nil {
 		return []byte{}, err
@@ -33,23 +43,77 @@ func (w *Wallet) twoWayAES(in []byte) ([]byte, error) {
 
 func (w *Wallet) reCrypt() error {
 	if len(w.password) == 0 {
-		return errors.New("ErrorWalletDoesNotHavePassword")
+		return errors.New(errorEmptyPassword)
 	}
 	privatebuf, err := json.Marshal(w.Crypto.confidential)
 	if err != nil {
 		return err
 	}
+	defer common.Zero(privatebuf)
 	ciphertext, err := w.twoWayAES(privatebuf)
 	if err != nil {
 		return err
 	}
 	w.Crypto.CipherText = util.Bytes2Hex(ciphertext)
+	w.dirty = true
 	if len(w.keystore) > 0 {
 		return w.SaveWallet()
 	}
 	return nil
 }
 
+// TimeFormat is the layout of the creation times stored in wallet files
+const TimeFormat = "2006-01-02T15-04-05.000Z"
+
 func nowTimeString() string {
-	return time.Now().UTC().Format("2006-01-02T15-04-05.000") + "Z"
+	return time.Now().UTC().Format(TimeFormat)
+}
+
+// newSalt returns a random salt for deriving the wallet encryption key
+func newSalt() (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(salt), nil
+}
+
+// syncFile flushes a file to disk. Tests replace it to simulate write failures.
+var syncFile = func(f *os.File) error {
+	return f.Sync()
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory as path, syncs it to
+// disk and renames it over path so a failure never leaves a partially written file behind
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := f.Name()
+	if _, err = f.Write(data); err == nil {
+		err = syncFile(f)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpName, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpName, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+	}
+	return err
+}
+
+// copyFile copies the contents of src to dst
+func copyFile(src, dst string, perm os.FileMode) error {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(dst, data, perm)
 }
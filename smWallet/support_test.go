@@ -0,0 +1,123 @@
+package smWallet
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteFileAtomicFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smwallet")
+	chkTErr(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "wallet.json")
+	chkTErr(t, ioutil.WriteFile(path, []byte("original"), 0600))
+
+	defer func(f func(*os.File) error) { syncFile = f }(syncFile)
+	syncFile = func(*os.File) error { return errors.New("disk full") }
+
+	if err := writeFileAtomic(path, []byte("new content"), 0600); err == nil {
+		t.Fatal("expected write failure")
+	}
+	data, err := ioutil.ReadFile(path)
+	chkTErr(t, err)
+	if string(data) != "original" {
+		t.Fatalf("original file was modified: %s", data)
+	}
+	files, err := ioutil.ReadDir(dir)
+	chkTErr(t, err)
+	if len(files) != 1 {
+		t.Fatalf("expected temp file to be removed, found %d files", len(files))
+	}
+}
+
+func TestSaveWalletKeepsBackup(t *testing.T) {
+	w, cleanup := newTestWallet(t, 1)
+	defer cleanup()
+
+	before, err := ioutil.ReadFile(w.WalletPath())
+	chkTErr(t, err)
+	_, err = w.GenerateNewPair("second")
+	chkTErr(t, err)
+
+	backup, err := ioutil.ReadFile(BackupPath(w.WalletPath()))
+	chkTErr(t, err)
+	if string(backup) != string(before) {
+		t.Fatal("expected backup to hold the previous version of the wallet file")
+	}
+}
+
+func TestSaveWalletFailureKeepsWallet(t *testing.T) {
+	w, cleanup := newTestWallet(t, 1)
+	defer cleanup()
+
+	before, err := ioutil.ReadFile(w.WalletPath())
+	chkTErr(t, err)
+
+	defer func(f func(*os.File) error) { syncFile = f }(syncFile)
+	syncFile = func(*os.File) error { return errors.New("disk full") }
+
+	if _, err := w.GenerateNewPair("second"); err == nil {
+		t.Fatal("expected save failure")
+	}
+	after, err := ioutil.ReadFile(w.WalletPath())
+	chkTErr(t, err)
+	if string(after) != string(before) {
+		t.Fatal("wallet file was modified by a failed save")
+	}
+}
+
+func TestRestoreFromBackup(t *testing.T) {
+	w, cleanup := newTestWallet(t, 1)
+	defer cleanup()
+
+	_, err := w.GenerateNewPair("second")
+	chkTErr(t, err)
+	chkTErr(t, ioutil.WriteFile(w.WalletPath(), []byte("{corrupt"), 0600))
+
+	if _, err := LoadWallet(w.WalletPath()); err == nil {
+		t.Fatal("expected corrupt wallet to fail loading")
+	}
+	restored, err := RestoreFromBackup(w.WalletPath())
+	chkTErr(t, err)
+	chkTErr(t, restored.Unlock(testPassword))
+	if restored.WalletPath() != w.WalletPath() {
+		t.Fatal("restored wallet should be saved to the original path")
+	}
+	if _, err := LoadWallet(w.WalletPath()); err != nil {
+		t.Fatal("expected wallet file to be restored", err)
+	}
+}
+
+func TestSaveWalletFileModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on Windows")
+	}
+	w, cleanup := newTestWallet(t, 1)
+	defer cleanup()
+
+	// a wallet file made world readable by another tool gets private again on save
+	chkTErr(t, os.Chmod(w.WalletPath(), 0644))
+	_, err := w.GenerateNewPair("second")
+	chkTErr(t, err)
+	for _, path := range []string{w.WalletPath(), BackupPath(w.WalletPath())} {
+		info, err := os.Stat(path)
+		chkTErr(t, err)
+		if info.Mode().Perm() != 0600 {
+			t.Fatalf("%s: expected mode 0600, got %v", path, info.Mode().Perm())
+		}
+	}
+
+	exported := filepath.Join(filepath.Dir(w.WalletPath()), "smapp.json")
+	_, err = w.ExportSmapp(exported)
+	chkTErr(t, err)
+	info, err := os.Stat(exported)
+	chkTErr(t, err)
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected exported wallet mode 0600, got %v", info.Mode().Perm())
+	}
+}